@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -8,15 +9,36 @@ import (
 
 	"myproxy.com/p/internal/database"
 	"myproxy.com/p/internal/ui"
+	"myproxy.com/p/internal/utils"
 )
 
 func main() {
 	if err := initDatabase(); err != nil {
+		// 数据库由新版本程序创建：提示用户并提供备份，不做迁移
+		var tooNew *database.SchemaTooNewError
+		if errors.As(err, &tooNew) {
+			log.Printf("初始化数据库失败: %v", err)
+			ui.RunSchemaTooNew(tooNew.Error(), tooNew.Path, func() (string, error) {
+				return database.BackupDBFile(tooNew.Path)
+			})
+			os.Exit(1)
+		}
 		log.Fatalf("初始化数据库失败: %v", err)
 	}
 	defer database.CloseDB()
 
+	// 命令行子命令：不启动界面
+	if len(os.Args) > 1 && os.Args[1] == smokeTestCommand {
+		code := runSmokeTest(os.Args[2:])
+		database.CloseDB()
+		os.Exit(code)
+	}
+
 	appState := ui.NewAppState()
+	// 兼容旧版启动参数：go run ./cmd/gui/main.go /path/to/config.json
+	if len(os.Args) > 1 {
+		appState.LegacyConfigPath = os.Args[1]
+	}
 	if err := appState.Startup(); err != nil {
 		log.Fatalf("应用启动失败: %v", err)
 	}
@@ -29,7 +51,7 @@ func initDatabase() error {
 		return fmt.Errorf("获取工作目录失败: %w", err)
 	}
 
-	dbPath := filepath.Join(workDir, "data", "myproxy.db")
+	dbPath := filepath.Join(workDir, utils.DataDir, "myproxy.db")
 	if err := database.InitDB(dbPath); err != nil {
 		return fmt.Errorf("初始化数据库失败: %w", err)
 	}
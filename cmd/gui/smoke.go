@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"myproxy.com/p/internal/database"
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/service"
+)
+
+// smokeTestCommand 命令行子命令名称：myproxy smoke-test [节点 ID 或名称]
+const smokeTestCommand = "smoke-test"
+
+// runSmokeTest 命令行冒烟测试：不启动界面，对指定节点（默认当前选中节点）执行冒烟测试并输出报告。
+// 返回：进程退出码，通过为 0，失败为 1，参数错误为 2
+func runSmokeTest(args []string) int {
+	nodes, err := database.GetAllServers()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取节点失败: %v\n", err)
+		return 2
+	}
+
+	var target *model.Node
+	for i := range nodes {
+		n := &nodes[i]
+		if (len(args) == 0 && n.Selected) || (len(args) > 0 && (n.ID == args[0] || n.Name == args[0])) {
+			target = n
+			break
+		}
+	}
+	if target == nil {
+		if len(args) == 0 {
+			fmt.Fprintln(os.Stderr, "未选中节点，请指定节点 ID 或名称: myproxy smoke-test <节点>")
+		} else {
+			fmt.Fprintf(os.Stderr, "找不到节点: %s\n", args[0])
+		}
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf("冒烟测试: %s\n", target.Name)
+	report := service.NewXrayControlService(nil, nil, nil, nil).SmokeTest(ctx, *target, nil, func(step service.SmokeStep) {
+		status := "通过"
+		if step.Err != nil {
+			status = "失败"
+		}
+		fmt.Printf("  %s ... %s (%d ms)\n", step.Name, status, step.Duration.Milliseconds())
+	})
+	fmt.Println()
+	fmt.Println(report.String())
+	if !report.Passed() {
+		return 1
+	}
+	return 0
+}
@@ -17,6 +17,7 @@ var DB *sql.DB
 
 // InitDB 初始化 SQLite 数据库，创建必要的表结构。
 // 如果数据库文件不存在，会自动创建。如果表已存在，不会重复创建。
+// 数据库由更新版本的程序创建时返回 *SchemaTooNewError，且不做任何迁移。
 // 参数：
 //   - dbPath: 数据库文件路径
 //
@@ -39,12 +40,19 @@ func InitDB(dbPath string) error {
 		return fmt.Errorf("数据库连接测试失败: %w", err)
 	}
 
+	// 检查结构版本：新版本创建的数据库不做迁移，避免旧程序误改
+	if err := checkSchemaVersion(dbPath); err != nil {
+		DB.Close()
+		DB = nil
+		return err
+	}
+
 	// 创建表
 	if err := createTables(); err != nil {
 		return fmt.Errorf("创建表失败: %w", err)
 	}
 
-	return nil
+	return updateSchemaVersion()
 }
 
 // createTables 创建数据库表
@@ -55,6 +63,7 @@ func createTables() error {
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		url TEXT NOT NULL UNIQUE,
 		label TEXT NOT NULL DEFAULT '',
+		notes TEXT NOT NULL DEFAULT '',
 		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 	);`
@@ -89,7 +98,30 @@ func createTables() error {
 		ssr_obfs_param TEXT DEFAULT '',
 		ssr_protocol TEXT DEFAULT '',
 		ssr_protocol_param TEXT DEFAULT '',
+		vless_uuid TEXT DEFAULT '',
+		vless_encryption TEXT DEFAULT '',
+		vless_flow TEXT DEFAULT '',
+		vless_network TEXT DEFAULT '',
+		vless_header_type TEXT DEFAULT '',
+		vless_host TEXT DEFAULT '',
+		vless_path TEXT DEFAULT '',
+		vless_mode TEXT DEFAULT '',
+		vless_security TEXT DEFAULT '',
+		vless_sni TEXT DEFAULT '',
+		vless_alpn TEXT DEFAULT '',
+		vless_fingerprint TEXT DEFAULT '',
+		vless_allow_insecure INTEGER DEFAULT 0,
+		vless_public_key TEXT DEFAULT '',
+		vless_short_id TEXT DEFAULT '',
+		vless_spider_x TEXT DEFAULT '',
 		raw_config TEXT DEFAULT '',
+		last_connected_at INTEGER NOT NULL DEFAULT 0,
+		connected_seconds INTEGER NOT NULL DEFAULT 0,
+		notes TEXT NOT NULL DEFAULT '',
+		user_modified INTEGER NOT NULL DEFAULT 0,
+		override_sni TEXT NOT NULL DEFAULT '',
+		override_host TEXT NOT NULL DEFAULT '',
+		override_path TEXT NOT NULL DEFAULT '',
 		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		FOREIGN KEY (subscription_id) REFERENCES subscriptions(id) ON DELETE SET NULL
@@ -131,6 +163,15 @@ func createTables() error {
 		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 	);`
 
+	// 创建功能使用统计表（仅本地保存，不上传）
+	createUsageStatsTable := `
+	CREATE TABLE IF NOT EXISTS usage_stats (
+		feature TEXT PRIMARY KEY,
+		count INTEGER NOT NULL DEFAULT 0,
+		first_used DATETIME NOT NULL,
+		last_used DATETIME NOT NULL
+	);`
+
 	// 创建索引
 	createIndexes := `
 	CREATE INDEX IF NOT EXISTS idx_servers_subscription_id ON servers(subscription_id);
@@ -162,6 +203,10 @@ func createTables() error {
 		return fmt.Errorf("创建访问记录表失败: %w", err)
 	}
 
+	if _, err := DB.Exec(createUsageStatsTable); err != nil {
+		return fmt.Errorf("创建使用统计表失败: %w", err)
+	}
+
 	// 先迁移 access_records（旧表无 address 列），再创建依赖 address 的索引
 	if err := migrateAccessRecordsTable(); err != nil {
 		return fmt.Errorf("迁移 access_records 表失败: %w", err)
@@ -176,6 +221,18 @@ func createTables() error {
 		return fmt.Errorf("迁移数据库表失败: %w", err)
 	}
 
+	// 节点查询（QueryServers）使用的索引依赖迁移后才有的字段，需在迁移之后创建
+	createServerQueryIndexes := `
+	CREATE INDEX IF NOT EXISTS idx_servers_protocol ON servers(node_protocol_type);
+	CREATE INDEX IF NOT EXISTS idx_servers_name ON servers(name COLLATE NOCASE);
+	CREATE INDEX IF NOT EXISTS idx_servers_delay ON servers(delay);
+	CREATE INDEX IF NOT EXISTS idx_servers_created_at ON servers(created_at);
+	CREATE INDEX IF NOT EXISTS idx_servers_last_connected_at ON servers(last_connected_at);
+	`
+	if _, err := DB.Exec(createServerQueryIndexes); err != nil {
+		return fmt.Errorf("创建节点查询索引失败: %w", err)
+	}
+
 	return nil
 }
 
@@ -230,7 +287,30 @@ func migrateTables() error {
 		{"ssr_obfs_param", "TEXT DEFAULT ''"},
 		{"ssr_protocol", "TEXT DEFAULT ''"},
 		{"ssr_protocol_param", "TEXT DEFAULT ''"},
+		{"vless_uuid", "TEXT DEFAULT ''"},
+		{"vless_encryption", "TEXT DEFAULT ''"},
+		{"vless_flow", "TEXT DEFAULT ''"},
+		{"vless_network", "TEXT DEFAULT ''"},
+		{"vless_header_type", "TEXT DEFAULT ''"},
+		{"vless_host", "TEXT DEFAULT ''"},
+		{"vless_path", "TEXT DEFAULT ''"},
+		{"vless_mode", "TEXT DEFAULT ''"},
+		{"vless_security", "TEXT DEFAULT ''"},
+		{"vless_sni", "TEXT DEFAULT ''"},
+		{"vless_alpn", "TEXT DEFAULT ''"},
+		{"vless_fingerprint", "TEXT DEFAULT ''"},
+		{"vless_allow_insecure", "INTEGER DEFAULT 0"},
+		{"vless_public_key", "TEXT DEFAULT ''"},
+		{"vless_short_id", "TEXT DEFAULT ''"},
+		{"vless_spider_x", "TEXT DEFAULT ''"},
 		{"raw_config", "TEXT DEFAULT ''"},
+		{"last_connected_at", "INTEGER NOT NULL DEFAULT 0"},
+		{"connected_seconds", "INTEGER NOT NULL DEFAULT 0"},
+		{"notes", "TEXT NOT NULL DEFAULT ''"},
+		{"user_modified", "INTEGER NOT NULL DEFAULT 0"},
+		{"override_sni", "TEXT NOT NULL DEFAULT ''"},
+		{"override_host", "TEXT NOT NULL DEFAULT ''"},
+		{"override_path", "TEXT NOT NULL DEFAULT ''"},
 	}
 
 	// 获取表结构信息
@@ -275,6 +355,37 @@ func migrateTables() error {
 		}
 	}
 
+	// subscriptions 表新增字段
+	return addColumnIfMissing("subscriptions", "notes", "TEXT NOT NULL DEFAULT ''")
+}
+
+// addColumnIfMissing 字段不存在时为表添加字段（用于已有数据库升级）。
+func addColumnIfMissing(table, column, colType string) error {
+	rows, err := DB.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return nil // 表可能不存在
+	}
+	exists := false
+	for rows.Next() {
+		var cid int
+		var name, typ string
+		var notnull int
+		var dfltValue sql.NullString
+		var pk int
+		if err := rows.Scan(&cid, &name, &typ, &notnull, &dfltValue, &pk); err != nil {
+			continue
+		}
+		if name == column {
+			exists = true
+		}
+	}
+	rows.Close()
+	if exists {
+		return nil
+	}
+	if _, err := DB.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, colType)); err != nil {
+		return fmt.Errorf("为 %s 表添加 %s 字段失败: %w", table, column, err)
+	}
 	return nil
 }
 
@@ -358,8 +469,8 @@ func AddOrUpdateSubscription(url, label string) (*Subscription, error) {
 
 	// 先尝试查询是否存在
 	var sub Subscription
-	err := DB.QueryRow("SELECT id, url, label, created_at, updated_at FROM subscriptions WHERE url = ?", url).
-		Scan(&sub.ID, &sub.URL, &sub.Label, &sub.CreatedAt, &sub.UpdatedAt)
+	err := DB.QueryRow("SELECT id, url, label, notes, created_at, updated_at FROM subscriptions WHERE url = ?", url).
+		Scan(&sub.ID, &sub.URL, &sub.Label, &sub.Notes, &sub.CreatedAt, &sub.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		// 不存在，插入新记录
@@ -407,9 +518,9 @@ func AddOrUpdateSubscription(url, label string) (*Subscription, error) {
 func GetSubscriptionByURL(url string) (*Subscription, error) {
 	var sub Subscription
 	err := DB.QueryRow(
-		"SELECT id, url, label, created_at, updated_at FROM subscriptions WHERE url = ?",
+		"SELECT id, url, label, notes, created_at, updated_at FROM subscriptions WHERE url = ?",
 		url,
-	).Scan(&sub.ID, &sub.URL, &sub.Label, &sub.CreatedAt, &sub.UpdatedAt)
+	).Scan(&sub.ID, &sub.URL, &sub.Label, &sub.Notes, &sub.CreatedAt, &sub.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -424,7 +535,7 @@ func GetSubscriptionByURL(url string) (*Subscription, error) {
 // GetAllSubscriptions 获取所有订阅列表。
 // 返回：订阅列表和错误（如果有）
 func GetAllSubscriptions() ([]*Subscription, error) {
-	rows, err := DB.Query("SELECT id, url, label, created_at, updated_at FROM subscriptions ORDER BY created_at DESC")
+	rows, err := DB.Query("SELECT id, url, label, notes, created_at, updated_at FROM subscriptions ORDER BY created_at DESC")
 	if err != nil {
 		return nil, fmt.Errorf("查询订阅列表失败: %w", err)
 	}
@@ -433,7 +544,7 @@ func GetAllSubscriptions() ([]*Subscription, error) {
 	var subscriptions []*Subscription
 	for rows.Next() {
 		var sub Subscription
-		if err := rows.Scan(&sub.ID, &sub.URL, &sub.Label, &sub.CreatedAt, &sub.UpdatedAt); err != nil {
+		if err := rows.Scan(&sub.ID, &sub.URL, &sub.Label, &sub.Notes, &sub.CreatedAt, &sub.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("扫描订阅数据失败: %w", err)
 		}
 		subscriptions = append(subscriptions, &sub)
@@ -473,9 +584,9 @@ func DeleteSubscription(subscriptionID int64) error {
 func GetSubscriptionByID(id int64) (*Subscription, error) {
 	var sub Subscription
 	err := DB.QueryRow(
-		"SELECT id, url, label, created_at, updated_at FROM subscriptions WHERE id = ?",
+		"SELECT id, url, label, notes, created_at, updated_at FROM subscriptions WHERE id = ?",
 		id,
-	).Scan(&sub.ID, &sub.URL, &sub.Label, &sub.CreatedAt, &sub.UpdatedAt)
+	).Scan(&sub.ID, &sub.URL, &sub.Label, &sub.Notes, &sub.CreatedAt, &sub.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -518,6 +629,19 @@ func UpdateSubscriptionByID(id int64, url, label string) error {
 	return nil
 }
 
+// UpdateSubscriptionNotes 更新订阅备注。
+// 参数：
+//   - id: 订阅 ID
+//   - notes: 备注内容
+//
+// 返回：错误（如果有）
+func UpdateSubscriptionNotes(id int64, notes string) error {
+	if _, err := DB.Exec("UPDATE subscriptions SET notes = ? WHERE id = ?", notes, id); err != nil {
+		return fmt.Errorf("更新订阅备注失败: %w", err)
+	}
+	return nil
+}
+
 // GetServerCountBySubscriptionID 获取指定订阅的服务器数量。
 // 参数：
 //   - subscriptionID: 订阅 ID
@@ -541,22 +665,32 @@ func GetServerCountBySubscriptionID(subscriptionID int64) (int, error) {
 //
 // 返回：错误（如果有）
 func AddOrUpdateServer(server Node, subscriptionID *int64) error {
+	return addOrUpdateServer(DB, server, subscriptionID)
+}
+
+// addOrUpdateServer AddOrUpdateServer 的实现，q 为数据库连接或事务。
+func addOrUpdateServer(q execer, server Node, subscriptionID *int64) error {
 	now := time.Now()
 
 	// 检查服务器是否存在
 	var existingID string
 	var existingSubscriptionID sql.NullInt64
-	err := DB.QueryRow("SELECT id, subscription_id FROM servers WHERE id = ?", server.ID).
+	err := q.QueryRow("SELECT id, subscription_id FROM servers WHERE id = ?", server.ID).
 		Scan(&existingID, &existingSubscriptionID)
 
 	if err == sql.ErrNoRows {
 		// 不存在，插入新记录
-		_, err = DB.Exec(
+		_, err = q.Exec(
 			`INSERT INTO servers (id, subscription_id, name, addr, port, username, password, delay, selected, enabled,
 				node_protocol_type, vmess_version, vmess_uuid, vmess_alter_id, vmess_security, vmess_network,
 				vmess_type, vmess_host, vmess_path, vmess_tls, ss_method, ss_plugin, ss_plugin_opts,
-				ssr_obfs, ssr_obfs_param, ssr_protocol, ssr_protocol_param, raw_config, created_at, updated_at)
-			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				ssr_obfs, ssr_obfs_param, ssr_protocol, ssr_protocol_param,
+				vless_uuid, vless_encryption, vless_flow, vless_network, vless_header_type, vless_host, vless_path,
+				vless_mode, vless_security, vless_sni, vless_alpn, vless_fingerprint, vless_allow_insecure,
+				vless_public_key, vless_short_id, vless_spider_x, raw_config, last_connected_at, connected_seconds,
+				notes, user_modified, override_sni, override_host, override_path, created_at, updated_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+				?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 			server.ID, subscriptionID, server.Name, server.Addr, server.Port,
 			server.Username, server.Password, server.Delay,
 			boolToInt(server.Selected), boolToInt(server.Enabled),
@@ -564,7 +698,12 @@ func AddOrUpdateServer(server Node, subscriptionID *int64) error {
 			server.VMessSecurity, server.VMessNetwork, server.VMessType, server.VMessHost,
 			server.VMessPath, server.VMessTLS, server.SSMethod, server.SSPlugin, server.SSPluginOpts,
 			server.SSRObfs, server.SSRObfsParam, server.SSRProtocol, server.SSRProtocolParam,
-			server.RawConfig, now, now,
+			server.VLESSUUID, server.VLESSEncryption, server.VLESSFlow, server.VLESSNetwork, server.VLESSHeaderType,
+			server.VLESSHost, server.VLESSPath, server.VLESSMode, server.VLESSSecurity, server.VLESSSNI,
+			server.VLESSAlpn, server.VLESSFingerprint, boolToInt(server.VLESSAllowInsecure),
+			server.VLESSPublicKey, server.VLESSShortID, server.VLESSSpiderX,
+			server.RawConfig, server.LastConnectedAt, server.ConnectedSeconds, server.Notes, boolToInt(server.UserModified),
+			server.OverrideSNI, server.OverrideHost, server.OverridePath, now, now,
 		)
 		if err != nil {
 			return fmt.Errorf("插入服务器失败: %w", err)
@@ -574,12 +713,14 @@ func AddOrUpdateServer(server Node, subscriptionID *int64) error {
 	} else {
 		// 存在，更新记录
 		// 如果 subscriptionID 为 nil，保持原有的 subscription_id
+		// 备注为空时保留原有备注（订阅解析出的节点不带备注），清除备注使用 UpdateServerNotes
+		// 用户覆盖（SNI / Host / 路径）不在此更新，只能通过 UpdateServerOverrides 修改
 		updateSubscriptionID := subscriptionID
 		if updateSubscriptionID == nil && existingSubscriptionID.Valid {
 			updateSubscriptionID = &existingSubscriptionID.Int64
 		}
 
-		_, err = DB.Exec(
+		_, err = q.Exec(
 			`UPDATE servers SET 
 				subscription_id = ?, name = ?, addr = ?, port = ?, username = ?, password = ?,
 				delay = ?, selected = ?, enabled = ?,
@@ -587,7 +728,11 @@ func AddOrUpdateServer(server Node, subscriptionID *int64) error {
 				vmess_network = ?, vmess_type = ?, vmess_host = ?, vmess_path = ?, vmess_tls = ?,
 				ss_method = ?, ss_plugin = ?, ss_plugin_opts = ?,
 				ssr_obfs = ?, ssr_obfs_param = ?, ssr_protocol = ?, ssr_protocol_param = ?,
-				raw_config = ?, updated_at = ?
+				vless_uuid = ?, vless_encryption = ?, vless_flow = ?, vless_network = ?, vless_header_type = ?,
+				vless_host = ?, vless_path = ?, vless_mode = ?, vless_security = ?, vless_sni = ?,
+				vless_alpn = ?, vless_fingerprint = ?, vless_allow_insecure = ?,
+				vless_public_key = ?, vless_short_id = ?, vless_spider_x = ?,
+				raw_config = ?, notes = CASE WHEN ? = '' THEN notes ELSE ? END, user_modified = ?, updated_at = ?
 			 WHERE id = ?`,
 			updateSubscriptionID, server.Name, server.Addr, server.Port,
 			server.Username, server.Password, server.Delay,
@@ -596,7 +741,11 @@ func AddOrUpdateServer(server Node, subscriptionID *int64) error {
 			server.VMessSecurity, server.VMessNetwork, server.VMessType, server.VMessHost,
 			server.VMessPath, server.VMessTLS, server.SSMethod, server.SSPlugin, server.SSPluginOpts,
 			server.SSRObfs, server.SSRObfsParam, server.SSRProtocol, server.SSRProtocolParam,
-			server.RawConfig, now, server.ID,
+			server.VLESSUUID, server.VLESSEncryption, server.VLESSFlow, server.VLESSNetwork, server.VLESSHeaderType,
+			server.VLESSHost, server.VLESSPath, server.VLESSMode, server.VLESSSecurity, server.VLESSSNI,
+			server.VLESSAlpn, server.VLESSFingerprint, boolToInt(server.VLESSAllowInsecure),
+			server.VLESSPublicKey, server.VLESSShortID, server.VLESSSpiderX,
+			server.RawConfig, server.Notes, server.Notes, boolToInt(server.UserModified), now, server.ID,
 		)
 		if err != nil {
 			return fmt.Errorf("更新服务器失败: %w", err)
@@ -612,62 +761,39 @@ func AddOrUpdateServer(server Node, subscriptionID *int64) error {
 //
 // 返回：服务器实例和错误（如果未找到或发生错误）
 func GetServer(id string) (*Node, error) {
-	var server Node
-	var selected, enabled int
-
-	err := DB.QueryRow(
-		`SELECT id, name, addr, port, username, password, delay, selected, enabled,
-			node_protocol_type, vmess_version, vmess_uuid, vmess_alter_id, vmess_security, vmess_network,
-			vmess_type, vmess_host, vmess_path, vmess_tls, ss_method, ss_plugin, ss_plugin_opts,
-			ssr_obfs, ssr_obfs_param, ssr_protocol, ssr_protocol_param, raw_config
-		 FROM servers WHERE id = ?`,
-		id,
-	).Scan(&server.ID, &server.Name, &server.Addr, &server.Port,
-		&server.Username, &server.Password, &server.Delay,
-		&selected, &enabled,
-		&server.ProtocolType, &server.VMessVersion, &server.VMessUUID, &server.VMessAlterID,
-		&server.VMessSecurity, &server.VMessNetwork, &server.VMessType, &server.VMessHost,
-		&server.VMessPath, &server.VMessTLS, &server.SSMethod, &server.SSPlugin, &server.SSPluginOpts,
-		&server.SSRObfs, &server.SSRObfsParam, &server.SSRProtocol, &server.SSRProtocolParam,
-		&server.RawConfig)
-
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("服务器不存在: %s", id)
-	}
+	rows, err := DB.Query("SELECT "+serverColumns+" FROM servers WHERE id = ?", id)
 	if err != nil {
 		return nil, fmt.Errorf("查询服务器失败: %w", err)
 	}
+	defer rows.Close()
 
-	server.Selected = intToBool(selected)
-	server.Enabled = intToBool(enabled)
-
-	// 如果 ProtocolType 为空，设置默认值
-	if server.ProtocolType == "" {
-		server.ProtocolType = "socks5"
+	servers, err := scanServers(rows)
+	if err != nil {
+		return nil, fmt.Errorf("查询服务器失败: %w", err)
 	}
-
-	return &server, nil
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("服务器不存在: %s", id)
+	}
+	return &servers[0], nil
 }
 
-// GetAllServers 获取所有服务器列表。
-// 返回：服务器列表和错误（如果有）
-func GetAllServers() ([]Node, error) {
-	rows, err := DB.Query(
-		`SELECT id, name, addr, port, username, password, delay, selected, enabled,
+// serverColumns 查询服务器列表时选取的字段，与 scanServers 的扫描顺序一致。
+const serverColumns = `id, name, addr, port, username, password, delay, selected, enabled,
 			node_protocol_type, vmess_version, vmess_uuid, vmess_alter_id, vmess_security, vmess_network,
 			vmess_type, vmess_host, vmess_path, vmess_tls, ss_method, ss_plugin, ss_plugin_opts,
-			ssr_obfs, ssr_obfs_param, ssr_protocol, ssr_protocol_param, raw_config
-		 FROM servers ORDER BY created_at DESC`,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("查询服务器列表失败: %w", err)
-	}
-	defer rows.Close()
-
+			ssr_obfs, ssr_obfs_param, ssr_protocol, ssr_protocol_param,
+			vless_uuid, vless_encryption, vless_flow, vless_network, vless_header_type, vless_host, vless_path,
+			vless_mode, vless_security, vless_sni, vless_alpn, vless_fingerprint, vless_allow_insecure,
+			vless_public_key, vless_short_id, vless_spider_x, raw_config,
+			last_connected_at, connected_seconds, notes, user_modified,
+			override_sni, override_host, override_path`
+
+// scanServers 扫描按 serverColumns 查询得到的服务器行。
+func scanServers(rows *sql.Rows) ([]Node, error) {
 	var servers []Node
 	for rows.Next() {
 		var server Node
-		var selected, enabled int
+		var selected, enabled, userModified, vlessAllowInsecure int
 
 		if err := rows.Scan(&server.ID, &server.Name, &server.Addr, &server.Port,
 			&server.Username, &server.Password, &server.Delay,
@@ -676,12 +802,19 @@ func GetAllServers() ([]Node, error) {
 			&server.VMessSecurity, &server.VMessNetwork, &server.VMessType, &server.VMessHost,
 			&server.VMessPath, &server.VMessTLS, &server.SSMethod, &server.SSPlugin, &server.SSPluginOpts,
 			&server.SSRObfs, &server.SSRObfsParam, &server.SSRProtocol, &server.SSRProtocolParam,
-			&server.RawConfig); err != nil {
+			&server.VLESSUUID, &server.VLESSEncryption, &server.VLESSFlow, &server.VLESSNetwork, &server.VLESSHeaderType,
+			&server.VLESSHost, &server.VLESSPath, &server.VLESSMode, &server.VLESSSecurity, &server.VLESSSNI,
+			&server.VLESSAlpn, &server.VLESSFingerprint, &vlessAllowInsecure,
+			&server.VLESSPublicKey, &server.VLESSShortID, &server.VLESSSpiderX,
+			&server.RawConfig, &server.LastConnectedAt, &server.ConnectedSeconds, &server.Notes, &userModified,
+			&server.OverrideSNI, &server.OverrideHost, &server.OverridePath); err != nil {
 			return nil, fmt.Errorf("扫描服务器数据失败: %w", err)
 		}
 
 		server.Selected = intToBool(selected)
 		server.Enabled = intToBool(enabled)
+		server.UserModified = intToBool(userModified)
+		server.VLESSAllowInsecure = intToBool(vlessAllowInsecure)
 
 		// 如果 ProtocolType 为空，设置默认值
 		if server.ProtocolType == "" {
@@ -698,6 +831,18 @@ func GetAllServers() ([]Node, error) {
 	return servers, nil
 }
 
+// GetAllServers 获取所有服务器列表。
+// 返回：服务器列表和错误（如果有）
+func GetAllServers() ([]Node, error) {
+	rows, err := DB.Query("SELECT " + serverColumns + " FROM servers ORDER BY created_at DESC")
+	if err != nil {
+		return nil, fmt.Errorf("查询服务器列表失败: %w", err)
+	}
+	defer rows.Close()
+
+	return scanServers(rows)
+}
+
 // GetServersBySubscriptionID 获取指定订阅关联的所有服务器。
 // 参数：
 //   - subscriptionID: 订阅 ID
@@ -705,11 +850,7 @@ func GetAllServers() ([]Node, error) {
 // 返回：服务器列表和错误（如果有）
 func GetServersBySubscriptionID(subscriptionID int64) ([]Node, error) {
 	rows, err := DB.Query(
-		`SELECT id, name, addr, port, username, password, delay, selected, enabled,
-			node_protocol_type, vmess_version, vmess_uuid, vmess_alter_id, vmess_security, vmess_network,
-			vmess_type, vmess_host, vmess_path, vmess_tls, ss_method, ss_plugin, ss_plugin_opts,
-			ssr_obfs, ssr_obfs_param, ssr_protocol, ssr_protocol_param, raw_config
-		 FROM servers WHERE subscription_id = ? ORDER BY created_at DESC`,
+		"SELECT "+serverColumns+" FROM servers WHERE subscription_id = ? ORDER BY created_at DESC",
 		subscriptionID,
 	)
 	if err != nil {
@@ -717,38 +858,88 @@ func GetServersBySubscriptionID(subscriptionID int64) ([]Node, error) {
 	}
 	defer rows.Close()
 
-	var servers []Node
-	for rows.Next() {
-		var server Node
-		var selected, enabled int
-
-		if err := rows.Scan(&server.ID, &server.Name, &server.Addr, &server.Port,
-			&server.Username, &server.Password, &server.Delay,
-			&selected, &enabled,
-			&server.ProtocolType, &server.VMessVersion, &server.VMessUUID, &server.VMessAlterID,
-			&server.VMessSecurity, &server.VMessNetwork, &server.VMessType, &server.VMessHost,
-			&server.VMessPath, &server.VMessTLS, &server.SSMethod, &server.SSPlugin, &server.SSPluginOpts,
-			&server.SSRObfs, &server.SSRObfsParam, &server.SSRProtocol, &server.SSRProtocolParam,
-			&server.RawConfig); err != nil {
-			return nil, fmt.Errorf("扫描服务器数据失败: %w", err)
-		}
-
-		server.Selected = intToBool(selected)
-		server.Enabled = intToBool(enabled)
+	return scanServers(rows)
+}
 
-		// 如果 ProtocolType 为空，设置默认值
-		if server.ProtocolType == "" {
-			server.ProtocolType = "socks5"
+// QueryServers 按条件过滤、排序并分页查询服务器，用于节点较多时避免在内存中逐个过滤。
+// 参数：
+//   - q: 查询条件，零值返回全部服务器（按添加时间倒序）
+//
+// 返回：当前页的服务器列表、满足条件的总数和错误（如果有）
+func QueryServers(q model.NodeQuery) ([]Node, int, error) {
+	var conds []string
+	var args []interface{}
+	if q.SubscriptionID != nil {
+		if *q.SubscriptionID == 0 {
+			conds = append(conds, "subscription_id IS NULL")
+		} else {
+			conds = append(conds, "subscription_id = ?")
+			args = append(args, *q.SubscriptionID)
 		}
-
-		servers = append(servers, server)
 	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("遍历服务器数据失败: %w", err)
+	if q.Protocol != "" {
+		conds = append(conds, "node_protocol_type = ?")
+		args = append(args, q.Protocol)
+	}
+	if q.Enabled != nil {
+		conds = append(conds, "enabled = ?")
+		args = append(args, boolToInt(*q.Enabled))
+	}
+	if text := strings.TrimSpace(q.Text); text != "" {
+		// LIKE 对 ASCII 不区分大小写；转义通配符，按字面匹配关键字
+		pattern := "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(text) + "%"
+		conds = append(conds, `(name LIKE ? ESCAPE '\' OR addr LIKE ? ESCAPE '\' OR node_protocol_type LIKE ? ESCAPE '\' OR notes LIKE ? ESCAPE '\')`)
+		args = append(args, pattern, pattern, pattern, pattern)
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var total int
+	if err := DB.QueryRow("SELECT COUNT(*) FROM servers"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("统计服务器数量失败: %w", err)
+	}
+
+	dir := "ASC"
+	if q.Desc {
+		dir = "DESC"
+	}
+	var order string
+	switch q.Sort {
+	case model.NodeSortName:
+		order = "name COLLATE NOCASE " + dir
+	case model.NodeSortDelay:
+		// 未测速（0）和超时（-1）始终排在最后
+		order = "CASE WHEN delay > 0 THEN 0 ELSE 1 END, delay " + dir
+	case model.NodeSortLastConnected:
+		order = "last_connected_at " + dir
+	case model.NodeSortCreated:
+		order = "created_at " + dir
+	default:
+		order = "created_at DESC"
+	}
+	// id 作为次序键，保证分页结果稳定
+	query := "SELECT " + serverColumns + " FROM servers" + where + " ORDER BY " + order + ", id"
+	if q.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, q.Limit, max(q.Offset, 0))
+	} else if q.Offset > 0 {
+		query += " LIMIT -1 OFFSET ?"
+		args = append(args, q.Offset)
+	}
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询服务器列表失败: %w", err)
 	}
+	defer rows.Close()
 
-	return servers, nil
+	servers, err := scanServers(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return servers, total, nil
 }
 
 // UpdateServerDelay 更新服务器的延迟值。
@@ -768,6 +959,78 @@ func UpdateServerDelay(id string, delay int) error {
 	return nil
 }
 
+// RecordServerConnected 记录服务器最近一次连接时间。
+// 参数：
+//   - id: 服务器 ID
+//   - at: 连接时间
+//
+// 返回：错误（如果有）
+func RecordServerConnected(id string, at time.Time) error {
+	_, err := DB.Exec("UPDATE servers SET last_connected_at = ? WHERE id = ?", at.Unix(), id)
+	if err != nil {
+		return fmt.Errorf("记录服务器连接时间失败: %w", err)
+	}
+	return nil
+}
+
+// UpdateServerNotes 更新服务器备注。
+// 参数：
+//   - id: 服务器 ID
+//   - notes: 备注内容（为空表示清除）
+//
+// 返回：错误（如果有）
+func UpdateServerNotes(id string, notes string) error {
+	if _, err := DB.Exec("UPDATE servers SET notes = ? WHERE id = ?", notes, id); err != nil {
+		return fmt.Errorf("更新服务器备注失败: %w", err)
+	}
+	return nil
+}
+
+// UpdateServerOverrides 更新服务器的用户覆盖（SNI、Host、路径），空值表示使用链接中的值。
+// 参数：
+//   - id: 服务器 ID
+//   - sni, host, path: 覆盖值
+//
+// 返回：错误（如果有）
+func UpdateServerOverrides(id, sni, host, path string) error {
+	return updateServerOverrides(DB, id, sni, host, path)
+}
+
+// updateServerOverrides UpdateServerOverrides 的实现，q 为数据库连接或事务。
+func updateServerOverrides(q execer, id, sni, host, path string) error {
+	if _, err := q.Exec(
+		"UPDATE servers SET override_sni = ?, override_host = ?, override_path = ?, updated_at = ? WHERE id = ?",
+		sni, host, path, time.Now(), id,
+	); err != nil {
+		return fmt.Errorf("更新服务器覆盖参数失败: %w", err)
+	}
+	return nil
+}
+
+// AddServerConnectedSeconds 累加服务器的连接时长。
+// 参数：
+//   - id: 服务器 ID
+//   - seconds: 本次连接时长（秒）
+//
+// 返回：错误（如果有）
+func AddServerConnectedSeconds(id string, seconds int64) error {
+	_, err := DB.Exec("UPDATE servers SET connected_seconds = connected_seconds + ? WHERE id = ?", seconds, id)
+	if err != nil {
+		return fmt.Errorf("累加服务器连接时长失败: %w", err)
+	}
+	return nil
+}
+
+// ClearServerConnectHistory 清除所有服务器的连接历史（最近连接时间和累计时长）。
+// 返回：错误（如果有）
+func ClearServerConnectHistory() error {
+	_, err := DB.Exec("UPDATE servers SET last_connected_at = 0, connected_seconds = 0")
+	if err != nil {
+		return fmt.Errorf("清除连接历史失败: %w", err)
+	}
+	return nil
+}
+
 // SelectServer 选中指定的服务器（取消其他服务器的选中状态）。
 // 参数：
 //   - id: 要选中的服务器 ID
@@ -795,7 +1058,12 @@ func SelectServer(id string) error {
 //
 // 返回：错误（如果有）
 func DeleteServer(id string) error {
-	_, err := DB.Exec("DELETE FROM servers WHERE id = ?", id)
+	return deleteServer(DB, id)
+}
+
+// deleteServer DeleteServer 的实现，q 为数据库连接或事务。
+func deleteServer(q execer, id string) error {
+	_, err := q.Exec("DELETE FROM servers WHERE id = ?", id)
 	if err != nil {
 		return fmt.Errorf("删除服务器失败: %w", err)
 	}
@@ -808,7 +1076,12 @@ func DeleteServer(id string) error {
 //
 // 返回：错误（如果有）
 func DeleteServersBySubscriptionID(subscriptionID int64) error {
-	_, err := DB.Exec("DELETE FROM servers WHERE subscription_id = ?", subscriptionID)
+	return deleteServersBySubscriptionID(DB, subscriptionID)
+}
+
+// deleteServersBySubscriptionID DeleteServersBySubscriptionID 的实现，q 为数据库连接或事务。
+func deleteServersBySubscriptionID(q execer, subscriptionID int64) error {
+	_, err := q.Exec("DELETE FROM servers WHERE subscription_id = ?", subscriptionID)
 	if err != nil {
 		return fmt.Errorf("删除订阅服务器失败: %w", err)
 	}
@@ -889,6 +1162,29 @@ func GetAppConfig(key string) (string, error) {
 	return value, nil
 }
 
+// GetAllAppConfig 获取 app_config 表中的全部配置项。
+// 返回：键到值的映射和错误（如果有）
+func GetAllAppConfig() (map[string]string, error) {
+	rows, err := DB.Query("SELECT key, value FROM app_config")
+	if err != nil {
+		return nil, fmt.Errorf("获取应用配置失败: %w", err)
+	}
+	defer rows.Close()
+
+	config := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("读取应用配置失败: %w", err)
+		}
+		config[key] = value
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取应用配置失败: %w", err)
+	}
+	return config, nil
+}
+
 // GetAppConfigWithDefault 获取应用配置，如果不存在则返回默认值。
 // 参数：
 //   - key: 配置键名
@@ -1040,3 +1336,52 @@ func boolToInt(b bool) int {
 func intToBool(i int) bool {
 	return i != 0
 }
+
+// IncrementUsageStat 累加功能使用次数，不存在时插入。
+func IncrementUsageStat(feature string) error {
+	now := time.Now()
+	_, err := DB.Exec(
+		`INSERT INTO usage_stats (feature, count, first_used, last_used)
+		 VALUES (?, 1, ?, ?)
+		 ON CONFLICT(feature) DO UPDATE SET
+			count = count + 1,
+			last_used = excluded.last_used`,
+		feature, now, now,
+	)
+	if err != nil {
+		return fmt.Errorf("更新使用统计失败: %w", err)
+	}
+	return nil
+}
+
+// GetAllUsageStats 获取所有功能使用统计，按次数倒序。
+func GetAllUsageStats() ([]model.UsageStat, error) {
+	rows, err := DB.Query(`SELECT feature, count, first_used, last_used FROM usage_stats ORDER BY count DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("查询使用统计失败: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []model.UsageStat
+	for rows.Next() {
+		var st model.UsageStat
+		var feature string
+		if err := rows.Scan(&feature, &st.Count, &st.FirstUsed, &st.LastUsed); err != nil {
+			return nil, fmt.Errorf("扫描使用统计失败: %w", err)
+		}
+		st.Feature = model.UsageFeature(feature)
+		stats = append(stats, st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历使用统计失败: %w", err)
+	}
+	return stats, nil
+}
+
+// ClearUsageStats 清空功能使用统计。
+func ClearUsageStats() error {
+	if _, err := DB.Exec("DELETE FROM usage_stats"); err != nil {
+		return fmt.Errorf("清空使用统计失败: %w", err)
+	}
+	return nil
+}
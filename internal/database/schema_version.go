@@ -0,0 +1,94 @@
+package database
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// SchemaVersion 当前程序使用的数据库结构版本，记录在 SQLite 的 user_version 中。
+// 每次修改表结构（新增表、字段或迁移）时加 1，旧版本程序据此拒绝打开新版本创建的数据库。
+//
+// 版本历史（0 表示引入版本号之前创建的数据库）：
+//   - 1：基线，开始在 user_version 中记录结构版本
+//   - 2：servers 表新增 user_modified 列（用户修改过的订阅节点）
+//   - 3：servers 表新增 override_sni/override_host/override_path 覆盖参数列
+//   - 4：servers 表新增 vless_* 节点参数列
+const SchemaVersion = 4
+
+// SchemaTooNewError 数据库由更新版本的程序创建，当前程序无法安全使用。
+type SchemaTooNewError struct {
+	Path      string // 数据库文件路径
+	DBVersion int    // 数据库中记录的结构版本
+	Supported int    // 当前程序支持的最高结构版本
+}
+
+func (e *SchemaTooNewError) Error() string {
+	return fmt.Sprintf("数据库结构版本 %d 高于当前程序支持的版本 %d，请使用新版本程序打开", e.DBVersion, e.Supported)
+}
+
+// getSchemaVersion 读取数据库记录的结构版本（新建或旧版数据库为 0）。
+func getSchemaVersion() (int, error) {
+	var version int
+	if err := DB.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return 0, fmt.Errorf("读取数据库结构版本失败: %w", err)
+	}
+	return version, nil
+}
+
+// checkSchemaVersion 检查数据库结构版本，高于当前程序支持的版本时返回 SchemaTooNewError。
+func checkSchemaVersion(dbPath string) error {
+	version, err := getSchemaVersion()
+	if err != nil {
+		return err
+	}
+	if version > SchemaVersion {
+		return &SchemaTooNewError{Path: dbPath, DBVersion: version, Supported: SchemaVersion}
+	}
+	return nil
+}
+
+// updateSchemaVersion 迁移完成后记录当前结构版本（只升不降）。
+func updateSchemaVersion() error {
+	version, err := getSchemaVersion()
+	if err != nil {
+		return err
+	}
+	if version >= SchemaVersion {
+		return nil
+	}
+	// PRAGMA 不支持参数占位符，SchemaVersion 为常量
+	if _, err := DB.Exec(fmt.Sprintf("PRAGMA user_version = %d", SchemaVersion)); err != nil {
+		return fmt.Errorf("记录数据库结构版本失败: %w", err)
+	}
+	return nil
+}
+
+// BackupDBFile 将数据库文件复制到同目录下带时间戳的备份文件（数据库未打开时调用）。
+// 参数：
+//   - dbPath: 数据库文件路径
+//
+// 返回：备份文件路径和错误（如果有）
+func BackupDBFile(dbPath string) (string, error) {
+	src, err := os.Open(dbPath)
+	if err != nil {
+		return "", fmt.Errorf("打开数据库文件失败: %w", err)
+	}
+	defer src.Close()
+
+	backupPath := fmt.Sprintf("%s.%s.bak", dbPath, time.Now().Format("20060102-150405"))
+	dst, err := os.OpenFile(backupPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return "", fmt.Errorf("创建备份文件失败: %w", err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(backupPath)
+		return "", fmt.Errorf("复制数据库文件失败: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		return "", fmt.Errorf("写入备份文件失败: %w", err)
+	}
+	return backupPath, nil
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// execer 由 *sql.DB 和 *sql.Tx 实现，使服务器写入函数可在事务内外复用。
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// ServerTx 事务内的服务器写入操作，由 UpdateServersInTx 提供，方法与同名包级函数一致。
+type ServerTx struct {
+	q execer
+}
+
+// UpdateServersInTx 在一个事务中执行 fn 中的服务器写入：fn 返回错误时全部回滚，
+// 用于订阅更新等需要整体替换节点的场景，避免中途失败留下不完整的节点列表。
+// 参数：
+//   - fn: 事务内的写入操作
+//
+// 返回：错误（如果有）
+func UpdateServersInTx(fn func(tx *ServerTx) error) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(&ServerTx{q: tx}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	return nil
+}
+
+// AddOrUpdateServer 添加或更新服务器，见包级函数 AddOrUpdateServer。
+func (t *ServerTx) AddOrUpdateServer(server Node, subscriptionID *int64) error {
+	return addOrUpdateServer(t.q, server, subscriptionID)
+}
+
+// UpdateServerOverrides 更新服务器的用户覆盖参数，见包级函数 UpdateServerOverrides。
+func (t *ServerTx) UpdateServerOverrides(id, sni, host, path string) error {
+	return updateServerOverrides(t.q, id, sni, host, path)
+}
+
+// DeleteServer 删除指定的服务器，见包级函数 DeleteServer。
+func (t *ServerTx) DeleteServer(id string) error {
+	return deleteServer(t.q, id)
+}
+
+// DeleteServersBySubscriptionID 删除指定订阅关联的所有服务器，见包级函数 DeleteServersBySubscriptionID。
+func (t *ServerTx) DeleteServersBySubscriptionID(subscriptionID int64) error {
+	return deleteServersBySubscriptionID(t.q, subscriptionID)
+}
@@ -0,0 +1,48 @@
+// Package errs 定义各层共用的哨兵错误和错误类型。
+// 各层返回错误时用 %w 包装这里的错误（保留上下文和底层原因），
+// 调用方用 errors.Is / errors.As 判断错误类别，不依赖错误消息的文字。
+package errs
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrStoreNotInitialized Store 尚未初始化（通常是启动流程未完成）
+	ErrStoreNotInitialized = errors.New("Store 未初始化")
+	// ErrUnsupportedProtocol 节点、入站或 DNS 服务器使用了不支持的协议
+	ErrUnsupportedProtocol = errors.New("不支持的协议")
+	// ErrUnsupportedOS 当前操作系统不支持该功能
+	ErrUnsupportedOS = errors.New("不支持的操作系统")
+	// ErrAuthFailed 远端拒绝了认证（如订阅地址中的 token 失效）
+	ErrAuthFailed = errors.New("认证失败")
+	// ErrPortInUse 端口已被占用，具体端口见 PortInUseError
+	ErrPortInUse = errors.New("端口已被占用")
+	// ErrNodeNotFound 节点不存在（可能已被删除或随订阅更新移除）
+	ErrNodeNotFound = errors.New("节点不存在")
+	// ErrNoNodeSelected 尚未选中节点
+	ErrNoNodeSelected = errors.New("未选中节点")
+	// ErrProxyNotRunning 代理未运行
+	ErrProxyNotRunning = errors.New("代理未运行")
+)
+
+// PortInUseError 端口已被占用或无法监听。errors.Is(err, ErrPortInUse) 为真，
+// 用 errors.As 取得具体端口。
+type PortInUseError struct {
+	Port int
+	Err  error // 底层原因（如监听失败的错误），可为 nil
+}
+
+func (e *PortInUseError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("端口 %d 已被占用: %v", e.Port, e.Err)
+	}
+	return fmt.Sprintf("端口 %d 已被占用", e.Port)
+}
+
+// Unwrap 返回底层原因。
+func (e *PortInUseError) Unwrap() error { return e.Err }
+
+// Is 使 errors.Is(err, ErrPortInUse) 成立。
+func (e *PortInUseError) Is(target error) bool { return target == ErrPortInUse }
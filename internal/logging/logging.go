@@ -7,6 +7,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"myproxy.com/p/internal/utils"
 )
 
 // LogLevel 日志级别
@@ -33,16 +35,67 @@ var levelNames = map[LogLevel]string{
 	LevelFatal: "FATAL",
 }
 
-// LogType 日志类型
+// LogType 日志类型（来源），写入日志行的第二个方括号，如 "[INFO] [ping]"
 type LogType string
 
 const (
-	// LogTypeApp 应用程序日志
+	// LogTypeApp 应用程序日志（未归类的来源）
 	LogTypeApp LogType = "app"
-	// LogTypeProxy 代理转发日志
+	// LogTypeProxy 代理启停与路由重建
 	LogTypeProxy LogType = "proxy"
+	// LogTypeXray xray-core 自身输出的日志
+	LogTypeXray LogType = "xray"
+	// LogTypeUI 界面操作与界面错误
+	LogTypeUI LogType = "ui"
+	// LogTypeSubscription 订阅拉取与更新
+	LogTypeSubscription LogType = "subscription"
+	// LogTypePing 测速
+	LogTypePing LogType = "ping"
+	// LogTypeSystemProxy 系统代理设置
+	LogTypeSystemProxy LogType = "systemproxy"
+	// LogTypeStore 本地数据读写
+	LogTypeStore LogType = "store"
+	// LogTypeServer 节点管理（选择、降级、故障转移、分享）
+	LogTypeServer LogType = "server"
 )
 
+// AllLogTypes 全部日志类型（顺序固定，用于日志面板筛选）。
+var AllLogTypes = []LogType{
+	LogTypeApp, LogTypeProxy, LogTypeXray, LogTypeUI, LogTypeSubscription,
+	LogTypePing, LogTypeSystemProxy, LogTypeStore, LogTypeServer,
+}
+
+// logTypeLabels 日志类型的中文名称
+var logTypeLabels = map[LogType]string{
+	LogTypeApp:          "应用",
+	LogTypeProxy:        "代理",
+	LogTypeXray:         "xray",
+	LogTypeUI:           "界面",
+	LogTypeSubscription: "订阅",
+	LogTypePing:         "测速",
+	LogTypeSystemProxy:  "系统代理",
+	LogTypeStore:        "存储",
+	LogTypeServer:       "节点",
+}
+
+// Label 返回日志类型的中文名称。
+func (t LogType) Label() string {
+	if label, ok := logTypeLabels[t]; ok {
+		return label
+	}
+	return string(t)
+}
+
+// ParseLogType 解析日志行或调用方传入的类型（不区分大小写），未知类型归为 app。
+// 旧版日志文件只有 app / xray 两种类型，均可直接解析。
+func ParseLogType(s string) LogType {
+	t := LogType(strings.ToLower(strings.TrimSpace(s)))
+	if _, ok := logTypeLabels[t]; ok {
+		return t
+	}
+	return LogTypeApp
+}
+
 // LogPanelCallback 日志面板回调函数类型
 // 当有新日志写入时，会调用此回调来更新UI
 type LogPanelCallback func(level, logType, message, logLine string)
@@ -57,6 +110,7 @@ type Logger struct {
 	logFilePath   string
 	logDir        string
 	panelCallback LogPanelCallback // UI面板回调函数（用于实时更新UI）
+	sessionFile   *os.File         // 调试会话文件：非空时日志同时写入此文件（见 StartSessionFile）
 }
 
 const (
@@ -208,16 +262,14 @@ func (l *Logger) log(level LogLevel, logType LogType, format string, args ...int
 		return
 	}
 
-	// 规范化日志类型：仅保留 app / xray，其他归并为 app
-	logTypeStr := strings.ToLower(string(logType))
-	if logTypeStr != "xray" {
-		logTypeStr = "app"
-	}
+	// 规范化日志类型：未知类型归并为 app
+	logTypeStr := string(ParseLogType(string(logType)))
 
 	// 生成日志消息
 	timestamp := time.Now().Format("2006-01-02 15:04:05")
 	levelName := levelNames[level]
-	message := fmt.Sprintf(format, args...)
+	// 默认脱敏：日志文件和日志面板都不落明文凭据
+	message := utils.RedactSecrets(fmt.Sprintf(format, args...))
 	// 在日志中添加类型标识
 	logLine := fmt.Sprintf("%s [%s] [%s] %s\n", timestamp, levelName, logTypeStr, message)
 
@@ -241,6 +293,10 @@ func (l *Logger) log(level LogLevel, logType LogType, format string, args ...int
 		}
 	}
 
+	if l.sessionFile != nil {
+		l.sessionFile.WriteString(logLine)
+	}
+
 	// 通知UI面板更新（确保文件写入和UI显示一致）
 	if l.panelCallback != nil {
 		// 移除末尾的换行符，因为UI显示不需要
@@ -279,6 +335,11 @@ func (l *Logger) InfoWithType(logType LogType, format string, args ...interface{
 	l.log(LevelInfo, logType, format, args...)
 }
 
+// ErrorWithType 记录指定类型的错误日志
+func (l *Logger) ErrorWithType(logType LogType, format string, args ...interface{}) {
+	l.log(LevelError, logType, format, args...)
+}
+
 // Error 记录错误日志（默认应用日志）
 func (l *Logger) Error(format string, args ...interface{}) {
 	l.log(LevelError, LogTypeApp, format, args...)
@@ -310,6 +371,10 @@ func (l *Logger) Close() {
 		l.file.Close()
 		l.file = nil
 	}
+	if l.sessionFile != nil {
+		l.sessionFile.Close()
+		l.sessionFile = nil
+	}
 }
 
 // GetLogFilePath 获取日志文件路径
@@ -325,11 +390,11 @@ func (l *Logger) WriteRawLine(line string) {
 	}
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
-	toWrite := line
+	toWrite := utils.RedactSecrets(line)
 	// 补全时间戳：xray 劫持有时不带时间戳，统一为 2026/02/12 10:43:05.123456 from...
 	if !strings.HasPrefix(strings.TrimSpace(line), "20") {
 		ts := time.Now().Format("2006/01/02 15:04:05.000000")
-		toWrite = ts + " " + strings.TrimLeft(line, " \t")
+		toWrite = ts + " " + strings.TrimLeft(toWrite, " \t")
 	}
 	if !strings.HasSuffix(toWrite, "\n") {
 		toWrite += "\n"
@@ -340,6 +405,36 @@ func (l *Logger) WriteRawLine(line string) {
 			l.file.WriteString(toWrite)
 		}
 	}
+	if l.sessionFile != nil {
+		l.sessionFile.WriteString(toWrite)
+	}
+}
+
+// StartSessionFile 开始调试会话：之后的日志（含 xray 原始日志）在写入日志文件的同时写入 path，
+// 便于把一段排查期间的详细日志单独发给他人。
+// 返回：错误（已在会话中或无法创建文件时）
+func (l *Logger) StartSessionFile(path string) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.sessionFile != nil {
+		return fmt.Errorf("调试会话文件已打开: %s", l.sessionFile.Name())
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("创建调试会话文件失败: %w", err)
+	}
+	l.sessionFile = f
+	return nil
+}
+
+// StopSessionFile 结束调试会话，关闭会话文件（未在会话中时无操作）。
+func (l *Logger) StopSessionFile() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.sessionFile != nil {
+		l.sessionFile.Close()
+		l.sessionFile = nil
+	}
 }
 
 // Log 记录日志（通用方法，支持外部调用）
@@ -350,13 +445,7 @@ func (l *Logger) Log(level, logType, message string) {
 		logLevel = LevelInfo
 	}
 
-	// 解析日志类型
-	var lt LogType = LogTypeApp
-	if strings.ToLower(logType) == "xray" {
-		lt = LogTypeProxy
-	}
-
-	l.log(logLevel, lt, "%s", message)
+	l.log(logLevel, ParseLogType(logType), "%s", message)
 }
 
 // SafeLogger 安全日志包装器，处理 Logger 为 nil 的情况
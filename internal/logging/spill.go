@@ -0,0 +1,176 @@
+package logging
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	// spillSegmentLines 每个溢出分段的行数，也是「加载更早日志」一次读取的条数
+	spillSegmentLines = 1000
+	// spillSegmentExt 溢出分段文件扩展名
+	spillSegmentExt = ".seg"
+)
+
+// LogSpill 日志面板的磁盘溢出环：内存缓冲区装不下的旧日志按固定行数分段写入独立目录，
+// 超出磁盘配额时删除最旧的分段。分段以递增序号命名，可按序号快速分页读取。
+// 仅保存当前会话的日志，创建时清空目录，与主日志文件互不影响。
+type LogSpill struct {
+	dir   string
+	quota int64
+
+	mu        sync.Mutex
+	segments  []int // 磁盘上现存分段序号（升序）
+	sizes     map[int]int64
+	current   *os.File
+	curSeq    int
+	curLines  int
+	totalSize int64
+}
+
+// NewLogSpill 创建日志溢出环。
+// 参数：
+//   - dir: 分段存放目录（会被清空）
+//   - quota: 磁盘配额（字节），必须大于 0
+//
+// 返回：溢出环实例和错误（如果有）
+func NewLogSpill(dir string, quota int64) (*LogSpill, error) {
+	if quota <= 0 {
+		return nil, fmt.Errorf("日志溢出: 磁盘配额必须大于 0")
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("日志溢出: 清理目录失败: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("日志溢出: 创建目录失败: %w", err)
+	}
+	return &LogSpill{dir: dir, quota: quota, sizes: make(map[int]int64), curSeq: -1}, nil
+}
+
+// SetQuota 调整磁盘配额，立即删除超出配额的最旧分段。
+func (s *LogSpill) SetQuota(quota int64) {
+	if quota <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quota = quota
+	s.enforceQuota()
+}
+
+// Append 追加被挤出内存的日志行（按时间顺序）。
+func (s *LogSpill) Append(lines []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, line := range lines {
+		if s.current == nil || s.curLines >= spillSegmentLines {
+			if err := s.rotate(); err != nil {
+				return err
+			}
+		}
+		n, err := s.current.WriteString(line + "\n")
+		if err != nil {
+			return fmt.Errorf("日志溢出: 写入失败: %w", err)
+		}
+		s.curLines++
+		s.sizes[s.curSeq] += int64(n)
+		s.totalSize += int64(n)
+	}
+	s.enforceQuota()
+	return nil
+}
+
+// rotate 关闭当前分段并开始新分段。
+func (s *LogSpill) rotate() error {
+	if s.current != nil {
+		s.current.Close()
+	}
+	s.curSeq++
+	f, err := os.OpenFile(s.segmentPath(s.curSeq), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		s.current = nil
+		return fmt.Errorf("日志溢出: 创建分段失败: %w", err)
+	}
+	s.current = f
+	s.curLines = 0
+	s.segments = append(s.segments, s.curSeq)
+	s.sizes[s.curSeq] = 0
+	return nil
+}
+
+// enforceQuota 删除最旧的分段直到总大小不超过配额（始终保留正在写入的分段）。
+func (s *LogSpill) enforceQuota() {
+	for s.totalSize > s.quota && len(s.segments) > 1 {
+		oldest := s.segments[0]
+		s.segments = s.segments[1:]
+		s.totalSize -= s.sizes[oldest]
+		delete(s.sizes, oldest)
+		_ = os.Remove(s.segmentPath(oldest))
+	}
+}
+
+// Range 返回现存分段的序号范围 [oldest, newest]，无分段时 ok 为 false。
+func (s *LogSpill) Range() (oldest, newest int, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.segments) == 0 {
+		return 0, 0, false
+	}
+	return s.segments[0], s.segments[len(s.segments)-1], true
+}
+
+// ReadSegment 读取指定序号分段的全部日志行（按时间顺序）。
+func (s *LogSpill) ReadSegment(seq int) ([]string, error) {
+	s.mu.Lock()
+	_, exists := s.sizes[seq]
+	s.mu.Unlock()
+	if !exists {
+		return nil, fmt.Errorf("日志溢出: 分段 %d 已被清理", seq)
+	}
+
+	f, err := os.Open(s.segmentPath(seq))
+	if err != nil {
+		return nil, fmt.Errorf("日志溢出: 读取分段失败: %w", err)
+	}
+	defer f.Close()
+
+	lines := make([]string, 0, spillSegmentLines)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return lines, fmt.Errorf("日志溢出: 读取分段失败: %w", err)
+	}
+	return lines, nil
+}
+
+// Size 返回当前占用的磁盘大小（字节）。
+func (s *LogSpill) Size() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.totalSize
+}
+
+// Close 关闭并删除所有分段。
+func (s *LogSpill) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current != nil {
+		s.current.Close()
+		s.current = nil
+	}
+	s.segments = nil
+	s.sizes = make(map[int]int64)
+	s.totalSize = 0
+	_ = os.RemoveAll(s.dir)
+}
+
+// segmentPath 返回分段文件路径（序号补零，目录列出时按时间排序）。
+func (s *LogSpill) segmentPath(seq int) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%08d%s", seq, spillSegmentExt))
+}
@@ -0,0 +1,41 @@
+package model
+
+import "fmt"
+
+// ConnPolicy 连接策略：对应 xray policy.levels.0，控制连接超时与缓冲区大小。
+// 超时单位为秒，默认值与 xray-core 内置默认值一致。
+type ConnPolicy struct {
+	Handshake    int `json:"handshake"`     // 握手超时：连接建立阶段的最长时间
+	ConnIdle     int `json:"conn_idle"`     // 空闲超时：连接无数据传输多久后断开
+	UplinkOnly   int `json:"uplink_only"`   // 下行关闭后，上行连接保留时间
+	DownlinkOnly int `json:"downlink_only"` // 上行关闭后，下行连接保留时间
+	BufferSize   int `json:"buffer_size"`   // 每个连接的缓冲区大小（KB），-1 表示使用 xray 默认值
+}
+
+// DefaultConnPolicy 返回 xray-core 默认的连接策略。
+func DefaultConnPolicy() ConnPolicy {
+	return ConnPolicy{
+		Handshake:    4,
+		ConnIdle:     300,
+		UplinkOnly:   2,
+		DownlinkOnly: 5,
+		BufferSize:   -1,
+	}
+}
+
+// Validate 校验各项取值是否在安全范围内，避免连接过早断开或长期占用资源。
+func (p ConnPolicy) Validate() error {
+	switch {
+	case p.Handshake < 1 || p.Handshake > 60:
+		return fmt.Errorf("握手超时需在 1–60 秒之间: %d", p.Handshake)
+	case p.ConnIdle < 10 || p.ConnIdle > 86400:
+		return fmt.Errorf("空闲超时需在 10–86400 秒之间: %d", p.ConnIdle)
+	case p.UplinkOnly < 0 || p.UplinkOnly > 3600:
+		return fmt.Errorf("上行保留时间需在 0–3600 秒之间: %d", p.UplinkOnly)
+	case p.DownlinkOnly < 0 || p.DownlinkOnly > 3600:
+		return fmt.Errorf("下行保留时间需在 0–3600 秒之间: %d", p.DownlinkOnly)
+	case p.BufferSize < -1 || p.BufferSize > 4096:
+		return fmt.Errorf("缓冲区大小需在 0–4096 KB 之间（-1 为默认）: %d", p.BufferSize)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package model
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"myproxy.com/p/internal/errs"
+)
+
+// DNSMode 域名解析方式。
+type DNSMode string
+
+const (
+	DNSModeRemote DNSMode = "remote" // 远程解析（默认）：走代理的域名交给节点解析，本机不查询
+	DNSModeLocal  DNSMode = "local"  // 本地解析：路由前用自定义 DNS 解析域名，使 IP 规则对域名生效
+	DNSModeFakeIP DNSMode = "fakeip" // Fake IP：本地 DNS 入站只返回虚拟地址，真实解析由节点完成，防止 DNS 泄漏
+)
+
+// DefaultDNSListenPort Fake IP 模式下本地 DNS 入站的默认端口
+const DefaultDNSListenPort = 10853
+
+// DNSOptions 域名解析设置：对应 xray 的 dns 配置，以及 Fake IP 模式下的本地 DNS 入站。
+type DNSOptions struct {
+	Mode         DNSMode  `json:"mode"`                  // 解析方式
+	Servers      []string `json:"servers,omitempty"`     // 自定义 DNS 服务器，按顺序使用；为空时使用系统 DNS
+	DisableCache bool     `json:"disable_cache"`         // 关闭 DNS 缓存（默认缓存）
+	ListenPort   int      `json:"listen_port,omitempty"` // Fake IP 模式下本地 DNS 入站端口（仅监听 127.0.0.1）
+}
+
+// DefaultDNSOptions 返回默认的解析设置：远程解析，直连流量使用系统 DNS。
+func DefaultDNSOptions() DNSOptions {
+	return DNSOptions{Mode: DNSModeRemote, ListenPort: DefaultDNSListenPort}
+}
+
+// IsDefault 是否与默认设置等效（无需写入 xray 配置）。
+func (o DNSOptions) IsDefault() bool {
+	return o.Mode == DNSModeRemote && len(o.Servers) == 0 && !o.DisableCache
+}
+
+// Validate 校验解析方式、DNS 服务器地址和监听端口。
+func (o DNSOptions) Validate() error {
+	switch o.Mode {
+	case DNSModeRemote, DNSModeLocal, DNSModeFakeIP:
+	default:
+		return fmt.Errorf("未知的解析方式: %s", o.Mode)
+	}
+	for _, s := range o.Servers {
+		if err := ValidateDNSServer(s); err != nil {
+			return err
+		}
+	}
+	if o.Mode == DNSModeFakeIP && (o.ListenPort < 1 || o.ListenPort > 65535) {
+		return fmt.Errorf("DNS 监听端口需在 1–65535 之间: %d", o.ListenPort)
+	}
+	return nil
+}
+
+// ValidateDNSServer 校验 DNS 服务器地址，格式与 xray 一致：
+// IP（UDP 53）、IP:端口、tcp://、tcp+local://、https://（DoH）、https+local://、quic+local:// 或 localhost（系统 DNS）。
+func ValidateDNSServer(s string) error {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return fmt.Errorf("DNS 服务器不能为空")
+	}
+	if s == "localhost" {
+		return nil
+	}
+	scheme, rest, hasScheme := strings.Cut(s, "://")
+	if !hasScheme {
+		if net.ParseIP(s) != nil {
+			return nil
+		}
+		if host, _, err := net.SplitHostPort(s); err == nil && net.ParseIP(host) != nil {
+			return nil
+		}
+		return fmt.Errorf("DNS 服务器地址无效（应为 IP 或带协议前缀的地址）: %s", s)
+	}
+	switch scheme {
+	case "tcp", "tcp+local", "https", "https+local", "quic+local":
+	case "tls":
+		return fmt.Errorf("xray 不支持 DoT（tls://），请改用 DoH（https://）: %s", s)
+	default:
+		return fmt.Errorf("DNS 服务器: %w %s: %s", errs.ErrUnsupportedProtocol, scheme, s)
+	}
+	host, _, _ := strings.Cut(rest, "/")
+	if host == "" {
+		return fmt.Errorf("DNS 服务器缺少主机: %s", s)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package model
+
+// HookEvent 生命周期事件类型，插件与事件脚本按事件订阅。
+type HookEvent string
+
+const (
+	HookEventProxyStarted        HookEvent = "proxy-started"        // 代理已启动
+	HookEventProxyStopped        HookEvent = "proxy-stopped"        // 代理已停止
+	HookEventNodeSwitched        HookEvent = "node-switched"        // 运行中切换了节点
+	HookEventSubscriptionUpdated HookEvent = "subscription-updated" // 订阅已更新
+)
+
+// AllHookEvents 全部生命周期事件（顺序固定，用于界面展示）。
+var AllHookEvents = []HookEvent{
+	HookEventProxyStarted,
+	HookEventProxyStopped,
+	HookEventNodeSwitched,
+	HookEventSubscriptionUpdated,
+}
+
+// Valid 判断是否为已知事件。
+func (e HookEvent) Valid() bool {
+	for _, ev := range AllHookEvents {
+		if e == ev {
+			return true
+		}
+	}
+	return false
+}
+
+// HookScript 事件脚本：事件发生时执行的外部程序，事件内容以 JSON 写入其标准输入。
+type HookScript struct {
+	Path    string      `json:"path"`             // 可执行文件路径
+	Events  []HookEvent `json:"events,omitempty"` // 订阅的事件，为空表示全部事件
+	Enabled bool        `json:"enabled"`          // 是否启用
+}
+
+// Subscribes 判断脚本是否订阅了指定事件。
+func (s HookScript) Subscribes(event HookEvent) bool {
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, e := range s.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
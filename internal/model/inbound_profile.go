@@ -0,0 +1,46 @@
+package model
+
+import "fmt"
+
+// MainInboundPort 主入站（规则模式 SOCKS5）固定监听端口
+const MainInboundPort = 10808
+
+// InboundMode 入站的路由模式。
+type InboundMode string
+
+const (
+	InboundModeRule   InboundMode = "rule"   // 按路由规则分流（与主入站一致）
+	InboundModeGlobal InboundMode = "global" // 除本地地址外全部走代理
+)
+
+// Valid 判断模式是否为已知取值。
+func (m InboundMode) Valid() bool {
+	return m == InboundModeRule || m == InboundModeGlobal
+}
+
+// InboundProtocol 入站协议。
+type InboundProtocol string
+
+const (
+	InboundProtocolSocks InboundProtocol = "socks" // SOCKS5
+	InboundProtocolHTTP  InboundProtocol = "http"  // HTTP 代理
+)
+
+// Valid 判断协议是否为已知取值。
+func (p InboundProtocol) Valid() bool {
+	return p == InboundProtocolSocks || p == InboundProtocolHTTP
+}
+
+// InboundProfile 额外的本地入站配置：与主入站同时运行，监听不同端口并使用各自的路由模式。
+type InboundProfile struct {
+	Name     string          `json:"name"`     // 名称
+	Port     int             `json:"port"`     // 本地监听端口（127.0.0.1）
+	Protocol InboundProtocol `json:"protocol"` // 协议：socks / http
+	Mode     InboundMode     `json:"mode"`     // 路由模式：rule / global
+	Enabled  bool            `json:"enabled"`  // 是否启用
+}
+
+// Tag 返回入站在 xray 配置中的 tag。
+func (p *InboundProfile) Tag() string {
+	return fmt.Sprintf("in-%s-%d", p.Protocol, p.Port)
+}
@@ -11,7 +11,17 @@ type Node struct {
 	Delay        int    `json:"delay"`         // 延迟（毫秒）
 	Selected     bool   `json:"selected"`      // 是否被选中
 	Enabled      bool   `json:"enabled"`       // 是否启用
-	ProtocolType string `json:"protocol_type"` // 协议类型: vmess, ss, ssr, socks5, etc.
+	ProtocolType string `json:"protocol_type"` // 协议类型: vmess, vless, ss, ssr, socks5, etc.
+
+	// 连接历史
+	LastConnectedAt  int64 `json:"last_connected_at,omitempty"` // 最近一次连接时间（Unix 秒，0 表示从未连接）
+	ConnectedSeconds int64 `json:"connected_seconds,omitempty"` // 累计连接时长（秒）
+
+	// 用户备注（如 "2025-03 到期"、"仅用于流媒体"），订阅更新时保留
+	Notes string `json:"notes,omitempty"`
+
+	// 用户修改过的订阅节点（重命名、调整传输等），订阅更新时按冲突策略处理而不是直接覆盖
+	UserModified bool `json:"user_modified,omitempty"`
 
 	// VMess 协议字段
 	VMessVersion  string `json:"vmess_version,omitempty"`  // VMess 版本 (v)
@@ -41,6 +51,131 @@ type Node struct {
 	TrojanAlpn          string `json:"trojan_alpn,omitempty"`           // Trojan ALPN
 	TrojanAllowInsecure bool   `json:"trojan_allow_insecure,omitempty"` // Trojan 是否允许不安全连接
 
+	// VLESS 协议字段（vless:// 链接的参数）
+	VLESSUUID          string `json:"vless_uuid,omitempty"`           // VLESS 用户 ID
+	VLESSEncryption    string `json:"vless_encryption,omitempty"`     // 加密方式 (encryption)，通常为 none
+	VLESSFlow          string `json:"vless_flow,omitempty"`           // 流控 (flow)，如 xtls-rprx-vision
+	VLESSNetwork       string `json:"vless_network,omitempty"`        // 传输协议 (type): tcp, ws, grpc, httpupgrade, xhttp
+	VLESSHeaderType    string `json:"vless_header_type,omitempty"`    // tcp 伪装类型 (headerType): none, http
+	VLESSHost          string `json:"vless_host,omitempty"`           // 伪装域名 (host)
+	VLESSPath          string `json:"vless_path,omitempty"`           // 路径 (path)，grpc 为 serviceName
+	VLESSMode          string `json:"vless_mode,omitempty"`           // grpc / xhttp 模式 (mode)
+	VLESSSecurity      string `json:"vless_security,omitempty"`       // 传输层安全 (security): none, tls, reality
+	VLESSSNI           string `json:"vless_sni,omitempty"`            // SNI (sni)
+	VLESSAlpn          string `json:"vless_alpn,omitempty"`           // ALPN (alpn)，逗号分隔
+	VLESSFingerprint   string `json:"vless_fingerprint,omitempty"`    // TLS 指纹 (fp)，如 chrome
+	VLESSAllowInsecure bool   `json:"vless_allow_insecure,omitempty"` // 是否允许不安全连接
+	VLESSPublicKey     string `json:"vless_public_key,omitempty"`     // REALITY 公钥 (pbk)
+	VLESSShortID       string `json:"vless_short_id,omitempty"`       // REALITY ShortId (sid)
+	VLESSSpiderX       string `json:"vless_spider_x,omitempty"`       // REALITY SpiderX (spx)
+
 	// 原始配置 JSON（用于存储完整的协议配置，便于未来扩展）
 	RawConfig string `json:"raw_config,omitempty"` // 原始配置 JSON 字符串
+
+	// 用户覆盖：部分服务商要求的 SNI / Host 与分享链接中的不同，非空时优先于解析出的值，订阅更新时保留
+	OverrideSNI  string `json:"override_sni,omitempty"`  // 覆盖 TLS SNI
+	OverrideHost string `json:"override_host,omitempty"` // 覆盖 ws / h2 Host 头（VMess、VLESS）
+	OverridePath string `json:"override_path,omitempty"` // 覆盖 ws / h2 路径、grpc serviceName（VMess、VLESS）
+}
+
+// HasOverrides 是否设置了任何用户覆盖。
+func (n Node) HasOverrides() bool {
+	return n.OverrideSNI != "" || n.OverrideHost != "" || n.OverridePath != ""
+}
+
+// EffectiveHost 返回生成出站配置时使用的 Host：覆盖值优先，否则为链接中的伪装域名。
+func (n Node) EffectiveHost() string {
+	if n.OverrideHost != "" {
+		return n.OverrideHost
+	}
+	if n.ProtocolType == "vless" {
+		return n.VLESSHost
+	}
+	return n.VMessHost
+}
+
+// EffectivePath 返回生成出站配置时使用的路径：覆盖值优先，否则为链接中的路径。
+func (n Node) EffectivePath() string {
+	if n.OverridePath != "" {
+		return n.OverridePath
+	}
+	if n.ProtocolType == "vless" {
+		return n.VLESSPath
+	}
+	return n.VMessPath
+}
+
+// EffectiveSNI 返回 TLS 握手使用的 SNI：覆盖值优先；否则 Trojan 使用链接中的 SNI，
+// VLESS 使用链接中的 SNI（未设置时同 Host），VMess 与以往一致使用 Host（含 Host 覆盖）。
+func (n Node) EffectiveSNI() string {
+	if n.OverrideSNI != "" {
+		return n.OverrideSNI
+	}
+	if n.ProtocolType == "trojan" {
+		return n.TrojanSNI
+	}
+	if n.ProtocolType == "vless" && n.VLESSSNI != "" {
+		return n.VLESSSNI
+	}
+	return n.EffectiveHost()
+}
+
+// WithOverridesApplied 返回把覆盖值写入对应解析字段、并清空覆盖后的节点副本，
+// 用于需要直接调整解析字段的场景（如自动诊断）。
+func (n Node) WithOverridesApplied() Node {
+	if !n.HasOverrides() {
+		return n
+	}
+	sni := n.EffectiveSNI()
+	if n.ProtocolType == "vless" {
+		n.VLESSSNI, n.VLESSHost, n.VLESSPath = sni, n.EffectiveHost(), n.EffectivePath()
+		n.OverrideSNI, n.OverrideHost, n.OverridePath = "", "", ""
+		return n
+	}
+	n.VMessHost, n.VMessPath = n.EffectiveHost(), n.EffectivePath()
+	if n.ProtocolType == "trojan" {
+		n.TrojanSNI = sni
+	} else if sni != n.VMessHost && n.VMessTLS == "tls" {
+		// VMess 没有独立的 SNI 字段，SNI 与 Host 不同时只能保留为覆盖
+		n.OverrideSNI, n.OverrideHost, n.OverridePath = sni, "", ""
+		return n
+	}
+	n.OverrideSNI, n.OverrideHost, n.OverridePath = "", "", ""
+	return n
+}
+
+// LatencyStats 一次测速的多次采样统计（毫秒），列表显示中位数，详情显示最小/中位/P95。
+type LatencyStats struct {
+	Min      int `json:"min"`      // 最小延迟
+	Median   int `json:"median"`   // 中位数延迟
+	P95      int `json:"p95"`      // P95 延迟
+	Samples  int `json:"samples"`  // 成功的采样次数
+	Failures int `json:"failures"` // 失败的采样次数
+
+	Phases DialPhases `json:"phases"` // 首次采样的分阶段耗时
+}
+
+// 测速连接阶段
+const (
+	DialPhaseDNS = "dns" // DNS 解析
+	DialPhaseTCP = "tcp" // TCP 连接
+	DialPhaseTLS = "tls" // TLS 握手
+)
+
+// DialPhases 一次测速连接的分阶段耗时（毫秒），用于判断慢或失败发生在 DNS、TCP 还是 TLS 阶段。
+type DialPhases struct {
+	DNS      int    `json:"dns"`                 // DNS 解析耗时（地址为 IP 时为 0）
+	TCP      int    `json:"tcp"`                 // TCP 连接耗时
+	TLS      int    `json:"tls"`                 // TLS 握手耗时（HasTLS 为 false 时无意义）
+	HasTLS   bool   `json:"has_tls"`             // 节点是否使用 TLS
+	FailedAt string `json:"failed_at,omitempty"` // 失败的阶段（DialPhase*），成功时为空
+	Error    string `json:"error,omitempty"`     // 失败原因
+}
+
+// Delay 返回用于列表显示和自动选择的延迟：中位数，全部采样失败时为 -1。
+func (s LatencyStats) Delay() int {
+	if s.Samples == 0 {
+		return -1
+	}
+	return s.Median
 }
@@ -0,0 +1,28 @@
+package model
+
+// NodeSortField 节点查询的排序字段。
+type NodeSortField string
+
+const (
+	NodeSortCreated       NodeSortField = "created"        // 添加时间
+	NodeSortName          NodeSortField = "name"           // 名称
+	NodeSortDelay         NodeSortField = "delay"          // 延迟（未测速和超时的排在最后）
+	NodeSortLastConnected NodeSortField = "last_connected" // 最近连接时间
+)
+
+// NodeQuery 节点过滤与分页查询条件，零值表示不过滤、按添加时间倒序返回全部节点。
+type NodeQuery struct {
+	SubscriptionID *int64        // 所属订阅，nil 表示不限；指向 0 表示手动添加（无订阅）的节点
+	Protocol       string        // 协议类型（如 vmess），空表示不限
+	Enabled        *bool         // 是否启用，nil 表示不限
+	Text           string        // 关键字，匹配名称、地址、协议和备注（不区分大小写）
+	Sort           NodeSortField // 排序字段，空表示按添加时间倒序（与节点列表默认顺序一致）并忽略 Desc
+	Desc           bool          // 是否倒序
+	Limit          int           // 最多返回条数，<= 0 表示不限
+	Offset         int           // 跳过的条数
+}
+
+// HasFilter 是否设置了过滤条件（不含排序和分页）。
+func (q NodeQuery) HasFilter() bool {
+	return q.SubscriptionID != nil || q.Protocol != "" || q.Enabled != nil || q.Text != ""
+}
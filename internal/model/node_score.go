@@ -0,0 +1,94 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	// scoreLatencyCeiling 延迟达到该值（毫秒）时延迟分为 0
+	scoreLatencyCeiling = 1000
+	// scoreJitterCeiling 抖动（P95 - 最小延迟）达到该值（毫秒）时抖动分为 0
+	scoreJitterCeiling = 300
+	// NodeScoreMaxWeight 单项权重上限
+	NodeScoreMaxWeight = 10
+)
+
+// NodeScoreWeights 节点综合评分的权重：延迟、抖动、历史成功率和地区偏好按权重加权平均，得分 0–100，越高越好。
+// 缺少数据的项（如只采样一次没有抖动）不参与加权，未设置偏好地区时地区项不参与。
+type NodeScoreWeights struct {
+	Latency          float64  `json:"latency"`                     // 延迟（中位数）
+	Jitter           float64  `json:"jitter"`                      // 抖动（P95 与最小延迟之差）
+	Success          float64  `json:"success"`                     // 历史测速成功率
+	Region           float64  `json:"region"`                      // 地区偏好
+	PreferredRegions []string `json:"preferred_regions,omitempty"` // 偏好地区关键字，节点名称包含任一关键字即视为匹配（不区分大小写）
+	UseForSelection  bool     `json:"use_for_selection"`           // 自动选择备选节点时按评分而不是延迟
+}
+
+// DefaultNodeScoreWeights 返回默认权重：以延迟为主，兼顾成功率和抖动，不按地区偏好。
+func DefaultNodeScoreWeights() NodeScoreWeights {
+	return NodeScoreWeights{Latency: 5, Jitter: 2, Success: 3}
+}
+
+// Validate 校验各项权重在 0–NodeScoreMaxWeight 之间且不全为 0。
+func (w NodeScoreWeights) Validate() error {
+	names := []string{"延迟", "抖动", "成功率", "地区"}
+	for i, v := range []float64{w.Latency, w.Jitter, w.Success, w.Region} {
+		if v < 0 || v > NodeScoreMaxWeight || math.IsNaN(v) {
+			return fmt.Errorf("%s权重需在 0–%d 之间: %g", names[i], NodeScoreMaxWeight, v)
+		}
+	}
+	if w.Latency+w.Jitter+w.Success+w.Region == 0 {
+		return fmt.Errorf("权重不能全为 0")
+	}
+	return nil
+}
+
+// MatchesRegion 节点名称是否包含任一偏好地区关键字。
+func (w NodeScoreWeights) MatchesRegion(name string) bool {
+	name = strings.ToLower(name)
+	for _, r := range w.PreferredRegions {
+		if r = strings.ToLower(strings.TrimSpace(r)); r != "" && strings.Contains(name, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Score 计算节点综合评分。
+// 参数：
+//   - node: 节点，延迟取 node.Delay
+//   - stats: 最近一次测速的采样统计，nil 表示没有（抖动项不参与）
+//   - successRate: 历史测速成功率（0–1），小于 0 表示没有记录（成功率项不参与）
+//
+// 返回：评分（0–100）和是否可评分（未测速或测速失败的节点不可评分）
+func (w NodeScoreWeights) Score(node Node, stats *LatencyStats, successRate float64) (int, bool) {
+	if node.Delay <= 0 {
+		return 0, false
+	}
+	var sum, total float64
+	add := func(weight, value float64) {
+		sum += weight * math.Max(0, math.Min(1, value))
+		total += weight
+	}
+	add(w.Latency, 1-float64(node.Delay)/scoreLatencyCeiling)
+	if stats != nil && stats.Samples > 1 {
+		add(w.Jitter, 1-float64(stats.P95-stats.Min)/scoreJitterCeiling)
+	}
+	if successRate >= 0 {
+		add(w.Success, successRate)
+	}
+	if len(w.PreferredRegions) > 0 {
+		region := 0.0
+		if w.MatchesRegion(node.Name) {
+			region = 1
+		}
+		add(w.Region, region)
+	}
+	if total == 0 {
+		// 仅设置了当前缺少数据的项时按延迟评分
+		return int(math.Round(100 * math.Max(0, 1-float64(node.Delay)/scoreLatencyCeiling))), true
+	}
+	return int(math.Round(100 * sum / total)), true
+}
@@ -0,0 +1,13 @@
+package model
+
+// OutboundBinding 出站连接绑定：多网卡机器上指定出站使用的网卡或源 IP。
+// 对应 xray 出站的 streamSettings.sockopt.interface 与 sendThrough，均为空表示不绑定。
+type OutboundBinding struct {
+	Interface   string `json:"interface,omitempty"`    // 网卡名称（如 en0、eth1）
+	SendThrough string `json:"send_through,omitempty"` // 源 IP 地址
+}
+
+// IsZero 判断是否未设置任何绑定。
+func (b OutboundBinding) IsZero() bool {
+	return b.Interface == "" && b.SendThrough == ""
+}
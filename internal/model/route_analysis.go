@@ -0,0 +1,205 @@
+package model
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// RouteIssueKind 路由规则静态检查发现的问题类型。
+type RouteIssueKind string
+
+const (
+	RouteIssueDuplicate RouteIssueKind = "duplicate" // 与前面的规则完全相同
+	RouteIssueShadowed  RouteIssueKind = "shadowed"  // 被前面的规则完全覆盖，永远不会匹配
+	RouteIssueConflict  RouteIssueKind = "conflict"  // 与前面的规则目标相同但动作不同，部分流量先被前者匹配
+)
+
+// RouteIssue 一条规则的检查结果。
+type RouteIssue struct {
+	Index int            // 有问题的规则下标
+	By    int            // 导致问题的前面规则的下标
+	Kind  RouteIssueKind // 问题类型
+}
+
+// Message 返回问题说明（规则序号从 1 开始，与列表显示一致）。
+func (i RouteIssue) Message() string {
+	switch i.Kind {
+	case RouteIssueDuplicate:
+		return fmt.Sprintf("与第 %d 条规则重复", i.By+1)
+	case RouteIssueShadowed:
+		return fmt.Sprintf("被第 %d 条规则完全覆盖，永远不会生效", i.By+1)
+	case RouteIssueConflict:
+		return fmt.Sprintf("与第 %d 条规则目标相同但动作不同，重叠部分按第 %d 条处理", i.By+1, i.By+1)
+	}
+	return ""
+}
+
+// AnalyzeRouteRules 静态检查路由规则列表（按顺序匹配）：找出重复、被前面规则完全覆盖以及
+// 同一目标动作冲突的规则。每条规则只报告最先发现的一个问题，无法判断的写法（geosite、regexp 等）
+// 仅在与前面规则完全相同时报告。
+// 返回：问题列表，按规则下标升序
+func AnalyzeRouteRules(rules []RouteRule) []RouteIssue {
+	var issues []RouteIssue
+	for j := 1; j < len(rules); j++ {
+		b := rules[j]
+		var conflict *RouteIssue
+		for i := 0; i < j; i++ {
+			a := rules[i]
+			if routeRuleEqual(a, b) {
+				if a.Action == b.Action {
+					issues = append(issues, RouteIssue{Index: j, By: i, Kind: RouteIssueDuplicate})
+				} else {
+					issues = append(issues, RouteIssue{Index: j, By: i, Kind: RouteIssueShadowed})
+				}
+				conflict = nil
+				break
+			}
+			if routeRuleCovers(a, b) {
+				kind := RouteIssueShadowed
+				// 互相覆盖且动作相同（如端口列表顺序不同、IP 与 /32）视为重复
+				if a.Action == b.Action && routeRuleCovers(b, a) {
+					kind = RouteIssueDuplicate
+				}
+				issues = append(issues, RouteIssue{Index: j, By: i, Kind: kind})
+				conflict = nil
+				break
+			}
+			if conflict == nil && a.Action != b.Action && routeTargetKey(a.Target) == routeTargetKey(b.Target) &&
+				routeNetworkOverlaps(a.Network, b.Network) && routePortsOverlap(a.Port, b.Port) {
+				conflict = &RouteIssue{Index: j, By: i, Kind: RouteIssueConflict}
+			}
+		}
+		if conflict != nil {
+			issues = append(issues, *conflict)
+		}
+	}
+	return issues
+}
+
+// routeTargetKey 规范化目标用于比较（去空白、小写）。
+func routeTargetKey(target string) string {
+	return strings.ToLower(strings.TrimSpace(target))
+}
+
+// routeRuleEqual 判断两条规则的匹配条件是否完全相同（不比较动作）。
+func routeRuleEqual(a, b RouteRule) bool {
+	portA, _ := NormalizeRoutePort(a.Port)
+	portB, _ := NormalizeRoutePort(b.Port)
+	return routeTargetKey(a.Target) == routeTargetKey(b.Target) && portA == portB && a.Network == b.Network
+}
+
+// routeRuleCovers 判断规则 a 是否覆盖规则 b 的全部匹配范围（目标、端口、协议均覆盖）。
+func routeRuleCovers(a, b RouteRule) bool {
+	if a.Network != "" && a.Network != b.Network {
+		return false
+	}
+	if !routePortsCover(a.Port, b.Port) {
+		return false
+	}
+	return routeTargetCovers(a.Target, b.Target)
+}
+
+// routeTargetCovers 判断目标 a 是否覆盖目标 b：空目标匹配任意地址；
+// domain:x 覆盖 x 及其子域（domain: / full:）；CIDR 覆盖其范围内的 IP 与更小网段。
+func routeTargetCovers(a, b string) bool {
+	a, b = routeTargetKey(a), routeTargetKey(b)
+	if a == "" {
+		return true
+	}
+	if b == "" {
+		return false
+	}
+	if a == b {
+		return true
+	}
+	if suffix, ok := strings.CutPrefix(a, "domain:"); ok {
+		host, ok := strings.CutPrefix(b, "domain:")
+		if !ok {
+			host, ok = strings.CutPrefix(b, "full:")
+		}
+		return ok && (host == suffix || strings.HasSuffix(host, "."+suffix))
+	}
+	netA := parseRouteCIDR(a)
+	netB := parseRouteCIDR(b)
+	if netA == nil || netB == nil {
+		return false
+	}
+	onesA, bitsA := netA.Mask.Size()
+	onesB, bitsB := netB.Mask.Size()
+	return bitsA == bitsB && onesA <= onesB && netA.Contains(netB.IP)
+}
+
+// parseRouteCIDR 将 IP 或 CIDR 目标解析为网段，非 IP 目标返回 nil。
+func parseRouteCIDR(s string) *net.IPNet {
+	if _, n, err := net.ParseCIDR(s); err == nil {
+		return n
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return &net.IPNet{IP: v4, Mask: net.CIDRMask(32, 32)}
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+}
+
+// routeNetworkOverlaps 判断两条规则的协议条件是否有交集。
+func routeNetworkOverlaps(a, b string) bool {
+	return a == "" || b == "" || a == b
+}
+
+// routePortRanges 将端口表达式解析为闭区间列表，空表达式表示全部端口。
+func routePortRanges(port string) [][2]int {
+	port, ok := NormalizeRoutePort(port)
+	if !ok || port == "" {
+		return [][2]int{{1, 65535}}
+	}
+	var ranges [][2]int
+	for _, part := range strings.Split(port, ",") {
+		lo, hi, isRange := strings.Cut(part, "-")
+		from, _ := strconv.Atoi(lo)
+		to := from
+		if isRange {
+			to, _ = strconv.Atoi(hi)
+		}
+		ranges = append(ranges, [2]int{from, to})
+	}
+	return ranges
+}
+
+// routePortsCover 判断端口表达式 a 是否覆盖 b 的全部端口。
+func routePortsCover(a, b string) bool {
+	rangesA := routePortRanges(a)
+	for _, rb := range routePortRanges(b) {
+		// 逐段推进：b 的每个区间都需被 a 的区间并集连续覆盖
+		next := rb[0]
+		for progressed := true; progressed && next <= rb[1]; {
+			progressed = false
+			for _, ra := range rangesA {
+				if ra[0] <= next && ra[1] >= next {
+					next = ra[1] + 1
+					progressed = true
+				}
+			}
+		}
+		if next <= rb[1] {
+			return false
+		}
+	}
+	return true
+}
+
+// routePortsOverlap 判断两个端口表达式是否有交集。
+func routePortsOverlap(a, b string) bool {
+	for _, ra := range routePortRanges(a) {
+		for _, rb := range routePortRanges(b) {
+			if ra[0] <= rb[1] && rb[0] <= ra[1] {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnalyzeRouteRules(t *testing.T) {
+	direct, proxy, block := RouteActionDirect, RouteActionProxy, RouteActionBlock
+	tests := []struct {
+		name  string
+		rules []RouteRule
+		want  []RouteIssue
+	}{
+		{
+			name: "没有问题",
+			rules: []RouteRule{
+				{Target: "domain:example.com", Action: direct},
+				{Target: "domain:example.org", Action: proxy},
+			},
+		},
+		{
+			name: "重复（忽略大小写与空白）",
+			rules: []RouteRule{
+				{Target: "domain:Example.com", Action: direct},
+				{Target: " domain:example.com ", Action: direct},
+			},
+			want: []RouteIssue{{Index: 1, By: 0, Kind: RouteIssueDuplicate}},
+		},
+		{
+			name: "端口顺序不同也算重复",
+			rules: []RouteRule{
+				{Target: "domain:example.com", Port: "443,80", Action: direct},
+				{Target: "domain:example.com", Port: "80, 443", Action: direct},
+			},
+			want: []RouteIssue{{Index: 1, By: 0, Kind: RouteIssueDuplicate}},
+		},
+		{
+			name: "IP 与 /32 网段重复",
+			rules: []RouteRule{
+				{Target: "1.2.3.4", Action: block},
+				{Target: "1.2.3.4/32", Action: block},
+			},
+			want: []RouteIssue{{Index: 1, By: 0, Kind: RouteIssueDuplicate}},
+		},
+		{
+			name: "条件相同动作不同视为被覆盖",
+			rules: []RouteRule{
+				{Target: "domain:example.com", Action: direct},
+				{Target: "domain:example.com", Action: block},
+			},
+			want: []RouteIssue{{Index: 1, By: 0, Kind: RouteIssueShadowed}},
+		},
+		{
+			name: "子域被 domain 覆盖",
+			rules: []RouteRule{
+				{Target: "domain:example.com", Action: proxy},
+				{Target: "full:www.example.com", Action: direct},
+				{Target: "domain:cdn.example.com", Action: block},
+				{Target: "domain:notexample.com", Action: block},
+			},
+			want: []RouteIssue{
+				{Index: 1, By: 0, Kind: RouteIssueShadowed},
+				{Index: 2, By: 0, Kind: RouteIssueShadowed},
+			},
+		},
+		{
+			name: "IP 与小网段被大网段覆盖",
+			rules: []RouteRule{
+				{Target: "10.0.0.0/8", Action: direct},
+				{Target: "10.1.0.0/16", Action: proxy},
+				{Target: "10.2.3.4", Action: block},
+				{Target: "11.0.0.0/16", Action: block},
+			},
+			want: []RouteIssue{
+				{Index: 1, By: 0, Kind: RouteIssueShadowed},
+				{Index: 2, By: 0, Kind: RouteIssueShadowed},
+			},
+		},
+		{
+			name: "空目标覆盖所有规则",
+			rules: []RouteRule{
+				{Port: "22", Action: block},
+				{Target: "domain:example.com", Port: "22", Action: direct},
+			},
+			want: []RouteIssue{{Index: 1, By: 0, Kind: RouteIssueShadowed}},
+		},
+		{
+			name: "不带端口的规则覆盖带端口的规则",
+			rules: []RouteRule{
+				{Target: "domain:example.com", Action: proxy},
+				{Target: "domain:example.com", Port: "443", Network: "tcp", Action: direct},
+			},
+			want: []RouteIssue{{Index: 1, By: 0, Kind: RouteIssueShadowed}},
+		},
+		{
+			name: "端口区间覆盖单个端口与列表",
+			rules: []RouteRule{
+				{Target: "domain:example.com", Port: "1000-2000", Action: proxy},
+				{Target: "domain:example.com", Port: "1500", Action: direct},
+				{Target: "domain:example.com", Port: "1000,1999-2000", Action: block},
+			},
+			want: []RouteIssue{
+				{Index: 1, By: 0, Kind: RouteIssueShadowed},
+				{Index: 2, By: 0, Kind: RouteIssueShadowed},
+			},
+		},
+		{
+			name: "相邻区间拼接后覆盖",
+			rules: []RouteRule{
+				{Target: "domain:example.com", Port: "1-100,101-200", Action: proxy},
+				{Target: "domain:example.com", Port: "50-150", Action: direct},
+			},
+			want: []RouteIssue{{Index: 1, By: 0, Kind: RouteIssueShadowed}},
+		},
+		{
+			name: "端口部分重叠且动作不同为冲突",
+			rules: []RouteRule{
+				{Target: "domain:example.com", Port: "1000-2000", Action: proxy},
+				{Target: "domain:example.com", Port: "1500-2500", Action: direct},
+			},
+			want: []RouteIssue{{Index: 1, By: 0, Kind: RouteIssueConflict}},
+		},
+		{
+			name: "端口不重叠不报告",
+			rules: []RouteRule{
+				{Target: "domain:example.com", Port: "80", Action: proxy},
+				{Target: "domain:example.com", Port: "443", Action: direct},
+			},
+		},
+		{
+			name: "带协议的规则不覆盖其他协议",
+			rules: []RouteRule{
+				{Target: "domain:example.com", Network: "tcp", Action: proxy},
+				{Target: "domain:example.com", Network: "udp", Action: direct},
+				{Target: "domain:example.com", Action: block},
+			},
+			want: []RouteIssue{{Index: 2, By: 0, Kind: RouteIssueConflict}},
+		},
+		{
+			name: "同一协议覆盖",
+			rules: []RouteRule{
+				{Target: "domain:example.com", Network: "udp", Action: block},
+				{Target: "domain:example.com", Port: "443", Network: "udp", Action: proxy},
+			},
+			want: []RouteIssue{{Index: 1, By: 0, Kind: RouteIssueShadowed}},
+		},
+		{
+			name: "覆盖优先于先发现的冲突",
+			rules: []RouteRule{
+				{Target: "domain:example.com", Port: "80", Action: proxy},
+				{Target: "", Action: block},
+				{Target: "domain:example.com", Action: direct},
+			},
+			want: []RouteIssue{{Index: 2, By: 1, Kind: RouteIssueShadowed}},
+		},
+		{
+			name: "无法判断的写法只报告完全相同",
+			rules: []RouteRule{
+				{Target: "geosite:cn", Action: direct},
+				{Target: "geosite:geolocation-cn", Action: direct},
+				{Target: "geosite:cn", Action: direct},
+			},
+			want: []RouteIssue{{Index: 2, By: 0, Kind: RouteIssueDuplicate}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AnalyzeRouteRules(tt.rules)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AnalyzeRouteRules() = %+v，期望 %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+)
+
+// RouteAction 路由规则的动作，取值与 xray 出站 tag 一致。
+type RouteAction string
+
+const (
+	RouteActionDirect RouteAction = "direct" // 直连
+	RouteActionProxy  RouteAction = "proxy"  // 走代理
+	RouteActionBlock  RouteAction = "block"  // 拦截
+)
+
+// Valid 判断动作是否为已知取值。
+func (a RouteAction) Valid() bool {
+	switch a {
+	case RouteActionDirect, RouteActionProxy, RouteActionBlock:
+		return true
+	}
+	return false
+}
+
+// 路由规则的传输层协议，空字符串表示 TCP 与 UDP 均匹配。
+const (
+	RouteNetworkTCP = "tcp"
+	RouteNetworkUDP = "udp"
+)
+
+// RouteRule 用户路由规则：目标地址（domain:xxx 或 IP/CIDR）、目标端口、协议及其动作。
+// 目标、端口、协议同时设置时需全部满足；设置了端口或协议时目标可为空（匹配任意地址）。
+type RouteRule struct {
+	Target  string      `json:"target"`            // 匹配目标
+	Port    string      `json:"port,omitempty"`    // 目标端口：单个端口、范围（1000-2000）或逗号分隔列表
+	Network string      `json:"network,omitempty"` // 协议：tcp / udp，空为两者
+	Action  RouteAction `json:"action"`            // 动作：direct / proxy / block
+}
+
+// HasPortMatch 判断规则是否带有端口或协议条件（此类规则在生成 xray 配置时不与其他规则合并）。
+func (r RouteRule) HasPortMatch() bool {
+	return r.Port != "" || r.Network != ""
+}
+
+// ValidRouteNetwork 判断协议取值是否有效（空、tcp、udp）。
+func ValidRouteNetwork(network string) bool {
+	switch network {
+	case "", RouteNetworkTCP, RouteNetworkUDP:
+		return true
+	}
+	return false
+}
+
+// NormalizeRoutePort 校验并规范化端口表达式（去除空白），格式与 xray 路由 port 字段一致：
+// 如 "443"、"1000-2000"、"53,443,1000-2000"。
+// 返回：规范化后的表达式和是否有效（空字符串视为有效，表示不限端口）
+func NormalizeRoutePort(port string) (string, bool) {
+	port = strings.TrimSpace(port)
+	if port == "" {
+		return "", true
+	}
+	parts := strings.Split(port, ",")
+	for i, part := range parts {
+		part = strings.ReplaceAll(part, " ", "")
+		lo, hi, isRange := strings.Cut(part, "-")
+		from, err := strconv.Atoi(lo)
+		if err != nil || from < 1 || from > 65535 {
+			return "", false
+		}
+		if isRange {
+			to, err := strconv.Atoi(hi)
+			if err != nil || to < from || to > 65535 {
+				return "", false
+			}
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, ","), true
+}
+
+// QUICBlockMode 拦截 QUIC（UDP 443）的范围。浏览器的 HTTP/3 连接被拦截后会回退到 TCP 上的 HTTPS，
+// 使基于 TLS 的规则和代理行为保持一致。
+type QUICBlockMode string
+
+const (
+	QUICBlockOff   QUICBlockMode = "off"   // 不拦截（默认）
+	QUICBlockProxy QUICBlockMode = "proxy" // 仅拦截会走代理的 QUIC 流量，直连的不受影响
+	QUICBlockAll   QUICBlockMode = "all"   // 拦截所有 QUIC 流量（本地地址除外）
+)
+
+// Valid 判断范围是否为已知取值。
+func (m QUICBlockMode) Valid() bool {
+	switch m {
+	case QUICBlockOff, QUICBlockProxy, QUICBlockAll:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package model
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"strings"
+)
+
+// RuleBundle 规则包：可从文件或 URL 导入的一组路由规则和定时拦截规则（如社区维护的规则模板）。
+// 发布者可用 ed25519 私钥对文件内容签名，签名以 Base64 保存在同名的 .sig 文件中。
+type RuleBundle struct {
+	Name        string      `json:"name"`                  // 名称
+	Description string      `json:"description,omitempty"` // 说明
+	Rules       []RouteRule `json:"rules,omitempty"`       // 路由规则，导入时追加到现有规则之后
+	TimeRules   []TimeRule  `json:"time_rules,omitempty"`  // 定时拦截规则，导入时追加
+}
+
+// Validate 校验规则包至少包含一条规则，且规则的动作、端口和协议有效。
+func (b RuleBundle) Validate() error {
+	if len(b.Rules) == 0 && len(b.TimeRules) == 0 {
+		return fmt.Errorf("规则包中没有规则")
+	}
+	for _, r := range b.Rules {
+		if !r.Action.Valid() {
+			return fmt.Errorf("路由规则 %s: 未知动作 %q", r.Target, r.Action)
+		}
+		if _, ok := NormalizeRoutePort(r.Port); !ok {
+			return fmt.Errorf("路由规则 %s: 端口格式无效 %q", r.Target, r.Port)
+		}
+		if !ValidRouteNetwork(r.Network) {
+			return fmt.Errorf("路由规则 %s: 未知协议 %q", r.Target, r.Network)
+		}
+	}
+	for _, r := range b.TimeRules {
+		if _, err := ParseClock(r.Start); err != nil {
+			return fmt.Errorf("定时规则 %s: %w", r.Name, err)
+		}
+		if _, err := ParseClock(r.End); err != nil {
+			return fmt.Errorf("定时规则 %s: %w", r.Name, err)
+		}
+	}
+	return nil
+}
+
+// TrustedKey 受信任的签名公钥，导入规则包时用于校验签名。
+type TrustedKey struct {
+	Name      string `json:"name"`       // 名称（如发布者）
+	PublicKey string `json:"public_key"` // ed25519 公钥：32 字节的 Base64 或 PEM（PUBLIC KEY）
+}
+
+// Key 解析公钥。
+func (k TrustedKey) Key() (ed25519.PublicKey, error) {
+	return ParseSigningPublicKey(k.PublicKey)
+}
+
+// Fingerprint 返回公钥指纹（SHA-256 前 8 字节的十六进制），便于与发布者核对；公钥无效时返回空字符串。
+func (k TrustedKey) Fingerprint() string {
+	key, err := k.Key()
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// ParseSigningPublicKey 解析 ed25519 公钥，支持 32 字节的 Base64 和 PEM 编码的 PKIX 公钥
+// （openssl pkey -pubout 的输出）。
+func ParseSigningPublicKey(s string) (ed25519.PublicKey, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, fmt.Errorf("公钥不能为空")
+	}
+	if block, _ := pem.Decode([]byte(s)); block != nil {
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("解析 PEM 公钥失败: %w", err)
+		}
+		key, ok := pub.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("不是 ed25519 公钥")
+		}
+		return key, nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("公钥不是有效的 Base64: %w", err)
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("公钥长度应为 %d 字节，实际 %d 字节", ed25519.PublicKeySize, len(raw))
+	}
+	return ed25519.PublicKey(raw), nil
+}
@@ -7,6 +7,38 @@ type Subscription struct {
 	ID        int64     `json:"id"`
 	URL       string    `json:"url"`
 	Label     string    `json:"label"`
+	Notes     string    `json:"notes,omitempty"` // 用户备注（如服务商、到期时间）
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
+
+// SkippedEntry 表示订阅解析时因无效而被跳过的一条原始内容。
+type SkippedEntry struct {
+	Raw    string `json:"raw"`    // 原始内容（一行链接或 JSON 条目）
+	Reason string `json:"reason"` // 跳过原因
+}
+
+// ConflictPolicy 订阅更新时用户修改过的节点与订阅新版本冲突的处理方式。
+type ConflictPolicy string
+
+const (
+	ConflictPolicyKeep      ConflictPolicy = "keep"      // 保留用户修改（默认）
+	ConflictPolicyOverwrite ConflictPolicy = "overwrite" // 使用订阅版本覆盖
+	ConflictPolicyFork      ConflictPolicy = "fork"      // 用户修改另存为独立节点，订阅节点使用新版本
+	ConflictPolicyAsk       ConflictPolicy = "ask"       // 暂时保留用户修改，由用户逐个处理
+)
+
+// Valid 判断是否为已知策略。
+func (p ConflictPolicy) Valid() bool {
+	switch p {
+	case ConflictPolicyKeep, ConflictPolicyOverwrite, ConflictPolicyFork, ConflictPolicyAsk:
+		return true
+	}
+	return false
+}
+
+// NodeConflict 订阅更新时的一处冲突：用户修改过的节点在订阅中也有了新版本。
+type NodeConflict struct {
+	Local  Node `json:"local"`  // 用户修改后的节点（数据库中的当前版本）
+	Remote Node `json:"remote"` // 订阅中的新版本
+}
@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// SystemChangeKind 本程序对系统所做改动的类别。
+type SystemChangeKind string
+
+const (
+	SystemChangeProxy    SystemChangeKind = "system_proxy"   // 系统代理设置
+	SystemChangeTerminal SystemChangeKind = "terminal_proxy" // 终端代理环境变量（shell 配置文件或用户环境变量）
+)
+
+// SystemChange 一项已生效、尚未撤销的系统改动，用于在设置中列出并一键撤销。
+type SystemChange struct {
+	Kind    SystemChangeKind `json:"kind"`
+	Targets []string         `json:"targets"` // 修改的文件、注册表项或网络服务设置
+	Time    time.Time        `json:"time"`    // 最近一次写入时间
+}
+
+// Label 返回改动类别的显示名称。
+func (k SystemChangeKind) Label() string {
+	switch k {
+	case SystemChangeProxy:
+		return "系统代理"
+	case SystemChangeTerminal:
+		return "终端代理"
+	}
+	return string(k)
+}
+
+// 系统代理模式名称：保存在配置项 systemProxyMode 中，界面和日志也使用同一名称。
+const (
+	SystemProxyModeNameClear    = "清除系统代理"
+	SystemProxyModeNameAuto     = "自动配置系统代理"
+	SystemProxyModeNameTerminal = "环境变量代理"
+)
@@ -0,0 +1,19 @@
+package model
+
+// StaleTerminalAction 启动时发现终端代理环境变量文件过期（指向本程序已不再使用的端口）时的处理方式。
+type StaleTerminalAction string
+
+const (
+	StaleTerminalAsk     StaleTerminalAction = "ask"     // 询问（默认）
+	StaleTerminalRefresh StaleTerminalAction = "refresh" // 自动改写为当前端口（代理未运行时改为删除）
+	StaleTerminalRemove  StaleTerminalAction = "remove"  // 自动删除
+)
+
+// Valid 判断处理方式是否为已知取值。
+func (a StaleTerminalAction) Valid() bool {
+	switch a {
+	case StaleTerminalAsk, StaleTerminalRefresh, StaleTerminalRemove:
+		return true
+	}
+	return false
+}
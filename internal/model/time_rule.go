@@ -0,0 +1,52 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimeRule 定时拦截规则，在指定时间段内拦截列出的域名或 IP。
+// Start/End 为 "HH:MM" 格式；End 早于 Start 表示跨午夜（如 22:00–07:00）。
+type TimeRule struct {
+	Name    string   `json:"name"`    // 规则名称
+	Start   string   `json:"start"`   // 开始时间 HH:MM
+	End     string   `json:"end"`     // 结束时间 HH:MM
+	Routes  []string `json:"routes"`  // 拦截目标（domain:xxx 或 IP/CIDR）
+	Enabled bool     `json:"enabled"` // 是否启用
+}
+
+// ParseClock 将 "HH:MM" 解析为当天的分钟数（0–1439）。
+func ParseClock(s string) (int, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil {
+		return 0, fmt.Errorf("时间格式无效: %s", s)
+	}
+	if h < 0 || h > 23 || m < 0 || m > 59 {
+		return 0, fmt.Errorf("时间超出范围: %s", s)
+	}
+	return h*60 + m, nil
+}
+
+// ActiveAt 判断规则在给定时刻是否生效。未启用或时间格式无效时返回 false。
+func (r *TimeRule) ActiveAt(t time.Time) bool {
+	if !r.Enabled {
+		return false
+	}
+	start, err := ParseClock(r.Start)
+	if err != nil {
+		return false
+	}
+	end, err := ParseClock(r.End)
+	if err != nil {
+		return false
+	}
+	now := t.Hour()*60 + t.Minute()
+	if start == end {
+		return false
+	}
+	if start < end {
+		return now >= start && now < end
+	}
+	// 跨午夜
+	return now >= start || now < end
+}
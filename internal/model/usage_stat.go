@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// UsageFeature 功能使用统计的功能标识。
+type UsageFeature string
+
+const (
+	UsageFeatureProxyStart         UsageFeature = "proxy_start"         // 启动代理
+	UsageFeatureSpeedTest          UsageFeature = "speed_test"          // 单节点测速
+	UsageFeatureSpeedTestAll       UsageFeature = "speed_test_all"      // 一键测速
+	UsageFeatureSubscriptionUpdate UsageFeature = "subscription_update" // 更新订阅
+	UsageFeatureSystemProxy        UsageFeature = "system_proxy"        // 切换系统代理模式
+	UsageFeatureTraySwitch         UsageFeature = "tray_switch"         // 托盘切换节点
+	UsageFeatureFailoverSwitch     UsageFeature = "failover_switch"     // 故障转移自动切换
+	UsageFeatureRouteEdit          UsageFeature = "route_edit"          // 编辑路由规则
+	UsageFeatureShare              UsageFeature = "share"               // 局域网分享节点
+)
+
+// UsageStat 功能使用统计：仅记录功能名称、次数和时间，不含任何节点、地址或凭据信息。
+type UsageStat struct {
+	Feature   UsageFeature `json:"feature"`    // 功能标识
+	Count     int64        `json:"count"`      // 累计使用次数
+	FirstUsed time.Time    `json:"first_used"` // 首次使用时间
+	LastUsed  time.Time    `json:"last_used"`  // 最近使用时间
+}
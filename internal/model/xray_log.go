@@ -0,0 +1,45 @@
+package model
+
+import "fmt"
+
+// xray 核心日志级别（对应 log.loglevel）
+const (
+	XrayLogLevelDebug   = "debug"
+	XrayLogLevelInfo    = "info"
+	XrayLogLevelWarning = "warning"
+	XrayLogLevelError   = "error"
+	XrayLogLevelNone    = "none"
+)
+
+// XrayLogLevels 全部可选的 xray 核心日志级别（由详细到关闭）
+var XrayLogLevels = []string{XrayLogLevelDebug, XrayLogLevelInfo, XrayLogLevelWarning, XrayLogLevelError, XrayLogLevelNone}
+
+// DefaultXrayAccessLogFile 访问日志默认文件（与应用日志分开，避免访问记录淹没应用日志）
+const DefaultXrayAccessLogFile = "xray-access.log"
+
+// XrayLogOptions xray 日志设置：核心日志级别与访问日志开关分别控制。
+// 核心日志写入应用日志；访问日志（每个连接一行）单独写入 AccessLogFile，并用于生成访问记录。
+type XrayLogOptions struct {
+	Level         string `json:"level"`           // 核心日志级别，见 XrayLogLevels
+	AccessLog     bool   `json:"access_log"`      // 是否开启访问日志；关闭后不再生成访问记录
+	AccessLogFile string `json:"access_log_file"` // 访问日志文件，为空时只生成访问记录不落盘
+}
+
+// DefaultXrayLogOptions 返回默认的 xray 日志设置：warning 级别，开启访问日志并写入独立文件。
+func DefaultXrayLogOptions() XrayLogOptions {
+	return XrayLogOptions{
+		Level:         XrayLogLevelWarning,
+		AccessLog:     true,
+		AccessLogFile: DefaultXrayAccessLogFile,
+	}
+}
+
+// Validate 校验日志级别是否有效。
+func (o XrayLogOptions) Validate() error {
+	for _, level := range XrayLogLevels {
+		if o.Level == level {
+			return nil
+		}
+	}
+	return fmt.Errorf("无效的 xray 日志级别: %s", o.Level)
+}
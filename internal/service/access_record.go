@@ -102,6 +102,18 @@ func (ars *AccessRecordService) RecordAccessBatchFromAddressCounts(addressCounts
 	return ars.store.AccessRecords.RecordAccessBatch(addressCounts)
 }
 
+// IsAccessLogLine 判断是否为 xray 访问日志行（[时间戳] from 来源 accepted|rejected 目标 ...），
+// 用于将访问日志与核心日志分开落盘。
+func IsAccessLogLine(line string) bool {
+	fields := strings.Fields(line)
+	for i := 0; i < len(fields) && i <= 2; i++ {
+		if fields[i] == "from" {
+			return len(fields) > i+3 && (fields[i+2] == "accepted" || fields[i+2] == "rejected")
+		}
+	}
+	return false
+}
+
 // extractAddressFromXrayAccessLine 从 xray 访问日志行提取 address (host:port)，保留端口信息。
 // 仅解析包含 "accepted" 的 xray 代理访问日志，排除 app 日志和 xray 启动等日志。
 // 规则：定位 "accepted" 后取其后的第一个 token 为 host:port，兼容有无时间戳两种格式：
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"myproxy.com/p/internal/utils"
+)
+
+// 省电与计费网络检测结果的缓存时间：Windows 上每次检测都要启动 PowerShell，不宜每个检测周期都执行
+const backgroundJobGateCacheTTL = 2 * time.Minute
+
+// BackgroundJobGate 后台定时任务（定时测速等）的电源与网络感知：省电模式或按流量计费网络（含手机热点）下暂停，
+// 两项判断可分别在设置中关闭。平台不支持检测时视为不暂停。
+type BackgroundJobGate struct {
+	config *ConfigService
+
+	mu           sync.Mutex
+	checkedAt    time.Time
+	batterySaver bool
+	metered      bool
+}
+
+// NewBackgroundJobGate 创建后台任务暂停判断。
+// 参数：
+//   - config: ConfigService，用于读取两项暂停开关
+func NewBackgroundJobGate(config *ConfigService) *BackgroundJobGate {
+	return &BackgroundJobGate{config: config}
+}
+
+// PauseReason 返回后台任务当前应暂停的原因，无需暂停时返回空字符串。
+func (g *BackgroundJobGate) PauseReason() string {
+	if g == nil || g.config == nil {
+		return ""
+	}
+	pauseOnSaver := g.config.GetPauseJobsOnBatterySaver()
+	pauseOnMetered := g.config.GetPauseJobsOnMetered()
+	if !pauseOnSaver && !pauseOnMetered {
+		return ""
+	}
+
+	batterySaver, metered := g.status()
+	switch {
+	case pauseOnSaver && batterySaver:
+		return "系统处于省电模式"
+	case pauseOnMetered && metered:
+		return "当前网络按流量计费"
+	}
+	return ""
+}
+
+// status 返回缓存的省电与计费网络状态，过期后重新检测（检测失败视为否）。
+func (g *BackgroundJobGate) status() (batterySaver, metered bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.checkedAt.IsZero() && time.Since(g.checkedAt) < backgroundJobGateCacheTTL {
+		return g.batterySaver, g.metered
+	}
+	g.batterySaver, _ = utils.BatterySaverActive()
+	g.metered, _ = utils.MeteredConnection()
+	g.checkedAt = time.Now()
+	return g.batterySaver, g.metered
+}
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// captureMaxConnections 单次调试捕获最多记录的连接数，超出后只统计不再记录明细
+	captureMaxConnections = 10000
+	// captureMaxDuration 调试捕获的最长时长
+	captureMaxDuration = 30 * time.Minute
+)
+
+// CaptureOutbounds 调试捕获统计流量的出站（与 xray 配置中的出站 tag 一致）
+var CaptureOutbounds = []string{"proxy", "direct", "block"}
+
+// CaptureConnection 调试捕获记录的一条连接（来自 xray 访问日志与路由日志）。
+type CaptureConnection struct {
+	Time        time.Time `json:"time"`
+	OffsetMs    int64     `json:"offset_ms"`      // 相对捕获开始的毫秒数
+	Source      string    `json:"source"`         // 来源地址（本机应用）
+	Destination string    `json:"destination"`    // 目标（tcp:host:port / udp:host:port）
+	Host        string    `json:"host"`           // 目标域名或 IP（不含协议与端口）
+	Inbound     string    `json:"inbound"`        // 入站 tag
+	Outbound    string    `json:"outbound"`       // 选择的出站 tag：proxy / direct / block
+	Rule        string    `json:"rule,omitempty"` // 命中的路由规则标签（如 user#3），空表示未经规则匹配
+	Rejected    bool      `json:"rejected,omitempty"`
+}
+
+// CaptureHost 按目标域名汇总的连接情况。
+type CaptureHost struct {
+	Host        string    `json:"host"`
+	Connections int       `json:"connections"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+	Outbounds   []string  `json:"outbounds"` // 出现过的出站（同一域名走了不同出站通常说明规则有问题）
+	Rules       []string  `json:"rules,omitempty"`
+}
+
+// CaptureTraffic 捕获期间各出站的流量（字节）。
+type CaptureTraffic struct {
+	Outbound string `json:"outbound"`
+	Upload   int64  `json:"upload"`
+	Download int64  `json:"download"`
+}
+
+// CaptureReport 调试捕获报告：时间窗口内的连接明细、按域名汇总和各出站流量。
+type CaptureReport struct {
+	StartedAt   time.Time           `json:"started_at"`
+	EndedAt     time.Time           `json:"ended_at"`
+	Node        string              `json:"node"`
+	Connections []CaptureConnection `json:"connections"`
+	Hosts       []CaptureHost       `json:"hosts"`
+	Traffic     []CaptureTraffic    `json:"traffic"`
+	Dropped     int                 `json:"dropped,omitempty"` // 超出上限未记录明细的连接数
+}
+
+// JSON 返回格式化的报告 JSON。
+func (r *CaptureReport) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// DebugCaptureService 调试捕获：在限定时间窗口内从 xray 日志中收集连接元数据（目标、时间、出站、命中规则），
+// 结束时生成结构化报告，用于排查某个网站经代理访问异常的原因。
+// 捕获期间需由调用方临时调高 xray 日志级别（见 XrayControlService.SetCaptureMode）。
+type DebugCaptureService struct {
+	mu          sync.Mutex
+	active      bool
+	node        string
+	startedAt   time.Time
+	deadline    time.Time
+	connections []CaptureConnection
+	dropped     int
+	pendingRule map[string]string // 目标 -> 命中的规则标签（路由日志先于访问日志输出）
+}
+
+// NewDebugCaptureService 创建调试捕获服务实例。
+func NewDebugCaptureService() *DebugCaptureService {
+	return &DebugCaptureService{}
+}
+
+// Start 开始捕获。
+// 参数：
+//   - node: 当前节点名称，写入报告
+//   - duration: 捕获时长，超过 captureMaxDuration 时按上限处理
+//
+// 返回：错误（已在捕获中时）
+func (dc *DebugCaptureService) Start(node string, duration time.Duration) error {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	if dc.active {
+		return fmt.Errorf("调试捕获: 已在进行中")
+	}
+	if duration <= 0 || duration > captureMaxDuration {
+		duration = captureMaxDuration
+	}
+	now := time.Now()
+	dc.active = true
+	dc.node = node
+	dc.startedAt = now
+	dc.deadline = now.Add(duration)
+	dc.connections = nil
+	dc.dropped = 0
+	dc.pendingRule = make(map[string]string)
+	return nil
+}
+
+// Active 是否正在捕获。
+func (dc *DebugCaptureService) Active() bool {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	return dc.active
+}
+
+// Progress 返回已记录的连接数和剩余时间（未在捕获时均为 0）。
+func (dc *DebugCaptureService) Progress() (connections int, remaining time.Duration) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	if !dc.active {
+		return 0, 0
+	}
+	remaining = time.Until(dc.deadline)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return len(dc.connections) + dc.dropped, remaining
+}
+
+// Feed 处理一行 xray 原始日志：路由日志记下命中的规则，访问日志生成连接记录。
+// 未在捕获或已过截止时间时直接忽略。
+func (dc *DebugCaptureService) Feed(line string) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	if !dc.active || time.Now().After(dc.deadline) {
+		return
+	}
+
+	if rule, dest, ok := parseRouteHitLine(line); ok {
+		dc.pendingRule[dest] = rule
+		return
+	}
+	conn, ok := parseCaptureAccessLine(line)
+	if !ok {
+		return
+	}
+	if conn.Time.Before(dc.startedAt) {
+		conn.Time = time.Now()
+	}
+	conn.OffsetMs = conn.Time.Sub(dc.startedAt).Milliseconds()
+	if rule, ok := dc.pendingRule[conn.Destination]; ok {
+		conn.Rule = rule
+		delete(dc.pendingRule, conn.Destination)
+	}
+	if len(dc.connections) >= captureMaxConnections {
+		dc.dropped++
+		return
+	}
+	dc.connections = append(dc.connections, conn)
+}
+
+// Stop 结束捕获并生成报告。
+// 参数：
+//   - traffic: 捕获期间各出站的流量（由调用方从 xray 实例读取）
+//
+// 返回：捕获报告，未在捕获时返回 nil
+func (dc *DebugCaptureService) Stop(traffic []CaptureTraffic) *CaptureReport {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	if !dc.active {
+		return nil
+	}
+	dc.active = false
+	endedAt := time.Now()
+	if endedAt.After(dc.deadline) {
+		endedAt = dc.deadline
+	}
+
+	report := &CaptureReport{
+		StartedAt:   dc.startedAt,
+		EndedAt:     endedAt,
+		Node:        dc.node,
+		Connections: dc.connections,
+		Hosts:       summarizeCaptureHosts(dc.connections),
+		Traffic:     traffic,
+		Dropped:     dc.dropped,
+	}
+	if report.Connections == nil {
+		report.Connections = []CaptureConnection{}
+	}
+	dc.connections = nil
+	dc.pendingRule = nil
+	return report
+}
+
+// summarizeCaptureHosts 按目标域名汇总连接，按连接数降序排列。
+func summarizeCaptureHosts(conns []CaptureConnection) []CaptureHost {
+	index := make(map[string]int)
+	var hosts []CaptureHost
+	appendUnique := func(list []string, v string) []string {
+		if v == "" {
+			return list
+		}
+		for _, x := range list {
+			if x == v {
+				return list
+			}
+		}
+		return append(list, v)
+	}
+	for _, c := range conns {
+		i, ok := index[c.Host]
+		if !ok {
+			i = len(hosts)
+			index[c.Host] = i
+			hosts = append(hosts, CaptureHost{Host: c.Host, FirstSeen: c.Time})
+		}
+		h := &hosts[i]
+		h.Connections++
+		h.LastSeen = c.Time
+		h.Outbounds = appendUnique(h.Outbounds, c.Outbound)
+		h.Rules = appendUnique(h.Rules, c.Rule)
+	}
+	sort.SliceStable(hosts, func(a, b int) bool { return hosts[a].Connections > hosts[b].Connections })
+	if hosts == nil {
+		hosts = []CaptureHost{}
+	}
+	return hosts
+}
+
+// parseRouteHitLine 解析 xray 路由日志：
+// "... app/dispatcher: Hit route rule: [user#3] so taking detour [direct] for [tcp:example.com:443]"
+// 返回：规则标签、目标和是否解析成功
+func parseRouteHitLine(line string) (rule, dest string, ok bool) {
+	idx := strings.Index(line, "Hit route rule: [")
+	if idx == -1 {
+		return "", "", false
+	}
+	rest := line[idx+len("Hit route rule: ["):]
+	rule, rest, ok = strings.Cut(rest, "]")
+	if !ok {
+		return "", "", false
+	}
+	forIdx := strings.LastIndex(rest, " for [")
+	if forIdx == -1 {
+		return "", "", false
+	}
+	dest, _, ok = strings.Cut(rest[forIdx+len(" for ["):], "]")
+	return rule, dest, ok && dest != ""
+}
+
+// parseCaptureAccessLine 解析 xray 访问日志：
+// "2026/02/12 10:20:40.159520 from tcp:127.0.0.1:52101 accepted tcp:example.com:443 [socks-in -> proxy]"
+// 路由说明中 "->" 表示命中规则，">>" 表示默认出站。
+func parseCaptureAccessLine(line string) (CaptureConnection, bool) {
+	var conn CaptureConnection
+	if !IsAccessLogLine(line) {
+		return conn, false
+	}
+	fields := strings.Fields(line)
+	from := 0
+	for fields[from] != "from" {
+		from++
+	}
+	if from >= 2 {
+		if t, err := time.ParseInLocation("2006/01/02 15:04:05.000000", fields[0]+" "+fields[1], time.Local); err == nil {
+			conn.Time = t
+		} else if t, err := time.ParseInLocation("2006/01/02 15:04:05", fields[0]+" "+fields[1], time.Local); err == nil {
+			conn.Time = t
+		}
+	}
+	conn.Source = fields[from+1]
+	conn.Rejected = fields[from+2] == "rejected"
+	conn.Destination = fields[from+3]
+	conn.Host = captureDestinationHost(conn.Destination)
+
+	if open := strings.Index(line, " ["); open != -1 {
+		if detour, _, ok := strings.Cut(line[open+2:], "]"); ok {
+			for _, sep := range []string{" ==> ", " -> ", " >> "} {
+				if in, out, found := strings.Cut(detour, sep); found {
+					conn.Inbound, conn.Outbound = in, out
+					break
+				}
+			}
+			if conn.Outbound == "" {
+				conn.Outbound = detour
+			}
+		}
+	}
+	return conn, true
+}
+
+// captureDestinationHost 从 xray 目标（tcp:host:port、//host:port）中提取主机名。
+func captureDestinationHost(dest string) string {
+	host := strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(dest, "tcp:"), "udp:"), "//")
+	if i := strings.LastIndex(host, ":"); i > 0 {
+		host = host[:i]
+	}
+	return strings.Trim(host, "[]")
+}
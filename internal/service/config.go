@@ -1,11 +1,19 @@
 package service
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"net"
+	"strconv"
 	"strings"
+	"time"
 
-	"fyne.io/fyne/v2"
+	"myproxy.com/p/internal/errs"
+	"myproxy.com/p/internal/model"
 	"myproxy.com/p/internal/store"
+	"myproxy.com/p/internal/utils"
 )
 
 // 默认的国内域名直连路由列表
@@ -85,33 +93,33 @@ func (cs *ConfigService) GetTheme() string {
 // 返回：错误（如果有）
 func (cs *ConfigService) SetTheme(theme string) error {
 	if cs.store == nil || cs.store.AppConfig == nil {
-		return fmt.Errorf("Store 未初始化")
+		return errs.ErrStoreNotInitialized
 	}
 	return cs.store.AppConfig.Set("theme", theme)
 }
 
 // GetWindowSize 获取窗口大小。
 // 参数：
-//   - defaultSize: 默认窗口大小
+//   - defaultWidth, defaultHeight: 默认窗口大小
 //
-// 返回：窗口大小
-func (cs *ConfigService) GetWindowSize(defaultSize fyne.Size) fyne.Size {
+// 返回：窗口宽度和高度
+func (cs *ConfigService) GetWindowSize(defaultWidth, defaultHeight float32) (float32, float32) {
 	if cs.store == nil || cs.store.AppConfig == nil {
-		return defaultSize
+		return defaultWidth, defaultHeight
 	}
-	return cs.store.AppConfig.GetWindowSize(defaultSize)
+	return cs.store.AppConfig.GetWindowSize(defaultWidth, defaultHeight)
 }
 
 // SaveWindowSize 保存窗口大小。
 // 参数：
-//   - size: 窗口大小
+//   - width, height: 窗口宽度和高度
 //
 // 返回：错误（如果有）
-func (cs *ConfigService) SaveWindowSize(size fyne.Size) error {
+func (cs *ConfigService) SaveWindowSize(width, height float32) error {
 	if cs.store == nil || cs.store.AppConfig == nil {
-		return fmt.Errorf("Store 未初始化")
+		return errs.ErrStoreNotInitialized
 	}
-	return cs.store.AppConfig.SaveWindowSize(size)
+	return cs.store.AppConfig.SaveWindowSize(width, height)
 }
 
 // GetLogsCollapsed 获取日志面板折叠状态。
@@ -134,7 +142,7 @@ func (cs *ConfigService) GetLogsCollapsed() bool {
 // 返回：错误（如果有）
 func (cs *ConfigService) SetLogsCollapsed(collapsed bool) error {
 	if cs.store == nil || cs.store.AppConfig == nil {
-		return fmt.Errorf("Store 未初始化")
+		return errs.ErrStoreNotInitialized
 	}
 	state := "false"
 	if collapsed {
@@ -143,8 +151,8 @@ func (cs *ConfigService) SetLogsCollapsed(collapsed bool) error {
 	return cs.store.AppConfig.Set("logsCollapsed", state)
 }
 
-// GetSystemProxyMode 获取系统代理模式。
-// 返回：系统代理模式（clear, auto, terminal）
+// GetSystemProxyMode 获取保存的系统代理模式。
+// 返回：系统代理模式名称（model.SystemProxyModeName*），未保存时为空
 func (cs *ConfigService) GetSystemProxyMode() string {
 	if cs.store == nil || cs.store.AppConfig == nil {
 		return ""
@@ -156,14 +164,14 @@ func (cs *ConfigService) GetSystemProxyMode() string {
 	return mode
 }
 
-// SetSystemProxyMode 设置系统代理模式。
+// SetSystemProxyMode 保存系统代理模式。
 // 参数：
-//   - mode: 系统代理模式（clear, auto, terminal）
+//   - mode: 系统代理模式名称（model.SystemProxyModeName*）
 //
 // 返回：错误（如果有）
 func (cs *ConfigService) SetSystemProxyMode(mode string) error {
 	if cs.store == nil || cs.store.AppConfig == nil {
-		return fmt.Errorf("Store 未初始化")
+		return errs.ErrStoreNotInitialized
 	}
 	return cs.store.AppConfig.Set("systemProxyMode", mode)
 }
@@ -175,7 +183,7 @@ func (cs *ConfigService) SetSystemProxyMode(mode string) error {
 // 返回：配置值和错误（如果有）
 func (cs *ConfigService) Get(key string) (string, error) {
 	if cs.store == nil || cs.store.AppConfig == nil {
-		return "", fmt.Errorf("Store 未初始化")
+		return "", errs.ErrStoreNotInitialized
 	}
 	return cs.store.AppConfig.Get(key)
 }
@@ -201,69 +209,110 @@ func (cs *ConfigService) GetWithDefault(key, defaultValue string) (string, error
 // 返回：错误（如果有）
 func (cs *ConfigService) Set(key, value string) error {
 	if cs.store == nil || cs.store.AppConfig == nil {
-		return fmt.Errorf("Store 未初始化")
+		return errs.ErrStoreNotInitialized
 	}
 	return cs.store.AppConfig.Set(key, value)
 }
 
-// GetDirectRoutes 获取直连路由列表（域名或 IP/CIDR，每行一条，对应 xray 规则）。
-// 返回：直连地址列表，空切片表示未配置
-func (cs *ConfigService) GetDirectRoutes() []string {
+// GetRouteRules 获取用户路由规则（按顺序匹配，每条规则指定直连/代理/拦截）。
+// 返回：规则列表，未配置或解析失败时返回空切片
+func (cs *ConfigService) GetRouteRules() []model.RouteRule {
 	if cs.store == nil || cs.store.AppConfig == nil {
 		return nil
 	}
-	raw, err := cs.store.AppConfig.GetWithDefault("directRoutes", "")
+	raw, err := cs.store.AppConfig.GetWithDefault("routeRules", "")
 	if err != nil || raw == "" {
 		return nil
 	}
-	return parseDirectRoutes(raw)
+	var rules []model.RouteRule
+	if err := json.Unmarshal([]byte(raw), &rules); err != nil {
+		return nil
+	}
+	return rules
 }
 
-// GetDirectRoutesRaw 获取直连路由原始字符串（换行分隔），供 UI 多行输入框使用。
-func (cs *ConfigService) GetDirectRoutesRaw() string {
-	routes := cs.GetDirectRoutes()
-	if len(routes) == 0 {
-		return ""
+// SetRouteRules 保存用户路由规则。
+// 参数：
+//   - rules: 规则列表，目标会规范化（纯域名补全为 domain:xxx），端口与协议会校验，序列化为 JSON 存储
+//
+// 返回：错误（如果有）
+func (cs *ConfigService) SetRouteRules(rules []model.RouteRule) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	out := make([]model.RouteRule, 0, len(rules))
+	for _, r := range rules {
+		if !r.Action.Valid() {
+			return fmt.Errorf("路由规则 %s: 未知动作 %q", r.Target, r.Action)
+		}
+		port, ok := model.NormalizeRoutePort(r.Port)
+		if !ok {
+			return fmt.Errorf("路由规则 %s: 端口格式无效 %q", r.Target, r.Port)
+		}
+		if !model.ValidRouteNetwork(r.Network) {
+			return fmt.Errorf("路由规则 %s: 未知协议 %q", r.Target, r.Network)
+		}
+		rule := model.RouteRule{Port: port, Network: r.Network, Action: r.Action}
+		if targets := parseDirectRoutes(r.Target); len(targets) > 0 {
+			rule.Target = targets[0]
+		}
+		// 既无目标也无端口/协议条件的规则没有意义，忽略
+		if rule.Target == "" && !rule.HasPortMatch() {
+			continue
+		}
+		out = append(out, rule)
+	}
+	data, err := json.Marshal(out)
+	if err != nil {
+		return fmt.Errorf("序列化路由规则失败: %w", err)
 	}
-	return formatDirectRoutes(routes)
+	return cs.store.AppConfig.Set("routeRules", string(data))
 }
 
-// SetDirectRoutesFromRaw 从 UI 多行字符串保存直连路由（会解析并规范化后存储）。
-func (cs *ConfigService) SetDirectRoutesFromRaw(raw string) error {
-	routes := parseDirectRoutes(raw)
-	return cs.SetDirectRoutes(routes)
+// GetDefaultRouteRules 获取默认路由规则（国内常用域名直连，不修改数据库）。
+func (cs *ConfigService) GetDefaultRouteRules() []model.RouteRule {
+	rules := make([]model.RouteRule, 0, len(defaultDirectRoutes))
+	for _, route := range defaultDirectRoutes {
+		rules = append(rules, model.RouteRule{Target: route, Action: model.RouteActionDirect})
+	}
+	return rules
 }
 
-// SetDirectRoutes 保存直连路由列表。
-// 参数：直连地址列表，会序列化为换行分隔的字符串存储
-func (cs *ConfigService) SetDirectRoutes(routes []string) error {
+// MigrateRouteRules 将旧版「直连列表 + 不走直连」配置迁移为逐条路由规则（仅执行一次）。
+// 旧版勾选「不走直连」时，整个直连列表实际走代理，迁移后这些规则的动作为 proxy，
+// 语义保持不变；首次运行（无旧配置）时写入默认路由规则。
+// 返回：旧配置是否依赖「不走直连」反转（调用方应提示用户）和错误（如果有）
+func (cs *ConfigService) MigrateRouteRules() (bool, error) {
 	if cs.store == nil || cs.store.AppConfig == nil {
-		return fmt.Errorf("Store 未初始化")
+		return false, errs.ErrStoreNotInitialized
 	}
-	raw := formatDirectRoutes(routes)
-	return cs.store.AppConfig.Set("directRoutes", raw)
-}
 
-// GetDirectRoutesUseProxy 获取「直连列表中的地址是否走代理」。
-// true：直连列表中的地址走代理；false：走直连。
-func (cs *ConfigService) GetDirectRoutesUseProxy() bool {
-	if cs.store == nil || cs.store.AppConfig == nil {
-		return false
+	if existing, err := cs.store.AppConfig.Get("routeRules"); err == nil && existing != "" {
+		return false, nil
 	}
-	v, _ := cs.store.AppConfig.GetWithDefault("directRoutesUseProxy", "false")
-	return v == "true"
-}
 
-// SetDirectRoutesUseProxy 设置「直连列表中的地址是否走代理」。
-func (cs *ConfigService) SetDirectRoutesUseProxy(useProxy bool) error {
-	if cs.store == nil || cs.store.AppConfig == nil {
-		return fmt.Errorf("Store 未初始化")
+	raw, _ := cs.store.AppConfig.GetWithDefault("directRoutes", "")
+	if raw == "" {
+		return false, cs.SetRouteRules(cs.GetDefaultRouteRules())
 	}
-	val := "false"
-	if useProxy {
-		val = "true"
+
+	inverted, _ := cs.store.AppConfig.GetWithDefault("directRoutesUseProxy", "false")
+	action := model.RouteActionDirect
+	if inverted == "true" {
+		action = model.RouteActionProxy
+	}
+
+	routes := parseDirectRoutes(raw)
+	rules := make([]model.RouteRule, 0, len(routes))
+	for _, route := range routes {
+		rules = append(rules, model.RouteRule{Target: route, Action: action})
+	}
+	if err := cs.SetRouteRules(rules); err != nil {
+		return false, fmt.Errorf("迁移路由规则失败: %w", err)
 	}
-	return cs.store.AppConfig.Set("directRoutesUseProxy", val)
+	// 清除旧开关，避免后续版本误读
+	_ = cs.store.AppConfig.Set("directRoutesUseProxy", "false")
+	return action == model.RouteActionProxy, nil
 }
 
 // GetTerminalProxyEnabled 获取是否启用终端代理配置。
@@ -283,7 +332,7 @@ func (cs *ConfigService) GetTerminalProxyEnabled() bool {
 // 返回：错误（如果有）
 func (cs *ConfigService) SetTerminalProxyEnabled(enabled bool) error {
 	if cs.store == nil || cs.store.AppConfig == nil {
-		return fmt.Errorf("Store 未初始化")
+		return errs.ErrStoreNotInitialized
 	}
 	val := "false"
 	if enabled {
@@ -292,6 +341,79 @@ func (cs *ConfigService) SetTerminalProxyEnabled(enabled bool) error {
 	return cs.store.AppConfig.Set("terminalProxyEnabled", val)
 }
 
+// GetStaleTerminalAction 获取启动时发现终端代理文件过期后的处理方式，默认询问。
+func (cs *ConfigService) GetStaleTerminalAction() model.StaleTerminalAction {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return model.StaleTerminalAsk
+	}
+	raw, err := cs.store.AppConfig.GetWithDefault("staleTerminalAction", string(model.StaleTerminalAsk))
+	if err != nil || !model.StaleTerminalAction(raw).Valid() {
+		return model.StaleTerminalAsk
+	}
+	return model.StaleTerminalAction(raw)
+}
+
+// SetStaleTerminalAction 设置启动时发现终端代理文件过期后的处理方式。
+func (cs *ConfigService) SetStaleTerminalAction(action model.StaleTerminalAction) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	if !action.Valid() {
+		return fmt.Errorf("未知的处理方式: %s", action)
+	}
+	return cs.store.AppConfig.Set("staleTerminalAction", string(action))
+}
+
+// GetConfirmSystemChanges 获取修改系统代理、shell 配置文件等系统设置前是否先列出改动请用户确认，默认确认。
+func (cs *ConfigService) GetConfirmSystemChanges() bool {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return true
+	}
+	v, _ := cs.store.AppConfig.GetWithDefault("confirmSystemChanges", "true")
+	return v != "false"
+}
+
+// SetConfirmSystemChanges 设置修改系统设置前是否先请用户确认。
+func (cs *ConfigService) SetConfirmSystemChanges(confirm bool) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	val := "false"
+	if confirm {
+		val = "true"
+	}
+	return cs.store.AppConfig.Set("confirmSystemChanges", val)
+}
+
+// GetSystemChanges 获取已生效、尚未撤销的系统改动清单。
+// 返回：改动列表，未记录或解析失败时返回空切片
+func (cs *ConfigService) GetSystemChanges() []model.SystemChange {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return nil
+	}
+	raw, err := cs.store.AppConfig.GetWithDefault("systemChanges", "")
+	if err != nil || raw == "" {
+		return nil
+	}
+	var changes []model.SystemChange
+	if err := json.Unmarshal([]byte(raw), &changes); err != nil {
+		return nil
+	}
+	return changes
+}
+
+// SetSystemChanges 保存系统改动清单。
+func (cs *ConfigService) SetSystemChanges(changes []model.SystemChange) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return fmt.Errorf("序列化系统改动清单失败: %w", err)
+	}
+	return cs.store.AppConfig.Set("systemChanges", string(data))
+}
+
 // GetProxyType 获取代理类型配置。
 // 返回：代理类型（https 或 socks5）
 func (cs *ConfigService) GetProxyType() string {
@@ -309,11 +431,525 @@ func (cs *ConfigService) GetProxyType() string {
 // 返回：错误（如果有）
 func (cs *ConfigService) SetProxyType(proxyType string) error {
 	if cs.store == nil || cs.store.AppConfig == nil {
-		return fmt.Errorf("Store 未初始化")
+		return errs.ErrStoreNotInitialized
 	}
 	return cs.store.AppConfig.Set("proxyType", proxyType)
 }
 
+// GetTimeRules 获取定时拦截规则列表。
+// 返回：规则列表，未配置或解析失败时返回空切片
+func (cs *ConfigService) GetTimeRules() []model.TimeRule {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return nil
+	}
+	raw, err := cs.store.AppConfig.GetWithDefault("timeRules", "")
+	if err != nil || raw == "" {
+		return nil
+	}
+	var rules []model.TimeRule
+	if err := json.Unmarshal([]byte(raw), &rules); err != nil {
+		return nil
+	}
+	return rules
+}
+
+// SetTimeRules 保存定时拦截规则列表。
+// 参数：
+//   - rules: 规则列表，会序列化为 JSON 存储
+//
+// 返回：错误（如果有）
+func (cs *ConfigService) SetTimeRules(rules []model.TimeRule) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	for i := range rules {
+		if _, err := model.ParseClock(rules[i].Start); err != nil {
+			return fmt.Errorf("定时规则 %s: %w", rules[i].Name, err)
+		}
+		if _, err := model.ParseClock(rules[i].End); err != nil {
+			return fmt.Errorf("定时规则 %s: %w", rules[i].Name, err)
+		}
+		rules[i].Routes = parseDirectRoutes(formatDirectRoutes(rules[i].Routes))
+	}
+	data, err := json.Marshal(rules)
+	if err != nil {
+		return fmt.Errorf("序列化定时规则失败: %w", err)
+	}
+	return cs.store.AppConfig.Set("timeRules", string(data))
+}
+
+// GetInboundProfiles 获取额外入站配置列表（与主入站同时运行）。
+// 返回：入站列表，未配置或解析失败时返回空切片
+func (cs *ConfigService) GetInboundProfiles() []model.InboundProfile {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return nil
+	}
+	raw, err := cs.store.AppConfig.GetWithDefault("inboundProfiles", "")
+	if err != nil || raw == "" {
+		return nil
+	}
+	var profiles []model.InboundProfile
+	if err := json.Unmarshal([]byte(raw), &profiles); err != nil {
+		return nil
+	}
+	return profiles
+}
+
+// SetInboundProfiles 保存额外入站配置列表。
+// 端口必须在 1–65535 之间，不能与主入站端口或其他入站重复。
+// 参数：
+//   - profiles: 入站列表，会序列化为 JSON 存储
+//
+// 返回：错误（如果有）
+func (cs *ConfigService) SetInboundProfiles(profiles []model.InboundProfile) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	used := map[int]bool{model.MainInboundPort: true}
+	for _, p := range profiles {
+		if p.Port < 1 || p.Port > 65535 {
+			return fmt.Errorf("入站 %s: 端口超出范围: %d", p.Name, p.Port)
+		}
+		if used[p.Port] {
+			return fmt.Errorf("入站 %s: %w", p.Name, &errs.PortInUseError{Port: p.Port})
+		}
+		used[p.Port] = true
+		if !p.Protocol.Valid() {
+			return fmt.Errorf("入站 %s: %w: %s", p.Name, errs.ErrUnsupportedProtocol, p.Protocol)
+		}
+		if !p.Mode.Valid() {
+			return fmt.Errorf("入站 %s: 不支持的模式: %s", p.Name, p.Mode)
+		}
+	}
+	data, err := json.Marshal(profiles)
+	if err != nil {
+		return fmt.Errorf("序列化入站配置失败: %w", err)
+	}
+	return cs.store.AppConfig.Set("inboundProfiles", string(data))
+}
+
+// GetConnPolicy 获取连接策略（超时与缓冲区），未配置或解析失败时返回 xray 默认值。
+func (cs *ConfigService) GetConnPolicy() model.ConnPolicy {
+	policy := model.DefaultConnPolicy()
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return policy
+	}
+	raw, err := cs.store.AppConfig.GetWithDefault("connPolicy", "")
+	if err != nil || raw == "" {
+		return policy
+	}
+	var saved model.ConnPolicy
+	if err := json.Unmarshal([]byte(raw), &saved); err != nil || saved.Validate() != nil {
+		return policy
+	}
+	return saved
+}
+
+// SetConnPolicy 保存连接策略。
+// 参数：
+//   - policy: 连接策略，各项须在安全范围内
+//
+// 返回：错误（如果有）
+func (cs *ConfigService) SetConnPolicy(policy model.ConnPolicy) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return fmt.Errorf("序列化连接策略失败: %w", err)
+	}
+	return cs.store.AppConfig.Set("connPolicy", string(data))
+}
+
+// GetXrayLogOptions 获取 xray 日志设置（核心日志级别、访问日志开关与文件），未配置或解析失败时返回默认值。
+func (cs *ConfigService) GetXrayLogOptions() model.XrayLogOptions {
+	options := model.DefaultXrayLogOptions()
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return options
+	}
+	raw, err := cs.store.AppConfig.GetWithDefault("xrayLog", "")
+	if err != nil || raw == "" {
+		return options
+	}
+	var saved model.XrayLogOptions
+	if err := json.Unmarshal([]byte(raw), &saved); err != nil || saved.Validate() != nil {
+		return options
+	}
+	return saved
+}
+
+// SetXrayLogOptions 保存 xray 日志设置，代理重启后生效。
+// 参数：
+//   - options: 日志设置，级别须为 model.XrayLogLevels 之一
+//
+// 返回：错误（如果有）
+func (cs *ConfigService) SetXrayLogOptions(options model.XrayLogOptions) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	if err := options.Validate(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(options)
+	if err != nil {
+		return fmt.Errorf("序列化日志设置失败: %w", err)
+	}
+	return cs.store.AppConfig.Set("xrayLog", string(data))
+}
+
+// DefaultLogSpillQuotaMB 日志面板溢出到磁盘的默认配额（MB）
+const DefaultLogSpillQuotaMB = 20
+
+// GetLogSpillQuotaMB 获取日志面板溢出到磁盘的配额（MB），0 表示不溢出（超出内存缓冲区的旧日志直接丢弃）。
+func (cs *ConfigService) GetLogSpillQuotaMB() int {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return DefaultLogSpillQuotaMB
+	}
+	raw, err := cs.store.AppConfig.GetWithDefault("logSpillQuotaMB", strconv.Itoa(DefaultLogSpillQuotaMB))
+	if err != nil {
+		return DefaultLogSpillQuotaMB
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return DefaultLogSpillQuotaMB
+	}
+	return n
+}
+
+// SetLogSpillQuotaMB 设置日志面板溢出到磁盘的配额（MB），范围 0–1024。
+func (cs *ConfigService) SetLogSpillQuotaMB(mb int) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	if mb < 0 || mb > 1024 {
+		return fmt.Errorf("日志磁盘配额超出范围: %d MB", mb)
+	}
+	return cs.store.AppConfig.Set("logSpillQuotaMB", strconv.Itoa(mb))
+}
+
+// GetLatencySamples 获取每次测速的采样次数（取中位数作为延迟）。
+func (cs *ConfigService) GetLatencySamples() int {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return utils.DefaultLatencySamples
+	}
+	v, _ := cs.store.AppConfig.GetWithDefault("latencySamples", strconv.Itoa(utils.DefaultLatencySamples))
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 || n > utils.MaxLatencySamples {
+		return utils.DefaultLatencySamples
+	}
+	return n
+}
+
+// SetLatencySamples 设置每次测速的采样次数。
+// 参数：
+//   - n: 采样次数，范围 1 ~ utils.MaxLatencySamples
+//
+// 返回：错误（如果有）
+func (cs *ConfigService) SetLatencySamples(n int) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	if n < 1 || n > utils.MaxLatencySamples {
+		return fmt.Errorf("采样次数无效: %d", n)
+	}
+	return cs.store.AppConfig.Set("latencySamples", strconv.Itoa(n))
+}
+
+// GetUsageStatsEnabled 获取是否记录本地功能使用统计（默认开启，数据仅保存在本机）。
+func (cs *ConfigService) GetUsageStatsEnabled() bool {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return false
+	}
+	v, _ := cs.store.AppConfig.GetWithDefault("usageStatsEnabled", "true")
+	return v == "true"
+}
+
+// SetUsageStatsEnabled 设置是否记录本地功能使用统计。
+func (cs *ConfigService) SetUsageStatsEnabled(enabled bool) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	val := "false"
+	if enabled {
+		val = "true"
+	}
+	return cs.store.AppConfig.Set("usageStatsEnabled", val)
+}
+
+// GetAlwaysStartAtHome 获取启动时是否总是显示主界面（默认关闭，即恢复上次关闭时的页面）。
+func (cs *ConfigService) GetAlwaysStartAtHome() bool {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return false
+	}
+	v, _ := cs.store.AppConfig.GetWithDefault("alwaysStartAtHome", "false")
+	return v == "true"
+}
+
+// SetAlwaysStartAtHome 设置启动时是否总是显示主界面。
+func (cs *ConfigService) SetAlwaysStartAtHome(enabled bool) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	val := "false"
+	if enabled {
+		val = "true"
+	}
+	return cs.store.AppConfig.Set("alwaysStartAtHome", val)
+}
+
+// GetVerifyBeforeStart 获取启动代理前是否先验证节点可用（默认关闭）。
+func (cs *ConfigService) GetVerifyBeforeStart() bool {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return false
+	}
+	v, _ := cs.store.AppConfig.GetWithDefault("verifyBeforeStart", "false")
+	return v == "true"
+}
+
+// SetVerifyBeforeStart 设置启动代理前是否先验证节点可用。
+func (cs *ConfigService) SetVerifyBeforeStart(enabled bool) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	val := "false"
+	if enabled {
+		val = "true"
+	}
+	return cs.store.AppConfig.Set("verifyBeforeStart", val)
+}
+
+// GetOutboundBinding 获取全局出站绑定（网卡 / 源 IP）。
+func (cs *ConfigService) GetOutboundBinding() model.OutboundBinding {
+	var binding model.OutboundBinding
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return binding
+	}
+	raw, err := cs.store.AppConfig.GetWithDefault("outboundBinding", "")
+	if err != nil || raw == "" {
+		return binding
+	}
+	_ = json.Unmarshal([]byte(raw), &binding)
+	return binding
+}
+
+// SetOutboundBinding 设置全局出站绑定。
+// 参数：
+//   - binding: 绑定配置，源 IP 必须是合法 IP 地址
+//
+// 返回：错误（如果有）
+func (cs *ConfigService) SetOutboundBinding(binding model.OutboundBinding) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	if err := validateOutboundBinding(&binding); err != nil {
+		return err
+	}
+	data, err := json.Marshal(binding)
+	if err != nil {
+		return fmt.Errorf("序列化出站绑定失败: %w", err)
+	}
+	return cs.store.AppConfig.Set("outboundBinding", string(data))
+}
+
+// getNodeOutboundBindings 读取所有节点的出站绑定（按节点 ID）。
+// 单独存放在配置中而非节点表；订阅更新重建节点时沿用原节点 ID，绑定不会丢失。
+func (cs *ConfigService) getNodeOutboundBindings() map[string]model.OutboundBinding {
+	bindings := make(map[string]model.OutboundBinding)
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return bindings
+	}
+	raw, err := cs.store.AppConfig.GetWithDefault("nodeOutboundBindings", "")
+	if err != nil || raw == "" {
+		return bindings
+	}
+	_ = json.Unmarshal([]byte(raw), &bindings)
+	return bindings
+}
+
+// GetNodeOutboundBinding 获取节点单独设置的出站绑定，未设置时返回零值（跟随全局）。
+func (cs *ConfigService) GetNodeOutboundBinding(nodeID string) model.OutboundBinding {
+	return cs.getNodeOutboundBindings()[nodeID]
+}
+
+// SetNodeOutboundBinding 设置节点的出站绑定，传入零值表示清除（跟随全局）。
+// 参数：
+//   - nodeID: 节点 ID
+//   - binding: 绑定配置
+//
+// 返回：错误（如果有）
+func (cs *ConfigService) SetNodeOutboundBinding(nodeID string, binding model.OutboundBinding) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	if err := validateOutboundBinding(&binding); err != nil {
+		return err
+	}
+	bindings := cs.getNodeOutboundBindings()
+	if binding.IsZero() {
+		delete(bindings, nodeID)
+	} else {
+		bindings[nodeID] = binding
+	}
+	data, err := json.Marshal(bindings)
+	if err != nil {
+		return fmt.Errorf("序列化出站绑定失败: %w", err)
+	}
+	return cs.store.AppConfig.Set("nodeOutboundBindings", string(data))
+}
+
+// GetEffectiveOutboundBinding 获取节点实际生效的出站绑定：节点单独设置优先，否则使用全局设置。
+func (cs *ConfigService) GetEffectiveOutboundBinding(nodeID string) model.OutboundBinding {
+	if binding := cs.GetNodeOutboundBinding(nodeID); !binding.IsZero() {
+		return binding
+	}
+	return cs.GetOutboundBinding()
+}
+
+// validateOutboundBinding 规范化并校验出站绑定。
+func validateOutboundBinding(binding *model.OutboundBinding) error {
+	binding.Interface = strings.TrimSpace(binding.Interface)
+	binding.SendThrough = strings.TrimSpace(binding.SendThrough)
+	if binding.SendThrough != "" && net.ParseIP(binding.SendThrough) == nil {
+		return fmt.Errorf("源 IP 无效: %s", binding.SendThrough)
+	}
+	return nil
+}
+
+// GetActiveBlockRoutes 获取在给定时刻生效的定时拦截目标（已去重）。
+func (cs *ConfigService) GetActiveBlockRoutes(now time.Time) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, r := range cs.GetTimeRules() {
+		if !r.ActiveAt(now) {
+			continue
+		}
+		for _, route := range r.Routes {
+			if !seen[route] {
+				seen[route] = true
+				out = append(out, route)
+			}
+		}
+	}
+	return out
+}
+
+// GetFailoverEnabled 获取是否启用自动故障转移。
+func (cs *ConfigService) GetFailoverEnabled() bool {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return false
+	}
+	v, _ := cs.store.AppConfig.GetWithDefault("failoverEnabled", "false")
+	return v == "true"
+}
+
+// SetFailoverEnabled 设置是否启用自动故障转移。
+func (cs *ConfigService) SetFailoverEnabled(enabled bool) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	val := "false"
+	if enabled {
+		val = "true"
+	}
+	return cs.store.AppConfig.Set("failoverEnabled", val)
+}
+
+// GetFailoverReturnToPrimary 获取主节点恢复后是否自动切回。
+func (cs *ConfigService) GetFailoverReturnToPrimary() bool {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return false
+	}
+	v, _ := cs.store.AppConfig.GetWithDefault("failoverReturnToPrimary", "false")
+	return v == "true"
+}
+
+// SetFailoverReturnToPrimary 设置主节点恢复后是否自动切回。
+func (cs *ConfigService) SetFailoverReturnToPrimary(enabled bool) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	val := "false"
+	if enabled {
+		val = "true"
+	}
+	return cs.store.AppConfig.Set("failoverReturnToPrimary", val)
+}
+
+// GetPauseJobsOnBatterySaver 获取省电模式下是否暂停后台定时任务（默认暂停）。
+func (cs *ConfigService) GetPauseJobsOnBatterySaver() bool {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return true
+	}
+	v, _ := cs.store.AppConfig.GetWithDefault("pauseJobsOnBatterySaver", "true")
+	return v == "true"
+}
+
+// SetPauseJobsOnBatterySaver 设置省电模式下是否暂停后台定时任务。
+func (cs *ConfigService) SetPauseJobsOnBatterySaver(enabled bool) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	val := "false"
+	if enabled {
+		val = "true"
+	}
+	return cs.store.AppConfig.Set("pauseJobsOnBatterySaver", val)
+}
+
+// GetPauseJobsOnMetered 获取按流量计费网络（含手机热点）下是否暂停后台定时任务（默认暂停）。
+func (cs *ConfigService) GetPauseJobsOnMetered() bool {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return true
+	}
+	v, _ := cs.store.AppConfig.GetWithDefault("pauseJobsOnMetered", "true")
+	return v == "true"
+}
+
+// SetPauseJobsOnMetered 设置按流量计费网络下是否暂停后台定时任务。
+func (cs *ConfigService) SetPauseJobsOnMetered(enabled bool) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	val := "false"
+	if enabled {
+		val = "true"
+	}
+	return cs.store.AppConfig.Set("pauseJobsOnMetered", val)
+}
+
+// GetFailoverOrder 获取故障转移顺序（节点 ID 列表，第一个为主节点）。
+func (cs *ConfigService) GetFailoverOrder() []string {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return nil
+	}
+	raw, err := cs.store.AppConfig.GetWithDefault("failoverOrder", "")
+	if err != nil || raw == "" {
+		return nil
+	}
+	var ids []string
+	for _, line := range strings.Split(raw, "\n") {
+		if id := strings.TrimSpace(line); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// SetFailoverOrder 保存故障转移顺序。
+// 参数：
+//   - ids: 节点 ID 列表，按优先级排列
+//
+// 返回：错误（如果有）
+func (cs *ConfigService) SetFailoverOrder(ids []string) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	return cs.store.AppConfig.Set("failoverOrder", strings.Join(ids, "\n"))
+}
+
 // parseDirectRoutes 从换行分隔的字符串解析直连路由列表。
 // 支持 domain:xxx、ip 或 cidr，纯域名会补全为 domain:xxx。
 func parseDirectRoutes(raw string) []string {
@@ -358,27 +994,365 @@ func formatDirectRoutes(routes []string) string {
 	return strings.TrimSpace(strings.Join(routes, "\n"))
 }
 
-// SaveDefaultDirectRoutes 保存默认的直连路由到数据库（仅在第一次运行时调用）。
-// 如果数据库中已有路由配置，则不会覆盖。
-func (cs *ConfigService) SaveDefaultDirectRoutes() error {
+// 控制令牌长度（字节），十六进制编码后为 32 个字符
+const controlTokenBytes = 16
+
+// 本地 Web 面板默认端口
+const defaultDashboardPort = 19090
+
+// GetControlToken 获取本机控制令牌（Web 面板等本地接口的访问凭据），首次调用时生成并保存。
+// 返回：令牌和错误（如果有）
+func (cs *ConfigService) GetControlToken() (string, error) {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return "", errs.ErrStoreNotInitialized
+	}
+	token, _ := cs.store.AppConfig.GetWithDefault("controlToken", "")
+	if token != "" {
+		return token, nil
+	}
+	return cs.ResetControlToken()
+}
+
+// ResetControlToken 重新生成控制令牌，旧令牌立即失效。
+// 返回：新令牌和错误（如果有）
+func (cs *ConfigService) ResetControlToken() (string, error) {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return "", errs.ErrStoreNotInitialized
+	}
+	buf := make([]byte, controlTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成控制令牌失败: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+	if err := cs.store.AppConfig.Set("controlToken", token); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// GetDashboardEnabled 获取是否启用本地 Web 面板。
+func (cs *ConfigService) GetDashboardEnabled() bool {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return false
+	}
+	v, _ := cs.store.AppConfig.GetWithDefault("dashboardEnabled", "false")
+	return v == "true"
+}
+
+// SetDashboardEnabled 设置是否启用本地 Web 面板。
+func (cs *ConfigService) SetDashboardEnabled(enabled bool) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	val := "false"
+	if enabled {
+		val = "true"
+	}
+	return cs.store.AppConfig.Set("dashboardEnabled", val)
+}
+
+// GetDashboardAllowLAN 获取 Web 面板是否允许局域网访问（否则仅监听 127.0.0.1）。
+func (cs *ConfigService) GetDashboardAllowLAN() bool {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return false
+	}
+	v, _ := cs.store.AppConfig.GetWithDefault("dashboardAllowLAN", "false")
+	return v == "true"
+}
+
+// SetDashboardAllowLAN 设置 Web 面板是否允许局域网访问。
+func (cs *ConfigService) SetDashboardAllowLAN(allow bool) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	val := "false"
+	if allow {
+		val = "true"
+	}
+	return cs.store.AppConfig.Set("dashboardAllowLAN", val)
+}
+
+// GetDashboardPort 获取 Web 面板监听端口。
+func (cs *ConfigService) GetDashboardPort() int {
 	if cs.store == nil || cs.store.AppConfig == nil {
-		return fmt.Errorf("Store 未初始化")
+		return defaultDashboardPort
+	}
+	v, _ := cs.store.AppConfig.GetWithDefault("dashboardPort", strconv.Itoa(defaultDashboardPort))
+	port, err := strconv.Atoi(v)
+	if err != nil || port < 1 || port > 65535 {
+		return defaultDashboardPort
 	}
+	return port
+}
 
-	existing, err := cs.store.AppConfig.Get("directRoutes")
-	if err == nil && existing != "" {
+// SetDashboardPort 设置 Web 面板监听端口。
+func (cs *ConfigService) SetDashboardPort(port int) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("端口超出范围: %d", port)
+	}
+	return cs.store.AppConfig.Set("dashboardPort", strconv.Itoa(port))
+}
+
+// GetHookScripts 获取事件脚本列表（生命周期事件发生时执行）。
+// 返回：脚本列表，未配置或解析失败时返回空切片
+func (cs *ConfigService) GetHookScripts() []model.HookScript {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return nil
+	}
+	raw, err := cs.store.AppConfig.GetWithDefault("hookScripts", "")
+	if err != nil || raw == "" {
+		return nil
+	}
+	var scripts []model.HookScript
+	if err := json.Unmarshal([]byte(raw), &scripts); err != nil {
 		return nil
 	}
+	return scripts
+}
+
+// SetHookScripts 保存事件脚本列表。
+// 参数：
+//   - scripts: 脚本列表，路径不能为空，事件必须为已知事件
+//
+// 返回：错误（如果有）
+func (cs *ConfigService) SetHookScripts(scripts []model.HookScript) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	for _, s := range scripts {
+		if strings.TrimSpace(s.Path) == "" {
+			return fmt.Errorf("事件脚本路径不能为空")
+		}
+		for _, e := range s.Events {
+			if !e.Valid() {
+				return fmt.Errorf("事件脚本 %s: 未知事件: %s", s.Path, e)
+			}
+		}
+	}
+	data, err := json.Marshal(scripts)
+	if err != nil {
+		return fmt.Errorf("序列化事件脚本失败: %w", err)
+	}
+	return cs.store.AppConfig.Set("hookScripts", string(data))
+}
 
-	return cs.SetDirectRoutes(defaultDirectRoutes)
+// GetConflictPolicy 获取订阅更新时用户修改过的节点的冲突处理方式，默认保留用户修改。
+func (cs *ConfigService) GetConflictPolicy() model.ConflictPolicy {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return model.ConflictPolicyKeep
+	}
+	raw, err := cs.store.AppConfig.GetWithDefault("conflictPolicy", string(model.ConflictPolicyKeep))
+	if err != nil || !model.ConflictPolicy(raw).Valid() {
+		return model.ConflictPolicyKeep
+	}
+	return model.ConflictPolicy(raw)
 }
 
-// RestoreDefaultDirectRoutes 恢复默认的直连路由（覆盖当前配置）。
-func (cs *ConfigService) RestoreDefaultDirectRoutes() error {
-	return cs.SetDirectRoutes(defaultDirectRoutes)
+// SetConflictPolicy 设置订阅更新时用户修改过的节点的冲突处理方式。
+func (cs *ConfigService) SetConflictPolicy(policy model.ConflictPolicy) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	if !policy.Valid() {
+		return fmt.Errorf("未知的冲突处理方式: %s", policy)
+	}
+	return cs.store.AppConfig.Set("conflictPolicy", string(policy))
 }
 
-// GetDefaultDirectRoutes 获取默认的直连路由列表（不修改数据库）。
-func (cs *ConfigService) GetDefaultDirectRoutes() []string {
-	return defaultDirectRoutes
+// GetQUICBlockMode 获取拦截 QUIC（UDP 443）的范围，默认不拦截。
+func (cs *ConfigService) GetQUICBlockMode() model.QUICBlockMode {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return model.QUICBlockOff
+	}
+	raw, err := cs.store.AppConfig.GetWithDefault("quicBlockMode", string(model.QUICBlockOff))
+	if err != nil || !model.QUICBlockMode(raw).Valid() {
+		return model.QUICBlockOff
+	}
+	return model.QUICBlockMode(raw)
+}
+
+// SetQUICBlockMode 设置拦截 QUIC（UDP 443）的范围，代理重建后生效。
+func (cs *ConfigService) SetQUICBlockMode(mode model.QUICBlockMode) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	if !mode.Valid() {
+		return fmt.Errorf("未知的 QUIC 拦截范围: %s", mode)
+	}
+	return cs.store.AppConfig.Set("quicBlockMode", string(mode))
+}
+
+// GetDNSOptions 获取域名解析设置，未配置或解析失败时返回默认值（远程解析）。
+func (cs *ConfigService) GetDNSOptions() model.DNSOptions {
+	options := model.DefaultDNSOptions()
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return options
+	}
+	raw, err := cs.store.AppConfig.GetWithDefault("dnsOptions", "")
+	if err != nil || raw == "" {
+		return options
+	}
+	var saved model.DNSOptions
+	if err := json.Unmarshal([]byte(raw), &saved); err != nil || saved.Validate() != nil {
+		return options
+	}
+	return saved
+}
+
+// SetDNSOptions 保存域名解析设置，代理重建后生效。
+// 参数：
+//   - options: 解析设置，DNS 服务器须为 xray 支持的格式
+//
+// 返回：错误（如果有）
+func (cs *ConfigService) SetDNSOptions(options model.DNSOptions) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	if err := options.Validate(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(options)
+	if err != nil {
+		return fmt.Errorf("序列化解析设置失败: %w", err)
+	}
+	return cs.store.AppConfig.Set("dnsOptions", string(data))
+}
+
+// GetNodeScoreWeights 获取节点综合评分的权重，未配置或解析失败时返回默认值。
+func (cs *ConfigService) GetNodeScoreWeights() model.NodeScoreWeights {
+	weights := model.DefaultNodeScoreWeights()
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return weights
+	}
+	raw, err := cs.store.AppConfig.GetWithDefault("nodeScoreWeights", "")
+	if err != nil || raw == "" {
+		return weights
+	}
+	var saved model.NodeScoreWeights
+	if err := json.Unmarshal([]byte(raw), &saved); err != nil || saved.Validate() != nil {
+		return weights
+	}
+	return saved
+}
+
+// SetNodeScoreWeights 保存节点综合评分的权重。
+func (cs *ConfigService) SetNodeScoreWeights(weights model.NodeScoreWeights) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	if err := weights.Validate(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(weights)
+	if err != nil {
+		return fmt.Errorf("序列化评分权重失败: %w", err)
+	}
+	return cs.store.AppConfig.Set("nodeScoreWeights", string(data))
+}
+
+// GetTrustedKeys 获取受信任的签名公钥列表（导入规则包时校验签名）。
+// 返回：公钥列表，未配置或解析失败时返回空切片
+func (cs *ConfigService) GetTrustedKeys() []model.TrustedKey {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return nil
+	}
+	raw, err := cs.store.AppConfig.GetWithDefault("trustedKeys", "")
+	if err != nil || raw == "" {
+		return nil
+	}
+	var keys []model.TrustedKey
+	if err := json.Unmarshal([]byte(raw), &keys); err != nil {
+		return nil
+	}
+	return keys
+}
+
+// SetTrustedKeys 保存受信任的签名公钥列表。名称不能为空，公钥必须有效且不能重复。
+func (cs *ConfigService) SetTrustedKeys(keys []model.TrustedKey) error {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	seen := make(map[string]string)
+	for i := range keys {
+		keys[i].Name = strings.TrimSpace(keys[i].Name)
+		keys[i].PublicKey = strings.TrimSpace(keys[i].PublicKey)
+		if keys[i].Name == "" {
+			return fmt.Errorf("公钥名称不能为空")
+		}
+		key, err := keys[i].Key()
+		if err != nil {
+			return fmt.Errorf("公钥 %s: %w", keys[i].Name, err)
+		}
+		if other, ok := seen[string(key)]; ok {
+			return fmt.Errorf("公钥 %s 与 %s 相同", keys[i].Name, other)
+		}
+		seen[string(key)] = keys[i].Name
+	}
+	data, err := json.Marshal(keys)
+	if err != nil {
+		return fmt.Errorf("序列化签名公钥失败: %w", err)
+	}
+	return cs.store.AppConfig.Set("trustedKeys", string(data))
+}
+
+// ImportRuleBundle 将规则包中的规则追加到现有路由规则和定时拦截规则之后。
+// 目标、端口、协议都相同的路由规则和同名的定时规则视为已存在，保留现有的不覆盖。
+// 返回：新增的路由规则数、新增的定时规则数和错误（如果有）
+func (cs *ConfigService) ImportRuleBundle(bundle model.RuleBundle) (int, int, error) {
+	if cs.store == nil || cs.store.AppConfig == nil {
+		return 0, 0, errs.ErrStoreNotInitialized
+	}
+	if err := bundle.Validate(); err != nil {
+		return 0, 0, err
+	}
+
+	rules := cs.GetRouteRules()
+	existing := make(map[model.RouteRule]bool, len(rules))
+	for _, r := range rules {
+		existing[model.RouteRule{Target: r.Target, Port: r.Port, Network: r.Network}] = true
+	}
+	addedRules := 0
+	for _, r := range bundle.Rules {
+		if targets := parseDirectRoutes(r.Target); len(targets) > 0 {
+			r.Target = targets[0]
+		}
+		r.Port, _ = model.NormalizeRoutePort(r.Port)
+		key := model.RouteRule{Target: r.Target, Port: r.Port, Network: r.Network}
+		if existing[key] {
+			continue
+		}
+		existing[key] = true
+		rules = append(rules, r)
+		addedRules++
+	}
+
+	timeRules := cs.GetTimeRules()
+	names := make(map[string]bool, len(timeRules))
+	for _, r := range timeRules {
+		names[r.Name] = true
+	}
+	addedTimeRules := 0
+	for _, r := range bundle.TimeRules {
+		if names[r.Name] {
+			continue
+		}
+		names[r.Name] = true
+		timeRules = append(timeRules, r)
+		addedTimeRules++
+	}
+
+	if addedRules > 0 {
+		if err := cs.SetRouteRules(rules); err != nil {
+			return 0, 0, err
+		}
+	}
+	if addedTimeRules > 0 {
+		if err := cs.SetTimeRules(timeRules); err != nil {
+			return addedRules, 0, err
+		}
+	}
+	return addedRules, addedTimeRules, nil
 }
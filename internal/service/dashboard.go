@@ -0,0 +1,355 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"myproxy.com/p/internal/store"
+	"myproxy.com/p/internal/utils"
+)
+
+const (
+	// 流量采样间隔与保留点数（约 2 分钟）
+	dashboardSampleInterval = time.Second
+	dashboardMaxSamples     = 120
+	// 日志尾部默认行数与上限
+	dashboardDefaultLogLines = 200
+	dashboardMaxLogLines     = 1000
+	// 读取日志尾部时最多读取的字节数
+	dashboardLogTailBytes = 256 * 1024
+)
+
+// DashboardRuntime 由调用方提供的运行时快照（xray 实例由 AppState 持有，Service 不直接访问）。
+type DashboardRuntime struct {
+	Running       bool  `json:"running"`
+	Port          int   `json:"port"`
+	UploadBytes   int64 `json:"upload_bytes"`
+	DownloadBytes int64 `json:"download_bytes"`
+}
+
+// DashboardStatus Web 面板的状态接口返回内容。
+type DashboardStatus struct {
+	DashboardRuntime
+	NodeName  string `json:"node_name"`
+	NodeAddr  string `json:"node_addr"`
+	ProxyMode string `json:"proxy_mode"`
+}
+
+// DashboardNode Web 面板的节点列表条目（不含任何凭据）。
+type DashboardNode struct {
+	Name     string `json:"name"`
+	Addr     string `json:"addr"`
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"`
+	Delay    int    `json:"delay"`
+	Selected bool   `json:"selected"`
+}
+
+// DashboardTrafficSample 一个流量采样点（字节/秒）。
+type DashboardTrafficSample struct {
+	Time     int64 `json:"time"`
+	Upload   int64 `json:"upload"`
+	Download int64 `json:"download"`
+}
+
+// DashboardService 只读 Web 面板：在 GUI 进程内提供状态、流量、节点延迟和日志尾部，
+// 所有请求都需要携带控制令牌（?token= 或 Authorization: Bearer）。
+type DashboardService struct {
+	store       *store.Store
+	config      *ConfigService
+	runtime     func() DashboardRuntime
+	logFilePath func() string
+
+	mu       sync.Mutex
+	server   *http.Server
+	url      string
+	stopCh   chan struct{}
+	samples  []DashboardTrafficSample
+	lastUp   int64
+	lastDown int64
+}
+
+// NewDashboardService 创建 Web 面板服务。
+// 参数：
+//   - store: Store 实例，用于读取节点列表
+//   - config: ConfigService，用于读取面板配置与控制令牌
+//   - runtime: 返回当前代理运行状态与累计流量
+//   - logFilePath: 返回当前日志文件路径
+//
+// 返回：Web 面板服务实例
+func NewDashboardService(store *store.Store, config *ConfigService, runtime func() DashboardRuntime, logFilePath func() string) *DashboardService {
+	return &DashboardService{
+		store:       store,
+		config:      config,
+		runtime:     runtime,
+		logFilePath: logFilePath,
+	}
+}
+
+// Start 按当前配置启动 Web 面板，已启动时先关闭再重启（用于配置变更后生效）。
+// 返回：面板访问地址（含令牌）和错误（如果有）
+func (ds *DashboardService) Start() (string, error) {
+	if ds.config == nil {
+		return "", fmt.Errorf("Web 面板: ConfigService 未初始化")
+	}
+	ds.Stop()
+
+	token, err := ds.config.GetControlToken()
+	if err != nil {
+		return "", fmt.Errorf("Web 面板: %w", err)
+	}
+
+	host := "127.0.0.1"
+	displayHost := host
+	if ds.config.GetDashboardAllowLAN() {
+		host = "0.0.0.0"
+		if ip, err := lanIPv4(); err == nil {
+			displayHost = ip
+		}
+	}
+	port := strconv.Itoa(ds.config.GetDashboardPort())
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return "", fmt.Errorf("Web 面板: 监听端口失败: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", ds.withToken(token, ds.handleIndex))
+	mux.HandleFunc("/api/status", ds.withToken(token, ds.handleStatus))
+	mux.HandleFunc("/api/traffic", ds.withToken(token, ds.handleTraffic))
+	mux.HandleFunc("/api/nodes", ds.withToken(token, ds.handleNodes))
+	mux.HandleFunc("/api/logs", ds.withToken(token, ds.handleLogs))
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	stopCh := make(chan struct{})
+	url := fmt.Sprintf("http://%s/?token=%s", net.JoinHostPort(displayHost, port), token)
+
+	ds.mu.Lock()
+	ds.server = server
+	ds.url = url
+	ds.stopCh = stopCh
+	ds.samples = nil
+	ds.lastUp, ds.lastDown = -1, -1
+	ds.mu.Unlock()
+
+	go ds.sampleLoop(stopCh)
+	return url, nil
+}
+
+// Stop 关闭 Web 面板（如果已启动）。
+func (ds *DashboardService) Stop() {
+	ds.mu.Lock()
+	server := ds.server
+	ds.server = nil
+	ds.url = ""
+	if ds.stopCh != nil {
+		close(ds.stopCh)
+		ds.stopCh = nil
+	}
+	ds.mu.Unlock()
+
+	if server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_ = server.Shutdown(ctx)
+	}
+}
+
+// URL 返回面板访问地址（含令牌），未启动时返回空字符串。
+func (ds *DashboardService) URL() string {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	return ds.url
+}
+
+// sampleLoop 按固定间隔把累计流量换算为速率，保留最近 dashboardMaxSamples 个点。
+func (ds *DashboardService) sampleLoop(stopCh chan struct{}) {
+	ticker := time.NewTicker(dashboardSampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case now := <-ticker.C:
+			rt := ds.currentRuntime()
+			ds.mu.Lock()
+			sample := DashboardTrafficSample{Time: now.Unix()}
+			// 首个采样点或计数被重置（代理重启）时只记录基准，不计算速率
+			if ds.lastUp >= 0 && rt.UploadBytes >= ds.lastUp && rt.DownloadBytes >= ds.lastDown {
+				sample.Upload = rt.UploadBytes - ds.lastUp
+				sample.Download = rt.DownloadBytes - ds.lastDown
+			}
+			ds.lastUp, ds.lastDown = rt.UploadBytes, rt.DownloadBytes
+			ds.samples = append(ds.samples, sample)
+			if len(ds.samples) > dashboardMaxSamples {
+				ds.samples = ds.samples[len(ds.samples)-dashboardMaxSamples:]
+			}
+			ds.mu.Unlock()
+		}
+	}
+}
+
+func (ds *DashboardService) currentRuntime() DashboardRuntime {
+	if ds.runtime == nil {
+		return DashboardRuntime{}
+	}
+	return ds.runtime()
+}
+
+// withToken 校验控制令牌，只允许 GET 请求。
+func (ds *DashboardService) withToken(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		got := r.URL.Query().Get("token")
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			got = strings.TrimPrefix(auth, "Bearer ")
+		}
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (ds *DashboardService) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = io.WriteString(w, dashboardHTML)
+}
+
+func (ds *DashboardService) handleStatus(w http.ResponseWriter, r *http.Request) {
+	status := DashboardStatus{DashboardRuntime: ds.currentRuntime()}
+	if ds.store != nil && ds.store.Nodes != nil {
+		if node := ds.store.Nodes.GetSelected(); node != nil {
+			status.NodeName = node.Name
+			status.NodeAddr = fmt.Sprintf("%s:%d", node.Addr, node.Port)
+		}
+	}
+	if ds.config != nil {
+		status.ProxyMode = ds.config.GetSystemProxyMode()
+	}
+	writeDashboardJSON(w, status)
+}
+
+func (ds *DashboardService) handleTraffic(w http.ResponseWriter, r *http.Request) {
+	ds.mu.Lock()
+	samples := make([]DashboardTrafficSample, len(ds.samples))
+	copy(samples, ds.samples)
+	ds.mu.Unlock()
+	writeDashboardJSON(w, samples)
+}
+
+func (ds *DashboardService) handleNodes(w http.ResponseWriter, r *http.Request) {
+	nodes := []DashboardNode{}
+	if ds.store != nil && ds.store.Nodes != nil {
+		for _, n := range ds.store.Nodes.GetAll() {
+			nodes = append(nodes, DashboardNode{
+				Name:     n.Name,
+				Addr:     n.Addr,
+				Port:     n.Port,
+				Protocol: n.ProtocolType,
+				Delay:    n.Delay,
+				Selected: n.Selected,
+			})
+		}
+	}
+	writeDashboardJSON(w, nodes)
+}
+
+func (ds *DashboardService) handleLogs(w http.ResponseWriter, r *http.Request) {
+	n := dashboardDefaultLogLines
+	if v, err := strconv.Atoi(r.URL.Query().Get("lines")); err == nil && v > 0 {
+		n = v
+	}
+	if n > dashboardMaxLogLines {
+		n = dashboardMaxLogLines
+	}
+	path := ""
+	if ds.logFilePath != nil {
+		path = ds.logFilePath()
+	}
+	lines, err := tailFileLines(path, n)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// 日志写入时已脱敏，这里再兜底一次，避免旧日志中的凭据经面板外泄
+	for i := range lines {
+		lines[i] = utils.RedactSecrets(lines[i])
+	}
+	writeDashboardJSON(w, lines)
+}
+
+func writeDashboardJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// tailFileLines 读取文件末尾最多 n 行。
+func tailFileLines(path string, n int) ([]string, error) {
+	if path == "" {
+		return []string{}, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("打开日志文件失败: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("读取日志文件失败: %w", err)
+	}
+	offset := info.Size() - dashboardLogTailBytes
+	if offset < 0 {
+		offset = 0
+	}
+	data, err := io.ReadAll(io.NewSectionReader(f, offset, info.Size()-offset))
+	if err != nil {
+		return nil, fmt.Errorf("读取日志文件失败: %w", err)
+	}
+	if offset > 0 {
+		// 丢弃被截断的首行
+		if idx := bytes.IndexByte(data, '\n'); idx >= 0 {
+			data = data[idx+1:]
+		}
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) == 1 && lines[0] == "" {
+		return []string{}, nil
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, nil
+}
@@ -0,0 +1,122 @@
+package service
+
+// dashboardHTML Web 面板页面（只读），通过页面地址中的 token 调用 /api/* 接口并定时刷新。
+const dashboardHTML = `<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>myproxy 面板</title>
+<style>
+  body { font-family: -apple-system, "Segoe UI", "PingFang SC", sans-serif; margin: 0; background: #f1f5f9; color: #0f172a; }
+  header { padding: 12px 20px; background: #0f172a; color: #fff; font-weight: 600; }
+  main { display: grid; grid-template-columns: repeat(auto-fit, minmax(320px, 1fr)); gap: 16px; padding: 16px; }
+  section { background: #fff; border-radius: 10px; border: 1px solid #e2e8f0; padding: 12px 16px; }
+  h2 { font-size: 14px; margin: 0 0 8px; color: #475569; }
+  table { width: 100%; border-collapse: collapse; font-size: 13px; }
+  td, th { padding: 4px 6px; text-align: left; border-bottom: 1px solid #f1f5f9; }
+  tr.selected { background: #ecfdf5; font-weight: 600; }
+  .ok { color: #16a34a; } .bad { color: #dc2626; } .muted { color: #94a3b8; }
+  pre { font-size: 12px; max-height: 360px; overflow: auto; margin: 0; white-space: pre-wrap; word-break: break-all; }
+  canvas { width: 100%; height: 140px; }
+  #logs-section { grid-column: 1 / -1; }
+</style>
+</head>
+<body>
+<header>myproxy 面板（只读）</header>
+<main>
+  <section>
+    <h2>状态</h2>
+    <table id="status"></table>
+  </section>
+  <section>
+    <h2>实时流量 <span id="speed" class="muted"></span></h2>
+    <canvas id="chart" width="640" height="140"></canvas>
+  </section>
+  <section>
+    <h2>节点</h2>
+    <table id="nodes"></table>
+  </section>
+  <section id="logs-section">
+    <h2>日志</h2>
+    <pre id="logs"></pre>
+  </section>
+</main>
+<script>
+const token = new URLSearchParams(location.search).get("token") || "";
+function api(path) {
+  return fetch(path, { headers: { "Authorization": "Bearer " + token } }).then(r => {
+    if (!r.ok) throw new Error(r.status + " " + r.statusText);
+    return r.json();
+  });
+}
+function esc(s) {
+  return String(s).replace(/[&<>"]/g, c => ({ "&": "&amp;", "<": "&lt;", ">": "&gt;", '"': "&quot;" }[c]));
+}
+function speed(b) {
+  if (b < 1024) return b + " B/s";
+  if (b < 1024 * 1024) return (b / 1024).toFixed(1) + " KB/s";
+  return (b / 1024 / 1024).toFixed(1) + " MB/s";
+}
+function bytes(b) {
+  if (b < 1024 * 1024) return (b / 1024).toFixed(1) + " KB";
+  if (b < 1024 * 1024 * 1024) return (b / 1024 / 1024).toFixed(1) + " MB";
+  return (b / 1024 / 1024 / 1024).toFixed(2) + " GB";
+}
+function loadStatus() {
+  return api("/api/status").then(s => {
+    document.getElementById("status").innerHTML =
+      "<tr><td>代理</td><td class='" + (s.running ? "ok'>运行中" : "bad'>已停止") + "</td></tr>" +
+      "<tr><td>节点</td><td>" + esc(s.node_name || "-") + " <span class='muted'>" + esc(s.node_addr || "") + "</span></td></tr>" +
+      "<tr><td>监听端口</td><td>" + (s.port || "-") + "</td></tr>" +
+      "<tr><td>系统代理</td><td>" + esc(s.proxy_mode || "-") + "</td></tr>" +
+      "<tr><td>累计流量</td><td>↑ " + bytes(s.upload_bytes) + " / ↓ " + bytes(s.download_bytes) + "</td></tr>";
+  });
+}
+function loadTraffic() {
+  return api("/api/traffic").then(points => {
+    const c = document.getElementById("chart"), g = c.getContext("2d");
+    g.clearRect(0, 0, c.width, c.height);
+    if (!points.length) return;
+    const last = points[points.length - 1];
+    document.getElementById("speed").textContent = "↑ " + speed(last.upload) + "  ↓ " + speed(last.download);
+    const max = Math.max(1024, ...points.map(p => Math.max(p.upload, p.download)));
+    const step = c.width / Math.max(1, points.length - 1);
+    [["download", "#16a34a"], ["upload", "#2563eb"]].forEach(([key, color]) => {
+      g.beginPath(); g.strokeStyle = color; g.lineWidth = 2;
+      points.forEach((p, i) => {
+        const x = i * step, y = c.height - (p[key] / max) * (c.height - 4) - 2;
+        i ? g.lineTo(x, y) : g.moveTo(x, y);
+      });
+      g.stroke();
+    });
+  });
+}
+function loadNodes() {
+  return api("/api/nodes").then(nodes => {
+    document.getElementById("nodes").innerHTML =
+      "<tr><th>名称</th><th>协议</th><th>延迟</th></tr>" +
+      nodes.map(n => "<tr class='" + (n.selected ? "selected" : "") + "'><td>" + esc(n.name) +
+        "</td><td>" + esc(n.protocol) + "</td><td class='" + (n.delay > 0 ? "ok'>" + n.delay + " ms" : "muted'>-") +
+        "</td></tr>").join("");
+  });
+}
+function loadLogs() {
+  return api("/api/logs?lines=200").then(lines => {
+    const el = document.getElementById("logs");
+    const atBottom = el.scrollTop + el.clientHeight >= el.scrollHeight - 4;
+    el.textContent = lines.join("\n");
+    if (atBottom) el.scrollTop = el.scrollHeight;
+  });
+}
+function tick() {
+  Promise.all([loadStatus(), loadTraffic()]).catch(e => { document.getElementById("speed").textContent = e.message; });
+}
+tick(); loadNodes(); loadLogs();
+setInterval(tick, 1000);
+setInterval(loadNodes, 10000);
+setInterval(loadLogs, 3000);
+</script>
+</body>
+</html>
+`
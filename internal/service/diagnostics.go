@@ -0,0 +1,279 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"myproxy.com/p/internal/errs"
+	"myproxy.com/p/internal/store"
+	"myproxy.com/p/internal/utils"
+)
+
+// 诊断包内的文件
+const (
+	diagBundleMetaFile   = "meta.json"
+	diagBundleConfigFile = "config.json"
+	diagBundleNodesFile  = "nodes.json"
+	diagBundleLogFile    = "myproxy.log"
+)
+
+const (
+	// diagBundleLogLines 诊断包中包含的日志行数（取日志文件末尾）
+	diagBundleLogLines = 5000
+	// diagBundleMaxSize 打开诊断包时允许的最大文件大小，防止误选大文件占满内存
+	diagBundleMaxSize = 64 << 20
+)
+
+// diagSecretConfigKeys 不导出到诊断包的配置项（本机凭据）
+var diagSecretConfigKeys = map[string]bool{
+	"controlToken": true,
+}
+
+// DiagnosticsMeta 诊断包的基本信息。
+type DiagnosticsMeta struct {
+	CreatedAt   time.Time `json:"created_at"`
+	OS          string    `json:"os"`
+	Arch        string    `json:"arch"`
+	ProxyStatus string    `json:"proxy_status,omitempty"` // 导出时的代理状态（如 "运行中"）
+	CurrentNode string    `json:"current_node,omitempty"` // 导出时选中的节点名称
+}
+
+// DiagnosticsNode 诊断包中的节点快照，只保留排查所需字段，不含任何凭据。
+type DiagnosticsNode struct {
+	Name         string `json:"name"`
+	Protocol     string `json:"protocol"`
+	Addr         string `json:"addr"`
+	Port         int    `json:"port"`
+	Delay        int    `json:"delay"`
+	Enabled      bool   `json:"enabled"`
+	Selected     bool   `json:"selected"`
+	UserModified bool   `json:"user_modified,omitempty"`
+}
+
+// DiagnosticsBundle 诊断包内容：日志、配置和节点列表快照，凭据均已脱敏。
+type DiagnosticsBundle struct {
+	Meta   DiagnosticsMeta
+	Config map[string]string
+	Nodes  []DiagnosticsNode
+	Logs   []string
+}
+
+// ConfigKeys 返回按名称排序的配置键，便于稳定显示。
+func (b *DiagnosticsBundle) ConfigKeys() []string {
+	keys := make([]string, 0, len(b.Config))
+	for k := range b.Config {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// DiagnosticsService 诊断包服务：导出本机日志与配置供他人排查，或打开其他机器导出的诊断包。
+type DiagnosticsService struct {
+	store *store.Store
+}
+
+// NewDiagnosticsService 创建诊断包服务实例。
+// 参数：
+//   - store: Store 实例，用于读取配置和节点
+//
+// 返回：初始化后的 DiagnosticsService 实例
+func NewDiagnosticsService(store *store.Store) *DiagnosticsService {
+	return &DiagnosticsService{
+		store: store,
+	}
+}
+
+// Collect 收集本机诊断信息：日志文件末尾、全部配置项和节点快照，凭据全部脱敏。
+// 参数：
+//   - logFilePath: 日志文件路径（为空时不含日志）
+//
+// 返回：诊断包内容和错误（如果有）
+func (ds *DiagnosticsService) Collect(logFilePath string) (*DiagnosticsBundle, error) {
+	if ds.store == nil || ds.store.AppConfig == nil || ds.store.Nodes == nil {
+		return nil, fmt.Errorf("诊断包: %w", errs.ErrStoreNotInitialized)
+	}
+
+	bundle := &DiagnosticsBundle{
+		Meta: DiagnosticsMeta{
+			CreatedAt: time.Now(),
+			OS:        runtime.GOOS,
+			Arch:      runtime.GOARCH,
+		},
+		Config: make(map[string]string),
+	}
+	if ds.store.ProxyStatus != nil {
+		bundle.Meta.ProxyStatus = ds.store.ProxyStatus.Status()
+	}
+
+	config, err := ds.store.AppConfig.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("诊断包: %w", err)
+	}
+	for key, value := range config {
+		if diagSecretConfigKeys[key] {
+			continue
+		}
+		bundle.Config[key] = utils.RedactSecrets(value)
+	}
+
+	for _, n := range ds.store.Nodes.GetAll() {
+		if n.Selected {
+			bundle.Meta.CurrentNode = n.Name
+		}
+		bundle.Nodes = append(bundle.Nodes, DiagnosticsNode{
+			Name:         n.Name,
+			Protocol:     n.ProtocolType,
+			Addr:         n.Addr,
+			Port:         n.Port,
+			Delay:        n.Delay,
+			Enabled:      n.Enabled,
+			Selected:     n.Selected,
+			UserModified: n.UserModified,
+		})
+	}
+
+	lines, err := tailFileLines(logFilePath, diagBundleLogLines)
+	if err != nil {
+		return nil, fmt.Errorf("诊断包: %w", err)
+	}
+	// 日志写入时已脱敏，这里再兜底一次，避免旧日志中的凭据随诊断包外泄
+	for i := range lines {
+		lines[i] = utils.RedactSecrets(lines[i])
+	}
+	bundle.Logs = lines
+	return bundle, nil
+}
+
+// Export 收集本机诊断信息并写为 zip 诊断包。
+// 参数：
+//   - w: 输出目标（通常为用户选择的文件）
+//   - logFilePath: 日志文件路径
+//
+// 返回：错误（如果有）
+func (ds *DiagnosticsService) Export(w io.Writer, logFilePath string) error {
+	bundle, err := ds.Collect(logFilePath)
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	writeJSON := func(name string, v interface{}) error {
+		f, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	if err := writeJSON(diagBundleMetaFile, bundle.Meta); err != nil {
+		return fmt.Errorf("诊断包: 写入失败: %w", err)
+	}
+	if err := writeJSON(diagBundleConfigFile, bundle.Config); err != nil {
+		return fmt.Errorf("诊断包: 写入失败: %w", err)
+	}
+	if err := writeJSON(diagBundleNodesFile, bundle.Nodes); err != nil {
+		return fmt.Errorf("诊断包: 写入失败: %w", err)
+	}
+	f, err := zw.Create(diagBundleLogFile)
+	if err != nil {
+		return fmt.Errorf("诊断包: 写入失败: %w", err)
+	}
+	for _, line := range bundle.Logs {
+		if _, err := io.WriteString(f, line+"\n"); err != nil {
+			return fmt.Errorf("诊断包: 写入失败: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("诊断包: 写入失败: %w", err)
+	}
+	return nil
+}
+
+// ReadDiagnosticsBundle 读取其他机器导出的诊断包，仅用于只读查看，不会导入任何配置或节点。
+// 缺少的文件按空内容处理，以便打开旧版本或手工裁剪过的诊断包。
+// 参数：
+//   - r: 诊断包 zip 数据
+//
+// 返回：诊断包内容和错误（如果有）
+func ReadDiagnosticsBundle(r io.Reader) (*DiagnosticsBundle, error) {
+	data, err := io.ReadAll(io.LimitReader(r, diagBundleMaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("诊断包: 读取失败: %w", err)
+	}
+	if len(data) > diagBundleMaxSize {
+		return nil, fmt.Errorf("诊断包: 文件超过 %d MB", diagBundleMaxSize>>20)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("诊断包: 不是有效的 zip 文件: %w", err)
+	}
+
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	if files[diagBundleMetaFile] == nil && files[diagBundleLogFile] == nil {
+		return nil, fmt.Errorf("诊断包: 缺少 %s 和 %s，不是 myproxy 导出的诊断包", diagBundleMetaFile, diagBundleLogFile)
+	}
+
+	// 解压后的总大小同样不超过 diagBundleMaxSize，防止高压缩比的 zip 炸弹耗尽内存
+	remaining := int64(diagBundleMaxSize)
+	readFile := func(name string) ([]byte, error) {
+		f := files[name]
+		if f == nil {
+			return nil, nil
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("诊断包: 读取 %s 失败: %w", name, err)
+		}
+		defer rc.Close()
+		content, err := io.ReadAll(io.LimitReader(rc, remaining+1))
+		if err != nil {
+			return nil, fmt.Errorf("诊断包: 读取 %s 失败: %w", name, err)
+		}
+		if int64(len(content)) > remaining {
+			return nil, fmt.Errorf("诊断包: 解压后超过 %d MB", diagBundleMaxSize>>20)
+		}
+		remaining -= int64(len(content))
+		return content, nil
+	}
+	readJSON := func(name string, v interface{}) error {
+		content, err := readFile(name)
+		if err != nil || content == nil {
+			return err
+		}
+		if err := json.Unmarshal(content, v); err != nil {
+			return fmt.Errorf("诊断包: 解析 %s 失败: %w", name, err)
+		}
+		return nil
+	}
+
+	bundle := &DiagnosticsBundle{Config: make(map[string]string)}
+	if err := readJSON(diagBundleMetaFile, &bundle.Meta); err != nil {
+		return nil, err
+	}
+	if err := readJSON(diagBundleConfigFile, &bundle.Config); err != nil {
+		return nil, err
+	}
+	if err := readJSON(diagBundleNodesFile, &bundle.Nodes); err != nil {
+		return nil, err
+	}
+	logs, err := readFile(diagBundleLogFile)
+	if err != nil {
+		return nil, err
+	}
+	if text := strings.TrimRight(string(logs), "\n"); text != "" {
+		bundle.Logs = strings.Split(text, "\n")
+	}
+	return bundle, nil
+}
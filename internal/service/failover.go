@@ -0,0 +1,210 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/store"
+	"myproxy.com/p/internal/utils"
+)
+
+const (
+	// 故障转移默认检测间隔
+	defaultFailoverInterval = 30 * time.Second
+	// 连续失败多少次判定当前节点不可用
+	failoverFailureThreshold = 2
+)
+
+// FailoverWatchdog 故障转移看门狗：定期检测当前节点，连续失败时按用户配置的顺序
+// 依次尝试备用节点，切换到第一个通过健康检查的节点；可选在主节点恢复后切回
+// （仅当当前节点是由故障转移切换过去的，用户手动选择的节点不会被切回）。
+type FailoverWatchdog struct {
+	store     *store.Store
+	config    *ConfigService
+	ping      *utils.Ping
+	health    *NodeHealthTracker
+	isRunning func() bool
+	onSwitch  func(from, to *model.Node, reason string)
+	interval  time.Duration
+	gate      *BackgroundJobGate // 省电或计费网络下暂停定时检测（可为 nil）
+
+	mu         sync.Mutex // 保护以下字段（Start/Stop 在 UI 线程调用，检测在后台 goroutine 中进行）
+	stopCh     chan struct{}
+	failures   int
+	switchedTo string // 故障转移切换到的节点 ID，当前节点仍为该节点时才允许切回主节点
+}
+
+// NewFailoverWatchdog 创建故障转移看门狗。
+// 参数：
+//   - store: Store 实例，用于读取和切换选中节点
+//   - config: ConfigService，用于读取故障转移配置
+//   - ping: 延迟测试工具，用于健康检查
+//   - health: 节点错误预算，检测失败计入预算，降级节点不参与切换（可为 nil）
+//   - isRunning: 代理是否正在运行，未运行时不做检测
+//   - onSwitch: 已切换选中节点后的回调（在后台 goroutine 中调用），由调用方重建代理并通知用户
+//
+// 返回：看门狗实例
+func NewFailoverWatchdog(store *store.Store, config *ConfigService, ping *utils.Ping, health *NodeHealthTracker, isRunning func() bool, onSwitch func(from, to *model.Node, reason string)) *FailoverWatchdog {
+	return &FailoverWatchdog{
+		store:     store,
+		config:    config,
+		ping:      ping,
+		health:    health,
+		isRunning: isRunning,
+		onSwitch:  onSwitch,
+		interval:  defaultFailoverInterval,
+	}
+}
+
+// SetJobGate 设置后台任务暂停判断，省电模式或按流量计费网络下跳过定时检测（在 Start 之前调用）。
+func (fw *FailoverWatchdog) SetJobGate(gate *BackgroundJobGate) {
+	fw.gate = gate
+}
+
+// Start 启动看门狗后台检测。
+func (fw *FailoverWatchdog) Start() {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	if fw.stopCh != nil {
+		return
+	}
+	fw.stopCh = make(chan struct{})
+	go fw.loop(fw.stopCh)
+}
+
+// Stop 停止看门狗。
+func (fw *FailoverWatchdog) Stop() {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	if fw.stopCh != nil {
+		close(fw.stopCh)
+		fw.stopCh = nil
+	}
+}
+
+func (fw *FailoverWatchdog) loop(stopCh chan struct{}) {
+	ticker := time.NewTicker(fw.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			fw.CheckOnce()
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// CheckOnce 执行一次检测，必要时切换节点。
+func (fw *FailoverWatchdog) CheckOnce() {
+	if fw.store == nil || fw.store.Nodes == nil || fw.config == nil || fw.ping == nil {
+		return
+	}
+	if !fw.config.GetFailoverEnabled() {
+		return
+	}
+	if fw.isRunning != nil && !fw.isRunning() {
+		return
+	}
+	// 省电模式或计费网络下跳过本次检测，不计入连续失败
+	if fw.gate.PauseReason() != "" {
+		return
+	}
+
+	active := fw.store.Nodes.GetSelected()
+	if active == nil {
+		return
+	}
+	order := fw.config.GetFailoverOrder()
+
+	if fw.healthy(active) {
+		fw.resetFailures()
+		// 当前节点正常：如开启了切回，当前节点由故障转移切换而来，且主节点已恢复，则切回主节点
+		if !fw.failedOver(active.ID) {
+			return
+		}
+		if len(order) > 0 && order[0] != active.ID && fw.config.GetFailoverReturnToPrimary() {
+			if primary, err := fw.store.Nodes.Get(order[0]); err == nil && !fw.health.IsDegraded(primary.ID) && fw.healthy(primary) {
+				fw.switchTo(active, primary, "主节点已恢复")
+			}
+		}
+		return
+	}
+
+	if fw.addFailure() < failoverFailureThreshold {
+		return
+	}
+
+	for _, id := range order {
+		if id == active.ID {
+			continue
+		}
+		candidate, err := fw.store.Nodes.Get(id)
+		if err != nil || !candidate.Enabled || fw.health.IsDegraded(candidate.ID) {
+			continue
+		}
+		if fw.healthy(candidate) {
+			if fw.switchTo(active, candidate, "当前节点不可用") {
+				fw.setSwitchedTo(candidate.ID)
+			}
+			return
+		}
+	}
+}
+
+// healthy 健康检查：能在超时时间内建立到节点的 TCP 连接即视为可用，失败计入错误预算。
+func (fw *FailoverWatchdog) healthy(node *model.Node) bool {
+	_, err := fw.ping.TestServerDelay(*node)
+	if err != nil {
+		fw.health.RecordFailure(node.ID)
+		return false
+	}
+	return true
+}
+
+// switchTo 切换选中节点并返回是否成功；切换后清除故障转移标记，由调用方按需重新设置。
+func (fw *FailoverWatchdog) switchTo(from, to *model.Node, reason string) bool {
+	if err := fw.store.SelectServer(to.ID); err != nil {
+		return false
+	}
+	fw.resetFailures()
+	fw.setSwitchedTo("")
+	if fw.onSwitch != nil {
+		fw.onSwitch(from, to, reason)
+	}
+	return true
+}
+
+// failedOver 返回当前节点是否由故障转移切换而来；用户已手动改选其他节点时清除标记。
+func (fw *FailoverWatchdog) failedOver(activeID string) bool {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	if fw.switchedTo != activeID {
+		fw.switchedTo = ""
+		return false
+	}
+	return true
+}
+
+// setSwitchedTo 记录故障转移切换到的节点 ID（空字符串表示清除）。
+func (fw *FailoverWatchdog) setSwitchedTo(id string) {
+	fw.mu.Lock()
+	fw.switchedTo = id
+	fw.mu.Unlock()
+}
+
+// addFailure 累加当前节点的连续失败次数并返回累加后的值。
+func (fw *FailoverWatchdog) addFailure() int {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	fw.failures++
+	return fw.failures
+}
+
+// resetFailures 清零连续失败次数。
+func (fw *FailoverWatchdog) resetFailures() {
+	fw.mu.Lock()
+	fw.failures = 0
+	fw.mu.Unlock()
+}
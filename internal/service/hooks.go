@@ -0,0 +1,175 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"myproxy.com/p/internal/model"
+)
+
+// hookScriptTimeout 单个事件脚本的最长运行时间，超时后强制结束
+const hookScriptTimeout = 30 * time.Second
+
+// HookNode 事件中的节点信息（不含密钥等敏感字段）。
+type HookNode struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Addr     string `json:"addr"`
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"`
+}
+
+// HookSubscription 事件中的订阅信息。
+type HookSubscription struct {
+	ID        int64  `json:"id"`
+	URL       string `json:"url"` // 已脱敏：token 等凭据替换为 ***，事件脚本不会拿到订阅凭据
+	Label     string `json:"label"`
+	NodeCount int    `json:"node_count"`
+}
+
+// HookPayload 生命周期事件内容，事件脚本从标准输入读取其 JSON。
+type HookPayload struct {
+	Event        model.HookEvent   `json:"event"`
+	Time         time.Time         `json:"time"`
+	Port         int               `json:"port,omitempty"`          // 本地 SOCKS5 端口（代理事件）
+	Node         *HookNode         `json:"node,omitempty"`          // 当前节点（代理事件）
+	PreviousNode *HookNode         `json:"previous_node,omitempty"` // 切换前的节点（node-switched）
+	Subscription *HookSubscription `json:"subscription,omitempty"`  // 更新的订阅（subscription-updated）
+}
+
+// Hook 编译期插件：在 init 中调用 RegisterHook 注册，收到全部生命周期事件，自行按 Event 过滤。
+// Handle 在独立 goroutine 中调用，不阻塞代理启停；返回的错误只记录日志。
+type Hook interface {
+	Name() string
+	Handle(payload HookPayload) error
+}
+
+var (
+	registeredHooksMu sync.RWMutex
+	registeredHooks   []Hook
+)
+
+// RegisterHook 注册编译期插件，通常在插件文件的 init 中调用。
+func RegisterHook(h Hook) {
+	if h == nil {
+		return
+	}
+	registeredHooksMu.Lock()
+	defer registeredHooksMu.Unlock()
+	registeredHooks = append(registeredHooks, h)
+}
+
+// HookService 生命周期事件分发：将事件异步发送给编译期插件和用户配置的事件脚本。
+type HookService struct {
+	config      *ConfigService
+	logCallback func(level, message string)
+}
+
+// NewHookService 创建事件分发服务。
+// 参数：
+//   - config: ConfigService，用于读取事件脚本列表
+//   - logCallback: 插件或脚本失败时的日志回调（可为 nil）
+//
+// 返回：初始化后的 HookService 实例
+func NewHookService(config *ConfigService, logCallback func(level, message string)) *HookService {
+	return &HookService{config: config, logCallback: logCallback}
+}
+
+// Fire 异步分发事件，立即返回。hs 为 nil 时不做任何事。
+func (hs *HookService) Fire(payload HookPayload) {
+	if hs == nil {
+		return
+	}
+	if payload.Time.IsZero() {
+		payload.Time = time.Now()
+	}
+
+	registeredHooksMu.RLock()
+	hooks := append([]Hook(nil), registeredHooks...)
+	registeredHooksMu.RUnlock()
+	for _, h := range hooks {
+		go hs.runHook(h, payload)
+	}
+
+	if hs.config == nil {
+		return
+	}
+	scripts := hs.config.GetHookScripts()
+	if len(scripts) == 0 {
+		return
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		hs.log("WARN", fmt.Sprintf("事件 %s: 序列化失败: %v", payload.Event, err))
+		return
+	}
+	for _, s := range scripts {
+		if s.Enabled && s.Subscribes(payload.Event) {
+			go hs.runScript(s.Path, payload.Event, data)
+		}
+	}
+}
+
+// runHook 调用编译期插件，插件 panic 不影响主程序。
+func (hs *HookService) runHook(h Hook, payload HookPayload) {
+	defer func() {
+		if r := recover(); r != nil {
+			hs.log("ERROR", fmt.Sprintf("插件 %s 处理事件 %s 时崩溃: %v", h.Name(), payload.Event, r))
+		}
+	}()
+	if err := h.Handle(payload); err != nil {
+		hs.log("WARN", fmt.Sprintf("插件 %s 处理事件 %s 失败: %v", h.Name(), payload.Event, err))
+	}
+}
+
+// runScript 执行事件脚本：事件 JSON 写入标准输入，事件名通过环境变量 MYPROXY_EVENT 传入。
+func (hs *HookService) runScript(path string, event model.HookEvent, data []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), hookScriptTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Env = append(os.Environ(), "MYPROXY_EVENT="+string(event))
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		return
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("运行超过 %v", hookScriptTimeout)
+	}
+	msg := fmt.Sprintf("事件脚本 %s 处理事件 %s 失败: %v", path, event, err)
+	if out := strings.TrimSpace(string(output)); out != "" {
+		if len(out) > 200 {
+			out = out[:200] + "..."
+		}
+		msg += ": " + out
+	}
+	hs.log("WARN", msg)
+}
+
+func (hs *HookService) log(level, message string) {
+	if hs.logCallback != nil {
+		hs.logCallback(level, message)
+	}
+}
+
+// newHookNode 从节点构造事件中的节点信息，node 为 nil 时返回 nil。
+func newHookNode(node *model.Node) *HookNode {
+	if node == nil {
+		return nil
+	}
+	return &HookNode{
+		ID:       node.ID,
+		Name:     node.Name,
+		Addr:     node.Addr,
+		Port:     node.Port,
+		Protocol: node.ProtocolType,
+	}
+}
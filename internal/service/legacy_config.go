@@ -0,0 +1,122 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"myproxy.com/p/internal/config"
+	"myproxy.com/p/internal/errs"
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/utils"
+)
+
+// legacyConfigMigratedKey 旧版配置迁移完成标记（app_config）。
+const legacyConfigMigratedKey = "legacyConfigMigrated"
+
+// LegacyMigrationResult 旧版配置迁移结果。
+type LegacyMigrationResult struct {
+	Imported int                  // 新导入的服务器数量
+	Existing int                  // 数据库中已存在而跳过的数量
+	Skipped  []model.SkippedEntry // 无效而跳过的条目
+}
+
+// MigrateLegacyConfig 将旧版 JSON 配置文件中的服务器列表导入数据库（仅执行一次）。
+// 导入后原文件备份为 *.bak，并从配置文件中移除服务器列表，避免重复导入。
+// 参数：
+//   - filePath: 旧版配置文件路径，为空时使用 config.DefaultConfigFile
+//
+// 返回：迁移结果（未发生迁移时为 nil）和错误（如果有）
+func (ss *ServerService) MigrateLegacyConfig(filePath string) (*LegacyMigrationResult, error) {
+	if ss.store == nil || ss.store.Nodes == nil || ss.store.AppConfig == nil {
+		return nil, fmt.Errorf("服务器服务: %w", errs.ErrStoreNotInitialized)
+	}
+	if done, _ := ss.store.AppConfig.GetWithDefault(legacyConfigMigratedKey, "false"); done == "true" {
+		return nil, nil
+	}
+	if filePath == "" {
+		filePath = config.DefaultConfigFile
+	}
+
+	// 没有旧配置文件（全新安装）时直接记录完成，不创建文件
+	data, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return nil, ss.store.AppConfig.Set(legacyConfigMigratedKey, "true")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("服务器服务: 读取旧版配置失败: %w", err)
+	}
+	cfg, err := config.LoadConfig(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("服务器服务: %w", err)
+	}
+	if len(cfg.Servers) == 0 {
+		return nil, ss.store.AppConfig.Set(legacyConfigMigratedKey, "true")
+	}
+
+	result := &LegacyMigrationResult{}
+	existing := make(map[string]bool)
+	for _, n := range ss.store.Nodes.GetAll() {
+		existing[n.ID] = true
+		existing[legacyNodeKey(*n)] = true
+	}
+	for _, server := range cfg.Servers {
+		if server.Addr == "" || server.Port <= 0 || server.Port > 65535 {
+			result.Skipped = append(result.Skipped, model.SkippedEntry{
+				Raw:    fmt.Sprintf("%s (%s:%d)", server.Name, server.Addr, server.Port),
+				Reason: "地址或端口无效",
+			})
+			continue
+		}
+		if (server.ID != "" && existing[server.ID]) || existing[legacyNodeKey(server)] {
+			result.Existing++
+			continue
+		}
+		if server.ID == "" {
+			server.ID = utils.GenerateServerID(server.Addr, server.Port, server.Username)
+		}
+		if server.Name == "" {
+			server.Name = server.Addr
+		}
+		if server.ProtocolType == "" {
+			// 旧版仅支持 SOCKS5
+			server.ProtocolType = "socks5"
+		}
+		// 选中状态由下方 SelectedServerID 统一处理
+		server.Selected = false
+		if err := ss.store.Nodes.Add(&server); err != nil {
+			return result, fmt.Errorf("服务器服务: 导入服务器失败: %w", err)
+		}
+		existing[server.ID] = true
+		existing[legacyNodeKey(server)] = true
+		result.Imported++
+	}
+
+	if cfg.SelectedServerID != "" && ss.store.Nodes.GetSelected() == nil {
+		_ = ss.store.Nodes.Select(cfg.SelectedServerID)
+	}
+
+	// 备份原文件后移除服务器列表，保留其余配置项。备份含服务器密码，仅当前用户可读；
+	// WriteFile 不会修改已存在文件的权限，因此再显式收紧一次
+	backupPath := filePath + ".bak"
+	if err := os.WriteFile(backupPath, data, 0600); err != nil {
+		return result, fmt.Errorf("服务器服务: 备份旧版配置失败: %w", err)
+	}
+	if err := os.Chmod(backupPath, 0600); err != nil {
+		return result, fmt.Errorf("服务器服务: 备份旧版配置失败: %w", err)
+	}
+	cfg.Servers = nil
+	if err := config.SaveConfig(cfg, filePath); err != nil {
+		return result, fmt.Errorf("服务器服务: %w", err)
+	}
+	return result, ss.store.AppConfig.Set(legacyConfigMigratedKey, "true")
+}
+
+// legacyNodeKey 以协议、地址、端口和用户名识别同一服务器（旧版 ID 可能与数据库不一致）。
+func legacyNodeKey(n model.Node) string {
+	protocol := n.ProtocolType
+	if protocol == "" {
+		protocol = "socks5"
+	}
+	return protocol + "://" + n.Username + "@" + n.Addr + ":" + strconv.Itoa(n.Port)
+}
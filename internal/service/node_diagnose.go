@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/xray"
+)
+
+const (
+	// 诊断探测地址：返回 204，经代理请求成功即视为该组合可用
+	diagnoseProbeURL = "https://www.gstatic.com/generate_204"
+	// 单个组合的探测超时
+	diagnoseProbeTimeout = 8 * time.Second
+	// 单次诊断最多尝试的组合数量（含原始配置）
+	maxDiagnoseVariants = 12
+)
+
+// DiagnoseResult 节点诊断中一个传输组合的探测结果。
+type DiagnoseResult struct {
+	Label    string     // 组合说明，如 "TLS: 关闭 · 传输: tcp"
+	Node     model.Node // 该组合对应的节点配置
+	Original bool       // 是否为原始配置
+	Delay    int        // 经代理完成请求的耗时（毫秒），失败时为 0
+	Err      error      // 失败原因
+}
+
+// OK 判断该组合是否连通。
+func (r DiagnoseResult) OK() bool {
+	return r.Err == nil
+}
+
+// DiagnoseNode 自动诊断节点：依次尝试节点传输配置的变体（TLS 开关、SNI、ALPN、ws/tcp 等），
+// 通过临时 xray 实例发起真实请求，报告哪些组合可以连通，帮助修正细微错误的分享链接。
+// 参数：
+//   - ctx: 上下文，取消时停止后续探测
+//   - node: 待诊断节点
+//   - active: 正在运行的代理实例（可为 nil），探测结束后恢复其日志输出
+//   - onResult: 每个组合探测完成后的回调（在调用 goroutine 中执行），可为 nil
+//
+// 返回：全部探测结果（顺序与尝试顺序一致，第一项为原始配置）
+func (xcs *XrayControlService) DiagnoseNode(ctx context.Context, node model.Node, active *xray.XrayInstance, onResult func(DiagnoseResult)) []DiagnoseResult {
+	if active != nil {
+		defer active.ReclaimLogHandler()
+	}
+
+	var results []DiagnoseResult
+	for i, v := range diagnoseVariants(node) {
+		if ctx.Err() != nil {
+			break
+		}
+		result := DiagnoseResult{Label: v.label, Node: v.node, Original: i == 0}
+		result.Delay, result.Err = probeNode(ctx, v.node)
+		results = append(results, result)
+		if onResult != nil {
+			onResult(result)
+		}
+	}
+	return results
+}
+
+// probeNode 使用临时 xray 实例经节点请求探测地址。
+func probeNode(ctx context.Context, node model.Node) (int, error) {
+	port, err := freeLocalPort()
+	if err != nil {
+		return 0, err
+	}
+	configJSON, err := xray.CreateProbeConfig(port, &node)
+	if err != nil {
+		return 0, err
+	}
+	instance, err := xray.NewProbeInstance(configJSON, port)
+	if err != nil {
+		return 0, err
+	}
+	if err := instance.Start(); err != nil {
+		return 0, err
+	}
+	defer instance.Stop()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy:             http.ProxyURL(&url.URL{Scheme: "socks5", Host: fmt.Sprintf("127.0.0.1:%d", port)}),
+			DisableKeepAlives: true,
+		},
+		Timeout: diagnoseProbeTimeout,
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, diagnoseProbeURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("请求失败: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return 0, fmt.Errorf("响应异常: HTTP %d", resp.StatusCode)
+	}
+	return int(time.Since(start).Milliseconds()), nil
+}
+
+// freeLocalPort 获取一个本地空闲端口。
+func freeLocalPort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, fmt.Errorf("获取空闲端口失败: %w", err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// diagnoseVariant 一个待探测的传输组合。
+type diagnoseVariant struct {
+	label string
+	node  model.Node
+}
+
+// diagnoseVariants 生成节点的传输变体，第一项为原始配置（含用户覆盖），去重后最多 maxDiagnoseVariants 项。
+// 仅 VMess 与 Trojan 有可调整的传输参数，其他协议只探测原始配置。
+func diagnoseVariants(node model.Node) []diagnoseVariant {
+	// 用户覆盖会掩盖对 Host / SNI 的调整，先写入解析字段再生成变体
+	node = node.WithOverridesApplied()
+	variants := []diagnoseVariant{{label: "原始配置", node: node}}
+	seen := map[string]bool{diagnoseKey(node): true}
+	add := func(n model.Node, changes []string) {
+		key := diagnoseKey(n)
+		if seen[key] || len(variants) >= maxDiagnoseVariants {
+			return
+		}
+		seen[key] = true
+		variants = append(variants, diagnoseVariant{label: strings.Join(changes, " · "), node: n})
+	}
+
+	addrIsDomain := net.ParseIP(node.Addr) == nil
+
+	switch node.ProtocolType {
+	case "vmess":
+		tlsOpts := []string{node.VMessTLS, toggle(node.VMessTLS, "tls")}
+		netOpts := []string{node.VMessNetwork}
+		switch node.VMessNetwork {
+		case "ws", "websocket":
+			netOpts = append(netOpts, "tcp")
+		case "", "tcp":
+			if node.VMessPath != "" {
+				netOpts = append(netOpts, "ws") // 带路径的 tcp 链接通常实为 ws
+			}
+		}
+		hostOpts := []string{node.VMessHost}
+		if node.VMessHost != "" {
+			hostOpts = append(hostOpts, "")
+		} else if addrIsDomain {
+			hostOpts = append(hostOpts, node.Addr)
+		}
+
+		for _, network := range netOpts {
+			for _, tls := range tlsOpts {
+				for _, host := range hostOpts {
+					n := node
+					n.VMessNetwork, n.VMessTLS, n.VMessHost = network, tls, host
+					var changes []string
+					if tls != node.VMessTLS {
+						changes = append(changes, "TLS: "+onOff(tls == "tls"))
+					}
+					if network != node.VMessNetwork {
+						changes = append(changes, "传输: "+network)
+					}
+					if host != node.VMessHost {
+						changes = append(changes, "Host/SNI: "+orNone(host))
+					}
+					add(n, changes)
+				}
+			}
+		}
+
+	case "trojan":
+		sniOpts := []string{node.TrojanSNI}
+		if node.TrojanSNI != "" {
+			sniOpts = append(sniOpts, "")
+		}
+		if addrIsDomain && node.TrojanSNI != node.Addr {
+			sniOpts = append(sniOpts, node.Addr)
+		}
+		alpnOpts := []string{node.TrojanAlpn, "h2,http/1.1", "http/1.1", ""}
+		insecureOpts := []bool{node.TrojanAllowInsecure}
+		if !node.TrojanAllowInsecure {
+			insecureOpts = append(insecureOpts, true)
+		}
+
+		for _, insecure := range insecureOpts {
+			for _, sni := range sniOpts {
+				for _, alpn := range alpnOpts {
+					n := node
+					n.TrojanSNI, n.TrojanAlpn, n.TrojanAllowInsecure = sni, alpn, insecure
+					var changes []string
+					if sni != node.TrojanSNI {
+						changes = append(changes, "SNI: "+orNone(sni))
+					}
+					if alpn != node.TrojanAlpn {
+						changes = append(changes, "ALPN: "+orNone(alpn))
+					}
+					if insecure != node.TrojanAllowInsecure {
+						changes = append(changes, "跳过证书校验")
+					}
+					add(n, changes)
+				}
+			}
+		}
+	}
+	return variants
+}
+
+// diagnoseKey 返回影响传输的字段组合，用于变体去重。
+func diagnoseKey(n model.Node) string {
+	return strings.Join([]string{
+		n.VMessNetwork, n.VMessTLS, n.VMessHost,
+		n.TrojanSNI, n.TrojanAlpn, fmt.Sprint(n.TrojanAllowInsecure),
+	}, "|")
+}
+
+// toggle 在空值与 on 之间切换。
+func toggle(v, on string) string {
+	if v == on {
+		return ""
+	}
+	return on
+}
+
+func onOff(b bool) string {
+	if b {
+		return "开启"
+	}
+	return "关闭"
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "无"
+	}
+	return s
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// 错误预算：统计窗口内失败达到该次数即判定节点抖动
+	nodeErrorBudget = 3
+	// 失败次数统计窗口
+	nodeErrorWindow = 5 * time.Minute
+	// 判定抖动后的冷却时长，期间自动切换逻辑不再选择该节点
+	nodeDegradedCooldown = 10 * time.Minute
+)
+
+// NodeHealthTracker 节点错误预算：记录健康检查和连接失败，窗口内失败过多的节点
+// 进入冷却期（降级），故障转移等自动逻辑跳过降级节点，避免在失效节点间反复切换。
+// 用户手动选择节点不受限制，也可在界面中手动恢复。状态仅保存在内存中。
+type NodeHealthTracker struct {
+	mu            sync.Mutex
+	failures      map[string][]time.Time
+	degradedUntil map[string]time.Time
+	onChange      func(nodeID string, degraded bool)
+	now           func() time.Time
+}
+
+// NewNodeHealthTracker 创建节点错误预算跟踪器。
+// 参数：
+//   - onChange: 节点进入或退出降级状态时的回调（可能在后台 goroutine 中调用），可为 nil
+//
+// 返回：跟踪器实例
+func NewNodeHealthTracker(onChange func(nodeID string, degraded bool)) *NodeHealthTracker {
+	return &NodeHealthTracker{
+		failures:      make(map[string][]time.Time),
+		degradedUntil: make(map[string]time.Time),
+		onChange:      onChange,
+		now:           time.Now,
+	}
+}
+
+// RecordFailure 记录一次失败（健康检查失败或连接失败）。
+// 返回：本次失败是否使节点进入降级状态
+func (t *NodeHealthTracker) RecordFailure(nodeID string) bool {
+	if t == nil || nodeID == "" {
+		return false
+	}
+	now := t.now()
+	t.mu.Lock()
+	if until, ok := t.degradedUntil[nodeID]; ok && now.Before(until) {
+		t.mu.Unlock()
+		return false
+	}
+	recent := t.failures[nodeID][:0]
+	for _, at := range t.failures[nodeID] {
+		if now.Sub(at) < nodeErrorWindow {
+			recent = append(recent, at)
+		}
+	}
+	recent = append(recent, now)
+	degraded := len(recent) >= nodeErrorBudget
+	if degraded {
+		t.degradedUntil[nodeID] = now.Add(nodeDegradedCooldown)
+		delete(t.failures, nodeID)
+	} else {
+		t.failures[nodeID] = recent
+	}
+	t.mu.Unlock()
+
+	if degraded && t.onChange != nil {
+		t.onChange(nodeID, true)
+	}
+	return degraded
+}
+
+// IsDegraded 判断节点是否处于冷却期，冷却期结束后自动恢复。
+func (t *NodeHealthTracker) IsDegraded(nodeID string) bool {
+	_, ok := t.DegradedUntil(nodeID)
+	return ok
+}
+
+// DegradedUntil 返回节点冷却期结束时间，未降级时第二个返回值为 false。
+func (t *NodeHealthTracker) DegradedUntil(nodeID string) (time.Time, bool) {
+	if t == nil {
+		return time.Time{}, false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	until, ok := t.degradedUntil[nodeID]
+	if !ok {
+		return time.Time{}, false
+	}
+	if !t.now().Before(until) {
+		delete(t.degradedUntil, nodeID)
+		return time.Time{}, false
+	}
+	return until, true
+}
+
+// Restore 手动恢复节点：清除降级状态和失败记录。
+func (t *NodeHealthTracker) Restore(nodeID string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	_, wasDegraded := t.degradedUntil[nodeID]
+	delete(t.degradedUntil, nodeID)
+	delete(t.failures, nodeID)
+	t.mu.Unlock()
+
+	if wasDegraded && t.onChange != nil {
+		t.onChange(nodeID, false)
+	}
+}
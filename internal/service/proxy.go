@@ -1,17 +1,43 @@
 package service
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
 
+	"golang.org/x/net/proxy"
+	"myproxy.com/p/internal/errs"
+	"myproxy.com/p/internal/model"
 	"myproxy.com/p/internal/systemproxy"
 	"myproxy.com/p/internal/xray"
 )
 
+const (
+	// 入站就绪检测的连接与握手超时
+	proxyReadyTimeout = 2 * time.Second
+	// 入站就绪检测时回环端口写出的探测数据
+	inboundProbeToken = "myproxy-inbound-ready"
+	// 设置系统代理后的观察期：期间代理停止或入站不可用则自动回滚系统代理
+	proxyGracePeriod = 15 * time.Second
+	// 观察期内的检测间隔
+	proxyGraceCheckInterval = 3 * time.Second
+)
+
 // ProxyService 系统代理服务层，提供系统代理相关的业务逻辑。
 type ProxyService struct {
 	systemProxy  *systemproxy.SystemProxy
 	xrayInstance *xray.XrayInstance
 	configService *ConfigService
+
+	mu         sync.Mutex          // 保护 xrayInstance、systemProxy 及以下字段
+	graceGen   int                 // 观察期代次，每次应用模式时递增，使旧的观察协程失效
+	onRollback func(reason string) // 观察期内自动回滚系统代理后的回调（在后台 goroutine 中调用）
+
+	changesMu sync.Mutex // 串行化系统改动清单的读写
 }
 
 // NewProxyService 创建新的代理服务实例。
@@ -31,23 +57,127 @@ func NewProxyService(xrayInstance *xray.XrayInstance, configService *ConfigServi
 
 // updateSystemProxyPort 更新系统代理管理器的端口。
 func (ps *ProxyService) updateSystemProxyPort() {
-	proxyPort := 10808
-	if ps.xrayInstance != nil && ps.xrayInstance.IsRunning() {
-		if port := ps.xrayInstance.GetPort(); port > 0 {
-			proxyPort = port
-		}
-	}
-	ps.systemProxy = systemproxy.NewSystemProxy("127.0.0.1", proxyPort)
+	sp := systemproxy.NewSystemProxy("127.0.0.1", ps.currentPort())
+	ps.mu.Lock()
+	ps.systemProxy = sp
+	ps.mu.Unlock()
 }
 
 // UpdateXrayInstance 更新 Xray 实例引用（当 Xray 实例变化时调用）。
 // 参数：
 //   - xrayInstance: Xray 实例
 func (ps *ProxyService) UpdateXrayInstance(xrayInstance *xray.XrayInstance) {
+	ps.mu.Lock()
 	ps.xrayInstance = xrayInstance
+	ps.mu.Unlock()
 	ps.updateSystemProxyPort()
 }
 
+// instance 返回当前 Xray 实例（观察期协程与界面调用并发读取，需加锁）。
+func (ps *ProxyService) instance() *xray.XrayInstance {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	return ps.xrayInstance
+}
+
+// SetOnRollback 设置系统代理自动回滚后的回调，调用方应同步界面状态并提示用户。
+func (ps *ProxyService) SetOnRollback(fn func(reason string)) {
+	ps.mu.Lock()
+	ps.onRollback = fn
+	ps.mu.Unlock()
+}
+
+// CheckInboundReady 检测本地入站是否可用：代理正在运行，且能经 127.0.0.1 入站以 SOCKS5 CONNECT
+// 连上本机临时监听的回环端口并读到探测数据。本地地址在路由中直连，检测结果不受远端节点影响。
+// 返回：未就绪的原因（就绪时为 nil）
+func (ps *ProxyService) CheckInboundReady() error {
+	inst := ps.instance()
+	if inst == nil || !inst.IsRunning() {
+		return fmt.Errorf("代理服务: %w", errs.ErrProxyNotRunning)
+	}
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(ps.currentPort()))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("代理服务: 创建回环探测端口失败: %w", err)
+	}
+	defer ln.Close()
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		_ = c.SetDeadline(time.Now().Add(proxyReadyTimeout))
+		_, _ = io.WriteString(c, inboundProbeToken)
+	}()
+
+	dialer, err := proxy.SOCKS5("tcp", addr, nil, &net.Dialer{Timeout: proxyReadyTimeout})
+	if err != nil {
+		return fmt.Errorf("代理服务: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), proxyReadyTimeout)
+	defer cancel()
+	conn, err := dialer.(proxy.ContextDialer).DialContext(ctx, "tcp", ln.Addr().String())
+	if err != nil {
+		return fmt.Errorf("代理服务: 经入站 %s 连接回环地址失败: %w", addr, err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(proxyReadyTimeout))
+
+	buf := make([]byte, len(inboundProbeToken))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return fmt.Errorf("代理服务: 经入站 %s 读取回环数据失败: %w", addr, err)
+	}
+	if string(buf) != inboundProbeToken {
+		return fmt.Errorf("代理服务: 经入站 %s 收到的回环数据不一致", addr)
+	}
+	return nil
+}
+
+// watchGracePeriod 设置系统代理后的观察期：代理停止或入站不可用时清除系统代理，避免断网。
+func (ps *ProxyService) watchGracePeriod(gen int, terminalEnabled bool) {
+	deadline := time.Now().Add(proxyGracePeriod)
+	for time.Now().Before(deadline) {
+		time.Sleep(proxyGraceCheckInterval)
+		ps.mu.Lock()
+		current := ps.graceGen == gen
+		onRollback := ps.onRollback
+		sp := ps.systemProxy
+		ps.mu.Unlock()
+		if !current {
+			return
+		}
+		err := ps.CheckInboundReady()
+		if err == nil {
+			continue
+		}
+		// 重建代理（如路由变更）时入站会短暂不可用，稍后复查一次再回滚
+		time.Sleep(time.Second)
+		if err = ps.CheckInboundReady(); err == nil {
+			continue
+		}
+		// 两次检查耗时数秒，期间用户可能已切换模式：持锁复查代次后再回滚，
+		// 新的 ApplySystemProxyMode 须等回滚结束才能递增代次，不会被本协程清除刚设置的代理
+		ps.mu.Lock()
+		if ps.graceGen != gen {
+			ps.mu.Unlock()
+			return
+		}
+		if sp.ClearSystemProxy() == nil {
+			ps.forgetSystemChange(model.SystemChangeProxy)
+		}
+		if terminalEnabled && sp.ClearTerminalProxy() == nil {
+			ps.forgetSystemChange(model.SystemChangeTerminal)
+		}
+		ps.mu.Unlock()
+		if onRollback != nil {
+			onRollback(err.Error())
+		}
+		return
+	}
+}
+
 // ApplySystemProxyModeResult 系统代理操作结果。
 type ApplySystemProxyModeResult struct {
 	LogMessage string // 日志消息
@@ -55,12 +185,27 @@ type ApplySystemProxyModeResult struct {
 }
 
 // ApplySystemProxyMode 应用系统代理模式。
+// 是否同时设置/清除终端代理由配置项 terminalProxyEnabled 决定。
 // 参数：
 //   - mode: 系统代理模式（clear, auto, terminal）
 //
 // 返回：操作结果（包含日志消息和错误）
 func (ps *ProxyService) ApplySystemProxyMode(mode string) *ApplySystemProxyModeResult {
 	ps.updateSystemProxyPort()
+	proxyPort := ps.currentPort()
+
+	// 新的模式使之前的观察期失效
+	ps.mu.Lock()
+	ps.graceGen++
+	gen := ps.graceGen
+	ps.mu.Unlock()
+
+	terminalEnabled := false
+	proxyType := "socks5"
+	if ps.configService != nil {
+		terminalEnabled = ps.configService.GetTerminalProxyEnabled()
+		proxyType = ps.configService.GetProxyType()
+	}
 
 	var err error
 	var logMessage string
@@ -68,52 +213,73 @@ func (ps *ProxyService) ApplySystemProxyMode(mode string) *ApplySystemProxyModeR
 	switch mode {
 	case "clear":
 		err = ps.systemProxy.ClearSystemProxy()
-		terminalErr := ps.systemProxy.ClearTerminalProxy()
-		if err == nil && terminalErr == nil {
-			logMessage = "已清除系统代理设置和环境变量代理"
-		} else if err != nil && terminalErr != nil {
-			logMessage = fmt.Sprintf("清除系统代理失败: %v; 清除环境变量代理失败: %v", err, terminalErr)
-			err = fmt.Errorf("代理服务: 清除失败: %v; %v", err, terminalErr)
-		} else if err != nil {
-			logMessage = fmt.Sprintf("清除系统代理失败: %v; 已清除环境变量代理", err)
+		if err == nil {
+			ps.forgetSystemChange(model.SystemChangeProxy)
+		}
+		if terminalEnabled {
+			terminalErr := ps.systemProxy.ClearTerminalProxy()
+			if terminalErr == nil {
+				ps.forgetSystemChange(model.SystemChangeTerminal)
+			}
+			if err == nil && terminalErr == nil {
+				logMessage = "已清除系统代理设置和环境变量代理"
+			} else if err != nil && terminalErr != nil {
+				logMessage = fmt.Sprintf("清除系统代理失败: %v; 清除环境变量代理失败: %v", err, terminalErr)
+				err = fmt.Errorf("代理服务: 清除失败: %v; %v", err, terminalErr)
+			} else if err != nil {
+				logMessage = fmt.Sprintf("清除系统代理失败: %v; 已清除环境变量代理", err)
+			} else {
+				logMessage = fmt.Sprintf("已清除系统代理设置; 清除环境变量代理失败: %v", terminalErr)
+				err = terminalErr
+			}
+		} else if err == nil {
+			logMessage = "已清除系统代理设置"
 		} else {
-			logMessage = fmt.Sprintf("已清除系统代理设置; 清除环境变量代理失败: %v", terminalErr)
-			err = terminalErr
+			logMessage = fmt.Sprintf("清除系统代理失败: %v", err)
 		}
 
 	case "auto":
+		// 就绪检查：入站确认可用后才修改系统代理，否则系统流量会被导向无人监听的端口
+		if readyErr := ps.CheckInboundReady(); readyErr != nil {
+			return &ApplySystemProxyModeResult{
+				LogMessage: fmt.Sprintf("代理入站未就绪，未修改系统代理: %v", readyErr),
+				Error:      fmt.Errorf("代理入站未就绪，未修改系统代理: %w", readyErr),
+			}
+		}
 		_ = ps.systemProxy.ClearSystemProxy()
-		_ = ps.systemProxy.ClearTerminalProxy()
 		err = ps.systemProxy.SetSystemProxy()
 		if err == nil {
-			proxyPort := 10808
-			if ps.xrayInstance != nil && ps.xrayInstance.IsRunning() {
-				if port := ps.xrayInstance.GetPort(); port > 0 {
-					proxyPort = port
+			ps.recordSystemChange(model.SystemChangeProxy)
+			go ps.watchGracePeriod(gen, terminalEnabled)
+			logMessage = fmt.Sprintf("已自动配置系统代理: 127.0.0.1:%d", proxyPort)
+			if terminalEnabled {
+				if terminalErr := ps.systemProxy.SetTerminalProxy(proxyType); terminalErr == nil {
+					ps.recordSystemChange(model.SystemChangeTerminal)
+					logMessage += "；已设置环境变量代理"
+				} else {
+					logMessage += fmt.Sprintf("；设置环境变量代理失败: %v", terminalErr)
 				}
 			}
-			logMessage = fmt.Sprintf("已自动配置系统代理: 127.0.0.1:%d", proxyPort)
 		} else {
 			logMessage = fmt.Sprintf("自动配置系统代理失败: %v", err)
 		}
 
 	case "terminal":
-		_ = ps.systemProxy.ClearSystemProxy()
-		_ = ps.systemProxy.ClearTerminalProxy()
-		// 获取代理类型
-		proxyType := "socks5"
-		if ps.configService != nil {
-			proxyType = ps.configService.GetProxyType()
+		// 就绪检查：环境变量会写入 shell 配置文件，指向无人监听的端口时新开的终端都无法联网
+		if readyErr := ps.CheckInboundReady(); readyErr != nil {
+			return &ApplySystemProxyModeResult{
+				LogMessage: fmt.Sprintf("代理入站未就绪，未设置环境变量代理: %v", readyErr),
+				Error:      fmt.Errorf("代理入站未就绪，未设置环境变量代理: %w", readyErr),
+			}
 		}
+		if ps.systemProxy.ClearSystemProxy() == nil {
+			ps.forgetSystemChange(model.SystemChangeProxy)
+		}
+		_ = ps.systemProxy.ClearTerminalProxy()
 		err = ps.systemProxy.SetTerminalProxy(proxyType)
 		if err == nil {
-			proxyPort := 10808
-			if ps.xrayInstance != nil && ps.xrayInstance.IsRunning() {
-				if port := ps.xrayInstance.GetPort(); port > 0 {
-					proxyPort = port
-				}
-			}
-			logMessage = fmt.Sprintf("已设置环境变量代理: socks5://127.0.0.1:%d (已写入shell配置文件)", proxyPort)
+			ps.recordSystemChange(model.SystemChangeTerminal)
+			logMessage = fmt.Sprintf("已设置环境变量代理: %s://127.0.0.1:%d (已写入shell配置文件)", proxyType, proxyPort)
 		} else {
 			logMessage = fmt.Sprintf("设置环境变量代理失败: %v", err)
 		}
@@ -130,3 +296,80 @@ func (ps *ProxyService) ApplySystemProxyMode(mode string) *ApplySystemProxyModeR
 		Error:      err,
 	}
 }
+
+// StaleTerminalProxy 过期的终端代理环境变量文件。
+type StaleTerminalProxy struct {
+	File        *systemproxy.TerminalProxyFile
+	Reason      string // 过期原因
+	Refreshable bool   // 当前设置仍使用终端代理，可改写为当前端口；否则只能删除
+}
+
+// terminalProxyInUse 当前设置是否会写入终端代理文件：终端代理模式，或自动配置系统代理且勾选了终端代理。
+func (ps *ProxyService) terminalProxyInUse() bool {
+	if ps.configService == nil {
+		return false
+	}
+	switch ps.configService.GetSystemProxyMode() {
+	case model.SystemProxyModeNameTerminal:
+		return true
+	case model.SystemProxyModeNameAuto:
+		return ps.configService.GetTerminalProxyEnabled()
+	}
+	return false
+}
+
+// CheckStaleTerminalProxy 检查终端代理环境变量文件是否过期：文件指向的端口不是本程序当前的入站端口，
+// 或当前设置已不再使用终端代理（如上次异常退出未清理）。过期文件会让新开的终端连向无人监听的端口。
+// 返回：过期文件信息（文件不存在或未过期时为 nil）和错误（如果有）
+func (ps *ProxyService) CheckStaleTerminalProxy() (*StaleTerminalProxy, error) {
+	file, err := systemproxy.ReadTerminalProxyFile()
+	if err != nil || file == nil {
+		return nil, err
+	}
+	inUse := ps.terminalProxyInUse()
+	port := ps.currentPort()
+	switch {
+	case !inUse:
+		return &StaleTerminalProxy{File: file, Reason: "当前未使用终端代理，文件是之前遗留的"}, nil
+	case file.Port != port:
+		return &StaleTerminalProxy{File: file, Refreshable: true,
+			Reason: fmt.Sprintf("文件指向端口 %d，当前入站端口为 %d", file.Port, port)}, nil
+	}
+	return nil, nil
+}
+
+// RefreshTerminalProxy 按当前入站端口和代理类型改写终端代理文件，入站未就绪时不改写。
+func (ps *ProxyService) RefreshTerminalProxy() error {
+	if err := ps.CheckInboundReady(); err != nil {
+		return fmt.Errorf("代理入站未就绪，无法刷新终端代理: %w", err)
+	}
+	ps.updateSystemProxyPort()
+	proxyType := "socks5"
+	if ps.configService != nil {
+		proxyType = ps.configService.GetProxyType()
+	}
+	if err := ps.systemProxy.SetTerminalProxy(proxyType); err != nil {
+		return err
+	}
+	ps.recordSystemChange(model.SystemChangeTerminal)
+	return nil
+}
+
+// RemoveTerminalProxy 删除终端代理文件及 shell 配置文件中的引用。
+func (ps *ProxyService) RemoveTerminalProxy() error {
+	if err := ps.systemProxy.ClearTerminalProxy(); err != nil {
+		return err
+	}
+	ps.forgetSystemChange(model.SystemChangeTerminal)
+	return nil
+}
+
+// currentPort 返回当前代理监听端口，未运行时返回默认端口 10808。
+func (ps *ProxyService) currentPort() int {
+	if inst := ps.instance(); inst != nil && inst.IsRunning() {
+		if port := inst.GetPort(); port > 0 {
+			return port
+		}
+	}
+	return 10808
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"myproxy.com/p/internal/model"
+)
+
+// ProxySnippet 可直接粘贴使用的本地代理配置片段。
+type ProxySnippet struct {
+	Name string // 名称，如 curl、git
+	Text string // 片段内容
+	Note string // 使用提示（可为空）
+}
+
+// ProxySnippets 生成当前本地代理的常用配置片段：curl 命令、终端环境变量以及 git / npm / pip 设置。
+// SOCKS5 地址使用 socks5h，由代理端解析域名；npm、pip 等仅支持 HTTP 代理的工具使用 HTTP 地址。
+// 返回：片段列表（顺序固定）
+func (ps *ProxyService) ProxySnippets() []ProxySnippet {
+	socks := fmt.Sprintf("socks5h://127.0.0.1:%d", ps.currentPort())
+	httpProxy := ps.httpInboundURL()
+
+	shell := fmt.Sprintf("export ALL_PROXY=%s all_proxy=%s http_proxy=%s https_proxy=%s HTTP_PROXY=%s HTTPS_PROXY=%s",
+		socks, socks, httpProxy, httpProxy, httpProxy, httpProxy)
+	powershell := fmt.Sprintf(`$env:ALL_PROXY="%s"; $env:HTTP_PROXY="%s"; $env:HTTPS_PROXY="%s"`, socks, httpProxy, httpProxy)
+
+	snippets := []ProxySnippet{
+		{Name: "curl", Text: fmt.Sprintf("curl --socks5-hostname 127.0.0.1:%d https://www.google.com", ps.currentPort())},
+		{Name: "终端 (bash/zsh)", Text: shell},
+		{Name: "终端 (PowerShell)", Text: powershell},
+		{Name: "git", Text: fmt.Sprintf("git config --global http.proxy %s", socks)},
+		{Name: "npm", Text: fmt.Sprintf("npm config set proxy %s && npm config set https-proxy %s", httpProxy, httpProxy)},
+		{Name: "pip", Text: fmt.Sprintf("pip config set global.proxy %s", httpProxy)},
+	}
+	return snippets
+}
+
+// DockerSnippets 生成容器使用本地代理的配置片段：Docker 守护进程（拉取镜像）的 daemon.json 代理设置，
+// 以及 docker run 传入代理环境变量的写法。本地代理只监听 127.0.0.1，容器需经 Docker Desktop 的
+// host.docker.internal 转发，或在 Linux 上使用 host 网络访问。
+// 返回：片段列表（顺序固定）
+func (ps *ProxyService) DockerSnippets() []ProxySnippet {
+	port := ps.currentPort()
+	httpPort := ps.httpInboundPort()
+
+	// 守护进程运行在宿主机上，可直接使用 127.0.0.1；使用 HTTP 地址，兼容性更好
+	daemonProxy := fmt.Sprintf("http://127.0.0.1:%d", httpPort)
+	daemon := fmt.Sprintf(`{
+  "proxies": {
+    "http-proxy": "%s",
+    "https-proxy": "%s",
+    "no-proxy": "localhost,127.0.0.0/8"
+  }
+}`, daemonProxy, daemonProxy)
+
+	// dockerRunEnv 生成指向 host 的代理环境变量参数
+	dockerRunEnv := func(host string) string {
+		socks := fmt.Sprintf("socks5h://%s:%d", host, port)
+		httpProxy := fmt.Sprintf("http://%s:%d", host, httpPort)
+		return fmt.Sprintf("-e ALL_PROXY=%s -e all_proxy=%s -e HTTP_PROXY=%s -e HTTPS_PROXY=%s -e http_proxy=%s -e https_proxy=%s -e NO_PROXY=localhost,127.0.0.1",
+			socks, socks, httpProxy, httpProxy, httpProxy, httpProxy)
+	}
+
+	return []ProxySnippet{
+		{
+			Name: "Docker 守护进程 (daemon.json)",
+			Text: daemon,
+			Note: "用于 docker pull 等守护进程访问（Docker 23.0 及以上）：Linux 写入 /etc/docker/daemon.json，Docker Desktop 在 Settings → Docker Engine 中合并，重启 Docker 后生效",
+		},
+		{
+			Name: "docker run (Docker Desktop)",
+			Text: fmt.Sprintf("docker run %s <镜像>", dockerRunEnv("host.docker.internal")),
+			Note: "host.docker.internal 由 Docker Desktop（macOS / Windows）解析为宿主机；Linux 上本地代理只监听 127.0.0.1，容器无法经 host.docker.internal 访问，请使用下方 host 网络写法",
+		},
+		{
+			Name: "docker run (Linux host 网络)",
+			Text: fmt.Sprintf("docker run --network host %s <镜像>", dockerRunEnv("127.0.0.1")),
+		},
+		{
+			Name: "docker build",
+			Text: fmt.Sprintf("docker build --network host %s .", strings.ReplaceAll(dockerRunEnv("127.0.0.1"), "-e ", "--build-arg ")),
+			Note: "构建期间的代理变量属于 Docker 预定义构建参数，不会写入镜像历史；Docker Desktop 上可将 127.0.0.1 换成 host.docker.internal 并去掉 --network host",
+		},
+	}
+}
+
+// httpInboundPort 返回 HTTP 代理端口：优先使用第一个已启用的 HTTP 多入站，
+// 未配置时使用主端口（主端口按首字节自动识别 SOCKS 与 HTTP，同样可作为 HTTP 代理）。
+func (ps *ProxyService) httpInboundPort() int {
+	if ps.configService != nil {
+		for _, p := range ps.configService.GetInboundProfiles() {
+			if p.Enabled && p.Protocol == model.InboundProtocolHTTP {
+				return p.Port
+			}
+		}
+	}
+	return ps.currentPort()
+}
+
+// httpInboundURL 返回 HTTP 代理地址（见 httpInboundPort）。
+func (ps *ProxyService) httpInboundURL() string {
+	return fmt.Sprintf("http://127.0.0.1:%d", ps.httpInboundPort())
+}
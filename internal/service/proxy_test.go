@@ -0,0 +1,38 @@
+package service
+
+import (
+	"testing"
+
+	"myproxy.com/p/internal/model"
+)
+
+func TestTerminalProxyInUse(t *testing.T) {
+	tests := []struct {
+		name            string
+		mode            string
+		terminalEnabled bool
+		want            bool
+	}{
+		{name: "未保存模式", mode: "", want: false},
+		{name: "清除", mode: model.SystemProxyModeNameClear, terminalEnabled: true, want: false},
+		{name: "终端代理", mode: model.SystemProxyModeNameTerminal, want: true},
+		{name: "系统代理未勾选终端", mode: model.SystemProxyModeNameAuto, want: false},
+		{name: "系统代理并勾选终端", mode: model.SystemProxyModeNameAuto, terminalEnabled: true, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestStore(t)
+			cs := NewConfigService(s)
+			if err := cs.SetSystemProxyMode(tt.mode); err != nil {
+				t.Fatalf("保存系统代理模式失败: %v", err)
+			}
+			if err := cs.SetTerminalProxyEnabled(tt.terminalEnabled); err != nil {
+				t.Fatalf("保存终端代理开关失败: %v", err)
+			}
+			ps := NewProxyService(nil, cs)
+			if got := ps.terminalProxyInUse(); got != tt.want {
+				t.Errorf("terminalProxyInUse() = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
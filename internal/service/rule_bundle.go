@@ -0,0 +1,154 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"myproxy.com/p/internal/model"
+)
+
+const (
+	// ruleBundleMaxSize 规则包文件的大小上限
+	ruleBundleMaxSize = 4 << 20
+	// RuleBundleSignatureExt 签名文件的扩展名：规则包 rules.json 的签名为 rules.json.sig
+	RuleBundleSignatureExt = ".sig"
+)
+
+// SignatureStatus 规则包的签名校验结果。
+type SignatureStatus int
+
+const (
+	SignatureVerified SignatureStatus = iota // 签名有效，且签名者在受信任公钥中
+	SignatureUnsigned                        // 没有签名文件
+	SignatureInvalid                         // 有签名但无法用任何受信任公钥验证：内容被篡改或签名者不受信任
+)
+
+// RuleBundleImport 读取并校验后、尚未应用的规则包。
+type RuleBundleImport struct {
+	Bundle model.RuleBundle
+	Source string          // 来源（文件名或 URL）
+	Status SignatureStatus // 签名校验结果
+	Signer string          // 签名有效时为对应受信任公钥的名称
+	Detail string          // 签名无效时的原因
+}
+
+// VerifyRuleBundle 校验规则包签名并解析内容。签名是对文件原始字节的 ed25519 签名，
+// 以 Base64 保存；签名无效时仍返回解析结果，由调用方决定是否在用户确认后应用。
+// 参数：
+//   - data: 规则包文件内容（JSON）
+//   - sig: 签名文件内容，nil 表示没有签名
+//   - keys: 受信任的签名公钥
+//
+// 返回：校验结果和错误（内容无法解析或没有有效规则时）
+func VerifyRuleBundle(data, sig []byte, keys []model.TrustedKey) (*RuleBundleImport, error) {
+	result := &RuleBundleImport{Status: SignatureUnsigned}
+	if sig != nil {
+		result.Status, result.Signer, result.Detail = verifyRuleBundleSignature(data, sig, keys)
+	}
+	if err := json.Unmarshal(data, &result.Bundle); err != nil {
+		return nil, fmt.Errorf("规则包: 解析失败: %w", err)
+	}
+	if err := result.Bundle.Validate(); err != nil {
+		return nil, fmt.Errorf("规则包: %w", err)
+	}
+	return result, nil
+}
+
+// verifyRuleBundleSignature 依次用受信任公钥验证签名。
+// 返回：校验结果、签名者名称和失败原因
+func verifyRuleBundleSignature(data, sig []byte, keys []model.TrustedKey) (SignatureStatus, string, string) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil || len(raw) != ed25519.SignatureSize {
+		return SignatureInvalid, "", "签名文件格式无效（应为 Base64 编码的 ed25519 签名）"
+	}
+	if len(keys) == 0 {
+		return SignatureInvalid, "", "尚未添加受信任公钥，无法验证签名"
+	}
+	for _, k := range keys {
+		key, err := k.Key()
+		if err != nil {
+			continue
+		}
+		if ed25519.Verify(key, data, raw) {
+			return SignatureVerified, k.Name, ""
+		}
+	}
+	return SignatureInvalid, "", "签名与所有受信任公钥都不匹配：内容可能被篡改，或签名者不在受信任公钥中"
+}
+
+// FetchRuleBundle 从 URL 下载规则包，并尝试下载同一地址加 .sig 的签名文件后校验。
+// 签名文件不存在（404）时视为未签名；其他下载失败返回错误，避免把签名下载失败误当作未签名。
+// 参数：
+//   - rawURL: 规则包地址（http / https）
+//   - keys: 受信任的签名公钥
+//
+// 返回：校验结果和错误（如果有）
+func FetchRuleBundle(rawURL string, keys []model.TrustedKey) (*RuleBundleImport, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("规则包: 地址无效（应为 http:// 或 https:// 开头）: %s", rawURL)
+	}
+	client := &http.Client{Timeout: 15 * time.Second}
+
+	data, err := fetchRuleBundleFile(client, u.String())
+	if err != nil {
+		return nil, fmt.Errorf("规则包: 下载失败: %w", err)
+	}
+	if data == nil {
+		return nil, fmt.Errorf("规则包: 下载失败: %s 不存在", u.String())
+	}
+
+	sigURL := *u
+	sigURL.Path += RuleBundleSignatureExt
+	sigURL.RawPath = ""
+	sig, err := fetchRuleBundleFile(client, sigURL.String())
+	if err != nil {
+		return nil, fmt.Errorf("规则包: 下载签名失败: %w", err)
+	}
+
+	result, err := VerifyRuleBundle(data, sig, keys)
+	if err != nil {
+		return nil, err
+	}
+	result.Source = u.String()
+	return result, nil
+}
+
+// fetchRuleBundleFile 下载文件内容，404 时返回 nil 和 nil 错误。
+func fetchRuleBundleFile(client *http.Client, rawURL string) ([]byte, error) {
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s 返回 %s", rawURL, resp.Status)
+	}
+	return ReadRuleBundleFile(resp.Body)
+}
+
+// ReadRuleBundleFile 读取规则包或签名文件，超过大小上限时返回错误。
+func ReadRuleBundleFile(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, ruleBundleMaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > ruleBundleMaxSize {
+		return nil, fmt.Errorf("文件超过 %d MB", ruleBundleMaxSize>>20)
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	return data, nil
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+
+	"myproxy.com/p/internal/model"
+)
+
+func TestVerifyRuleBundle(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("生成签名密钥失败: %v", err)
+	}
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("生成签名密钥失败: %v", err)
+	}
+	trusted := model.TrustedKey{Name: "发布者", PublicKey: base64.StdEncoding.EncodeToString(pub)}
+	other := model.TrustedKey{Name: "其他", PublicKey: base64.StdEncoding.EncodeToString(otherPub)}
+
+	data := []byte(`{"name":"测试","rules":[{"target":"example.com","action":"block"}]}`)
+	tampered := []byte(`{"name":"测试","rules":[{"target":"example.org","action":"block"}]}`)
+	sig := []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, data)) + "\n")
+	shortSig := []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, data)[:ed25519.SignatureSize-1]))
+
+	tests := []struct {
+		name       string
+		data       []byte
+		sig        []byte
+		keys       []model.TrustedKey
+		wantStatus SignatureStatus
+		wantSigner string
+	}{
+		{name: "签名有效", data: data, sig: sig, keys: []model.TrustedKey{other, trusted}, wantStatus: SignatureVerified, wantSigner: "发布者"},
+		{name: "没有签名", data: data, sig: nil, keys: []model.TrustedKey{trusted}, wantStatus: SignatureUnsigned},
+		{name: "内容被篡改", data: tampered, sig: sig, keys: []model.TrustedKey{trusted}, wantStatus: SignatureInvalid},
+		{name: "签名者不受信任", data: data, sig: sig, keys: []model.TrustedKey{other}, wantStatus: SignatureInvalid},
+		{name: "签名不是 Base64", data: data, sig: []byte("不是签名"), keys: []model.TrustedKey{trusted}, wantStatus: SignatureInvalid},
+		{name: "签名长度错误", data: data, sig: shortSig, keys: []model.TrustedKey{trusted}, wantStatus: SignatureInvalid},
+		{name: "没有受信任公钥", data: data, sig: sig, keys: nil, wantStatus: SignatureInvalid},
+		{name: "跳过无效公钥", data: data, sig: sig, keys: []model.TrustedKey{{Name: "无效", PublicKey: "abc"}, trusted}, wantStatus: SignatureVerified, wantSigner: "发布者"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := VerifyRuleBundle(tt.data, tt.sig, tt.keys)
+			if err != nil {
+				t.Fatalf("VerifyRuleBundle 返回错误: %v", err)
+			}
+			if result.Status != tt.wantStatus {
+				t.Errorf("Status = %v，期望 %v（%s）", result.Status, tt.wantStatus, result.Detail)
+			}
+			if result.Signer != tt.wantSigner {
+				t.Errorf("Signer = %q，期望 %q", result.Signer, tt.wantSigner)
+			}
+			if tt.wantStatus == SignatureInvalid && result.Detail == "" {
+				t.Error("签名无效时应给出原因")
+			}
+			if len(result.Bundle.Rules) != 1 {
+				t.Errorf("签名无效时也应返回解析结果，得到 %d 条规则", len(result.Bundle.Rules))
+			}
+		})
+	}
+}
+
+func TestVerifyRuleBundleRejectsInvalidContent(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "不是 JSON", data: "rules"},
+		{name: "没有规则", data: `{"name":"空"}`},
+		{name: "未知动作", data: `{"rules":[{"target":"example.com","action":"drop"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := VerifyRuleBundle([]byte(tt.data), nil, nil); err == nil {
+				t.Error("内容无效时应返回错误")
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"fmt"
 
+	"myproxy.com/p/internal/errs"
 	"myproxy.com/p/internal/model"
 	"myproxy.com/p/internal/store"
 )
@@ -28,7 +29,7 @@ func NewServerService(store *store.Store) *ServerService {
 // 返回：服务器列表和错误（如果有）
 func (ss *ServerService) GetAllServers() ([]*model.Node, error) {
 	if ss.store == nil || ss.store.Nodes == nil {
-		return nil, fmt.Errorf("服务器服务: Store 未初始化")
+		return nil, fmt.Errorf("服务器服务: %w", errs.ErrStoreNotInitialized)
 	}
 	return ss.store.Nodes.GetAll(), nil
 }
@@ -40,7 +41,7 @@ func (ss *ServerService) GetAllServers() ([]*model.Node, error) {
 // 返回：服务器节点和错误（如果有）
 func (ss *ServerService) GetServerByID(id string) (*model.Node, error) {
 	if ss.store == nil || ss.store.Nodes == nil {
-		return nil, fmt.Errorf("服务器服务: Store 未初始化")
+		return nil, fmt.Errorf("服务器服务: %w", errs.ErrStoreNotInitialized)
 	}
 	return ss.store.Nodes.Get(id)
 }
@@ -89,7 +90,7 @@ func (ss *ServerService) ListServers() []model.Node {
 // 返回：服务器列表和错误（如果有）
 func (ss *ServerService) GetServersBySubscriptionID(subscriptionID int64) ([]model.Node, error) {
 	if ss.store == nil || ss.store.Nodes == nil {
-		return nil, fmt.Errorf("服务器服务: Store 未初始化")
+		return nil, fmt.Errorf("服务器服务: %w", errs.ErrStoreNotInitialized)
 	}
 
 	nodes, err := ss.store.Nodes.GetBySubscriptionID(subscriptionID)
@@ -113,12 +114,64 @@ func (ss *ServerService) GetServersBySubscriptionID(subscriptionID int64) ([]mod
 // 返回：错误（如果有）
 func (ss *ServerService) UpdateServerDelay(id string, delay int) error {
 	if ss.store == nil || ss.store.Nodes == nil {
-		return fmt.Errorf("服务器服务: Store 未初始化")
+		return fmt.Errorf("服务器服务: %w", errs.ErrStoreNotInitialized)
 	}
 
 	return ss.store.Nodes.UpdateDelay(id, delay)
 }
 
+// UpdateLatency 保存一次测速的采样统计，有成功采样时同时更新延迟（取中位数）。
+// 参数：
+//   - id: 服务器ID
+//   - stats: 测速采样统计
+//
+// 返回：错误（如果有）
+func (ss *ServerService) UpdateLatency(id string, stats model.LatencyStats) error {
+	if ss.store == nil || ss.store.Nodes == nil {
+		return fmt.Errorf("服务器服务: %w", errs.ErrStoreNotInitialized)
+	}
+
+	return ss.store.Nodes.UpdateLatency(id, stats)
+}
+
+// UpdateNotes 更新节点备注。
+// 参数：
+//   - id: 服务器ID
+//   - notes: 备注
+//
+// 返回：错误（如果有）
+func (ss *ServerService) UpdateNotes(id, notes string) error {
+	if ss.store == nil || ss.store.Nodes == nil {
+		return fmt.Errorf("服务器服务: %w", errs.ErrStoreNotInitialized)
+	}
+
+	return ss.store.Nodes.UpdateNotes(id, notes)
+}
+
+// UpdateOverrides 更新节点的 SNI、Host、Path 覆盖参数，留空表示使用订阅中的值。
+// 参数：
+//   - id: 服务器ID
+//   - sni, host, path: 覆盖参数
+//
+// 返回：错误（如果有）
+func (ss *ServerService) UpdateOverrides(id, sni, host, path string) error {
+	if ss.store == nil || ss.store.Nodes == nil {
+		return fmt.Errorf("服务器服务: %w", errs.ErrStoreNotInitialized)
+	}
+
+	return ss.store.Nodes.UpdateOverrides(id, sni, host, path)
+}
+
+// ClearHistory 清空所有节点的连接历史（最近连接时间和累计连接时长）。
+// 返回：错误（如果有）
+func (ss *ServerService) ClearHistory() error {
+	if ss.store == nil || ss.store.Nodes == nil {
+		return fmt.Errorf("服务器服务: %w", errs.ErrStoreNotInitialized)
+	}
+
+	return ss.store.Nodes.ClearHistory()
+}
+
 // AddOrUpdateServer 添加或更新服务器。
 // 参数：
 //   - node: 服务器节点
@@ -127,12 +180,26 @@ func (ss *ServerService) UpdateServerDelay(id string, delay int) error {
 // 返回：错误（如果有）
 func (ss *ServerService) AddOrUpdateServer(node model.Node, subscriptionID *int64) error {
 	if ss.store == nil || ss.store.Nodes == nil {
-		return fmt.Errorf("服务器服务: Store 未初始化")
+		return fmt.Errorf("服务器服务: %w", errs.ErrStoreNotInitialized)
 	}
 
 	return ss.store.Nodes.Add(&node)
 }
 
+// SaveUserEdit 保存用户对节点的修改（重命名、调整传输等），并标记为用户修改，
+// 订阅更新时按冲突策略处理而不是直接覆盖。
+// 参数：
+//   - node: 修改后的节点
+//
+// 返回：错误（如果有）
+func (ss *ServerService) SaveUserEdit(node model.Node) error {
+	if ss.store == nil || ss.store.Nodes == nil {
+		return fmt.Errorf("服务器服务: %w", errs.ErrStoreNotInitialized)
+	}
+	node.UserModified = true
+	return ss.store.Nodes.Update(&node)
+}
+
 // DeleteServer 删除服务器。
 // 参数：
 //   - id: 服务器ID
@@ -140,7 +207,7 @@ func (ss *ServerService) AddOrUpdateServer(node model.Node, subscriptionID *int6
 // 返回：错误（如果有）
 func (ss *ServerService) DeleteServer(id string) error {
 	if ss.store == nil || ss.store.Nodes == nil {
-		return fmt.Errorf("服务器服务: Store 未初始化")
+		return fmt.Errorf("服务器服务: %w", errs.ErrStoreNotInitialized)
 	}
 
 	return ss.store.Nodes.Delete(id)
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"myproxy.com/p/internal/errs"
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/store"
+)
+
+// 局域网分享默认有效期
+const defaultShareTTL = 5 * time.Minute
+
+// SharePayload 局域网分享的数据内容，节点与订阅二选一或同时存在。
+type SharePayload struct {
+	Nodes         []model.Node         `json:"nodes,omitempty"`
+	Subscriptions []model.Subscription `json:"subscriptions,omitempty"`
+}
+
+// ShareSession 一次分享会话的信息，URL 中已包含访问令牌。
+type ShareSession struct {
+	URL       string
+	Token     string
+	ExpiresAt time.Time
+}
+
+// ShareImportResult 接收分享后的导入结果。
+type ShareImportResult struct {
+	NodeCount         int
+	SubscriptionCount int
+}
+
+// ShareService 局域网节点分享服务，在本机临时开启带令牌的 HTTP 端点供另一台设备拉取。
+type ShareService struct {
+	store *store.Store
+
+	mu      sync.Mutex
+	server  *http.Server
+	session *ShareSession
+	timer   *time.Timer
+}
+
+// NewShareService 创建局域网分享服务实例。
+// 参数：
+//   - store: Store 实例，用于导入接收到的节点和订阅
+//
+// 返回：初始化后的 ShareService 实例
+func NewShareService(store *store.Store) *ShareService {
+	return &ShareService{
+		store: store,
+	}
+}
+
+// StartShare 开启分享：监听局域网地址的随机端口，有效期到后自动关闭。
+// 同一时间只保留一个分享会话，重复调用会先关闭旧会话。
+// 参数：
+//   - payload: 要分享的节点或订阅
+//
+// 返回：分享会话信息和错误（如果有）
+func (ss *ShareService) StartShare(payload *SharePayload) (*ShareSession, error) {
+	if payload == nil || (len(payload.Nodes) == 0 && len(payload.Subscriptions) == 0) {
+		return nil, fmt.Errorf("分享服务: 没有可分享的内容")
+	}
+
+	ss.StopShare()
+
+	ip, err := lanIPv4()
+	if err != nil {
+		return nil, fmt.Errorf("分享服务: %w", err)
+	}
+
+	tokenBytes := make([]byte, 16)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, fmt.Errorf("分享服务: 生成令牌失败: %w", err)
+	}
+	token := hex.EncodeToString(tokenBytes)
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("分享服务: 序列化分享内容失败: %w", err)
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(ip, "0"))
+	if err != nil {
+		return nil, fmt.Errorf("分享服务: 监听端口失败: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/share", func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("token")), []byte(token)) != 1 {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	})
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	session := &ShareSession{
+		URL:       fmt.Sprintf("http://%s/share?token=%s", listener.Addr().String(), token),
+		Token:     token,
+		ExpiresAt: time.Now().Add(defaultShareTTL),
+	}
+
+	ss.mu.Lock()
+	ss.server = server
+	ss.session = session
+	ss.timer = time.AfterFunc(defaultShareTTL, ss.StopShare)
+	ss.mu.Unlock()
+
+	return session, nil
+}
+
+// StopShare 关闭当前分享会话（如果有）。
+func (ss *ShareService) StopShare() {
+	ss.mu.Lock()
+	server := ss.server
+	ss.server = nil
+	ss.session = nil
+	if ss.timer != nil {
+		ss.timer.Stop()
+		ss.timer = nil
+	}
+	ss.mu.Unlock()
+
+	if server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_ = server.Shutdown(ctx)
+	}
+}
+
+// CurrentSession 返回当前分享会话，未分享时返回 nil。
+func (ss *ShareService) CurrentSession() *ShareSession {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return ss.session
+}
+
+// Receive 从另一台设备的分享地址拉取内容并导入到本地。
+// 参数：
+//   - shareURL: 对方显示的分享地址（包含令牌）
+//
+// 返回：导入结果和错误（如果有）
+func (ss *ShareService) Receive(shareURL string) (*ShareImportResult, error) {
+	if ss.store == nil || ss.store.Nodes == nil || ss.store.Subscriptions == nil {
+		return nil, fmt.Errorf("分享服务: %w", errs.ErrStoreNotInitialized)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(shareURL)
+	if err != nil {
+		return nil, fmt.Errorf("分享服务: 请求分享地址失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("分享服务: 分享地址返回 %s（令牌无效或已过期）", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, fmt.Errorf("分享服务: 读取分享内容失败: %w", err)
+	}
+
+	var payload SharePayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("分享服务: 解析分享内容失败: %w", err)
+	}
+
+	result := &ShareImportResult{}
+	for i := range payload.Nodes {
+		node := payload.Nodes[i]
+		node.Selected = false
+		if err := ss.store.Nodes.Add(&node); err != nil {
+			return result, fmt.Errorf("分享服务: 导入节点 %s 失败: %w", node.Name, err)
+		}
+		result.NodeCount++
+	}
+	for _, sub := range payload.Subscriptions {
+		if err := ss.store.Subscriptions.Fetch(sub.URL, sub.Label); err != nil {
+			// 拉取失败时仍保留订阅地址，用户可稍后手动更新
+			if _, addErr := ss.store.Subscriptions.Add(sub.URL, sub.Label); addErr != nil {
+				return result, fmt.Errorf("分享服务: 导入订阅 %s 失败: %w", sub.Label, addErr)
+			}
+		}
+		// 保留分享方的订阅备注
+		if sub.Notes != "" {
+			if imported, err := ss.store.Subscriptions.GetByURL(sub.URL); err == nil {
+				_ = ss.store.Subscriptions.UpdateNotes(imported.ID, sub.Notes)
+			}
+		}
+		result.SubscriptionCount++
+	}
+
+	return result, nil
+}
+
+// lanIPv4 获取本机第一个非回环的局域网 IPv4 地址。
+func lanIPv4() (string, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return "", fmt.Errorf("获取网络接口失败: %w", err)
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			if ip4 := ipNet.IP.To4(); ip4 != nil && ip4.IsPrivate() {
+				return ip4.String(), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("未找到局域网地址")
+}
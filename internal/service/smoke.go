@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/proxy"
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/xray"
+)
+
+const (
+	// smokeDNSServer 冒烟测试的 DNS 服务器，经代理以 TCP 查询
+	smokeDNSServer = "1.1.1.1:53"
+	// smokeDNSHost 冒烟测试查询的域名
+	smokeDNSHost = "www.google.com"
+	// smokeStepTimeout 单个步骤的超时
+	smokeStepTimeout = 10 * time.Second
+)
+
+// SmokeStep 冒烟测试中的一个步骤结果。
+type SmokeStep struct {
+	Name     string        // 步骤名称，如 "HTTP 请求"
+	Duration time.Duration // 耗时
+	Detail   string        // 成功时的说明，如 "HTTP 204"
+	Err      error         // 失败原因
+}
+
+// SmokeReport 冒烟测试报告。
+type SmokeReport struct {
+	Node  model.Node    // 被测节点
+	Port  int           // 临时实例的本地 SOCKS5 端口
+	Steps []SmokeStep   // 按执行顺序排列的步骤，某步失败后不再执行后续步骤
+	Total time.Duration // 总耗时
+}
+
+// Passed 判断是否全部步骤成功。
+func (r SmokeReport) Passed() bool {
+	if len(r.Steps) == 0 {
+		return false
+	}
+	for _, s := range r.Steps {
+		if s.Err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// String 返回纯文本报告，用于命令行输出和复制。
+func (r SmokeReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "节点: %s (%s:%d %s)\n", r.Node.Name, r.Node.Addr, r.Node.Port, r.Node.ProtocolType)
+	if r.Port > 0 {
+		fmt.Fprintf(&b, "临时端口: 127.0.0.1:%d\n", r.Port)
+	}
+	for _, s := range r.Steps {
+		if s.Err != nil {
+			fmt.Fprintf(&b, "[失败] %s (%d ms): %v\n", s.Name, s.Duration.Milliseconds(), s.Err)
+		} else {
+			fmt.Fprintf(&b, "[通过] %s (%d ms): %s\n", s.Name, s.Duration.Milliseconds(), s.Detail)
+		}
+	}
+	result := "失败"
+	if r.Passed() {
+		result = "通过"
+	}
+	fmt.Fprintf(&b, "结果: %s，总耗时 %d ms", result, r.Total.Milliseconds())
+	return b.String()
+}
+
+// SmokeTest 端到端冒烟测试：用节点配置在临时端口启动 xray 实例，经其完成一次 HTTP 请求和一次 DNS 查询，
+// 记录各步耗时后销毁实例，回答「这个节点到底能不能用」。
+// 参数：
+//   - ctx: 上下文，取消时立即停止
+//   - node: 被测节点
+//   - active: 正在运行的代理实例（可为 nil），测试结束后恢复其日志输出
+//   - onStep: 每个步骤完成后的回调（在调用 goroutine 中执行），可为 nil
+//
+// 返回：测试报告
+func (xcs *XrayControlService) SmokeTest(ctx context.Context, node model.Node, active *xray.XrayInstance, onStep func(SmokeStep)) (report SmokeReport) {
+	if active != nil {
+		defer active.ReclaimLogHandler()
+	}
+
+	// report 为具名返回值，延迟函数写入的总耗时才会体现在返回结果中
+	report = SmokeReport{Node: node}
+	start := time.Now()
+	defer func() { report.Total = time.Since(start) }()
+
+	// run 执行一个步骤并记录结果，返回是否成功
+	run := func(name string, fn func(ctx context.Context) (string, error)) bool {
+		stepCtx, cancel := context.WithTimeout(ctx, smokeStepTimeout)
+		defer cancel()
+		stepStart := time.Now()
+		detail, err := fn(stepCtx)
+		step := SmokeStep{Name: name, Duration: time.Since(stepStart), Detail: detail, Err: err}
+		report.Steps = append(report.Steps, step)
+		if onStep != nil {
+			onStep(step)
+		}
+		return err == nil
+	}
+
+	var instance *xray.XrayInstance
+	ok := run("启动临时实例", func(context.Context) (string, error) {
+		port, err := freeLocalPort()
+		if err != nil {
+			return "", err
+		}
+		configJSON, err := xray.CreateProbeConfig(port, &node)
+		if err != nil {
+			return "", err
+		}
+		instance, err = xray.NewProbeInstance(configJSON, port)
+		if err != nil {
+			return "", err
+		}
+		if err := instance.Start(); err != nil {
+			return "", fmt.Errorf("启动失败: %w", err)
+		}
+		report.Port = port
+		return fmt.Sprintf("监听 127.0.0.1:%d", port), nil
+	})
+	if !ok {
+		return report
+	}
+	defer instance.Stop()
+
+	if !run("HTTP 请求", func(ctx context.Context) (string, error) {
+		client := &http.Client{
+			Transport: &http.Transport{
+				Proxy:             http.ProxyURL(&url.URL{Scheme: "socks5", Host: fmt.Sprintf("127.0.0.1:%d", report.Port)}),
+				DisableKeepAlives: true,
+			},
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, diagnoseProbeURL, nil)
+		if err != nil {
+			return "", err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("请求失败: %w", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			return "", fmt.Errorf("响应异常: HTTP %d", resp.StatusCode)
+		}
+		return fmt.Sprintf("HTTP %d %s", resp.StatusCode, diagnoseProbeURL), nil
+	}) {
+		return report
+	}
+
+	run("DNS 查询", func(ctx context.Context) (string, error) {
+		dialer, err := proxy.SOCKS5("tcp", fmt.Sprintf("127.0.0.1:%d", report.Port), nil, proxy.Direct)
+		if err != nil {
+			return "", err
+		}
+		// 经代理以 TCP 连接 DNS 服务器（Go 解析器对非 PacketConn 连接使用 TCP 格式）
+		resolver := &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.(proxy.ContextDialer).DialContext(ctx, "tcp", smokeDNSServer)
+			},
+		}
+		addrs, err := resolver.LookupHost(ctx, smokeDNSHost)
+		if err != nil {
+			return "", fmt.Errorf("查询 %s 失败: %w", smokeDNSHost, err)
+		}
+		return fmt.Sprintf("%s @%s -> %s", smokeDNSHost, smokeDNSServer, strings.Join(addrs, ", ")), nil
+	})
+	return report
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/store"
+	"myproxy.com/p/internal/xray"
+)
+
+// VerifyNode 启动前验证节点：经临时 xray 实例请求探测地址，确认节点确实可用，
+// 避免把整个系统切到不可用的代理上。
+// 参数：
+//   - ctx: 上下文，取消时立即返回
+//   - node: 待验证节点
+//   - active: 正在运行的代理实例（可为 nil），验证结束后恢复其日志输出
+//
+// 返回：经代理完成请求的耗时（毫秒）和错误（如果有）
+func (xcs *XrayControlService) VerifyNode(ctx context.Context, node model.Node, active *xray.XrayInstance) (int, error) {
+	if active != nil {
+		defer active.ReclaimLogHandler()
+	}
+	return probeNode(ctx, node)
+}
+
+// BestAlternativeNode 返回除 excludeID 外最好的备选节点：已启用、最近测速成功且未降级。
+// 默认取延迟最低的节点；评分设置中开启「按评分自动选择」时取综合评分最高的节点。
+// 参数：
+//   - excludeID: 排除的节点 ID（通常为验证失败的当前节点）
+//   - health: 节点错误预算，降级节点不参与选择（可为 nil）
+//
+// 返回：备选节点，没有合适节点时返回 nil
+func (xcs *XrayControlService) BestAlternativeNode(excludeID string, health *NodeHealthTracker) *model.Node {
+	if xcs.store == nil || xcs.store.Nodes == nil {
+		return nil
+	}
+	var weights model.NodeScoreWeights
+	if xcs.config != nil {
+		weights = xcs.config.GetNodeScoreWeights()
+	}
+	var best *model.Node
+	bestScore := -1
+	for _, node := range xcs.store.Nodes.GetAll() {
+		if node.ID == excludeID || !node.Enabled || node.Delay <= 0 || health.IsDegraded(node.ID) {
+			continue
+		}
+		if weights.UseForSelection {
+			if score, ok := NodeScore(xcs.store.Nodes, weights, *node); ok && score > bestScore {
+				best, bestScore = node, score
+			}
+			continue
+		}
+		if best == nil || node.Delay < best.Delay {
+			best = node
+		}
+	}
+	return best
+}
+
+// NodeScore 按评分权重计算节点综合评分，测速采样统计和历史成功率取自节点存储。
+// 返回：评分（0–100）和是否可评分（未测速或测速失败的节点不可评分）
+func NodeScore(nodes *store.NodesStore, weights model.NodeScoreWeights, node model.Node) (int, bool) {
+	var stats *model.LatencyStats
+	successRate := -1.0
+	if nodes != nil {
+		if s, ok := nodes.GetLatency(node.ID); ok {
+			stats = &s
+		}
+		if rate, ok := nodes.SuccessRate(node.ID); ok {
+			successRate = rate
+		}
+	}
+	return weights.Score(node, stats, successRate)
+}
@@ -3,29 +3,156 @@ package service
 import (
 	"fmt"
 
+	"myproxy.com/p/internal/errs"
+	"myproxy.com/p/internal/model"
 	"myproxy.com/p/internal/store"
 	"myproxy.com/p/internal/subscription"
+	"myproxy.com/p/internal/utils"
 )
 
 // SubscriptionService 订阅服务层，提供订阅相关的业务逻辑。
 type SubscriptionService struct {
 	store               *store.Store
+	config              *ConfigService
 	subscriptionManager *subscription.SubscriptionManager
+	hooks               *HookService // 生命周期事件分发（可为 nil）
 }
 
 // NewSubscriptionService 创建新的订阅服务实例。
 // 参数：
 //   - store: Store 实例，用于数据访问
+//   - config: ConfigService，用于读取用户修改节点的冲突策略
 //   - subscriptionManager: 订阅管理器，用于订阅更新操作
 //
 // 返回：初始化后的 SubscriptionService 实例
-func NewSubscriptionService(store *store.Store, subscriptionManager *subscription.SubscriptionManager) *SubscriptionService {
+func NewSubscriptionService(store *store.Store, config *ConfigService, subscriptionManager *subscription.SubscriptionManager) *SubscriptionService {
 	return &SubscriptionService{
 		store:               store,
+		config:              config,
 		subscriptionManager: subscriptionManager,
 	}
 }
 
+// SetHooks 设置生命周期事件分发，订阅更新成功后触发 subscription-updated 事件。
+func (ss *SubscriptionService) SetHooks(hooks *HookService) {
+	ss.hooks = hooks
+}
+
+// SkippedEntries 获取订阅最近一次拉取时因无效而被跳过的条目（仅保存在内存中）。
+// 参数：
+//   - url: 订阅 URL
+//
+// 返回：跳过的条目列表，没有记录时返回空列表
+func (ss *SubscriptionService) SkippedEntries(url string) []model.SkippedEntry {
+	if ss.subscriptionManager == nil {
+		return nil
+	}
+	return ss.subscriptionManager.SkippedEntries(url)
+}
+
+// Conflicts 获取订阅最近一次更新留下的待处理冲突（询问策略下产生，仅保存在内存中）。
+// 参数：
+//   - url: 订阅 URL
+//
+// 返回：冲突列表，没有时返回空列表
+func (ss *SubscriptionService) Conflicts(url string) []model.NodeConflict {
+	if ss.subscriptionManager == nil {
+		return nil
+	}
+	return ss.subscriptionManager.Conflicts(url)
+}
+
+// ResolveConflict 处理一处订阅更新冲突并刷新节点数据。
+// 参数：
+//   - url: 订阅 URL
+//   - localID: 用户修改的节点 ID
+//   - resolution: 处理方式：保留修改、使用订阅版本或另存为独立节点
+//
+// 返回：错误（如果有）
+func (ss *SubscriptionService) ResolveConflict(url, localID string, resolution model.ConflictPolicy) error {
+	if ss.subscriptionManager == nil {
+		return fmt.Errorf("订阅管理器未初始化")
+	}
+	if err := ss.subscriptionManager.ResolveConflict(url, localID, resolution); err != nil {
+		return fmt.Errorf("处理订阅冲突失败: %w", err)
+	}
+	if ss.store != nil && ss.store.Nodes != nil {
+		if err := ss.store.Nodes.Load(); err != nil {
+			return fmt.Errorf("刷新节点数据失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// Add 添加订阅（不拉取内容，拉取见 Fetch）。
+// 参数：
+//   - url: 订阅 URL
+//   - label: 订阅标签
+//
+// 返回：错误（如果有）
+func (ss *SubscriptionService) Add(url, label string) error {
+	if ss.store == nil || ss.store.Subscriptions == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	if _, err := ss.store.Subscriptions.Add(url, label); err != nil {
+		return fmt.Errorf("添加订阅失败: %w", err)
+	}
+	return nil
+}
+
+// Update 修改订阅的 URL 和标签。
+// 参数：
+//   - id: 订阅 ID
+//   - url: 订阅 URL
+//   - label: 订阅标签
+//
+// 返回：错误（如果有）
+func (ss *SubscriptionService) Update(id int64, url, label string) error {
+	if ss.store == nil || ss.store.Subscriptions == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	if err := ss.store.Subscriptions.Update(id, url, label); err != nil {
+		return fmt.Errorf("更新订阅失败: %w", err)
+	}
+	return nil
+}
+
+// UpdateNotes 修改订阅备注。
+// 参数：
+//   - id: 订阅 ID
+//   - notes: 备注
+//
+// 返回：错误（如果有）
+func (ss *SubscriptionService) UpdateNotes(id int64, notes string) error {
+	if ss.store == nil || ss.store.Subscriptions == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	if err := ss.store.Subscriptions.UpdateNotes(id, notes); err != nil {
+		return fmt.Errorf("更新订阅备注失败: %w", err)
+	}
+	return nil
+}
+
+// Delete 删除订阅及其下属节点，并刷新节点数据。
+// 参数：
+//   - id: 订阅 ID
+//
+// 返回：错误（如果有）
+func (ss *SubscriptionService) Delete(id int64) error {
+	if ss.store == nil || ss.store.Subscriptions == nil {
+		return errs.ErrStoreNotInitialized
+	}
+	if err := ss.store.Subscriptions.Delete(id); err != nil {
+		return fmt.Errorf("删除订阅失败: %w", err)
+	}
+	if ss.store.Nodes != nil {
+		if err := ss.store.Nodes.Load(); err != nil {
+			return fmt.Errorf("刷新节点数据失败: %w", err)
+		}
+	}
+	return nil
+}
+
 // UpdateByID 根据订阅 ID 更新订阅（拉取最新内容）。
 // 参数：
 //   - id: 订阅 ID
@@ -36,10 +163,13 @@ func (ss *SubscriptionService) UpdateByID(id int64) error {
 		return fmt.Errorf("订阅管理器未初始化，无法更新订阅")
 	}
 	if ss.store == nil || ss.store.Subscriptions == nil {
-		return fmt.Errorf("Store 未初始化")
+		return errs.ErrStoreNotInitialized
 	}
 
-	// 调用 SubscriptionManager 更新订阅（会更新数据库中的订阅和节点）
+	// 调用 SubscriptionManager 更新订阅（会更新数据库中的订阅和节点），用户修改过的节点按冲突策略处理
+	if ss.config != nil {
+		ss.subscriptionManager.SetConflictPolicy(ss.config.GetConflictPolicy())
+	}
 	if err := ss.subscriptionManager.UpdateSubscriptionByID(id); err != nil {
 		return fmt.Errorf("更新订阅失败: %w", err)
 	}
@@ -56,6 +186,9 @@ func (ss *SubscriptionService) UpdateByID(id int64) error {
 		}
 	}
 
+	if sub, err := ss.store.Subscriptions.Get(id); err == nil {
+		ss.fireUpdated(sub.ID, sub.URL, sub.Label)
+	}
 	return nil
 }
 
@@ -70,7 +203,7 @@ func (ss *SubscriptionService) Fetch(url string, label ...string) error {
 		return fmt.Errorf("订阅管理器未初始化，无法获取订阅")
 	}
 	if ss.store == nil || ss.store.Subscriptions == nil {
-		return fmt.Errorf("Store 未初始化")
+		return errs.ErrStoreNotInitialized
 	}
 
 	// 调用 SubscriptionManager 获取订阅（会更新数据库中的订阅和节点）
@@ -91,5 +224,20 @@ func (ss *SubscriptionService) Fetch(url string, label ...string) error {
 		}
 	}
 
+	if sub, err := ss.store.Subscriptions.GetByURL(url); err == nil {
+		ss.fireUpdated(sub.ID, sub.URL, sub.Label)
+	}
 	return nil
 }
+
+// fireUpdated 触发 subscription-updated 事件，附带订阅当前的节点数。
+func (ss *SubscriptionService) fireUpdated(id int64, url, label string) {
+	if ss.hooks == nil {
+		return
+	}
+	count, _ := ss.store.Subscriptions.GetServerCount(id)
+	ss.hooks.Fire(HookPayload{
+		Event:        model.HookEventSubscriptionUpdated,
+		Subscription: &HookSubscription{ID: id, URL: utils.RedactSecrets(url), Label: label, NodeCount: count},
+	})
+}
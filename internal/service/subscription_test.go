@@ -0,0 +1,101 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"myproxy.com/p/internal/database"
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/store"
+	"myproxy.com/p/internal/subscription"
+)
+
+// newTestStore 使用临时数据库创建 Store，测试结束后关闭数据库。
+func newTestStore(t *testing.T) (*store.Store, *subscription.SubscriptionManager) {
+	t.Helper()
+	if err := database.InitDB(filepath.Join(t.TempDir(), "myproxy.db")); err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	t.Cleanup(func() { _ = database.CloseDB() })
+	sm := subscription.NewSubscriptionManager()
+	s := store.NewStore(sm)
+	s.LoadAll()
+	return s, sm
+}
+
+// newFeed 返回内容可随时替换的测试订阅服务器，节点依次使用 10.0.0.1、10.0.0.2……
+func newFeed(t *testing.T) (url string, set func(names ...string)) {
+	t.Helper()
+	var mu sync.Mutex
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, func(names ...string) {
+		items := make([]string, 0, len(names))
+		for i, name := range names {
+			items = append(items, fmt.Sprintf(`{"name":%q,"addr":"10.0.0.%d","port":1080,"username":"u","password":"p"}`, name, i+1))
+		}
+		mu.Lock()
+		body = "[" + strings.Join(items, ",") + "]"
+		mu.Unlock()
+	}
+}
+
+// nodeByName 按名称查找节点。
+func nodeByName(t *testing.T, s *store.Store, name string) *model.Node {
+	t.Helper()
+	for _, n := range s.Nodes.GetAll() {
+		if n.Name == name {
+			return n
+		}
+	}
+	t.Fatalf("未找到节点 %s", name)
+	return nil
+}
+
+func TestNodeOutboundBindingSurvivesSubscriptionUpdate(t *testing.T) {
+	s, sm := newTestStore(t)
+	cs := NewConfigService(s)
+	ss := NewSubscriptionService(s, cs, sm)
+	url, setFeed := newFeed(t)
+
+	setFeed("A", "B")
+	if err := ss.Fetch(url, "测试"); err != nil {
+		t.Fatalf("获取订阅失败: %v", err)
+	}
+	node := nodeByName(t, s, "A")
+	want := model.OutboundBinding{Interface: "eth1", SendThrough: "192.168.1.10"}
+	if err := cs.SetNodeOutboundBinding(node.ID, want); err != nil {
+		t.Fatalf("设置出站绑定失败: %v", err)
+	}
+
+	// 订阅中节点改名，身份标识不变
+	setFeed("A2", "B2")
+	sub, err := s.Subscriptions.GetByURL(url)
+	if err != nil {
+		t.Fatalf("获取订阅失败: %v", err)
+	}
+	if err := ss.UpdateByID(sub.ID); err != nil {
+		t.Fatalf("更新订阅失败: %v", err)
+	}
+
+	updated := nodeByName(t, s, "A2")
+	if updated.ID != node.ID {
+		t.Fatalf("更新后节点 ID = %s，期望沿用 %s", updated.ID, node.ID)
+	}
+	if got := cs.GetNodeOutboundBinding(updated.ID); got != want {
+		t.Errorf("出站绑定 = %+v，期望 %+v", got, want)
+	}
+	if got := cs.GetNodeOutboundBinding(nodeByName(t, s, "B2").ID); !got.IsZero() {
+		t.Errorf("未设置的节点出站绑定 = %+v，期望为空", got)
+	}
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"myproxy.com/p/internal/model"
+)
+
+// PlannedSystemChanges 返回应用指定系统代理模式时将修改的系统设置和文件，清除模式返回空。
+// 参数：
+//   - mode: 系统代理模式（clear, auto, terminal）
+func (ps *ProxyService) PlannedSystemChanges(mode string) []model.SystemChange {
+	terminalEnabled := ps.configService != nil && ps.configService.GetTerminalProxyEnabled()
+	var changes []model.SystemChange
+	if mode == "auto" {
+		changes = append(changes, model.SystemChange{Kind: model.SystemChangeProxy, Targets: ps.systemProxy.SystemProxyTargets()})
+	}
+	if mode == "terminal" || (mode == "auto" && terminalEnabled) {
+		changes = append(changes, model.SystemChange{Kind: model.SystemChangeTerminal, Targets: ps.systemProxy.TerminalProxyTargets()})
+	}
+	return slices.DeleteFunc(changes, func(c model.SystemChange) bool { return len(c.Targets) == 0 })
+}
+
+// UnconfirmedSystemChanges 返回应用指定模式时将修改、且不在改动清单中的系统设置，
+// 即需要请用户确认的部分；改动清单中已有相同目标的（如启动时恢复上次的模式）不再确认。
+func (ps *ProxyService) UnconfirmedSystemChanges(mode string) []model.SystemChange {
+	recorded := ps.SystemChanges()
+	var pending []model.SystemChange
+	for _, c := range ps.PlannedSystemChanges(mode) {
+		known := slices.ContainsFunc(recorded, func(r model.SystemChange) bool {
+			return r.Kind == c.Kind && slices.Equal(r.Targets, c.Targets)
+		})
+		if !known {
+			pending = append(pending, c)
+		}
+	}
+	return pending
+}
+
+// SystemChanges 返回已生效、尚未撤销的系统改动清单。
+func (ps *ProxyService) SystemChanges() []model.SystemChange {
+	if ps.configService == nil {
+		return nil
+	}
+	return ps.configService.GetSystemChanges()
+}
+
+// recordSystemChange 在改动清单中记录（或更新）一类改动。
+func (ps *ProxyService) recordSystemChange(kind model.SystemChangeKind) {
+	var targets []string
+	switch kind {
+	case model.SystemChangeProxy:
+		targets = ps.systemProxy.SystemProxyTargets()
+	case model.SystemChangeTerminal:
+		targets = ps.systemProxy.TerminalProxyTargets()
+	}
+	if len(targets) == 0 {
+		return
+	}
+	ps.updateSystemChanges(func(changes []model.SystemChange) []model.SystemChange {
+		changes = slices.DeleteFunc(changes, func(c model.SystemChange) bool { return c.Kind == kind })
+		return append(changes, model.SystemChange{Kind: kind, Targets: targets, Time: time.Now()})
+	})
+}
+
+// forgetSystemChange 从改动清单中移除已撤销的一类改动。
+func (ps *ProxyService) forgetSystemChange(kind model.SystemChangeKind) {
+	ps.updateSystemChanges(func(changes []model.SystemChange) []model.SystemChange {
+		return slices.DeleteFunc(changes, func(c model.SystemChange) bool { return c.Kind == kind })
+	})
+}
+
+// updateSystemChanges 读取、修改并保存改动清单；观察期回滚在后台 goroutine 中调用，需串行化。
+func (ps *ProxyService) updateSystemChanges(update func([]model.SystemChange) []model.SystemChange) {
+	if ps.configService == nil {
+		return
+	}
+	ps.changesMu.Lock()
+	defer ps.changesMu.Unlock()
+	_ = ps.configService.SetSystemChanges(update(ps.configService.GetSystemChanges()))
+}
+
+// RevertAllSystemChanges 撤销改动清单中的全部系统改动：清除系统代理（按快照恢复之前的设置），
+// 删除终端代理文件及 shell 配置文件中的引用。撤销成功的项从清单中移除，失败的保留以便重试。
+// 撤销后保存的系统代理模式切换为「清除」，避免下次启动时按原模式重新设置。
+// 返回：各项撤销失败的错误（如果有）
+func (ps *ProxyService) RevertAllSystemChanges() error {
+	// 使观察期失效，避免撤销后又被回滚逻辑重复清除
+	ps.mu.Lock()
+	ps.graceGen++
+	ps.mu.Unlock()
+
+	var errs []error
+	for _, c := range ps.SystemChanges() {
+		var err error
+		switch c.Kind {
+		case model.SystemChangeProxy:
+			err = ps.systemProxy.ClearSystemProxy()
+		case model.SystemChangeTerminal:
+			err = ps.systemProxy.ClearTerminalProxy()
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("撤销%s失败: %w", c.Kind.Label(), err))
+			continue
+		}
+		ps.forgetSystemChange(c.Kind)
+	}
+	if ps.configService != nil {
+		if err := ps.configService.SetSystemProxyMode(model.SystemProxyModeNameClear); err != nil {
+			errs = append(errs, fmt.Errorf("保存系统代理模式失败: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
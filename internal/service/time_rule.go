@@ -0,0 +1,120 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"myproxy.com/p/internal/model"
+)
+
+// TimeRuleScheduler 定时规则调度器，在规则时间窗口边界触发回调，
+// 由调用方重新生成路由配置（重启 xray 实例）使拦截规则生效或失效。
+type TimeRuleScheduler struct {
+	config     *ConfigService
+	onBoundary func()
+
+	mu     sync.Mutex
+	timer  *time.Timer
+	stopCh chan struct{}
+}
+
+// NewTimeRuleScheduler 创建定时规则调度器。
+// 参数：
+//   - config: ConfigService，用于读取定时规则
+//   - onBoundary: 到达时间窗口边界时的回调（在后台 goroutine 中调用）
+//
+// 返回：调度器实例
+func NewTimeRuleScheduler(config *ConfigService, onBoundary func()) *TimeRuleScheduler {
+	return &TimeRuleScheduler{
+		config:     config,
+		onBoundary: onBoundary,
+	}
+}
+
+// Start 启动调度器；重复调用会按当前规则重新计算下一个边界。
+func (s *TimeRuleScheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopLocked()
+	s.stopCh = make(chan struct{})
+	s.scheduleLocked(time.Now())
+}
+
+// Reset 规则变更后重新计算下一个边界。
+func (s *TimeRuleScheduler) Reset() {
+	s.Start()
+}
+
+// Stop 停止调度器。
+func (s *TimeRuleScheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopLocked()
+}
+
+func (s *TimeRuleScheduler) stopLocked() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if s.stopCh != nil {
+		close(s.stopCh)
+		s.stopCh = nil
+	}
+}
+
+// scheduleLocked 计算下一个边界并设置定时器，调用方需持有锁。
+func (s *TimeRuleScheduler) scheduleLocked(now time.Time) {
+	if s.config == nil {
+		return
+	}
+	next, ok := NextTimeRuleBoundary(s.config.GetTimeRules(), now)
+	if !ok {
+		return
+	}
+	stopCh := s.stopCh
+	s.timer = time.AfterFunc(next.Sub(now), func() {
+		select {
+		case <-stopCh:
+			return
+		default:
+		}
+		if s.onBoundary != nil {
+			s.onBoundary()
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.stopCh == stopCh {
+			s.scheduleLocked(time.Now())
+		}
+	})
+}
+
+// NextTimeRuleBoundary 计算 now 之后最近的一个规则开始或结束时刻。
+// 返回：边界时刻，以及是否存在已启用的规则
+func NextTimeRuleBoundary(rules []model.TimeRule, now time.Time) (time.Time, bool) {
+	var next time.Time
+	found := false
+	year, month, day := now.Date()
+	for _, r := range rules {
+		if !r.Enabled {
+			continue
+		}
+		for _, clock := range []string{r.Start, r.End} {
+			minutes, err := model.ParseClock(clock)
+			if err != nil {
+				continue
+			}
+			// 按墙上时间构造，夏令时切换当天不会偏移一小时
+			t := time.Date(year, month, day, minutes/60, minutes%60, 0, 0, now.Location())
+			if !t.After(now) {
+				t = t.AddDate(0, 0, 1)
+			}
+			if !found || t.Before(next) {
+				next = t
+				found = true
+			}
+		}
+	}
+	return next, found
+}
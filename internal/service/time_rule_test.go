@@ -0,0 +1,66 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"myproxy.com/p/internal/model"
+)
+
+func TestNextTimeRuleBoundary(t *testing.T) {
+	at := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+	}
+	work := model.TimeRule{Name: "工作时间", Start: "09:00", End: "18:00", Enabled: true}
+	night := model.TimeRule{Name: "夜间", Start: "23:00", End: "06:30", Enabled: true}
+
+	tests := []struct {
+		name   string
+		rules  []model.TimeRule
+		now    time.Time
+		want   time.Time
+		wantOK bool
+	}{
+		{name: "没有规则", now: at(2026, 3, 4, 12, 0)},
+		{name: "规则未启用", rules: []model.TimeRule{{Start: "09:00", End: "18:00"}}, now: at(2026, 3, 4, 12, 0)},
+		{name: "时间格式无效", rules: []model.TimeRule{{Start: "9点", End: "25:00", Enabled: true}}, now: at(2026, 3, 4, 12, 0)},
+		{name: "窗口开始前", rules: []model.TimeRule{work}, now: at(2026, 3, 4, 8, 59), want: at(2026, 3, 4, 9, 0), wantOK: true},
+		{name: "正好在开始时刻取结束时刻", rules: []model.TimeRule{work}, now: at(2026, 3, 4, 9, 0), want: at(2026, 3, 4, 18, 0), wantOK: true},
+		{name: "窗口内", rules: []model.TimeRule{work}, now: at(2026, 3, 4, 12, 0), want: at(2026, 3, 4, 18, 0), wantOK: true},
+		{name: "正好在结束时刻取次日开始", rules: []model.TimeRule{work}, now: at(2026, 3, 4, 18, 0), want: at(2026, 3, 5, 9, 0), wantOK: true},
+		{name: "跨午夜窗口开始前", rules: []model.TimeRule{night}, now: at(2026, 3, 4, 22, 0), want: at(2026, 3, 4, 23, 0), wantOK: true},
+		{name: "跨午夜窗口内（午夜前）", rules: []model.TimeRule{night}, now: at(2026, 3, 4, 23, 30), want: at(2026, 3, 5, 6, 30), wantOK: true},
+		{name: "跨午夜窗口内（午夜后）", rules: []model.TimeRule{night}, now: at(2026, 3, 5, 1, 0), want: at(2026, 3, 5, 6, 30), wantOK: true},
+		{name: "跨午夜窗口结束后", rules: []model.TimeRule{night}, now: at(2026, 3, 5, 7, 0), want: at(2026, 3, 5, 23, 0), wantOK: true},
+		{name: "多条规则取最近边界", rules: []model.TimeRule{work, night}, now: at(2026, 3, 4, 19, 0), want: at(2026, 3, 4, 23, 0), wantOK: true},
+		{name: "周六跨到周日", rules: []model.TimeRule{work}, now: at(2026, 3, 7, 20, 0), want: at(2026, 3, 8, 9, 0), wantOK: true},
+		{name: "跨月", rules: []model.TimeRule{work}, now: at(2026, 2, 28, 20, 0), want: at(2026, 3, 1, 9, 0), wantOK: true},
+		{name: "跨年", rules: []model.TimeRule{night}, now: at(2026, 12, 31, 23, 59), want: at(2027, 1, 1, 6, 30), wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := NextTimeRuleBoundary(tt.rules, tt.now)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v，期望 %v", ok, tt.wantOK)
+			}
+			if ok && !got.Equal(tt.want) {
+				t.Errorf("NextTimeRuleBoundary() = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextTimeRuleBoundaryDaylightSaving(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("没有时区数据: %v", err)
+	}
+	rules := []model.TimeRule{{Start: "09:00", End: "18:00", Enabled: true}}
+	// 2026-03-08 凌晨 2 点切换到夏令时，当天只有 23 小时
+	now := time.Date(2026, 3, 8, 0, 30, 0, 0, loc)
+	got, ok := NextTimeRuleBoundary(rules, now)
+	want := time.Date(2026, 3, 8, 9, 0, 0, 0, loc)
+	if !ok || !got.Equal(want) {
+		t.Errorf("NextTimeRuleBoundary() = %v, %v，期望 %v", got, ok, want)
+	}
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"myproxy.com/p/internal/utils"
+)
+
+const (
+	// talkerWindow 活跃应用统计的时间窗口
+	talkerWindow = 10 * time.Minute
+	// talkerMaxEvents 窗口内最多保留的连接数，超出后丢弃最早的
+	talkerMaxEvents = 20000
+	// talkerResolveDelay 收到连接后延迟多久批量查找所属进程（合并查找，连接通常仍未关闭）
+	talkerResolveDelay = time.Second
+	// talkerWarnMinConnections 窗口内连接数达到该值才判断是否有应用独占代理
+	talkerWarnMinConnections = 200
+	// talkerWarnShare 单个应用的连接占比达到该值时提醒
+	talkerWarnShare = 0.7
+	// talkerWarnCooldown 同一应用两次提醒的最小间隔
+	talkerWarnCooldown = 30 * time.Minute
+)
+
+// UnknownTalkerApp 无法确定所属进程的连接（UDP、连接已关闭或平台不支持时）归入此项。
+const UnknownTalkerApp = "未知应用"
+
+// TalkerStat 时间窗口内某个应用经代理发起的连接汇总。
+type TalkerStat struct {
+	App          string    // 进程名，无法确定时为 UnknownTalkerApp
+	Connections  int       // 连接数
+	Share        float64   // 占窗口内全部连接的比例（0-1）
+	Destinations int       // 不同目标主机数
+	TopHosts     []string  // 连接最多的几个目标主机
+	LastSeen     time.Time // 最近一次连接时间
+}
+
+// talkerEvent 一次经代理的连接
+type talkerEvent struct {
+	time time.Time
+	app  string
+	host string
+}
+
+// talkerPending 等待查找所属进程的连接
+type talkerPending struct {
+	time time.Time
+	port int // 来源端口，0 表示无法查找（如 UDP）
+	host string
+}
+
+// TopTalkersService 活跃应用统计：根据 xray 访问日志中的来源端口查找发起连接的本机进程，
+// 按应用汇总时间窗口内的连接数，并在单个应用占用大部分代理连接时提醒（多个应用共用代理时便于发现谁在占满带宽）。
+// xray 不提供单个连接的流量，因此按连接数而非字节数排名；进程查找仅支持 Linux 和 macOS。
+type TopTalkersService struct {
+	mu        sync.Mutex
+	events    []talkerEvent
+	pending   []talkerPending
+	scheduled bool
+	warned    map[string]time.Time // 应用 -> 上次提醒时间
+	lookup    func() (map[int]string, error)
+	// onDominant 单个应用占用大部分代理连接时调用（在后台 goroutine 中）
+	onDominant func(stat TalkerStat)
+}
+
+// NewTopTalkersService 创建活跃应用统计服务实例。
+// 参数：
+//   - onDominant: 单个应用占用大部分代理连接时的回调，可为 nil
+func NewTopTalkersService(onDominant func(stat TalkerStat)) *TopTalkersService {
+	lookup := utils.LocalTCPPortProcesses
+	if !utils.ProcessLookupSupported() {
+		lookup = nil
+	}
+	return &TopTalkersService{
+		warned:     make(map[string]time.Time),
+		lookup:     lookup,
+		onDominant: onDominant,
+	}
+}
+
+// Supported 当前平台是否能确定连接所属的应用；不支持时所有连接都归入 UnknownTalkerApp。
+func (tt *TopTalkersService) Supported() bool {
+	return tt.lookup != nil
+}
+
+// Feed 处理一行 xray 原始日志，访问日志记为一次连接，稍后批量查找所属进程。
+func (tt *TopTalkersService) Feed(line string) {
+	conn, ok := parseCaptureAccessLine(line)
+	if !ok || conn.Rejected {
+		return
+	}
+	p := talkerPending{time: time.Now(), host: conn.Host}
+	if src, found := strings.CutPrefix(conn.Source, "tcp:"); found {
+		if i := strings.LastIndex(src, ":"); i != -1 {
+			p.port, _ = strconv.Atoi(src[i+1:])
+		}
+	}
+
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	tt.pending = append(tt.pending, p)
+	if len(tt.pending) > talkerMaxEvents {
+		tt.pending = tt.pending[len(tt.pending)-talkerMaxEvents:]
+	}
+	if !tt.scheduled {
+		tt.scheduled = true
+		time.AfterFunc(talkerResolveDelay, tt.resolve)
+	}
+}
+
+// resolve 查找待处理连接的所属进程并计入统计，然后检查是否有应用独占代理。
+func (tt *TopTalkersService) resolve() {
+	tt.mu.Lock()
+	pending := tt.pending
+	tt.pending = nil
+	tt.scheduled = false
+	tt.mu.Unlock()
+
+	var procs map[int]string
+	if tt.lookup != nil {
+		procs, _ = tt.lookup() // 查找失败时本批连接归入未知应用
+	}
+
+	tt.mu.Lock()
+	for _, p := range pending {
+		app := procs[p.port]
+		if p.port == 0 || app == "" {
+			app = UnknownTalkerApp
+		}
+		tt.events = append(tt.events, talkerEvent{time: p.time, app: app, host: p.host})
+	}
+	tt.trimLocked(time.Now())
+	dominant, ok := tt.dominantLocked(time.Now())
+	tt.mu.Unlock()
+
+	if ok && tt.onDominant != nil {
+		tt.onDominant(dominant)
+	}
+}
+
+// trimLocked 丢弃窗口外和超出上限的连接，调用方需持有 tt.mu。
+func (tt *TopTalkersService) trimLocked(now time.Time) {
+	cutoff := now.Add(-talkerWindow)
+	i := sort.Search(len(tt.events), func(i int) bool { return !tt.events[i].time.Before(cutoff) })
+	if n := len(tt.events) - talkerMaxEvents; n > i {
+		i = n
+	}
+	if i > 0 {
+		tt.events = append(tt.events[:0], tt.events[i:]...)
+	}
+}
+
+// dominantLocked 判断是否有已知应用在多应用共用代理时占用大部分连接，每个应用在冷却期内只提醒一次。
+// 调用方需持有 tt.mu。
+func (tt *TopTalkersService) dominantLocked(now time.Time) (TalkerStat, bool) {
+	stats := summarizeTalkers(tt.events, 1)
+	if len(tt.events) < talkerWarnMinConnections || len(stats) < 2 {
+		return TalkerStat{}, false
+	}
+	top := stats[0]
+	if top.App == UnknownTalkerApp || top.Share < talkerWarnShare {
+		return TalkerStat{}, false
+	}
+	if last, ok := tt.warned[top.App]; ok && now.Sub(last) < talkerWarnCooldown {
+		return TalkerStat{}, false
+	}
+	tt.warned[top.App] = now
+	return top, true
+}
+
+// Top 返回时间窗口内按连接数降序排列的应用。
+// 参数：
+//   - hosts: 每个应用列出的常访问目标数
+//
+// 返回：应用汇总列表（无连接时为空）
+func (tt *TopTalkersService) Top(hosts int) []TalkerStat {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	tt.trimLocked(time.Now())
+	return summarizeTalkers(tt.events, hosts)
+}
+
+// Window 统计的时间窗口。
+func (tt *TopTalkersService) Window() time.Duration {
+	return talkerWindow
+}
+
+// summarizeTalkers 按应用汇总连接，按连接数降序排列。
+func summarizeTalkers(events []talkerEvent, hosts int) []TalkerStat {
+	type agg struct {
+		stat  TalkerStat
+		hosts map[string]int
+	}
+	index := make(map[string]*agg)
+	var order []*agg
+	for _, e := range events {
+		a, ok := index[e.app]
+		if !ok {
+			a = &agg{stat: TalkerStat{App: e.app}, hosts: make(map[string]int)}
+			index[e.app] = a
+			order = append(order, a)
+		}
+		a.stat.Connections++
+		a.stat.LastSeen = e.time
+		a.hosts[e.host]++
+	}
+
+	stats := make([]TalkerStat, 0, len(order))
+	for _, a := range order {
+		s := a.stat
+		s.Share = float64(s.Connections) / float64(len(events))
+		s.Destinations = len(a.hosts)
+		names := make([]string, 0, len(a.hosts))
+		for h := range a.hosts {
+			names = append(names, h)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if a.hosts[names[i]] != a.hosts[names[j]] {
+				return a.hosts[names[i]] > a.hosts[names[j]]
+			}
+			return names[i] < names[j]
+		})
+		if len(names) > hosts {
+			names = names[:hosts]
+		}
+		s.TopHosts = names
+		stats = append(stats, s)
+	}
+	sort.SliceStable(stats, func(i, j int) bool { return stats[i].Connections > stats[j].Connections })
+	return stats
+}
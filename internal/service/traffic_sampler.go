@@ -0,0 +1,125 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// trafficSampleInterval 流量采样间隔
+	trafficSampleInterval = time.Second
+	// trafficMaxSamples 保留的采样点数（约 1 分钟）
+	trafficMaxSamples = 60
+)
+
+// TrafficSample 一个流量采样点：采样间隔内的平均速率（字节/秒）。
+type TrafficSample struct {
+	Upload   int64 // 上传速率
+	Download int64 // 下载速率
+	Time     time.Time
+}
+
+// TrafficSampler 实时流量采样：在后台每秒读取一次累计流量并换算为速率，保留最近的采样点。
+// 与界面生命周期无关，流量图等组件只读取共享的采样缓冲，切换页面重建组件后历史仍在。
+type TrafficSampler struct {
+	source func() (int64, int64)
+
+	mu           sync.RWMutex
+	samples      []TrafficSample
+	lastUpload   int64
+	lastDownload int64
+	lastTime     time.Time
+	stopCh       chan struct{}
+}
+
+// NewTrafficSampler 创建流量采样器。
+// 参数：
+//   - source: 返回当前累计的上传、下载字节数（代理未运行时返回 0, 0），在后台 goroutine 中调用
+//
+// 返回：采样器实例，需调用 Start 开始采样
+func NewTrafficSampler(source func() (int64, int64)) *TrafficSampler {
+	return &TrafficSampler{source: source}
+}
+
+// Start 开始采样；已在采样时不做任何事。
+func (ts *TrafficSampler) Start() {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.stopCh != nil {
+		return
+	}
+	ts.stopCh = make(chan struct{})
+	ts.lastTime = time.Now()
+	go ts.loop(ts.stopCh)
+}
+
+// Stop 停止采样，已有的采样点保留。
+func (ts *TrafficSampler) Stop() {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.stopCh != nil {
+		close(ts.stopCh)
+		ts.stopCh = nil
+	}
+}
+
+func (ts *TrafficSampler) loop(stopCh chan struct{}) {
+	ticker := time.NewTicker(trafficSampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ts.sample()
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// sample 读取累计流量，按与上次采样的差值计算速率并追加采样点。
+func (ts *TrafficSampler) sample() {
+	var totalUpload, totalDownload int64
+	if ts.source != nil {
+		totalUpload, totalDownload = ts.source()
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	now := time.Now()
+	seconds := now.Sub(ts.lastTime).Seconds()
+	if seconds <= 0 {
+		seconds = 1
+	}
+	// 代理重启后累计值归零，差值为负时按 0 计
+	upload := max(0, int64(float64(totalUpload-ts.lastUpload)/seconds))
+	download := max(0, int64(float64(totalDownload-ts.lastDownload)/seconds))
+	ts.lastUpload = totalUpload
+	ts.lastDownload = totalDownload
+	ts.lastTime = now
+
+	ts.samples = append(ts.samples, TrafficSample{Upload: upload, Download: download, Time: now})
+	if len(ts.samples) > trafficMaxSamples {
+		ts.samples = append(ts.samples[:0], ts.samples[len(ts.samples)-trafficMaxSamples:]...)
+	}
+}
+
+// Samples 返回最近的采样点副本（按时间升序）。
+func (ts *TrafficSampler) Samples() []TrafficSample {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	out := make([]TrafficSample, len(ts.samples))
+	copy(out, ts.samples)
+	return out
+}
+
+// Current 返回最近一次采样的速率，尚无采样时返回 0, 0。
+func (ts *TrafficSampler) Current() (int64, int64) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	if len(ts.samples) == 0 {
+		return 0, 0
+	}
+	last := ts.samples[len(ts.samples)-1]
+	return last.Upload, last.Download
+}
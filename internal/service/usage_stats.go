@@ -0,0 +1,83 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"time"
+
+	"myproxy.com/p/internal/errs"
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/store"
+)
+
+// UsageStatsService 本地功能使用统计：只记录功能名称与次数，保存在本机数据库，
+// 不会自动上传；用户可生成匿名报告后自行决定是否分享。
+type UsageStatsService struct {
+	store  *store.Store
+	config *ConfigService
+}
+
+// UsageReport 使用统计报告（匿名，不含节点、地址、订阅或凭据信息）。
+type UsageReport struct {
+	GeneratedAt time.Time         `json:"generated_at"`
+	OS          string            `json:"os"`
+	Arch        string            `json:"arch"`
+	Features    []model.UsageStat `json:"features"`
+}
+
+// NewUsageStatsService 创建使用统计服务。
+// 参数：
+//   - store: Store 实例，用于读写统计数据
+//   - config: ConfigService，用于读取是否启用统计
+//
+// 返回：使用统计服务实例
+func NewUsageStatsService(store *store.Store, config *ConfigService) *UsageStatsService {
+	return &UsageStatsService{
+		store:  store,
+		config: config,
+	}
+}
+
+// Record 记录一次功能使用，未启用统计时忽略。失败不影响功能本身，因此不返回错误。
+func (us *UsageStatsService) Record(feature model.UsageFeature) {
+	if us == nil || us.store == nil || us.store.UsageStats == nil {
+		return
+	}
+	if us.config == nil || !us.config.GetUsageStatsEnabled() {
+		return
+	}
+	_ = us.store.UsageStats.Increment(feature)
+}
+
+// GetAll 获取所有功能使用统计，按次数倒序。
+func (us *UsageStatsService) GetAll() []model.UsageStat {
+	if us == nil || us.store == nil || us.store.UsageStats == nil {
+		return nil
+	}
+	return us.store.UsageStats.GetAll()
+}
+
+// Clear 清空使用统计。
+func (us *UsageStatsService) Clear() error {
+	if us.store == nil || us.store.UsageStats == nil {
+		return fmt.Errorf("使用统计服务: %w", errs.ErrStoreNotInitialized)
+	}
+	return us.store.UsageStats.ClearAll()
+}
+
+// GenerateReport 生成匿名使用统计报告（JSON），供用户查看后自行选择是否分享。
+// 返回：报告内容和错误（如果有）
+func (us *UsageStatsService) GenerateReport() (string, error) {
+	report := UsageReport{
+		GeneratedAt: time.Now(),
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		Features:    us.GetAll(),
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("使用统计服务: 生成报告失败: %w", err)
+	}
+	return string(data), nil
+}
@@ -2,7 +2,13 @@ package service
 
 import (
 	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
 
+	"myproxy.com/p/internal/errs"
+	"myproxy.com/p/internal/model"
 	"myproxy.com/p/internal/store"
 	"myproxy.com/p/internal/xray"
 )
@@ -13,6 +19,16 @@ type XrayControlService struct {
 	config         *ConfigService
 	logCallback    func(level, message string)      // 应用级消息（如启动成功）
 	rawLogCallback func(level, rawLine string)     // xray 劫持的原始日志行：落盘、展示、解析
+
+	// 当前连接会话，用于记录节点连接历史
+	sessionMu     sync.Mutex
+	sessionNodeID string
+	sessionStart  time.Time
+
+	hooks *HookService // 生命周期事件分发（可为 nil）
+
+	captureMode bool // 调试捕获中：日志级别至少为 info 并开启访问日志，以便记录路由命中
+	debugMode   bool // 调试会话中：日志级别临时调为 debug
 }
 
 // NewXrayControlService 创建新的代理控制服务实例。
@@ -30,6 +46,23 @@ func NewXrayControlService(store *store.Store, config *ConfigService, logCallbac
 	}
 }
 
+// SetHooks 设置生命周期事件分发，代理启动、停止和切换节点时触发事件。
+func (xcs *XrayControlService) SetHooks(hooks *HookService) {
+	xcs.hooks = hooks
+}
+
+// SetCaptureMode 设置调试捕获模式，下次启动（或重建）代理时生效。
+// 捕获模式下 xray 日志级别至少为 info、访问日志强制开启，路由命中的规则随日志输出。
+func (xcs *XrayControlService) SetCaptureMode(enabled bool) {
+	xcs.captureMode = enabled
+}
+
+// SetDebugMode 设置调试会话模式，下次启动（或重建）代理时生效。
+// 调试会话模式下 xray 日志级别临时调为 debug，不修改保存的日志设置。
+func (xcs *XrayControlService) SetDebugMode(enabled bool) {
+	xcs.debugMode = enabled
+}
+
 // StartProxyResult 启动代理操作结果。
 type StartProxyResult struct {
 	XrayInstance *xray.XrayInstance // Xray 实例
@@ -49,7 +82,7 @@ func (xcs *XrayControlService) StartProxy(oldInstance *xray.XrayInstance, logFil
 	if xcs.store == nil || xcs.store.Nodes == nil {
 		return &StartProxyResult{
 			LogMessage: "启动代理失败: Store 未初始化",
-			Error:      fmt.Errorf("Xray控制服务: Store 未初始化"),
+			Error:      fmt.Errorf("Xray控制服务: %w", errs.ErrStoreNotInitialized),
 		}
 	}
 
@@ -58,7 +91,7 @@ func (xcs *XrayControlService) StartProxy(oldInstance *xray.XrayInstance, logFil
 	if selectedNode == nil {
 		return &StartProxyResult{
 			LogMessage: "启动代理失败: 未选中服务器",
-			Error:      fmt.Errorf("Xray控制服务: 未选中服务器"),
+			Error:      fmt.Errorf("Xray控制服务: %w", errs.ErrNoNodeSelected),
 		}
 	}
 
@@ -71,28 +104,77 @@ func (xcs *XrayControlService) StartProxy(oldInstance *xray.XrayInstance, logFil
 	}
 
 	// 使用固定的10808端口监听本地SOCKS5
-	proxyPort := 10808
+	proxyPort := model.MainInboundPort
 
 	// 记录开始启动日志
 	if xcs.logCallback != nil {
 		xcs.logCallback("INFO", fmt.Sprintf("开始启动xray-core代理: %s", selectedNode.Name))
 	}
 
-	// 读取直连路由配置：如果用户配置为空，则使用默认路由
+	// 读取路由规则：如果用户配置为空，则使用默认路由规则
 	var routing *xray.RoutingOptions
 	if xcs.config != nil {
-		routes := xcs.config.GetDirectRoutes()
-		useProxy := xcs.config.GetDirectRoutesUseProxy()
-		// 如果用户配置为空，使用默认路由
-		if len(routes) == 0 {
-			routes = xcs.config.GetDefaultDirectRoutes()
-		}
-		if len(routes) > 0 {
-			routing = &xray.RoutingOptions{
-				DirectRoutes:         routes,
-				DirectRoutesUseProxy: useProxy,
+		rules := xcs.config.GetRouteRules()
+		if len(rules) == 0 {
+			rules = xcs.config.GetDefaultRouteRules()
+		}
+		// 定时拦截规则：仅取当前时间窗口内生效的部分，窗口边界由 TimeRuleScheduler 触发重建
+		blockRoutes := xcs.config.GetActiveBlockRoutes(time.Now())
+		// 额外入站：仅启用的入站随主入站一同启动
+		var inbounds []model.InboundProfile
+		for _, p := range xcs.config.GetInboundProfiles() {
+			if p.Enabled {
+				inbounds = append(inbounds, p)
 			}
 		}
+		// 出站绑定：节点单独设置优先，否则使用全局设置
+		binding := xcs.config.GetEffectiveOutboundBinding(selectedNode.ID)
+		// 连接策略：与默认值一致时不写入配置
+		var policy *model.ConnPolicy
+		if p := xcs.config.GetConnPolicy(); p != model.DefaultConnPolicy() {
+			policy = &p
+		}
+		// 日志设置：核心日志级别与访问日志开关
+		logOptions := xcs.config.GetXrayLogOptions()
+		if xcs.captureMode {
+			logOptions.AccessLog = true
+			if logOptions.Level != model.XrayLogLevelDebug {
+				logOptions.Level = model.XrayLogLevelInfo
+			}
+		}
+		if xcs.debugMode {
+			logOptions.Level = model.XrayLogLevelDebug
+		}
+		// 域名解析：远程解析、本地解析或 Fake IP，自定义 DNS 服务器
+		dnsOptions := xcs.config.GetDNSOptions()
+		routing = &xray.RoutingOptions{
+			Rules:         rules,
+			BlockRoutes:   blockRoutes,
+			ExtraInbounds: inbounds,
+			Binding:       binding,
+			Policy:        policy,
+			Log:           &logOptions,
+			BlockQUIC:     xcs.config.GetQUICBlockMode(),
+			DNS:           &dnsOptions,
+		}
+	}
+
+	// 端口检查：入站端口被其他程序占用时 xray 启动失败的错误难以辨认，提前给出明确的错误
+	ports := []int{proxyPort}
+	if routing != nil {
+		for _, p := range routing.ExtraInbounds {
+			ports = append(ports, p.Port)
+		}
+	}
+	if err := checkPortsAvailable(ports); err != nil {
+		logMsg := fmt.Sprintf("启动代理失败: %v", err)
+		if xcs.logCallback != nil {
+			xcs.logCallback("ERROR", logMsg)
+		}
+		return &StartProxyResult{
+			LogMessage: logMsg,
+			Error:      fmt.Errorf("Xray控制服务: %w", err),
+		}
 	}
 
 	// 创建 xray 配置（不设日志路径，由劫持 handler 落盘）
@@ -149,11 +231,24 @@ func (xcs *XrayControlService) StartProxy(oldInstance *xray.XrayInstance, logFil
 	// 启动成功，设置端口信息
 	xrayInstance.SetPort(proxyPort)
 
+	// 记录连接历史：结束上一个会话（切换节点时），开始新会话
+	prevNodeID := xcs.currentSessionNode()
+	xcs.beginSession(selectedNode.ID)
+	xcs.fireStartHook(prevNodeID, selectedNode, proxyPort)
+
 	// 记录日志（统一日志记录）
 	logMsg := fmt.Sprintf("xray-core代理已启动: %s (端口: %d)", selectedNode.Name, proxyPort)
 	if xcs.logCallback != nil {
 		xcs.logCallback("INFO", logMsg)
 		xcs.logCallback("INFO", fmt.Sprintf("服务器信息: %s:%d, 协议: %s", selectedNode.Addr, selectedNode.Port, selectedNode.ProtocolType))
+		if routing != nil {
+			if !routing.Binding.IsZero() {
+				xcs.logCallback("INFO", fmt.Sprintf("出站绑定: 网卡=%s 源IP=%s", routing.Binding.Interface, routing.Binding.SendThrough))
+			}
+			for _, p := range routing.ExtraInbounds {
+				xcs.logCallback("INFO", fmt.Sprintf("额外入站已启动: %s (%s 端口: %d, 模式: %s)", p.Name, p.Protocol, p.Port, p.Mode))
+			}
+		}
 	}
 
 	return &StartProxyResult{
@@ -163,6 +258,18 @@ func (xcs *XrayControlService) StartProxy(oldInstance *xray.XrayInstance, logFil
 	}
 }
 
+// checkPortsAvailable 确认本地入站端口都可以监听，无法监听时返回 *errs.PortInUseError。
+func checkPortsAvailable(ports []int) error {
+	for _, port := range ports {
+		ln, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+		if err != nil {
+			return &errs.PortInUseError{Port: port, Err: err}
+		}
+		_ = ln.Close()
+	}
+	return nil
+}
+
 // StopProxyResult 停止代理操作结果。
 type StopProxyResult struct {
 	LogMessage string // 日志消息
@@ -207,6 +314,10 @@ func (xcs *XrayControlService) StopProxy(instance *xray.XrayInstance) *StopProxy
 		}
 	}
 
+	nodeID := xcs.currentSessionNode()
+	xcs.endSession()
+	xcs.hooks.Fire(HookPayload{Event: model.HookEventProxyStopped, Node: newHookNode(xcs.nodeByID(nodeID))})
+
 	// 记录成功日志
 	logMsg := "xray-core代理已停止"
 	if xcs.logCallback != nil {
@@ -219,6 +330,75 @@ func (xcs *XrayControlService) StopProxy(instance *xray.XrayInstance) *StopProxy
 	}
 }
 
+// beginSession 开始一次节点连接会话：记录最近连接时间，并结算上一个会话的时长。
+func (xcs *XrayControlService) beginSession(nodeID string) {
+	xcs.endSession()
+
+	now := time.Now()
+	xcs.sessionMu.Lock()
+	xcs.sessionNodeID = nodeID
+	xcs.sessionStart = now
+	xcs.sessionMu.Unlock()
+
+	if xcs.store == nil || xcs.store.Nodes == nil {
+		return
+	}
+	if err := xcs.store.Nodes.RecordConnected(nodeID, now); err != nil && xcs.logCallback != nil {
+		xcs.logCallback("WARN", err.Error())
+	}
+}
+
+// endSession 结束当前连接会话，将本次连接时长累加到节点。
+func (xcs *XrayControlService) endSession() {
+	xcs.sessionMu.Lock()
+	nodeID := xcs.sessionNodeID
+	start := xcs.sessionStart
+	xcs.sessionNodeID = ""
+	xcs.sessionMu.Unlock()
+
+	if nodeID == "" || xcs.store == nil || xcs.store.Nodes == nil {
+		return
+	}
+	if err := xcs.store.Nodes.AddConnectedDuration(nodeID, time.Since(start)); err != nil && xcs.logCallback != nil {
+		xcs.logCallback("WARN", err.Error())
+	}
+}
+
+// currentSessionNode 返回当前会话的节点 ID，未在运行时为空。
+func (xcs *XrayControlService) currentSessionNode() string {
+	xcs.sessionMu.Lock()
+	defer xcs.sessionMu.Unlock()
+	return xcs.sessionNodeID
+}
+
+// fireStartHook 代理启动后触发事件：原本未运行为 proxy-started，运行中换了节点为 node-switched，
+// 同一节点重建（如路由变更）不触发。
+func (xcs *XrayControlService) fireStartHook(prevNodeID string, node *model.Node, port int) {
+	switch {
+	case prevNodeID == "":
+		xcs.hooks.Fire(HookPayload{Event: model.HookEventProxyStarted, Node: newHookNode(node), Port: port})
+	case prevNodeID != node.ID:
+		xcs.hooks.Fire(HookPayload{
+			Event:        model.HookEventNodeSwitched,
+			Node:         newHookNode(node),
+			PreviousNode: newHookNode(xcs.nodeByID(prevNodeID)),
+			Port:         port,
+		})
+	}
+}
+
+// nodeByID 按 ID 查找节点，找不到时返回 nil。
+func (xcs *XrayControlService) nodeByID(id string) *model.Node {
+	if id == "" || xcs.store == nil || xcs.store.Nodes == nil {
+		return nil
+	}
+	node, err := xcs.store.Nodes.Get(id)
+	if err != nil {
+		return nil
+	}
+	return node
+}
+
 // IsRunning 检查代理是否正在运行。
 // 参数：
 //   - instance: Xray 实例
@@ -4,13 +4,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
-	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/data/binding"
 	"myproxy.com/p/internal/database"
+	"myproxy.com/p/internal/errs"
 	"myproxy.com/p/internal/model"
 	"myproxy.com/p/internal/subscription"
 )
@@ -23,6 +24,7 @@ type Store struct {
 	AppConfig       *AppConfigStore
 	ProxyStatus     *ProxyStatusStore
 	AccessRecords   *AccessRecordsStore
+	UsageStats      *UsageStatsStore
 }
 
 func NewStore(subscriptionManager *subscription.SubscriptionManager) *Store {
@@ -33,6 +35,7 @@ func NewStore(subscriptionManager *subscription.SubscriptionManager) *Store {
 		AppConfig:     NewAppConfigStore(),
 		ProxyStatus:   NewProxyStatusStore(),
 		AccessRecords: NewAccessRecordsStore(),
+		UsageStats:    NewUsageStatsStore(),
 	}
 	s.Subscriptions.setParentStore(s)
 	return s
@@ -44,6 +47,7 @@ func (s *Store) LoadAll() {
 	s.Layout.Load()
 	s.AppConfig.Load()
 	_ = s.AccessRecords.Load()
+	_ = s.UsageStats.Load()
 	// 将当前选中的服务器 ID 同步到 AppConfig，供自动启动等逻辑使用
 	if id := s.Nodes.GetSelectedID(); id != "" {
 		_ = s.AppConfig.Set("selectedServerID", id)
@@ -59,17 +63,42 @@ func (s *Store) Reset() {
 	s.initialized = false
 }
 
+// changeListeners 数据变更回调列表。回调在修改数据的 goroutine 中同步调用，
+// 界面层需自行切回主线程（如 fyne.Do）。
+type changeListeners struct {
+	mu  sync.Mutex
+	fns []func()
+}
+
+func (l *changeListeners) add(fn func()) {
+	l.mu.Lock()
+	l.fns = append(l.fns, fn)
+	l.mu.Unlock()
+}
+
+func (l *changeListeners) notify() {
+	l.mu.Lock()
+	fns := append([]func(){}, l.fns...)
+	l.mu.Unlock()
+	for _, fn := range fns {
+		fn()
+	}
+}
+
 type NodesStore struct {
 	mu               sync.RWMutex
 	nodes            []*model.Node
-	NodesBinding     binding.UntypedList
+	listeners        changeListeners
 	selectedServerID string
+	latency          map[string]model.LatencyStats // 最近一次测速的采样统计（仅内存）
+	samples          map[string][2]int             // 本次运行累计的测速采样数：成功、失败（仅内存）
 }
 
 func NewNodesStore() *NodesStore {
 	return &NodesStore{
-		nodes:        make([]*model.Node, 0),
-		NodesBinding: binding.NewUntypedList(),
+		nodes:   make([]*model.Node, 0),
+		latency: make(map[string]model.LatencyStats),
+		samples: make(map[string][2]int),
 	}
 }
 
@@ -80,7 +109,7 @@ func (ns *NodesStore) Load() error {
 		ns.nodes = []*model.Node{}
 		ns.selectedServerID = ""
 		ns.mu.Unlock()
-		ns.updateBinding()
+		ns.listeners.notify()
 		return fmt.Errorf("节点存储: 加载节点列表失败: %w", err)
 	}
 
@@ -99,18 +128,13 @@ func (ns *NodesStore) Load() error {
 	}
 	ns.mu.Unlock()
 
-	ns.updateBinding()
+	ns.listeners.notify()
 	return nil
 }
 
-func (ns *NodesStore) updateBinding() {
-	ns.mu.RLock()
-	items := make([]any, len(ns.nodes))
-	for i, node := range ns.nodes {
-		items[i] = node
-	}
-	ns.mu.RUnlock()
-	_ = ns.NodesBinding.Set(items)
+// AddListener 注册节点列表重新加载后的回调（在加载数据的 goroutine 中调用）。
+func (ns *NodesStore) AddListener(fn func()) {
+	ns.listeners.add(fn)
 }
 
 func (ns *NodesStore) GetAll() []*model.Node {
@@ -129,7 +153,7 @@ func (ns *NodesStore) Get(id string) (*model.Node, error) {
 			return node, nil
 		}
 	}
-	return nil, fmt.Errorf("节点存储: 节点不存在: %s", id)
+	return nil, fmt.Errorf("节点存储: %w: %s", errs.ErrNodeNotFound, id)
 }
 
 func (ns *NodesStore) GetSelected() *model.Node {
@@ -173,6 +197,103 @@ func (ns *NodesStore) UpdateDelay(id string, delay int) error {
 	return ns.Load()
 }
 
+// UpdateLatency 记录测速采样统计，延迟（中位数）写入数据库；全部采样失败时不覆盖已有延迟。
+func (ns *NodesStore) UpdateLatency(id string, stats model.LatencyStats) error {
+	ns.mu.Lock()
+	ns.latency[id] = stats
+	counts := ns.samples[id]
+	ns.samples[id] = [2]int{counts[0] + stats.Samples, counts[1] + stats.Failures}
+	ns.mu.Unlock()
+	if stats.Samples == 0 {
+		return nil
+	}
+	return ns.UpdateDelay(id, stats.Median)
+}
+
+// GetLatency 获取节点最近一次测速的采样统计。
+func (ns *NodesStore) GetLatency(id string) (model.LatencyStats, bool) {
+	ns.mu.RLock()
+	defer ns.mu.RUnlock()
+	stats, ok := ns.latency[id]
+	return stats, ok
+}
+
+// SuccessRate 获取节点在本次运行中累计的测速成功率（成功采样 / 全部采样）。
+// 返回：成功率（0–1）和是否有测速记录
+func (ns *NodesStore) SuccessRate(id string) (float64, bool) {
+	ns.mu.RLock()
+	defer ns.mu.RUnlock()
+	counts := ns.samples[id]
+	if counts[0]+counts[1] == 0 {
+		return 0, false
+	}
+	return float64(counts[0]) / float64(counts[0]+counts[1]), true
+}
+
+// RecordConnected 记录节点最近一次连接时间。
+func (ns *NodesStore) RecordConnected(id string, at time.Time) error {
+	if err := database.RecordServerConnected(id, at); err != nil {
+		return fmt.Errorf("节点存储: 记录连接时间失败: %w", err)
+	}
+	return ns.Load()
+}
+
+// UpdateNotes 更新节点备注。
+func (ns *NodesStore) UpdateNotes(id, notes string) error {
+	if err := database.UpdateServerNotes(id, notes); err != nil {
+		return fmt.Errorf("节点存储: 更新备注失败: %w", err)
+	}
+	return ns.Load()
+}
+
+// UpdateOverrides 更新节点的用户覆盖（SNI、Host、路径），空值表示使用链接中的值。
+func (ns *NodesStore) UpdateOverrides(id, sni, host, path string) error {
+	if err := database.UpdateServerOverrides(id, sni, host, path); err != nil {
+		return fmt.Errorf("节点存储: 更新覆盖参数失败: %w", err)
+	}
+	return ns.Load()
+}
+
+// AddConnectedDuration 累加节点的连接时长。
+func (ns *NodesStore) AddConnectedDuration(id string, d time.Duration) error {
+	seconds := int64(d / time.Second)
+	if seconds <= 0 {
+		return nil
+	}
+	if err := database.AddServerConnectedSeconds(id, seconds); err != nil {
+		return fmt.Errorf("节点存储: 累加连接时长失败: %w", err)
+	}
+	return ns.Load()
+}
+
+// ClearHistory 清除所有节点的连接历史。
+func (ns *NodesStore) ClearHistory() error {
+	if err := database.ClearServerConnectHistory(); err != nil {
+		return fmt.Errorf("节点存储: 清除连接历史失败: %w", err)
+	}
+	return ns.Load()
+}
+
+// GetRecent 返回最近连接过的节点，按最近连接时间倒序，最多 limit 个。
+func (ns *NodesStore) GetRecent(limit int) []*model.Node {
+	ns.mu.RLock()
+	recent := make([]*model.Node, 0, len(ns.nodes))
+	for _, node := range ns.nodes {
+		if node.LastConnectedAt > 0 {
+			recent = append(recent, node)
+		}
+	}
+	ns.mu.RUnlock()
+
+	sort.Slice(recent, func(i, j int) bool {
+		return recent[i].LastConnectedAt > recent[j].LastConnectedAt
+	})
+	if limit > 0 && len(recent) > limit {
+		recent = recent[:limit]
+	}
+	return recent
+}
+
 func (ns *NodesStore) Delete(id string) error {
 	if err := database.DeleteServer(id); err != nil {
 		return fmt.Errorf("节点存储: 删除节点失败: %w", err)
@@ -207,21 +328,32 @@ func (ns *NodesStore) GetBySubscriptionID(subscriptionID int64) ([]*model.Node,
 	return result, nil
 }
 
+// Query 按条件从数据库过滤、排序并分页查询节点（节点较多时供列表页使用，不经过内存全量过滤）。
+// 返回：当前页节点、满足条件的总数和错误（如果有）
+func (ns *NodesStore) Query(q model.NodeQuery) ([]*model.Node, int, error) {
+	nodes, total, err := database.QueryServers(q)
+	if err != nil {
+		return nil, 0, fmt.Errorf("节点存储: 查询节点失败: %w", err)
+	}
+	result := make([]*model.Node, len(nodes))
+	for i := range nodes {
+		result[i] = &nodes[i]
+	}
+	return result, total, nil
+}
+
 type SubscriptionsStore struct {
-	mu                   sync.RWMutex
-	subscriptions        []*database.Subscription
-	SubscriptionsBinding binding.UntypedList
-	LabelsBinding        binding.StringList
-	subscriptionManager  *subscription.SubscriptionManager
-	parentStore          *Store
+	mu                  sync.RWMutex
+	subscriptions       []*database.Subscription
+	listeners           changeListeners
+	subscriptionManager *subscription.SubscriptionManager
+	parentStore         *Store
 }
 
 func NewSubscriptionsStore(subscriptionManager *subscription.SubscriptionManager) *SubscriptionsStore {
 	return &SubscriptionsStore{
-		subscriptions:        make([]*database.Subscription, 0),
-		SubscriptionsBinding: binding.NewUntypedList(),
-		LabelsBinding:        binding.NewStringList(),
-		subscriptionManager:  subscriptionManager,
+		subscriptions:       make([]*database.Subscription, 0),
+		subscriptionManager: subscriptionManager,
 	}
 }
 
@@ -239,32 +371,20 @@ func (ss *SubscriptionsStore) Load() error {
 		ss.mu.Lock()
 		ss.subscriptions = []*database.Subscription{}
 		ss.mu.Unlock()
-		ss.updateBinding()
+		ss.listeners.notify()
 		return fmt.Errorf("订阅存储: 加载订阅列表失败: %w", err)
 	}
 
 	ss.mu.Lock()
 	ss.subscriptions = subscriptions
 	ss.mu.Unlock()
-	ss.updateBinding()
+	ss.listeners.notify()
 	return nil
 }
 
-func (ss *SubscriptionsStore) updateBinding() {
-	ss.mu.RLock()
-	items := make([]any, len(ss.subscriptions))
-	for i, sub := range ss.subscriptions {
-		items[i] = sub
-	}
-	labels := make([]string, 0, len(ss.subscriptions))
-	for _, sub := range ss.subscriptions {
-		if sub.Label != "" {
-			labels = append(labels, sub.Label)
-		}
-	}
-	ss.mu.RUnlock()
-	_ = ss.SubscriptionsBinding.Set(items)
-	_ = ss.LabelsBinding.Set(labels)
+// AddListener 注册订阅列表重新加载后的回调（在加载数据的 goroutine 中调用）。
+func (ss *SubscriptionsStore) AddListener(fn func()) {
+	ss.listeners.add(fn)
 }
 
 func (ss *SubscriptionsStore) GetAll() []*database.Subscription {
@@ -321,6 +441,14 @@ func (ss *SubscriptionsStore) Update(id int64, url, label string) error {
 	return ss.Load()
 }
 
+// UpdateNotes 更新订阅备注。
+func (ss *SubscriptionsStore) UpdateNotes(id int64, notes string) error {
+	if err := database.UpdateSubscriptionNotes(id, notes); err != nil {
+		return fmt.Errorf("订阅存储: 更新备注失败: %w", err)
+	}
+	return ss.Load()
+}
+
 func (ss *SubscriptionsStore) Delete(id int64) error {
 	if err := database.DeleteSubscription(id); err != nil {
 		return fmt.Errorf("订阅存储: 删除订阅失败: %w", err)
@@ -378,14 +506,19 @@ func (ss *SubscriptionsStore) Fetch(url string, label ...string) error {
 }
 
 type LayoutStore struct {
-	config        *LayoutConfig
-	ConfigBinding binding.Untyped
+	config *LayoutConfig
 }
 
 type LayoutConfig struct {
 	SubscriptionOffset float64 `json:"subscriptionOffset"`
 	ServerListOffset   float64 `json:"serverListOffset"`
 	StatusOffset       float64 `json:"statusOffset"`
+
+	// 上次关闭时的页面状态，启动时恢复（页面类型取值见 ui.PageType）
+	LastPage       int     `json:"lastPage"`
+	PageStack      []int   `json:"pageStack,omitempty"`
+	SettingsMenu   int     `json:"settingsMenu"`
+	NodeListOffset float32 `json:"nodeListOffset"`
 }
 
 func DefaultLayoutConfig() *LayoutConfig {
@@ -398,8 +531,7 @@ func DefaultLayoutConfig() *LayoutConfig {
 
 func NewLayoutStore() *LayoutStore {
 	return &LayoutStore{
-		config:        DefaultLayoutConfig(),
-		ConfigBinding: binding.NewUntyped(),
+		config: DefaultLayoutConfig(),
 	}
 }
 
@@ -408,26 +540,19 @@ func (ls *LayoutStore) Load() error {
 	if err != nil || configJSON == "" {
 		ls.config = DefaultLayoutConfig()
 		ls.save()
-		ls.updateBinding()
 		return nil
 	}
 	var config LayoutConfig
 	if err := json.Unmarshal([]byte(configJSON), &config); err != nil {
 		ls.config = DefaultLayoutConfig()
 		ls.save()
-		ls.updateBinding()
 		return nil
 	}
 
 	ls.config = &config
-	ls.updateBinding()
 	return nil
 }
 
-func (ls *LayoutStore) updateBinding() {
-	_ = ls.ConfigBinding.Set(ls.config)
-}
-
 func (ls *LayoutStore) Get() *LayoutConfig {
 	return ls.config
 }
@@ -449,14 +574,13 @@ func (ls *LayoutStore) save() error {
 	if err := database.SetLayoutConfig("layout_config", string(configJSON)); err != nil {
 		return fmt.Errorf("布局存储: 保存布局配置失败: %w", err)
 	}
-
-	ls.updateBinding()
 	return nil
 }
 
 type AppConfigStore struct {
-	config     map[string]string
-	windowSize fyne.Size
+	config       map[string]string
+	windowWidth  float32
+	windowHeight float32
 }
 
 func NewAppConfigStore() *AppConfigStore {
@@ -466,37 +590,34 @@ func NewAppConfigStore() *AppConfigStore {
 }
 
 func (acs *AppConfigStore) Load() error {
-	defaultSize := fyne.NewSize(420, 520)
+	acs.windowWidth, acs.windowHeight = 420, 520
 	sizeStr, err := database.GetAppConfig("windowSize")
 	if err != nil || sizeStr == "" {
-		acs.windowSize = defaultSize
-	} else {
-		parts := splitSizeString(sizeStr)
-		if len(parts) == 2 {
-			width, err1 := strconv.ParseFloat(parts[0], 32)
-			height, err2 := strconv.ParseFloat(parts[1], 32)
-			if err1 == nil && err2 == nil {
-				acs.windowSize = fyne.NewSize(float32(width), float32(height))
-			} else {
-				acs.windowSize = defaultSize
-			}
-		} else {
-			acs.windowSize = defaultSize
+		return nil
+	}
+	parts := splitSizeString(sizeStr)
+	if len(parts) == 2 {
+		width, err1 := strconv.ParseFloat(parts[0], 32)
+		height, err2 := strconv.ParseFloat(parts[1], 32)
+		if err1 == nil && err2 == nil {
+			acs.windowWidth, acs.windowHeight = float32(width), float32(height)
 		}
 	}
 	return nil
 }
 
-func (acs *AppConfigStore) GetWindowSize(defaultSize fyne.Size) fyne.Size {
-	if acs.windowSize.Width == 0 && acs.windowSize.Height == 0 {
-		return defaultSize
+// GetWindowSize 获取保存的窗口大小，未保存时返回默认值。
+func (acs *AppConfigStore) GetWindowSize(defaultWidth, defaultHeight float32) (float32, float32) {
+	if acs.windowWidth == 0 && acs.windowHeight == 0 {
+		return defaultWidth, defaultHeight
 	}
-	return acs.windowSize
+	return acs.windowWidth, acs.windowHeight
 }
 
-func (acs *AppConfigStore) SaveWindowSize(size fyne.Size) error {
-	acs.windowSize = size
-	sizeStr := fmt.Sprintf("%.0f,%.0f", float64(size.Width), float64(size.Height))
+// SaveWindowSize 保存窗口大小。
+func (acs *AppConfigStore) SaveWindowSize(width, height float32) error {
+	acs.windowWidth, acs.windowHeight = width, height
+	sizeStr := fmt.Sprintf("%.0f,%.0f", float64(width), float64(height))
 	if err := database.SetAppConfig("windowSize", sizeStr); err != nil {
 		return fmt.Errorf("应用配置存储: 保存窗口大小失败: %w", err)
 	}
@@ -511,6 +632,15 @@ func (acs *AppConfigStore) GetWithDefault(key, defaultValue string) (string, err
 	return database.GetAppConfigWithDefault(key, defaultValue)
 }
 
+// GetAll 获取全部配置项（用于导出诊断包）。
+func (acs *AppConfigStore) GetAll() (map[string]string, error) {
+	config, err := database.GetAllAppConfig()
+	if err != nil {
+		return nil, fmt.Errorf("应用配置存储: %w", err)
+	}
+	return config, nil
+}
+
 func (acs *AppConfigStore) Set(key, value string) error {
 	if err := database.SetAppConfig(key, value); err != nil {
 		return fmt.Errorf("应用配置存储: 保存配置失败: %w", err)
@@ -523,18 +653,43 @@ func splitSizeString(s string) []string {
 	return strings.Split(s, ",")
 }
 
+// ProxyStatusStore 代理状态的显示文本（连接状态、监听端口、当前节点名）。
 type ProxyStatusStore struct {
-	ProxyStatusBinding binding.String
-	PortBinding        binding.String
-	ServerNameBinding  binding.String
+	mu         sync.RWMutex
+	status     string
+	port       string
+	serverName string
+	listeners  changeListeners
 }
 
 func NewProxyStatusStore() *ProxyStatusStore {
-	return &ProxyStatusStore{
-		ProxyStatusBinding: binding.NewString(),
-		PortBinding:        binding.NewString(),
-		ServerNameBinding:  binding.NewString(),
-	}
+	return &ProxyStatusStore{}
+}
+
+// AddListener 注册代理状态变化后的回调（在更新状态的 goroutine 中调用）。
+func (ps *ProxyStatusStore) AddListener(fn func()) {
+	ps.listeners.add(fn)
+}
+
+// Status 返回连接状态文本。
+func (ps *ProxyStatusStore) Status() string {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	return ps.status
+}
+
+// Port 返回监听端口文本。
+func (ps *ProxyStatusStore) Port() string {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	return ps.port
+}
+
+// ServerName 返回当前节点名称，未选中时为「无」。
+func (ps *ProxyStatusStore) ServerName() string {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	return ps.serverName
 }
 
 func (ps *ProxyStatusStore) UpdateProxyStatus(xrayInstance interface {
@@ -567,27 +722,24 @@ func (ps *ProxyStatusStore) UpdateProxyStatus(xrayInstance interface {
 			}()
 		}
 	}
+	status, port := "当前连接状态: ⚪ 未连接", "监听端口: -"
 	if isRunning {
-		ps.ProxyStatusBinding.Set("当前连接状态: 🟢 已连接")
+		status = "当前连接状态: 🟢 已连接"
 		if proxyPort > 0 {
-			ps.PortBinding.Set(fmt.Sprintf("监听端口: %d", proxyPort))
-		} else {
-			ps.PortBinding.Set("监听端口: -")
+			port = fmt.Sprintf("监听端口: %d", proxyPort)
 		}
-	} else {
-		ps.ProxyStatusBinding.Set("当前连接状态: ⚪ 未连接")
-		ps.PortBinding.Set("监听端口: -")
 	}
+	serverName := "无"
 	if nodesStore != nil {
-		selectedNode := nodesStore.GetSelected()
-		if selectedNode != nil {
-			ps.ServerNameBinding.Set(selectedNode.Name)
-		} else {
-			ps.ServerNameBinding.Set("无")
+		if selectedNode := nodesStore.GetSelected(); selectedNode != nil {
+			serverName = selectedNode.Name
 		}
-	} else {
-		ps.ServerNameBinding.Set("无")
 	}
+
+	ps.mu.Lock()
+	ps.status, ps.port, ps.serverName = status, port, serverName
+	ps.mu.Unlock()
+	ps.listeners.notify()
 }
 
 // AccessRecordsStore 访问记录存储，用于流量分析。
@@ -653,3 +805,50 @@ func (ars *AccessRecordsStore) ClearAll() error {
 	ars.mu.Unlock()
 	return nil
 }
+
+// UsageStatsStore 功能使用统计存储（仅本地）。
+type UsageStatsStore struct {
+	mu    sync.RWMutex
+	stats []model.UsageStat
+}
+
+func NewUsageStatsStore() *UsageStatsStore {
+	return &UsageStatsStore{}
+}
+
+func (uss *UsageStatsStore) Load() error {
+	stats, err := database.GetAllUsageStats()
+	if err != nil {
+		return fmt.Errorf("使用统计存储: 加载失败: %w", err)
+	}
+	uss.mu.Lock()
+	uss.stats = stats
+	uss.mu.Unlock()
+	return nil
+}
+
+func (uss *UsageStatsStore) GetAll() []model.UsageStat {
+	uss.mu.RLock()
+	defer uss.mu.RUnlock()
+	result := make([]model.UsageStat, len(uss.stats))
+	copy(result, uss.stats)
+	return result
+}
+
+// Increment 累加功能使用次数。
+func (uss *UsageStatsStore) Increment(feature model.UsageFeature) error {
+	if err := database.IncrementUsageStat(string(feature)); err != nil {
+		return err
+	}
+	return uss.Load()
+}
+
+func (uss *UsageStatsStore) ClearAll() error {
+	if err := database.ClearUsageStats(); err != nil {
+		return err
+	}
+	uss.mu.Lock()
+	uss.stats = nil
+	uss.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,29 @@
+package subscription
+
+import (
+	"encoding/base64"
+	"strings"
+)
+
+// base64Normalizer 去除空白并把 URL 安全字母表统一为标准字母表
+var base64Normalizer = strings.NewReplacer(
+	" ", "", "\t", "", "\r", "", "\n", "",
+	"-", "+", "_", "/",
+)
+
+// decodeBase64Loose 宽松的 Base64 解码，兼容各服务商的不规范输出：
+// 有无填充均可、标准与 URL 安全字母表混用、开头带 BOM、内容中夹有换行（含 CRLF）或空格。
+// 解码结果开头的 UTF-8 BOM 同样去除。
+// 参数：
+//   - s: Base64 文本
+//
+// 返回：解码后的数据和错误（含非法字符时）
+func decodeBase64Loose(s string) ([]byte, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "\ufeff")
+	s = strings.TrimRight(base64Normalizer.Replace(s), "=")
+	decoded, err := base64.RawStdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(strings.TrimPrefix(string(decoded), "\ufeff")), nil
+}
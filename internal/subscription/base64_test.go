@@ -0,0 +1,47 @@
+package subscription
+
+import "testing"
+
+func TestDecodeBase64Loose(t *testing.T) {
+	// 标准编码为 c3M6Ly9hP2I+Y35k//4=，同时包含 +、/ 和填充
+	const plain = "ss://a?b>c~d\xff\xfe"
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "标准字母表带填充", input: "c3M6Ly9hP2I+Y35k//4=", want: plain},
+		{name: "标准字母表无填充", input: "c3M6Ly9hP2I+Y35k//4", want: plain},
+		{name: "多余填充", input: "c3M6Ly9hP2I+Y35k//4===", want: plain},
+		{name: "URL 安全字母表无填充", input: "c3M6Ly9hP2I-Y35k__4", want: plain},
+		{name: "URL 安全字母表带填充", input: "c3M6Ly9hP2I-Y35k__4=", want: plain},
+		{name: "两种字母表混用", input: "c3M6Ly9hP2I+Y35k__4", want: plain},
+		{name: "内容中夹有 CRLF 换行", input: "c3M6\r\nLy9h\r\nP2I+\r\nY35k\r\n//4=\r\n", want: plain},
+		{name: "内容中夹有空格和制表符", input: " c3M6Ly9h P2I+Y35k\t//4= ", want: plain},
+		{name: "开头带 BOM", input: "\ufeffc3M6Ly9hP2I+Y35k//4=", want: plain},
+		{name: "解码结果开头的 BOM 去除", input: "77u/aGVsbG8=", want: "hello"},
+		{name: "空字符串", input: "", want: ""},
+		{name: "非法字符", input: "c3M6*Ly9h", wantErr: true},
+		{name: "长度非法", input: "c3M6L", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeBase64Loose(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("decodeBase64Loose(%q) = %q，期望返回错误", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeBase64Loose(%q) 返回错误: %v", tt.input, err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("decodeBase64Loose(%q) = %q，期望 %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package subscription
+
+import (
+	"fmt"
+
+	"myproxy.com/p/internal/database"
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/utils"
+)
+
+// forkNameSuffix 用户修改另存为独立节点时追加的名称后缀
+const forkNameSuffix = "（本地修改）"
+
+// SetConflictPolicy 设置订阅更新时用户修改过的节点的冲突处理方式，未知策略按保留处理。
+func (sm *SubscriptionManager) SetConflictPolicy(policy model.ConflictPolicy) {
+	if !policy.Valid() {
+		policy = model.ConflictPolicyKeep
+	}
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.policy = policy
+}
+
+// conflictPolicy 返回当前冲突策略。
+func (sm *SubscriptionManager) conflictPolicy() model.ConflictPolicy {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if sm.policy == "" {
+		return model.ConflictPolicyKeep
+	}
+	return sm.policy
+}
+
+// Conflicts 返回指定订阅最近一次更新留下的待处理冲突（仅询问策略产生，仅保存在内存中）。
+func (sm *SubscriptionManager) Conflicts(url string) []model.NodeConflict {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	conflicts := sm.conflicts[url]
+	result := make([]model.NodeConflict, len(conflicts))
+	copy(result, conflicts)
+	return result
+}
+
+// ResolveConflict 处理一处待处理冲突。
+// 参数：
+//   - url: 订阅 URL
+//   - localID: 用户修改的节点 ID
+//   - resolution: 处理方式（保留 / 覆盖 / 另存），不能为询问
+//
+// 返回：错误（如果有）
+func (sm *SubscriptionManager) ResolveConflict(url, localID string, resolution model.ConflictPolicy) error {
+	if !resolution.Valid() || resolution == model.ConflictPolicyAsk {
+		return fmt.Errorf("无效的冲突处理方式: %s", resolution)
+	}
+
+	sm.mu.Lock()
+	var conflict *model.NodeConflict
+	pending := sm.conflicts[url]
+	for i := range pending {
+		if pending[i].Local.ID == localID {
+			c := pending[i]
+			conflict = &c
+			sm.conflicts[url] = append(pending[:i:i], pending[i+1:]...)
+			break
+		}
+	}
+	sm.mu.Unlock()
+	if conflict == nil {
+		return fmt.Errorf("冲突不存在或已处理")
+	}
+
+	// 以数据库中的当前版本为准（冲突产生后用户可能又改过）
+	local := conflict.Local
+	if current, err := database.GetServer(localID); err == nil {
+		local = *current
+	}
+	return database.UpdateServersInTx(func(tx *database.ServerTx) error {
+		return applyResolution(tx, local, conflict.Remote, resolution, nil)
+	})
+}
+
+// mergeModified 订阅更新时合并用户修改过的节点 local 与订阅新版本 remote。
+// 订阅内容与用户修改时相同（原始配置一致）时保留用户修改（覆盖策略除外）；
+// 询问策略下暂时保留用户修改并返回冲突，其余策略直接处理。写入在调用方的事务 tx 中进行。
+func mergeModified(tx *database.ServerTx, local, remote model.Node, policy model.ConflictPolicy, subscriptionID *int64) (*model.NodeConflict, error) {
+	if local.RawConfig == remote.RawConfig && policy != model.ConflictPolicyOverwrite {
+		return nil, applyResolution(tx, local, remote, model.ConflictPolicyKeep, subscriptionID)
+	}
+	if policy == model.ConflictPolicyAsk {
+		if err := tx.AddOrUpdateServer(local, subscriptionID); err != nil {
+			return nil, fmt.Errorf("保存用户修改的节点失败: %w", err)
+		}
+		return &model.NodeConflict{Local: local, Remote: remote}, nil
+	}
+	return nil, applyResolution(tx, local, remote, policy, subscriptionID)
+}
+
+// applyResolution 按处理方式在事务 tx 中写入节点，始终沿用本地节点 ID，保留选中状态和连接历史。
+// remote 为订阅解析出的新版本，尚未写入数据库。
+func applyResolution(tx *database.ServerTx, local, remote model.Node, resolution model.ConflictPolicy, subscriptionID *int64) error {
+	node := local
+	switch resolution {
+	case model.ConflictPolicyOverwrite:
+		node = adoptRemote(local, remote)
+	case model.ConflictPolicyFork:
+		fork := local
+		fork.ID = utils.GenerateServerID(local.Addr, local.Port, local.Username)
+		fork.Name = local.Name + forkNameSuffix
+		fork.Selected = false
+		fork.UserModified = false
+		// 连接历史留在原节点上，避免「最近使用」中出现两个相同节点
+		fork.LastConnectedAt = 0
+		fork.ConnectedSeconds = 0
+		// 新记录不关联订阅，后续更新不再影响
+		if err := tx.AddOrUpdateServer(fork, nil); err != nil {
+			return fmt.Errorf("另存用户修改的节点失败: %w", err)
+		}
+		node = adoptRemote(local, remote)
+	default:
+		// 保留：记下订阅的新原始配置，订阅再次变化前不再视为冲突
+		node.RawConfig = remote.RawConfig
+	}
+
+	if err := tx.AddOrUpdateServer(node, subscriptionID); err != nil {
+		return fmt.Errorf("保存节点失败: %w", err)
+	}
+	return nil
+}
+
+// adoptRemote 使用订阅版本的配置，保留本地节点的 ID、选中、启用、延迟、备注和连接历史。
+func adoptRemote(local, remote model.Node) model.Node {
+	node := remote
+	node.ID = local.ID
+	node.Selected = local.Selected
+	node.Enabled = local.Enabled
+	node.Delay = local.Delay
+	node.Notes = local.Notes
+	node.LastConnectedAt = local.LastConnectedAt
+	node.ConnectedSeconds = local.ConnectedSeconds
+	node.UserModified = false
+	return node
+}
+
+// nodeIdentity 订阅节点的身份标识：节点 ID 每次解析都会重新生成，
+// 以协议、地址、端口和凭据匹配更新前后的同一节点。
+func nodeIdentity(n model.Node) string {
+	return fmt.Sprintf("%s|%s|%d|%s|%s|%s|%s", n.ProtocolType, n.Addr, n.Port, n.Username, n.Password, n.VMessUUID, n.VLESSUUID)
+}
@@ -0,0 +1,325 @@
+package subscription
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"myproxy.com/p/internal/database"
+	"myproxy.com/p/internal/errs"
+	"myproxy.com/p/internal/model"
+)
+
+// feedServer 返回内容可随时替换的测试订阅服务器。
+type feedServer struct {
+	*httptest.Server
+	mu     sync.Mutex
+	status int
+	body   string
+}
+
+func newFeedServer(t *testing.T) *feedServer {
+	t.Helper()
+	fs := &feedServer{status: http.StatusOK}
+	fs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fs.mu.Lock()
+		defer fs.mu.Unlock()
+		w.WriteHeader(fs.status)
+		fmt.Fprint(w, fs.body)
+	}))
+	t.Cleanup(fs.Close)
+	return fs
+}
+
+func (fs *feedServer) set(status int, body string) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.status, fs.body = status, body
+}
+
+// jsonFeed 生成 JSON 格式订阅，names 为各节点名称，节点依次使用 10.0.0.1、10.0.0.2……
+// 名称只影响原始配置，不影响节点身份标识。
+func jsonFeed(names ...string) string {
+	items := make([]string, 0, len(names))
+	for i, name := range names {
+		items = append(items, fmt.Sprintf(`{"name":%q,"addr":"10.0.0.%d","port":1080,"username":"u","password":"p"}`, name, i+1))
+	}
+	return "[" + strings.Join(items, ",") + "]"
+}
+
+// newTestDB 使用临时数据库，测试结束后关闭。
+func newTestDB(t *testing.T) {
+	t.Helper()
+	if err := database.InitDB(filepath.Join(t.TempDir(), "myproxy.db")); err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	t.Cleanup(func() { _ = database.CloseDB() })
+}
+
+// subscriptionNodes 返回订阅下的节点（按名称索引）。
+func subscriptionNodes(t *testing.T, url string) map[string]database.Node {
+	t.Helper()
+	sub, err := database.GetSubscriptionByURL(url)
+	if err != nil || sub == nil {
+		t.Fatalf("获取订阅失败: %v", err)
+	}
+	servers, err := database.GetServersBySubscriptionID(sub.ID)
+	if err != nil {
+		t.Fatalf("获取订阅节点失败: %v", err)
+	}
+	nodes := make(map[string]database.Node)
+	for _, s := range servers {
+		nodes[s.Name] = s
+	}
+	return nodes
+}
+
+// modifyNode 模拟用户编辑节点：改名并标记为用户修改。
+func modifyNode(t *testing.T, node database.Node, name string) database.Node {
+	t.Helper()
+	node.Name = name
+	node.Notes = "自用"
+	node.UserModified = true
+	if err := database.AddOrUpdateServer(node, nil); err != nil {
+		t.Fatalf("修改节点失败: %v", err)
+	}
+	return node
+}
+
+func TestUpdateSubscriptionKeepsNodesWhenFetchFails(t *testing.T) {
+	newTestDB(t)
+	feed := newFeedServer(t)
+	sm := NewSubscriptionManager()
+
+	feed.set(http.StatusOK, jsonFeed("A", "B"))
+	if err := sm.UpdateSubscription(feed.URL, "测试"); err != nil {
+		t.Fatalf("首次更新失败: %v", err)
+	}
+	local := modifyNode(t, subscriptionNodes(t, feed.URL)["A"], "我的A")
+
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr error
+	}{
+		{name: "token 失效", status: http.StatusUnauthorized, wantErr: errs.ErrAuthFailed},
+		{name: "服务器错误", status: http.StatusInternalServerError},
+		{name: "没有有效节点", status: http.StatusOK, body: "not a subscription"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed.set(tt.status, tt.body)
+			err := sm.UpdateSubscription(feed.URL)
+			if err == nil {
+				t.Fatal("更新应当失败")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("错误 = %v，期望包装 %v", err, tt.wantErr)
+			}
+
+			nodes := subscriptionNodes(t, feed.URL)
+			if len(nodes) != 2 {
+				t.Fatalf("节点数 = %d，期望 2", len(nodes))
+			}
+			got, ok := nodes["我的A"]
+			if !ok || got.ID != local.ID || !got.UserModified || got.Notes != "自用" {
+				t.Errorf("用户修改的节点 = %+v，期望保持不变", got)
+			}
+		})
+	}
+}
+
+func TestUpdateSubscriptionConflictPolicies(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        model.ConflictPolicy
+		wantName      string // 本地节点 ID 对应的节点名
+		wantModified  bool
+		wantFork      bool // 是否另存了不关联订阅的本地副本
+		wantConflicts int
+	}{
+		{name: "保留", policy: model.ConflictPolicyKeep, wantName: "我的A", wantModified: true},
+		{name: "覆盖", policy: model.ConflictPolicyOverwrite, wantName: "A2"},
+		{name: "另存", policy: model.ConflictPolicyFork, wantName: "A2", wantFork: true},
+		{name: "询问", policy: model.ConflictPolicyAsk, wantName: "我的A", wantModified: true, wantConflicts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestDB(t)
+			feed := newFeedServer(t)
+			sm := NewSubscriptionManager()
+			sm.SetConflictPolicy(tt.policy)
+
+			feed.set(http.StatusOK, jsonFeed("A"))
+			if err := sm.UpdateSubscription(feed.URL, "测试"); err != nil {
+				t.Fatalf("首次更新失败: %v", err)
+			}
+			local := modifyNode(t, subscriptionNodes(t, feed.URL)["A"], "我的A")
+
+			// 订阅中同一节点的原始配置发生变化
+			feed.set(http.StatusOK, jsonFeed("A2"))
+			if err := sm.UpdateSubscription(feed.URL); err != nil {
+				t.Fatalf("更新失败: %v", err)
+			}
+
+			got, err := database.GetServer(local.ID)
+			if err != nil {
+				t.Fatalf("本地节点丢失: %v", err)
+			}
+			if got.Name != tt.wantName || got.UserModified != tt.wantModified {
+				t.Errorf("本地节点 = (%s, 已修改 %v)，期望 (%s, %v)", got.Name, got.UserModified, tt.wantName, tt.wantModified)
+			}
+			if got.Notes != "自用" {
+				t.Errorf("备注 = %q，期望保留", got.Notes)
+			}
+			if n := len(subscriptionNodes(t, feed.URL)); n != 1 {
+				t.Errorf("订阅节点数 = %d，期望 1", n)
+			}
+
+			all, err := database.GetAllServers()
+			if err != nil {
+				t.Fatalf("获取全部节点失败: %v", err)
+			}
+			forked := false
+			for _, s := range all {
+				if s.Name == "我的A"+forkNameSuffix && s.ID != local.ID && !s.UserModified {
+					forked = true
+				}
+			}
+			if forked != tt.wantFork {
+				t.Errorf("另存副本 = %v，期望 %v", forked, tt.wantFork)
+			}
+
+			conflicts := sm.Conflicts(feed.URL)
+			if len(conflicts) != tt.wantConflicts {
+				t.Fatalf("冲突数 = %d，期望 %d", len(conflicts), tt.wantConflicts)
+			}
+			if tt.wantConflicts == 0 {
+				return
+			}
+			if conflicts[0].Local.ID != local.ID || conflicts[0].Remote.Name != "A2" {
+				t.Errorf("冲突 = %+v，期望本地 %s 与订阅版本 A2", conflicts[0], local.ID)
+			}
+			// 询问后选择覆盖
+			if err := sm.ResolveConflict(feed.URL, local.ID, model.ConflictPolicyOverwrite); err != nil {
+				t.Fatalf("处理冲突失败: %v", err)
+			}
+			if got, err := database.GetServer(local.ID); err != nil || got.Name != "A2" || got.UserModified {
+				t.Errorf("覆盖后节点 = %+v（%v），期望名称 A2 且不再标记修改", got, err)
+			}
+			if n := len(sm.Conflicts(feed.URL)); n != 0 {
+				t.Errorf("处理后冲突数 = %d，期望 0", n)
+			}
+		})
+	}
+}
+
+func TestUpdateSubscriptionModifiedNodeLeftFeed(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   model.ConflictPolicy
+		wantKept bool
+	}{
+		{name: "保留", policy: model.ConflictPolicyKeep, wantKept: true},
+		{name: "询问", policy: model.ConflictPolicyAsk, wantKept: true},
+		{name: "另存", policy: model.ConflictPolicyFork, wantKept: true},
+		{name: "覆盖", policy: model.ConflictPolicyOverwrite, wantKept: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestDB(t)
+			feed := newFeedServer(t)
+			sm := NewSubscriptionManager()
+			sm.SetConflictPolicy(tt.policy)
+
+			feed.set(http.StatusOK, jsonFeed("A", "B"))
+			if err := sm.UpdateSubscription(feed.URL, "测试"); err != nil {
+				t.Fatalf("首次更新失败: %v", err)
+			}
+			local := modifyNode(t, subscriptionNodes(t, feed.URL)["B"], "我的B")
+
+			// B 从订阅中移除
+			feed.set(http.StatusOK, jsonFeed("A"))
+			if err := sm.UpdateSubscription(feed.URL); err != nil {
+				t.Fatalf("更新失败: %v", err)
+			}
+
+			if _, inFeed := subscriptionNodes(t, feed.URL)["我的B"]; inFeed {
+				t.Error("已离开订阅的节点仍关联订阅")
+			}
+			got, err := database.GetServer(local.ID)
+			if kept := err == nil; kept != tt.wantKept {
+				t.Fatalf("保留本地节点 = %v，期望 %v", kept, tt.wantKept)
+			}
+			if tt.wantKept && (got.Name != "我的B" || got.Notes != "自用") {
+				t.Errorf("保留的节点 = %+v，期望名称和备注不变", got)
+			}
+		})
+	}
+}
+
+func TestUpdateSubscriptionKeepsConnectHistory(t *testing.T) {
+	newTestDB(t)
+	feed := newFeedServer(t)
+	sm := NewSubscriptionManager()
+
+	feed.set(http.StatusOK, jsonFeed("A"))
+	if err := sm.UpdateSubscription(feed.URL, "测试"); err != nil {
+		t.Fatalf("首次更新失败: %v", err)
+	}
+	node := subscriptionNodes(t, feed.URL)["A"]
+	connectedAt := time.Unix(1700000000, 0)
+	if err := database.RecordServerConnected(node.ID, connectedAt); err != nil {
+		t.Fatalf("记录连接失败: %v", err)
+	}
+	if err := database.AddServerConnectedSeconds(node.ID, 90); err != nil {
+		t.Fatalf("累加连接时长失败: %v", err)
+	}
+
+	if err := sm.UpdateSubscription(feed.URL); err != nil {
+		t.Fatalf("更新失败: %v", err)
+	}
+	got := subscriptionNodes(t, feed.URL)["A"]
+	if got.LastConnectedAt != connectedAt.Unix() || got.ConnectedSeconds != 90 {
+		t.Errorf("连接历史 = (%d, %d 秒)，期望 (%d, 90 秒)", got.LastConnectedAt, got.ConnectedSeconds, connectedAt.Unix())
+	}
+}
+
+func TestUpdateSubscriptionKeepsNodeIDs(t *testing.T) {
+	newTestDB(t)
+	feed := newFeedServer(t)
+	sm := NewSubscriptionManager()
+
+	feed.set(http.StatusOK, jsonFeed("A", "B"))
+	if err := sm.UpdateSubscription(feed.URL, "测试"); err != nil {
+		t.Fatalf("首次更新失败: %v", err)
+	}
+	before := subscriptionNodes(t, feed.URL)
+
+	// 订阅新增节点 C，A、B 名称变化但身份标识不变
+	feed.set(http.StatusOK, jsonFeed("A2", "B2", "C"))
+	if err := sm.UpdateSubscription(feed.URL); err != nil {
+		t.Fatalf("更新失败: %v", err)
+	}
+	after := subscriptionNodes(t, feed.URL)
+	if len(after) != 3 {
+		t.Fatalf("节点数 = %d，期望 3", len(after))
+	}
+	for old, cur := range map[string]string{"A": "A2", "B": "B2"} {
+		if after[cur].ID != before[old].ID {
+			t.Errorf("节点 %s 的 ID = %s，期望沿用 %s", cur, after[cur].ID, before[old].ID)
+		}
+	}
+	if id := after["C"].ID; id == before["A"].ID || id == before["B"].ID {
+		t.Errorf("新节点 C 复用了旧 ID %s", id)
+	}
+}
@@ -1,8 +1,8 @@
 package subscription
 
 import (
-	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,9 +10,12 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"myproxy.com/p/internal/database"
+	"myproxy.com/p/internal/errs"
 	"myproxy.com/p/internal/model"
 	"myproxy.com/p/internal/utils"
 )
@@ -30,29 +33,25 @@ type VMessParser struct{}
 func (p *VMessParser) Parse(content string) (*model.Node, error) {
 	// 移除前缀
 	vmessData := strings.TrimPrefix(content, "vmess://")
-	// 解码Base64
-	decoded, err := base64.StdEncoding.DecodeString(vmessData)
+	// 解码Base64（兼容无填充、URL 安全字母表等变体）
+	decoded, err := decodeBase64Loose(vmessData)
 	if err != nil {
-		// 尝试URL安全的Base64解码
-		decoded, err = base64.URLEncoding.DecodeString(vmessData)
-		if err != nil {
-			return nil, err
-		}
+		return nil, err
 	}
 
 	// 解析JSON - 包含所有字段
 	var vmessConfig struct {
-		V    string `json:"v"`    // 版本
-		Ps   string `json:"ps"`   // 备注/名称
-		Add  string `json:"add"`  // 地址
-		Port string `json:"port"` // 端口（字符串类型）
-		Id   string `json:"id"`   // UUID
-		Aid  string `json:"aid"`  // AlterID（字符串类型，可能是 "0"）
-		Net  string `json:"net"`  // 传输协议: tcp, kcp, ws, h2, quic, grpc
-		Type string `json:"type"` // 伪装类型: none, http, srtp, utp, wechat-video
-		Host string `json:"host"` // 伪装域名
-		Path string `json:"path"` // 路径
-		Tls  string `json:"tls"`  // TLS: "" 或 "tls"
+		V    string     `json:"v"`    // 版本
+		Ps   string     `json:"ps"`   // 备注/名称
+		Add  string     `json:"add"`  // 地址
+		Port flexString `json:"port"` // 端口（部分订阅为数字类型）
+		Id   string     `json:"id"`   // UUID
+		Aid  flexString `json:"aid"`  // AlterID（可能是 "0" 或 0）
+		Net  string     `json:"net"`  // 传输协议: tcp, kcp, ws, h2, quic, grpc
+		Type string     `json:"type"` // 伪装类型: none, http, srtp, utp, wechat-video
+		Host string     `json:"host"` // 伪装域名
+		Path string     `json:"path"` // 路径
+		Tls  string     `json:"tls"`  // TLS: "" 或 "tls"
 	}
 
 	decodedStr := string(decoded)
@@ -62,15 +61,15 @@ func (p *VMessParser) Parse(content string) (*model.Node, error) {
 	}
 
 	// 将port转换为整数
-	port, err := strconv.Atoi(vmessConfig.Port)
+	port, err := strconv.Atoi(string(vmessConfig.Port))
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid VMess port: %w", err)
 	}
 
 	// 将aid转换为整数
 	aid := 0
 	if vmessConfig.Aid != "" {
-		if aidInt, err := strconv.Atoi(vmessConfig.Aid); err == nil {
+		if aidInt, err := strconv.Atoi(string(vmessConfig.Aid)); err == nil {
 			aid = aidInt
 		}
 	}
@@ -112,6 +111,24 @@ func (p *VMessParser) Parse(content string) (*model.Node, error) {
 	return s, nil
 }
 
+// flexString 兼容字符串和数字两种 JSON 取值（如 vmess 链接中的 port、aid）。
+type flexString string
+
+// UnmarshalJSON 实现 json.Unmarshaler。
+func (f *flexString) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*f = flexString(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("invalid value: %s", data)
+	}
+	*f = flexString(n.String())
+	return nil
+}
+
 // SSConfig SS协议配置
 type SSConfig struct {
 	Cipher     string
@@ -142,19 +159,9 @@ func (p *SSParser) Parse(content string) (*model.Node, error) {
 
 	if !found {
 		// 如果没有 @ 符号，说明整个部分都是 Base64 编码的
-		// 解码Base64 - 处理可能的填充问题
-		base64Str := ssDataWithoutRemark
-		// 确保Base64字符串的长度是4的倍数，必要时添加填充字符
-		for len(base64Str)%4 != 0 {
-			base64Str += "="
-		}
-		decoded, err := base64.StdEncoding.DecodeString(base64Str)
+		decoded, err := decodeBase64Loose(ssDataWithoutRemark)
 		if err != nil {
-			// 尝试URL安全的Base64解码
-			decoded, err = base64.URLEncoding.DecodeString(base64Str)
-			if err != nil {
-				return nil, err
-			}
+			return nil, err
 		}
 
 		ssStr := string(decoded)
@@ -169,13 +176,9 @@ func (p *SSParser) Parse(content string) (*model.Node, error) {
 		}
 	} else {
 		// 解码Base64
-		decoded, err := base64.StdEncoding.DecodeString(base64Part)
+		decoded, err := decodeBase64Loose(base64Part)
 		if err != nil {
-			// 尝试URL安全的Base64解码
-			decoded, err = base64.URLEncoding.DecodeString(base64Part)
-			if err != nil {
-				return nil, err
-			}
+			return nil, err
 		}
 
 		ssStr := string(decoded)
@@ -189,7 +192,7 @@ func (p *SSParser) Parse(content string) (*model.Node, error) {
 
 	// 解析地址和端口，以及可能的参数
 	addrPort, pluginPart, _ := strings.Cut(addrPortPart, "?")
-	addr, portStr, found := strings.Cut(addrPort, ":")
+	addr, portStr, found := splitAddrPort(addrPort)
 	if !found {
 		return nil, fmt.Errorf("invalid SS format: missing addr:port")
 	}
@@ -287,13 +290,15 @@ func (p *TrojanParser) Parse(content string) (*model.Node, error) {
 	passwordAddrPart, paramPart, _ := strings.Cut(trojanDataWithoutRemark, "?")
 
 	// 解析密码和地址端口
-	password, addrPort, found := strings.Cut(passwordAddrPart, "@")
-	if !found {
+	// 密码中可能含有未转义的 @，以最后一个 @ 分隔
+	at := strings.LastIndex(passwordAddrPart, "@")
+	if at == -1 {
 		return nil, fmt.Errorf("invalid Trojan format: missing @ separator")
 	}
+	password, addrPort := passwordAddrPart[:at], passwordAddrPart[at+1:]
 
 	// 解析地址和端口
-	addr, portStr, found := strings.Cut(addrPort, ":")
+	addr, portStr, found := splitAddrPort(addrPort)
 	if !found {
 		return nil, fmt.Errorf("invalid Trojan format: missing addr:port")
 	}
@@ -357,6 +362,102 @@ func (p *TrojanParser) Parse(content string) (*model.Node, error) {
 	return s, nil
 }
 
+// VLESSParser VLESS协议解析器
+type VLESSParser struct{}
+
+// Parse 解析VLESS协议
+// 格式：vless://uuid@addr:port?type=tcp&security=reality&flow=xtls-rprx-vision&sni=...&pbk=...&sid=...#name
+func (p *VLESSParser) Parse(content string) (*model.Node, error) {
+	// 备注中可能含有未转义的字符，先拆出再解析其余部分
+	link, name, _ := strings.Cut(content, "#")
+	if decodedName, err := url.QueryUnescape(name); err == nil {
+		name = decodedName
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VLESS format: %w", err)
+	}
+	uuid := ""
+	if u.User != nil {
+		uuid = u.User.Username()
+	}
+	if uuid == "" {
+		return nil, fmt.Errorf("invalid VLESS format: missing uuid")
+	}
+	addr := u.Hostname()
+	if addr == "" || u.Port() == "" {
+		return nil, fmt.Errorf("invalid VLESS format: missing addr:port")
+	}
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		return nil, fmt.Errorf("invalid VLESS port: %w", err)
+	}
+
+	q := u.Query()
+	network := strings.ToLower(q.Get("type"))
+	if network == "" {
+		network = "tcp"
+	}
+	security := strings.ToLower(q.Get("security"))
+	if security == "" {
+		security = "none"
+	}
+	encryption := q.Get("encryption")
+	if encryption == "" {
+		encryption = "none"
+	}
+	// grpc 的路径参数为 serviceName
+	path := q.Get("path")
+	if network == "grpc" && q.Get("serviceName") != "" {
+		path = q.Get("serviceName")
+	}
+	if security == "reality" && q.Get("pbk") == "" {
+		return nil, fmt.Errorf("invalid VLESS format: REALITY missing pbk")
+	}
+	allowInsecure := q.Get("allowInsecure") == "1" || strings.ToLower(q.Get("allowInsecure")) == "true"
+
+	// 生成服务器ID
+	serverID := utils.GenerateServerID(addr, port, uuid)
+
+	s := &model.Node{
+		ID:           serverID,
+		Name:         name,
+		Addr:         addr,
+		Port:         port,
+		Delay:        0,
+		Selected:     false,
+		Enabled:      true,
+		ProtocolType: "vless",
+		// VLESS 协议字段
+		VLESSUUID:          uuid,
+		VLESSEncryption:    encryption,
+		VLESSFlow:          q.Get("flow"),
+		VLESSNetwork:       network,
+		VLESSHeaderType:    q.Get("headerType"),
+		VLESSHost:          q.Get("host"),
+		VLESSPath:          path,
+		VLESSMode:          q.Get("mode"),
+		VLESSSecurity:      security,
+		VLESSSNI:           q.Get("sni"),
+		VLESSAlpn:          q.Get("alpn"),
+		VLESSFingerprint:   q.Get("fp"),
+		VLESSAllowInsecure: allowInsecure,
+		VLESSPublicKey:     q.Get("pbk"),
+		VLESSShortID:       q.Get("sid"),
+		VLESSSpiderX:       q.Get("spx"),
+		// 保存原始配置
+		RawConfig: content,
+	}
+
+	// 如果名称为空，使用地址:端口作为名称
+	if s.Name == "" {
+		s.Name = fmt.Sprintf("%s:%d", s.Addr, s.Port)
+	}
+
+	return s, nil
+}
+
 // SOCKS5Parser SOCKS5协议解析器
 type SOCKS5Parser struct{}
 
@@ -441,11 +542,71 @@ func (p *SimpleParser) Parse(content string) (*model.Node, error) {
 	return s, nil
 }
 
+// splitAddrPort 拆分 "addr:port"，支持 "[IPv6]:port"；IPv6 地址去掉方括号。
+// 订阅内容不可信，任何输入都只返回 found=false 而不会越界。
+func splitAddrPort(s string) (addr, port string, found bool) {
+	if strings.HasPrefix(s, "[") {
+		end := strings.Index(s, "]")
+		if end == -1 || !strings.HasPrefix(s[end+1:], ":") {
+			return "", "", false
+		}
+		return s[1:end], s[end+2:], true
+	}
+	idx := strings.LastIndex(s, ":")
+	if idx == -1 {
+		return "", "", false
+	}
+	return s[:idx], s[idx+1:], true
+}
+
+// errParserPanic 解析器 panic，说明解析器存在缺陷。
+var errParserPanic = errors.New("解析器异常")
+
+// safeParse 调用解析器并将 panic 转为包装 errParserPanic 的错误，单条畸形链接不会中断整个订阅的解析。
+// 该条目记入跳过列表，原因中带有解析器类型和 panic 信息，用户可在订阅页查看，
+// 开发者可据原始链接补充模糊测试语料并修复。
+func safeParse(parser ServerParser, line string) (node *model.Node, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			node, err = nil, fmt.Errorf("%w（%T）: %v", errParserPanic, parser, r)
+		}
+	}()
+	return parser.Parse(line)
+}
+
+// expiredNodeRegex 节点名称中明显的过期标记
+var expiredNodeRegex = regexp.MustCompile(`(?i)已过期|已到期|expired`)
+
+// validateNode 校验解析出的节点是否可用，返回 nil 表示有效。
+// 地址为空、端口超出范围、占位地址或名称带过期标记的节点在连接时必然失败，解析阶段直接跳过。
+func validateNode(n *model.Node) error {
+	addr := strings.TrimSpace(n.Addr)
+	if addr == "" {
+		return fmt.Errorf("地址为空")
+	}
+	if n.Port < 1 || n.Port > 65535 {
+		return fmt.Errorf("端口超出范围: %d", n.Port)
+	}
+	switch addr {
+	case "0.0.0.0", "127.0.0.1", "localhost", "::1":
+		return fmt.Errorf("占位地址: %s", addr)
+	}
+	if expiredNodeRegex.MatchString(n.Name) {
+		return fmt.Errorf("节点已过期: %s", n.Name)
+	}
+	return nil
+}
+
 // SubscriptionManager 订阅管理器
 // 注意：不再维护订阅列表缓存，数据统一由 Store 管理
 type SubscriptionManager struct {
 	client  *http.Client
 	parsers map[string]ServerParser // 服务器配置解析器映射，key为协议前缀
+
+	mu        sync.Mutex
+	skipped   map[string][]model.SkippedEntry // 每个订阅 URL 最近一次解析跳过的条目
+	conflicts map[string][]model.NodeConflict // 每个订阅 URL 待用户处理的冲突
+	policy    model.ConflictPolicy            // 用户修改过的节点的冲突处理方式
 }
 
 // NewSubscriptionManager 创建新的订阅管理器
@@ -455,38 +616,37 @@ func NewSubscriptionManager() *SubscriptionManager {
 	parsers["vmess://"] = &VMessParser{}
 	parsers["ss://"] = &SSParser{}
 	parsers["trojan://"] = &TrojanParser{}
+	parsers["vless://"] = &VLESSParser{}
 	parsers["socks5://"] = &SOCKS5Parser{}
 
 	sm := &SubscriptionManager{
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		parsers: parsers,
+		parsers:   parsers,
+		skipped:   make(map[string][]model.SkippedEntry),
+		conflicts: make(map[string][]model.NodeConflict),
 	}
 
 	return sm
 }
 
+// SkippedEntries 返回指定订阅最近一次拉取时跳过的无效条目。
+func (sm *SubscriptionManager) SkippedEntries(url string) []model.SkippedEntry {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	entries := sm.skipped[url]
+	result := make([]model.SkippedEntry, len(entries))
+	copy(result, entries)
+	return result
+}
+
 // FetchSubscription 从URL获取订阅服务器列表
 // label 参数用于为订阅添加标签，如果为空则使用默认标签
 func (sm *SubscriptionManager) FetchSubscription(url string, label ...string) ([]model.Node, error) {
-	// 发送HTTP请求获取订阅内容
-	resp, err := sm.client.Get(url)
+	servers, err := sm.fetch(url)
 	if err != nil {
-		return nil, fmt.Errorf("获取订阅失败: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// 读取响应内容
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取订阅内容失败: %w", err)
-	}
-
-	// 解析订阅内容
-	servers, err := sm.parseSubscription(string(body))
-	if err != nil {
-		return nil, fmt.Errorf("解析订阅失败: %w", err)
+		return nil, err
 	}
 
 	// 保存订阅到数据库
@@ -523,86 +683,156 @@ func (sm *SubscriptionManager) FetchSubscription(url string, label ...string) ([
 	return servers, nil
 }
 
+// fetch 下载并解析订阅内容，记录被跳过的条目，不写入数据库。
+func (sm *SubscriptionManager) fetch(url string) ([]model.Node, error) {
+	// 发送HTTP请求获取订阅内容
+	resp, err := sm.client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("获取订阅失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// 401/403 通常是订阅地址中的 token 失效或被重置，单独标识以便界面提示重新复制订阅地址
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, fmt.Errorf("获取订阅失败: %w（%s）", errs.ErrAuthFailed, resp.Status)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return nil, fmt.Errorf("获取订阅失败: 服务器返回 %s", resp.Status)
+	}
+
+	// 读取响应内容
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取订阅内容失败: %w", err)
+	}
+
+	// 解析订阅内容
+	servers, skipped, err := sm.parseSubscription(string(body))
+	sm.mu.Lock()
+	sm.skipped[url] = skipped
+	sm.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("解析订阅失败: %w", err)
+	}
+	return servers, nil
+}
+
 // UpdateSubscription 更新订阅
-// label 参数用于更新订阅标签，如果为空则保持原有标签
+// label 参数用于更新订阅标签，如果为空则保持原有标签。
+// 先拉取并解析订阅，成功后才在一个事务中替换节点；拉取或写入失败时原有节点（含用户修改）保持不变。
 func (sm *SubscriptionManager) UpdateSubscription(url string, label ...string) error {
-	// 获取订阅服务器列表（会自动保存到数据库）
 	subscriptionLabel := ""
 	if len(label) > 0 && label[0] != "" {
 		subscriptionLabel = label[0]
-	} else {
-		// 如果未提供标签，尝试从数据库获取现有标签
-		existingSub, err := database.GetSubscriptionByURL(url)
-		if err == nil && existingSub != nil {
-			subscriptionLabel = existingSub.Label
-		}
 	}
 
-	// 获取现有订阅（用于清理旧服务器和保存状态）
+	// 获取现有订阅（用于保存状态和标签）
 	existingSub, err := database.GetSubscriptionByURL(url)
 	if err != nil {
 		return fmt.Errorf("获取订阅信息失败: %w", err)
 	}
+	if subscriptionLabel == "" && existingSub != nil {
+		// 未提供标签时保持原有标签
+		subscriptionLabel = existingSub.Label
+	}
 
-	// 如果存在旧订阅，先保存现有服务器的状态（Selected 和 Delay）
-	// 这样在清理后重新保存时能恢复状态
-	serverStates := make(map[string]struct {
-		Selected bool
-		Delay    int
-	})
+	// 拉取并解析最新服务器，此时尚未改动数据库
+	servers, err := sm.fetch(url)
+	if err != nil {
+		return err
+	}
+
+	// 如果存在旧订阅，先保存现有服务器的状态（ID、Selected、Delay、用户备注、覆盖参数和连接历史），
+	// 替换节点时据此恢复。解析器每次拉取都会重新生成节点 ID，因此按身份标识索引，
+	// 并沿用旧 ID，使故障转移顺序、出站绑定等按 ID 引用节点的配置在更新后仍然有效
+	previous := make(map[string]database.Node)
+	// 用户修改过的节点（按身份标识索引），更新后按冲突策略合并
+	modified := make(map[string]database.Node)
 	if existingSub != nil {
-		// 获取该订阅下的所有服务器
 		existingServers, err := database.GetServersBySubscriptionID(existingSub.ID)
-		if err == nil {
-			for _, s := range existingServers {
-				serverStates[s.ID] = struct {
-					Selected bool
-					Delay    int
-				}{
-					Selected: s.Selected,
-					Delay:    s.Delay,
-				}
-			}
+		if err != nil {
+			return fmt.Errorf("获取订阅服务器失败: %w", err)
 		}
-
-		// 清理该订阅下的旧服务器
-		if err := database.DeleteServersBySubscriptionID(existingSub.ID); err != nil {
-			return fmt.Errorf("清理旧订阅服务器失败: %w", err)
+		for _, s := range existingServers {
+			previous[nodeIdentity(s)] = s
+			if s.UserModified {
+				modified[nodeIdentity(s)] = s
+			}
 		}
 	}
 
-	// 拉取并保存最新服务器；内部会更新订阅标签并写库
-	servers, err := sm.FetchSubscription(url, subscriptionLabel)
-	if err != nil {
-		return err
-	}
-
-	// 再次获取订阅信息（防止标签更新或首次创建）
-	sub, err := database.GetSubscriptionByURL(url)
+	sub, err := database.AddOrUpdateSubscription(url, subscriptionLabel)
 	if err != nil {
-		return fmt.Errorf("获取订阅信息失败: %w", err)
+		return fmt.Errorf("保存订阅到数据库失败: %w", err)
 	}
-
 	var subscriptionID *int64
 	if sub != nil {
 		subscriptionID = &sub.ID
 	}
 
-	// 更新服务器列表，恢复之前保存的状态
-	for _, s := range servers {
-		// 如果之前保存了状态，恢复它
-		if state, ok := serverStates[s.ID]; ok {
-			s.Selected = state.Selected
-			s.Delay = state.Delay
+	policy := sm.conflictPolicy()
+	var conflicts []model.NodeConflict
+	err = database.UpdateServersInTx(func(tx *database.ServerTx) error {
+		// 清理该订阅下的旧服务器
+		if subscriptionID != nil {
+			if err := tx.DeleteServersBySubscriptionID(*subscriptionID); err != nil {
+				return fmt.Errorf("清理旧订阅服务器失败: %w", err)
+			}
 		}
 
-		// 更新数据库中的服务器信息（确保 subscriptionID 正确关联）
-		// 注意：Store 会在订阅更新后自动刷新节点数据（通过 parentStore）
-		if err := database.AddOrUpdateServer(s, subscriptionID); err != nil {
-			return fmt.Errorf("更新服务器到数据库失败: %w", err)
+		for _, s := range servers {
+			if local, ok := modified[nodeIdentity(s)]; ok {
+				delete(modified, nodeIdentity(s))
+				delete(previous, nodeIdentity(s))
+				conflict, err := mergeModified(tx, local, s, policy, subscriptionID)
+				if err != nil {
+					return fmt.Errorf("合并用户修改的节点失败: %w", err)
+				}
+				if conflict != nil {
+					conflicts = append(conflicts, *conflict)
+				}
+				continue
+			}
+
+			// 如果之前保存了状态，恢复它（同一身份标识只沿用一次旧 ID，订阅内的重复节点使用新 ID）
+			old, hasOld := previous[nodeIdentity(s)]
+			if hasOld {
+				delete(previous, nodeIdentity(s))
+				s.ID = old.ID
+				s.Selected = old.Selected
+				s.Delay = old.Delay
+				s.Notes = old.Notes
+				s.OverrideSNI = old.OverrideSNI
+				s.OverrideHost = old.OverrideHost
+				s.OverridePath = old.OverridePath
+				s.LastConnectedAt = old.LastConnectedAt
+				s.ConnectedSeconds = old.ConnectedSeconds
+			}
+
+			// 写入服务器信息（确保 subscriptionID 正确关联）
+			// 注意：Store 会在订阅更新后自动刷新节点数据（通过 parentStore）
+			if err := tx.AddOrUpdateServer(s, subscriptionID); err != nil {
+				return fmt.Errorf("更新服务器到数据库失败: %w", err)
+			}
 		}
+
+		// 订阅中已不存在的用户修改节点：除覆盖策略外保留为独立节点（不再关联订阅）
+		if policy != model.ConflictPolicyOverwrite {
+			for _, local := range modified {
+				if err := tx.AddOrUpdateServer(local, nil); err != nil {
+					return fmt.Errorf("保留用户修改的节点失败: %w", err)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
+	sm.mu.Lock()
+	sm.conflicts[url] = conflicts
+	sm.mu.Unlock()
 	return nil
 }
 
@@ -626,13 +856,15 @@ func (sm *SubscriptionManager) UpdateSubscriptionByID(id int64) error {
 	return sm.UpdateSubscription(sub.URL, sub.Label)
 }
 
-// parseSubscription 解析订阅内容
-func (sm *SubscriptionManager) parseSubscription(content string) ([]model.Node, error) {
-	// 尝试解码Base64
-	decoded, err := base64.StdEncoding.DecodeString(content)
-	if err == nil {
+// parseSubscription 解析订阅内容，返回有效节点和被跳过的无效条目
+func (sm *SubscriptionManager) parseSubscription(content string) ([]model.Node, []model.SkippedEntry, error) {
+	// 尝试解码Base64；解码结果不是文本时按原文处理（如恰好只含 Base64 字符的明文）
+	if decoded, err := decodeBase64Loose(content); err == nil && utf8.Valid(decoded) {
 		content = string(decoded)
 	}
+	content = strings.TrimPrefix(content, "\ufeff")
+
+	var skipped []model.SkippedEntry
 
 	// 1. 尝试JSON格式
 	var jsonServers []struct {
@@ -645,10 +877,10 @@ func (sm *SubscriptionManager) parseSubscription(content string) ([]model.Node,
 
 	if err := json.Unmarshal([]byte(content), &jsonServers); err == nil {
 		// JSON格式解析成功
-		servers := make([]model.Node, len(jsonServers))
-		for i, js := range jsonServers {
+		servers := make([]model.Node, 0, len(jsonServers))
+		for _, js := range jsonServers {
 			rawConfig, _ := json.Marshal(js)
-			servers[i] = model.Node{
+			node := model.Node{
 				ID:           utils.GenerateServerID(js.Addr, js.Port, js.Username),
 				Name:         js.Name,
 				Addr:         js.Addr,
@@ -661,8 +893,16 @@ func (sm *SubscriptionManager) parseSubscription(content string) ([]model.Node,
 				ProtocolType: "socks5", // JSON格式默认为 SOCKS5
 				RawConfig:    string(rawConfig),
 			}
+			if err := validateNode(&node); err != nil {
+				skipped = append(skipped, model.SkippedEntry{Raw: string(rawConfig), Reason: err.Error()})
+				continue
+			}
+			servers = append(servers, node)
 		}
-		return servers, nil
+		if len(servers) == 0 {
+			return nil, skipped, fmt.Errorf("订阅中没有有效节点（跳过 %d 条无效条目）", len(skipped))
+		}
+		return servers, skipped, nil
 	}
 
 	// 2. 尝试Clash格式 (每行一个服务器配置)
@@ -683,35 +923,56 @@ func (sm *SubscriptionManager) parseSubscription(content string) ([]model.Node,
 
 		// 使用注册的解析器解析服务器配置
 		var parsedServer *model.Node
+		var parseErr error
 
 		// 直接根据前缀获取解析器
 		// 查找字符串中第一个 "://" 出现的位置
 		if idx := strings.Index(line, "://"); idx != -1 {
 			// 提取前缀（包括 "://"）
 			prefix := line[:idx+3]
-			fmt.Println("prefix", prefix)
 			// 从 map 中获取对应的解析器
 			if parser, ok := sm.parsers[prefix]; ok {
-				parsedServer, err = parser.Parse(line)
-				fmt.Println("parsedServer", parsedServer)
+				parsedServer, parseErr = safeParse(parser, line)
+			}
+		}
+
+		// 如果没有找到解析器或解析失败，尝试使用 SimpleParser；解析器 panic 的条目直接跳过并记录原因
+		if parsedServer == nil && !errors.Is(parseErr, errParserPanic) {
+			var simpleErr error
+			parsedServer, simpleErr = safeParse(&SimpleParser{}, line)
+			if parseErr == nil || errors.Is(simpleErr, errParserPanic) {
+				parseErr = simpleErr
 			}
 		}
 
-		// 如果没有找到解析器或解析失败，尝试使用 SimpleParser
+		if errors.Is(parseErr, errParserPanic) {
+			skipped = append(skipped, model.SkippedEntry{Raw: line, Reason: parseErr.Error()})
+			continue
+		}
 		if parsedServer == nil {
-			simpleParser := &SimpleParser{}
-			parsedServer, err = simpleParser.Parse(line)
+			reason := "无法识别的格式"
+			if parseErr != nil {
+				reason = fmt.Sprintf("%s: %v", reason, parseErr)
+			}
+			skipped = append(skipped, model.SkippedEntry{Raw: line, Reason: reason})
+			continue
 		}
 
-		// 如果解析成功，添加到服务器列表
-		if parsedServer != nil {
-			servers = append(servers, *parsedServer)
+		// 校验节点，无效节点不入库
+		if err := validateNode(parsedServer); err != nil {
+			skipped = append(skipped, model.SkippedEntry{Raw: line, Reason: err.Error()})
+			continue
 		}
+
+		servers = append(servers, *parsedServer)
 	}
 
 	if len(servers) == 0 {
-		return nil, fmt.Errorf("不支持的订阅格式")
+		if len(skipped) > 0 {
+			return nil, skipped, fmt.Errorf("订阅中没有有效节点（跳过 %d 条无效条目）", len(skipped))
+		}
+		return nil, skipped, fmt.Errorf("不支持的订阅格式")
 	}
 
-	return servers, nil
+	return servers, skipped, nil
 }
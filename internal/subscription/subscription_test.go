@@ -0,0 +1,80 @@
+package subscription
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"myproxy.com/p/internal/model"
+)
+
+// fuzzParser 对解析器做模糊测试：任意输入都不能 panic，解析成功时必须返回节点。
+// 种子语料位于 testdata/fuzz/<目标名>，运行 go test -fuzz=<目标名> 时发现的崩溃输入也会保存在此。
+func fuzzParser(f *testing.F, parser ServerParser, protocol string, seeds ...string) {
+	for _, s := range seeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, link string) {
+		node, err := parser.Parse(link)
+		if err != nil {
+			return
+		}
+		if node == nil {
+			t.Fatalf("解析 %q 未返回错误也未返回节点", link)
+		}
+		if node.ProtocolType != protocol {
+			t.Fatalf("解析 %q 得到协议 %q，期望 %q", link, node.ProtocolType, protocol)
+		}
+	})
+}
+
+func FuzzParseVMess(f *testing.F) {
+	fuzzParser(f, &VMessParser{}, "vmess", "vmess://", "vmess://e30", "vmess://eyJwb3J0IjoiIn0=")
+}
+
+func FuzzParseSS(f *testing.F) {
+	fuzzParser(f, &SSParser{}, "ss", "ss://", "ss://@:", "ss://YQ@[::1]:1")
+}
+
+func FuzzParseTrojan(f *testing.F) {
+	fuzzParser(f, &TrojanParser{}, "trojan", "trojan://@", "trojan://a@[::1", "trojan://a@b:1?")
+}
+
+func FuzzParseVLESS(f *testing.F) {
+	fuzzParser(f, &VLESSParser{}, "vless", "vless://", "vless://@:0", "vless://a@[::1]:1?type=grpc")
+}
+
+func FuzzParseSOCKS5(f *testing.F) {
+	fuzzParser(f, &SOCKS5Parser{}, "socks5", "socks5://", "socks5://a:1", "socks5://u:p@a:99999999999999999999")
+}
+
+func FuzzParseSimple(f *testing.F) {
+	fuzzParser(f, &SimpleParser{}, "socks5", "a:1 u p", "a:1", ":1 u p")
+}
+
+// panicParser 总是 panic 的解析器，模拟存在缺陷的解析器。
+type panicParser struct{}
+
+func (panicParser) Parse(string) (*model.Node, error) {
+	var node *model.Node
+	return node, fmt.Errorf("不可达: %s", node.Name)
+}
+
+func TestParseSubscriptionReportsParserPanic(t *testing.T) {
+	sm := NewSubscriptionManager()
+	sm.parsers["bad://"] = panicParser{}
+
+	nodes, skipped, err := sm.parseSubscription("bad://x\n10.0.0.1:1080 u p")
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if len(nodes) != 1 || nodes[0].Addr != "10.0.0.1" {
+		t.Errorf("节点 = %+v，期望只解析出 10.0.0.1", nodes)
+	}
+	if len(skipped) != 1 || skipped[0].Raw != "bad://x" {
+		t.Fatalf("跳过条目 = %+v，期望只跳过 bad://x", skipped)
+	}
+	if !strings.Contains(skipped[0].Reason, errParserPanic.Error()) || !strings.Contains(skipped[0].Reason, "panicParser") {
+		t.Errorf("跳过原因 = %q，期望注明解析器异常及解析器类型", skipped[0].Reason)
+	}
+}
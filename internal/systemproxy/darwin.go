@@ -1,10 +1,17 @@
 package systemproxy
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
+
+	"myproxy.com/p/internal/utils"
 )
 
 // DarwinProxy macOS 平台的代理实现
@@ -20,36 +27,104 @@ func newDarwinProxy(host string, port int) *DarwinProxy {
 	}
 }
 
-// ClearSystemProxy 清除 macOS 系统代理设置
+// darwinProxyKinds macOS 系统代理的三种类型，对应 networksetup 的 get/set 子命令
+var darwinProxyKinds = []struct {
+	name     string // 快照中的键
+	get      string // 读取命令
+	set      string // 设置地址命令
+	setState string // 开关命令
+}{
+	{"web", "-getwebproxy", "-setwebproxy", "-setwebproxystate"},
+	{"secureweb", "-getsecurewebproxy", "-setsecurewebproxy", "-setsecurewebproxystate"},
+	{"socks", "-getsocksfirewallproxy", "-setsocksfirewallproxy", "-setsocksfirewallproxystate"},
+}
+
+// darwinProxySetting 单个网络服务上某一类代理的设置
+type darwinProxySetting struct {
+	Enabled bool   `json:"enabled"`
+	Server  string `json:"server"`
+	Port    string `json:"port"`
+}
+
+// darwinProxySnapshot 修改前的系统代理快照：服务名 -> 代理类型 -> 设置
+type darwinProxySnapshot map[string]map[string]darwinProxySetting
+
+// ClearSystemProxy 清除 macOS 系统代理设置。
+// 如果存在设置前的快照，则恢复快照中的原有配置（包括用户自己配置的代理）；否则只关闭当前活动服务上
+// 指向本程序本地端口的代理，用户自己配置的其他代理保持不变。
 func (p *DarwinProxy) ClearSystemProxy() error {
-	services, err := p.getNetworkServices()
+	snapshot, err := loadDarwinSnapshot()
+	if err == nil && len(snapshot) > 0 {
+		for service, kinds := range snapshot {
+			for _, kind := range darwinProxyKinds {
+				setting, ok := kinds[kind.name]
+				if !ok {
+					continue
+				}
+				restoreDarwinProxy(service, kind.set, kind.setState, setting)
+			}
+		}
+		removeDarwinSnapshot()
+		return nil
+	}
+
+	services, err := p.getActiveNetworkServices()
 	if err != nil {
 		return fmt.Errorf("获取网络服务失败: %v", err)
 	}
 
 	for _, service := range services {
-		// 清除 HTTP 代理
-		cmd := exec.Command("networksetup", "-setwebproxystate", service, "off")
-		_ = cmd.Run()
-
-		// 清除 HTTPS 代理
-		cmd = exec.Command("networksetup", "-setsecurewebproxystate", service, "off")
-		_ = cmd.Run()
-
-		// 清除 SOCKS 代理
-		cmd = exec.Command("networksetup", "-setsocksfirewallproxystate", service, "off")
-		_ = cmd.Run()
+		for _, kind := range darwinProxyKinds {
+			setting, err := readDarwinProxy(service, kind.get)
+			if err != nil || !p.ownsProxySetting(setting) {
+				continue
+			}
+			_ = exec.Command("networksetup", kind.setState, service, "off").Run()
+		}
 	}
 	return nil
 }
 
-// SetSystemProxy 设置 macOS 系统代理
+// ownsProxySetting 判断代理设置是否由本程序设置：已启用且指向本程序的本地地址和端口（端口未知时只比较地址）。
+func (p *DarwinProxy) ownsProxySetting(setting darwinProxySetting) bool {
+	if !setting.Enabled {
+		return false
+	}
+	host := p.proxyHost
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	if setting.Server != host {
+		return false
+	}
+	return p.proxyPort <= 0 || setting.Port == strconv.Itoa(p.proxyPort)
+}
+
+// SetSystemProxy 设置 macOS 系统代理。
+// 只修改当前活动的网络服务；修改前先保存原有设置的快照，供 ClearSystemProxy 恢复。
 func (p *DarwinProxy) SetSystemProxy(host string, port int) error {
-	services, err := p.getNetworkServices()
+	services, err := p.getActiveNetworkServices()
 	if err != nil {
 		return fmt.Errorf("获取网络服务失败: %v", err)
 	}
 
+	// 已有快照说明代理仍处于本程序设置的状态（如端口变化后重新设置），此时不能覆盖快照
+	if snapshot, err := loadDarwinSnapshot(); err != nil || len(snapshot) == 0 {
+		snapshot = darwinProxySnapshot{}
+		for _, service := range services {
+			kinds := make(map[string]darwinProxySetting)
+			for _, kind := range darwinProxyKinds {
+				if setting, err := readDarwinProxy(service, kind.get); err == nil {
+					kinds[kind.name] = setting
+				}
+			}
+			snapshot[service] = kinds
+		}
+		if err := saveDarwinSnapshot(snapshot); err != nil {
+			return fmt.Errorf("保存系统代理快照失败: %v", err)
+		}
+	}
+
 	portStr := fmt.Sprintf("%d", port)
 	for _, service := range services {
 		// 设置 HTTP 代理
@@ -85,7 +160,7 @@ func (p *DarwinProxy) SetTerminalProxy(host string, port int, proxyType string)
 	os.Setenv("all_proxy", proxyURL)
 
 	// 2. 使用外部shell文件方案（推荐）
-	return p.setupExternalShellFile(proxyURL)
+	return p.setupExternalShellFile(proxyURL, port)
 }
 
 // ClearTerminalProxy 清除终端代理
@@ -110,7 +185,35 @@ func (p *DarwinProxy) GetCurrentProxyMode() ProxyMode {
 	return ProxyModeNone
 }
 
-// getNetworkServices 获取 macOS 网络服务列表
+// SystemProxyTargets 设置系统代理时修改的网络服务代理设置，以及修改前设置的快照文件
+func (p *DarwinProxy) SystemProxyTargets() []string {
+	var targets []string
+	if services, err := p.getActiveNetworkServices(); err == nil {
+		for _, service := range services {
+			targets = append(targets, fmt.Sprintf("网络服务「%s」的 HTTP、HTTPS、SOCKS 代理（networksetup）", service))
+		}
+	}
+	if path, err := darwinSnapshotPath(); err == nil {
+		targets = append(targets, path+"（修改前代理设置的快照，清除时据此恢复）")
+	}
+	return targets
+}
+
+// TerminalProxyTargets 设置终端代理时写入的环境变量文件和追加 source 语句的 shell 配置文件
+func (p *DarwinProxy) TerminalProxyTargets() []string {
+	var targets []string
+	proxyFile, err := TerminalProxyFilePath()
+	if err != nil {
+		return nil
+	}
+	targets = append(targets, proxyFile+"（代理环境变量）")
+	if rc, err := darwinShellRCFile(os.Getenv("HOME")); err == nil {
+		targets = append(targets, rc+"（追加一行 source "+proxyFile+"）")
+	}
+	return targets
+}
+
+// getNetworkServices 获取 macOS 已启用的网络服务列表（已停用的服务以 * 开头，跳过）
 func (p *DarwinProxy) getNetworkServices() ([]string, error) {
 	cmd := exec.Command("networksetup", "-listallnetworkservices")
 	output, err := cmd.Output()
@@ -125,7 +228,7 @@ func (p *DarwinProxy) getNetworkServices() ([]string, error) {
 			continue // 跳过第一行标题
 		}
 		line = strings.TrimSpace(line)
-		if line != "" {
+		if line != "" && !strings.HasPrefix(line, "*") {
 			services = append(services, line)
 		}
 	}
@@ -137,44 +240,197 @@ func (p *DarwinProxy) getNetworkServices() ([]string, error) {
 	return services, nil
 }
 
+// getActiveNetworkServices 获取当前活动（已分配 IP 地址）的网络服务。
+// 通过 networksetup -listnetworkserviceorder 得到服务对应的设备名，再用 ipconfig 检查设备是否有地址；
+// 检测不到任何活动服务时退回到全部已启用的服务。
+func (p *DarwinProxy) getActiveNetworkServices() ([]string, error) {
+	enabled, err := p.getNetworkServices()
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := exec.Command("networksetup", "-listnetworkserviceorder").Output()
+	if err != nil {
+		return enabled, nil
+	}
+	devices := parseServiceDevices(string(output))
+
+	var active []string
+	for _, service := range enabled {
+		device := devices[service]
+		if device == "" {
+			continue
+		}
+		addr, err := exec.Command("ipconfig", "getifaddr", device).Output()
+		if err == nil && strings.TrimSpace(string(addr)) != "" {
+			active = append(active, service)
+		}
+	}
+
+	if len(active) == 0 {
+		return enabled, nil
+	}
+	return active, nil
+}
+
+// parseServiceDevices 解析 networksetup -listnetworkserviceorder 的输出，返回服务名 -> 设备名。
+// 输出格式：
+//
+//	(1) Wi-Fi
+//	(Hardware Port: Wi-Fi, Device: en0)
+func parseServiceDevices(output string) map[string]string {
+	devices := make(map[string]string)
+	var service string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "(Hardware Port:") {
+			if idx := strings.Index(line, "Device: "); idx >= 0 && service != "" {
+				devices[service] = strings.TrimSuffix(line[idx+len("Device: "):], ")")
+			}
+			service = ""
+			continue
+		}
+		if strings.HasPrefix(line, "(") {
+			if idx := strings.Index(line, ") "); idx >= 0 {
+				service = strings.TrimPrefix(line[idx+2:], "*")
+			}
+		}
+	}
+	return devices
+}
+
+// readDarwinProxy 读取某个网络服务的代理设置（networksetup -getwebproxy 等）。
+func readDarwinProxy(service, getCmd string) (darwinProxySetting, error) {
+	var setting darwinProxySetting
+	output, err := exec.Command("networksetup", getCmd, service).Output()
+	if err != nil {
+		return setting, err
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "Enabled":
+			setting.Enabled = value == "Yes"
+		case "Server":
+			setting.Server = value
+		case "Port":
+			setting.Port = value
+		}
+	}
+	return setting, nil
+}
+
+// restoreDarwinProxy 将某个网络服务的代理恢复为快照中的设置。
+func restoreDarwinProxy(service, setCmd, setStateCmd string, setting darwinProxySetting) {
+	if setting.Server != "" && setting.Port != "" && setting.Port != "0" {
+		_ = exec.Command("networksetup", setCmd, service, setting.Server, setting.Port).Run()
+	}
+	state := "off"
+	if setting.Enabled {
+		state = "on"
+	}
+	_ = exec.Command("networksetup", setStateCmd, service, state).Run()
+}
+
+// darwinSnapshotFileName 系统代理快照文件名（位于应用数据目录下）
+const darwinSnapshotFileName = "system_proxy_snapshot.json"
+
+// darwinSnapshotPath 系统代理快照文件路径：与数据库等应用数据放在同一目录；
+// 不放在本次运行的临时目录中，程序异常退出后下次启动仍需据此恢复。
+func darwinSnapshotPath() (string, error) {
+	dir, err := filepath.Abs(utils.DataDir)
+	if err != nil {
+		return "", fmt.Errorf("获取数据目录失败: %v", err)
+	}
+	return filepath.Join(dir, darwinSnapshotFileName), nil
+}
+
+// legacyDarwinSnapshotPath 旧版本保存在用户主目录下的快照路径，升级后仍需据此恢复一次。
+func legacyDarwinSnapshotPath() string {
+	homeDir := os.Getenv("HOME")
+	if homeDir == "" {
+		return ""
+	}
+	return filepath.Join(homeDir, ".myproxy_system_proxy.json")
+}
+
+// loadDarwinSnapshot 读取系统代理快照，快照保存在磁盘上，程序异常退出后下次清除时仍可恢复。
+// 数据目录中没有快照时读取旧版本的快照。
+func loadDarwinSnapshot() (darwinProxySnapshot, error) {
+	path, err := darwinSnapshotPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		if legacy := legacyDarwinSnapshotPath(); legacy != "" {
+			data, err = os.ReadFile(legacy)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snapshot darwinProxySnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// saveDarwinSnapshot 保存系统代理快照。
+func saveDarwinSnapshot(snapshot darwinProxySnapshot) error {
+	path, err := darwinSnapshotPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// removeDarwinSnapshot 删除系统代理快照（恢复完成后调用），包括旧版本的快照。
+func removeDarwinSnapshot() {
+	if path, err := darwinSnapshotPath(); err == nil {
+		_ = os.Remove(path)
+	}
+	if legacy := legacyDarwinSnapshotPath(); legacy != "" {
+		_ = os.Remove(legacy)
+	}
+}
+
 // setupExternalShellFile 使用外部shell文件方案设置代理
-// 方案：在 ~/.myproxy_proxy.sh 中定义代理环境变量，然后在 shell 配置文件中 source 它
-func (p *DarwinProxy) setupExternalShellFile(proxyURL string) error {
+// 方案：在 ~/.myproxy_proxy.sh 中定义代理环境变量，然后在 shell 配置文件中 source 它；
+// 文件头部记录端口和写入时间，供启动时检查文件是否过期
+func (p *DarwinProxy) setupExternalShellFile(proxyURL string, port int) error {
 	homeDir := os.Getenv("HOME")
 	if homeDir == "" {
 		return fmt.Errorf("无法获取用户主目录")
 	}
 
 	// 1. 创建外部代理配置文件
-	proxyFile := fmt.Sprintf("%s/.myproxy_proxy.sh", homeDir)
-	configContent := fmt.Sprintf(`# Proxy settings (set by myproxy)
-# This file is managed by myproxy. Do not edit manually.
-
-export HTTP_PROXY=%s
-export HTTPS_PROXY=%s
-export http_proxy=%s
-export https_proxy=%s
-export ALL_PROXY=%s
-export all_proxy=%s
-`, proxyURL, proxyURL, proxyURL, proxyURL, proxyURL, proxyURL)
+	proxyFile, err := TerminalProxyFilePath()
+	if err != nil {
+		return err
+	}
+	configContent := terminalProxyFileContent(proxyURL, port, time.Now())
 
 	if err := os.WriteFile(proxyFile, []byte(configContent), 0644); err != nil {
 		return fmt.Errorf("写入代理配置文件失败: %v", err)
 	}
 
 	// 2. 在 shell 配置文件中添加 source 语句（如果不存在）
-	shell := os.Getenv("SHELL")
-	if shell == "" {
-		shell = "/bin/zsh"
-	}
-
-	var configFile string
-	if strings.Contains(shell, "zsh") {
-		configFile = fmt.Sprintf("%s/.zshrc", homeDir)
-	} else if strings.Contains(shell, "bash") {
-		configFile = fmt.Sprintf("%s/.bashrc", homeDir)
-	} else {
-		return fmt.Errorf("不支持的 shell: %s", shell)
+	configFile, err := darwinShellRCFile(homeDir)
+	if err != nil {
+		return err
 	}
 
 	// 读取现有配置
@@ -207,6 +463,21 @@ export all_proxy=%s
 	return os.WriteFile(configFile, []byte(newContent), 0644)
 }
 
+// darwinShellRCFile 返回当前 shell（SHELL 环境变量，默认 zsh）的配置文件路径，仅支持 zsh 和 bash。
+func darwinShellRCFile(homeDir string) (string, error) {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/zsh"
+	}
+	if strings.Contains(shell, "zsh") {
+		return fmt.Sprintf("%s/.zshrc", homeDir), nil
+	}
+	if strings.Contains(shell, "bash") {
+		return fmt.Sprintf("%s/.bashrc", homeDir), nil
+	}
+	return "", fmt.Errorf("不支持的 shell: %s", shell)
+}
+
 // removeExternalShellFile 移除外部shell文件配置
 func (p *DarwinProxy) removeExternalShellFile() error {
 	homeDir := os.Getenv("HOME")
@@ -219,17 +490,8 @@ func (p *DarwinProxy) removeExternalShellFile() error {
 	_ = os.Remove(proxyFile) // 忽略错误，文件可能不存在
 
 	// 2. 从 shell 配置文件中移除 source 语句
-	shell := os.Getenv("SHELL")
-	if shell == "" {
-		shell = "/bin/zsh"
-	}
-
-	var configFile string
-	if strings.Contains(shell, "zsh") {
-		configFile = fmt.Sprintf("%s/.zshrc", homeDir)
-	} else if strings.Contains(shell, "bash") {
-		configFile = fmt.Sprintf("%s/.bashrc", homeDir)
-	} else {
+	configFile, err := darwinShellRCFile(homeDir)
+	if err != nil {
 		return nil
 	}
 
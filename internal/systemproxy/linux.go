@@ -58,6 +58,16 @@ func (p *LinuxProxy) ClearTerminalProxy() error {
 	return nil
 }
 
+// SystemProxyTargets Linux 系统代理尚未实现，不修改任何设置
+func (p *LinuxProxy) SystemProxyTargets() []string {
+	return nil
+}
+
+// TerminalProxyTargets Linux 终端代理只设置当前进程的环境变量，不修改任何文件
+func (p *LinuxProxy) TerminalProxyTargets() []string {
+	return nil
+}
+
 func (p *LinuxProxy) GetCurrentProxyMode() ProxyMode {
 	if os.Getenv("HTTP_PROXY") != "" || os.Getenv("http_proxy") != "" {
 		return ProxyModeTerminal
@@ -3,6 +3,8 @@ package systemproxy
 import (
 	"fmt"
 	"runtime"
+
+	"myproxy.com/p/internal/errs"
 )
 
 // PlatformProxy 平台特定的代理操作接口
@@ -17,6 +19,10 @@ type PlatformProxy interface {
 	ClearTerminalProxy() error
 	// GetCurrentProxyMode 获取当前代理模式
 	GetCurrentProxyMode() ProxyMode
+	// SystemProxyTargets 设置系统代理时会修改的系统设置和文件（用于修改前确认），不修改持久设置时为空
+	SystemProxyTargets() []string
+	// TerminalProxyTargets 设置终端代理时会修改的文件或用户环境变量，仅修改当前进程时为空
+	TerminalProxyTargets() []string
 }
 
 // NewPlatformProxy 根据当前平台创建对应的代理管理器
@@ -43,21 +49,29 @@ func newUnsupportedProxy(os string) *UnsupportedProxy {
 }
 
 func (p *UnsupportedProxy) ClearSystemProxy() error {
-	return fmt.Errorf("不支持的操作系统: %s", p.os)
+	return fmt.Errorf("%w: %s", errs.ErrUnsupportedOS, p.os)
 }
 
 func (p *UnsupportedProxy) SetSystemProxy(host string, port int) error {
-	return fmt.Errorf("不支持的操作系统: %s", p.os)
+	return fmt.Errorf("%w: %s", errs.ErrUnsupportedOS, p.os)
 }
 
 func (p *UnsupportedProxy) SetTerminalProxy(host string, port int, proxyType string) error {
-	return fmt.Errorf("不支持的操作系统: %s", p.os)
+	return fmt.Errorf("%w: %s", errs.ErrUnsupportedOS, p.os)
 }
 
 func (p *UnsupportedProxy) ClearTerminalProxy() error {
-	return fmt.Errorf("不支持的操作系统: %s", p.os)
+	return fmt.Errorf("%w: %s", errs.ErrUnsupportedOS, p.os)
 }
 
 func (p *UnsupportedProxy) GetCurrentProxyMode() ProxyMode {
 	return ProxyModeNone
 }
+
+func (p *UnsupportedProxy) SystemProxyTargets() []string {
+	return nil
+}
+
+func (p *UnsupportedProxy) TerminalProxyTargets() []string {
+	return nil
+}
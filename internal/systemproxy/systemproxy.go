@@ -55,6 +55,16 @@ func (sp *SystemProxy) GetCurrentProxyMode() ProxyMode {
 	return sp.platform.GetCurrentProxyMode()
 }
 
+// SystemProxyTargets 设置系统代理时会修改的系统设置和文件
+func (sp *SystemProxy) SystemProxyTargets() []string {
+	return sp.platform.SystemProxyTargets()
+}
+
+// TerminalProxyTargets 设置终端代理时会修改的文件或用户环境变量
+func (sp *SystemProxy) TerminalProxyTargets() []string {
+	return sp.platform.TerminalProxyTargets()
+}
+
 // UpdateProxy 更新代理地址和端口（用于动态更新）
 func (sp *SystemProxy) UpdateProxy(host string, port int) {
 	sp.proxyHost = host
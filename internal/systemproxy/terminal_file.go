@@ -0,0 +1,96 @@
+package systemproxy
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// terminalProxyFileName 终端代理环境变量文件名（位于用户主目录，由 shell 配置文件 source）
+const terminalProxyFileName = ".myproxy_proxy.sh"
+
+// 环境变量文件头部记录写入时的端口和时间，用于启动时判断文件是否过期
+const (
+	terminalHeaderPort    = "# myproxy-port: "
+	terminalHeaderUpdated = "# myproxy-updated: "
+)
+
+// TerminalProxyFile 已写入的终端代理环境变量文件。
+type TerminalProxyFile struct {
+	Path      string    // 文件路径
+	ProxyURL  string    // 代理地址，如 socks5://127.0.0.1:10808
+	Port      int       // 代理端口，无法确定时为 0
+	UpdatedAt time.Time // 写入时间，旧版文件没有时间头时为零值
+}
+
+// TerminalProxyFilePath 返回终端代理环境变量文件路径。
+func TerminalProxyFilePath() (string, error) {
+	homeDir := os.Getenv("HOME")
+	if homeDir == "" {
+		return "", fmt.Errorf("无法获取用户主目录")
+	}
+	return filepath.Join(homeDir, terminalProxyFileName), nil
+}
+
+// terminalProxyFileContent 生成终端代理环境变量文件内容，头部带端口和写入时间。
+func terminalProxyFileContent(proxyURL string, port int, now time.Time) string {
+	return fmt.Sprintf(`# Proxy settings (set by myproxy)
+# This file is managed by myproxy. Do not edit manually.
+%s%d
+%s%s
+
+export HTTP_PROXY=%s
+export HTTPS_PROXY=%s
+export http_proxy=%s
+export https_proxy=%s
+export ALL_PROXY=%s
+export all_proxy=%s
+`, terminalHeaderPort, port, terminalHeaderUpdated, now.Format(time.RFC3339),
+		proxyURL, proxyURL, proxyURL, proxyURL, proxyURL, proxyURL)
+}
+
+// ReadTerminalProxyFile 读取终端代理环境变量文件。
+// 端口优先取头部记录，旧版文件没有头部时从 HTTP_PROXY 的地址中解析。
+// 返回：文件信息和错误，文件不存在时返回 nil, nil
+func ReadTerminalProxyFile() (*TerminalProxyFile, error) {
+	path, err := TerminalProxyFilePath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取终端代理文件失败: %w", err)
+	}
+	defer f.Close()
+
+	info := &TerminalProxyFile{Path: path}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if v, ok := strings.CutPrefix(line, terminalHeaderPort); ok {
+			info.Port, _ = strconv.Atoi(strings.TrimSpace(v))
+		} else if v, ok := strings.CutPrefix(line, terminalHeaderUpdated); ok {
+			info.UpdatedAt, _ = time.Parse(time.RFC3339, strings.TrimSpace(v))
+		} else if v, ok := strings.CutPrefix(line, "export HTTP_PROXY="); ok && info.ProxyURL == "" {
+			info.ProxyURL = strings.Trim(strings.TrimSpace(v), `"'`)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取终端代理文件失败: %w", err)
+	}
+	if info.Port == 0 && info.ProxyURL != "" {
+		if u, err := url.Parse(info.ProxyURL); err == nil {
+			info.Port, _ = strconv.Atoi(u.Port())
+		}
+	}
+	return info, nil
+}
@@ -174,3 +174,13 @@ func (p *WindowsProxy) GetCurrentProxyMode() ProxyMode {
 	}
 	return ProxyModeNone
 }
+
+// SystemProxyTargets 设置系统代理时修改的注册表项
+func (p *WindowsProxy) SystemProxyTargets() []string {
+	return []string{`注册表 HKCU\Software\Microsoft\Windows\CurrentVersion\Internet Settings 的 ProxyEnable、ProxyServer、ProxyOverride`}
+}
+
+// TerminalProxyTargets 设置终端代理时修改的用户环境变量
+func (p *WindowsProxy) TerminalProxyTargets() []string {
+	return []string{`注册表 HKCU\Environment 的用户环境变量 HTTP_PROXY、HTTPS_PROXY、ALL_PROXY（含小写形式）`}
+}
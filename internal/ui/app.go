@@ -1,14 +1,21 @@
 package ui
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/errs"
 	"myproxy.com/p/internal/logging"
+	"myproxy.com/p/internal/model"
 	"myproxy.com/p/internal/service"
 	"myproxy.com/p/internal/store"
 	"myproxy.com/p/internal/subscription"
@@ -32,7 +39,19 @@ type AppState struct {
 	SubscriptionService *service.SubscriptionService
 	XrayControlService   *service.XrayControlService
 	AccessRecordService *service.AccessRecordService
-	XrayInstance        *xray.XrayInstance
+	TimeRuleScheduler   *service.TimeRuleScheduler // 定时拦截规则调度器，窗口边界时重建路由
+	ShareService        *service.ShareService      // 局域网节点分享
+	FailoverWatchdog    *service.FailoverWatchdog  // 故障转移看门狗，按优先级列表自动切换节点
+	NodeHealth          *service.NodeHealthTracker // 节点错误预算，频繁失败的节点进入冷却期
+	DashboardService    *service.DashboardService  // 只读 Web 面板（可选）
+	UsageStatsService   *service.UsageStatsService // 本地功能使用统计
+	HookService         *service.HookService       // 生命周期事件分发（编译期插件与事件脚本）
+	DiagnosticsService  *service.DiagnosticsService // 诊断包导出与只读查看
+	DebugCaptureService *service.DebugCaptureService // 调试捕获（限时记录连接元数据）
+	TopTalkers          *service.TopTalkersService   // 活跃应用统计（按来源进程汇总代理连接）
+	TrafficSampler      *service.TrafficSampler      // 实时流量采样，流量图读取共享的采样点
+	LegacyConfigPath    string                     // 旧版 JSON 配置文件路径，启动时将其中的服务器迁移到数据库
+	XrayInstance        *xray.XrayInstance // 仅在 UI goroutine 中直接读取；写入用 SetXrayInstance，后台 goroutine 用 CurrentXrayInstance 读取
 	LogsPanel           *LogsPanel // 日志面板，仅设置页使用；OnLogLine 分发到此
 	ProxyStatusBinding  binding.String
 	PortBinding         binding.String
@@ -41,6 +60,30 @@ type AppState struct {
 	// OnLogLine 统一日志入口：收到完整日志行时调用，用于分发到展示和访问记录。
 	// 由 MainWindow 设置，供 Logger 的 panelCallback 和文件读取使用。
 	OnLogLine func(logLine string)
+
+	toast *widget.PopUp // 当前显示的轻提示（见 showToast）
+
+	// xray 访问日志：与应用日志分开写入独立文件（见 ApplyXrayLogOptions）
+	accessLogMu sync.Mutex
+	accessLog   *logging.Logger
+
+	// 调试会话：临时调高日志级别（见 StartDebugSession）
+	debugSessionMu sync.Mutex
+	debugSession   *debugSessionState
+
+	xrayMu sync.RWMutex // 保护 XrayInstance 的写入与后台读取
+
+	// 启动前验证（见 MainWindow.verifyAndStartProxy）的取消函数，窗口关闭或切换节点时取消
+	verifyCancel context.CancelFunc
+
+	// 代理启停实现，nil 时使用 XrayControlService（见 proxyControl）；测试中替换为桩实现
+	proxyCtl proxyController
+}
+
+// proxyController 代理启停操作，由 XrayControlService 实现。
+type proxyController interface {
+	StartProxy(oldInstance *xray.XrayInstance, logFilePath string) *service.StartProxyResult
+	StopProxy(instance *xray.XrayInstance) *service.StopProxyResult
 }
 
 func NewAppState() *AppState {
@@ -48,7 +91,7 @@ func NewAppState() *AppState {
 	dataStore := store.NewStore(subscriptionManager)
 	serverService := service.NewServerService(dataStore)
 	configService := service.NewConfigService(dataStore)
-	subscriptionService := service.NewSubscriptionService(dataStore, subscriptionManager)
+	subscriptionService := service.NewSubscriptionService(dataStore, configService, subscriptionManager)
 	pingUtil := utils.NewPing()
 
 	appState := &AppState{
@@ -59,20 +102,160 @@ func NewAppState() *AppState {
 		ServerService:       serverService,
 		ConfigService:       configService,
 		SubscriptionService: subscriptionService,
-		ProxyStatusBinding:  dataStore.ProxyStatus.ProxyStatusBinding,
-		PortBinding:         dataStore.ProxyStatus.PortBinding,
-		ServerNameBinding:   dataStore.ProxyStatus.ServerNameBinding,
+		ProxyStatusBinding:  binding.NewString(),
+		PortBinding:         binding.NewString(),
+		ServerNameBinding:   binding.NewString(),
 		ProxyService:         service.NewProxyService(nil, configService),
 		XrayControlService:   service.NewXrayControlService(dataStore, configService, nil, nil),
 		AccessRecordService:  service.NewAccessRecordService(dataStore),
+		ShareService:         service.NewShareService(dataStore),
+		DiagnosticsService:   service.NewDiagnosticsService(dataStore),
+		DebugCaptureService:  service.NewDebugCaptureService(),
+		UsageStatsService:    service.NewUsageStatsService(dataStore, configService),
 	}
 
 	// LogCallback 保留用于兼容，但展示已改为通过 OnLogLine 统一分发
 	appState.LogCallback = nil
 
+	// 代理状态由 Store 维护，界面通过数据绑定展示（binding 的 Set 可在任意 goroutine 中调用）
+	dataStore.ProxyStatus.AddListener(func() {
+		_ = appState.ProxyStatusBinding.Set(dataStore.ProxyStatus.Status())
+		_ = appState.PortBinding.Set(dataStore.ProxyStatus.Port())
+		_ = appState.ServerNameBinding.Set(dataStore.ProxyStatus.ServerName())
+	})
+
+	appState.HookService = service.NewHookService(configService, func(level, message string) {
+		appState.AppendLog(level, "app", message)
+	})
+	appState.XrayControlService.SetHooks(appState.HookService)
+	subscriptionService.SetHooks(appState.HookService)
+
+	appState.TimeRuleScheduler = service.NewTimeRuleScheduler(configService, func() {
+		fyne.Do(func() {
+			appState.ReloadProxy("定时规则时间窗口切换")
+		})
+	})
+
+	appState.ProxyService.SetOnRollback(func(reason string) {
+		fyne.Do(func() {
+			appState.onSystemProxyRollback(reason)
+		})
+	})
+
+	appState.TopTalkers = service.NewTopTalkersService(func(stat service.TalkerStat) {
+		fyne.Do(func() {
+			appState.onDominantTalker(stat)
+		})
+	})
+
+	appState.TrafficSampler = service.NewTrafficSampler(func() (int64, int64) {
+		inst := appState.CurrentXrayInstance()
+		if inst == nil || !inst.IsRunning() {
+			return 0, 0
+		}
+		return appState.XrayControlService.GetTrafficStats(inst)
+	})
+
+	appState.NodeHealth = service.NewNodeHealthTracker(func(nodeID string, degraded bool) {
+		fyne.Do(func() {
+			appState.onNodeHealthChange(nodeID, degraded)
+		})
+	})
+
+	jobGate := service.NewBackgroundJobGate(configService)
+	appState.FailoverWatchdog = service.NewFailoverWatchdog(dataStore, configService, pingUtil, appState.NodeHealth,
+		func() bool {
+			inst := appState.CurrentXrayInstance()
+			return inst != nil && inst.IsRunning()
+		},
+		func(from, to *model.Node, reason string) {
+			fyne.Do(func() {
+				appState.onFailoverSwitch(from, to, reason)
+			})
+		})
+	appState.FailoverWatchdog.SetJobGate(jobGate)
+
+	appState.DashboardService = service.NewDashboardService(dataStore, configService,
+		func() service.DashboardRuntime {
+			inst := appState.CurrentXrayInstance()
+			if inst == nil {
+				return service.DashboardRuntime{}
+			}
+			up, down := inst.TrafficStats()
+			return service.DashboardRuntime{
+				Running:       inst.IsRunning(),
+				Port:          inst.GetPort(),
+				UploadBytes:   up,
+				DownloadBytes: down,
+			}
+		},
+		func() string {
+			if appState.Logger == nil {
+				return ""
+			}
+			return appState.Logger.GetLogFilePath()
+		})
+
 	return appState
 }
 
+// onFailoverSwitch 故障转移切换节点后：记录日志、重建代理并发送系统通知。
+func (a *AppState) onFailoverSwitch(from, to *model.Node, reason string) {
+	msg := fmt.Sprintf("故障转移（%s）: %s -> %s", reason, from.Name, to.Name)
+	a.CancelVerify()
+	a.AppendLog("WARN", "server", msg)
+	a.UsageStatsService.Record(model.UsageFeatureFailoverSwitch)
+	a.ReloadProxy(msg)
+	if a.App != nil {
+		a.App.SendNotification(fyne.NewNotification("myproxy 节点已切换", msg))
+	}
+	if a.MainWindow != nil {
+		a.MainWindow.Refresh()
+	}
+}
+
+// onSystemProxyRollback 系统代理在观察期内因代理不可用被自动清除后：界面切回「清除」并提示用户。
+func (a *AppState) onSystemProxyRollback(reason string) {
+	msg := "代理不可用，已自动清除系统代理以免断网: " + reason
+	a.AppendLog("WARN", "systemproxy", msg)
+	if a.ConfigService != nil {
+		_ = a.ConfigService.SetSystemProxyMode(SystemProxyModeClear.String())
+	}
+	if a.MainWindow != nil {
+		a.MainWindow.updateProxyModeButtonsState(SystemProxyModeClear)
+	}
+	a.refreshTrayProxyMenu()
+	if a.App != nil {
+		a.App.SendNotification(fyne.NewNotification("系统代理已回滚", msg))
+	}
+}
+
+// onDominantTalker 单个应用占用大部分代理连接时：记录日志并提示，便于发现共用代理时谁在占满带宽。
+func (a *AppState) onDominantTalker(stat service.TalkerStat) {
+	msg := fmt.Sprintf("%s 在最近 %d 分钟内占用了 %.0f%% 的代理连接（%d 个），其他应用可能变慢",
+		stat.App, int(a.TopTalkers.Window().Minutes()), stat.Share*100, stat.Connections)
+	a.AppendLog("WARN", "proxy", msg)
+	showToast(a, FeedbackWarning, msg)
+}
+
+// onNodeHealthChange 节点进入或退出冷却期后：记录日志并刷新节点列表。
+func (a *AppState) onNodeHealthChange(nodeID string, degraded bool) {
+	name := nodeID
+	if a.Store != nil && a.Store.Nodes != nil {
+		if node, err := a.Store.Nodes.Get(nodeID); err == nil {
+			name = node.Name
+		}
+	}
+	if degraded {
+		a.AppendLog("WARN", "server", fmt.Sprintf("节点 %s 短时间内多次失败，已降级，冷却期内不会被自动选择", name))
+	} else {
+		a.AppendLog("INFO", "server", fmt.Sprintf("节点 %s 已手动恢复", name))
+	}
+	if a.MainWindow != nil {
+		a.MainWindow.Refresh()
+	}
+}
+
 func (a *AppState) updateStatusBindings() {
 	if a.Store == nil || a.Store.ProxyStatus == nil {
 		return
@@ -89,6 +272,7 @@ func (a *AppState) UpdateProxyStatus() {
 func (a *AppState) refreshTrayProxyMenu() {
 	if a.TrayManager != nil {
 		a.TrayManager.RefreshProxyModeMenu()
+		a.TrayManager.RefreshStatus()
 	}
 }
 
@@ -113,9 +297,36 @@ func (a *AppState) InitApp() error {
 	if a.Store != nil {
 		a.Store.LoadAll()
 	}
+	if a.Ping != nil && a.ConfigService != nil {
+		a.Ping.SetSamples(a.ConfigService.GetLatencySamples())
+	}
+
+	if a.ServerService != nil {
+		// 旧版配置文件中的服务器列表一次性导入数据库
+		result, err := a.ServerService.MigrateLegacyConfig(a.LegacyConfigPath)
+		if err != nil {
+			a.SafeLogger.Warn(fmt.Sprintf("迁移旧版配置失败: %v", err))
+		}
+		if result != nil {
+			msg := fmt.Sprintf("已从旧版配置导入 %d 个服务器（已存在 %d 个，无效 %d 个）", result.Imported, result.Existing, len(result.Skipped))
+			a.SafeLogger.Info(msg)
+			for _, entry := range result.Skipped {
+				a.SafeLogger.Warn(fmt.Sprintf("旧版配置跳过: %s: %s", entry.Raw, entry.Reason))
+			}
+			a.App.SendNotification(fyne.NewNotification("旧版配置已迁移", msg))
+		}
+	}
 
 	if a.ConfigService != nil {
-		_ = a.ConfigService.SaveDefaultDirectRoutes()
+		// 首次运行写入默认路由规则；旧版「不走直连」配置迁移为逐条规则
+		inverted, err := a.ConfigService.MigrateRouteRules()
+		if err != nil {
+			a.SafeLogger.Warn(fmt.Sprintf("迁移路由规则失败: %v", err))
+		} else if inverted {
+			msg := "旧版「不走直连」已启用：原直连列表中的地址已迁移为「代理」规则，请在设置中确认路由规则"
+			a.SafeLogger.Warn(msg)
+			a.App.SendNotification(fyne.NewNotification("路由规则已迁移", msg))
+		}
 	}
 
 	a.updateStatusBindings()
@@ -153,9 +364,25 @@ func (a *AppState) InitLogger() error {
 		// logCallback: 应用级消息（如启动成功）走 AppendLog
 		// rawLogCallback: xray 劫持的原始日志 -> 落盘、展示、解析访问记录
 		realLogCallback := func(level, message string) {
-			a.AppendLog(level, "xray", message)
+			a.AppendLog(level, "proxy", message)
 		}
 		rawLogCallback := func(level, rawLine string) {
+			if a.DebugCaptureService != nil {
+				a.DebugCaptureService.Feed(rawLine)
+			}
+			if a.TopTalkers != nil {
+				a.TopTalkers.Feed(rawLine)
+			}
+			// 访问日志：生成访问记录并写入独立文件，不进入应用日志和日志面板
+			if service.IsAccessLogLine(rawLine) {
+				if a.AccessRecordService != nil {
+					a.AccessRecordService.RecordAccessFromLogLine(rawLine)
+				}
+				a.accessLogMu.Lock()
+				a.accessLog.WriteRawLine(rawLine)
+				a.accessLogMu.Unlock()
+				return
+			}
 			if a.Logger != nil {
 				a.Logger.WriteRawLine(rawLine)
 			}
@@ -164,17 +391,92 @@ func (a *AppState) InitLogger() error {
 			}
 		}
 		a.XrayControlService = service.NewXrayControlService(a.Store, a.ConfigService, realLogCallback, rawLogCallback)
+		a.XrayControlService.SetHooks(a.HookService)
 	}
+	a.openAccessLog()
 
 	return nil
 }
 
+// openAccessLog 按 xray 日志设置打开独立的访问日志文件；访问日志关闭或未设置文件时关闭已打开的文件。
+func (a *AppState) openAccessLog() {
+	options := model.DefaultXrayLogOptions()
+	if a.ConfigService != nil {
+		options = a.ConfigService.GetXrayLogOptions()
+	}
+
+	a.accessLogMu.Lock()
+	defer a.accessLogMu.Unlock()
+	if a.accessLog != nil {
+		if options.AccessLog && a.accessLog.GetLogFilePath() == options.AccessLogFile {
+			return
+		}
+		a.accessLog.Close()
+		a.accessLog = nil
+	}
+	if !options.AccessLog || options.AccessLogFile == "" {
+		return
+	}
+	accessLog, err := logging.NewLogger(options.AccessLogFile, false, "info")
+	if err != nil {
+		a.AppendLog("WARN", "proxy", "打开访问日志文件失败: "+err.Error())
+		return
+	}
+	a.accessLog = accessLog
+}
+
+// ApplyXrayLogOptions xray 日志设置变更后调用：重新打开访问日志文件，代理运行时重建实例使级别与开关生效。
+func (a *AppState) ApplyXrayLogOptions() {
+	a.openAccessLog()
+	a.ReloadProxy("xray 日志设置变更")
+}
+
+// proxyControl 返回代理启停实现，未初始化时返回 nil。
+func (a *AppState) proxyControl() proxyController {
+	if a.proxyCtl != nil {
+		return a.proxyCtl
+	}
+	if a.XrayControlService == nil {
+		return nil
+	}
+	return a.XrayControlService
+}
+
+// CurrentXrayInstance 返回当前代理实例，可在后台 goroutine 中调用。
+func (a *AppState) CurrentXrayInstance() *xray.XrayInstance {
+	a.xrayMu.RLock()
+	defer a.xrayMu.RUnlock()
+	return a.XrayInstance
+}
+
+// SetXrayInstance 更新当前代理实例（在 UI goroutine 中调用）。
+func (a *AppState) SetXrayInstance(inst *xray.XrayInstance) {
+	a.xrayMu.Lock()
+	a.XrayInstance = inst
+	a.xrayMu.Unlock()
+}
+
+// beginVerify 开始一次启动前验证并取消之前未结束的验证（在 UI goroutine 中调用）。
+// 返回的上下文在 CancelVerify 或下一次 beginVerify 时取消。
+func (a *AppState) beginVerify() context.Context {
+	a.CancelVerify()
+	ctx, cancel := context.WithCancel(context.Background())
+	a.verifyCancel = cancel
+	return ctx
+}
+
+// CancelVerify 取消进行中的启动前验证（窗口关闭、切换节点时调用，在 UI goroutine 中调用）。
+func (a *AppState) CancelVerify() {
+	if a.verifyCancel != nil {
+		a.verifyCancel()
+		a.verifyCancel = nil
+	}
+}
+
 // AppendLog 追加一条日志。由 Logger 写入文件并调用 panelCallback，统一由 OnLogLine 分发到展示和访问记录。
+// logType 为日志来源（见 logging.LogType），未知类型归为 app。
 func (a *AppState) AppendLog(level, logType, message string) {
 	level = strings.ToUpper(level)
-	if strings.ToLower(logType) != "xray" {
-		logType = "app"
-	}
 	if a.Logger != nil {
 		a.Logger.Log(level, logType, message)
 	}
@@ -183,7 +485,8 @@ func (a *AppState) AppendLog(level, logType, message string) {
 // LoadWindowSize 从配置加载窗口大小，未配置时返回默认尺寸。
 func (a *AppState) LoadWindowSize(defaultSize fyne.Size) fyne.Size {
 	if a.ConfigService != nil {
-		return a.ConfigService.GetWindowSize(defaultSize)
+		width, height := a.ConfigService.GetWindowSize(defaultSize.Width, defaultSize.Height)
+		return fyne.NewSize(width, height)
 	}
 	return defaultSize
 }
@@ -191,7 +494,7 @@ func (a *AppState) LoadWindowSize(defaultSize fyne.Size) fyne.Size {
 // SaveWindowSize 将窗口大小保存到配置。
 func (a *AppState) SaveWindowSize(size fyne.Size) {
 	if a.ConfigService != nil {
-		_ = a.ConfigService.SaveWindowSize(size)
+		_ = a.ConfigService.SaveWindowSize(size.Width, size.Height)
 	}
 }
 
@@ -207,9 +510,13 @@ func (a *AppState) SetupWindowCloseHandler() {
 	}
 
 	a.Window.SetCloseIntercept(func() {
+		a.CancelVerify()
 		if a.Window != nil && a.Window.Canvas() != nil {
 			a.SaveWindowSize(a.Window.Canvas().Size())
 		}
+		if a.MainWindow != nil {
+			a.MainWindow.SaveLayoutConfig()
+		}
 		a.Window.Hide()
 	})
 }
@@ -240,16 +547,44 @@ func (a *AppState) Startup() error {
 
 	// xray 日志由劫持 handler 落盘并分发，无需文件监控
 
+	a.scavengeRunArtifacts()
+
 	content := mainWindow.Build()
 	if content != nil {
 		a.Window.SetContent(content)
 	}
+	mainWindow.RestoreLastPage()
 
 	a.SetupTray()
 	a.SetupWindowCloseHandler()
 
 	if err := a.autoLoadProxyConfig(); err != nil {
-		a.AppendLog("INFO", "app", "自动加载代理配置失败: "+err.Error())
+		a.AppendLog("INFO", "proxy", "自动加载代理配置失败: "+err.Error())
+	}
+
+	// 窗口显示后再检查终端代理文件，需要询问时对话框才能正常显示
+	if a.App != nil {
+		a.App.Lifecycle().SetOnStarted(a.checkStaleTerminalProxy)
+	}
+
+	if a.TimeRuleScheduler != nil {
+		a.TimeRuleScheduler.Start()
+	}
+
+	if a.TrafficSampler != nil {
+		a.TrafficSampler.Start()
+	}
+
+	if a.FailoverWatchdog != nil {
+		a.FailoverWatchdog.Start()
+	}
+
+	if a.DashboardService != nil && a.ConfigService != nil && a.ConfigService.GetDashboardEnabled() {
+		if url, err := a.DashboardService.Start(); err != nil {
+			a.AppendLog("WARN", "app", "启动 Web 面板失败: "+err.Error())
+		} else {
+			a.AppendLog("INFO", "app", "Web 面板已启动: "+url)
+		}
 	}
 
 	a.initialized = true
@@ -266,7 +601,7 @@ func (a *AppState) Reset() {
 
 func (a *AppState) autoLoadProxyConfig() error {
 	if a.Store == nil || a.Store.AppConfig == nil {
-		return fmt.Errorf("应用状态: Store 未初始化")
+		return fmt.Errorf("应用状态: %w", errs.ErrStoreNotInitialized)
 	}
 
 	autoStart, err := a.Store.AppConfig.GetWithDefault("autoStartProxy", "false")
@@ -283,9 +618,9 @@ func (a *AppState) autoLoadProxyConfig() error {
 		return fmt.Errorf("应用状态: 选中服务器失败: %w", err)
 	}
 
-	a.AppendLog("INFO", "app", "正在自动启动代理服务...")
+	a.AppendLog("INFO", "proxy", "正在自动启动代理服务...")
 
-	if a.XrayControlService == nil {
+	if a.proxyControl() == nil {
 		return fmt.Errorf("应用状态: XrayControlService 未初始化")
 	}
 
@@ -293,12 +628,12 @@ func (a *AppState) autoLoadProxyConfig() error {
 	if a.Logger != nil {
 		unifiedLogPath = a.Logger.GetLogFilePath()
 	}
-	result := a.XrayControlService.StartProxy(a.XrayInstance, unifiedLogPath)
+	result := a.proxyControl().StartProxy(a.XrayInstance, unifiedLogPath)
 	if result.Error != nil {
 		return fmt.Errorf("应用状态: 启动代理失败: %w", result.Error)
 	}
 
-	a.XrayInstance = result.XrayInstance
+	a.SetXrayInstance(result.XrayInstance)
 
 	if a.ProxyService != nil {
 		a.ProxyService.UpdateXrayInstance(a.XrayInstance)
@@ -306,23 +641,112 @@ func (a *AppState) autoLoadProxyConfig() error {
 
 	a.updateStatusBindings()
 
-	a.AppendLog("INFO", "app", "代理服务自动启动成功")
+	a.AppendLog("INFO", "proxy", "代理服务自动启动成功")
+	if a.MainWindow != nil {
+		a.MainWindow.applySavedSystemProxyAfterStart()
+	}
 	return nil
 }
 
+// ReloadProxy 在代理运行时按当前配置重建 xray 实例（如路由规则变化），不弹出对话框。
+// 参数：
+//   - reason: 重建原因，写入日志
+func (a *AppState) ReloadProxy(reason string) {
+	if a.XrayInstance == nil || !a.XrayInstance.IsRunning() || a.proxyControl() == nil {
+		return
+	}
+
+	a.AppendLog("INFO", "proxy", "重新生成路由配置: "+reason)
+
+	unifiedLogPath := ""
+	if a.Logger != nil {
+		unifiedLogPath = a.Logger.GetLogFilePath()
+	}
+	result := a.proxyControl().StartProxy(a.XrayInstance, unifiedLogPath)
+	if result.Error != nil {
+		a.AppendLog("ERROR", "proxy", "重建代理失败: "+result.Error.Error())
+		a.SetXrayInstance(nil)
+		if a.ProxyService != nil {
+			a.ProxyService.UpdateXrayInstance(nil)
+		}
+		a.UpdateProxyStatus()
+		return
+	}
+
+	a.SetXrayInstance(result.XrayInstance)
+	if a.ProxyService != nil {
+		a.ProxyService.UpdateXrayInstance(a.XrayInstance)
+	}
+	a.UpdateProxyStatus()
+}
+
+// scavengeRunArtifacts 清理以前运行（崩溃或被强制结束）遗留的临时文件，并删除旧版本固定位置的日志溢出目录。
+func (a *AppState) scavengeRunArtifacts() {
+	removed, err := utils.ScavengeRunDirs(utils.DataDir)
+	if err != nil {
+		a.AppendLog("WARN", "app", err.Error())
+	}
+	legacy := filepath.Join(utils.DataDir, "logspill")
+	if _, err := os.Stat(legacy); err == nil && os.RemoveAll(legacy) == nil {
+		removed = append(removed, legacy)
+	}
+	for _, path := range removed {
+		a.AppendLog("INFO", "app", "已清理上次运行遗留的临时文件: "+path)
+	}
+}
+
 func (a *AppState) Cleanup() {
+	a.CancelVerify()
+
+	if a.TimeRuleScheduler != nil {
+		a.TimeRuleScheduler.Stop()
+	}
+
+	if a.ShareService != nil {
+		a.ShareService.StopShare()
+	}
+
+	if a.FailoverWatchdog != nil {
+		a.FailoverWatchdog.Stop()
+	}
+
+	if a.TrafficSampler != nil {
+		a.TrafficSampler.Stop()
+	}
+
+	if a.DashboardService != nil {
+		a.DashboardService.Stop()
+	}
+
+	if a.TrayManager != nil {
+		a.TrayManager.Stop()
+	}
+
 	if a.XrayInstance != nil {
 		if a.XrayInstance.IsRunning() {
 			_ = a.XrayInstance.Stop()
 		}
-		a.XrayInstance = nil
+		a.SetXrayInstance(nil)
 	}
 
+	// 日志面板关闭溢出分段后删除本次运行的临时目录
+	if a.LogsPanel != nil {
+		a.LogsPanel.Stop()
+	}
+	_ = os.RemoveAll(utils.RunDir(utils.DataDir))
+
 	if a.Logger != nil {
 		a.Logger.Close()
 		a.Logger = nil
 	}
 
+	a.accessLogMu.Lock()
+	if a.accessLog != nil {
+		a.accessLog.Close()
+		a.accessLog = nil
+	}
+	a.accessLogMu.Unlock()
+
 	if a.SafeLogger != nil {
 		a.SafeLogger.SetLogger(nil)
 	}
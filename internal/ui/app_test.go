@@ -0,0 +1,103 @@
+package ui
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/database"
+	"myproxy.com/p/internal/model"
+)
+
+// 界面测试使用 fyne 的软件渲染测试驱动，无需显示器：
+//
+//	go test -tags ci ./internal/ui/
+
+// newTestAppState 创建使用临时数据库和 fyne 测试驱动的应用状态，测试结束后关闭数据库。
+// 数据库初始化与 cmd/gui 一致，节点经 ServerService 写入。
+func newTestAppState(t *testing.T, nodes ...model.Node) *AppState {
+	t.Helper()
+	if err := database.InitDB(filepath.Join(t.TempDir(), "myproxy.db")); err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	t.Cleanup(func() { _ = database.CloseDB() })
+	if err := database.InitDefaultConfig(); err != nil {
+		t.Fatalf("初始化默认配置失败: %v", err)
+	}
+
+	appState := NewAppState()
+	appState.App = test.NewTempApp(t)
+	appState.Window = test.NewTempWindow(t, widget.NewLabel(""))
+	appState.Store.LoadAll()
+	for _, node := range nodes {
+		if err := appState.ServerService.AddOrUpdateServer(node, nil); err != nil {
+			t.Fatalf("写入节点失败: %v", err)
+		}
+	}
+	// 测试中不弹出系统改动确认框
+	if err := appState.ConfigService.SetConfirmSystemChanges(false); err != nil {
+		t.Fatalf("保存配置失败: %v", err)
+	}
+	return appState
+}
+
+// newTestMainWindow 创建主窗口，测试结束时停止流量图等后台刷新，避免影响后续测试。
+func newTestMainWindow(t *testing.T, appState *AppState) *MainWindow {
+	t.Helper()
+	mw := NewMainWindow(appState)
+	appState.MainWindow = mw
+	t.Cleanup(mw.Cleanup)
+	return mw
+}
+
+// showInTestWindow 将界面放入测试窗口并布局，使列表等延迟创建的子项完成渲染。
+func showInTestWindow(appState *AppState, content fyne.CanvasObject) {
+	appState.Window.SetContent(content)
+	appState.Window.Resize(fyne.NewSize(480, 640))
+}
+
+// findCheck 在界面树中查找文字为 text 的勾选框，找不到时返回 nil。
+func findCheck(root fyne.CanvasObject, text string) *widget.Check {
+	for _, obj := range test.LaidOutObjects(root) {
+		if check, ok := obj.(*widget.Check); ok && check.Text == text {
+			return check
+		}
+	}
+	return nil
+}
+
+// findSelect 在界面树中查找包含选项 option 的下拉框，找不到时返回 nil。
+func findSelect(root fyne.CanvasObject, option string) *widget.Select {
+	for _, obj := range test.LaidOutObjects(root) {
+		if sel, ok := obj.(*widget.Select); ok && slices.Contains(sel.Options, option) {
+			return sel
+		}
+	}
+	return nil
+}
+
+// waitFor 轮询 cond 直到为真，超时则测试失败（用于等待后台 goroutine 完成）。
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("等待%s超时", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// findButton 在界面树中查找文字为 text 的按钮，找不到时返回 nil。
+func findButton(root fyne.CanvasObject, text string) *widget.Button {
+	for _, obj := range test.LaidOutObjects(root) {
+		if btn, ok := obj.(*widget.Button); ok && btn.Text == text {
+			return btn
+		}
+	}
+	return nil
+}
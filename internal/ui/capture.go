@@ -0,0 +1,179 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/service"
+)
+
+// captureDurationOptions 调试捕获时长选项
+var captureDurationOptions = []time.Duration{time.Minute, 2 * time.Minute, 5 * time.Minute, 10 * time.Minute}
+
+// showDebugCaptureDialog 调试捕获：在选定时间内记录经代理的连接（目标、时间、出站、命中的路由规则）
+// 和各出站流量，结束后导出为 JSON 报告。捕获期间临时调高 xray 日志级别，结束后恢复。
+// 关闭对话框即放弃本次捕获。
+func showDebugCaptureDialog(appState *AppState) {
+	if appState == nil || appState.Window == nil || appState.DebugCaptureService == nil || appState.XrayControlService == nil {
+		return
+	}
+	if appState.XrayInstance == nil || !appState.XrayInstance.IsRunning() {
+		showToast(appState, FeedbackWarning, "请先启动代理再开始调试捕获")
+		return
+	}
+	capture := appState.DebugCaptureService
+
+	durationLabels := make([]string, len(captureDurationOptions))
+	for i, d := range captureDurationOptions {
+		durationLabels[i] = fmt.Sprintf("%d 分钟", int(d.Minutes()))
+	}
+	durationSel := widget.NewSelect(durationLabels, nil)
+	durationSel.SetSelected(durationLabels[0])
+
+	hint := widget.NewLabel("复现问题期间记录每个连接的目标、时间、出站和命中的路由规则，以及各出站流量。" +
+		"捕获期间 xray 日志级别临时调为「信息」，代理会短暂重建一次。")
+	hint.Wrapping = fyne.TextWrapWord
+	statusLabel := widget.NewLabel("")
+	progress := widget.NewProgressBar()
+	progress.Hide()
+
+	var d dialog.Dialog
+	var startBtn, stopBtn *widget.Button
+	done := make(chan struct{})
+	var finished bool
+
+	// finish 结束捕获并恢复日志级别；export 为 true 时导出报告
+	finish := func(export bool) {
+		if finished {
+			return
+		}
+		finished = true
+		close(done)
+		var traffic []service.CaptureTraffic
+		if appState.XrayInstance != nil {
+			for _, tag := range service.CaptureOutbounds {
+				up, down := appState.XrayInstance.OutboundTrafficStats(tag)
+				traffic = append(traffic, service.CaptureTraffic{Outbound: tag, Upload: up, Download: down})
+			}
+		}
+		report := capture.Stop(traffic)
+		appState.XrayControlService.SetCaptureMode(false)
+		appState.ReloadProxy("结束调试捕获")
+		if report == nil {
+			return
+		}
+		appState.AppendLog("INFO", "proxy", fmt.Sprintf("调试捕获结束: 记录 %d 个连接", len(report.Connections)))
+		if export {
+			exportCaptureReport(appState, report)
+		}
+	}
+
+	startBtn = widget.NewButton("开始捕获", func() {
+		duration := captureDurationOptions[0]
+		for i, label := range durationLabels {
+			if label == durationSel.Selected {
+				duration = captureDurationOptions[i]
+			}
+		}
+		nodeName := ""
+		if appState.Store != nil && appState.Store.Nodes != nil {
+			if node := appState.Store.Nodes.GetSelected(); node != nil {
+				nodeName = node.Name
+			}
+		}
+		if err := capture.Start(nodeName, duration); err != nil {
+			showErrorDetail(appState, "开始调试捕获失败", err)
+			return
+		}
+		appState.XrayControlService.SetCaptureMode(true)
+		appState.ReloadProxy("开始调试捕获")
+		appState.AppendLog("INFO", "proxy", fmt.Sprintf("开始调试捕获: %s", durationSel.Selected))
+
+		durationSel.Disable()
+		startBtn.Disable()
+		stopBtn.Enable()
+		progress.Show()
+		go func() {
+			ticker := time.NewTicker(time.Second)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+				}
+				count, remaining := capture.Progress()
+				fyne.Do(func() {
+					if finished {
+						return
+					}
+					statusLabel.SetText(fmt.Sprintf("捕获中：已记录 %d 个连接，剩余 %s", count, remaining.Round(time.Second)))
+					progress.SetValue(1 - remaining.Seconds()/duration.Seconds())
+					if remaining <= 0 {
+						finish(true)
+						d.Hide()
+						showToast(appState, FeedbackInfo, "调试捕获时间已到")
+					}
+				})
+			}
+		}()
+	})
+	startBtn.Importance = widget.HighImportance
+	stopBtn = widget.NewButton("停止并导出", func() {
+		finish(true)
+		d.Hide()
+	})
+	stopBtn.Disable()
+
+	content := container.NewVBox(
+		hint,
+		container.NewBorder(nil, nil, widget.NewLabel("时长"), nil, durationSel),
+		statusLabel,
+		progress,
+		container.NewHBox(startBtn, stopBtn),
+	)
+	d = dialog.NewCustom("调试捕获", "关闭", content, appState.Window)
+	d.SetOnClosed(func() {
+		// 未导出就关闭：放弃本次捕获并恢复日志级别
+		if capture.Active() {
+			finish(false)
+		}
+	})
+	d.Resize(fyne.NewSize(420, 0))
+	d.Show()
+}
+
+// exportCaptureReport 选择保存位置并写入调试捕获报告（JSON）。
+func exportCaptureReport(appState *AppState, report *service.CaptureReport) {
+	data, err := report.JSON()
+	if err != nil {
+		showErrorDetail(appState, "导出调试捕获失败", err)
+		return
+	}
+	save := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
+		if err != nil {
+			showErrorDetail(appState, "导出调试捕获失败", err)
+			return
+		}
+		if w == nil {
+			return
+		}
+		_, writeErr := w.Write(data)
+		if closeErr := w.Close(); writeErr == nil {
+			writeErr = closeErr
+		}
+		if writeErr != nil {
+			showErrorDetail(appState, "导出调试捕获失败", writeErr)
+			return
+		}
+		showToast(appState, FeedbackSuccess, fmt.Sprintf("已导出 %d 个连接的调试报告", len(report.Connections)))
+	}, appState.Window)
+	save.SetFileName(fmt.Sprintf("myproxy-capture-%s.json", report.StartedAt.Format("20060102-150405")))
+	save.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+	save.Show()
+}
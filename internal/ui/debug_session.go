@@ -0,0 +1,189 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// debugSessionDurationOptions 调试会话时长选项（默认第 2 项）
+var debugSessionDurationOptions = []time.Duration{5 * time.Minute, 10 * time.Minute, 30 * time.Minute}
+
+// debugSessionState 调试会话状态：临时调高应用与 xray 日志级别，期间日志另存一份到会话文件。
+type debugSessionState struct {
+	timer    *time.Timer
+	deadline time.Time
+	file     string
+}
+
+// StartDebugSession 开始调试会话：应用和 xray 核心日志级别临时调为 debug，详细日志另存到独立的会话文件，
+// 到时自动恢复（也可提前调用 StopDebugSession）。保存的日志设置不变，异常退出后重启即恢复原级别。
+// 参数：
+//   - duration: 会话时长
+//
+// 返回：会话文件路径和错误（已在会话中或无法创建文件时）
+func (a *AppState) StartDebugSession(duration time.Duration) (string, error) {
+	if a.Logger == nil || a.XrayControlService == nil {
+		return "", fmt.Errorf("调试会话: 日志未初始化")
+	}
+	a.debugSessionMu.Lock()
+	if a.debugSession != nil {
+		a.debugSessionMu.Unlock()
+		return "", fmt.Errorf("调试会话: 已在进行中")
+	}
+	now := time.Now()
+	path := filepath.Join(filepath.Dir(a.Logger.GetLogFilePath()), fmt.Sprintf("debug-session-%s.log", now.Format("20060102-150405")))
+	if err := a.Logger.StartSessionFile(path); err != nil {
+		a.debugSessionMu.Unlock()
+		return "", fmt.Errorf("调试会话: %w", err)
+	}
+	a.Logger.SetLogLevel("debug")
+	a.XrayControlService.SetDebugMode(true)
+	a.debugSession = &debugSessionState{
+		deadline: now.Add(duration),
+		file:     path,
+		timer: time.AfterFunc(duration, func() {
+			fyne.Do(func() {
+				if file := a.StopDebugSession(); file != "" {
+					showToast(a, FeedbackInfo, "调试会话已结束，日志级别已恢复，详细日志已保存到 "+file)
+				}
+			})
+		}),
+	}
+	a.debugSessionMu.Unlock()
+
+	a.AppendLog("INFO", "app", fmt.Sprintf("开始调试会话（%d 分钟），详细日志另存到 %s", int(duration.Minutes()), path))
+	a.ReloadProxy("开始调试会话")
+	return path, nil
+}
+
+// StopDebugSession 结束调试会话：恢复保存的应用日志级别和 xray 日志设置，关闭会话文件。
+// 返回：会话文件路径，未在会话中时返回空字符串
+func (a *AppState) StopDebugSession() string {
+	a.debugSessionMu.Lock()
+	session := a.debugSession
+	a.debugSession = nil
+	a.debugSessionMu.Unlock()
+	if session == nil {
+		return ""
+	}
+	session.timer.Stop()
+
+	// 恢复为保存的级别（会话期间在设置中修改的级别也在此生效）
+	level := "info"
+	if a.ConfigService != nil {
+		if saved, err := a.ConfigService.GetWithDefault("logLevel", "info"); err == nil {
+			level = saved
+		}
+	}
+	a.AppendLog("INFO", "app", "调试会话结束，日志级别恢复为 "+level)
+	a.Logger.SetLogLevel(level)
+	a.Logger.StopSessionFile()
+	a.XrayControlService.SetDebugMode(false)
+	a.ReloadProxy("结束调试会话")
+	return session.file
+}
+
+// DebugSessionStatus 返回调试会话的剩余时间和会话文件；未在会话中时 active 为 false。
+func (a *AppState) DebugSessionStatus() (remaining time.Duration, file string, active bool) {
+	a.debugSessionMu.Lock()
+	defer a.debugSessionMu.Unlock()
+	if a.debugSession == nil {
+		return 0, "", false
+	}
+	remaining = time.Until(a.debugSession.deadline)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining, a.debugSession.file, true
+}
+
+// showDebugSessionDialog 调试会话：选择时长后临时把日志级别调为调试，期间日志另存为独立文件，到时自动恢复。
+// 会话进行中打开时显示剩余时间，可提前结束。
+func showDebugSessionDialog(appState *AppState) {
+	if appState == nil || appState.Window == nil {
+		return
+	}
+
+	hint := widget.NewLabel("排查问题期间临时把应用和 xray 核心日志级别调为「调试」，详细日志另存为独立文件，" +
+		"到时自动恢复原级别，避免长期开启调试日志。代理运行时会短暂重建一次。")
+	hint.Wrapping = fyne.TextWrapWord
+	statusLabel := widget.NewLabel("")
+	statusLabel.Wrapping = fyne.TextWrapWord
+
+	durationLabels := make([]string, len(debugSessionDurationOptions))
+	for i, d := range debugSessionDurationOptions {
+		durationLabels[i] = fmt.Sprintf("%d 分钟", int(d.Minutes()))
+	}
+	durationSel := widget.NewSelect(durationLabels, nil)
+	durationSel.SetSelected(durationLabels[1])
+
+	var startBtn, stopBtn *widget.Button
+	done := make(chan struct{})
+	// refresh 按会话状态更新按钮和状态文本
+	refresh := func() {
+		remaining, file, active := appState.DebugSessionStatus()
+		if active {
+			statusLabel.SetText(fmt.Sprintf("调试会话进行中，剩余 %s\n会话文件：%s", remaining.Round(time.Second), file))
+			durationSel.Disable()
+			startBtn.Disable()
+			stopBtn.Enable()
+		} else {
+			statusLabel.SetText("")
+			durationSel.Enable()
+			startBtn.Enable()
+			stopBtn.Disable()
+		}
+	}
+
+	startBtn = widget.NewButton("开始", func() {
+		duration := debugSessionDurationOptions[1]
+		for i, label := range durationLabels {
+			if label == durationSel.Selected {
+				duration = debugSessionDurationOptions[i]
+			}
+		}
+		if _, err := appState.StartDebugSession(duration); err != nil {
+			showErrorDetail(appState, "开始调试会话失败", err)
+			return
+		}
+		refresh()
+	})
+	startBtn.Importance = widget.HighImportance
+	stopBtn = widget.NewButton("提前结束", func() {
+		if file := appState.StopDebugSession(); file != "" {
+			showToast(appState, FeedbackSuccess, "调试会话已结束，详细日志已保存到 "+file)
+		}
+		refresh()
+	})
+	refresh()
+
+	content := container.NewVBox(
+		hint,
+		container.NewBorder(nil, nil, widget.NewLabel("时长"), nil, durationSel),
+		statusLabel,
+		container.NewHBox(startBtn, stopBtn),
+	)
+	d := dialog.NewCustom("调试会话", "关闭", content, appState.Window)
+	// 关闭对话框不结束会话，只停止刷新剩余时间
+	d.SetOnClosed(func() { close(done) })
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			fyne.Do(refresh)
+		}
+	}()
+	d.Resize(fyne.NewSize(440, 0))
+	d.Show()
+}
@@ -0,0 +1,283 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/logging"
+	"myproxy.com/p/internal/service"
+)
+
+// exportDiagnosticsBundle 导出诊断包：选择保存位置后写入日志末尾、配置和节点快照（均已脱敏）。
+func exportDiagnosticsBundle(appState *AppState) {
+	if appState == nil || appState.Window == nil || appState.DiagnosticsService == nil {
+		return
+	}
+	logPath := ""
+	if appState.Logger != nil {
+		logPath = appState.Logger.GetLogFilePath()
+	}
+
+	save := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
+		if err != nil {
+			showErrorDetail(appState, "导出诊断包失败", err)
+			return
+		}
+		if w == nil {
+			return
+		}
+		exportErr := appState.DiagnosticsService.Export(w, logPath)
+		if closeErr := w.Close(); exportErr == nil {
+			exportErr = closeErr
+		}
+		if exportErr != nil {
+			showErrorDetail(appState, "导出诊断包失败", exportErr)
+			return
+		}
+		appState.AppendLog("INFO", "app", "已导出诊断包: "+w.URI().Path())
+		showToast(appState, FeedbackSuccess, "诊断包已导出（凭据已脱敏）")
+	}, appState.Window)
+	save.SetFileName(fmt.Sprintf("myproxy-diag-%s.zip", time.Now().Format("20060102-150405")))
+	save.SetFilter(storage.NewExtensionFileFilter([]string{".zip"}))
+	save.Show()
+}
+
+// openDiagnosticsBundle 打开其他机器导出的诊断包，在独立窗口中只读查看。
+func openDiagnosticsBundle(appState *AppState) {
+	if appState == nil || appState.Window == nil {
+		return
+	}
+	open := dialog.NewFileOpen(func(r fyne.URIReadCloser, err error) {
+		if err != nil {
+			showErrorDetail(appState, "打开诊断包失败", err)
+			return
+		}
+		if r == nil {
+			return
+		}
+		defer r.Close()
+		bundle, err := service.ReadDiagnosticsBundle(r)
+		if err != nil {
+			showErrorDetail(appState, "打开诊断包失败", err)
+			return
+		}
+		showDiagnosticsViewer(appState, r.URI().Name(), bundle)
+	}, appState.Window)
+	open.SetFilter(storage.NewExtensionFileFilter([]string{".zip"}))
+	open.Show()
+}
+
+// showDiagnosticsViewer 诊断包只读查看窗口：概览与配置、日志（可按级别、类型和关键字过滤）、节点快照。
+// 内容仅在窗口中展示，不会写入本机数据库或影响正在运行的代理。
+func showDiagnosticsViewer(appState *AppState, name string, bundle *service.DiagnosticsBundle) {
+	if appState == nil || appState.App == nil || bundle == nil {
+		return
+	}
+	w := appState.App.NewWindow("诊断包（只读）- " + name)
+
+	notice := widget.NewLabel("正在查看其他机器导出的诊断包，内容只读，不影响本机配置")
+	notice.Importance = widget.WarningImportance
+	notice.Wrapping = fyne.TextWrapWord
+
+	tabs := container.NewAppTabs(
+		container.NewTabItemWithIcon("概览", theme.InfoIcon(), buildDiagnosticsSummary(bundle)),
+		container.NewTabItemWithIcon(fmt.Sprintf("日志 (%d)", len(bundle.Logs)), theme.DocumentIcon(), buildDiagnosticsLogs(bundle)),
+		container.NewTabItemWithIcon(fmt.Sprintf("节点 (%d)", len(bundle.Nodes)), theme.ListIcon(), buildDiagnosticsNodes(bundle)),
+	)
+	w.SetContent(container.NewBorder(
+		container.NewVBox(container.NewPadded(notice), NewSeparator()), nil, nil, nil, tabs,
+	))
+	w.Resize(fyne.NewSize(720, 560))
+	w.CenterOnScreen()
+	w.Show()
+}
+
+// buildDiagnosticsSummary 概览页：导出环境信息和全部配置项。
+func buildDiagnosticsSummary(bundle *service.DiagnosticsBundle) fyne.CanvasObject {
+	meta := bundle.Meta
+	createdAt := "未知"
+	if !meta.CreatedAt.IsZero() {
+		createdAt = meta.CreatedAt.Format("2006-01-02 15:04:05")
+	}
+	orDash := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return s
+	}
+	info := widget.NewForm(
+		widget.NewFormItem("导出时间", widget.NewLabel(createdAt)),
+		widget.NewFormItem("系统", widget.NewLabel(orDash(strings.Trim(meta.OS+"/"+meta.Arch, "/")))),
+		widget.NewFormItem("代理状态", widget.NewLabel(orDash(meta.ProxyStatus))),
+		widget.NewFormItem("当前节点", widget.NewLabel(orDash(meta.CurrentNode))),
+	)
+
+	keys := bundle.ConfigKeys()
+	configList := widget.NewList(
+		func() int { return len(keys) },
+		func() fyne.CanvasObject {
+			key := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+			value := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+			value.Truncation = fyne.TextTruncateEllipsis
+			return container.NewBorder(nil, nil, container.NewGridWrap(fyne.NewSize(180, 36), key), nil, value)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			row := obj.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(bundle.Config[keys[id]])
+			row.Objects[1].(*fyne.Container).Objects[0].(*widget.Label).SetText(keys[id])
+		},
+	)
+	configList.OnSelected = func(id widget.ListItemID) {
+		// 长配置（如路由规则 JSON）在列表中被截断，选中时复制完整内容
+		fyne.CurrentApp().Clipboard().SetContent(keys[id] + " = " + bundle.Config[keys[id]])
+		configList.UnselectAll()
+	}
+
+	configTitle := widget.NewLabelWithStyle(fmt.Sprintf("配置项 (%d)，点击复制完整内容", len(keys)), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	return container.NewBorder(container.NewVBox(info, NewSeparator(), configTitle), nil, nil, nil, configList)
+}
+
+// buildDiagnosticsLogs 日志页：按级别、类型和关键字过滤诊断包中的日志。
+func buildDiagnosticsLogs(bundle *service.DiagnosticsBundle) fyne.CanvasObject {
+	entries := make([]LogEntry, 0, len(bundle.Logs))
+	for _, line := range bundle.Logs {
+		if e := parseLogLine(line); e != nil {
+			entries = append(entries, *e)
+		} else {
+			// 无法解析的行（如 panic 堆栈）仅在不过滤级别和类型时显示
+			entries = append(entries, LogEntry{Line: line})
+		}
+	}
+
+	var filtered []LogEntry
+	var list *widget.List
+	levelSel := widget.NewSelect([]string{"全部", "DEBUG", "INFO", "WARN", "ERROR", "FATAL"}, nil)
+	typeOptions := []string{"全部"}
+	for _, t := range logging.AllLogTypes {
+		typeOptions = append(typeOptions, t.Label())
+	}
+	typeSel := widget.NewSelect(typeOptions, nil)
+	search := widget.NewEntry()
+	search.SetPlaceHolder("搜索日志")
+	countLabel := widget.NewLabel("")
+	countLabel.Importance = widget.LowImportance
+
+	apply := func() {
+		keyword := strings.ToLower(strings.TrimSpace(search.Text))
+		filtered = filtered[:0]
+		for _, e := range entries {
+			if levelSel.Selected != "全部" && e.Level != levelSel.Selected {
+				continue
+			}
+			if typeSel.Selected != "全部" && (e.Type == "" || logging.LogType(e.Type).Label() != typeSel.Selected) {
+				continue
+			}
+			if keyword != "" && !strings.Contains(strings.ToLower(e.Line), keyword) {
+				continue
+			}
+			filtered = append(filtered, e)
+		}
+		countLabel.SetText(fmt.Sprintf("%d / %d 条", len(filtered), len(entries)))
+		if list != nil {
+			list.Refresh()
+			list.ScrollToBottom()
+		}
+	}
+
+	list = widget.NewList(
+		func() int { return len(filtered) },
+		func() fyne.CanvasObject {
+			label := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			label := obj.(*widget.Label)
+			label.SetText(filtered[id].Line)
+			switch filtered[id].Level {
+			case "ERROR", "FATAL":
+				label.Importance = widget.DangerImportance
+			case "WARN":
+				label.Importance = widget.WarningImportance
+			default:
+				label.Importance = widget.MediumImportance
+			}
+			label.Refresh()
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		// 长日志行在列表中被截断，选中时复制整行
+		fyne.CurrentApp().Clipboard().SetContent(filtered[id].Line)
+		list.UnselectAll()
+	}
+
+	levelSel.OnChanged = func(string) { apply() }
+	typeSel.OnChanged = func(string) { apply() }
+	search.OnChanged = func(string) { apply() }
+	levelSel.SetSelected("全部")
+	typeSel.SetSelected("全部")
+
+	filters := container.NewHBox(
+		widget.NewLabel("级别"), container.NewGridWrap(fyne.NewSize(100, 40), levelSel),
+		widget.NewLabel("类型"), container.NewGridWrap(fyne.NewSize(100, 40), typeSel),
+		layout.NewSpacer(), countLabel,
+	)
+	return container.NewBorder(container.NewVBox(filters, search), nil, nil, nil, list)
+}
+
+// buildDiagnosticsNodes 节点页：导出时的节点列表快照（不含凭据）。
+func buildDiagnosticsNodes(bundle *service.DiagnosticsBundle) fyne.CanvasObject {
+	if len(bundle.Nodes) == 0 {
+		return container.NewCenter(widget.NewLabel("诊断包中没有节点"))
+	}
+	list := widget.NewList(
+		func() int { return len(bundle.Nodes) },
+		func() fyne.CanvasObject {
+			icon := widget.NewIcon(theme.RadioButtonIcon())
+			name := widget.NewLabel("")
+			name.Truncation = fyne.TextTruncateEllipsis
+			detail := widget.NewLabel("")
+			detail.Importance = widget.LowImportance
+			return container.NewBorder(nil, nil, icon, detail, name)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			n := bundle.Nodes[id]
+			row := obj.(*fyne.Container)
+			name := row.Objects[0].(*widget.Label)
+			icon := row.Objects[1].(*widget.Icon)
+			detail := row.Objects[2].(*widget.Label)
+
+			if n.Selected {
+				icon.SetResource(theme.RadioButtonCheckedIcon())
+			} else {
+				icon.SetResource(theme.RadioButtonIcon())
+			}
+			title := n.Name
+			if n.UserModified {
+				title += "（已修改）"
+			}
+			name.SetText(title)
+
+			delay := "未测速"
+			if n.Delay > 0 {
+				delay = fmt.Sprintf("%d ms", n.Delay)
+			} else if n.Delay < 0 {
+				delay = "超时"
+			}
+			parts := []string{n.Protocol, fmt.Sprintf("%s:%d", n.Addr, n.Port), delay}
+			if !n.Enabled {
+				parts = append(parts, "已禁用")
+			}
+			detail.SetText(strings.Join(parts, " · "))
+		},
+	)
+	return list
+}
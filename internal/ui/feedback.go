@@ -0,0 +1,229 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/errs"
+	"myproxy.com/p/internal/model"
+)
+
+// 界面反馈组件：轻提示（toast）、页内横幅和标准错误对话框。
+// 约定：操作成功等不需要确认的结果用轻提示；持续存在、需要用户留意的问题用横幅；
+// 操作失败用错误对话框（同时记录日志）。所有函数须在主线程调用。
+
+// toastDuration 轻提示显示时长
+const toastDuration = 2500 * time.Millisecond
+
+// FeedbackLevel 反馈级别，决定图标与颜色。
+type FeedbackLevel int
+
+const (
+	FeedbackInfo    FeedbackLevel = iota // 一般提示
+	FeedbackSuccess                      // 操作成功
+	FeedbackWarning                      // 警告
+	FeedbackError                        // 错误
+)
+
+// icon 返回级别对应的图标。
+func (l FeedbackLevel) icon() fyne.Resource {
+	switch l {
+	case FeedbackSuccess:
+		return theme.ConfirmIcon()
+	case FeedbackWarning:
+		return theme.WarningIcon()
+	case FeedbackError:
+		return theme.ErrorIcon()
+	default:
+		return theme.InfoIcon()
+	}
+}
+
+// colorName 返回级别对应的主题颜色名。
+func (l FeedbackLevel) colorName() fyne.ThemeColorName {
+	switch l {
+	case FeedbackSuccess:
+		return theme.ColorNameSuccess
+	case FeedbackWarning:
+		return theme.ColorNameWarning
+	case FeedbackError:
+		return theme.ColorNameError
+	default:
+		return theme.ColorNamePrimary
+	}
+}
+
+// showToast 在窗口底部居中短暂显示一条提示，到时自动消失；新提示会替换仍在显示的旧提示。
+func showToast(appState *AppState, level FeedbackLevel, message string) {
+	if appState == nil || appState.Window == nil {
+		return
+	}
+	if appState.toast != nil {
+		appState.toast.Hide()
+	}
+
+	c := appState.Window.Canvas()
+	bg := canvas.NewRectangle(withAlpha(CurrentThemeColor(appState.App, theme.ColorNameOverlayBackground), 0xf0))
+	bg.CornerRadius = theme.InputRadiusSize()
+	bg.StrokeColor = CurrentThemeColor(appState.App, level.colorName())
+	bg.StrokeWidth = 1
+	content := container.NewStack(bg, container.NewPadded(
+		container.NewHBox(widget.NewIcon(level.icon()), widget.NewLabel(message)),
+	))
+
+	toast := widget.NewPopUp(content, c)
+	size := content.MinSize()
+	canvasSize := c.Size()
+	toast.ShowAtPosition(fyne.NewPos((canvasSize.Width-size.Width)/2, canvasSize.Height-size.Height-theme.Padding()*8))
+	appState.toast = toast
+
+	time.AfterFunc(toastDuration, func() {
+		fyne.Do(func() {
+			toast.Hide()
+			if appState.toast == toast {
+				appState.toast = nil
+			}
+		})
+	})
+}
+
+// showErrorDetail 显示标准错误对话框：概要信息 + 可展开的详细错误链，并记录日志。
+// 参数：
+//   - appState: 应用状态
+//   - summary: 操作概要，如 "启动代理失败"
+//   - err: 错误
+func showErrorDetail(appState *AppState, summary string, err error) {
+	if appState == nil || err == nil {
+		return
+	}
+	appState.AppendLog("ERROR", "ui", fmt.Sprintf("%s: %v", summary, err))
+	if appState.Window == nil {
+		return
+	}
+
+	message := widget.NewLabel(err.Error())
+	message.Wrapping = fyne.TextWrapWord
+	hint := widget.NewLabel(errorHint(err))
+	hint.Wrapping = fyne.TextWrapWord
+	hint.Importance = widget.LowImportance
+	if hint.Text == "" {
+		hint.Hide()
+	}
+
+	// 详细信息：逐层展开错误链，并附带发生时间，便于反馈问题时复制
+	var lines []string
+	lines = append(lines, "时间: "+time.Now().Format("2006-01-02 15:04:05"))
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		lines = append(lines, "- "+e.Error())
+	}
+	details := strings.Join(lines, "\n")
+	detailLabel := widget.NewLabelWithStyle(details, fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	detailLabel.Wrapping = fyne.TextWrapBreak
+	copyBtn := widget.NewButtonWithIcon("复制详情", theme.ContentCopyIcon(), func() {
+		appState.Window.Clipboard().SetContent(summary + "\n" + details)
+	})
+	copyBtn.Importance = widget.LowImportance
+	accordion := widget.NewAccordion(widget.NewAccordionItem("详细信息", container.NewVBox(detailLabel, copyBtn)))
+
+	content := container.NewVBox(
+		container.NewHBox(widget.NewIcon(theme.ErrorIcon()), widget.NewLabelWithStyle(summary, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})),
+		message,
+		hint,
+		accordion,
+	)
+	d := dialog.NewCustom("错误", "关闭", content, appState.Window)
+	d.Resize(fyne.NewSize(420, 0))
+	d.Show()
+}
+
+// errorHint 按错误类别给出处理建议，未知类别返回空字符串。
+func errorHint(err error) string {
+	var portErr *errs.PortInUseError
+	switch {
+	case errors.As(err, &portErr):
+		if portErr.Port == model.MainInboundPort {
+			return fmt.Sprintf("主入站端口 %d 被其他程序占用，请关闭占用该端口的程序（如另一个代理客户端或本程序的另一个实例）后重试", portErr.Port)
+		}
+		return fmt.Sprintf("端口 %d 被其他程序占用，请关闭占用该端口的程序，或在 设置 → 多入站 中更换端口", portErr.Port)
+	case errors.Is(err, errs.ErrAuthFailed):
+		return "订阅服务器拒绝访问：订阅地址中的 token 可能已失效或被重置，请在服务商处重新复制订阅地址"
+	case errors.Is(err, errs.ErrUnsupportedProtocol):
+		return "该协议暂不支持，请更换节点或检查配置"
+	case errors.Is(err, errs.ErrUnsupportedOS):
+		return "当前操作系统不支持此功能"
+	case errors.Is(err, errs.ErrNodeNotFound):
+		return "节点可能已被删除或随订阅更新移除，请刷新节点列表后重新选择"
+	case errors.Is(err, errs.ErrNoNodeSelected):
+		return "请先在节点列表中选择一个节点"
+	case errors.Is(err, errs.ErrProxyNotRunning):
+		return "请先启动代理"
+	case errors.Is(err, errs.ErrStoreNotInitialized):
+		return "应用尚未完成初始化，请稍后重试或重启应用"
+	}
+	return ""
+}
+
+// Banner 页内横幅：在页面顶部持续显示一条提示（如批量操作的失败汇总），用户可手动关闭。
+// 初始为隐藏状态，调用 ShowMessage 后显示。
+type Banner struct {
+	widget.BaseWidget
+	appState *AppState
+	bg       *canvas.Rectangle
+	icon     *widget.Icon
+	label    *widget.Label
+	closeBtn *widget.Button
+}
+
+// NewBanner 创建页内横幅。
+// 参数：
+//   - appState: 应用状态（用于读取主题颜色）
+//
+// 返回：横幅实例（初始隐藏）
+func NewBanner(appState *AppState) *Banner {
+	b := &Banner{
+		appState: appState,
+		bg:       canvas.NewRectangle(color.Transparent),
+		icon:     widget.NewIcon(theme.InfoIcon()),
+		label:    widget.NewLabel(""),
+	}
+	b.label.Wrapping = fyne.TextWrapWord
+	b.closeBtn = widget.NewButtonWithIcon("", theme.CancelIcon(), b.Hide)
+	b.closeBtn.Importance = widget.LowImportance
+	b.ExtendBaseWidget(b)
+	b.Hide()
+	return b
+}
+
+// ShowMessage 以指定级别显示横幅内容。
+func (b *Banner) ShowMessage(level FeedbackLevel, message string) {
+	b.icon.SetResource(level.icon())
+	b.label.SetText(message)
+	var app fyne.App
+	if b.appState != nil {
+		app = b.appState.App
+	}
+	b.bg.FillColor = withAlpha(CurrentThemeColor(app, level.colorName()), 0x33)
+	b.bg.Refresh()
+	b.Show()
+}
+
+// CreateRenderer 实现 fyne.Widget。
+func (b *Banner) CreateRenderer() fyne.WidgetRenderer {
+	row := container.NewBorder(nil, nil, b.icon, b.closeBtn, b.label)
+	return widget.NewSimpleRenderer(container.NewStack(b.bg, container.NewPadded(row)))
+}
+
+// withAlpha 返回替换透明度后的颜色。
+func withAlpha(c color.Color, alpha uint8) color.Color {
+	r, g, bl, _ := c.RGBA()
+	return color.NRGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(bl >> 8), A: alpha}
+}
@@ -0,0 +1,134 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/model"
+)
+
+// hookEventLabels 生命周期事件的显示名称。
+var hookEventLabels = map[model.HookEvent]string{
+	model.HookEventProxyStarted:        "代理启动",
+	model.HookEventProxyStopped:        "代理停止",
+	model.HookEventNodeSwitched:        "切换节点",
+	model.HookEventSubscriptionUpdated: "订阅更新",
+}
+
+// hookScriptLabel 返回事件脚本在列表中的显示文本，如 "/path/notify.sh · 代理启动, 切换节点"。
+func hookScriptLabel(s model.HookScript) string {
+	if len(s.Events) == 0 {
+		return s.Path + " · 全部事件"
+	}
+	names := make([]string, 0, len(s.Events))
+	for _, e := range s.Events {
+		names = append(names, hookEventLabels[e])
+	}
+	return s.Path + " · " + strings.Join(names, ", ")
+}
+
+// showHookScriptsDialog 弹出事件脚本对话框：代理启停、切换节点、订阅更新时执行外部程序，
+// 事件内容以 JSON 写入脚本标准输入，事件名通过环境变量 MYPROXY_EVENT 传入。
+func showHookScriptsDialog(appState *AppState) {
+	if appState == nil || appState.Window == nil || appState.ConfigService == nil {
+		return
+	}
+	cs := appState.ConfigService
+	scripts := cs.GetHookScripts()
+
+	var list *widget.List
+	// save 保存，失败时回滚为已保存的配置
+	save := func() {
+		if err := cs.SetHookScripts(scripts); err != nil {
+			dialog.ShowError(err, appState.Window)
+			scripts = cs.GetHookScripts()
+		}
+		if list != nil {
+			list.Refresh()
+		}
+	}
+
+	list = widget.NewList(
+		func() int { return len(scripts) },
+		func() fyne.CanvasObject {
+			check := widget.NewCheck("", nil)
+			delBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), nil)
+			delBtn.Importance = widget.LowImportance
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return container.NewBorder(nil, nil, check, delBtn, label)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id < 0 || id >= len(scripts) {
+				return
+			}
+			row := obj.(*fyne.Container)
+			label := row.Objects[0].(*widget.Label)
+			check := row.Objects[1].(*widget.Check)
+			delBtn := row.Objects[2].(*widget.Button)
+			label.SetText(hookScriptLabel(scripts[id]))
+			// 先解除回调再设置状态，避免列表复用行时误触发保存
+			check.OnChanged = nil
+			check.SetChecked(scripts[id].Enabled)
+			check.OnChanged = func(b bool) {
+				scripts[id].Enabled = b
+				save()
+			}
+			delBtn.OnTapped = func() {
+				scripts = append(scripts[:id], scripts[id+1:]...)
+				save()
+			}
+		},
+	)
+
+	pathEntry := widget.NewEntry()
+	pathEntry.SetPlaceHolder("可执行文件路径，如 /usr/local/bin/notify.sh")
+	eventOptions := make([]string, 0, len(model.AllHookEvents))
+	for _, e := range model.AllHookEvents {
+		eventOptions = append(eventOptions, hookEventLabels[e])
+	}
+	eventGroup := widget.NewCheckGroup(eventOptions, nil)
+	eventGroup.Horizontal = true
+	addBtn := widget.NewButtonWithIcon("添加", theme.ContentAddIcon(), func() {
+		path := strings.TrimSpace(pathEntry.Text)
+		if path == "" {
+			dialog.ShowError(fmt.Errorf("请填写脚本路径"), appState.Window)
+			return
+		}
+		// 未勾选任何事件表示订阅全部事件
+		var events []model.HookEvent
+		for _, e := range model.AllHookEvents {
+			for _, sel := range eventGroup.Selected {
+				if sel == hookEventLabels[e] {
+					events = append(events, e)
+				}
+			}
+		}
+		scripts = append(scripts, model.HookScript{Path: path, Events: events, Enabled: true})
+		save()
+		pathEntry.SetText("")
+		eventGroup.SetSelected(nil)
+	})
+	addBtn.Importance = widget.LowImportance
+
+	hint := widget.NewLabel("事件发生时执行脚本，事件 JSON 写入标准输入，事件名见环境变量 MYPROXY_EVENT；不勾选事件表示全部事件")
+	hint.Wrapping = fyne.TextWrapWord
+	listScroll := container.NewScroll(list)
+	listScroll.SetMinSize(fyne.NewSize(420, 160))
+	content := container.NewBorder(
+		hint,
+		container.NewVBox(
+			container.NewBorder(nil, nil, nil, addBtn, pathEntry),
+			eventGroup,
+		),
+		nil, nil,
+		listScroll,
+	)
+	d := dialog.NewCustom("事件脚本", "关闭", content, appState.Window)
+	d.Show()
+}
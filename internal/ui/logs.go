@@ -16,6 +16,8 @@ import (
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/fsnotify/fsnotify"
+	"myproxy.com/p/internal/logging"
+	"myproxy.com/p/internal/utils"
 )
 
 // LogEntry 表示一条日志条目
@@ -29,11 +31,26 @@ type LogEntry struct {
 
 // 日志面板内存优化常量
 const (
-	maxBufferSize     = 1000  // 内存中最多保留的日志条数
-	maxDisplayLines   = 500   // RichText 最多渲染的条数（减少 UI 内存占用）
-	refreshDebounceMs = 300   // 快速追加日志时的刷新防抖间隔（毫秒）
+	maxBufferSize     = 1000              // 内存中最多保留的日志条数
+	maxDisplayLines   = 500               // RichText 最多渲染的条数（减少 UI 内存占用）
+	refreshDebounceMs = 300               // 快速追加日志时的刷新防抖间隔（毫秒）
+	maxOlderEntries   = 5 * maxBufferSize // 已读回的更早日志最多保留的条数，超出时丢弃最早的部分
 )
 
+// 日志文件跟踪：正常时依靠 fsnotify 通知并低频轮询兜底；监控不可用（平台限制、监控数超限等）时
+// 退化为高频轮询，并定期重试建立监控
+const (
+	logWatchPollInterval    = 5 * time.Second        // 监控正常时的兜底轮询间隔
+	logDegradedPollInterval = 500 * time.Millisecond // 监控不可用时的轮询间隔
+	logWatchRetryInterval   = 30 * time.Second       // 监控不可用时重试建立监控的间隔
+)
+
+// logSpillDir 日志面板溢出分段目录（位于本次运行的临时目录下，独立于主日志文件）
+var logSpillDir = filepath.Join(utils.RunDir(utils.DataDir), "logspill")
+
+// logSpillQuotaOptions 日志磁盘配额选项（MB），0 表示关闭
+var logSpillQuotaOptions = []int{0, 10, 20, 50, 100}
+
 // LogsPanel 管理应用日志和代理日志的显示。
 // 它支持按日志级别和类型过滤，并提供追加日志功能。
 // 内存优化：仅保留最近 N 条日志，限制显示条数，并对快速追加做防抖。
@@ -44,10 +61,12 @@ type LogsPanel struct {
 	typeSel        *widget.Select
 	logBuffer      []LogEntry         // 日志缓冲区
 	bufferMutex    sync.Mutex         // 保护日志缓冲区的互斥锁
-	fileWatcher    *fsnotify.Watcher  // 文件监控器
 	ctx            context.Context    // 上下文，用于控制监控 goroutine
 	cancel         context.CancelFunc // 取消函数
+	watchCancel    context.CancelFunc // 停止当前的日志文件跟踪 goroutine
 	lastReadPos    int64              // 最后读取的位置
+	tailDegraded   bool               // 文件监控不可用，已退化为轮询（仅跟踪 goroutine 访问）
+	tailStatus     *widget.Label      // 文件监控不可用时的提示
 	isCollapsed    bool               // 是否折叠
 	collapseBtn    *widget.Button     // 折叠/展开按钮
 	logScroll      *container.Scroll  // 日志滚动容器
@@ -56,6 +75,17 @@ type LogsPanel struct {
 	// 防抖刷新
 	refreshTimer  *time.Timer
 	refreshTimerMu sync.Mutex
+
+	// 磁盘溢出：挤出内存缓冲区的旧日志写入 logSpill，可通过「加载更早日志」分页读回
+	spillMu       sync.Mutex
+	spill         *logging.LogSpill
+	spillFailed   bool           // 创建或写入失败后不再重试，避免每条日志都触发磁盘错误
+	olderEntries  []LogEntry     // 已读回的更早日志（位于 logBuffer 之前，受 bufferMutex 保护）
+	olderLoaded   bool           // 是否已读回过更早日志；读回后新挤出的日志同时追加到 olderEntries 以保持连续
+	olderNextSeq  int            // 下一次读取的分段序号
+	olderTrimmed  bool           // 更早日志超出上限被丢弃过开头，继续向前读取会不连续
+	olderBtn      *widget.Button // 加载更早日志按钮
+	olderResetBtn *widget.Button // 收起更早日志按钮
 }
 
 // NewLogsPanel 创建并初始化日志显示面板。
@@ -92,9 +122,13 @@ func NewLogsPanel(appState *AppState) *LogsPanel {
 		},
 	)
 
-	// 日志类型选择器：仅用于过滤显示
+	// 日志类型选择器：仅用于过滤显示，选项为类型的中文名称
+	typeOptions := []string{"全部"}
+	for _, t := range logging.AllLogTypes {
+		typeOptions = append(typeOptions, t.Label())
+	}
 	lp.typeSel = widget.NewSelect(
-		[]string{"全部", "app", "proxy", "xray"},
+		typeOptions,
 		func(value string) {
 			if lp.levelSel != nil { // 确保 levelSel 已初始化
 				lp.refreshDisplay() // 仅刷新显示
@@ -106,6 +140,11 @@ func NewLogsPanel(appState *AppState) *LogsPanel {
 	lp.levelSel.SetSelected("全部")
 	lp.typeSel.SetSelected("全部")
 
+	lp.tailStatus = widget.NewLabel("")
+	lp.tailStatus.Importance = widget.WarningImportance
+	lp.tailStatus.Wrapping = fyne.TextWrapWord
+	lp.tailStatus.Hide()
+
 	// 创建上下文用于控制监控 goroutine
 	lp.ctx, lp.cancel = context.WithCancel(context.Background())
 
@@ -136,7 +175,36 @@ func (lp *LogsPanel) Build() fyne.CanvasObject {
 		container.NewGridWrap(fyne.NewSize(100, 40), lp.typeSel),
 		layout.NewSpacer(),
 	)
-	topBar := container.NewPadded(container.NewVBox(levelRow, typeRow))
+	// 更早日志：从磁盘溢出分段分页读回；磁盘配额可调
+	lp.olderBtn = widget.NewButtonWithIcon("加载更早日志", theme.MoveUpIcon(), lp.loadOlder)
+	lp.olderBtn.Importance = widget.LowImportance
+	lp.olderResetBtn = widget.NewButtonWithIcon("收起", theme.MoveDownIcon(), lp.resetOlder)
+	lp.olderResetBtn.Importance = widget.LowImportance
+	lp.olderResetBtn.Hide()
+	quotaLabels := make([]string, len(logSpillQuotaOptions))
+	for i, mb := range logSpillQuotaOptions {
+		quotaLabels[i] = logSpillQuotaLabel(mb)
+	}
+	quotaSel := widget.NewSelect(quotaLabels, nil)
+	if lp.appState != nil && lp.appState.ConfigService != nil {
+		quotaSel.SetSelected(logSpillQuotaLabel(lp.appState.ConfigService.GetLogSpillQuotaMB()))
+	}
+	quotaSel.OnChanged = func(label string) {
+		for _, mb := range logSpillQuotaOptions {
+			if logSpillQuotaLabel(mb) == label {
+				lp.setSpillQuota(mb)
+				return
+			}
+		}
+	}
+	olderRow := container.NewHBox(
+		lp.olderBtn,
+		lp.olderResetBtn,
+		layout.NewSpacer(),
+		widget.NewLabel("磁盘"),
+		container.NewGridWrap(fyne.NewSize(100, 40), quotaSel),
+	)
+	topBar := container.NewPadded(container.NewVBox(levelRow, typeRow, olderRow, lp.tailStatus))
 
 	// 日志内容区域
 	lp.logScroll = container.NewScroll(lp.logContent)
@@ -166,7 +234,7 @@ func (lp *LogsPanel) toggleCollapse() {
 	if lp.appState != nil && lp.appState.ConfigService != nil {
 		if err := lp.appState.ConfigService.SetLogsCollapsed(lp.isCollapsed); err != nil {
 			if lp.appState.Logger != nil {
-				lp.appState.Logger.Error("保存日志折叠状态失败: %v", err)
+				lp.appState.Logger.ErrorWithType(logging.LogTypeUI, "保存日志折叠状态失败: %v", err)
 			}
 		}
 	}
@@ -220,14 +288,9 @@ func (lp *LogsPanel) AppendLog(level, logType, message string) {
 		return
 	}
 
-	// 规范化：级别一律大写，类型仅允许 app/xray
+	// 规范化：级别一律大写，未知类型归为 app
 	level = strings.ToUpper(level)
-	switch strings.ToLower(logType) {
-	case "xray":
-		logType = "xray"
-	default:
-		logType = "app"
-	}
+	logType = string(logging.ParseLogType(logType))
 
 	// 构建完整的日志行（与Logger.log()中的格式保持一致）
 	timestamp := time.Now().Format("2006-01-02 15:04:05")
@@ -252,18 +315,35 @@ func (lp *LogsPanel) AppendLogLine(logLine string) {
 	}
 
 	// 解析日志行
-	entry := lp.parseLogLine(logLine)
+	entry := parseLogLine(logLine)
 	if entry == nil {
 		return
 	}
 
+	var evicted []string
 	lp.bufferMutex.Lock()
 	lp.logBuffer = append(lp.logBuffer, *entry)
 	if len(lp.logBuffer) > maxBufferSize {
-		lp.logBuffer = lp.logBuffer[len(lp.logBuffer)-maxBufferSize:]
+		drop := len(lp.logBuffer) - maxBufferSize
+		for _, e := range lp.logBuffer[:drop] {
+			evicted = append(evicted, e.Line)
+		}
+		if lp.olderLoaded {
+			lp.olderEntries = append(lp.olderEntries, lp.logBuffer[:drop]...)
+			if len(lp.olderEntries) > maxOlderEntries {
+				// 一次多丢弃一个缓冲区的量并复制到新切片，避免每条日志都触发复制且让旧底层数组得以释放
+				keep := maxOlderEntries - maxBufferSize
+				lp.olderEntries = append([]LogEntry(nil), lp.olderEntries[len(lp.olderEntries)-keep:]...)
+				lp.olderTrimmed = true
+			}
+		}
+		lp.logBuffer = lp.logBuffer[drop:]
 	}
 	lp.bufferMutex.Unlock()
 
+	if len(evicted) > 0 {
+		lp.spillLines(evicted)
+	}
 	lp.scheduleRefresh()
 }
 
@@ -271,7 +351,7 @@ func (lp *LogsPanel) AppendLogLine(logLine string) {
 // 支持两种格式：
 // 1. 应用日志格式: timestamp [LEVEL] [type] message
 // 2. xray 日志格式: timestamp [Level] tag: message 或 timestamp [Level] tag/subtag: message
-func (lp *LogsPanel) parseLogLine(line string) *LogEntry {
+func parseLogLine(line string) *LogEntry {
 	// 尝试解析应用日志格式: timestamp [LEVEL] [type] message
 	levelStart := strings.Index(line, "[")
 	if levelStart == -1 {
@@ -346,7 +426,7 @@ func (lp *LogsPanel) parseLogLine(line string) *LogEntry {
 	default:
 		level = "INFO"
 	}
-	logType = strings.ToLower(logType)
+	logType = string(logging.ParseLogType(logType))
 
 	return &LogEntry{
 		Timestamp: timestamp,
@@ -385,21 +465,29 @@ func (lp *LogsPanel) refreshDisplay() {
 	levelFilter := lp.levelSel.Selected
 	typeFilter := lp.typeSel.Selected
 
+	entries := lp.logBuffer
+	limit := maxDisplayLines
+	if len(lp.olderEntries) > 0 {
+		// 已读回更早日志时全部显示，由用户「收起」释放
+		entries = append(append([]LogEntry(nil), lp.olderEntries...), lp.logBuffer...)
+		limit += len(lp.olderEntries)
+	}
+
 	var filteredEntries []LogEntry
-	for _, entry := range lp.logBuffer {
+	for _, entry := range entries {
 		if levelFilter != "全部" && entry.Level != levelFilter {
 			continue
 		}
-		if typeFilter != "全部" && entry.Type != typeFilter {
+		if typeFilter != "全部" && logging.LogType(entry.Type).Label() != typeFilter {
 			continue
 		}
 		filteredEntries = append(filteredEntries, entry)
 	}
 
-	// 只显示最近 maxDisplayLines 条，减少 RichText 内存占用
+	// 只显示最近 limit 条，减少 RichText 内存占用
 	start := 0
-	if len(filteredEntries) > maxDisplayLines {
-		start = len(filteredEntries) - maxDisplayLines
+	if len(filteredEntries) > limit {
+		start = len(filteredEntries) - limit
 	}
 	displayEntries := filteredEntries[start:]
 	lp.bufferMutex.Unlock()
@@ -421,12 +509,138 @@ func (lp *LogsPanel) refreshDisplay() {
 	})
 }
 
+// spillLines 将挤出内存缓冲区的日志写入磁盘溢出环（首次调用时按配置创建）。
+func (lp *LogsPanel) spillLines(lines []string) {
+	lp.spillMu.Lock()
+	defer lp.spillMu.Unlock()
+	if lp.spillFailed {
+		return
+	}
+	if lp.spill == nil {
+		if lp.appState == nil || lp.appState.ConfigService == nil {
+			return
+		}
+		mb := lp.appState.ConfigService.GetLogSpillQuotaMB()
+		if mb <= 0 {
+			return
+		}
+		spill, err := logging.NewLogSpill(logSpillDir, int64(mb)<<20)
+		if err != nil {
+			lp.spillFailed = true
+			return
+		}
+		lp.spill = spill
+	}
+	if err := lp.spill.Append(lines); err != nil {
+		lp.spillFailed = true
+	}
+}
+
+// setSpillQuota 保存磁盘配额并立即生效；设为 0 时删除已溢出的日志。
+func (lp *LogsPanel) setSpillQuota(mb int) {
+	if lp.appState == nil || lp.appState.ConfigService == nil {
+		return
+	}
+	if err := lp.appState.ConfigService.SetLogSpillQuotaMB(mb); err != nil {
+		showErrorDetail(lp.appState, "保存日志磁盘配额失败", err)
+		return
+	}
+	lp.spillMu.Lock()
+	defer lp.spillMu.Unlock()
+	lp.spillFailed = false
+	if lp.spill == nil {
+		return
+	}
+	if mb <= 0 {
+		lp.spill.Close()
+		lp.spill = nil
+		return
+	}
+	lp.spill.SetQuota(int64(mb) << 20)
+}
+
+// loadOlder 从磁盘溢出环读回一页（一个分段）更早的日志，显示在当前日志之前。
+func (lp *LogsPanel) loadOlder() {
+	lp.spillMu.Lock()
+	spill := lp.spill
+	lp.spillMu.Unlock()
+	if spill == nil {
+		showToast(lp.appState, FeedbackInfo, "没有更早的日志")
+		return
+	}
+	oldest, newest, ok := spill.Range()
+
+	lp.bufferMutex.Lock()
+	seq := lp.olderNextSeq
+	if !lp.olderLoaded {
+		seq = newest
+	}
+	full := lp.olderTrimmed || len(lp.olderEntries) >= maxOlderEntries
+	lp.bufferMutex.Unlock()
+	if full {
+		showToast(lp.appState, FeedbackInfo, "已读回的日志达到上限，请先收起再加载")
+		return
+	}
+	if !ok || seq < oldest {
+		showToast(lp.appState, FeedbackInfo, "没有更早的日志")
+		return
+	}
+
+	lines, err := spill.ReadSegment(seq)
+	if err != nil {
+		showErrorDetail(lp.appState, "读取更早日志失败", err)
+		return
+	}
+	page := make([]LogEntry, 0, len(lines))
+	for _, line := range lines {
+		if e := parseLogLine(line); e != nil {
+			page = append(page, *e)
+		}
+	}
+
+	lp.bufferMutex.Lock()
+	lp.olderEntries = append(page, lp.olderEntries...)
+	lp.olderLoaded = true
+	lp.olderNextSeq = seq - 1
+	lp.bufferMutex.Unlock()
+
+	lp.olderResetBtn.Show()
+	if seq-1 < oldest {
+		lp.olderBtn.SetText("没有更早的日志")
+		lp.olderBtn.Disable()
+	}
+	lp.refreshDisplay()
+}
+
+// resetOlder 收起已读回的更早日志，释放内存。
+func (lp *LogsPanel) resetOlder() {
+	lp.bufferMutex.Lock()
+	lp.olderEntries = nil
+	lp.olderLoaded = false
+	lp.olderTrimmed = false
+	lp.bufferMutex.Unlock()
+
+	lp.olderResetBtn.Hide()
+	lp.olderBtn.SetText("加载更早日志")
+	lp.olderBtn.Enable()
+	lp.refreshDisplay()
+}
+
+// logSpillQuotaLabel 返回磁盘配额的显示文本。
+func logSpillQuotaLabel(mb int) string {
+	if mb <= 0 {
+		return "关闭"
+	}
+	return fmt.Sprintf("%d MB", mb)
+}
+
 // Refresh 刷新日志显示，重新应用当前过滤条件。
 func (lp *LogsPanel) Refresh() {
 	lp.refreshDisplay()
 }
 
-// StartLogFileWatcher 启动日志文件监控（公开方法，可在Logger初始化后调用）
+// StartLogFileWatcher 启动日志文件监控（公开方法，可在Logger初始化后调用）。
+// 重复调用会先停止之前的监控；fsnotify 不可用时退化为轮询，不会停止跟踪。
 func (lp *LogsPanel) StartLogFileWatcher() {
 	if lp.appState == nil || lp.appState.Logger == nil {
 		return
@@ -441,33 +655,12 @@ func (lp *LogsPanel) StartLogFileWatcher() {
 		logFilePath = abs
 	}
 
-	// 如果监控器已存在，先关闭
-	if lp.fileWatcher != nil {
-		lp.fileWatcher.Close()
-	}
-
-	// 创建文件监控器
-	watcher, err := fsnotify.NewWatcher()
-	if err != nil {
-		return
-	}
-	lp.fileWatcher = watcher
-
-	// 监控日志文件所在目录
-	logDir := logFilePath
-	if lastSlash := strings.LastIndex(logDir, "/"); lastSlash >= 0 {
-		logDir = logDir[:lastSlash]
-	}
-	if logDir == "" {
-		logDir = "."
-	}
-
-	// 添加目录到监控
-	if err := watcher.Add(logDir); err != nil {
-		watcher.Close()
-		lp.fileWatcher = nil
-		return
+	// 如果监控已在运行，先停止
+	if lp.watchCancel != nil {
+		lp.watchCancel()
 	}
+	ctx, cancel := context.WithCancel(lp.ctx)
+	lp.watchCancel = cancel
 
 	// 初始化 lastReadPos 为当前文件大小（避免重复读取已有内容）
 	if fileInfo, err := os.Stat(logFilePath); err == nil {
@@ -475,33 +668,79 @@ func (lp *LogsPanel) StartLogFileWatcher() {
 	}
 
 	// 启动监控 goroutine
-	go lp.watchLogFile()
+	go lp.watchLogFile(ctx, logFilePath)
 }
 
-// watchLogFile 监控日志文件变化
-func (lp *LogsPanel) watchLogFile() {
-	if lp.fileWatcher == nil {
-		return
+// newLogDirWatcher 创建监控日志文件所在目录的 fsnotify 监控器。
+func newLogDirWatcher(logFilePath string) (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
 	}
-	defer lp.fileWatcher.Close()
+	if err := watcher.Add(filepath.Dir(logFilePath)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	return watcher, nil
+}
 
-	logFilePath := lp.appState.Logger.GetLogFilePath()
-	if abs, err := filepath.Abs(logFilePath); err == nil {
-		logFilePath = abs
+// watchLogFile 跟踪日志文件变化：有 fsnotify 通知时立即读取，并始终轮询兜底。
+// 监控创建失败、报错或被关闭时改为高频轮询，定期重试，恢复后回到通知模式。
+func (lp *LogsPanel) watchLogFile(ctx context.Context, logFilePath string) {
+	watcher, err := newLogDirWatcher(logFilePath)
+	lp.setTailDegraded(err)
+	defer func() {
+		if watcher != nil {
+			watcher.Close()
+		}
+	}()
+
+	poll := time.NewTicker(logWatchPollInterval)
+	defer poll.Stop()
+	if watcher == nil {
+		poll.Reset(logDegradedPollInterval)
+	}
+	retry := time.NewTicker(logWatchRetryInterval)
+	defer retry.Stop()
+
+	// degrade 丢弃失效的监控器，改为高频轮询
+	degrade := func(err error) {
+		if watcher != nil {
+			watcher.Close()
+			watcher = nil
+		}
+		poll.Reset(logDegradedPollInterval)
+		lp.setTailDegraded(err)
 	}
-	ticker := time.NewTicker(500 * time.Millisecond) // 每 500ms 检查一次文件变化
-	defer ticker.Stop()
 
 	for {
+		// 无监控器时 events/errs 为 nil，对应分支不会被选中
+		var events <-chan fsnotify.Event
+		var errs <-chan error
+		if watcher != nil {
+			events, errs = watcher.Events, watcher.Errors
+		}
+
 		select {
-		case <-lp.ctx.Done():
+		case <-ctx.Done():
 			return
-		case <-ticker.C:
-			// 读取文件的新内容
+		case <-poll.C:
 			lp.readNewLogLines(logFilePath)
-		case event, ok := <-lp.fileWatcher.Events:
+		case <-retry.C:
+			if watcher != nil {
+				continue
+			}
+			if w, err := newLogDirWatcher(logFilePath); err == nil {
+				watcher = w
+				poll.Reset(logWatchPollInterval)
+				lp.setTailDegraded(nil)
+				// 补读重试期间写入的内容
+				lp.readNewLogLines(logFilePath)
+			}
+		case event, ok := <-events:
 			if !ok {
-				return
+				degrade(fmt.Errorf("文件监控已关闭"))
+				continue
 			}
 			// 检查是否是目标日志文件的变化（使用绝对路径比较，兼容不同平台）
 			if event.Op&fsnotify.Write == fsnotify.Write {
@@ -509,16 +748,49 @@ func (lp *LogsPanel) watchLogFile() {
 					lp.readNewLogLines(logFilePath)
 				}
 			}
-		case err, ok := <-lp.fileWatcher.Errors:
+		case err, ok := <-errs:
 			if !ok {
-				return
+				err = fmt.Errorf("文件监控已关闭")
 			}
-			// 忽略监控错误，继续运行
-			_ = err
+			// 监控出错（如事件队列溢出）后通知可能已丢失，补读一次再退化为轮询
+			lp.readNewLogLines(logFilePath)
+			degrade(err)
 		}
 	}
 }
 
+// setTailDegraded 更新文件监控状态：err 不为 nil 表示监控不可用、已退化为轮询，为 nil 表示已恢复。
+// 仅在状态变化时记录日志并更新面板提示。
+func (lp *LogsPanel) setTailDegraded(err error) {
+	degraded := err != nil
+	if degraded == lp.tailDegraded {
+		return
+	}
+	lp.tailDegraded = degraded
+
+	var text string
+	if degraded {
+		text = fmt.Sprintf("日志文件实时监控不可用（%v），已改为每 %.1f 秒轮询，将每 %d 秒自动重试",
+			err, logDegradedPollInterval.Seconds(), int(logWatchRetryInterval.Seconds()))
+		if lp.appState != nil {
+			lp.appState.AppendLog("WARN", "app", text)
+		}
+	} else if lp.appState != nil {
+		lp.appState.AppendLog("INFO", "app", "日志文件实时监控已恢复")
+	}
+	fyne.Do(func() {
+		if lp.tailStatus == nil {
+			return
+		}
+		lp.tailStatus.SetText(text)
+		if degraded {
+			lp.tailStatus.Show()
+		} else {
+			lp.tailStatus.Hide()
+		}
+	})
+}
+
 // readNewLogLines 读取日志文件的新行
 // 注意：此方法主要用于读取直接从文件写入的日志（如xray日志）
 // 通过Logger写入的日志会通过回调直接更新UI，避免重复处理
@@ -577,7 +849,10 @@ func (lp *LogsPanel) Stop() {
 		lp.refreshTimer = nil
 	}
 	lp.refreshTimerMu.Unlock()
-	if lp.fileWatcher != nil {
-		lp.fileWatcher.Close()
+	lp.spillMu.Lock()
+	if lp.spill != nil {
+		lp.spill.Close()
+		lp.spill = nil
 	}
+	lp.spillMu.Unlock()
 }
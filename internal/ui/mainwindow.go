@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
 
 	"fyne.io/fyne/v2"
@@ -10,10 +11,11 @@ import (
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/errs"
 	"myproxy.com/p/internal/logging"
+	"myproxy.com/p/internal/model"
 	"myproxy.com/p/internal/service"
 	"myproxy.com/p/internal/store"
-	"myproxy.com/p/internal/systemproxy"
 )
 
 // proxyModeButtonLayout 自定义布局，确保两个按钮平分宽度
@@ -206,6 +208,11 @@ func (ps *PageStack) Peek() (PageType, bool) {
 	return ps.stack[len(ps.stack)-1], true
 }
 
+// Items 返回栈中页面的副本（栈底在前）
+func (ps *PageStack) Items() []PageType {
+	return append([]PageType(nil), ps.stack...)
+}
+
 // Size 返回栈中页面的数量
 func (ps *PageStack) Size() int {
 	return len(ps.stack)
@@ -257,11 +264,11 @@ const (
 func (m SystemProxyMode) String() string {
 	switch m {
 	case SystemProxyModeClear:
-		return "清除系统代理"
+		return model.SystemProxyModeNameClear
 	case SystemProxyModeAuto:
-		return "自动配置系统代理"
+		return model.SystemProxyModeNameAuto
 	case SystemProxyModeTerminal:
-		return "环境变量代理"
+		return model.SystemProxyModeNameTerminal
 	default:
 		return ""
 	}
@@ -281,14 +288,26 @@ func (m SystemProxyMode) ShortString() string {
 	}
 }
 
+// ServiceMode 返回 ProxyService 使用的模式标识（clear/auto/terminal）
+func (m SystemProxyMode) ServiceMode() string {
+	switch m {
+	case SystemProxyModeAuto:
+		return "auto"
+	case SystemProxyModeTerminal:
+		return "terminal"
+	default:
+		return "clear"
+	}
+}
+
 // ParseSystemProxyMode 从完整模式名称解析 SystemProxyMode
 func ParseSystemProxyMode(fullModeName string) SystemProxyMode {
 	switch fullModeName {
-	case "清除系统代理":
+	case model.SystemProxyModeNameClear:
 		return SystemProxyModeClear
-	case "自动配置系统代理":
+	case model.SystemProxyModeNameAuto:
 		return SystemProxyModeAuto
-	case "环境变量代理":
+	case model.SystemProxyModeNameTerminal:
 		return SystemProxyModeTerminal
 	default:
 		return SystemProxyModeClear // 默认返回清除模式
@@ -331,11 +350,11 @@ type MainWindow struct {
 	homeLogoIcon *widget.Icon // 主页logo图标，用于主题变化时更新
 
 	// 主界面状态UI组件（使用双向绑定）
-	mainToggleButton *CircularButton          // 主开关按钮（连接/断开，圆形，替代了状态显示）
-	serverNameLabel  *widget.Label            // 服务器名称标签（绑定到 ServerNameBinding）
-	proxyModeButtons [2]*widget.Button        // 系统代理模式按钮组（清除、系统）
-	systemProxy      *systemproxy.SystemProxy // 系统代理管理器
-	trafficChart     *TrafficChart            // 实时流量图组件
+	mainToggleButton *CircularButton   // 主开关按钮（连接/断开，圆形，替代了状态显示）
+	verifying        bool              // 启动前验证进行中（忽略主开关重复点击）
+	serverNameLabel  *widget.Label     // 服务器名称标签（绑定到 ServerNameBinding）
+	proxyModeButtons [2]*widget.Button // 系统代理模式按钮组（清除、系统）
+	trafficChart     *TrafficChart     // 实时流量图组件
 
 	// 状态标志
 	systemProxyRestored bool // 标记系统代理状态是否已恢复（避免重复恢复）
@@ -356,9 +375,6 @@ func NewMainWindow(appState *AppState) *MainWindow {
 
 	// 布局配置由 Store 管理，无需在这里加载
 
-	// 创建系统代理管理器（默认使用 localhost:10808）
-	mw.systemProxy = systemproxy.NewSystemProxy("127.0.0.1", 10808)
-
 	return mw
 }
 
@@ -398,14 +414,60 @@ func (mw *MainWindow) Refresh() {
 }
 
 // SaveLayoutConfig 保存当前的布局配置到 Store。
-// 该方法会在窗口关闭时自动调用，以保存用户的布局偏好。
+// 该方法会在窗口关闭时自动调用，以保存用户的布局偏好，以及当前页面、路由栈和节点列表滚动位置。
 func (mw *MainWindow) SaveLayoutConfig() {
 	if mw.appState == nil || mw.appState.Store == nil || mw.appState.Store.Layout == nil {
 		return
 	}
 
+	config := *mw.GetLayoutConfig()
+	config.LastPage = int(mw.currentPage)
+	config.PageStack = nil
+	for _, p := range mw.pageStack.Items() {
+		config.PageStack = append(config.PageStack, int(p))
+	}
+	if mw.settingsPageInstance != nil {
+		config.SettingsMenu = int(mw.settingsPageInstance.currentMenu)
+	}
+	if mw.nodePageInstance != nil && mw.nodePageInstance.list != nil {
+		config.NodeListOffset = mw.nodePageInstance.list.GetScrollOffset()
+	}
+	_ = mw.appState.Store.Layout.Save(&config)
+}
+
+// RestoreLastPage 恢复上次关闭时的页面、路由栈和滚动位置（启动时调用）。
+// 设置了「总是从主界面启动」时不恢复。
+func (mw *MainWindow) RestoreLastPage() {
+	if mw.appState == nil || mw.appState.ConfigService == nil || mw.appState.ConfigService.GetAlwaysStartAtHome() {
+		return
+	}
 	config := mw.GetLayoutConfig()
-	_ = mw.appState.Store.Layout.Save(config)
+	page := PageType(config.LastPage)
+	if page == PageTypeHome || page > PageTypeSubscription {
+		return
+	}
+
+	mw.pageStack.Clear()
+	for _, p := range config.PageStack {
+		if PageType(p) >= PageTypeHome && PageType(p) <= PageTypeSubscription {
+			mw.pageStack.Push(PageType(p))
+		}
+	}
+	if menu := SettingsMenu(config.SettingsMenu); page == PageTypeSettings && mw.settingsPageInstance != nil &&
+		menu >= SettingsMenuAppearance && menu <= SettingsMenuAbout {
+		mw.settingsPageInstance.switchMenu(menu)
+	}
+	mw.navigateToPage(page, false)
+
+	// 节点页：在 navigateToPage 的 scrollToSelected 之后恢复滚动位置
+	if page == PageTypeNode && config.NodeListOffset > 0 && mw.nodePageInstance != nil {
+		offset := config.NodeListOffset
+		fyne.Do(func() {
+			if mw.nodePageInstance.list != nil {
+				mw.nodePageInstance.list.ScrollToOffset(offset)
+			}
+		})
+	}
 }
 
 // Cleanup 清理资源（在窗口关闭时调用）
@@ -511,7 +573,10 @@ func (mw *MainWindow) buildHomePage() fyne.CanvasObject {
 			if savedModeStr != "" {
 				savedMode := ParseSystemProxyMode(savedModeStr)
 				// 应用系统代理设置（不保存到 Store，因为这是从 Store 恢复的）
-				_ = mw.applySystemProxyModeWithoutSave(savedMode)
+				// 「系统」模式需要入站就绪，代理尚未启动时由 applySavedSystemProxyAfterStart 在启动后应用
+				if savedMode != SystemProxyModeAuto || (mw.appState.XrayInstance != nil && mw.appState.XrayInstance.IsRunning()) {
+					_ = mw.applySystemProxyModeWithoutSave(savedMode)
+				}
 			}
 		}
 		mw.systemProxyRestored = true
@@ -593,7 +658,7 @@ func (mw *MainWindow) buildHomePage() fyne.CanvasObject {
 		trafficArea,
 	)
 
-	// 顶部标题栏：左侧logo，右侧设置入口
+	// 顶部标题栏：左侧logo，右侧搜索和设置入口
 	logoResource := createHomeLogo(mw.appState)
 	mw.homeLogoIcon = widget.NewIcon(logoResource)
 	if mw.homeLogoIcon != nil {
@@ -603,6 +668,7 @@ func (mw *MainWindow) buildHomePage() fyne.CanvasObject {
 	headerButtons := container.NewHBox(
 		mw.homeLogoIcon,
 		layout.NewSpacer(),
+		mw.globalSearchButton(),
 		NewButtonWithIcon("设置", theme.SettingsIcon(), func() {
 			mw.ShowSettingsPage()
 		}),
@@ -675,6 +741,11 @@ func (mw *MainWindow) Back() {
 func (mw *MainWindow) navigateToPage(pageType PageType, pushCurrent bool) {
 	var pageContent fyne.CanvasObject
 
+	// 离开节点页时取消正在进行的测速，避免后台任务继续运行并更新其他页面
+	if mw.currentPage == PageTypeNode && pageType != PageTypeNode && mw.nodePageInstance != nil {
+		mw.nodePageInstance.CancelTests()
+	}
+
 	switch pageType {
 	case PageTypeHome:
 		if mw.homePage == nil {
@@ -773,7 +844,7 @@ func (mw *MainWindow) RebuildCurrentPageForTheme() {
 
 // onToggleProxy 主开关按钮回调：启动/停止代理
 func (mw *MainWindow) onToggleProxy() {
-	if mw.appState == nil {
+	if mw.appState == nil || mw.verifying {
 		return
 	}
 
@@ -786,6 +857,10 @@ func (mw *MainWindow) onToggleProxy() {
 	if isRunning {
 		// 停止代理
 		mw.stopProxy()
+	} else if mw.appState.ConfigService != nil && mw.appState.ConfigService.GetVerifyBeforeStart() {
+		// 先验证节点可用，验证结束后再启动
+		mw.verifyAndStartProxy()
+		return
 	} else {
 		// 启动代理（使用当前选中的服务器）
 		mw.startProxy()
@@ -795,6 +870,95 @@ func (mw *MainWindow) onToggleProxy() {
 	mw.refreshHomePageStatus()
 }
 
+// verifyAndStartProxy 启动前验证选中节点：可用则启动；不可用时提示改用备选节点（延迟最低或综合评分最高），
+// 避免把系统切到不可用的代理上。验证期间忽略主开关点击。
+func (mw *MainWindow) verifyAndStartProxy() {
+	if mw.appState.XrayControlService == nil || mw.appState.Store == nil || mw.appState.Store.Nodes == nil {
+		mw.startProxy()
+		mw.refreshHomePageStatus()
+		return
+	}
+	node := mw.appState.Store.Nodes.GetSelected()
+	if node == nil {
+		mw.startProxy()
+		mw.refreshHomePageStatus()
+		return
+	}
+
+	mw.verifying = true
+	showToast(mw.appState, FeedbackInfo, fmt.Sprintf("正在验证节点 %s…", node.Name))
+	target := *node
+	// 在 UI goroutine 中取实例与上下文；窗口关闭或切换节点时上下文被取消，验证结果作废
+	active := mw.appState.XrayInstance
+	ctx := mw.appState.beginVerify()
+	go func() {
+		delay, err := mw.appState.XrayControlService.VerifyNode(ctx, target, active)
+		fyne.Do(func() {
+			mw.verifying = false
+			if ctx.Err() != nil {
+				mw.appState.AppendLog("INFO", "server", fmt.Sprintf("节点 %s 的启动前验证已取消", target.Name))
+				return
+			}
+			mw.appState.CancelVerify()
+			if err == nil {
+				mw.appState.AppendLog("INFO", "server", fmt.Sprintf("节点 %s 验证通过（%d ms）", target.Name, delay))
+				mw.startProxy()
+				mw.refreshHomePageStatus()
+				return
+			}
+			mw.appState.AppendLog("WARN", "server", fmt.Sprintf("节点 %s 验证失败: %v", target.Name, err))
+			mw.appState.NodeHealth.RecordFailure(target.ID)
+			mw.showVerifyFailedDialog(target, err)
+		})
+	}()
+}
+
+// showVerifyFailedDialog 节点验证失败时询问：改用备选节点、仍然启动或取消。
+func (mw *MainWindow) showVerifyFailedDialog(node model.Node, verifyErr error) {
+	alt := mw.appState.XrayControlService.BestAlternativeNode(node.ID, mw.appState.NodeHealth)
+
+	message := widget.NewLabel(fmt.Sprintf("节点 %s 验证失败，启动后可能无法上网。\n原因: %v", node.Name, verifyErr))
+	message.Wrapping = fyne.TextWrapWord
+	content := container.NewVBox(message)
+
+	var d dialog.Dialog
+	buttons := container.NewHBox(layout.NewSpacer())
+	cancelBtn := widget.NewButton("取消", func() { d.Hide() })
+	forceBtn := widget.NewButton("仍然启动", func() {
+		d.Hide()
+		mw.startProxy()
+		mw.refreshHomePageStatus()
+	})
+	buttons.Add(cancelBtn)
+	buttons.Add(forceBtn)
+	if alt != nil {
+		basis := "延迟最低"
+		if mw.appState.ConfigService != nil && mw.appState.ConfigService.GetNodeScoreWeights().UseForSelection {
+			basis = "综合评分最高"
+		}
+		content.Add(widget.NewLabel(fmt.Sprintf("可改用%s的节点: %s（%d ms）", basis, alt.Name, alt.Delay)))
+		altID := alt.ID
+		altBtn := widget.NewButton("改用该节点", func() {
+			d.Hide()
+			if err := mw.appState.Store.SelectServer(altID); err != nil {
+				mw.logAndShowError("切换节点失败", err)
+				return
+			}
+			// 备选节点同样先验证，不可用时会再次提示
+			mw.verifyAndStartProxy()
+		})
+		altBtn.Importance = widget.HighImportance
+		buttons.Add(altBtn)
+	} else {
+		content.Add(widget.NewLabel("没有可用的备选节点，可先在节点页测速。"))
+	}
+	content.Add(buttons)
+
+	d = dialog.NewCustomWithoutButtons("节点不可用", content, mw.appState.Window)
+	d.Resize(fyne.NewSize(420, 0))
+	d.Show()
+}
+
 // refreshHomePageStatus 刷新主界面状态显示
 func (mw *MainWindow) refreshHomePageStatus() {
 	if mw.appState != nil {
@@ -814,7 +978,7 @@ func (mw *MainWindow) startProxy() {
 		return
 	}
 
-	if mw.appState.XrayControlService == nil {
+	if mw.appState.proxyControl() == nil {
 		mw.logAndShowError("启动代理失败", fmt.Errorf("XrayControlService 未初始化"))
 		return
 	}
@@ -826,8 +990,14 @@ func (mw *MainWindow) startProxy() {
 	}
 
 	// 调用 service 启动代理
-	result := mw.appState.XrayControlService.StartProxy(mw.appState.XrayInstance, unifiedLogPath)
+	result := mw.appState.proxyControl().StartProxy(mw.appState.XrayInstance, unifiedLogPath)
 
+	if errors.Is(result.Error, errs.ErrNoNodeSelected) {
+		// 未选节点不是故障，轻提示即可
+		showToast(mw.appState, FeedbackWarning, "请先在节点列表中选择一个节点")
+		mw.appState.UpdateProxyStatus()
+		return
+	}
 	if result.Error != nil {
 		mw.logAndShowError("启动代理失败", result.Error)
 		if mw.appState != nil {
@@ -837,7 +1007,8 @@ func (mw *MainWindow) startProxy() {
 	}
 
 	// 启动成功，更新 AppState 中的 XrayInstance
-	mw.appState.XrayInstance = result.XrayInstance
+	mw.appState.SetXrayInstance(result.XrayInstance)
+	mw.appState.UsageStatsService.Record(model.UsageFeatureProxyStart)
 
 	// 更新 ProxyService 的 xray 实例引用
 	if mw.appState.ProxyService != nil {
@@ -868,12 +1039,13 @@ func (mw *MainWindow) startProxy() {
 		mw.nodePageInstance.Refresh()
 	}
 
-	// 显示成功对话框
-	if mw.appState.Window != nil && result.XrayInstance != nil {
-		selectedNode := mw.appState.Store.Nodes.GetSelected()
-		if selectedNode != nil {
-			message := fmt.Sprintf("代理已启动\n节点: %s\n端口: %d", selectedNode.Name, result.XrayInstance.GetPort())
-			dialog.ShowInformation("代理启动成功", message, mw.appState.Window)
+	// 入站就绪后再应用已保存的系统代理模式
+	mw.applySavedSystemProxyAfterStart()
+
+	// 显示成功提示
+	if result.XrayInstance != nil {
+		if selectedNode := mw.appState.Store.Nodes.GetSelected(); selectedNode != nil {
+			showToast(mw.appState, FeedbackSuccess, fmt.Sprintf("代理已启动: %s（端口 %d）", selectedNode.Name, result.XrayInstance.GetPort()))
 		}
 	}
 }
@@ -892,13 +1064,13 @@ func (mw *MainWindow) stopProxy() {
 		return
 	}
 
-	if mw.appState.XrayControlService == nil {
+	if mw.appState.proxyControl() == nil {
 		mw.logAndShowError("停止代理失败", fmt.Errorf("XrayControlService 未初始化"))
 		return
 	}
 
 	// 调用 service 停止代理
-	result := mw.appState.XrayControlService.StopProxy(mw.appState.XrayInstance)
+	result := mw.appState.proxyControl().StopProxy(mw.appState.XrayInstance)
 
 	if result.Error != nil {
 		mw.logAndShowError("停止代理失败", result.Error)
@@ -906,7 +1078,7 @@ func (mw *MainWindow) stopProxy() {
 	}
 
 	// 停止成功，销毁实例（生命周期 = 代理运行生命周期）
-	mw.appState.XrayInstance = nil
+	mw.appState.SetXrayInstance(nil)
 
 	// 记录日志（统一日志记录）
 	if mw.appState.Logger != nil {
@@ -926,13 +1098,11 @@ func (mw *MainWindow) stopProxy() {
 		mw.nodePageInstance.Refresh()
 	}
 
-	// 显示成功对话框
-	if mw.appState.Window != nil {
-		if result.LogMessage == "代理未运行" {
-			dialog.ShowInformation("提示", "代理未运行", mw.appState.Window)
-		} else {
-			dialog.ShowInformation("代理停止成功", "代理已停止", mw.appState.Window)
-		}
+	// 显示提示
+	if result.LogMessage == "代理未运行" {
+		showToast(mw.appState, FeedbackInfo, "代理未运行")
+	} else {
+		showToast(mw.appState, FeedbackSuccess, "代理已停止")
 	}
 }
 
@@ -943,16 +1113,7 @@ func (mw *MainWindow) RefreshMainToggleButton() {
 
 // logAndShowError 记录日志并显示错误（统一错误处理）
 func (mw *MainWindow) logAndShowError(message string, err error) {
-	if mw.appState != nil && mw.appState.Logger != nil {
-		mw.appState.Logger.Error("%s: %v", message, err)
-	}
-	if mw.appState != nil && mw.appState.Window != nil {
-		errorMsg := fmt.Errorf("%s: %w", message, err)
-		dialog.ShowError(errorMsg, mw.appState.Window)
-	}
-	if mw.appState != nil {
-		mw.appState.AppendLog("ERROR", "app", fmt.Sprintf("%s: %v", message, err))
-	}
+	showErrorDetail(mw.appState, message, err)
 }
 
 // 注意：updateStatusIcon 已移除，因为圆形按钮已经替代了状态图标显示
@@ -1024,79 +1185,19 @@ func (mw *MainWindow) applySystemProxyModeCore(mode SystemProxyMode, saveToStore
 		return fmt.Errorf("appState 未初始化")
 	}
 
-	// 获取当前代理端口
-	proxyPort := 10808
-	if mw.appState.XrayInstance != nil && mw.appState.XrayInstance.IsRunning() {
-		if port := mw.appState.XrayInstance.GetPort(); port > 0 {
-			proxyPort = port
-		}
-	}
-
-	// 确保 SystemProxy 实例已创建
-	if mw.systemProxy == nil {
-		mw.systemProxy = systemproxy.NewSystemProxy("127.0.0.1", proxyPort)
-	} else {
-		mw.systemProxy.UpdateProxy("127.0.0.1", proxyPort)
+	// 系统代理的实际设置统一由 ProxyService 完成，UI 不直接操作 systemproxy
+	if mw.appState.ProxyService == nil {
+		mw.appState.ProxyService = service.NewProxyService(mw.appState.XrayInstance, mw.appState.ConfigService)
 	}
 
 	var err error
 	var logMessage string
 
 	switch mode {
-	case SystemProxyModeClear:
-		err = mw.systemProxy.ClearSystemProxy()
-		// 根据设置页面的配置决定是否清除终端代理
-		shouldClearTerminal := false
-		if mw.appState != nil && mw.appState.ConfigService != nil {
-			shouldClearTerminal = mw.appState.ConfigService.GetTerminalProxyEnabled()
-		}
-		if shouldClearTerminal {
-			terminalErr := mw.systemProxy.ClearTerminalProxy()
-			if err == nil && terminalErr == nil {
-				logMessage = "已清除系统代理设置和环境变量代理"
-			} else if err != nil && terminalErr != nil {
-				logMessage = fmt.Sprintf("清除系统代理失败: %v; 清除环境变量代理失败: %v", err, terminalErr)
-				err = fmt.Errorf("清除失败: %v; %v", err, terminalErr)
-			} else if err != nil {
-				logMessage = fmt.Sprintf("清除系统代理失败: %v; 已清除环境变量代理", err)
-			} else {
-				logMessage = fmt.Sprintf("已清除系统代理设置; 清除环境变量代理失败: %v", terminalErr)
-				err = terminalErr
-			}
-		} else {
-			if err == nil {
-				logMessage = "已清除系统代理设置"
-			} else {
-				logMessage = fmt.Sprintf("清除系统代理失败: %v", err)
-			}
-		}
-
-	case SystemProxyModeAuto:
-		_ = mw.systemProxy.ClearSystemProxy()
-		// 根据设置页面的配置决定是否设置终端代理
-		shouldSetTerminal := false
-		if mw.appState != nil && mw.appState.ConfigService != nil {
-			shouldSetTerminal = mw.appState.ConfigService.GetTerminalProxyEnabled()
-		}
-		err = mw.systemProxy.SetSystemProxy()
-		if err == nil {
-			logMessage = fmt.Sprintf("已自动配置系统代理: 127.0.0.1:%d", proxyPort)
-			if shouldSetTerminal {
-				// 获取代理类型
-				proxyType := "socks5"
-				if mw.appState != nil && mw.appState.ConfigService != nil {
-					proxyType = mw.appState.ConfigService.GetProxyType()
-				}
-				terminalErr := mw.systemProxy.SetTerminalProxy(proxyType)
-				if terminalErr == nil {
-					logMessage += "；已设置环境变量代理"
-				} else {
-					logMessage += fmt.Sprintf("；设置环境变量代理失败: %v", terminalErr)
-				}
-			}
-		} else {
-			logMessage = fmt.Sprintf("自动配置系统代理失败: %v", err)
-		}
+	case SystemProxyModeClear, SystemProxyModeAuto:
+		result := mw.appState.ProxyService.ApplySystemProxyMode(mode.ServiceMode())
+		logMessage = result.LogMessage
+		err = result.Error
 
 	default:
 		logMessage = fmt.Sprintf("未知的系统代理模式: %s", mode.String())
@@ -1105,15 +1206,9 @@ func (mw *MainWindow) applySystemProxyModeCore(mode SystemProxyMode, saveToStore
 
 	// 输出日志
 	if err == nil {
-		mw.appState.AppendLog("INFO", "app", logMessage)
-		if mw.appState.Logger != nil {
-			mw.appState.Logger.InfoWithType(logging.LogTypeApp, "%s", logMessage)
-		}
+		mw.appState.AppendLog("INFO", "systemproxy", logMessage)
 	} else {
-		mw.appState.AppendLog("ERROR", "app", logMessage)
-		if mw.appState.Logger != nil {
-			mw.appState.Logger.Error("%s", logMessage)
-		}
+		mw.appState.AppendLog("ERROR", "systemproxy", logMessage)
 	}
 
 	// 保存状态到 Store（如果需要）
@@ -1125,7 +1220,7 @@ func (mw *MainWindow) applySystemProxyModeCore(mode SystemProxyMode, saveToStore
 }
 
 // onProxyModeButtonClicked 系统代理模式按钮点击处理
-// 直接调用 systemproxy 方法设置系统代理，不启动代理
+// 通过 ProxyService 设置系统代理，不启动代理
 func (mw *MainWindow) onProxyModeButtonClicked(mode SystemProxyMode) {
 	if mw.appState == nil {
 		return
@@ -1136,6 +1231,7 @@ func (mw *MainWindow) onProxyModeButtonClicked(mode SystemProxyMode) {
 }
 
 // SetSystemProxyMode 设置系统代理模式（公共方法，供托盘等外部调用）
+// 将修改尚未确认过的系统设置时，先列出改动请用户确认，确认后再应用（此时返回 nil）。
 // 参数：
 //   - mode: 系统代理模式
 func (mw *MainWindow) SetSystemProxyMode(mode SystemProxyMode) error {
@@ -1143,11 +1239,21 @@ func (mw *MainWindow) SetSystemProxyMode(mode SystemProxyMode) error {
 		return fmt.Errorf("appState 未初始化")
 	}
 
+	if changes := mw.appState.unconfirmedSystemChanges(mode); len(changes) > 0 {
+		showSystemChangesConfirm(mw.appState, changes, func() { _ = mw.setSystemProxyMode(mode) })
+		return nil
+	}
+	return mw.setSystemProxyMode(mode)
+}
+
+// setSystemProxyMode 应用并保存系统代理模式，同步按钮和托盘菜单状态。
+func (mw *MainWindow) setSystemProxyMode(mode SystemProxyMode) error {
 	// 更新按钮选中状态（如果按钮已创建）
 	mw.updateProxyModeButtonsState(mode)
 
 	// 应用系统代理模式（保存到 Store）
 	err := mw.applySystemProxyModeCore(mode, true)
+	mw.appState.UsageStatsService.Record(model.UsageFeatureSystemProxy)
 	mw.appState.refreshTrayProxyMenu()
 	return err
 }
@@ -1196,22 +1302,6 @@ func (mw *MainWindow) applySystemProxyMode(mode SystemProxyMode) error {
 	return mw.applySystemProxyModeCore(mode, true)
 }
 
-// updateSystemProxyPort 更新系统代理管理器的端口
-func (mw *MainWindow) updateSystemProxyPort() {
-	if mw.appState == nil {
-		return
-	}
-
-	proxyPort := 10808
-	if mw.appState.XrayInstance != nil && mw.appState.XrayInstance.IsRunning() {
-		if port := mw.appState.XrayInstance.GetPort(); port > 0 {
-			proxyPort = port
-		}
-	}
-
-	mw.systemProxy = systemproxy.NewSystemProxy("127.0.0.1", proxyPort)
-}
-
 // saveSystemProxyState 保存系统代理状态到数据库
 func (mw *MainWindow) saveSystemProxyState(mode SystemProxyMode) {
 	if mw.appState == nil || mw.appState.ConfigService == nil {
@@ -1220,13 +1310,23 @@ func (mw *MainWindow) saveSystemProxyState(mode SystemProxyMode) {
 	// 保存完整模式名称字符串到 Store
 	if err := mw.appState.ConfigService.SetSystemProxyMode(mode.String()); err != nil {
 		if mw.appState.Logger != nil {
-			mw.appState.Logger.Error("保存系统代理状态失败: %v", err)
+			mw.appState.Logger.ErrorWithType(logging.LogTypeSystemProxy, "保存系统代理状态失败: %v", err)
 		}
 	}
 }
 
+// applySavedSystemProxyAfterStart 代理启动后，如已保存的模式为「系统」则应用（经过入站就绪检查）。
+func (mw *MainWindow) applySavedSystemProxyAfterStart() {
+	if mw.GetCurrentSystemProxyMode() != SystemProxyModeAuto {
+		return
+	}
+	if err := mw.applySystemProxyModeWithoutSave(SystemProxyModeAuto); err == nil {
+		mw.appState.refreshTrayProxyMenu()
+	}
+}
+
 // applySystemProxyModeWithoutSave 应用系统代理模式但不保存到 Store（用于恢复时避免重复保存）
-// 直接调用 systemproxy 方法，不通过 ProxyService
+// 同样通过 ProxyService 应用，仅跳过保存
 func (mw *MainWindow) applySystemProxyModeWithoutSave(mode SystemProxyMode) error {
 	// 使用核心方法，但不保存到 Store
 	return mw.applySystemProxyModeCore(mode, false)
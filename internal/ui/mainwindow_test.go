@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/service"
+	"myproxy.com/p/internal/xray"
+)
+
+func TestProxyModeSwitchSavesMode(t *testing.T) {
+	appState := newTestAppState(t)
+	mw := newTestMainWindow(t, appState)
+	showInTestWindow(appState, mw.buildHomePage())
+
+	// 代理未运行：切换到「系统」模式时不修改系统设置（入站就绪检查失败），但保存模式供代理启动后应用
+	test.Tap(mw.proxyModeButtons[1])
+
+	if got := appState.ConfigService.GetSystemProxyMode(); got != SystemProxyModeAuto.String() {
+		t.Errorf("保存的系统代理模式 = %q，期望 %q", got, SystemProxyModeAuto.String())
+	}
+	if got := mw.GetCurrentSystemProxyMode(); got != SystemProxyModeAuto {
+		t.Errorf("当前系统代理模式 = %v，期望 %v", got, SystemProxyModeAuto)
+	}
+	if mw.proxyModeButtons[1].Importance != widget.HighImportance || mw.proxyModeButtons[0].Importance != widget.LowImportance {
+		t.Errorf("模式按钮高亮状态未随切换更新")
+	}
+	if changes := appState.ProxyService.SystemChanges(); len(changes) != 0 {
+		t.Errorf("入站未就绪时不应记录系统改动，实际 %v", changes)
+	}
+}
+
+// fakeProxyController 代理启停桩实现：不连接节点，启动时创建一个没有入站的空 xray 实例。
+type fakeProxyController struct {
+	t      *testing.T
+	starts int
+	stops  int
+}
+
+func (f *fakeProxyController) StartProxy(oldInstance *xray.XrayInstance, logFilePath string) *service.StartProxyResult {
+	f.starts++
+	inst, err := xray.NewXrayInstanceFromJSON([]byte(`{"outbounds":[{"protocol":"freedom"}]}`))
+	if err == nil {
+		err = inst.Start()
+	}
+	if err != nil {
+		f.t.Fatalf("创建测试 xray 实例失败: %v", err)
+	}
+	f.t.Cleanup(func() { _ = inst.Stop() })
+	inst.SetPort(10808)
+	return &service.StartProxyResult{XrayInstance: inst}
+}
+
+func (f *fakeProxyController) StopProxy(instance *xray.XrayInstance) *service.StopProxyResult {
+	f.stops++
+	if instance == nil || !instance.IsRunning() {
+		return &service.StopProxyResult{LogMessage: "代理未运行"}
+	}
+	if err := instance.Stop(); err != nil {
+		return &service.StopProxyResult{Error: err}
+	}
+	return &service.StopProxyResult{}
+}
+
+func TestMainToggleStartsAndStopsProxy(t *testing.T) {
+	appState := newTestAppState(t, model.Node{ID: "node-a", Name: "香港 01", ProtocolType: "socks5", Addr: "10.0.0.1", Port: 1080, Enabled: true})
+	if err := appState.Store.SelectServer("node-a"); err != nil {
+		t.Fatalf("选中节点失败: %v", err)
+	}
+	if err := appState.ConfigService.SetVerifyBeforeStart(false); err != nil {
+		t.Fatalf("保存配置失败: %v", err)
+	}
+	fake := &fakeProxyController{t: t}
+	appState.proxyCtl = fake
+
+	mw := newTestMainWindow(t, appState)
+	showInTestWindow(appState, mw.buildHomePage())
+
+	test.Tap(mw.mainToggleButton)
+	if fake.starts != 1 {
+		t.Fatalf("启动次数 = %d，期望 1", fake.starts)
+	}
+	if inst := appState.XrayInstance; inst == nil || !inst.IsRunning() {
+		t.Fatal("点击主开关后代理未处于运行状态")
+	}
+	if !mw.mainToggleButton.isActive {
+		t.Error("代理运行时主开关未显示为开启")
+	}
+
+	test.Tap(mw.mainToggleButton)
+	if fake.stops != 1 {
+		t.Fatalf("停止次数 = %d，期望 1", fake.stops)
+	}
+	if appState.XrayInstance != nil {
+		t.Error("停止代理后仍保留 xray 实例")
+	}
+	if mw.mainToggleButton.isActive {
+		t.Error("代理停止后主开关仍显示为开启")
+	}
+}
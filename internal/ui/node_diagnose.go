@@ -0,0 +1,111 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/service"
+)
+
+// showNodeDiagnoseDialog 自动诊断节点：逐个尝试传输组合并实时显示结果，
+// 可连通的组合可一键应用到节点，应用后调用 onApplied（可为 nil）。关闭对话框即停止诊断。
+func showNodeDiagnoseDialog(appState *AppState, node model.Node, onApplied func()) {
+	if appState == nil || appState.Window == nil || appState.XrayControlService == nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	progress := widget.NewProgressBarInfinite()
+	statusLabel := widget.NewLabel("正在尝试不同的传输组合（TLS / SNI / ALPN / ws·tcp）…")
+	statusLabel.Wrapping = fyne.TextWrapWord
+	resultsBox := container.NewVBox()
+	scroll := container.NewVScroll(resultsBox)
+	scroll.SetMinSize(fyne.NewSize(460, 280))
+
+	var d dialog.Dialog
+	apply := func(r service.DiagnoseResult) {
+		if err := appState.ServerService.SaveUserEdit(r.Node); err != nil {
+			showErrorDetail(appState, "应用诊断结果失败", err)
+			return
+		}
+		// 诊断时覆盖值已写入解析字段，同步更新覆盖参数，避免旧的覆盖掩盖诊断结果
+		if node.HasOverrides() {
+			if err := appState.ServerService.UpdateOverrides(r.Node.ID, r.Node.OverrideSNI, r.Node.OverrideHost, r.Node.OverridePath); err != nil {
+				showErrorDetail(appState, "应用诊断结果失败", err)
+				return
+			}
+		}
+		if r.Node.Selected {
+			appState.ReloadProxy("节点传输配置已按诊断结果修改")
+		}
+		showToast(appState, FeedbackSuccess, fmt.Sprintf("已应用到节点 %s: %s", node.Name, r.Label))
+		d.Hide()
+		if onApplied != nil {
+			onApplied()
+		}
+	}
+
+	addRow := func(r service.DiagnoseResult) {
+		icon := widget.NewIcon(theme.ErrorIcon())
+		detail := r.Err.Error()
+		if r.OK() {
+			icon.SetResource(theme.ConfirmIcon())
+			detail = fmt.Sprintf("%d ms", r.Delay)
+		}
+		label := widget.NewLabel(r.Label)
+		label.Wrapping = fyne.TextWrapWord
+		detailLabel := widget.NewLabel(detail)
+		detailLabel.Importance = widget.LowImportance
+		detailLabel.Wrapping = fyne.TextWrapWord
+
+		var right fyne.CanvasObject
+		if r.OK() && !r.Original {
+			applyBtn := widget.NewButton("应用", func() { apply(r) })
+			applyBtn.Importance = widget.HighImportance
+			right = applyBtn
+		}
+		resultsBox.Add(container.NewBorder(nil, nil, icon, right, container.NewVBox(label, detailLabel)))
+	}
+
+	d = dialog.NewCustom("自动诊断: "+node.Name, "关闭", container.NewBorder(
+		container.NewVBox(statusLabel, progress), nil, nil, nil, scroll,
+	), appState.Window)
+	d.SetOnClosed(cancel)
+	d.Show()
+
+	active := appState.XrayInstance
+	go func() {
+		results := appState.XrayControlService.DiagnoseNode(ctx, node, active, func(r service.DiagnoseResult) {
+			fyne.Do(func() { addRow(r) })
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		okCount := 0
+		originalOK := false
+		for _, r := range results {
+			if r.OK() {
+				okCount++
+				originalOK = originalOK || r.Original
+			}
+		}
+		fyne.Do(func() {
+			progress.Stop()
+			progress.Hide()
+			switch {
+			case originalOK:
+				statusLabel.SetText("原始配置可以连通，节点配置无需修改。")
+			case okCount > 0:
+				statusLabel.SetText(fmt.Sprintf("原始配置无法连通，找到 %d 个可用组合，可点击「应用」修正节点配置。", okCount))
+			default:
+				statusLabel.SetText("所有组合均无法连通，问题可能不在传输配置（如节点失效、凭据错误或网络受限）。")
+			}
+		})
+	}()
+}
@@ -1,20 +1,22 @@
 package ui
 
 import (
+	"context"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
-	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
-	"myproxy.com/p/internal/database"
 	"myproxy.com/p/internal/logging"
 	"myproxy.com/p/internal/model"
 	"myproxy.com/p/internal/service"
+	"myproxy.com/p/internal/utils"
 )
 
 // NodePage 管理服务器列表的显示和操作。
@@ -29,25 +31,54 @@ type NodePage struct {
 	searchEntry *widget.Entry // 节点搜索输入框
 	searchText  string        // 当前搜索关键字（小写）
 
+	// 过滤条件：与搜索关键字一起组成数据库查询（model.NodeQuery）
+	subscriptionSelect *widget.Select   // 按订阅过滤
+	protocolSelect     *widget.Select   // 按协议过滤
+	enabledSelect      *widget.Select   // 按启用状态过滤
+	subscriptionIDs    map[string]int64 // 订阅选项 → 订阅 ID（手动添加为 0）
+
+	// 查询结果缓存：列表按页从数据库读取，每行渲染只取所在页；过滤条件或节点数据变化时失效
+	nodePages  map[int][]*model.Node
+	nodeTotal  int
+	pagesValid bool
+
 	// UI 组件
 	selectedServerLabel *widget.Label // 当前选中服务器名标签
+
+	// 测速相关：测速与页面绑定，离开页面时取消；进度在页面底部内联显示
+	testCancel   context.CancelFunc   // 取消当前测速
+	testProgress *widget.ProgressBar  // 测速进度条
+	testStatus   *widget.Label        // 测速状态文本
+	testBar      *fyne.Container      // 进度条 + 状态文本容器
+
+	recentBar *fyne.Container // 最近使用节点快捷栏（无历史时隐藏）
+
+	scoreWeights model.NodeScoreWeights // 节点综合评分权重（评分列使用，Refresh 时重新读取）
 }
 
 // NewNodePage 创建节点管理页面
 func NewNodePage(appState *AppState) *NodePage {
 	np := &NodePage{
-		appState: appState,
+		appState:     appState,
+		scoreWeights: model.DefaultNodeScoreWeights(),
+	}
+	if appState != nil && appState.ConfigService != nil {
+		np.scoreWeights = appState.ConfigService.GetNodeScoreWeights()
 	}
 
-	// 监听 Store 的节点绑定数据变化，自动刷新列表
+	// 监听 Store 的节点数据变化，自动刷新列表。
+	// 使用 fyne.Do 确保 UI 刷新在主线程执行（节点可能在 goroutine 中重新加载）
 	if appState != nil && appState.Store != nil && appState.Store.Nodes != nil {
-		appState.Store.Nodes.NodesBinding.AddListener(binding.NewDataListener(func() {
-			if np.list != nil {
-				np.list.Refresh()
-				// 数据更新后，尝试滚动到选中位置
-				np.scrollToSelected()
-			}
-		}))
+		appState.Store.Nodes.AddListener(func() {
+			fyne.Do(func() {
+				np.pagesValid = false
+				if np.list != nil {
+					np.list.Refresh()
+					// 数据更新后，尝试滚动到选中位置
+					np.scrollToSelected()
+				}
+			})
+		})
 	}
 
 	return np
@@ -97,10 +128,24 @@ func (np *NodePage) Build() fyne.CanvasObject {
 	})
 	subscriptionBtn.Importance = widget.LowImportance
 
+	// 冒烟测试：经当前选中节点完成 HTTP 请求和 DNS 查询，一键确认节点是否真正可用
+	smokeBtn := widget.NewButtonWithIcon("冒烟测试", theme.MediaPlayIcon(), func() {
+		if np.appState == nil || np.appState.Store == nil || np.appState.Store.Nodes == nil {
+			return
+		}
+		node := np.appState.Store.Nodes.GetSelected()
+		if node == nil {
+			showToast(np.appState, FeedbackWarning, "请先选择节点")
+			return
+		}
+		showSmokeTestDialog(np.appState, *node)
+	})
+	smokeBtn.Importance = widget.LowImportance
+
 	// 4. 头部栏布局（返回按钮 + 选中服务器标签 + 操作按钮）
 	// 使用 Border 布局让 labelContainer 自动占满剩余空间
 	labelContainer := container.NewPadded(np.selectedServerLabel)
-	rightButtons := container.NewHBox(testAllBtn, subscriptionBtn)
+	rightButtons := container.NewHBox(testAllBtn, smokeBtn, subscriptionBtn)
 	headerBar := container.NewBorder(
 		nil, nil, // 上下为空
 		backBtn,        // 左侧：返回按钮
@@ -146,6 +191,14 @@ func (np *NodePage) Build() fyne.CanvasObject {
 		np.searchEntry, // 移除 padding 降低搜索框高度
 	)
 
+	// 过滤栏：订阅、协议、启用状态，选项随节点和订阅变化在 Refresh 时更新
+	np.subscriptionSelect = widget.NewSelect(nil, func(string) { np.applyFilter() })
+	np.protocolSelect = widget.NewSelect(nil, func(string) { np.applyFilter() })
+	np.enabledSelect = widget.NewSelect([]string{nodeFilterAllStates, nodeFilterEnabled, nodeFilterDisabled}, func(string) { np.applyFilter() })
+	np.enabledSelect.Selected = nodeFilterAllStates
+	np.updateFilterOptions()
+	filterBar := container.NewGridWithColumns(3, np.subscriptionSelect, np.protocolSelect, np.enabledSelect)
+
 	// 6. 表格头（与列表项对齐，使用最小高度）
 	regionHeader := widget.NewLabel("地区")
 	regionHeader.Alignment = fyne.TextAlignCenter
@@ -157,7 +210,7 @@ func (np *NodePage) Build() fyne.CanvasObject {
 	nameHeader.TextStyle = fyne.TextStyle{Bold: true}
 	nameHeader.Importance = widget.MediumImportance
 
-	delayHeader := widget.NewLabel("延迟")
+	delayHeader := widget.NewLabel("延迟 / 评分")
 	delayHeader.Alignment = fyne.TextAlignTrailing
 	delayHeader.TextStyle = fyne.TextStyle{Bold: true}
 	delayHeader.Importance = widget.MediumImportance
@@ -180,16 +233,33 @@ func (np *NodePage) Build() fyne.CanvasObject {
 	// 包装在滚动容器中并设置最小尺寸确保布局占满
 	np.scrollList = container.NewScroll(np.list)
 
-	// 8. 组合布局：头部 + 搜索栏 + 表头 + 列表
+	// 8. 最近使用节点快捷栏（搜索栏下方，点击即选中）
+	np.recentBar = container.NewHBox()
+	np.updateRecentBar()
+
+	// 9. 测速进度条（默认隐藏，测速时在列表下方显示）
+	np.testProgress = widget.NewProgressBar()
+	np.testStatus = widget.NewLabel("")
+	np.testStatus.Truncation = fyne.TextTruncateEllipsis
+	cancelTestBtn := widget.NewButtonWithIcon("", theme.CancelIcon(), np.CancelTests)
+	cancelTestBtn.Importance = widget.LowImportance
+	np.testBar = container.NewBorder(nil, nil, nil, cancelTestBtn,
+		container.NewVBox(np.testStatus, np.testProgress))
+	np.testBar.Hide()
+
+	// 10. 组合布局：头部 + 搜索栏 + 最近使用 + 表头 + 列表 + 测速进度
 	// 移除所有不必要的 padding，降低高度
 	np.content = container.NewBorder(
 		container.NewVBox(
 			headerStack,
-			searchBar,   // 移除 padding
+			searchBar, // 移除 padding
+			filterBar,
+			np.recentBar,
 			tableHeader, // 表头直接放置，不添加额外 padding
 			canvas.NewLine(separatorColor),
 		),
-		nil, nil, nil,
+		np.testBar,
+		nil, nil,
 		container.NewPadded(np.scrollList),
 	)
 
@@ -198,8 +268,14 @@ func (np *NodePage) Build() fyne.CanvasObject {
 
 // Refresh 刷新节点列表的显示，使 UI 反映最新的节点数据。
 func (np *NodePage) Refresh() {
+	np.pagesValid = false
+	if np.appState != nil && np.appState.ConfigService != nil {
+		np.scoreWeights = np.appState.ConfigService.GetNodeScoreWeights()
+	}
 	np.loadNodes()
+	np.updateFilterOptions()
 	np.updateSelectedServerLabel() // 更新选中服务器标签
+	np.updateRecentBar()           // 更新最近使用节点
 	// 绑定数据更新后会自动触发列表刷新，无需手动调用
 	if np.list != nil {
 		np.list.Refresh()
@@ -218,16 +294,27 @@ func (np *NodePage) scrollToSelected() {
 		return
 	}
 
-	// 在过滤后的节点列表中找到选中节点的索引
-	nodes := np.getFilteredNodes()
-	for i, node := range nodes {
-		if node.ID == selectedID {
-			// 滚动到该位置（Fyne v2 的 widget.List 支持 ScrollTo 方法）
-			// 使用 widget.ListItemID 类型（即 int）
+	// 在过滤结果中找到选中节点的行号并滚动到该位置
+	if i := np.indexOfNode(selectedID); i >= 0 {
+		np.list.ScrollTo(widget.ListItemID(i))
+	}
+}
+
+// FocusNode 清除搜索和过滤条件并滚动到指定节点（全局搜索跳转使用）。
+func (np *NodePage) FocusNode(nodeID string) {
+	if np.list == nil {
+		return
+	}
+	np.clearFilters()
+	if np.searchEntry != nil && np.searchEntry.Text != "" {
+		np.searchEntry.SetText("") // 触发 OnChanged 清空过滤并刷新
+	}
+	// 与 navigateToPage 中的 scrollToSelected 一样延迟执行，确保在其之后滚动
+	fyne.Do(func() {
+		if i := np.indexOfNode(nodeID); i >= 0 {
 			np.list.ScrollTo(widget.ListItemID(i))
-			return
 		}
-	}
+	})
 }
 
 // updateSelectedServerLabel 更新当前选中服务器名标签
@@ -253,40 +340,201 @@ func (np *NodePage) updateSelectedServerLabel() {
 	np.selectedServerLabel.Importance = widget.MediumImportance
 }
 
-// getNodeCount 获取节点数量
+// recentNodeLimit 最近使用快捷栏显示的节点数量
+const recentNodeLimit = 3
+
+// updateRecentBar 根据连接历史重建最近使用节点快捷栏。
+func (np *NodePage) updateRecentBar() {
+	if np.recentBar == nil {
+		return
+	}
+	var recent []*model.Node
+	if np.appState != nil && np.appState.Store != nil && np.appState.Store.Nodes != nil {
+		recent = np.appState.Store.Nodes.GetRecent(recentNodeLimit)
+	}
+
+	np.recentBar.RemoveAll()
+	if len(recent) == 0 {
+		np.recentBar.Hide()
+		return
+	}
+
+	np.recentBar.Add(widget.NewIcon(theme.HistoryIcon()))
+	for _, node := range recent {
+		nodeID := node.ID
+		name := []rune(node.Name)
+		if len(name) > 10 {
+			name = append(name[:10], '…') // 名称过长时截断，避免撑开页面宽度
+		}
+		btn := widget.NewButton(string(name), func() { np.selectNodeByID(nodeID) })
+		btn.Importance = widget.LowImportance
+		np.recentBar.Add(btn)
+	}
+	np.recentBar.Show()
+	np.recentBar.Refresh()
+}
+
+// 过滤选项
+const (
+	nodeFilterAllSubscriptions = "全部订阅"
+	nodeFilterManual           = "手动添加"
+	nodeFilterAllProtocols     = "全部协议"
+	nodeFilterAllStates        = "全部状态"
+	nodeFilterEnabled          = "已启用"
+	nodeFilterDisabled         = "已停用"
+)
+
+// nodePageSize 节点列表每次从数据库读取的条数，滚动到未读取的行时再读取所在页
+const nodePageSize = 200
+
+// getNodeCount 获取满足当前过滤条件的节点数量
 func (np *NodePage) getNodeCount() int {
-	return len(np.getFilteredNodes())
+	np.nodePage(0)
+	return np.nodeTotal
+}
+
+// nodeQuery 根据搜索关键字和过滤选项生成节点查询条件（不含分页）。
+func (np *NodePage) nodeQuery() model.NodeQuery {
+	q := model.NodeQuery{Text: np.searchText}
+	if np.subscriptionSelect != nil {
+		if id, ok := np.subscriptionIDs[np.subscriptionSelect.Selected]; ok {
+			q.SubscriptionID = &id
+		}
+	}
+	if np.protocolSelect != nil && np.protocolSelect.Selected != nodeFilterAllProtocols {
+		q.Protocol = np.protocolSelect.Selected
+	}
+	if np.enabledSelect != nil && np.enabledSelect.Selected != nodeFilterAllStates {
+		enabled := np.enabledSelect.Selected == nodeFilterEnabled
+		q.Enabled = &enabled
+	}
+	return q
 }
 
-// getFilteredNodes 根据当前搜索关键字返回过滤后的节点列表。
-// 支持按名称、地址、协议类型进行不区分大小写的匹配。
-func (np *NodePage) getFilteredNodes() []*model.Node {
-	// 从 Store 获取所有节点
-	var allNodes []*model.Node
+// nodePage 返回过滤结果的第 page 页（每页 nodePageSize 条），尚未读取时由数据库查询。
+// 查询失败时记录警告并按空页缓存，避免每行渲染都重复查询。
+func (np *NodePage) nodePage(page int) []*model.Node {
+	if !np.pagesValid {
+		np.nodePages = make(map[int][]*model.Node)
+		np.nodeTotal = 0
+		np.pagesValid = true
+	}
+	if nodes, ok := np.nodePages[page]; ok {
+		return nodes
+	}
+	var nodes []*model.Node
 	if np.appState != nil && np.appState.Store != nil && np.appState.Store.Nodes != nil {
-		allNodes = np.appState.Store.Nodes.GetAll()
-	} else {
-		allNodes = []*model.Node{}
+		q := np.nodeQuery()
+		q.Limit = nodePageSize
+		q.Offset = page * nodePageSize
+		result, total, err := np.appState.Store.Nodes.Query(q)
+		if err != nil {
+			np.appState.AppendLog("WARN", "server", fmt.Sprintf("节点查询失败: %v", err))
+		} else {
+			nodes = result
+			np.nodeTotal = total
+		}
 	}
+	np.nodePages[page] = nodes
+	return nodes
+}
 
-	// 如果没有搜索关键字，直接返回完整列表
-	if np.searchText == "" {
-		return allNodes
+// nodeAt 返回过滤结果中第 id 行的节点，超出范围时返回 nil。
+func (np *NodePage) nodeAt(id widget.ListItemID) *model.Node {
+	if id < 0 {
+		return nil
+	}
+	nodes := np.nodePage(id / nodePageSize)
+	if i := id % nodePageSize; i < len(nodes) {
+		return nodes[i]
 	}
+	return nil
+}
 
-	filtered := make([]*model.Node, 0, len(allNodes))
-	for _, node := range allNodes {
-		name := strings.ToLower(node.Name)
-		addr := strings.ToLower(node.Addr)
-		protocol := strings.ToLower(node.ProtocolType)
+// indexOfNode 返回节点在过滤结果中的行号（逐页查找），不在结果中时返回 -1。
+func (np *NodePage) indexOfNode(nodeID string) int {
+	np.nodePage(0)
+	for page := 0; page*nodePageSize < np.nodeTotal; page++ {
+		for i, node := range np.nodePage(page) {
+			if node.ID == nodeID {
+				return page*nodePageSize + i
+			}
+		}
+	}
+	return -1
+}
 
-		if strings.Contains(name, np.searchText) ||
-			strings.Contains(addr, np.searchText) ||
-			strings.Contains(protocol, np.searchText) {
-			filtered = append(filtered, node)
+// applyFilter 过滤条件变化后重新查询并回到列表顶部。
+func (np *NodePage) applyFilter() {
+	np.pagesValid = false
+	if np.list != nil {
+		np.list.Refresh()
+		np.list.ScrollToTop()
+	}
+}
+
+// clearFilters 将订阅、协议和启用状态过滤恢复为全部（不触发 OnChanged）。
+func (np *NodePage) clearFilters() {
+	for sel, all := range map[*widget.Select]string{
+		np.subscriptionSelect: nodeFilterAllSubscriptions,
+		np.protocolSelect:     nodeFilterAllProtocols,
+		np.enabledSelect:      nodeFilterAllStates,
+	} {
+		if sel != nil && sel.Selected != all {
+			sel.Selected = all
+			sel.Refresh()
 		}
 	}
-	return filtered
+	np.applyFilter()
+}
+
+// updateFilterOptions 按当前订阅和节点协议重建过滤选项；已选项不再存在时恢复为全部。
+func (np *NodePage) updateFilterOptions() {
+	if np.subscriptionSelect == nil || np.protocolSelect == nil {
+		return
+	}
+	subOptions := []string{nodeFilterAllSubscriptions, nodeFilterManual}
+	np.subscriptionIDs = map[string]int64{nodeFilterManual: 0}
+	protoOptions := []string{nodeFilterAllProtocols}
+	if np.appState != nil && np.appState.Store != nil {
+		if np.appState.Store.Subscriptions != nil {
+			for _, sub := range np.appState.Store.Subscriptions.GetAll() {
+				label := sub.Label
+				if label == "" {
+					label = sub.URL
+				}
+				if _, dup := np.subscriptionIDs[label]; dup || label == nodeFilterAllSubscriptions {
+					label = fmt.Sprintf("%s (%d)", label, sub.ID)
+				}
+				np.subscriptionIDs[label] = sub.ID
+				subOptions = append(subOptions, label)
+			}
+		}
+		if np.appState.Store.Nodes != nil {
+			seen := make(map[string]bool)
+			var protocols []string
+			for _, node := range np.appState.Store.Nodes.GetAll() {
+				if node.ProtocolType != "" && !seen[node.ProtocolType] {
+					seen[node.ProtocolType] = true
+					protocols = append(protocols, node.ProtocolType)
+				}
+			}
+			slices.Sort(protocols)
+			protoOptions = append(protoOptions, protocols...)
+		}
+	}
+	setFilterOptions(np.subscriptionSelect, subOptions)
+	setFilterOptions(np.protocolSelect, protoOptions)
+}
+
+// setFilterOptions 更新下拉选项并保留原选中项，原选中项不在新选项中时选中第一项（全部）。
+// 直接修改字段而不调用 SetSelected，避免触发 OnChanged 重复查询。
+func setFilterOptions(sel *widget.Select, options []string) {
+	sel.Options = options
+	if !slices.Contains(options, sel.Selected) {
+		sel.Selected = options[0]
+	}
+	sel.Refresh()
 }
 
 // createNodeItem 创建节点列表项
@@ -296,12 +544,11 @@ func (np *NodePage) createNodeItem() fyne.CanvasObject {
 
 // updateNodeItem 更新节点列表项
 func (np *NodePage) updateNodeItem(id widget.ListItemID, obj fyne.CanvasObject) {
-	nodes := np.getFilteredNodes()
-	if id < 0 || id >= len(nodes) {
+	node := np.nodeAt(id)
+	if node == nil {
 		return
 	}
 
-	node := nodes[id]
 	item := obj.(*ServerListItem)
 
 	// 设置面板引用和ID
@@ -322,18 +569,22 @@ func (np *NodePage) updateNodeItem(id widget.ListItemID, obj fyne.CanvasObject)
 
 // onNodeSelected 节点选中事件（单击选中）
 func (np *NodePage) onNodeSelected(id widget.ListItemID) {
-	nodes := np.getFilteredNodes()
-	if id < 0 || id >= len(nodes) {
+	node := np.nodeAt(id)
+	if node == nil {
 		return
 	}
 
-	node := nodes[id]
+	np.selectNodeByID(node.ID)
+}
 
+// selectNodeByID 选中指定节点并刷新页面和主界面显示（列表单击、最近使用快捷栏共用）。
+func (np *NodePage) selectNodeByID(nodeID string) {
 	// 通过 Store 选中节点并同步到 AppConfig（应用层与列表页一致）
 	if np.appState != nil && np.appState.Store != nil {
-		if err := np.appState.Store.SelectServer(node.ID); err != nil {
+		np.appState.CancelVerify()
+		if err := np.appState.Store.SelectServer(nodeID); err != nil {
 			if np.appState.Logger != nil {
-				np.appState.Logger.Error("选中服务器失败: %v", err)
+				np.appState.Logger.ErrorWithType(logging.LogTypeServer, "选中服务器失败: %v", err)
 			}
 			return
 		}
@@ -343,6 +594,7 @@ func (np *NodePage) onNodeSelected(id widget.ListItemID) {
 	np.updateSelectedServerLabel()
 
 	// 强制刷新列表显示（确保选中状态立即更新）
+	np.pagesValid = false
 	if np.list != nil {
 		np.list.Refresh()
 	}
@@ -360,8 +612,8 @@ func (np *NodePage) onNodeSelected(id widget.ListItemID) {
 
 // onRightClick 右键菜单 - 显示操作菜单
 func (np *NodePage) onRightClick(id widget.ListItemID, ev *fyne.PointEvent) {
-	nodes := np.getFilteredNodes()
-	if id < 0 || id >= len(nodes) {
+	node := np.nodeAt(id)
+	if node == nil {
 		return
 	}
 
@@ -378,6 +630,10 @@ func (np *NodePage) onRightClick(id widget.ListItemID, ev *fyne.PointEvent) {
 			// 测速
 			np.onTestSpeed(id)
 		}),
+		fyne.NewMenuItem("发送到设备", func() {
+			// 通过局域网分享给另一台设备
+			showShareDialog(np.appState, &service.SharePayload{Nodes: []model.Node{*node}})
+		}),
 	}
 
 	// 如果代理正在运行，添加停止选项
@@ -400,12 +656,13 @@ func (np *NodePage) onRightClick(id widget.ListItemID, ev *fyne.PointEvent) {
 
 // onTestSpeed 测速
 func (np *NodePage) onTestSpeed(id widget.ListItemID) {
-	nodes := np.getFilteredNodes()
-	if id < 0 || id >= len(nodes) {
+	node := np.nodeAt(id)
+	if node == nil {
 		return
 	}
 
-	node := nodes[id]
+	ctx := np.beginTest(fmt.Sprintf("正在测速: %s", node.Name), 1)
+	np.appState.UsageStatsService.Record(model.UsageFeatureSpeedTest)
 
 	// 在goroutine中执行测速
 	go func() {
@@ -414,32 +671,32 @@ func (np *NodePage) onTestSpeed(id widget.ListItemID) {
 			np.appState.AppendLog("INFO", "ping", fmt.Sprintf("开始测试服务器延迟: %s (%s:%d)", node.Name, node.Addr, node.Port))
 		}
 
-		delay, err := np.appState.Ping.TestServerDelay(*node)
+		stats, err := np.appState.Ping.TestServerLatencyContext(ctx, *node)
+		if ctx.Err() != nil {
+			// 已取消（离开页面或手动取消），不再更新数据和界面
+			return
+		}
 		if err != nil {
-			// 记录失败日志
+			// 记录失败日志；保存统计以便在延迟列悬停查看失败阶段
 			if np.appState != nil {
 				np.appState.AppendLog("ERROR", "ping", fmt.Sprintf("服务器 %s 测速失败: %v", node.Name, err))
+				np.appState.NodeHealth.RecordFailure(node.ID)
+				_ = np.appState.ServerService.UpdateLatency(node.ID, stats)
 			}
 			fyne.Do(func() {
-				if np.appState != nil && np.appState.Window != nil {
-					dialog.ShowError(fmt.Errorf("测速失败: %w", err), np.appState.Window)
-				}
+				np.endTest(ctx, fmt.Sprintf("%s 测速失败", node.Name))
 			})
 			return
 		}
 
-		// 通过 Store 更新服务器延迟（会自动更新数据库和绑定）
-		if np.appState != nil && np.appState.Store != nil && np.appState.Store.Nodes != nil {
-			if err := np.appState.Store.Nodes.UpdateDelay(node.ID, delay); err != nil {
-				if np.appState != nil {
-					np.appState.AppendLog("ERROR", "ping", fmt.Sprintf("更新延迟失败: %v", err))
-				}
-			}
+		// 通过 ServerService 更新服务器延迟（会更新数据库和 Store）
+		if err := np.appState.ServerService.UpdateLatency(node.ID, stats); err != nil {
+			np.appState.AppendLog("ERROR", "ping", fmt.Sprintf("更新延迟失败: %v", err))
 		}
 
 		// 记录成功日志
 		if np.appState != nil {
-			np.appState.AppendLog("INFO", "ping", fmt.Sprintf("服务器 %s 测速完成: %d ms", node.Name, delay))
+			np.appState.AppendLog("INFO", "ping", fmt.Sprintf("服务器 %s 测速完成: %s", node.Name, formatLatencyStats(stats)))
 		}
 
 		// 更新UI（需要在主线程中执行）
@@ -449,18 +706,58 @@ func (np *NodePage) onTestSpeed(id widget.ListItemID) {
 			if np.appState != nil {
 				np.appState.UpdateProxyStatus()
 			}
-			if np.appState != nil && np.appState.Window != nil {
-				message := fmt.Sprintf("节点: %s\n延迟: %d ms", node.Name, delay)
-				dialog.ShowInformation("测速完成", message, np.appState.Window)
-			}
+			np.endTest(ctx, fmt.Sprintf("%s: %d ms", node.Name, stats.Median))
 		})
 	}()
 }
 
+// beginTest 开始一次测速：取消上一次未完成的测速，显示进度条，返回与本页面绑定的上下文。
+func (np *NodePage) beginTest(status string, total int) context.Context {
+	if np.testCancel != nil {
+		np.testCancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	np.testCancel = cancel
+
+	if np.testBar != nil {
+		np.testProgress.Max = float64(total)
+		np.testProgress.SetValue(0)
+		np.testProgress.Show()
+		np.testStatus.SetText(status)
+		np.testBar.Show()
+	}
+	return ctx
+}
+
+// endTest 结束测速：隐藏进度条，仅保留结果文本。ctx 已被新的测速替换或已取消时忽略。
+func (np *NodePage) endTest(ctx context.Context, status string) {
+	if ctx.Err() != nil {
+		return
+	}
+	if np.testCancel != nil {
+		np.testCancel()
+		np.testCancel = nil
+	}
+	if np.testBar != nil {
+		np.testProgress.Hide()
+		np.testStatus.SetText(status)
+	}
+}
+
+// CancelTests 取消正在进行的测速（离开节点页时由 MainWindow 调用）。
+func (np *NodePage) CancelTests() {
+	if np.testCancel != nil {
+		np.testCancel()
+		np.testCancel = nil
+	}
+	if np.testBar != nil {
+		np.testBar.Hide()
+	}
+}
+
 // onStartProxy 启动代理（右键菜单使用）
 func (np *NodePage) onStartProxy(id widget.ListItemID) {
-	nodes := np.getFilteredNodes()
-	if id < 0 || id >= len(nodes) {
+	if np.nodeAt(id) == nil {
 		return
 	}
 
@@ -472,7 +769,7 @@ func (np *NodePage) onStartProxy(id widget.ListItemID) {
 }
 
 // startProxyWithServer 使用指定的服务器启动代理 - 注释功能
-// func (np *NodePage) startProxyWithServer(srv *database.Node) {
+// func (np *NodePage) startProxyWithServer(srv *model.Node) {
 // 	// 使用固定的10808端口监听本地SOCKS5
 // 	proxyPort := 10808
 
@@ -564,7 +861,7 @@ func (np *NodePage) StartProxyForSelected() {
 		return
 	}
 
-	if np.appState.XrayControlService == nil {
+	if np.appState.proxyControl() == nil {
 		np.logAndShowError("启动代理失败", fmt.Errorf("XrayControlService 未初始化"))
 		return
 	}
@@ -576,7 +873,7 @@ func (np *NodePage) StartProxyForSelected() {
 	}
 
 	// 调用 service 启动代理
-	result := np.appState.XrayControlService.StartProxy(np.appState.XrayInstance, unifiedLogPath)
+	result := np.appState.proxyControl().StartProxy(np.appState.XrayInstance, unifiedLogPath)
 
 	if result.Error != nil {
 		np.logAndShowError("启动代理失败", result.Error)
@@ -585,7 +882,7 @@ func (np *NodePage) StartProxyForSelected() {
 	}
 
 	// 启动成功，更新 AppState 中的 XrayInstance
-	np.appState.XrayInstance = result.XrayInstance
+	np.appState.SetXrayInstance(result.XrayInstance)
 
 	// 更新 ProxyService 的 xray 实例引用
 	if np.appState.ProxyService != nil {
@@ -612,25 +909,17 @@ func (np *NodePage) StartProxyForSelected() {
 		np.appState.MainWindow.RefreshMainToggleButton()
 	}
 
-	// 显示成功对话框
-	if np.appState.Window != nil && result.XrayInstance != nil {
-		selectedNode := np.appState.Store.Nodes.GetSelected()
-		if selectedNode != nil {
-			message := fmt.Sprintf("代理已启动\n节点: %s\n端口: %d", selectedNode.Name, result.XrayInstance.GetPort())
-			dialog.ShowInformation("代理启动成功", message, np.appState.Window)
+	// 显示成功提示
+	if result.XrayInstance != nil {
+		if selectedNode := np.appState.Store.Nodes.GetSelected(); selectedNode != nil {
+			showToast(np.appState, FeedbackSuccess, fmt.Sprintf("代理已启动: %s（端口 %d）", selectedNode.Name, result.XrayInstance.GetPort()))
 		}
 	}
 }
 
 // logAndShowError 记录日志并显示错误对话框（统一错误处理）
 func (np *NodePage) logAndShowError(message string, err error) {
-	if np.appState != nil && np.appState.Logger != nil {
-		np.appState.Logger.Error("%s: %v", message, err)
-	}
-	if np.appState != nil && np.appState.Window != nil {
-		errorMsg := fmt.Errorf("%s: %w", message, err)
-		dialog.ShowError(errorMsg, np.appState.Window)
-	}
+	showErrorDetail(np.appState, message, err)
 }
 
 // saveConfigToDB 保存应用配置到数据库（统一配置保存）
@@ -647,13 +936,13 @@ func (np *NodePage) onStopProxy() {
 		return
 	}
 
-	if np.appState.XrayControlService == nil {
+	if np.appState.proxyControl() == nil {
 		np.logAndShowError("停止代理失败", fmt.Errorf("XrayControlService 未初始化"))
 		return
 	}
 
 	// 调用 service 停止代理
-	result := np.appState.XrayControlService.StopProxy(np.appState.XrayInstance)
+	result := np.appState.proxyControl().StopProxy(np.appState.XrayInstance)
 
 	if result.Error != nil {
 		np.logAndShowError("停止代理失败", result.Error)
@@ -661,7 +950,7 @@ func (np *NodePage) onStopProxy() {
 	}
 
 	// 停止成功，销毁实例（生命周期 = 代理运行生命周期）
-	np.appState.XrayInstance = nil
+	np.appState.SetXrayInstance(nil)
 
 	// 记录日志（统一日志记录）
 	if np.appState.Logger != nil {
@@ -676,13 +965,11 @@ func (np *NodePage) onStopProxy() {
 		np.appState.MainWindow.RefreshMainToggleButton()
 	}
 
-	// 显示成功对话框
-	if np.appState.Window != nil {
-		if result.LogMessage == "代理未运行" {
-			dialog.ShowInformation("提示", "代理未运行", np.appState.Window)
-		} else {
-			dialog.ShowInformation("代理停止成功", "代理已停止", np.appState.Window)
-		}
+	// 显示提示
+	if result.LogMessage == "代理未运行" {
+		showToast(np.appState, FeedbackInfo, "代理未运行")
+	} else {
+		showToast(np.appState, FeedbackSuccess, "代理已停止")
 	}
 }
 
@@ -692,67 +979,75 @@ func (np *NodePage) StopProxy() {
 	np.onStopProxy()
 }
 
-// onTestAll 一键测延迟 - 注释功能
+// onTestAll 一键测延迟
 func (np *NodePage) onTestAll() {
-	// 在goroutine中执行测速
-	go func() {
-		var servers []*database.Node
-		if np.appState != nil && np.appState.Store != nil && np.appState.Store.Nodes != nil {
-			servers = np.appState.Store.Nodes.GetAll()
-		}
-		enabledCount := 0
-		for _, s := range servers {
-			if s != nil && s.Enabled {
-				enabledCount++
-			}
+	var servers []*model.Node
+	if np.appState != nil && np.appState.Store != nil && np.appState.Store.Nodes != nil {
+		servers = np.appState.Store.Nodes.GetAll()
+	}
+
+	// 转换为 model.Node 列表
+	serverList := make([]model.Node, 0, len(servers))
+	for _, s := range servers {
+		if s != nil && s.Enabled {
+			serverList = append(serverList, *s)
 		}
+	}
+	total := len(serverList)
+	ctx := np.beginTest(fmt.Sprintf("正在测速 0/%d", total), total)
+	np.appState.UsageStatsService.Record(model.UsageFeatureSpeedTestAll)
 
+	// 在goroutine中执行测速
+	go func() {
 		// 记录开始测速日志
 		if np.appState != nil {
-			np.appState.AppendLog("INFO", "ping", fmt.Sprintf("开始一键测速，共 %d 个启用的服务器", enabledCount))
+			np.appState.AppendLog("INFO", "ping", fmt.Sprintf("开始一键测速，共 %d 个启用的服务器", total))
 		}
 
-		// 转换为 model.Node 列表
-		serverList := make([]model.Node, 0, len(servers))
-		for _, s := range servers {
-			if s != nil && s.Enabled {
-				serverList = append(serverList, *s)
-			}
-		}
-
-		// 测试所有服务器延迟
-		results := np.appState.Ping.TestAllServersDelay(serverList)
-
-		// 统计结果并记录每个服务器的详细日志，同时更新延迟
+		var mu sync.Mutex
+		done := 0
 		successCount := 0
 		failCount := 0
-		for _, srv := range servers {
-			if srv == nil || !srv.Enabled {
-				continue
-			}
-			delay, exists := results[srv.ID]
-			if !exists {
-				continue
+
+		// 测试所有服务器延迟，每完成一个即更新延迟和进度
+		results := np.appState.Ping.TestAllServersDelayContext(ctx, serverList, func(srv model.Node, stats model.LatencyStats) {
+			delay := stats.Delay()
+			if delay > 0 {
+				// 通过 ServerService 更新服务器延迟（会更新数据库和 Store）
+				if err := np.appState.ServerService.UpdateLatency(srv.ID, stats); err != nil {
+					np.appState.AppendLog("ERROR", "ping", fmt.Sprintf("更新服务器 %s 延迟失败: %v", srv.Name, err))
+				}
+				np.appState.AppendLog("INFO", "ping", fmt.Sprintf("服务器 %s (%s:%d) 测速完成: %s", srv.Name, srv.Addr, srv.Port, formatLatencyStats(stats)))
+			} else if ctx.Err() == nil {
+				np.appState.AppendLog("ERROR", "ping", fmt.Sprintf("服务器 %s (%s:%d) 测速失败: %s", srv.Name, srv.Addr, srv.Port, formatDialPhases(stats.Phases)))
+				np.appState.NodeHealth.RecordFailure(srv.ID)
+				_ = np.appState.ServerService.UpdateLatency(srv.ID, stats)
 			}
+
+			mu.Lock()
+			done++
 			if delay > 0 {
 				successCount++
-				// 通过 Store 更新服务器延迟（会自动更新数据库和绑定）
-				if np.appState != nil && np.appState.Store != nil && np.appState.Store.Nodes != nil {
-					if err := np.appState.Store.Nodes.UpdateDelay(srv.ID, delay); err != nil {
-						if np.appState != nil {
-							np.appState.AppendLog("ERROR", "ping", fmt.Sprintf("更新服务器 %s 延迟失败: %v", srv.Name, err))
-						}
-					}
-				}
-				if np.appState != nil {
-					np.appState.AppendLog("INFO", "ping", fmt.Sprintf("服务器 %s (%s:%d) 测速完成: %d ms", srv.Name, srv.Addr, srv.Port, delay))
-				}
 			} else {
 				failCount++
-				if np.appState != nil {
-					np.appState.AppendLog("ERROR", "ping", fmt.Sprintf("服务器 %s (%s:%d) 测速失败", srv.Name, srv.Addr, srv.Port))
+			}
+			current := done
+			mu.Unlock()
+
+			fyne.Do(func() {
+				if ctx.Err() != nil || np.testProgress == nil {
+					return
 				}
+				np.testProgress.SetValue(float64(current))
+				np.testStatus.SetText(fmt.Sprintf("正在测速 %d/%d", current, total))
+			})
+		})
+
+		if ctx.Err() != nil {
+			if np.appState != nil {
+				np.appState.AppendLog("INFO", "ping", "一键测速已取消")
 			}
+			return
 		}
 
 		// 记录完成日志
@@ -763,10 +1058,7 @@ func (np *NodePage) onTestAll() {
 		// 更新UI（需要在主线程中执行）
 		fyne.Do(func() {
 			np.Refresh()
-			if np.appState != nil && np.appState.Window != nil {
-				message := fmt.Sprintf("测速完成\n成功: %d 个\n失败: %d 个\n共测试: %d 个服务器", successCount, failCount, len(results))
-				dialog.ShowInformation("批量测速完成", message, np.appState.Window)
-			}
+			np.endTest(ctx, fmt.Sprintf("测速完成: 成功 %d 个，失败 %d 个", successCount, failCount))
 		})
 	}()
 }
@@ -816,6 +1108,8 @@ type ServerListItem struct {
 	regionLabel *widget.Label
 	nameLabel   *widget.Label
 	delayText   *canvas.Text       // 延迟列（按 50/150ms 阈值着色）
+	delayTip    *TooltipArea       // 延迟列悬停提示：最小/中位/P95
+	scoreText   *canvas.Text       // 延迟下方的综合评分（未测速时为空）
 	statusIcon  *widget.Icon       // 在线/离线状态图标
 	menuButton  *widget.Button    // 右侧"..."菜单按钮
 	isSelected  bool              // 是否选中
@@ -848,6 +1142,9 @@ func NewServerListItem(panel *NodePage, appState *AppState) *ServerListItem {
 	if appState != nil && appState.App != nil {
 		item.delayText.TextSize = theme.DefaultTheme().Size(theme.SizeNameText)
 	}
+	item.scoreText = canvas.NewText("", CurrentThemeColor(appState.App, theme.ColorNamePlaceHolder))
+	item.scoreText.Alignment = fyne.TextAlignTrailing
+	item.scoreText.TextSize = theme.DefaultTheme().Size(theme.SizeNameCaptionText)
 
 	// 使用 setupLayout 创建渲染对象（参考 SubscriptionCard 的设计）
 	item.renderObj = item.setupLayout()
@@ -861,7 +1158,8 @@ func (s *ServerListItem) setupLayout() fyne.CanvasObject {
 	s.bgRect = canvas.NewRectangle(bgColor)
 	s.bgRect.CornerRadius = 4 // 较小的圆角，适合列表项
 
-	delayCell := container.New(&rightAlignLayout{minWidth: 70}, s.delayText)
+	s.delayTip = NewTooltipArea(s.delayText)
+	delayCell := container.New(&rightAlignLayout{minWidth: 70}, container.NewVBox(s.delayTip, s.scoreText))
 	content := container.NewGridWithColumns(3,
 		s.regionLabel,
 		s.nameLabel,
@@ -958,10 +1256,17 @@ func (s *ServerListItem) Update(server model.Node) {
 		if !server.Enabled {
 			prefix += "[禁用] "
 			s.nameLabel.Importance = widget.LowImportance
+		} else if until, ok := s.appState.NodeHealth.DegradedUntil(server.ID); ok {
+			prefix += fmt.Sprintf("[降级至 %s] ", until.Format("15:04"))
+			s.nameLabel.Importance = widget.WarningImportance
 		} else {
 			s.nameLabel.Importance = widget.MediumImportance
 		}
-		s.nameLabel.SetText(prefix + server.Name)
+		if server.Notes != "" {
+			s.nameLabel.SetText(prefix + server.Name + " · " + firstLine(server.Notes))
+		} else {
+			s.nameLabel.SetText(prefix + server.Name)
+		}
 
 		// 延迟 - 按 0-60ms 绿 / 60-150ms 黄 / >150ms 红 / 超时或未测速 灰 着色
 		delayDisplay := "未测速"
@@ -973,6 +1278,22 @@ func (s *ServerListItem) Update(server model.Node) {
 		s.delayText.Text = delayDisplay
 		s.delayText.Color = DelayColor(s.appState.App, server.Delay)
 		s.delayText.Refresh()
+		if s.delayTip != nil {
+			tip := ""
+			if s.appState.Store != nil && s.appState.Store.Nodes != nil {
+				if stats, ok := s.appState.Store.Nodes.GetLatency(server.ID); ok {
+					tip = formatLatencyStats(stats)
+				}
+			}
+			s.delayTip.SetText(tip)
+		}
+		s.scoreText.Text = ""
+		if s.panel != nil && s.appState.Store != nil {
+			if score, ok := service.NodeScore(s.appState.Store.Nodes, s.panel.scoreWeights, server); ok {
+				s.scoreText.Text = fmt.Sprintf("评分 %d", score)
+			}
+		}
+		s.scoreText.Refresh()
 
 		// 更新在线/离线状态图标
 		if s.statusIcon != nil {
@@ -1003,6 +1324,192 @@ func (s *ServerListItem) Update(server model.Node) {
 	})
 }
 
+// copyNodeInfo 复制节点信息到剪贴板；withSecrets 为 false 时密码、UUID 等凭据以 *** 代替。
+func (s *ServerListItem) copyNodeInfo(server model.Node, withSecrets bool) {
+	if s.panel == nil || s.panel.appState == nil || s.panel.appState.Window == nil {
+		return
+	}
+	secret := func(v string) string {
+		if withSecrets {
+			return v
+		}
+		return utils.RedactedMask
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "名称: %s\n地址: %s:%d\n协议: %s", server.Name, server.Addr, server.Port, server.ProtocolType)
+	if server.Username != "" {
+		fmt.Fprintf(&b, "\n用户名: %s", server.Username)
+	}
+	if server.Password != "" {
+		fmt.Fprintf(&b, "\n密码: %s", secret(server.Password))
+	}
+	if server.VMessUUID != "" {
+		fmt.Fprintf(&b, "\nUUID: %s", secret(server.VMessUUID))
+	}
+	if server.VLESSUUID != "" {
+		fmt.Fprintf(&b, "\nUUID: %s", secret(server.VLESSUUID))
+	}
+	if server.SSMethod != "" {
+		fmt.Fprintf(&b, "\n加密方式: %s", server.SSMethod)
+	}
+	if server.Notes != "" {
+		fmt.Fprintf(&b, "\n备注: %s", server.Notes)
+	}
+
+	s.panel.appState.Window.Clipboard().SetContent(b.String())
+	if withSecrets {
+		showToast(s.panel.appState, FeedbackWarning, "节点信息已复制到剪贴板（含凭据，请注意保管）")
+	} else {
+		showToast(s.panel.appState, FeedbackSuccess, "节点信息已复制到剪贴板（凭据已隐藏）")
+	}
+}
+
+// showNodeOutboundBinding 设置节点单独的出站绑定，留空表示跟随全局设置。
+func (s *ServerListItem) showNodeOutboundBinding(server model.Node) {
+	if s.panel == nil || s.panel.appState == nil || s.panel.appState.ConfigService == nil {
+		return
+	}
+	appState := s.panel.appState
+	cs := appState.ConfigService
+	hint := fmt.Sprintf("节点 %s 出站使用的网卡或源 IP，留空跟随全局设置（当前全局: %s）。", server.Name, outboundBindingSummary(cs.GetOutboundBinding()))
+	showOutboundBindingDialog(appState, "出站绑定", hint, cs.GetNodeOutboundBinding(server.ID), func(b model.OutboundBinding) error {
+		if err := cs.SetNodeOutboundBinding(server.ID, b); err != nil {
+			return err
+		}
+		if server.Selected {
+			appState.ReloadProxy("节点出站绑定变更")
+		}
+		return nil
+	})
+}
+
+// showNodeNotesDialog 编辑节点备注（如到期时间、用途），订阅更新时保留。
+func (s *ServerListItem) showNodeNotesDialog(server model.Node) {
+	if s.panel == nil || s.panel.appState == nil || s.panel.appState.Window == nil || s.panel.appState.Store == nil {
+		return
+	}
+	appState := s.panel.appState
+	entry := widget.NewMultiLineEntry()
+	entry.SetText(server.Notes)
+	entry.SetPlaceHolder("如 2025-03 到期、仅用于流媒体")
+	entry.Wrapping = fyne.TextWrapWord
+
+	d := dialog.NewForm("节点备注", "保存", "取消", []*widget.FormItem{
+		{Text: server.Name, Widget: entry},
+	}, func(ok bool) {
+		if !ok {
+			return
+		}
+		if err := appState.ServerService.UpdateNotes(server.ID, strings.TrimSpace(entry.Text)); err != nil {
+			showErrorDetail(appState, "保存节点备注失败", err)
+			return
+		}
+		s.panel.Refresh()
+	}, appState.Window)
+	d.Resize(fyne.NewSize(400, 240))
+	d.Show()
+}
+
+// showNodeRenameDialog 重命名节点。订阅节点重命名后标记为用户修改，订阅更新时按冲突策略处理。
+func (s *ServerListItem) showNodeRenameDialog(server model.Node) {
+	if s.panel == nil || s.panel.appState == nil || s.panel.appState.Window == nil || s.panel.appState.ServerService == nil {
+		return
+	}
+	appState := s.panel.appState
+	entry := widget.NewEntry()
+	entry.SetText(server.Name)
+
+	d := dialog.NewForm("重命名节点", "保存", "取消", []*widget.FormItem{
+		{Text: "名称", Widget: entry},
+	}, func(ok bool) {
+		name := strings.TrimSpace(entry.Text)
+		if !ok || name == "" || name == server.Name {
+			return
+		}
+		server.Name = name
+		if err := appState.ServerService.SaveUserEdit(server); err != nil {
+			showErrorDetail(appState, "重命名节点失败", err)
+			return
+		}
+		s.panel.Refresh()
+	}, appState.Window)
+	d.Resize(fyne.NewSize(360, 0))
+	d.Show()
+}
+
+// showNodeOverridesDialog 编辑节点的覆盖参数（SNI、Host、路径）：非空时优先于分享链接中的值，
+// 订阅更新后保留。输入框占位文字显示链接中的原值，留空即恢复使用链接中的值。
+func (s *ServerListItem) showNodeOverridesDialog(server model.Node) {
+	if s.panel == nil || s.panel.appState == nil || s.panel.appState.Window == nil ||
+		s.panel.appState.Store == nil || s.panel.appState.Store.Nodes == nil {
+		return
+	}
+	appState := s.panel.appState
+	if server.ProtocolType != "vmess" && server.ProtocolType != "trojan" && server.ProtocolType != "vless" {
+		showToast(appState, FeedbackInfo, "仅 VMess、VLESS 和 Trojan 节点支持覆盖参数")
+		return
+	}
+	isVMess := server.ProtocolType == "vmess"
+	isVLESS := server.ProtocolType == "vless"
+
+	// newOverrideEntry 覆盖输入框，占位文字为链接中的值
+	newOverrideEntry := func(value, parsed string) *widget.Entry {
+		entry := widget.NewEntry()
+		entry.SetText(value)
+		if parsed != "" {
+			entry.SetPlaceHolder("链接中的值：" + parsed)
+		} else {
+			entry.SetPlaceHolder("链接中未设置")
+		}
+		return entry
+	}
+	parsedSNI, parsedHost, parsedPath := server.TrojanSNI, server.VMessHost, server.VMessPath
+	if isVMess {
+		parsedSNI = server.VMessHost
+	}
+	if isVLESS {
+		parsedSNI, parsedHost, parsedPath = server.VLESSSNI, server.VLESSHost, server.VLESSPath
+	}
+	sniEntry := newOverrideEntry(server.OverrideSNI, parsedSNI)
+	hostEntry := newOverrideEntry(server.OverrideHost, parsedHost)
+	pathEntry := newOverrideEntry(server.OverridePath, parsedPath)
+
+	hint := widget.NewLabel("覆盖项优先于分享链接中的值，订阅更新后保留；留空表示使用链接中的值")
+	hint.Wrapping = fyne.TextWrapWord
+	hint.Importance = widget.WarningImportance
+	items := []*widget.FormItem{
+		{Text: "", Widget: hint},
+		{Text: "SNI（覆盖）", Widget: sniEntry},
+	}
+	if isVMess || isVLESS {
+		items = append(items,
+			&widget.FormItem{Text: "ws/h2 Host（覆盖）", Widget: hostEntry},
+			&widget.FormItem{Text: "ws/h2 路径（覆盖）", Widget: pathEntry, HintText: "grpc 传输时为 serviceName"},
+		)
+	}
+
+	d := dialog.NewForm("覆盖参数 - "+server.Name, "保存", "取消", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		sni, host, path := strings.TrimSpace(sniEntry.Text), strings.TrimSpace(hostEntry.Text), strings.TrimSpace(pathEntry.Text)
+		if sni == server.OverrideSNI && host == server.OverrideHost && path == server.OverridePath {
+			return
+		}
+		if err := appState.ServerService.UpdateOverrides(server.ID, sni, host, path); err != nil {
+			showErrorDetail(appState, "保存覆盖参数失败", err)
+			return
+		}
+		if server.ID == appState.Store.Nodes.GetSelectedID() {
+			appState.ReloadProxy("节点覆盖参数已修改")
+		}
+		s.panel.Refresh()
+	}, appState.Window)
+	d.Resize(fyne.NewSize(460, 0))
+	d.Show()
+}
+
 // showQuickMenu 显示快速操作菜单 - 注释功能
 func (s *ServerListItem) showQuickMenu(server model.Node) {
 	if s.panel == nil || s.panel.appState == nil || s.panel.appState.Window == nil {
@@ -1024,20 +1531,42 @@ func (s *ServerListItem) showQuickMenu(server model.Node) {
 		fyne.NewMenuItem("收藏", func() {
 			// TODO: 实现收藏功能
 			if s.panel != nil && s.panel.appState != nil && s.panel.appState.Window != nil {
-				dialog.ShowInformation("提示", "收藏功能开发中", s.panel.appState.Window)
+				showToast(s.panel.appState, FeedbackInfo, "收藏功能开发中")
 			}
 		}),
 		fyne.NewMenuItem("复制信息", func() {
-			// TODO: 实现复制节点信息功能
-			info := fmt.Sprintf("名称: %s\n地址: %s:%d\n协议: %s",
-				server.Name, server.Addr, server.Port, server.ProtocolType)
-			if s.panel != nil && s.panel.appState != nil && s.panel.appState.Window != nil {
-				s.panel.appState.Window.Clipboard().SetContent(info)
-				dialog.ShowInformation("提示", "节点信息已复制到剪贴板", s.panel.appState.Window)
-			}
+			s.copyNodeInfo(server, true)
+		}),
+		fyne.NewMenuItem("复制信息（不含凭据）", func() {
+			s.copyNodeInfo(server, false)
+		}),
+		fyne.NewMenuItem("出站绑定", func() {
+			s.showNodeOutboundBinding(server)
+		}),
+		fyne.NewMenuItem("重命名", func() {
+			s.showNodeRenameDialog(server)
+		}),
+		fyne.NewMenuItem("备注", func() {
+			s.showNodeNotesDialog(server)
+		}),
+		fyne.NewMenuItem("覆盖参数", func() {
+			s.showNodeOverridesDialog(server)
+		}),
+		fyne.NewMenuItem("自动诊断", func() {
+			showNodeDiagnoseDialog(s.panel.appState, server, s.panel.Refresh)
+		}),
+		fyne.NewMenuItem("冒烟测试", func() {
+			showSmokeTestDialog(s.panel.appState, server)
 		}),
 	)
 
+	// 降级节点：允许手动恢复，立即重新参与故障转移
+	if s.panel.appState.NodeHealth.IsDegraded(server.ID) {
+		menu.Items = append(menu.Items, fyne.NewMenuItemSeparator(), fyne.NewMenuItem("恢复节点（解除降级）", func() {
+			s.panel.appState.NodeHealth.Restore(server.ID)
+		}))
+	}
+
 	// 显示菜单
 	popup := widget.NewPopUpMenu(menu, s.panel.appState.Window.Canvas())
 	// 在菜单按钮位置显示
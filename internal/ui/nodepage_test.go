@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+	"myproxy.com/p/internal/database"
+	"myproxy.com/p/internal/model"
+)
+
+func TestNodePageTapSelectsNode(t *testing.T) {
+	nodes := []model.Node{
+		{ID: "node-a", Name: "香港 01", ProtocolType: "socks5", Addr: "10.0.0.1", Port: 1080, Enabled: true},
+		{ID: "node-b", Name: "日本 01", ProtocolType: "socks5", Addr: "10.0.0.2", Port: 1080, Enabled: true},
+	}
+	appState := newTestAppState(t, nodes...)
+	np := NewNodePage(appState)
+	content := np.Build()
+	showInTestWindow(appState, content)
+
+	var target *ServerListItem
+	for _, obj := range test.LaidOutObjects(content) {
+		if item, ok := obj.(*ServerListItem); ok && item.panel != nil {
+			if node := np.nodeAt(item.id); node != nil && node.ID == "node-b" {
+				target = item
+				break
+			}
+		}
+	}
+	if target == nil {
+		t.Fatal("节点列表中未找到 node-b")
+	}
+
+	test.Tap(target)
+
+	if got := appState.Store.Nodes.GetSelectedID(); got != "node-b" {
+		t.Errorf("选中节点 = %q，期望 node-b", got)
+	}
+	if got := np.selectedServerLabel.Text; got != "日本 01" {
+		t.Errorf("选中节点标签 = %q，期望 日本 01", got)
+	}
+	saved, err := appState.Store.AppConfig.GetWithDefault("selectedServerID", "")
+	if err != nil || saved != "node-b" {
+		t.Errorf("保存的选中节点 = %q（%v），期望 node-b", saved, err)
+	}
+}
+
+func TestNodePageFilters(t *testing.T) {
+	appState := newTestAppState(t,
+		model.Node{ID: "manual-socks", Name: "手动 01", ProtocolType: "socks5", Addr: "10.0.0.1", Port: 1080, Enabled: true},
+		model.Node{ID: "manual-off", Name: "手动 02", ProtocolType: "socks5", Addr: "10.0.0.2", Port: 1080, Enabled: false},
+	)
+	sub, err := appState.Store.Subscriptions.Add("https://example.com/sub", "机场")
+	if err != nil {
+		t.Fatalf("添加订阅失败: %v", err)
+	}
+	vmess := database.Node{ID: "sub-vmess", Name: "订阅 01", ProtocolType: "vmess", Addr: "10.0.1.1", Port: 443, Enabled: true}
+	if err := database.AddOrUpdateServer(vmess, &sub.ID); err != nil {
+		t.Fatalf("写入订阅节点失败: %v", err)
+	}
+	np := NewNodePage(appState)
+	showInTestWindow(appState, np.Build())
+	np.Refresh()
+
+	ids := func() []string {
+		var got []string
+		for i := 0; i < np.getNodeCount(); i++ {
+			got = append(got, np.nodeAt(i).ID)
+		}
+		slices.Sort(got)
+		return got
+	}
+	check := func(name string, want ...string) {
+		t.Helper()
+		if got := ids(); !slices.Equal(got, want) {
+			t.Errorf("%s: 节点 = %v，期望 %v", name, got, want)
+		}
+	}
+
+	check("不过滤", "manual-off", "manual-socks", "sub-vmess")
+	np.subscriptionSelect.SetSelected("机场")
+	check("按订阅", "sub-vmess")
+	np.subscriptionSelect.SetSelected(nodeFilterManual)
+	check("手动添加", "manual-off", "manual-socks")
+	np.enabledSelect.SetSelected(nodeFilterEnabled)
+	check("手动添加且已启用", "manual-socks")
+	np.subscriptionSelect.SetSelected(nodeFilterAllSubscriptions)
+	np.protocolSelect.SetSelected("vmess")
+	check("按协议且已启用", "sub-vmess")
+	np.enabledSelect.SetSelected(nodeFilterDisabled)
+	check("无匹配")
+
+	np.FocusNode("manual-off")
+	check("跳转节点后清除过滤", "manual-off", "manual-socks", "sub-vmess")
+}
+
+func TestNodePageLoadsPages(t *testing.T) {
+	appState := newTestAppState(t)
+	total := nodePageSize + 5
+	for i := 0; i < total; i++ {
+		node := database.Node{ID: fmt.Sprintf("node-%03d", i), Name: fmt.Sprintf("节点 %03d", i), ProtocolType: "socks5", Addr: "10.0.0.1", Port: 1000 + i, Enabled: true}
+		if err := database.AddOrUpdateServer(node, nil); err != nil {
+			t.Fatalf("写入节点失败: %v", err)
+		}
+	}
+	np := NewNodePage(appState)
+
+	if got := np.getNodeCount(); got != total {
+		t.Fatalf("节点数量 = %d，期望 %d", got, total)
+	}
+	if len(np.nodePages) != 1 {
+		t.Errorf("只取数量时应只读取第一页，已读取 %d 页", len(np.nodePages))
+	}
+	seen := make(map[string]bool)
+	for i := 0; i < total; i++ {
+		node := np.nodeAt(i)
+		if node == nil {
+			t.Fatalf("第 %d 行没有节点", i)
+		}
+		seen[node.ID] = true
+	}
+	if len(seen) != total {
+		t.Errorf("分页读取到 %d 个不同节点，期望 %d", len(seen), total)
+	}
+	if np.nodeAt(total) != nil {
+		t.Error("超出范围的行应返回 nil")
+	}
+	if got := np.indexOfNode("node-000"); got < 0 || np.nodeAt(got).ID != "node-000" {
+		t.Errorf("indexOfNode(node-000) = %d", got)
+	}
+}
@@ -0,0 +1,115 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/utils"
+)
+
+// outboundBindingNone 网卡下拉框中"不绑定"的显示文本。
+const outboundBindingNone = "不绑定"
+
+// showOutboundBindingDialog 显示出站绑定对话框（网卡 / 源 IP），全局设置与节点设置共用。
+// 参数：
+//   - appState: 应用状态
+//   - title: 对话框标题
+//   - hint: 顶部说明文字
+//   - current: 当前绑定
+//   - onSave: 保存回调，返回错误时提示并保留对话框
+func showOutboundBindingDialog(appState *AppState, title, hint string, current model.OutboundBinding, onSave func(model.OutboundBinding) error) {
+	if appState == nil || appState.Window == nil {
+		return
+	}
+
+	ifaces, err := utils.ListNetInterfaces()
+	if err != nil {
+		appState.AppendLog("ERROR", "ui", fmt.Sprintf("枚举网卡失败: %v", err))
+	}
+	addrsByName := make(map[string][]string, len(ifaces))
+	options := []string{outboundBindingNone}
+	for _, iface := range ifaces {
+		addrsByName[iface.Name] = iface.Addrs
+		options = append(options, iface.Name)
+	}
+	// 已保存的网卡当前不存在（如未插入网线）时仍保留在选项中
+	if current.Interface != "" {
+		if _, ok := addrsByName[current.Interface]; !ok {
+			options = append(options, current.Interface)
+		}
+	}
+
+	addrsLabel := widget.NewLabel("")
+	addrsLabel.Wrapping = fyne.TextWrapWord
+	sourceEntry := widget.NewSelectEntry(nil)
+	sourceEntry.SetPlaceHolder("源 IP（留空不指定）")
+	sourceEntry.SetText(current.SendThrough)
+
+	ifaceSelect := widget.NewSelect(options, func(name string) {
+		addrs := addrsByName[name]
+		sourceEntry.SetOptions(addrs)
+		switch {
+		case name == outboundBindingNone:
+			addrsLabel.SetText("")
+		case len(addrs) == 0:
+			addrsLabel.SetText("该网卡当前不可用")
+		default:
+			addrsLabel.SetText("地址: " + strings.Join(addrs, ", "))
+		}
+	})
+	if current.Interface != "" {
+		ifaceSelect.SetSelected(current.Interface)
+	} else {
+		ifaceSelect.SetSelected(outboundBindingNone)
+	}
+
+	var d dialog.Dialog
+	saveBtn := widget.NewButton("保存", func() {
+		binding := model.OutboundBinding{SendThrough: strings.TrimSpace(sourceEntry.Text)}
+		if ifaceSelect.Selected != outboundBindingNone {
+			binding.Interface = ifaceSelect.Selected
+		}
+		if err := onSave(binding); err != nil {
+			dialog.ShowError(err, appState.Window)
+			return
+		}
+		d.Hide()
+	})
+	saveBtn.Importance = widget.HighImportance
+	clearBtn := widget.NewButton("清除", func() {
+		ifaceSelect.SetSelected(outboundBindingNone)
+		sourceEntry.SetText("")
+	})
+
+	hintLabel := widget.NewLabel(hint)
+	hintLabel.Wrapping = fyne.TextWrapWord
+	content := container.NewVBox(
+		hintLabel,
+		container.NewBorder(nil, nil, widget.NewLabel("网卡"), nil, ifaceSelect),
+		addrsLabel,
+		container.NewBorder(nil, nil, widget.NewLabel("源 IP"), nil, sourceEntry),
+		container.NewHBox(clearBtn, saveBtn),
+	)
+	d = dialog.NewCustom(title, "关闭", content, appState.Window)
+	d.Resize(fyne.NewSize(420, 0))
+	d.Show()
+}
+
+// outboundBindingSummary 返回出站绑定的简短描述。
+func outboundBindingSummary(b model.OutboundBinding) string {
+	switch {
+	case b.IsZero():
+		return outboundBindingNone
+	case b.Interface != "" && b.SendThrough != "":
+		return fmt.Sprintf("%s / %s", b.Interface, b.SendThrough)
+	case b.Interface != "":
+		return b.Interface
+	default:
+		return b.SendThrough
+	}
+}
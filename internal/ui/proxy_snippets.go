@@ -0,0 +1,73 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/service"
+)
+
+// showProxySnippetsDialog 显示当前本地代理的常用配置片段（curl、终端环境变量、git / npm / pip），每条可一键复制。
+func showProxySnippetsDialog(appState *AppState) {
+	if appState == nil || appState.Window == nil || appState.ProxyService == nil {
+		return
+	}
+	showSnippetsDialog(appState, "代理使用命令", appState.ProxyService.ProxySnippets())
+}
+
+// showIntegrationsDialog 集成：显示容器（Docker 守护进程、docker run / build）使用本地代理的配置片段。
+func showIntegrationsDialog(appState *AppState) {
+	if appState == nil || appState.Window == nil || appState.ProxyService == nil {
+		return
+	}
+	showSnippetsDialog(appState, "集成：Docker / 容器", appState.ProxyService.DockerSnippets())
+}
+
+// showSnippetsDialog 逐条显示配置片段及使用提示，每条可一键复制。
+func showSnippetsDialog(appState *AppState, title string, snippets []service.ProxySnippet) {
+	items := []fyne.CanvasObject{}
+	for _, snippet := range snippets {
+		text := snippet.Text
+		title := widget.NewLabelWithStyle(snippet.Name, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+		copyBtn := widget.NewButtonWithIcon("复制", theme.ContentCopyIcon(), func() {
+			appState.Window.Clipboard().SetContent(text)
+		})
+		copyBtn.Importance = widget.LowImportance
+		textLabel := widget.NewLabelWithStyle(text, fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+		textLabel.Wrapping = fyne.TextWrapBreak
+
+		items = append(items, container.NewBorder(nil, nil, title, copyBtn), textLabel)
+		if snippet.Note != "" {
+			note := widget.NewLabel(snippet.Note)
+			note.Wrapping = fyne.TextWrapWord
+			note.Importance = widget.WarningImportance
+			items = append(items, note)
+		}
+		items = append(items, widget.NewSeparator())
+	}
+
+	scroll := container.NewVScroll(container.NewVBox(items...))
+	scroll.SetMinSize(fyne.NewSize(520, 360))
+	d := dialog.NewCustom(title, "关闭", scroll, appState.Window)
+	d.Show()
+}
+
+// buildProxySnippetsMenuItem 构建托盘「复制代理命令」子菜单，点击即复制对应片段。
+func buildProxySnippetsMenuItem(appState *AppState, window fyne.Window) *fyne.MenuItem {
+	item := fyne.NewMenuItem("复制代理命令", nil)
+	if appState == nil || appState.ProxyService == nil || window == nil {
+		item.Disabled = true
+		return item
+	}
+	var subItems []*fyne.MenuItem
+	for _, snippet := range appState.ProxyService.ProxySnippets() {
+		text := snippet.Text
+		subItems = append(subItems, fyne.NewMenuItem(snippet.Name, func() {
+			window.Clipboard().SetContent(text)
+		}))
+	}
+	item.ChildMenu = fyne.NewMenu("", subItems...)
+	return item
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"image"
 	"image/color"
+	"image/draw"
 	"image/png"
 	"math"
 	"os"
@@ -21,6 +22,8 @@ var (
 	appIconCache      fyne.Resource
 	settingsLogoCache fyne.Resource
 	iconCacheMutex    sync.Mutex
+	// 带状态点的托盘图标缓存（按状态）
+	trayStatusIconCache = make(map[trayStatus]fyne.Resource)
 )
 
 // getIconDir 获取图标存储目录
@@ -42,6 +45,7 @@ func ClearIconCaches() {
 	defer iconCacheMutex.Unlock()
 	trayIconCache = nil
 	appIconCache = nil
+	trayStatusIconCache = make(map[trayStatus]fyne.Resource)
 }
 
 // createAppIcon 创建应用图标资源（用于窗口图标，228x228）
@@ -74,6 +78,56 @@ func createTrayIconResource(appState *AppState) fyne.Resource {
 	return trayIconCache
 }
 
+// createTrayStatusIconResource 在托盘图标右下角叠加状态点（绿/黄/红），trayStatusOff 时返回原图标。
+// 参数：
+//   - appState: 应用状态（用于获取主题配置）
+//   - status: 当前连接状态
+func createTrayStatusIconResource(appState *AppState, status trayStatus) fyne.Resource {
+	base := createTrayIconResource(appState)
+	if base == nil || status == trayStatusOff {
+		return base
+	}
+
+	iconCacheMutex.Lock()
+	defer iconCacheMutex.Unlock()
+	if res, ok := trayStatusIconCache[status]; ok {
+		return res
+	}
+
+	src, err := png.Decode(bytes.NewReader(base.Content()))
+	if err != nil {
+		return base
+	}
+	bounds := src.Bounds()
+	img := image.NewRGBA(bounds)
+	draw.Draw(img, bounds, src, bounds.Min, draw.Src)
+
+	// 状态点半径为图标的 1/5，外圈 1px 透明描边与图标主体分隔
+	r := float64(bounds.Dx()) / 5.0
+	cx := float64(bounds.Max.X) - r - 0.5
+	cy := float64(bounds.Max.Y) - r - 0.5
+	dot := status.color()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			dist := math.Hypot(float64(x)-cx, float64(y)-cy)
+			switch {
+			case dist <= r:
+				img.Set(x, y, dot)
+			case dist <= r+1:
+				img.Set(x, y, color.RGBA{})
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return base
+	}
+	res := fyne.NewStaticResource(fmt.Sprintf("tray-icon-status-%d.png", status), buf.Bytes())
+	trayStatusIconCache[status] = res
+	return res
+}
+
 // createSettingsLogo 创建设置页面logo资源（64x64，根据主题变化）
 // 参数：
 //   - appState: 应用状态（用于获取主题配置）
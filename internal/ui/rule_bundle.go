@@ -0,0 +1,321 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/service"
+)
+
+// showRuleBundleDialog 弹出导入规则包对话框：从 URL 或本地文件读取规则包，校验签名后预览，确认后才应用。
+func (sp *SettingsPage) showRuleBundleDialog() {
+	if sp.appState == nil || sp.appState.Window == nil || sp.appState.ConfigService == nil {
+		return
+	}
+	cs := sp.appState.ConfigService
+
+	hint := widget.NewLabel("规则包是包含路由规则和定时拦截规则的 JSON 文件。发布者签名时，签名保存在同名的 .sig 文件中，" +
+		"导入前会用受信任公钥校验；未签名或校验失败的规则包会在应用前明确提示。")
+	hint.Wrapping = fyne.TextWrapWord
+
+	var d dialog.Dialog
+	urlEntry := widget.NewEntry()
+	urlEntry.SetPlaceHolder("https://example.com/rules.json")
+	var fetchBtn *widget.Button
+	fetchBtn = widget.NewButtonWithIcon("下载", theme.DownloadIcon(), func() {
+		rawURL := strings.TrimSpace(urlEntry.Text)
+		if rawURL == "" {
+			return
+		}
+		fetchBtn.Disable()
+		go func() {
+			imp, err := service.FetchRuleBundle(rawURL, cs.GetTrustedKeys())
+			fyne.Do(func() {
+				fetchBtn.Enable()
+				if err != nil {
+					showErrorDetail(sp.appState, "导入规则包失败", err)
+					return
+				}
+				d.Hide()
+				sp.showRuleBundlePreview(imp)
+			})
+		}()
+	})
+
+	fileBtn := widget.NewButtonWithIcon("从文件导入…", theme.FolderOpenIcon(), func() {
+		open := dialog.NewFileOpen(func(r fyne.URIReadCloser, err error) {
+			if err != nil {
+				showErrorDetail(sp.appState, "导入规则包失败", err)
+				return
+			}
+			if r == nil {
+				return
+			}
+			defer r.Close()
+			imp, err := readRuleBundleURI(r, cs.GetTrustedKeys())
+			if err != nil {
+				showErrorDetail(sp.appState, "导入规则包失败", err)
+				return
+			}
+			d.Hide()
+			sp.showRuleBundlePreview(imp)
+		}, sp.appState.Window)
+		open.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+		open.Show()
+	})
+
+	keysBtn := widget.NewButtonWithIcon("受信任公钥", theme.AccountIcon(), sp.showTrustedKeysDialog)
+	keysBtn.Importance = widget.LowImportance
+
+	content := container.NewVBox(
+		hint,
+		container.NewBorder(nil, nil, nil, fetchBtn, urlEntry),
+		container.NewHBox(fileBtn, layout.NewSpacer(), keysBtn),
+	)
+	d = dialog.NewCustom("导入规则包", "关闭", content, sp.appState.Window)
+	d.Resize(fyne.NewSize(520, 0))
+	d.Show()
+}
+
+// readRuleBundleURI 读取本地规则包，同目录下存在同名 .sig 文件时一并读取并校验签名。
+func readRuleBundleURI(r fyne.URIReadCloser, keys []model.TrustedKey) (*service.RuleBundleImport, error) {
+	data, err := service.ReadRuleBundleFile(r)
+	if err != nil {
+		return nil, fmt.Errorf("读取规则包失败: %w", err)
+	}
+	var sig []byte
+	sigURI, err := storage.ParseURI(r.URI().String() + service.RuleBundleSignatureExt)
+	if err == nil {
+		if ok, _ := storage.Exists(sigURI); ok {
+			sr, err := storage.Reader(sigURI)
+			if err != nil {
+				return nil, fmt.Errorf("读取签名文件失败: %w", err)
+			}
+			sig, err = service.ReadRuleBundleFile(sr)
+			sr.Close()
+			if err != nil {
+				return nil, fmt.Errorf("读取签名文件失败: %w", err)
+			}
+		}
+	}
+	imp, err := service.VerifyRuleBundle(data, sig, keys)
+	if err != nil {
+		return nil, err
+	}
+	imp.Source = r.URI().Name()
+	return imp, nil
+}
+
+// ruleBundleStatusText 返回签名校验结果的提示文字和对应的显示级别。
+func ruleBundleStatusText(imp *service.RuleBundleImport) (string, widget.Importance) {
+	switch imp.Status {
+	case service.SignatureVerified:
+		return "签名有效：由受信任公钥「" + imp.Signer + "」签名，内容未被修改。", widget.SuccessImportance
+	case service.SignatureUnsigned:
+		return "未签名：无法确认规则包的来源和完整性。规则会改变流量走向（直连、代理或拦截），请只导入可信来源的规则包。",
+			widget.WarningImportance
+	default:
+		return "签名校验失败：" + imp.Detail + "。除非确认来源可靠，否则不要导入。", widget.DangerImportance
+	}
+}
+
+// showRuleBundlePreview 预览规则包内容和签名校验结果，确认后追加到现有规则。
+// 签名校验失败时需先勾选风险确认才能导入。
+func (sp *SettingsPage) showRuleBundlePreview(imp *service.RuleBundleImport) {
+	if sp.appState == nil || sp.appState.Window == nil || imp == nil {
+		return
+	}
+	bundle := imp.Bundle
+
+	statusText, importance := ruleBundleStatusText(imp)
+	status := widget.NewLabel(statusText)
+	status.Importance = importance
+	status.Wrapping = fyne.TextWrapWord
+	statusIcon := theme.ConfirmIcon()
+	if imp.Status != service.SignatureVerified {
+		statusIcon = theme.WarningIcon()
+	}
+
+	name := bundle.Name
+	if name == "" {
+		name = imp.Source
+	}
+	title := widget.NewLabelWithStyle(name, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	info := widget.NewLabel(fmt.Sprintf("来源：%s\n%d 条路由规则、%d 条定时拦截规则，导入后追加到现有规则之后（已存在的不重复添加）。",
+		imp.Source, len(bundle.Rules), len(bundle.TimeRules)))
+	info.Wrapping = fyne.TextWrapWord
+	header := container.NewVBox(container.NewBorder(nil, nil, widget.NewIcon(statusIcon), nil, status), title)
+	if bundle.Description != "" {
+		desc := widget.NewLabel(bundle.Description)
+		desc.Wrapping = fyne.TextWrapWord
+		header.Add(desc)
+	}
+	header.Add(info)
+
+	rows := container.NewVBox()
+	for _, r := range bundle.Rules {
+		rows.Add(widget.NewLabel(routeRuleLabel(r) + " → " + routeActionLabel(r.Action)))
+	}
+	for _, r := range bundle.TimeRules {
+		rows.Add(widget.NewLabel(fmt.Sprintf("定时拦截 %s  %s–%s：%s", r.Name, r.Start, r.End, strings.Join(r.Routes, ", "))))
+	}
+	scroll := container.NewVScroll(rows)
+	scroll.SetMinSize(fyne.NewSize(0, 200))
+
+	var d dialog.Dialog
+	importBtn := widget.NewButtonWithIcon("导入", theme.DownloadIcon(), func() {
+		d.Hide()
+		sp.applyRuleBundle(imp)
+	})
+	importBtn.Importance = widget.HighImportance
+	footer := container.NewHBox(layout.NewSpacer())
+	switch imp.Status {
+	case service.SignatureUnsigned:
+		importBtn.SetText("仍然导入")
+		importBtn.Importance = widget.WarningImportance
+	case service.SignatureInvalid:
+		importBtn.SetText("仍然导入")
+		importBtn.Importance = widget.DangerImportance
+		importBtn.Disable()
+		riskCheck := widget.NewCheck("我已确认来源可靠", func(b bool) {
+			if b {
+				importBtn.Enable()
+			} else {
+				importBtn.Disable()
+			}
+		})
+		footer.Add(riskCheck)
+	}
+	cancelBtn := widget.NewButton("取消", func() { d.Hide() })
+	footer.Add(cancelBtn)
+	footer.Add(importBtn)
+
+	content := container.NewBorder(header, footer, nil, nil, scroll)
+	d = dialog.NewCustomWithoutButtons("导入规则包", content, sp.appState.Window)
+	d.Resize(fyne.NewSize(560, 480))
+	d.Show()
+}
+
+// applyRuleBundle 应用规则包并重建代理，日志中记录签名校验结果。
+func (sp *SettingsPage) applyRuleBundle(imp *service.RuleBundleImport) {
+	cs := sp.appState.ConfigService
+	rules, timeRules, err := cs.ImportRuleBundle(imp.Bundle)
+	if err != nil {
+		showErrorDetail(sp.appState, "导入规则包失败", err)
+		return
+	}
+	verified := "未签名"
+	level := "WARN"
+	switch imp.Status {
+	case service.SignatureVerified:
+		verified, level = "签名者 "+imp.Signer, "INFO"
+	case service.SignatureInvalid:
+		verified = "签名校验失败"
+	}
+	sp.appState.AppendLog(level, "app", fmt.Sprintf("已导入规则包 %s（%s，%s）：新增 %d 条路由规则、%d 条定时拦截规则",
+		imp.Bundle.Name, imp.Source, verified, rules, timeRules))
+	if rules == 0 && timeRules == 0 {
+		showToast(sp.appState, FeedbackInfo, "规则包中的规则均已存在")
+		return
+	}
+
+	if timeRules > 0 && sp.appState.TimeRuleScheduler != nil {
+		sp.appState.TimeRuleScheduler.Reset()
+	}
+	sp.loadRoutes()
+	if sp.routesList != nil {
+		sp.routesList.Refresh()
+	}
+	sp.appState.ReloadProxy("导入规则包")
+	showToast(sp.appState, FeedbackSuccess, fmt.Sprintf("已导入 %d 条路由规则、%d 条定时拦截规则", rules, timeRules))
+}
+
+// showTrustedKeysDialog 管理受信任的签名公钥（ed25519），导入规则包时用于校验签名。
+func (sp *SettingsPage) showTrustedKeysDialog() {
+	if sp.appState == nil || sp.appState.Window == nil || sp.appState.ConfigService == nil {
+		return
+	}
+	cs := sp.appState.ConfigService
+	keys := cs.GetTrustedKeys()
+
+	var list *widget.List
+	// save 保存，失败时回滚为已保存的配置
+	save := func() bool {
+		if err := cs.SetTrustedKeys(keys); err != nil {
+			dialog.ShowError(err, sp.appState.Window)
+			keys = cs.GetTrustedKeys()
+			if list != nil {
+				list.Refresh()
+			}
+			return false
+		}
+		if list != nil {
+			list.Refresh()
+		}
+		return true
+	}
+
+	list = widget.NewList(
+		func() int { return len(keys) },
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			delBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), nil)
+			return container.NewBorder(nil, nil, nil, delBtn, label)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id < 0 || id >= len(keys) {
+				return
+			}
+			row := obj.(*fyne.Container)
+			label := row.Objects[0].(*widget.Label)
+			delBtn := row.Objects[1].(*widget.Button)
+			label.SetText(fmt.Sprintf("%s  ·  指纹 %s", keys[id].Name, keys[id].Fingerprint()))
+			delBtn.OnTapped = func() {
+				keys = append(keys[:id], keys[id+1:]...)
+				save()
+			}
+		},
+	)
+
+	addBtn := widget.NewButtonWithIcon("添加公钥", theme.ContentAddIcon(), func() {
+		nameEntry := widget.NewEntry()
+		nameEntry.SetPlaceHolder("如：规则发布者名称")
+		keyEntry := widget.NewMultiLineEntry()
+		keyEntry.SetPlaceHolder("Base64 公钥，或 -----BEGIN PUBLIC KEY----- 开头的 PEM")
+		keyEntry.Wrapping = fyne.TextWrapBreak
+
+		form := dialog.NewForm("添加受信任公钥", "确定", "取消", []*widget.FormItem{
+			{Text: "名称", Widget: nameEntry},
+			{Text: "公钥", Widget: keyEntry},
+		}, func(ok bool) {
+			if !ok {
+				return
+			}
+			keys = append(keys, model.TrustedKey{Name: nameEntry.Text, PublicKey: keyEntry.Text})
+			if save() {
+				sp.appState.AppendLog("INFO", "app", fmt.Sprintf("已添加受信任公钥 %s（指纹 %s）",
+					keys[len(keys)-1].Name, keys[len(keys)-1].Fingerprint()))
+			}
+		}, sp.appState.Window)
+		form.Resize(fyne.NewSize(440, 0))
+		form.Show()
+	})
+	addBtn.Importance = widget.LowImportance
+
+	hint := widget.NewLabel("用这些 ed25519 公钥校验规则包签名。添加前请通过可靠渠道与发布者核对指纹。")
+	hint.Wrapping = fyne.TextWrapWord
+	listScroll := container.NewScroll(list)
+	listScroll.SetMinSize(fyne.NewSize(360, 160))
+	content := container.NewBorder(hint, addBtn, nil, nil, listScroll)
+	d := dialog.NewCustom("受信任公钥", "关闭", content, sp.appState.Window)
+	d.Resize(fyne.NewSize(460, 0))
+	d.Show()
+}
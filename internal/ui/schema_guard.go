@@ -0,0 +1,60 @@
+package ui
+
+import (
+	"net/url"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/app"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// RunSchemaTooNew 数据库由更新版本的程序创建时显示的独立窗口：说明原因，提供备份数据库和
+// 打开数据目录，不进入主界面，关闭窗口即退出。在主界面创建之前调用，阻塞直到窗口关闭。
+// 参数：
+//   - message: 错误说明
+//   - dbPath: 数据库文件路径
+//   - backup: 备份数据库文件，返回备份路径
+func RunSchemaTooNew(message, dbPath string, backup func() (string, error)) {
+	a := app.NewWithID("com.myproxy.socks5")
+	w := a.NewWindow("myproxy - 数据库版本不兼容")
+
+	title := widget.NewLabelWithStyle("无法打开数据库", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	detail := widget.NewLabel(message + "。\n\n为避免旧版本程序错误迁移导致数据损坏，程序不会继续运行。" +
+		"请升级到新版本；如需使用当前版本，可先备份数据库，再移走数据库文件后重新启动（将创建空数据库）。")
+	detail.Wrapping = fyne.TextWrapWord
+	pathLabel := widget.NewLabelWithStyle(dbPath, fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	pathLabel.Wrapping = fyne.TextWrapBreak
+	resultLabel := widget.NewLabel("")
+	resultLabel.Wrapping = fyne.TextWrapBreak
+
+	backupBtn := widget.NewButtonWithIcon("备份数据库", theme.DocumentSaveIcon(), func() {
+		backupPath, err := backup()
+		if err != nil {
+			resultLabel.Importance = widget.DangerImportance
+			resultLabel.SetText("备份失败: " + err.Error())
+			return
+		}
+		resultLabel.Importance = widget.SuccessImportance
+		resultLabel.SetText("已备份到: " + backupPath)
+	})
+	backupBtn.Importance = widget.HighImportance
+	openDirBtn := widget.NewButtonWithIcon("打开数据目录", theme.FolderOpenIcon(), func() {
+		_ = a.OpenURL(&url.URL{Scheme: "file", Path: filepath.ToSlash(filepath.Dir(dbPath))})
+	})
+	quitBtn := widget.NewButton("退出", a.Quit)
+
+	w.SetContent(container.NewPadded(container.NewVBox(
+		container.NewHBox(widget.NewIcon(theme.ErrorIcon()), title),
+		detail,
+		pathLabel,
+		resultLabel,
+		container.NewHBox(backupBtn, openDirBtn, layout.NewSpacer(), quitBtn),
+	)))
+	w.Resize(fyne.NewSize(520, 0))
+	w.CenterOnScreen()
+	w.ShowAndRun()
+}
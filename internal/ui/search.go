@@ -0,0 +1,218 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// searchResult 全局搜索结果项；isHeader 为 true 时表示分组标题行。
+type searchResult struct {
+	isHeader bool
+	title    string
+	detail   string
+	open     func()
+}
+
+// settingsSearchEntry 设置页可搜索的菜单项及其关键字。
+type settingsSearchEntry struct {
+	menu     SettingsMenu
+	title    string
+	keywords string
+}
+
+// settingsSearchEntries 设置页中可通过全局搜索跳转的菜单。
+var settingsSearchEntries = []settingsSearchEntry{
+	{SettingsMenuAppearance, "外观", "主题 深色 浅色 跟随系统 theme"},
+	{SettingsMenuDirectRoute, "代理配置", "路由 直连 代理 拦截 终端代理 代理类型 socks5 https 定时拦截 故障转移 route " +
+		"导入规则 规则包 签名 受信任公钥 rule bundle 多入站 inbound Web 面板 dashboard 出站绑定 网卡 " +
+		"连接策略 超时 policy 域名解析 DNS 节点评分 评分权重 score 使用命令 集成 事件脚本 钩子 hook 系统改动"},
+	{SettingsMenuLog, "日志", "日志 log 错误 诊断包 diagnostics"},
+	{SettingsMenuAccessRecord, "访问记录", "访问记录 连接 域名 access"},
+	{SettingsMenuUsageStats, "使用统计", "使用统计 功能统计 报告 usage"},
+	{SettingsMenuAbout, "关于", "关于 版本 about"},
+}
+
+// showGlobalSearchDialog 全局搜索：同时搜索节点、订阅和设置，按分组显示结果，选中后跳转到对应页面和条目。
+func (mw *MainWindow) showGlobalSearchDialog() {
+	if mw == nil || mw.appState == nil || mw.appState.Window == nil {
+		return
+	}
+
+	var results []searchResult
+	var d dialog.Dialog
+
+	list := widget.NewList(
+		func() int { return len(results) },
+		func() fyne.CanvasObject {
+			title := widget.NewLabel("")
+			title.Truncation = fyne.TextTruncateEllipsis
+			detail := widget.NewLabel("")
+			detail.Importance = widget.LowImportance
+			detail.Truncation = fyne.TextTruncateEllipsis
+			return container.NewBorder(nil, nil, nil, detail, title)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id < 0 || id >= len(results) {
+				return
+			}
+			row := obj.(*fyne.Container)
+			title := row.Objects[0].(*widget.Label)
+			detail := row.Objects[1].(*widget.Label)
+			r := results[id]
+			title.TextStyle = fyne.TextStyle{Bold: r.isHeader}
+			title.SetText(r.title)
+			detail.SetText(r.detail)
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		list.UnselectAll()
+		if id < 0 || id >= len(results) || results[id].isHeader {
+			return
+		}
+		open := results[id].open
+		if d != nil {
+			d.Hide()
+		}
+		if open != nil {
+			open()
+		}
+	}
+
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder("搜索节点、订阅或设置...")
+	entry.OnChanged = func(value string) {
+		results = mw.globalSearch(value)
+		list.Refresh()
+	}
+	entry.OnSubmitted = func(string) {
+		// 回车直接打开第一条结果
+		for _, r := range results {
+			if !r.isHeader && r.open != nil {
+				if d != nil {
+					d.Hide()
+				}
+				r.open()
+				return
+			}
+		}
+	}
+
+	content := container.NewBorder(entry, nil, nil, nil, list)
+	d = dialog.NewCustom("搜索", "关闭", content, mw.appState.Window)
+	d.Resize(fyne.NewSize(380, 420))
+	d.Show()
+	mw.appState.Window.Canvas().Focus(entry)
+}
+
+// globalSearch 在节点、订阅和设置中搜索关键字（不区分大小写），返回带分组标题的结果列表。
+func (mw *MainWindow) globalSearch(query string) []searchResult {
+	q := strings.ToLower(strings.TrimSpace(query))
+	if q == "" {
+		return nil
+	}
+
+	var out []searchResult
+	appendGroup := func(name string, items []searchResult) {
+		if len(items) == 0 {
+			return
+		}
+		out = append(out, searchResult{isHeader: true, title: fmt.Sprintf("%s (%d)", name, len(items))})
+		out = append(out, items...)
+	}
+
+	store := mw.appState.Store
+
+	// 节点：名称、地址、协议
+	var nodes []searchResult
+	if store != nil && store.Nodes != nil {
+		for _, node := range store.Nodes.GetAll() {
+			if node == nil {
+				continue
+			}
+			if !strings.Contains(strings.ToLower(node.Name), q) &&
+				!strings.Contains(strings.ToLower(node.Addr), q) &&
+				!strings.Contains(strings.ToLower(node.ProtocolType), q) &&
+				!strings.Contains(strings.ToLower(node.Notes), q) {
+				continue
+			}
+			nodeID := node.ID
+			nodes = append(nodes, searchResult{
+				title:  node.Name,
+				detail: fmt.Sprintf("%s:%d", node.Addr, node.Port),
+				open: func() {
+					mw.ShowNodePage()
+					if mw.nodePageInstance != nil {
+						mw.nodePageInstance.FocusNode(nodeID)
+					}
+				},
+			})
+		}
+	}
+	appendGroup("节点", nodes)
+
+	// 订阅：标签、地址
+	var subs []searchResult
+	if store != nil && store.Subscriptions != nil {
+		for _, sub := range store.Subscriptions.GetAll() {
+			if sub == nil {
+				continue
+			}
+			if !strings.Contains(strings.ToLower(sub.Label), q) &&
+				!strings.Contains(strings.ToLower(sub.URL), q) &&
+				!strings.Contains(strings.ToLower(sub.Notes), q) {
+				continue
+			}
+			subID := sub.ID
+			title := sub.Label
+			if title == "" {
+				title = sub.URL
+			}
+			subs = append(subs, searchResult{
+				title:  title,
+				detail: sub.URL,
+				open: func() {
+					mw.ShowSubscriptionPage()
+					if mw.subscriptionPageInstance != nil {
+						mw.subscriptionPageInstance.FocusSubscription(subID)
+					}
+				},
+			})
+		}
+	}
+	appendGroup("订阅", subs)
+
+	// 设置：菜单名称和关键字
+	var settings []searchResult
+	for _, s := range settingsSearchEntries {
+		if !strings.Contains(strings.ToLower(s.title+" "+s.keywords), q) {
+			continue
+		}
+		menu := s.menu
+		settings = append(settings, searchResult{
+			title:  s.title,
+			detail: "设置",
+			open: func() {
+				mw.ShowSettingsPage()
+				if mw.settingsPageInstance != nil {
+					mw.settingsPageInstance.switchMenu(menu)
+				}
+			},
+		})
+	}
+	appendGroup("设置", settings)
+
+	return out
+}
+
+// globalSearchButton 主界面标题栏的全局搜索入口。
+func (mw *MainWindow) globalSearchButton() *widget.Button {
+	btn := NewIconButton(theme.SearchIcon(), mw.showGlobalSearchDialog)
+	btn.Importance = widget.LowImportance
+	return btn
+}
@@ -2,6 +2,9 @@ package ui
 
 import (
 	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 
 	"fyne.io/fyne/v2"
@@ -22,6 +25,7 @@ const (
 	SettingsMenuDirectRoute
 	SettingsMenuLog
 	SettingsMenuAccessRecord
+	SettingsMenuUsageStats
 	SettingsMenuAbout
 )
 
@@ -51,6 +55,8 @@ func (m SettingsMenu) String() string {
 		return "日志"
 	case SettingsMenuAccessRecord:
 		return "访问记录"
+	case SettingsMenuUsageStats:
+		return "使用统计"
 	case SettingsMenuAbout:
 		return "关于"
 	default:
@@ -100,15 +106,19 @@ func (f fixedMenuContentLayout) Layout(objects []fyne.CanvasObject, size fyne.Si
 type SettingsPage struct {
 	appState    *AppState
 	content     fyne.CanvasObject
-	menuButtons [5]*widget.Button
+	menuButtons [6]*widget.Button
 	contentCard *fyne.Container
 	currentMenu SettingsMenu
 
-	// 直连路由相关
-	routesList    *widget.List
-	routesData    []string
-	routeAddEntry *widget.Entry
-	routeUseProxy *widget.Check
+	// 路由规则相关
+	routesList      *widget.List
+	routesData      []model.RouteRule
+	routeAddEntry   *widget.Entry
+	routeAddPort    *widget.Entry
+	routeAddNetwork *widget.Select
+	routeAddAction  *widget.Select
+	routeIssues     map[int]model.RouteIssue // 静态检查发现的问题（按规则下标）
+	routesLabel     *widget.Label
 
 	// 日志：在设置页「日志」菜单中复用，用于查看日志
 	logsPanel *LogsPanel
@@ -149,7 +159,8 @@ func (sp *SettingsPage) Build() fyne.CanvasObject {
 	sp.menuButtons[1] = widget.NewButton("代理配置", func() { sp.switchMenu(SettingsMenuDirectRoute) })
 	sp.menuButtons[2] = widget.NewButton("日志", func() { sp.switchMenu(SettingsMenuLog) })
 	sp.menuButtons[3] = widget.NewButton("访问记录", func() { sp.switchMenu(SettingsMenuAccessRecord) })
-	sp.menuButtons[4] = widget.NewButton("关于", func() { sp.switchMenu(SettingsMenuAbout) })
+	sp.menuButtons[4] = widget.NewButton("使用统计", func() { sp.switchMenu(SettingsMenuUsageStats) })
+	sp.menuButtons[5] = widget.NewButton("关于", func() { sp.switchMenu(SettingsMenuAbout) })
 
 	for i := range sp.menuButtons {
 		sp.menuButtons[i].Importance = widget.LowImportance
@@ -162,6 +173,7 @@ func (sp *SettingsPage) Build() fyne.CanvasObject {
 		sp.menuButtons[2],
 		sp.menuButtons[3],
 		sp.menuButtons[4],
+		sp.menuButtons[5],
 	)
 	menuBox := container.NewPadded(menuContent)
 	// 极简柔光：浅色模式下侧边栏背景 #F1F5F9，增加物理隔离感
@@ -205,6 +217,8 @@ func (sp *SettingsPage) switchMenu(menu SettingsMenu) {
 		sp.contentCard.Add(sp.buildLogContent())
 	case SettingsMenuAccessRecord:
 		sp.contentCard.Add(sp.buildAccessRecordContent())
+	case SettingsMenuUsageStats:
+		sp.contentCard.Add(sp.buildUsageStatsContent())
 	case SettingsMenuAbout:
 		sp.contentCard.Add(sp.buildAboutContent())
 	}
@@ -283,9 +297,20 @@ func (sp *SettingsPage) buildAppearanceContent() fyne.CanvasObject {
 	}
 	themeSelect.SetSelected(currentThemeDisplay)
 
+	// 启动页面：默认恢复上次关闭时的页面，勾选后总是从主界面启动
+	startAtHomeCheck := widget.NewCheck("总是从主界面启动（不恢复上次打开的页面）", func(b bool) {
+		if sp.appState != nil && sp.appState.ConfigService != nil {
+			_ = sp.appState.ConfigService.SetAlwaysStartAtHome(b)
+		}
+	})
+	if sp.appState != nil && sp.appState.ConfigService != nil {
+		startAtHomeCheck.SetChecked(sp.appState.ConfigService.GetAlwaysStartAtHome())
+	}
+
 	return container.NewVBox(
 		widget.NewLabel("主题"),
 		themeSelect,
+		startAtHomeCheck,
 		// 添加主题预览区域
 		widget.NewSeparator(),
 		buildThemePreview(),
@@ -296,53 +321,123 @@ func (sp *SettingsPage) buildAppearanceContent() fyne.CanvasObject {
 func (sp *SettingsPage) buildDirectRouteContent() fyne.CanvasObject {
 	sp.loadRoutes()
 
-	sp.routeUseProxy = widget.NewCheck("不走直连", func(b bool) {
-		if sp.appState != nil && sp.appState.ConfigService != nil {
-			_ = sp.appState.ConfigService.SetDirectRoutesUseProxy(b)
-		}
-	})
-	if sp.appState != nil && sp.appState.ConfigService != nil {
-		sp.routeUseProxy.SetChecked(sp.appState.ConfigService.GetDirectRoutesUseProxy())
-	}
-
 	sp.routesList = widget.NewList(
 		func() int { return len(sp.routesData) },
 		func() fyne.CanvasObject {
 			textBtn := widget.NewButton("", nil)
+			warnTip := NewTooltipArea(widget.NewIcon(theme.WarningIcon()))
+			actionSelect := widget.NewSelect(routeActionOptions, nil)
 			delBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), nil)
-			return container.NewHBox(textBtn, layout.NewSpacer(), delBtn)
+			return container.NewHBox(textBtn, warnTip, layout.NewSpacer(), actionSelect, delBtn)
 		},
 		func(id widget.ListItemID, obj fyne.CanvasObject) {
 			row := obj.(*fyne.Container)
 			textBtn := row.Objects[0].(*widget.Button)
-			delBtn := row.Objects[2].(*widget.Button)
+			warnTip := row.Objects[1].(*TooltipArea)
+			actionSelect := row.Objects[3].(*widget.Select)
+			delBtn := row.Objects[4].(*widget.Button)
 
 			if id < 0 || id >= len(sp.routesData) {
 				return
 			}
-			route := sp.routesData[id]
-			textBtn.SetText(route)
+			rule := sp.routesData[id]
+			textBtn.SetText(routeRuleLabel(rule))
+			// 静态检查：重复、被覆盖或动作冲突的规则显示警告图标，悬停查看原因
+			if issue, ok := sp.routeIssues[id]; ok {
+				textBtn.Importance = widget.WarningImportance
+				warnTip.SetText(issue.Message())
+				warnTip.Show()
+			} else {
+				textBtn.Importance = widget.MediumImportance
+				warnTip.SetText("")
+				warnTip.Hide()
+			}
+			textBtn.Refresh()
 			textBtn.OnTapped = func() { sp.showEditRouteDialog(id) }
+			// 先解除回调再设置选中项，避免列表复用行时误触发保存
+			actionSelect.OnChanged = nil
+			actionSelect.SetSelected(routeActionLabel(rule.Action))
+			actionSelect.OnChanged = func(label string) { sp.setRouteAction(id, routeActionFromLabel(label)) }
 			delBtn.OnTapped = func() { sp.deleteRoute(id) }
 		},
 	)
 
 	sp.routeAddEntry = widget.NewEntry()
-	sp.routeAddEntry.SetPlaceHolder("domain:xxx 或 IP/CIDR")
+	sp.routeAddEntry.SetPlaceHolder("domain:xxx 或 IP/CIDR（仅按端口匹配时留空）")
+	sp.routeAddPort = widget.NewEntry()
+	sp.routeAddPort.SetPlaceHolder("端口")
+	sp.routeAddNetwork = widget.NewSelect(routeNetworkOptions, nil)
+	sp.routeAddNetwork.SetSelected(routeNetworkLabel(""))
+	sp.routeAddAction = widget.NewSelect(routeActionOptions, nil)
+	sp.routeAddAction.SetSelected(routeActionLabel(model.RouteActionDirect))
 	addBtn := widget.NewButtonWithIcon("添加", theme.ContentAddIcon(), sp.addRoute)
 	addBtn.Importance = widget.LowImportance
 
-	addArea := container.NewBorder(nil, nil, nil, addBtn, sp.routeAddEntry)
+	portBox := container.NewGridWrap(fyne.NewSize(110, sp.routeAddPort.MinSize().Height), sp.routeAddPort)
+	addArea := container.NewBorder(nil, nil, nil,
+		container.NewHBox(portBox, sp.routeAddNetwork, sp.routeAddAction, addBtn), sp.routeAddEntry)
 
 	listScroll := container.NewScroll(sp.routesList)
 	listScroll.SetMinSize(fyne.NewSize(0, 120))
 
-	// 重置按钮：添加默认路由（如果不存在）
+	// 重置按钮：添加默认路由规则（如果不存在）
 	resetBtn := widget.NewButtonWithIcon("重置", theme.ViewRefreshIcon(), func() {
 		sp.resetToDefaultRoutes()
 	})
 	resetBtn.Importance = widget.LowImportance
 
+	// 导入规则包：从文件或 URL 导入规则模板，应用前校验发布者签名
+	importBtn := widget.NewButtonWithIcon("导入规则", theme.DownloadIcon(), sp.showRuleBundleDialog)
+	importBtn.Importance = widget.LowImportance
+
+	// 定时拦截规则：在指定时间段内拦截列出的域名/IP
+	timeRulesBtn := widget.NewButtonWithIcon("定时拦截", theme.HistoryIcon(), sp.showTimeRulesDialog)
+	timeRulesBtn.Importance = widget.LowImportance
+
+	// 故障转移：节点优先级列表
+	failoverBtn := widget.NewButtonWithIcon("故障转移", theme.MediaReplayIcon(), sp.showFailoverDialog)
+	failoverBtn.Importance = widget.LowImportance
+
+	// 多入站：额外端口（如全局模式端口），与主入站同时运行
+	inboundsBtn := widget.NewButtonWithIcon("多入站", theme.StorageIcon(), sp.showInboundProfilesDialog)
+	inboundsBtn.Importance = widget.LowImportance
+
+	// Web 面板：只读状态页，可在浏览器或局域网设备上查看
+	dashboardBtn := widget.NewButtonWithIcon("Web 面板", theme.ComputerIcon(), sp.showDashboardDialog)
+	dashboardBtn.Importance = widget.LowImportance
+
+	// 出站绑定：多网卡机器上指定代理出站使用的网卡 / 源 IP
+	bindingBtn := widget.NewButtonWithIcon("出站绑定", theme.SettingsIcon(), sp.showOutboundBindingDialog)
+	bindingBtn.Importance = widget.LowImportance
+
+	// 连接策略：握手 / 空闲超时与缓冲区大小
+	policyBtn := widget.NewButtonWithIcon("连接策略", theme.HistoryIcon(), sp.showConnPolicyDialog)
+	policyBtn.Importance = widget.LowImportance
+
+	// 域名解析：远程 / 本地解析、Fake IP 与自定义 DNS 服务器
+	dnsBtn := widget.NewButtonWithIcon("域名解析", theme.SearchIcon(), sp.showDNSDialog)
+	dnsBtn.Importance = widget.LowImportance
+
+	// 节点评分：延迟、抖动、成功率和地区偏好的加权评分
+	scoreBtn := widget.NewButtonWithIcon("节点评分", theme.ListIcon(), sp.showNodeScoreDialog)
+	scoreBtn.Importance = widget.LowImportance
+
+	// 使用命令：复制 curl、终端环境变量及 git / npm / pip 的代理设置
+	snippetsBtn := widget.NewButtonWithIcon("使用命令", theme.ContentCopyIcon(), func() { showProxySnippetsDialog(sp.appState) })
+	snippetsBtn.Importance = widget.LowImportance
+
+	// 集成：Docker 守护进程与容器的代理配置片段
+	integrationsBtn := widget.NewButtonWithIcon("集成", theme.GridIcon(), func() { showIntegrationsDialog(sp.appState) })
+	integrationsBtn.Importance = widget.LowImportance
+
+	// 事件脚本：代理启停、切换节点、订阅更新时执行外部程序
+	hooksBtn := widget.NewButtonWithIcon("事件脚本", theme.MediaPlayIcon(), func() { showHookScriptsDialog(sp.appState) })
+	hooksBtn.Importance = widget.LowImportance
+
+	// 系统改动：列出已修改的系统代理设置、shell 配置文件等，可一键撤销
+	systemChangesBtn := widget.NewButtonWithIcon("系统改动", theme.HistoryIcon(), func() { showSystemChangesDialog(sp.appState) })
+	systemChangesBtn.Importance = widget.LowImportance
+
 	// 终端代理配置选项
 	terminalProxyCheck := widget.NewCheck("终端代理", func(b bool) {
 		if sp.appState != nil && sp.appState.ConfigService != nil {
@@ -353,6 +448,81 @@ func (sp *SettingsPage) buildDirectRouteContent() fyne.CanvasObject {
 		terminalProxyCheck.SetChecked(sp.appState.ConfigService.GetTerminalProxyEnabled())
 	}
 
+	// 过期终端代理文件：启动时发现 ~/.myproxy_proxy.sh 指向已不使用的端口时的处理方式
+	staleTerminalSelect := widget.NewSelect(staleTerminalActionLabels(), nil)
+	if sp.appState != nil && sp.appState.ConfigService != nil {
+		staleTerminalSelect.SetSelected(staleTerminalActionLabel(sp.appState.ConfigService.GetStaleTerminalAction()))
+	}
+	staleTerminalSelect.OnChanged = func(label string) {
+		if sp.appState != nil && sp.appState.ConfigService != nil {
+			_ = sp.appState.ConfigService.SetStaleTerminalAction(staleTerminalActionFromLabel(label))
+		}
+	}
+
+	// 启动前验证：主开关启动代理前先经节点请求一次，失败时提示改用其他节点
+	verifyCheck := widget.NewCheck("启动前验证节点可用", func(b bool) {
+		if sp.appState != nil && sp.appState.ConfigService != nil {
+			_ = sp.appState.ConfigService.SetVerifyBeforeStart(b)
+		}
+	})
+	if sp.appState != nil && sp.appState.ConfigService != nil {
+		verifyCheck.SetChecked(sp.appState.ConfigService.GetVerifyBeforeStart())
+	}
+
+	// 拦截 QUIC：浏览器的 HTTP/3（UDP 443）被拦截后回退到 TCP，使基于 TLS 的规则生效
+	quicCheck := widget.NewCheck("拦截 QUIC（UDP 443）", nil)
+	quicProxyOnlyCheck := widget.NewCheck("仅限代理流量", nil)
+	quicProxyOnlyCheck.SetChecked(true)
+	quicProxyOnlyCheck.Disable()
+	if sp.appState != nil && sp.appState.ConfigService != nil {
+		if mode := sp.appState.ConfigService.GetQUICBlockMode(); mode != model.QUICBlockOff {
+			quicCheck.SetChecked(true)
+			quicProxyOnlyCheck.SetChecked(mode == model.QUICBlockProxy)
+			quicProxyOnlyCheck.Enable()
+		}
+	}
+	// applyQUICBlock 按两个勾选框保存拦截范围并重建代理
+	applyQUICBlock := func() {
+		if sp.appState == nil || sp.appState.ConfigService == nil {
+			return
+		}
+		mode := model.QUICBlockOff
+		if quicCheck.Checked {
+			mode = model.QUICBlockAll
+			if quicProxyOnlyCheck.Checked {
+				mode = model.QUICBlockProxy
+			}
+		}
+		if mode == sp.appState.ConfigService.GetQUICBlockMode() {
+			return
+		}
+		if err := sp.appState.ConfigService.SetQUICBlockMode(mode); err != nil {
+			showErrorDetail(sp.appState, "保存 QUIC 拦截设置失败", err)
+			return
+		}
+		sp.appState.ReloadProxy("QUIC 拦截设置变更")
+	}
+	quicProxyOnlyCheck.OnChanged = func(bool) { applyQUICBlock() }
+	quicCheck.OnChanged = func(b bool) {
+		if b {
+			quicProxyOnlyCheck.Enable()
+		} else {
+			quicProxyOnlyCheck.Disable()
+		}
+		applyQUICBlock()
+	}
+	quicHelp := widget.NewButtonWithIcon("", theme.QuestionIcon(), func() {
+		if sp.appState == nil || sp.appState.Window == nil {
+			return
+		}
+		dialog.ShowInformation("拦截 QUIC（UDP 443）",
+			"QUIC 是浏览器 HTTP/3 使用的 UDP 协议。很多节点对 UDP 支持不佳，且 QUIC 流量不经过基于 TLS 的规则处理。\n"+
+				"拦截后浏览器会自动回退到 TCP 上的 HTTPS，网页照常访问。\n\n"+
+				"勾选「仅限代理流量」时只拦截会走代理的 QUIC，直连的不受影响（带端口或协议条件的代理规则除外）；"+
+				"取消则拦截所有 QUIC（本地地址除外）。", sp.appState.Window)
+	})
+	quicHelp.Importance = widget.LowImportance
+
 	// 代理类型选择
 	proxyTypeOptions := []string{"socks5", "https"}
 	proxyTypeSelect := widget.NewSelect(proxyTypeOptions, func(s string) {
@@ -365,62 +535,104 @@ func (sp *SettingsPage) buildDirectRouteContent() fyne.CanvasObject {
 	}
 	proxyTypeLabel := widget.NewLabel("代理类型")
 
-	// 代理配置区域：包含"终端代理"标题、"不走直连"、"重置"按钮
+	// 测速采样次数：每次测速连接多次取中位数，减少抖动
+	latencySamplesSelect := widget.NewSelect([]string{"1", "3", "5", "10"}, func(s string) {
+		n, err := strconv.Atoi(s)
+		if err != nil || sp.appState == nil || sp.appState.ConfigService == nil {
+			return
+		}
+		if err := sp.appState.ConfigService.SetLatencySamples(n); err == nil && sp.appState.Ping != nil {
+			sp.appState.Ping.SetSamples(n)
+		}
+	})
+	if sp.appState != nil && sp.appState.ConfigService != nil {
+		latencySamplesSelect.SetSelected(strconv.Itoa(sp.appState.ConfigService.GetLatencySamples()))
+	}
+
+	// 代理配置区域：包含"终端代理"标题、"重置"按钮
 	proxyConfigArea := container.NewVBox(
-		terminalProxyCheck,
+		container.NewHBox(terminalProxyCheck, layout.NewSpacer(), widget.NewLabel("文件过期时"), staleTerminalSelect),
+		verifyCheck,
+		container.NewHBox(quicCheck, quicProxyOnlyCheck, quicHelp),
 		container.NewVBox(
 			proxyTypeLabel,
 			proxyTypeSelect,
 		),
+		container.NewBorder(nil, nil, widget.NewLabel("测速采样次数"), nil, latencySamplesSelect),
 		widget.NewSeparator(),
-		container.NewHBox(sp.routeUseProxy, resetBtn, layout.NewSpacer()),
+		container.NewHBox(resetBtn, importBtn, timeRulesBtn, failoverBtn, inboundsBtn, dashboardBtn, bindingBtn, policyBtn, dnsBtn, scoreBtn, snippetsBtn, integrationsBtn, hooksBtn, systemChangesBtn, layout.NewSpacer()),
 	)
 
-	routesLabel := widget.NewLabel("路由列表")
+	sp.routesLabel = widget.NewLabel("")
+	sp.updateRoutesLabel()
 
 	// 使用 Border 布局：顶部固定代理配置区域，中间路由列表占满剩余空间，底部固定添加路由区域
 	return container.NewBorder(
-		container.NewVBox(proxyConfigArea, routesLabel), // 顶部：代理配置区域 + "路由列表"标签
+		container.NewVBox(proxyConfigArea, sp.routesLabel), // 顶部：代理配置区域 + "路由列表"标签
 		addArea, // 底部：添加路由输入框
 		nil, nil,
 		listScroll, // 中间：路由列表占满剩余空间
 	)
 }
 
-// loadRoutes 从 ConfigService 加载直连路由到 routesData。
+// loadRoutes 从 ConfigService 加载路由规则到 routesData。
 func (sp *SettingsPage) loadRoutes() {
 	sp.routesData = nil
 	if sp.appState != nil && sp.appState.ConfigService != nil {
-		sp.routesData = sp.appState.ConfigService.GetDirectRoutes()
+		sp.routesData = sp.appState.ConfigService.GetRouteRules()
 	}
 	if sp.routesData == nil {
-		sp.routesData = []string{}
+		sp.routesData = []model.RouteRule{}
 	}
+	sp.analyzeRoutes()
 }
 
-// resetToDefaultRoutes 重置直连路由：如果当前列表中没有默认路由则添加（使用map提高效率）
+// analyzeRoutes 对 routesData 做静态检查，结果用于列表行内警告和标题中的问题数。
+func (sp *SettingsPage) analyzeRoutes() {
+	sp.routeIssues = make(map[int]model.RouteIssue)
+	for _, issue := range model.AnalyzeRouteRules(sp.routesData) {
+		sp.routeIssues[issue.Index] = issue
+	}
+	sp.updateRoutesLabel()
+}
+
+// updateRoutesLabel 更新路由列表标题，有问题时附带数量。
+func (sp *SettingsPage) updateRoutesLabel() {
+	if sp.routesLabel == nil {
+		return
+	}
+	if n := len(sp.routeIssues); n > 0 {
+		sp.routesLabel.Importance = widget.WarningImportance
+		sp.routesLabel.SetText(fmt.Sprintf("路由规则（按顺序匹配）· %d 条规则可能不会按预期生效", n))
+		return
+	}
+	sp.routesLabel.Importance = widget.MediumImportance
+	sp.routesLabel.SetText("路由规则（按顺序匹配）")
+}
+
+// resetToDefaultRoutes 重置路由规则：如果当前列表中没有默认规则的目标则添加（使用map提高效率）
 func (sp *SettingsPage) resetToDefaultRoutes() {
 	if sp.appState == nil || sp.appState.ConfigService == nil {
 		return
 	}
 
-	// 从 ConfigService 获取默认路由
-	defaultRoutes := sp.appState.ConfigService.GetDefaultDirectRoutes()
-	if len(defaultRoutes) == 0 {
+	// 从 ConfigService 获取默认路由规则
+	defaultRules := sp.appState.ConfigService.GetDefaultRouteRules()
+	if len(defaultRules) == 0 {
 		return
 	}
 
 	// 使用map提高查找效率
-	existingRoutes := make(map[string]bool)
-	for _, route := range sp.routesData {
-		existingRoutes[route] = true
+	existingTargets := make(map[string]bool)
+	for _, rule := range sp.routesData {
+		existingTargets[rule.Target] = true
 	}
 
-	// 检查默认路由，如果不存在则添加
+	// 检查默认规则，如果目标不存在则添加
 	added := false
-	for _, defaultRoute := range defaultRoutes {
-		if !existingRoutes[defaultRoute] {
-			sp.routesData = append(sp.routesData, defaultRoute)
+	for _, defaultRule := range defaultRules {
+		if !existingTargets[defaultRule.Target] {
+			sp.routesData = append(sp.routesData, defaultRule)
 			added = true
 		}
 	}
@@ -436,43 +648,77 @@ func (sp *SettingsPage) resetToDefaultRoutes() {
 
 // saveRoutes 将 routesData 保存到 ConfigService。
 func (sp *SettingsPage) saveRoutes() {
+	sp.analyzeRoutes()
 	if sp.appState == nil || sp.appState.ConfigService == nil {
 		return
 	}
-	_ = sp.appState.ConfigService.SetDirectRoutes(sp.routesData)
+	if err := sp.appState.ConfigService.SetRouteRules(sp.routesData); err != nil && sp.appState.Window != nil {
+		dialog.ShowError(err, sp.appState.Window)
+		return
+	}
+	sp.appState.UsageStatsService.Record(model.UsageFeatureRouteEdit)
 }
 
-// addRoute 添加一条新路由。
+// addRoute 添加一条新路由规则，端口、协议、动作取自添加区域的输入框和选择框。
+// 目标为空但填写了端口或协议时，添加一条匹配任意地址的端口规则。
 func (sp *SettingsPage) addRoute() {
 	text := strings.TrimSpace(sp.routeAddEntry.Text)
-	if text == "" {
+	port, ok := model.NormalizeRoutePort(sp.routeAddPort.Text)
+	if !ok {
+		if sp.appState != nil && sp.appState.Window != nil {
+			dialog.ShowError(fmt.Errorf("端口格式无效，应为 443、1000-2000 或 53,443"), sp.appState.Window)
+		}
 		return
 	}
+	network := routeNetworkFromLabel(sp.routeAddNetwork.Selected)
 	routes := parseSingleRoute(text)
 	if len(routes) == 0 {
-		return
+		if port == "" && network == "" {
+			return
+		}
+		routes = []string{""}
+	}
+	action := model.RouteActionDirect
+	if sp.routeAddAction != nil {
+		action = routeActionFromLabel(sp.routeAddAction.Selected)
 	}
 	for _, r := range routes {
-		// 去重
+		rule := model.RouteRule{Target: r, Port: port, Network: network, Action: action}
+		// 去重：同一目标、端口、协议只保留一条规则
 		found := false
 		for _, existing := range sp.routesData {
-			if existing == r {
+			if existing.Target == rule.Target && existing.Port == rule.Port && existing.Network == rule.Network {
 				found = true
 				break
 			}
 		}
 		if !found {
-			sp.routesData = append(sp.routesData, r)
+			sp.routesData = append(sp.routesData, rule)
 		}
 	}
 	sp.routeAddEntry.SetText("")
+	sp.routeAddPort.SetText("")
 	sp.saveRoutes()
 	if sp.routesList != nil {
 		sp.routesList.Refresh()
+		sp.routesList.ScrollToBottom()
+	}
+	// 新规则排在最后，容易被前面的规则覆盖：立即提示
+	if issue, ok := sp.routeIssues[len(sp.routesData)-1]; ok {
+		showToast(sp.appState, FeedbackWarning, "新规则"+issue.Message())
 	}
 }
 
-// deleteRoute 删除指定索引的路由。
+// setRouteAction 修改指定索引路由规则的动作。
+func (sp *SettingsPage) setRouteAction(id widget.ListItemID, action model.RouteAction) {
+	if id < 0 || id >= len(sp.routesData) || sp.routesData[id].Action == action {
+		return
+	}
+	sp.routesData[id].Action = action
+	sp.saveRoutes()
+}
+
+// deleteRoute 删除指定索引的路由规则。
 func (sp *SettingsPage) deleteRoute(id widget.ListItemID) {
 	if id < 0 || id >= len(sp.routesData) {
 		return
@@ -489,32 +735,866 @@ func (sp *SettingsPage) showEditRouteDialog(id widget.ListItemID) {
 	if sp.appState == nil || sp.appState.Window == nil || id < 0 || id >= len(sp.routesData) {
 		return
 	}
+	rule := sp.routesData[id]
 	entry := widget.NewEntry()
-	entry.SetText(sp.routesData[id])
+	entry.SetText(rule.Target)
+	entry.SetPlaceHolder("留空表示任意地址")
+	portEntry := widget.NewEntry()
+	portEntry.SetText(rule.Port)
+	portEntry.SetPlaceHolder("如 443、1000-2000、53,443")
+	networkSelect := widget.NewSelect(routeNetworkOptions, nil)
+	networkSelect.SetSelected(routeNetworkLabel(rule.Network))
 
 	d := dialog.NewForm("编辑路由", "确定", "取消", []*widget.FormItem{
 		{Text: "路由", Widget: entry},
+		{Text: "端口", Widget: portEntry},
+		{Text: "协议", Widget: networkSelect},
 	}, func(ok bool) {
 		if !ok {
 			return
 		}
-		text := strings.TrimSpace(entry.Text)
-		if text == "" {
+		port, valid := model.NormalizeRoutePort(portEntry.Text)
+		if !valid {
+			dialog.ShowError(fmt.Errorf("端口格式无效，应为 443、1000-2000 或 53,443"), sp.appState.Window)
 			return
 		}
-		routes := parseSingleRoute(text)
-		if len(routes) > 0 {
-			sp.routesData[id] = routes[0]
-			sp.saveRoutes()
-			if sp.routesList != nil {
-				sp.routesList.Refresh()
-			}
+		edited := model.RouteRule{Port: port, Network: routeNetworkFromLabel(networkSelect.Selected), Action: rule.Action}
+		if routes := parseSingleRoute(strings.TrimSpace(entry.Text)); len(routes) > 0 {
+			edited.Target = routes[0]
+		}
+		if edited.Target == "" && !edited.HasPortMatch() {
+			return
+		}
+		sp.routesData[id] = edited
+		sp.saveRoutes()
+		if sp.routesList != nil {
+			sp.routesList.Refresh()
 		}
 	}, sp.appState.Window)
 	d.Resize(fyne.NewSize(320, 0))
 	d.Show()
 }
 
+// showTimeRulesDialog 弹出定时拦截规则管理对话框（启用/停用、添加、删除）。
+func (sp *SettingsPage) showTimeRulesDialog() {
+	if sp.appState == nil || sp.appState.Window == nil || sp.appState.ConfigService == nil {
+		return
+	}
+	rules := sp.appState.ConfigService.GetTimeRules()
+
+	// save 保存修改后的副本，成功后才替换当前列表；失败时列表保持原状并刷新以撤销界面上的勾选
+	var list *widget.List
+	save := func(next []model.TimeRule) bool {
+		if err := sp.appState.ConfigService.SetTimeRules(next); err != nil {
+			dialog.ShowError(err, sp.appState.Window)
+			if list != nil {
+				list.Refresh()
+			}
+			return false
+		}
+		rules = next
+		if sp.appState.TimeRuleScheduler != nil {
+			sp.appState.TimeRuleScheduler.Reset()
+		}
+		sp.appState.ReloadProxy("定时拦截规则已修改")
+		if list != nil {
+			list.Refresh()
+		}
+		return true
+	}
+
+	list = widget.NewList(
+		func() int { return len(rules) },
+		func() fyne.CanvasObject {
+			check := widget.NewCheck("", nil)
+			delBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), nil)
+			return container.NewBorder(nil, nil, nil, delBtn, check)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id < 0 || id >= len(rules) {
+				return
+			}
+			row := obj.(*fyne.Container)
+			check := row.Objects[0].(*widget.Check)
+			delBtn := row.Objects[1].(*widget.Button)
+			r := rules[id]
+			check.OnChanged = nil
+			check.SetText(fmt.Sprintf("%s  %s–%s（%d 条）", r.Name, r.Start, r.End, len(r.Routes)))
+			check.SetChecked(r.Enabled)
+			check.OnChanged = func(b bool) {
+				next := slices.Clone(rules)
+				next[id].Enabled = b
+				save(next)
+			}
+			delBtn.OnTapped = func() {
+				save(slices.Delete(slices.Clone(rules), id, id+1))
+			}
+		},
+	)
+
+	addBtn := widget.NewButtonWithIcon("添加规则", theme.ContentAddIcon(), func() {
+		nameEntry := widget.NewEntry()
+		nameEntry.SetPlaceHolder("如：夜间拦截")
+		startEntry := widget.NewEntry()
+		startEntry.SetText("22:00")
+		endEntry := widget.NewEntry()
+		endEntry.SetText("07:00")
+		routesEntry := widget.NewMultiLineEntry()
+		routesEntry.SetPlaceHolder("每行一条：domain:xxx 或 IP/CIDR")
+
+		form := dialog.NewForm("添加定时拦截", "确定", "取消", []*widget.FormItem{
+			{Text: "名称", Widget: nameEntry},
+			{Text: "开始", Widget: startEntry},
+			{Text: "结束", Widget: endEntry},
+			{Text: "拦截列表", Widget: routesEntry},
+		}, func(ok bool) {
+			if !ok {
+				return
+			}
+			routes := parseSingleRoute(routesEntry.Text)
+			if len(routes) == 0 {
+				return
+			}
+			save(append(slices.Clone(rules), model.TimeRule{
+				Name:    strings.TrimSpace(nameEntry.Text),
+				Start:   strings.TrimSpace(startEntry.Text),
+				End:     strings.TrimSpace(endEntry.Text),
+				Routes:  routes,
+				Enabled: true,
+			}))
+		}, sp.appState.Window)
+		form.Resize(fyne.NewSize(360, 0))
+		form.Show()
+	})
+	addBtn.Importance = widget.LowImportance
+
+	listScroll := container.NewScroll(list)
+	listScroll.SetMinSize(fyne.NewSize(320, 160))
+	content := container.NewBorder(
+		widget.NewLabel("在时间段内拦截列出的地址（结束早于开始表示跨午夜）"),
+		addBtn, nil, nil,
+		listScroll,
+	)
+	d := dialog.NewCustom("定时拦截", "关闭", content, sp.appState.Window)
+	d.Show()
+}
+
+// showFailoverDialog 弹出故障转移配置对话框：启用开关、切回主节点开关、节点优先级列表。
+func (sp *SettingsPage) showFailoverDialog() {
+	if sp.appState == nil || sp.appState.Window == nil || sp.appState.ConfigService == nil || sp.appState.Store == nil || sp.appState.Store.Nodes == nil {
+		return
+	}
+	cs := sp.appState.ConfigService
+	order := cs.GetFailoverOrder()
+
+	nodeName := func(id string) string {
+		if node, err := sp.appState.Store.Nodes.Get(id); err == nil {
+			return node.Name
+		}
+		return id + "（已删除）"
+	}
+
+	// save 保存修改后的副本，成功后才替换当前顺序；失败时顺序保持原状
+	var list *widget.List
+	save := func(next []string) bool {
+		if err := cs.SetFailoverOrder(next); err != nil {
+			showErrorDetail(sp.appState, "保存故障转移顺序失败", err)
+			return false
+		}
+		order = next
+		if list != nil {
+			list.Refresh()
+		}
+		return true
+	}
+
+	// bindCheck 勾选框修改后立即保存，保存失败时恢复勾选状态
+	bindCheck := func(text string, checked bool, set func(bool) error) *widget.Check {
+		check := widget.NewCheck(text, nil)
+		check.SetChecked(checked)
+		check.OnChanged = func(b bool) {
+			if err := set(b); err != nil {
+				showErrorDetail(sp.appState, "保存设置失败", err)
+				revertCheck(check, !b)
+			}
+		}
+		return check
+	}
+	enabledCheck := bindCheck("启用自动故障转移", cs.GetFailoverEnabled(), cs.SetFailoverEnabled)
+	returnCheck := bindCheck("主节点恢复后切回", cs.GetFailoverReturnToPrimary(), cs.SetFailoverReturnToPrimary)
+	saverCheck := bindCheck("省电模式下暂停定时检测", cs.GetPauseJobsOnBatterySaver(), cs.SetPauseJobsOnBatterySaver)
+	meteredCheck := bindCheck("按流量计费网络（含手机热点）下暂停定时检测", cs.GetPauseJobsOnMetered(), cs.SetPauseJobsOnMetered)
+
+	list = widget.NewList(
+		func() int { return len(order) },
+		func() fyne.CanvasObject {
+			upBtn := widget.NewButtonWithIcon("", theme.MoveUpIcon(), nil)
+			downBtn := widget.NewButtonWithIcon("", theme.MoveDownIcon(), nil)
+			delBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), nil)
+			upBtn.Importance = widget.LowImportance
+			downBtn.Importance = widget.LowImportance
+			delBtn.Importance = widget.LowImportance
+			return container.NewBorder(nil, nil, nil, container.NewHBox(upBtn, downBtn, delBtn), widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id < 0 || id >= len(order) {
+				return
+			}
+			row := obj.(*fyne.Container)
+			label := row.Objects[0].(*widget.Label)
+			btns := row.Objects[1].(*fyne.Container)
+			text := fmt.Sprintf("%d. %s", id+1, nodeName(order[id]))
+			if until, ok := sp.appState.NodeHealth.DegradedUntil(order[id]); ok {
+				text += fmt.Sprintf("（降级至 %s，暂不参与切换）", until.Format("15:04"))
+			}
+			label.SetText(text)
+			btns.Objects[0].(*widget.Button).OnTapped = func() {
+				if id > 0 && id < len(order) {
+					next := slices.Clone(order)
+					next[id-1], next[id] = next[id], next[id-1]
+					save(next)
+				}
+			}
+			btns.Objects[1].(*widget.Button).OnTapped = func() {
+				if id >= 0 && id < len(order)-1 {
+					next := slices.Clone(order)
+					next[id+1], next[id] = next[id], next[id+1]
+					save(next)
+				}
+			}
+			btns.Objects[2].(*widget.Button).OnTapped = func() {
+				if id >= 0 && id < len(order) {
+					save(slices.Delete(slices.Clone(order), id, id+1))
+				}
+			}
+		},
+	)
+
+	// 添加节点：下拉选择尚未在列表中的节点
+	var options []string
+	optionIDs := make(map[string]string)
+	inOrder := make(map[string]bool)
+	for _, id := range order {
+		inOrder[id] = true
+	}
+	for _, node := range sp.appState.Store.Nodes.GetAll() {
+		if inOrder[node.ID] {
+			continue
+		}
+		label := fmt.Sprintf("%s (%s:%d)", node.Name, node.Addr, node.Port)
+		options = append(options, label)
+		optionIDs[label] = node.ID
+	}
+	nodeSelect := widget.NewSelect(options, nil)
+	nodeSelect.PlaceHolder = "选择节点"
+	addBtn := widget.NewButtonWithIcon("添加", theme.ContentAddIcon(), func() {
+		id, ok := optionIDs[nodeSelect.Selected]
+		if !ok {
+			return
+		}
+		if slices.Contains(order, id) {
+			return
+		}
+		if save(append(slices.Clone(order), id)) {
+			nodeSelect.ClearSelected()
+		}
+	})
+	addBtn.Importance = widget.LowImportance
+
+	listScroll := container.NewScroll(list)
+	listScroll.SetMinSize(fyne.NewSize(340, 180))
+	content := container.NewBorder(
+		container.NewVBox(
+			enabledCheck,
+			returnCheck,
+			saverCheck,
+			meteredCheck,
+			widget.NewLabel("当前节点不可用时按以下顺序尝试（第一个为主节点）"),
+		),
+		container.NewBorder(nil, nil, nil, addBtn, nodeSelect),
+		nil, nil,
+		listScroll,
+	)
+	d := dialog.NewCustom("故障转移", "关闭", content, sp.appState.Window)
+	d.Show()
+}
+
+// inboundModeOptions 入站路由模式的显示选项
+var inboundModeOptions = []string{"规则", "全局"}
+
+// inboundModeLabel 返回入站模式的显示名称。
+func inboundModeLabel(mode model.InboundMode) string {
+	if mode == model.InboundModeGlobal {
+		return "全局"
+	}
+	return "规则"
+}
+
+// inboundModeFromLabel 由显示名称解析入站模式。
+func inboundModeFromLabel(label string) model.InboundMode {
+	if label == "全局" {
+		return model.InboundModeGlobal
+	}
+	return model.InboundModeRule
+}
+
+// showInboundProfilesDialog 弹出多入站配置对话框：在主入站之外同时监听其他端口，
+// 每个入站可选择规则或全局模式，工具直接指向对应端口即可，无需切换全局模式。
+func (sp *SettingsPage) showInboundProfilesDialog() {
+	if sp.appState == nil || sp.appState.Window == nil || sp.appState.ConfigService == nil {
+		return
+	}
+	cs := sp.appState.ConfigService
+	profiles := cs.GetInboundProfiles()
+
+	var list *widget.List
+	// save 保存并在代理运行时重建，失败时回滚为已保存的配置
+	save := func() {
+		if err := cs.SetInboundProfiles(profiles); err != nil {
+			dialog.ShowError(err, sp.appState.Window)
+			profiles = cs.GetInboundProfiles()
+		} else {
+			sp.appState.ReloadProxy("入站配置变更")
+		}
+		if list != nil {
+			list.Refresh()
+		}
+	}
+
+	list = widget.NewList(
+		func() int { return len(profiles) },
+		func() fyne.CanvasObject {
+			check := widget.NewCheck("", nil)
+			delBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), nil)
+			delBtn.Importance = widget.LowImportance
+			return container.NewBorder(nil, nil, check, delBtn, widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id < 0 || id >= len(profiles) {
+				return
+			}
+			row := obj.(*fyne.Container)
+			label := row.Objects[0].(*widget.Label)
+			check := row.Objects[1].(*widget.Check)
+			delBtn := row.Objects[2].(*widget.Button)
+			p := profiles[id]
+			label.SetText(fmt.Sprintf("%s · %s 127.0.0.1:%d · %s", p.Name, p.Protocol, p.Port, inboundModeLabel(p.Mode)))
+			// 先解除回调再设置状态，避免列表复用行时误触发保存
+			check.OnChanged = nil
+			check.SetChecked(p.Enabled)
+			check.OnChanged = func(b bool) {
+				profiles[id].Enabled = b
+				save()
+			}
+			delBtn.OnTapped = func() {
+				profiles = append(profiles[:id], profiles[id+1:]...)
+				save()
+			}
+		},
+	)
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("名称")
+	portEntry := widget.NewEntry()
+	portEntry.SetPlaceHolder("端口")
+	protocolSelect := widget.NewSelect([]string{string(model.InboundProtocolSocks), string(model.InboundProtocolHTTP)}, nil)
+	protocolSelect.SetSelected(string(model.InboundProtocolSocks))
+	modeSelect := widget.NewSelect(inboundModeOptions, nil)
+	modeSelect.SetSelected(inboundModeLabel(model.InboundModeGlobal))
+	addBtn := widget.NewButtonWithIcon("添加", theme.ContentAddIcon(), func() {
+		port, err := strconv.Atoi(strings.TrimSpace(portEntry.Text))
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("端口无效: %s", portEntry.Text), sp.appState.Window)
+			return
+		}
+		name := strings.TrimSpace(nameEntry.Text)
+		if name == "" {
+			name = fmt.Sprintf("%s-%d", inboundModeLabel(inboundModeFromLabel(modeSelect.Selected)), port)
+		}
+		profiles = append(profiles, model.InboundProfile{
+			Name:     name,
+			Port:     port,
+			Protocol: model.InboundProtocol(protocolSelect.Selected),
+			Mode:     inboundModeFromLabel(modeSelect.Selected),
+			Enabled:  true,
+		})
+		save()
+		nameEntry.SetText("")
+		portEntry.SetText("")
+	})
+	addBtn.Importance = widget.LowImportance
+
+	listScroll := container.NewScroll(list)
+	listScroll.SetMinSize(fyne.NewSize(380, 160))
+	content := container.NewBorder(
+		widget.NewLabel(fmt.Sprintf("主入站 socks 127.0.0.1:%d 使用规则模式；以下入站同时运行", model.MainInboundPort)),
+		container.NewVBox(
+			container.NewGridWithColumns(2, nameEntry, portEntry),
+			container.NewBorder(nil, nil, nil, addBtn, container.NewGridWithColumns(2, protocolSelect, modeSelect)),
+		),
+		nil, nil,
+		listScroll,
+	)
+	d := dialog.NewCustom("多入站", "关闭", content, sp.appState.Window)
+	d.Show()
+}
+
+// showDashboardDialog 弹出 Web 面板配置对话框：启用开关、局域网访问、端口、访问地址与令牌重置。
+func (sp *SettingsPage) showDashboardDialog() {
+	if sp.appState == nil || sp.appState.Window == nil || sp.appState.ConfigService == nil || sp.appState.DashboardService == nil {
+		return
+	}
+	cs := sp.appState.ConfigService
+	ds := sp.appState.DashboardService
+
+	urlEntry := NewSecretEntry("未启动")
+	urlEntry.SetText(ds.URL())
+	copyBtn := widget.NewButtonWithIcon("", theme.ContentCopyIcon(), func() {
+		if u := ds.URL(); u != "" {
+			sp.appState.Window.Clipboard().SetContent(u)
+		}
+	})
+	copyBtn.Importance = widget.LowImportance
+	openBtn := widget.NewButtonWithIcon("", theme.ComputerIcon(), func() {
+		if parsed, err := url.Parse(ds.URL()); err == nil && parsed.Host != "" {
+			_ = sp.appState.App.OpenURL(parsed)
+		}
+	})
+	openBtn.Importance = widget.LowImportance
+
+	// restart 按当前配置重启面板（或关闭），并刷新地址显示
+	restart := func() {
+		if !cs.GetDashboardEnabled() {
+			ds.Stop()
+			urlEntry.SetText("")
+			return
+		}
+		u, err := ds.Start()
+		if err != nil {
+			urlEntry.SetText("")
+			dialog.ShowError(err, sp.appState.Window)
+			return
+		}
+		urlEntry.SetText(u)
+	}
+
+	enabledCheck := widget.NewCheck("启用 Web 面板", nil)
+	enabledCheck.SetChecked(cs.GetDashboardEnabled())
+	enabledCheck.OnChanged = func(b bool) {
+		if err := cs.SetDashboardEnabled(b); err != nil {
+			showErrorDetail(sp.appState, "保存 Web 面板开关失败", err)
+			revertCheck(enabledCheck, !b)
+			return
+		}
+		restart()
+	}
+	lanCheck := widget.NewCheck("允许局域网访问", nil)
+	lanCheck.SetChecked(cs.GetDashboardAllowLAN())
+	lanCheck.OnChanged = func(b bool) {
+		if err := cs.SetDashboardAllowLAN(b); err != nil {
+			showErrorDetail(sp.appState, "保存局域网访问设置失败", err)
+			revertCheck(lanCheck, !b)
+			return
+		}
+		restart()
+	}
+
+	portEntry := widget.NewEntry()
+	portEntry.SetText(strconv.Itoa(cs.GetDashboardPort()))
+	portEntry.OnSubmitted = func(text string) {
+		port, err := strconv.Atoi(strings.TrimSpace(text))
+		if err == nil {
+			err = cs.SetDashboardPort(port)
+		}
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("端口无效: %w", err), sp.appState.Window)
+			portEntry.SetText(strconv.Itoa(cs.GetDashboardPort()))
+			return
+		}
+		restart()
+	}
+
+	resetTokenBtn := widget.NewButtonWithIcon("重置令牌", theme.ViewRefreshIcon(), func() {
+		dialog.ShowConfirm("重置令牌", "重置后已打开的面板页面和旧地址将无法访问，确定继续？", func(ok bool) {
+			if !ok {
+				return
+			}
+			if _, err := cs.ResetControlToken(); err != nil {
+				dialog.ShowError(err, sp.appState.Window)
+				return
+			}
+			restart()
+		}, sp.appState.Window)
+	})
+	resetTokenBtn.Importance = widget.LowImportance
+
+	content := container.NewVBox(
+		enabledCheck,
+		lanCheck,
+		container.NewBorder(nil, nil, widget.NewLabel("端口（回车生效）"), nil, portEntry),
+		widget.NewLabel("访问地址（含令牌，请勿泄露）"),
+		container.NewBorder(nil, nil, nil, container.NewHBox(copyBtn, openBtn), urlEntry),
+		container.NewHBox(resetTokenBtn, layout.NewSpacer()),
+	)
+	d := dialog.NewCustom("Web 面板", "关闭", content, sp.appState.Window)
+	d.Resize(fyne.NewSize(420, 0))
+	d.Show()
+}
+
+// showOutboundBindingDialog 显示全局出站绑定设置，节点单独设置时以节点为准。
+func (sp *SettingsPage) showOutboundBindingDialog() {
+	if sp.appState == nil || sp.appState.ConfigService == nil {
+		return
+	}
+	cs := sp.appState.ConfigService
+	showOutboundBindingDialog(sp.appState, "出站绑定", "代理出站连接使用的网卡或源 IP，适用于多网卡机器。节点菜单中可单独设置，节点设置优先。",
+		cs.GetOutboundBinding(), func(b model.OutboundBinding) error {
+			if err := cs.SetOutboundBinding(b); err != nil {
+				return err
+			}
+			sp.appState.ReloadProxy("出站绑定变更")
+			return nil
+		})
+}
+
+// showConnPolicyDialog 显示连接策略设置（xray policy 超时与缓冲区），保存后重建代理生效。
+func (sp *SettingsPage) showConnPolicyDialog() {
+	if sp.appState == nil || sp.appState.ConfigService == nil || sp.appState.Window == nil {
+		return
+	}
+	cs := sp.appState.ConfigService
+
+	handshakeEntry := widget.NewEntry()
+	connIdleEntry := widget.NewEntry()
+	uplinkEntry := widget.NewEntry()
+	downlinkEntry := widget.NewEntry()
+	bufferEntry := widget.NewEntry()
+	bufferEntry.SetPlaceHolder("留空使用默认值")
+	fill := func(p model.ConnPolicy) {
+		handshakeEntry.SetText(strconv.Itoa(p.Handshake))
+		connIdleEntry.SetText(strconv.Itoa(p.ConnIdle))
+		uplinkEntry.SetText(strconv.Itoa(p.UplinkOnly))
+		downlinkEntry.SetText(strconv.Itoa(p.DownlinkOnly))
+		bufferEntry.SetText("")
+		if p.BufferSize >= 0 {
+			bufferEntry.SetText(strconv.Itoa(p.BufferSize))
+		}
+	}
+	fill(cs.GetConnPolicy())
+
+	parse := func() (model.ConnPolicy, error) {
+		var p model.ConnPolicy
+		entries := []*widget.Entry{handshakeEntry, connIdleEntry, uplinkEntry, downlinkEntry}
+		names := []string{"握手超时", "空闲超时", "上行保留", "下行保留"}
+		dsts := []*int{&p.Handshake, &p.ConnIdle, &p.UplinkOnly, &p.DownlinkOnly}
+		for i, entry := range entries {
+			n, err := strconv.Atoi(strings.TrimSpace(entry.Text))
+			if err != nil {
+				return p, fmt.Errorf("%s必须是整数", names[i])
+			}
+			*dsts[i] = n
+		}
+		p.BufferSize = -1
+		if text := strings.TrimSpace(bufferEntry.Text); text != "" {
+			n, err := strconv.Atoi(text)
+			if err != nil {
+				return p, fmt.Errorf("缓冲区大小必须是整数")
+			}
+			p.BufferSize = n
+		}
+		return p, p.Validate()
+	}
+
+	var d dialog.Dialog
+	saveBtn := widget.NewButton("保存", func() {
+		p, err := parse()
+		if err == nil {
+			err = cs.SetConnPolicy(p)
+		}
+		if err != nil {
+			dialog.ShowError(err, sp.appState.Window)
+			return
+		}
+		sp.appState.ReloadProxy("连接策略变更")
+		showToast(sp.appState, FeedbackSuccess, "连接策略已保存")
+		d.Hide()
+	})
+	saveBtn.Importance = widget.HighImportance
+	resetBtn := widget.NewButton("恢复默认", func() { fill(model.DefaultConnPolicy()) })
+
+	hint := widget.NewLabel("长连接（如 SSH、WebSocket）被意外断开时可调大空闲超时；连接长期不释放时可调小。默认值与 xray-core 一致。")
+	hint.Wrapping = fyne.TextWrapWord
+	form := widget.NewForm(
+		widget.NewFormItem("握手超时（秒）", handshakeEntry),
+		widget.NewFormItem("空闲超时（秒）", connIdleEntry),
+		widget.NewFormItem("上行保留（秒）", uplinkEntry),
+		widget.NewFormItem("下行保留（秒）", downlinkEntry),
+		widget.NewFormItem("缓冲区（KB）", bufferEntry),
+	)
+	d = dialog.NewCustom("连接策略", "关闭", container.NewVBox(hint, form, container.NewHBox(resetBtn, saveBtn)), sp.appState.Window)
+	d.Resize(fyne.NewSize(420, 0))
+	d.Show()
+}
+
+// dnsModeOptions 解析方式选项（显示名与取值顺序一致）
+var dnsModeOptions = []struct {
+	label string
+	mode  model.DNSMode
+}{
+	{"远程解析（默认）", model.DNSModeRemote},
+	{"本地解析", model.DNSModeLocal},
+	{"Fake IP", model.DNSModeFakeIP},
+}
+
+// showDNSDialog 域名解析设置：解析方式、自定义 DNS 服务器（每行一个）、缓存开关和 Fake IP 模式的本地 DNS 端口。
+func (sp *SettingsPage) showDNSDialog() {
+	if sp.appState == nil || sp.appState.ConfigService == nil || sp.appState.Window == nil {
+		return
+	}
+	cs := sp.appState.ConfigService
+
+	modeLabels := make([]string, len(dnsModeOptions))
+	for i, o := range dnsModeOptions {
+		modeLabels[i] = o.label
+	}
+	modeHint := widget.NewLabel("")
+	modeHint.Wrapping = fyne.TextWrapWord
+	portEntry := widget.NewEntry()
+	modeSelect := widget.NewSelect(modeLabels, func(label string) {
+		switch label {
+		case dnsModeOptions[1].label:
+			modeHint.SetText("路由前先用下方 DNS 解析域名，使 IP、网段规则对域名访问也生效；解析请求从本机发出。")
+			portEntry.Disable()
+		case dnsModeOptions[2].label:
+			modeHint.SetText("本机 127.0.0.1 上开启 DNS 服务，只返回 198.18.0.0/15 的虚拟地址，真实地址由节点解析，" +
+				"可防止受限网络中的 DNS 泄漏。需把系统或应用的 DNS 设为 127.0.0.1 并使用下方端口（系统 DNS 需为 53 端口）。")
+			portEntry.Enable()
+		default:
+			modeHint.SetText("走代理的域名交给节点解析，本机不查询（客户端使用 socks5h 或 HTTP 代理时）；直连流量使用下方 DNS，未填写时使用系统 DNS。")
+			portEntry.Disable()
+		}
+	})
+	serversEntry := widget.NewMultiLineEntry()
+	serversEntry.SetPlaceHolder("每行一个，留空使用系统 DNS，例如：\n223.5.5.5\nhttps://1.1.1.1/dns-query\ntcp://8.8.8.8:53")
+	serversEntry.SetMinRowsVisible(4)
+	cacheCheck := widget.NewCheck("缓存解析结果", nil)
+
+	fill := func(o model.DNSOptions) {
+		for _, opt := range dnsModeOptions {
+			if opt.mode == o.Mode {
+				modeSelect.SetSelected(opt.label)
+			}
+		}
+		serversEntry.SetText(strings.Join(o.Servers, "\n"))
+		cacheCheck.SetChecked(!o.DisableCache)
+		portEntry.SetText(strconv.Itoa(o.ListenPort))
+	}
+	fill(cs.GetDNSOptions())
+
+	parse := func() (model.DNSOptions, error) {
+		o := model.DNSOptions{Mode: model.DNSModeRemote, DisableCache: !cacheCheck.Checked}
+		for _, opt := range dnsModeOptions {
+			if opt.label == modeSelect.Selected {
+				o.Mode = opt.mode
+			}
+		}
+		for _, line := range strings.Split(serversEntry.Text, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				o.Servers = append(o.Servers, line)
+			}
+		}
+		port, err := strconv.Atoi(strings.TrimSpace(portEntry.Text))
+		if err != nil {
+			return o, fmt.Errorf("DNS 端口必须是整数")
+		}
+		o.ListenPort = port
+		return o, o.Validate()
+	}
+
+	var d dialog.Dialog
+	saveBtn := widget.NewButton("保存", func() {
+		o, err := parse()
+		if err == nil {
+			err = cs.SetDNSOptions(o)
+		}
+		if err != nil {
+			dialog.ShowError(err, sp.appState.Window)
+			return
+		}
+		sp.appState.ReloadProxy("域名解析设置变更")
+		showToast(sp.appState, FeedbackSuccess, "域名解析设置已保存")
+		d.Hide()
+	})
+	saveBtn.Importance = widget.HighImportance
+	resetBtn := widget.NewButton("恢复默认", func() { fill(model.DefaultDNSOptions()) })
+
+	hint := widget.NewLabel("支持 IP（UDP）、tcp://、https://（DoH）、https+local://、quic+local://，xray 不支持 DoT。")
+	hint.Wrapping = fyne.TextWrapWord
+	form := widget.NewForm(
+		widget.NewFormItem("解析方式", modeSelect),
+		widget.NewFormItem("", modeHint),
+		widget.NewFormItem("DNS 服务器", serversEntry),
+		widget.NewFormItem("", cacheCheck),
+		widget.NewFormItem("本地 DNS 端口", portEntry),
+	)
+	d = dialog.NewCustom("域名解析", "关闭", container.NewVBox(form, hint, container.NewHBox(resetBtn, saveBtn)), sp.appState.Window)
+	d.Resize(fyne.NewSize(480, 0))
+	d.Show()
+}
+
+// showNodeScoreDialog 节点评分设置：延迟、抖动、成功率、地区偏好的权重（0–10），偏好地区关键字，
+// 以及是否按评分自动选择备选节点。保存后刷新节点列表的评分。
+func (sp *SettingsPage) showNodeScoreDialog() {
+	if sp.appState == nil || sp.appState.ConfigService == nil || sp.appState.Window == nil {
+		return
+	}
+	cs := sp.appState.ConfigService
+
+	// newWeightSlider 权重滑块（0–10，步长 1），右侧显示当前值
+	newWeightSlider := func() (*widget.Slider, fyne.CanvasObject) {
+		slider := widget.NewSlider(0, model.NodeScoreMaxWeight)
+		slider.Step = 1
+		value := widget.NewLabel("")
+		slider.OnChanged = func(v float64) { value.SetText(strconv.Itoa(int(v))) }
+		return slider, container.NewBorder(nil, nil, nil, value, slider)
+	}
+	latencySlider, latencyRow := newWeightSlider()
+	jitterSlider, jitterRow := newWeightSlider()
+	successSlider, successRow := newWeightSlider()
+	regionSlider, regionRow := newWeightSlider()
+	regionsEntry := widget.NewEntry()
+	regionsEntry.SetPlaceHolder("逗号分隔，如 香港, HK, 日本")
+	selectionCheck := widget.NewCheck("自动选择备选节点时按评分（而不是延迟）", nil)
+
+	fill := func(w model.NodeScoreWeights) {
+		latencySlider.SetValue(w.Latency)
+		jitterSlider.SetValue(w.Jitter)
+		successSlider.SetValue(w.Success)
+		regionSlider.SetValue(w.Region)
+		regionsEntry.SetText(strings.Join(w.PreferredRegions, ", "))
+		selectionCheck.SetChecked(w.UseForSelection)
+	}
+	fill(cs.GetNodeScoreWeights())
+
+	var d dialog.Dialog
+	saveBtn := widget.NewButton("保存", func() {
+		w := model.NodeScoreWeights{
+			Latency:         latencySlider.Value,
+			Jitter:          jitterSlider.Value,
+			Success:         successSlider.Value,
+			Region:          regionSlider.Value,
+			UseForSelection: selectionCheck.Checked,
+		}
+		for _, r := range strings.FieldsFunc(regionsEntry.Text, func(c rune) bool { return c == ',' || c == '，' }) {
+			if r = strings.TrimSpace(r); r != "" {
+				w.PreferredRegions = append(w.PreferredRegions, r)
+			}
+		}
+		if err := cs.SetNodeScoreWeights(w); err != nil {
+			dialog.ShowError(err, sp.appState.Window)
+			return
+		}
+		if mw := sp.appState.MainWindow; mw != nil && mw.nodePageInstance != nil {
+			mw.nodePageInstance.Refresh()
+		}
+		showToast(sp.appState, FeedbackSuccess, "节点评分设置已保存")
+		d.Hide()
+	})
+	saveBtn.Importance = widget.HighImportance
+	resetBtn := widget.NewButton("恢复默认", func() { fill(model.DefaultNodeScoreWeights()) })
+
+	hint := widget.NewLabel("节点列表在延迟下方显示 0–100 的综合评分：各项按权重加权平均，权重为 0 的项不计入。" +
+		"抖动取最近一次测速的 P95 与最小延迟之差（采样 1 次时不计入），成功率为本次运行累计的测速成功率，" +
+		"名称包含任一偏好地区关键字的节点地区项满分。")
+	hint.Wrapping = fyne.TextWrapWord
+	form := widget.NewForm(
+		widget.NewFormItem("延迟", latencyRow),
+		widget.NewFormItem("抖动", jitterRow),
+		widget.NewFormItem("成功率", successRow),
+		widget.NewFormItem("地区偏好", regionRow),
+		widget.NewFormItem("偏好地区", regionsEntry),
+	)
+	d = dialog.NewCustom("节点评分", "关闭", container.NewVBox(hint, form, selectionCheck, container.NewHBox(resetBtn, saveBtn)), sp.appState.Window)
+	d.Resize(fyne.NewSize(480, 0))
+	d.Show()
+}
+
+// routeActionOptions 路由规则动作的显示选项（顺序与下拉框一致）。
+var routeActionOptions = []string{"直连", "代理", "拦截"}
+
+// routeActionLabel 返回路由动作的显示名称。
+func routeActionLabel(action model.RouteAction) string {
+	switch action {
+	case model.RouteActionProxy:
+		return "代理"
+	case model.RouteActionBlock:
+		return "拦截"
+	default:
+		return "直连"
+	}
+}
+
+// routeNetworkOptions 路由规则协议的显示选项，"TCP+UDP" 对应空值。
+var routeNetworkOptions = []string{"TCP+UDP", "TCP", "UDP"}
+
+// routeNetworkLabel 返回路由协议的显示名称。
+func routeNetworkLabel(network string) string {
+	switch network {
+	case model.RouteNetworkTCP:
+		return "TCP"
+	case model.RouteNetworkUDP:
+		return "UDP"
+	default:
+		return "TCP+UDP"
+	}
+}
+
+// routeNetworkFromLabel 将显示名称转换为路由协议。
+func routeNetworkFromLabel(label string) string {
+	switch label {
+	case "TCP":
+		return model.RouteNetworkTCP
+	case "UDP":
+		return model.RouteNetworkUDP
+	default:
+		return ""
+	}
+}
+
+// routeRuleLabel 返回路由规则在列表中的显示文本，如 "UDP 443"、"domain:x.com · TCP 22"。
+func routeRuleLabel(rule model.RouteRule) string {
+	var cond []string
+	if rule.Network != "" {
+		cond = append(cond, routeNetworkLabel(rule.Network))
+	}
+	if rule.Port != "" {
+		cond = append(cond, rule.Port)
+	}
+	switch {
+	case len(cond) == 0:
+		return rule.Target
+	case rule.Target == "":
+		if rule.Network == "" {
+			return "端口 " + rule.Port
+		}
+		return strings.Join(cond, " ")
+	default:
+		return rule.Target + " · " + strings.Join(cond, " ")
+	}
+}
+
+// routeActionFromLabel 将显示名称转换为路由动作。
+func routeActionFromLabel(label string) model.RouteAction {
+	switch label {
+	case "代理":
+		return model.RouteActionProxy
+	case "拦截":
+		return model.RouteActionBlock
+	default:
+		return model.RouteActionDirect
+	}
+}
+
 // parseSingleRoute 解析单条路由输入，返回规范化后的列表。
 func parseSingleRoute(input string) []string {
 	// 复用 ConfigService 的解析逻辑：通过换行分割，空行忽略
@@ -550,15 +1630,205 @@ func isLikelyIPOrCIDR(s string) bool {
 	return true
 }
 
-// buildLogContent 构建设置「日志」内容区，嵌入完整日志面板用于查看日志。
+// buildLogContent 构建设置「日志」内容区，嵌入完整日志面板用于查看日志，
+// 顶部提供诊断包的导出（发给他人排查）和打开（只读查看他人导出的诊断包）。
 func (sp *SettingsPage) buildLogContent() fyne.CanvasObject {
+	exportBtn := widget.NewButtonWithIcon("导出诊断包", theme.DocumentSaveIcon(), func() { exportDiagnosticsBundle(sp.appState) })
+	exportBtn.Importance = widget.LowImportance
+	openBtn := widget.NewButtonWithIcon("打开诊断包", theme.FolderOpenIcon(), func() { openDiagnosticsBundle(sp.appState) })
+	openBtn.Importance = widget.LowImportance
+	captureBtn := widget.NewButtonWithIcon("调试捕获", theme.MediaRecordIcon(), func() { showDebugCaptureDialog(sp.appState) })
+	captureBtn.Importance = widget.LowImportance
+	debugSessionBtn := widget.NewButtonWithIcon("调试会话", theme.WarningIcon(), func() { showDebugSessionDialog(sp.appState) })
+	debugSessionBtn.Importance = widget.LowImportance
+	toolbar := container.NewVBox(sp.buildXrayLogOptions(), container.NewHBox(captureBtn, debugSessionBtn, layout.NewSpacer(), exportBtn, openBtn))
+
+	var panel fyne.CanvasObject
 	if sp.appState != nil && sp.appState.LogsPanel != nil {
-		return sp.appState.LogsPanel.Build()
+		panel = sp.appState.LogsPanel.Build()
+	} else {
+		if sp.logsPanel == nil {
+			sp.logsPanel = NewLogsPanel(sp.appState)
+		}
+		panel = sp.logsPanel.Build()
+	}
+	return container.NewBorder(toolbar, nil, nil, nil, panel)
+}
+
+// xrayLogLevelLabels xray 核心日志级别的显示名称。
+var xrayLogLevelLabels = map[string]string{
+	model.XrayLogLevelDebug:   "调试",
+	model.XrayLogLevelInfo:    "信息",
+	model.XrayLogLevelWarning: "警告",
+	model.XrayLogLevelError:   "错误",
+	model.XrayLogLevelNone:    "关闭",
+}
+
+// buildXrayLogOptions 构建 xray 日志设置：核心日志级别、访问日志开关与独立文件，修改后立即保存并重建运行中的代理。
+func (sp *SettingsPage) buildXrayLogOptions() fyne.CanvasObject {
+	if sp.appState == nil || sp.appState.ConfigService == nil {
+		return container.NewHBox()
+	}
+	cs := sp.appState.ConfigService
+	options := cs.GetXrayLogOptions()
+
+	save := func() {
+		if err := cs.SetXrayLogOptions(options); err != nil {
+			showErrorDetail(sp.appState, "保存日志设置失败", err)
+			return
+		}
+		sp.appState.ApplyXrayLogOptions()
+	}
+
+	levelLabels := make([]string, len(model.XrayLogLevels))
+	for i, level := range model.XrayLogLevels {
+		levelLabels[i] = xrayLogLevelLabels[level]
+	}
+	levelSel := widget.NewSelect(levelLabels, nil)
+	levelSel.SetSelected(xrayLogLevelLabels[options.Level])
+	levelSel.OnChanged = func(label string) {
+		for _, level := range model.XrayLogLevels {
+			if xrayLogLevelLabels[level] == label && level != options.Level {
+				options.Level = level
+				save()
+				return
+			}
+		}
+	}
+
+	fileEntry := widget.NewEntry()
+	fileEntry.SetPlaceHolder("留空则只生成访问记录，不写入文件")
+	fileEntry.SetText(options.AccessLogFile)
+	fileEntry.OnSubmitted = func(text string) {
+		text = strings.TrimSpace(text)
+		if text != options.AccessLogFile {
+			options.AccessLogFile = text
+			save()
+			showToast(sp.appState, FeedbackSuccess, "访问日志文件已更新")
+		}
+	}
+	accessCheck := widget.NewCheck("访问日志（用于访问记录）", nil)
+	accessCheck.SetChecked(options.AccessLog)
+	if !options.AccessLog {
+		fileEntry.Disable()
+	}
+	accessCheck.OnChanged = func(b bool) {
+		options.AccessLog = b
+		if b {
+			fileEntry.Enable()
+		} else {
+			fileEntry.Disable()
+		}
+		save()
+	}
+
+	return container.NewPadded(container.NewVBox(
+		container.NewHBox(
+			widget.NewLabel("核心日志"),
+			container.NewGridWrap(fyne.NewSize(100, 40), levelSel),
+			layout.NewSpacer(),
+			accessCheck,
+		),
+		container.NewBorder(nil, nil, widget.NewLabel("访问日志文件"), nil, fileEntry),
+	))
+}
+
+// usageFeatureLabels 功能使用统计的显示名称。
+var usageFeatureLabels = map[model.UsageFeature]string{
+	model.UsageFeatureProxyStart:         "启动代理",
+	model.UsageFeatureSpeedTest:          "单节点测速",
+	model.UsageFeatureSpeedTestAll:       "一键测速",
+	model.UsageFeatureSubscriptionUpdate: "更新订阅",
+	model.UsageFeatureSystemProxy:        "切换系统代理",
+	model.UsageFeatureTraySwitch:         "托盘切换节点",
+	model.UsageFeatureFailoverSwitch:     "故障转移切换",
+	model.UsageFeatureRouteEdit:          "编辑路由规则",
+	model.UsageFeatureShare:              "局域网分享",
+}
+
+// buildUsageStatsContent 构建设置「使用统计」内容区：本地功能使用次数、开关、生成报告与清空。
+func (sp *SettingsPage) buildUsageStatsContent() fyne.CanvasObject {
+	if sp.appState == nil || sp.appState.UsageStatsService == nil || sp.appState.ConfigService == nil {
+		return widget.NewLabel("使用统计不可用")
 	}
-	if sp.logsPanel == nil {
-		sp.logsPanel = NewLogsPanel(sp.appState)
+	us := sp.appState.UsageStatsService
+	stats := us.GetAll()
+
+	list := widget.NewList(
+		func() int { return len(stats) },
+		func() fyne.CanvasObject {
+			return container.NewHBox(widget.NewLabel(""), layout.NewSpacer(), widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id < 0 || id >= len(stats) {
+				return
+			}
+			st := stats[id]
+			name := usageFeatureLabels[st.Feature]
+			if name == "" {
+				name = string(st.Feature)
+			}
+			row := obj.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(name)
+			row.Objects[2].(*widget.Label).SetText(fmt.Sprintf("%d 次 · 最近 %s", st.Count, st.LastUsed.Local().Format("2006-01-02 15:04")))
+		},
+	)
+	reload := func() {
+		stats = us.GetAll()
+		list.Refresh()
 	}
-	return sp.logsPanel.Build()
+
+	enabledCheck := widget.NewCheck("记录功能使用次数（仅保存在本机，不会上传）", func(b bool) {
+		_ = sp.appState.ConfigService.SetUsageStatsEnabled(b)
+	})
+	enabledCheck.SetChecked(sp.appState.ConfigService.GetUsageStatsEnabled())
+
+	reportBtn := widget.NewButtonWithIcon("生成报告", theme.DocumentIcon(), func() {
+		report, err := us.GenerateReport()
+		if err != nil {
+			dialog.ShowError(err, sp.appState.Window)
+			return
+		}
+		entry := widget.NewMultiLineEntry()
+		entry.SetText(report)
+		entry.Wrapping = fyne.TextWrapBreak
+		copyBtn := widget.NewButtonWithIcon("复制", theme.ContentCopyIcon(), func() {
+			sp.appState.Window.Clipboard().SetContent(report)
+		})
+		hint := widget.NewLabel("报告仅包含功能名称、次数和系统类型，不含节点、地址或凭据。是否分享由你决定。")
+		hint.Wrapping = fyne.TextWrapWord
+		content := container.NewBorder(hint, container.NewHBox(layout.NewSpacer(), copyBtn), nil, nil, entry)
+		d := dialog.NewCustom("使用统计报告", "关闭", content, sp.appState.Window)
+		d.Resize(fyne.NewSize(480, 420))
+		d.Show()
+	})
+	reportBtn.Importance = widget.LowImportance
+
+	clearBtn := widget.NewButtonWithIcon("清空统计", theme.DeleteIcon(), func() {
+		dialog.ShowConfirm("清空使用统计", "确定要清空所有使用统计吗？", func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := us.Clear(); err != nil {
+				dialog.ShowError(err, sp.appState.Window)
+				return
+			}
+			reload()
+		}, sp.appState.Window)
+	})
+	clearBtn.Importance = widget.LowImportance
+
+	refreshBtn := widget.NewButtonWithIcon("刷新", theme.ViewRefreshIcon(), reload)
+	refreshBtn.Importance = widget.LowImportance
+
+	listScroll := container.NewScroll(list)
+	listScroll.SetMinSize(fyne.NewSize(0, 240))
+
+	return container.NewVBox(
+		enabledCheck,
+		container.NewHBox(widget.NewLabel("功能使用次数"), layout.NewSpacer(), refreshBtn, reportBtn, clearBtn),
+		listScroll,
+	)
 }
 
 // buildAccessRecordContent 构建设置「访问记录」内容区，展示访问的网站及累计访问次数。
@@ -622,9 +1892,15 @@ func (sp *SettingsPage) buildAccessRecordContent() fyne.CanvasObject {
 	})
 	refreshBtn.Importance = widget.LowImportance
 
+	talkersBtn := widget.NewButtonWithIcon("活跃应用", theme.ComputerIcon(), func() {
+		showTopTalkersDialog(sp.appState)
+	})
+	talkersBtn.Importance = widget.LowImportance
+
 	topBar := container.NewHBox(
 		widget.NewLabel("访问的地址（host:port，按最近访问时间排序）"),
 		layout.NewSpacer(),
+		talkersBtn,
 		refreshBtn,
 		clearBtn,
 	)
@@ -632,9 +1908,29 @@ func (sp *SettingsPage) buildAccessRecordContent() fyne.CanvasObject {
 	listScroll := container.NewScroll(sp.accessRecordsList)
 	listScroll.SetMinSize(fyne.NewSize(0, 200))
 
+	// 节点连接历史（最近使用、累计连接时长）
+	clearHistoryBtn := widget.NewButtonWithIcon("清除节点连接历史", theme.HistoryIcon(), func() {
+		if sp.appState == nil || sp.appState.Window == nil {
+			return
+		}
+		dialog.ShowConfirm("清除连接历史", "确定要清除所有节点的最近使用记录和连接时长吗？", func(ok bool) {
+			if !ok || sp.appState.Store == nil || sp.appState.Store.Nodes == nil {
+				return
+			}
+			if err := sp.appState.ServerService.ClearHistory(); err != nil {
+				dialog.ShowError(err, sp.appState.Window)
+				return
+			}
+			sp.appState.AppendLog("INFO", "store", "已清除节点连接历史")
+			sp.appState.UpdateProxyStatus()
+		}, sp.appState.Window)
+	})
+	clearHistoryBtn.Importance = widget.LowImportance
+
 	return container.NewBorder(
 		container.NewVBox(topBar, NewSeparator()),
-		nil, nil, nil,
+		container.NewHBox(layout.NewSpacer(), clearHistoryBtn),
+		nil, nil,
 		listScroll,
 	)
 }
@@ -722,7 +2018,8 @@ func (sp *SettingsPage) onLogLevelChanged(level string) {
 	if sp.appState == nil {
 		return
 	}
-	if sp.appState.Logger != nil {
+	// 调试会话期间只保存，会话结束时再生效
+	if _, _, active := sp.appState.DebugSessionStatus(); !active && sp.appState.Logger != nil {
 		sp.appState.Logger.SetLogLevel(level)
 	}
 	if sp.appState.ConfigService != nil {
@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/theme"
+)
+
+func TestSettingsPageSavesVerifyBeforeStart(t *testing.T) {
+	appState := newTestAppState(t)
+	before := appState.ConfigService.GetVerifyBeforeStart()
+
+	sp := NewSettingsPage(appState)
+	content := sp.Build()
+	showInTestWindow(appState, content)
+	test.Tap(sp.menuButtons[1]) // 代理配置
+
+	check := findCheck(content, "启动前验证节点可用")
+	if check == nil {
+		t.Fatal("代理配置页中未找到「启动前验证节点可用」")
+	}
+	if check.Checked != before {
+		t.Fatalf("勾选框初始状态 = %v，期望与配置一致 %v", check.Checked, before)
+	}
+	test.Tap(check)
+
+	if got := appState.ConfigService.GetVerifyBeforeStart(); got == before {
+		t.Errorf("点击后配置未保存，仍为 %v", got)
+	}
+
+	// 重新打开设置页，勾选状态从配置恢复
+	reopened := NewSettingsPage(appState)
+	reopenedContent := reopened.Build()
+	showInTestWindow(appState, reopenedContent)
+	test.Tap(reopened.menuButtons[1])
+	if check := findCheck(reopenedContent, "启动前验证节点可用"); check == nil || check.Checked == before {
+		t.Errorf("重新打开设置页后勾选状态未恢复为已保存的值")
+	}
+}
+
+func TestSettingsPageSwitchesTheme(t *testing.T) {
+	appState := newTestAppState(t)
+	appState.ApplyTheme()
+	if got := appState.GetTheme(); got != ThemeDark {
+		t.Fatalf("默认主题 = %q，期望 %q", got, ThemeDark)
+	}
+
+	sp := NewSettingsPage(appState)
+	content := sp.Build()
+	showInTestWindow(appState, content)
+	test.Tap(sp.menuButtons[0]) // 外观
+
+	themeSelect := findSelect(content, ThemeDisplayLight)
+	if themeSelect == nil {
+		t.Fatal("外观页中未找到主题下拉框")
+	}
+	if themeSelect.Selected != ThemeDisplayDark {
+		t.Fatalf("主题下拉框初始选中 = %q，期望 %q", themeSelect.Selected, ThemeDisplayDark)
+	}
+	themeSelect.SetSelected(ThemeDisplayLight)
+
+	if got := appState.ConfigService.GetTheme(); got != ThemeLight {
+		t.Errorf("保存的主题 = %q，期望 %q", got, ThemeLight)
+	}
+	mt, ok := appState.App.Settings().Theme().(*MonochromeTheme)
+	if !ok || mt.variant != theme.VariantLight {
+		t.Errorf("应用的主题 = %#v，期望浅色 MonochromeTheme", appState.App.Settings().Theme())
+	}
+}
@@ -0,0 +1,97 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/skip2/go-qrcode"
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/service"
+)
+
+// showShareDialog 开启局域网分享并显示二维码与地址，关闭对话框即停止分享。
+func showShareDialog(appState *AppState, payload *service.SharePayload) {
+	if appState == nil || appState.Window == nil || appState.ShareService == nil {
+		return
+	}
+
+	session, err := appState.ShareService.StartShare(payload)
+	if err != nil {
+		dialog.ShowError(err, appState.Window)
+		return
+	}
+	appState.UsageStatsService.Record(model.UsageFeatureShare)
+	appState.AppendLog("INFO", "server", fmt.Sprintf("已开启局域网分享，有效期至 %s", session.ExpiresAt.Format("15:04:05")))
+
+	items := []fyne.CanvasObject{}
+	if png, err := qrcode.Encode(session.URL, qrcode.Medium, 256); err == nil {
+		img := canvas.NewImageFromResource(fyne.NewStaticResource("share_qr.png", png))
+		img.FillMode = canvas.ImageFillContain
+		img.SetMinSize(fyne.NewSize(200, 200))
+		items = append(items, img)
+	}
+
+	urlLabel := widget.NewLabel(session.URL)
+	urlLabel.Wrapping = fyne.TextWrapBreak
+	copyBtn := widget.NewButtonWithIcon("复制地址", theme.ContentCopyIcon(), func() {
+		appState.Window.Clipboard().SetContent(session.URL)
+	})
+	copyBtn.Importance = widget.LowImportance
+
+	items = append(items,
+		urlLabel,
+		widget.NewLabel(fmt.Sprintf("在另一台设备的「订阅 → 接收」中输入此地址，%s 前有效", session.ExpiresAt.Format("15:04"))),
+		copyBtn,
+	)
+
+	d := dialog.NewCustom("发送到设备", "停止分享", container.NewVBox(items...), appState.Window)
+	d.SetOnClosed(func() {
+		appState.ShareService.StopShare()
+	})
+	d.Resize(fyne.NewSize(360, 0))
+	d.Show()
+}
+
+// showReceiveDialog 输入另一台设备的分享地址并导入节点/订阅。
+func showReceiveDialog(appState *AppState, onDone func()) {
+	if appState == nil || appState.Window == nil || appState.ShareService == nil {
+		return
+	}
+
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder("http://192.168.x.x:port/share?token=...")
+
+	d := dialog.NewForm("从设备接收", "接收", "取消", []*widget.FormItem{
+		{Text: "分享地址", Widget: entry},
+	}, func(ok bool) {
+		if !ok {
+			return
+		}
+		shareURL := strings.TrimSpace(entry.Text)
+		if shareURL == "" {
+			return
+		}
+		go func() {
+			result, err := appState.ShareService.Receive(shareURL)
+			fyne.Do(func() {
+				if err != nil {
+					dialog.ShowError(err, appState.Window)
+					return
+				}
+				appState.AppendLog("INFO", "server", fmt.Sprintf("已从设备接收 %d 个节点、%d 个订阅", result.NodeCount, result.SubscriptionCount))
+				dialog.ShowInformation("接收成功", fmt.Sprintf("节点: %d\n订阅: %d", result.NodeCount, result.SubscriptionCount), appState.Window)
+				if onDone != nil {
+					onDone()
+				}
+			})
+		}()
+	}, appState.Window)
+	d.Resize(fyne.NewSize(400, 0))
+	d.Show()
+}
@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/service"
+)
+
+// showSmokeTestDialog 冒烟测试节点：在临时端口启动节点配置，经其完成 HTTP 请求和 DNS 查询，
+// 实时显示各步骤耗时，结束后给出通过 / 失败结论，报告可复制。关闭对话框即停止测试。
+func showSmokeTestDialog(appState *AppState, node model.Node) {
+	if appState == nil || appState.Window == nil || appState.XrayControlService == nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	progress := widget.NewProgressBarInfinite()
+	statusLabel := widget.NewLabel("正在启动临时实例并经节点请求…")
+	statusLabel.Wrapping = fyne.TextWrapWord
+	stepsBox := container.NewVBox()
+	var report service.SmokeReport
+	copyBtn := widget.NewButtonWithIcon("复制报告", theme.ContentCopyIcon(), func() {
+		appState.Window.Clipboard().SetContent(report.String())
+		showToast(appState, FeedbackSuccess, "报告已复制")
+	})
+	copyBtn.Importance = widget.LowImportance
+	copyBtn.Disable()
+
+	addRow := func(step service.SmokeStep) {
+		icon := widget.NewIcon(theme.ConfirmIcon())
+		detail := step.Detail
+		if step.Err != nil {
+			icon.SetResource(theme.ErrorIcon())
+			detail = step.Err.Error()
+		}
+		title := widget.NewLabel(fmt.Sprintf("%s · %d ms", step.Name, step.Duration.Milliseconds()))
+		detailLabel := widget.NewLabel(detail)
+		detailLabel.Importance = widget.LowImportance
+		detailLabel.Wrapping = fyne.TextWrapWord
+		stepsBox.Add(container.NewBorder(nil, nil, icon, nil, container.NewVBox(title, detailLabel)))
+	}
+
+	content := container.NewBorder(
+		container.NewVBox(statusLabel, progress), container.NewHBox(copyBtn), nil, nil,
+		container.NewVScroll(stepsBox),
+	)
+	d := dialog.NewCustom("冒烟测试: "+node.Name, "关闭", content, appState.Window)
+	d.SetOnClosed(cancel)
+	d.Resize(fyne.NewSize(460, 360))
+	d.Show()
+
+	// 在 UI goroutine 中取当前实例，避免后台 goroutine 与启动/停止代理并发读写 AppState
+	active := appState.XrayInstance
+	go func() {
+		result := appState.XrayControlService.SmokeTest(ctx, node, active, func(step service.SmokeStep) {
+			fyne.Do(func() { addRow(step) })
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		level, logMsg := "INFO", fmt.Sprintf("节点 %s 冒烟测试通过，总耗时 %d ms", node.Name, result.Total.Milliseconds())
+		if !result.Passed() {
+			level, logMsg = "WARN", fmt.Sprintf("节点 %s 冒烟测试失败", node.Name)
+		}
+		appState.AppendLog(level, "server", logMsg)
+		fyne.Do(func() {
+			report = result
+			progress.Stop()
+			progress.Hide()
+			copyBtn.Enable()
+			if result.Passed() {
+				statusLabel.SetText(fmt.Sprintf("通过：节点可以正常转发 HTTP 和 DNS，总耗时 %d ms。", result.Total.Milliseconds()))
+				statusLabel.Importance = widget.SuccessImportance
+			} else {
+				statusLabel.SetText("失败：节点无法正常使用，见下方失败步骤。")
+				statusLabel.Importance = widget.DangerImportance
+			}
+			statusLabel.Refresh()
+		})
+	}()
+}
@@ -2,23 +2,26 @@ package ui
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
-	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
-	"myproxy.com/p/internal/database"
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/service"
+	"myproxy.com/p/internal/utils"
 )
 
 // SubscriptionPage 订阅管理页面
 type SubscriptionPage struct {
 	appState *AppState
 	list     *widget.List
+	banner   *Banner // 页内横幅：批量更新失败汇总等
 	content  fyne.CanvasObject
 }
 
@@ -28,16 +31,16 @@ func NewSubscriptionPage(appState *AppState) *SubscriptionPage {
 		appState: appState,
 	}
 
-	// 监听 Store 的订阅绑定数据变化，自动刷新列表。
-	// 使用 fyne.Do 确保 UI 刷新在主线程执行（ binding 可能在 goroutine 中触发）
+	// 监听 Store 的订阅数据变化，自动刷新列表。
+	// 使用 fyne.Do 确保 UI 刷新在主线程执行（订阅可能在 goroutine 中重新加载）
 	if appState != nil && appState.Store != nil && appState.Store.Subscriptions != nil {
-		appState.Store.Subscriptions.SubscriptionsBinding.AddListener(binding.NewDataListener(func() {
+		appState.Store.Subscriptions.AddListener(func() {
 			fyne.Do(func() {
 				if sp.list != nil {
 					sp.list.Refresh()
 				}
 			})
-		}))
+		})
 	}
 
 	return sp
@@ -60,19 +63,40 @@ func (sp *SubscriptionPage) Build() fyne.CanvasObject {
 	batchUpdateBtn := widget.NewButtonWithIcon("全部更新", theme.ViewRefreshIcon(), sp.batchUpdateSubscriptions)
 	batchUpdateBtn.Importance = widget.LowImportance
 
+	receiveBtn := widget.NewButtonWithIcon("接收", theme.DownloadIcon(), func() {
+		showReceiveDialog(sp.appState, sp.Refresh)
+	})
+	receiveBtn.Importance = widget.LowImportance
+
+	// 冲突策略：订阅更新时如何处理用户修改过（重命名、调整传输）的节点
+	policySelect := widget.NewSelect(conflictPolicyOptions, nil)
+	if sp.appState != nil && sp.appState.ConfigService != nil {
+		policySelect.SetSelected(conflictPolicyLabel(sp.appState.ConfigService.GetConflictPolicy()))
+		policySelect.OnChanged = func(label string) {
+			if err := sp.appState.ConfigService.SetConflictPolicy(conflictPolicyFromLabel(label)); err != nil {
+				showErrorDetail(sp.appState, "保存冲突策略失败", err)
+			}
+		}
+	}
+
 	// 合并返回按钮和操作工具栏到一行
 	headerBar := container.NewHBox(
 		backBtn,
 		layout.NewSpacer(),
+		widget.NewLabel("修改过的节点"),
+		policySelect,
 		addBtn,
 		batchUpdateBtn,
+		receiveBtn,
 	)
 
 	// 组合头部区域
 	separatorColor := CurrentThemeColor(sp.appState.App, theme.ColorNameSeparator)
+	sp.banner = NewBanner(sp.appState)
 	headerStack := container.NewVBox(
 		container.NewPadded(headerBar),
 		canvas.NewLine(separatorColor),
+		sp.banner,
 	)
 
 	// 3. 订阅列表 (支持滚动)
@@ -110,7 +134,7 @@ func (sp *SubscriptionPage) createSubscriptionItem() fyne.CanvasObject {
 }
 
 func (sp *SubscriptionPage) updateSubscriptionItem(id widget.ListItemID, obj fyne.CanvasObject) {
-	var subscriptions []*database.Subscription
+	var subscriptions []*model.Subscription
 	if sp.appState != nil && sp.appState.Store != nil && sp.appState.Store.Subscriptions != nil {
 		subscriptions = sp.appState.Store.Subscriptions.GetAll()
 	}
@@ -126,10 +150,22 @@ func (sp *SubscriptionPage) Refresh() {
 	// 绑定数据更新后会自动触发列表刷新，无需手动调用
 }
 
+// FocusSubscription 滚动到指定订阅（全局搜索跳转使用）。
+func (sp *SubscriptionPage) FocusSubscription(id int64) {
+	if sp.list == nil || sp.appState == nil || sp.appState.Store == nil || sp.appState.Store.Subscriptions == nil {
+		return
+	}
+	for i, sub := range sp.appState.Store.Subscriptions.GetAll() {
+		if sub != nil && sub.ID == id {
+			sp.list.ScrollTo(widget.ListItemID(i))
+			return
+		}
+	}
+}
+
 // showAddSubscriptionDialog 修复逻辑：支持添加重复URL作为新订阅
 func (sp *SubscriptionPage) showAddSubscriptionDialog() {
-	urlEntry := widget.NewEntry()
-	urlEntry.SetPlaceHolder("https://...")
+	urlEntry := NewSecretEntry("https://...")
 	labelEntry := widget.NewEntry()
 	labelEntry.SetPlaceHolder("订阅名称")
 
@@ -143,33 +179,25 @@ func (sp *SubscriptionPage) showAddSubscriptionDialog() {
 			return
 		}
 
+		url, label := urlEntry.Text, labelEntry.Text
 		go func() {
-			// 通过 Store 添加订阅（会自动更新数据库和绑定）
-			if sp.appState != nil && sp.appState.Store != nil && sp.appState.Store.Subscriptions != nil {
-				_, err := sp.appState.Store.Subscriptions.Add(urlEntry.Text, labelEntry.Text)
-				if err != nil {
-					fyne.Do(func() { dialog.ShowError(err, sp.appState.Window) })
-					return
-				}
+			// 通过 SubscriptionService 添加订阅（会更新数据库并刷新 Store）
+			if err := sp.appState.SubscriptionService.Add(url, label); err != nil {
+				fyne.Do(func() { showErrorDetail(sp.appState, "添加订阅失败", err) })
+				return
+			}
 
-				// 立即执行一次抓取（通过 Store）
-				if err := sp.appState.Store.Subscriptions.Fetch(urlEntry.Text, labelEntry.Text); err != nil {
-					fyne.Do(func() { dialog.ShowError(err, sp.appState.Window) })
-					return
-				}
-			} else {
-				// 降级方案：通过Store添加订阅
-				if sp.appState != nil && sp.appState.Store != nil && sp.appState.Store.Subscriptions != nil {
-					_, err := sp.appState.Store.Subscriptions.Add(urlEntry.Text, labelEntry.Text)
-					if err != nil {
-						fyne.Do(func() { dialog.ShowError(err, sp.appState.Window) })
-						return
-					}
-				}
+			// 立即执行一次抓取
+			if err := sp.appState.SubscriptionService.Fetch(url, label); err != nil {
+				fyne.Do(func() { showErrorDetail(sp.appState, "拉取订阅失败", err) })
+				return
 			}
 
 			// 更新绑定数据，自动刷新 UI
-			fyne.Do(func() { sp.Refresh() })
+			fyne.Do(func() {
+				sp.Refresh()
+				showToast(sp.appState, FeedbackSuccess, "订阅已添加")
+			})
 		}()
 	}, sp.appState.Window)
 
@@ -178,7 +206,7 @@ func (sp *SubscriptionPage) showAddSubscriptionDialog() {
 }
 
 func (sp *SubscriptionPage) batchUpdateSubscriptions() {
-	var subscriptions []*database.Subscription
+	var subscriptions []*model.Subscription
 	if sp.appState != nil && sp.appState.Store != nil && sp.appState.Store.Subscriptions != nil {
 		subscriptions = sp.appState.Store.Subscriptions.GetAll()
 	}
@@ -190,20 +218,32 @@ func (sp *SubscriptionPage) batchUpdateSubscriptions() {
 			return
 		}
 		go func() {
-			var subs []*database.Subscription
+			var subs []*model.Subscription
 			if sp.appState != nil && sp.appState.Store != nil && sp.appState.Store.Subscriptions != nil {
 				subs = sp.appState.Store.Subscriptions.GetAll()
 			}
+			// 逐个更新，失败汇总后在页内横幅中显示，避免连续弹出多个对话框
+			var failures []string
 			for _, sub := range subs {
 				if sp.appState != nil && sp.appState.SubscriptionService != nil {
+					sp.appState.UsageStatsService.Record(model.UsageFeatureSubscriptionUpdate)
 					if err := sp.appState.SubscriptionService.UpdateByID(sub.ID); err != nil {
-						fyne.Do(func() {
-							dialog.ShowError(fmt.Errorf("更新订阅失败: %w", err), sp.appState.Window)
-						})
+						failures = append(failures, fmt.Sprintf("%s: %v", sub.Label, err))
+						sp.appState.AppendLog("ERROR", "subscription", fmt.Sprintf("更新订阅 %s 失败: %v", sub.Label, err))
+						continue
 					}
+					sp.appState.AppendLog("INFO", "subscription", fmt.Sprintf("订阅 %s 已更新", sub.Label))
 				}
 			}
-			fyne.Do(func() { sp.Refresh() })
+			fyne.Do(func() {
+				sp.Refresh()
+				if len(failures) > 0 {
+					sp.banner.ShowMessage(FeedbackError, fmt.Sprintf("%d 个订阅更新失败\n%s", len(failures), strings.Join(failures, "\n")))
+					return
+				}
+				sp.banner.Hide()
+				showToast(sp.appState, FeedbackSuccess, fmt.Sprintf("已更新 %d 个订阅", len(subs)))
+			})
 		}()
 	}, sp.appState.Window)
 }
@@ -214,7 +254,7 @@ type SubscriptionCard struct {
 	widget.BaseWidget
 	page      *SubscriptionPage
 	appState  *AppState
-	sub       *database.Subscription
+	sub       *model.Subscription
 	renderObj fyne.CanvasObject
 
 	nameLabel *widget.Label
@@ -225,7 +265,11 @@ type SubscriptionCard struct {
 
 	updateBtn *widget.Button
 	editBtn   *widget.Button
+	shareBtn  *widget.Button
 	deleteBtn *widget.Button
+
+	skippedBtn  *widget.Button // 有无效条目被跳过时显示，点击查看原始行
+	conflictBtn *widget.Button // 有待处理的节点冲突时显示，点击逐个处理
 }
 
 func NewSubscriptionCard(page *SubscriptionPage, appState *AppState) *SubscriptionCard {
@@ -237,6 +281,14 @@ func NewSubscriptionCard(page *SubscriptionPage, appState *AppState) *Subscripti
 
 	card.infoLabel = widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{})
 
+	card.skippedBtn = widget.NewButtonWithIcon("", theme.WarningIcon(), nil)
+	card.skippedBtn.Importance = widget.LowImportance
+	card.skippedBtn.Hide()
+
+	card.conflictBtn = widget.NewButtonWithIcon("", theme.QuestionIcon(), nil)
+	card.conflictBtn.Importance = widget.WarningImportance
+	card.conflictBtn.Hide()
+
 	primaryColor := CurrentThemeColor(appState.App, theme.ColorNamePrimary)
 	card.statusBar = canvas.NewRectangle(primaryColor)
 	card.statusBar.SetMinSize(fyne.NewSize(4, 0))
@@ -249,6 +301,9 @@ func NewSubscriptionCard(page *SubscriptionPage, appState *AppState) *Subscripti
 	card.editBtn = widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), nil)
 	card.editBtn.Importance = widget.LowImportance
 
+	card.shareBtn = widget.NewButtonWithIcon("", theme.MailSendIcon(), nil)
+	card.shareBtn.Importance = widget.LowImportance
+
 	card.deleteBtn = widget.NewButtonWithIcon("", theme.DeleteIcon(), nil)
 	card.deleteBtn.Importance = widget.DangerImportance // 红色警告背景，白色前景
 
@@ -267,7 +322,7 @@ func (card *SubscriptionCard) setupLayout() fyne.CanvasObject {
 	textInfo := container.NewVBox(
 		card.nameLabel,
 		card.urlLabel,
-		container.NewHBox(widget.NewIcon(theme.InfoIcon()), card.infoLabel),
+		container.NewHBox(widget.NewIcon(theme.InfoIcon()), card.infoLabel, card.skippedBtn, card.conflictBtn),
 	)
 
 	// 右侧按钮组，水平排列，使用 Center 垂直居中避免占据整个容器高度
@@ -275,6 +330,7 @@ func (card *SubscriptionCard) setupLayout() fyne.CanvasObject {
 		container.NewHBox(
 			card.updateBtn,
 			card.editBtn,
+			card.shareBtn,
 			card.deleteBtn,
 		),
 	)
@@ -289,7 +345,7 @@ func (card *SubscriptionCard) setupLayout() fyne.CanvasObject {
 	return container.NewStack(bg, content)
 }
 
-func (card *SubscriptionCard) Update(sub *database.Subscription) {
+func (card *SubscriptionCard) Update(sub *model.Subscription) {
 	card.sub = sub
 	card.statusBar.FillColor = CurrentThemeColor(card.appState.App, theme.ColorNamePrimary)
 	card.statusBar.Refresh()
@@ -306,7 +362,8 @@ func (card *SubscriptionCard) Update(sub *database.Subscription) {
 	}
 	card.nameLabel.SetText(sub.Label)
 
-	urlDisplay := sub.URL
+	// 订阅链接中通常带有 token，卡片上只展示脱敏后的地址
+	urlDisplay := utils.RedactSecrets(sub.URL)
 	if len(urlDisplay) > 50 {
 		urlDisplay = urlDisplay[:47] + "..."
 	}
@@ -320,17 +377,41 @@ func (card *SubscriptionCard) Update(sub *database.Subscription) {
 	if !sub.UpdatedAt.IsZero() {
 		lastUpdate = card.formatTime(sub.UpdatedAt)
 	}
-	card.infoLabel.SetText(fmt.Sprintf("%d 节点 · 更新于 %s", nodeCount, lastUpdate))
+	if sub.Notes != "" {
+		lastUpdate += " · " + firstLine(sub.Notes)
+	}
+	var skipped []model.SkippedEntry
+	if card.appState.SubscriptionService != nil {
+		skipped = card.appState.SubscriptionService.SkippedEntries(sub.URL)
+	}
+	if len(skipped) > 0 {
+		card.infoLabel.SetText(fmt.Sprintf("%d 节点 · 跳过 %d 条无效 · 更新于 %s", nodeCount, len(skipped), lastUpdate))
+		card.skippedBtn.OnTapped = func() { card.showSkippedDialog(skipped) }
+		card.skippedBtn.Show()
+	} else {
+		card.infoLabel.SetText(fmt.Sprintf("%d 节点 · 更新于 %s", nodeCount, lastUpdate))
+		card.skippedBtn.OnTapped = nil
+		card.skippedBtn.Hide()
+	}
+	if n := card.conflictCount(); n > 0 {
+		card.conflictBtn.SetText(fmt.Sprintf("%d 个冲突", n))
+		card.conflictBtn.OnTapped = card.showConflictsDialog
+		card.conflictBtn.Show()
+	} else {
+		card.conflictBtn.OnTapped = nil
+		card.conflictBtn.Hide()
+	}
 
 	// 绑定事件 (基于 ID 操作)
 		card.updateBtn.OnTapped = func() {
 		card.updateBtn.Disable()
 		go func() {
 			if card.page != nil && card.page.appState != nil && card.page.appState.SubscriptionService != nil {
+				card.page.appState.UsageStatsService.Record(model.UsageFeatureSubscriptionUpdate)
 				if err := card.page.appState.SubscriptionService.UpdateByID(sub.ID); err != nil {
 					fyne.Do(func() {
 						card.updateBtn.Enable()
-						dialog.ShowError(fmt.Errorf("更新订阅失败: %w", err), card.page.appState.Window)
+						showErrorDetail(card.page.appState, "更新订阅失败", err)
 					})
 					return
 				}
@@ -339,46 +420,166 @@ func (card *SubscriptionCard) Update(sub *database.Subscription) {
 			fyne.Do(func() {
 				card.updateBtn.Enable()
 				card.page.Refresh()
+				card.appState.AppendLog("INFO", "subscription", fmt.Sprintf("订阅 %s 已更新", sub.Label))
+				// 询问策略：有修改过的节点在订阅中也变了，立即让用户处理
+				if n := card.conflictCount(); n > 0 {
+					showToast(card.page.appState, FeedbackWarning, fmt.Sprintf("订阅 %s 已更新，%d 个修改过的节点待处理", sub.Label, n))
+					card.showConflictsDialog()
+					return
+				}
+				showToast(card.page.appState, FeedbackSuccess, fmt.Sprintf("订阅 %s 已更新", sub.Label))
 			})
 		}()
 	}
 
 	card.editBtn.OnTapped = card.showEditDialog
 
+	card.shareBtn.OnTapped = func() {
+		showShareDialog(card.appState, &service.SharePayload{Subscriptions: []model.Subscription{*sub}})
+	}
+
 	card.deleteBtn.OnTapped = func() {
 		msg := fmt.Sprintf("确定删除订阅 '%s' 吗？\n下属的 %d 个节点将被移除。", sub.Label, nodeCount)
 		dialog.ShowConfirm("删除确认", msg, func(ok bool) {
 			if ok {
-				// 通过 Store 删除订阅（会自动更新数据库和绑定）
-				if card.page.appState != nil && card.page.appState.Store != nil && card.page.appState.Store.Subscriptions != nil {
-					if err := card.page.appState.Store.Subscriptions.Delete(sub.ID); err != nil {
-						dialog.ShowError(err, card.page.appState.Window)
-						return
-					}
-				} else {
-					// 降级方案：通过Store删除订阅
-					if card.page.appState != nil && card.page.appState.Store != nil && card.page.appState.Store.Subscriptions != nil {
-						_ = card.page.appState.Store.Subscriptions.Delete(sub.ID)
-					}
+				// 通过 SubscriptionService 删除订阅及下属节点（会更新数据库并刷新 Store）
+				if err := card.page.appState.SubscriptionService.Delete(sub.ID); err != nil {
+					showErrorDetail(card.page.appState, "删除订阅失败", err)
+					return
 				}
-				// 更新绑定数据，自动刷新 UI
 				card.page.Refresh()
 			}
 		}, card.page.appState.Window)
 	}
 }
 
+// showSkippedDialog 展示最近一次拉取时被跳过的无效条目及原因，原始行可选中复制。
+func (card *SubscriptionCard) showSkippedDialog(entries []model.SkippedEntry) {
+	var b strings.Builder
+	for i, e := range entries {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "# %s\n%s\n", e.Reason, e.Raw)
+	}
+	text := widget.NewMultiLineEntry()
+	text.SetText(b.String())
+	text.Wrapping = fyne.TextWrapBreak
+	text.OnChanged = func(string) { text.SetText(b.String()) } // 只读，保留选中复制能力
+
+	d := dialog.NewCustom(fmt.Sprintf("跳过的无效条目（%d）", len(entries)), "关闭", text, card.appState.Window)
+	d.Resize(fyne.NewSize(560, 400))
+	d.Show()
+}
+
+// conflictCount 返回当前订阅待处理的节点冲突数。
+func (card *SubscriptionCard) conflictCount() int {
+	if card.sub == nil || card.appState.SubscriptionService == nil {
+		return 0
+	}
+	return len(card.appState.SubscriptionService.Conflicts(card.sub.URL))
+}
+
+// showConflictsDialog 逐个处理订阅更新冲突：用户修改过的节点在订阅中也有了新版本，
+// 可保留修改、使用订阅版本或将修改另存为独立节点。全部处理后自动关闭。
+func (card *SubscriptionCard) showConflictsDialog() {
+	if card.sub == nil || card.appState.SubscriptionService == nil {
+		return
+	}
+	url := card.sub.URL
+	conflicts := card.appState.SubscriptionService.Conflicts(url)
+	if len(conflicts) == 0 {
+		return
+	}
+
+	var d dialog.Dialog
+	rows := container.NewVBox()
+	remaining := len(conflicts)
+	for _, c := range conflicts {
+		c := c
+		var row *fyne.Container
+		resolve := func(resolution model.ConflictPolicy) {
+			if err := card.appState.SubscriptionService.ResolveConflict(url, c.Local.ID, resolution); err != nil {
+				showErrorDetail(card.appState, "处理冲突失败", err)
+				return
+			}
+			if c.Local.Selected && resolution != model.ConflictPolicyKeep {
+				card.appState.ReloadProxy("订阅节点冲突已处理")
+			}
+			rows.Remove(row)
+			remaining--
+			card.page.Refresh()
+			if remaining == 0 {
+				d.Hide()
+				showToast(card.appState, FeedbackSuccess, "冲突已全部处理")
+			}
+		}
+
+		title := widget.NewLabelWithStyle(c.Local.Name, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+		detail := widget.NewLabel(fmt.Sprintf("订阅新版本: %s（%s:%d %s）", c.Remote.Name, c.Remote.Addr, c.Remote.Port, c.Remote.ProtocolType))
+		detail.Importance = widget.LowImportance
+		detail.Wrapping = fyne.TextWrapWord
+		keepBtn := widget.NewButton("保留我的修改", func() { resolve(model.ConflictPolicyKeep) })
+		overwriteBtn := widget.NewButton("使用订阅版本", func() { resolve(model.ConflictPolicyOverwrite) })
+		forkBtn := widget.NewButton("另存为副本", func() { resolve(model.ConflictPolicyFork) })
+		row = container.NewVBox(title, detail, container.NewHBox(keepBtn, overwriteBtn, forkBtn), widget.NewSeparator())
+		rows.Add(row)
+	}
+
+	hint := widget.NewLabel("以下节点你修改过（重命名或调整传输），订阅中也有了新版本。另存为副本会保留你的修改为独立节点，订阅节点使用新版本。")
+	hint.Wrapping = fyne.TextWrapWord
+	scroll := container.NewVScroll(rows)
+	scroll.SetMinSize(fyne.NewSize(480, 260))
+	d = dialog.NewCustom(fmt.Sprintf("订阅节点冲突（%d）", len(conflicts)), "稍后处理", container.NewBorder(hint, nil, nil, nil, scroll), card.appState.Window)
+	d.Show()
+}
+
+// conflictPolicyOptions 冲突策略的显示选项（顺序固定）。
+var conflictPolicyOptions = []string{"保留我的修改", "使用订阅版本", "另存为副本", "每次询问"}
+
+// conflictPolicyLabel 返回冲突策略的显示名称。
+func conflictPolicyLabel(policy model.ConflictPolicy) string {
+	switch policy {
+	case model.ConflictPolicyOverwrite:
+		return "使用订阅版本"
+	case model.ConflictPolicyFork:
+		return "另存为副本"
+	case model.ConflictPolicyAsk:
+		return "每次询问"
+	default:
+		return "保留我的修改"
+	}
+}
+
+// conflictPolicyFromLabel 将显示名称转换为冲突策略。
+func conflictPolicyFromLabel(label string) model.ConflictPolicy {
+	switch label {
+	case "使用订阅版本":
+		return model.ConflictPolicyOverwrite
+	case "另存为副本":
+		return model.ConflictPolicyFork
+	case "每次询问":
+		return model.ConflictPolicyAsk
+	default:
+		return model.ConflictPolicyKeep
+	}
+}
+
 func (card *SubscriptionCard) showEditDialog() {
-	urlEntry := widget.NewEntry()
+	urlEntry := NewSecretEntry("https://...")
 	urlEntry.SetText(card.sub.URL)
-	urlEntry.SetPlaceHolder("https://...")
 	labelEntry := widget.NewEntry()
 	labelEntry.SetText(card.sub.Label)
 	labelEntry.SetPlaceHolder("订阅名称")
+	notesEntry := widget.NewMultiLineEntry()
+	notesEntry.SetText(card.sub.Notes)
+	notesEntry.SetPlaceHolder("备注，如服务商、到期时间")
+	notesEntry.Wrapping = fyne.TextWrapWord
 
 	items := []*widget.FormItem{
 		{Text: "名称", Widget: labelEntry},
 		{Text: "链接", Widget: urlEntry},
+		{Text: "备注", Widget: notesEntry},
 	}
 
 	d := dialog.NewForm("编辑订阅", "确认", "取消", items, func(ok bool) {
@@ -386,23 +587,22 @@ func (card *SubscriptionCard) showEditDialog() {
 			return
 		}
 
-		// 通过 Store 更新订阅（会自动更新数据库和绑定）
-		if card.page.appState != nil && card.page.appState.Store != nil && card.page.appState.Store.Subscriptions != nil {
-			if err := card.page.appState.Store.Subscriptions.Update(card.sub.ID, urlEntry.Text, labelEntry.Text); err != nil {
-				dialog.ShowError(err, card.page.appState.Window)
+		// 通过 SubscriptionService 更新订阅（会更新数据库并刷新 Store）
+		ss := card.page.appState.SubscriptionService
+		if err := ss.Update(card.sub.ID, urlEntry.Text, labelEntry.Text); err != nil {
+			showErrorDetail(card.page.appState, "保存订阅失败", err)
+			return
+		}
+		if notes := strings.TrimSpace(notesEntry.Text); notes != card.sub.Notes {
+			if err := ss.UpdateNotes(card.sub.ID, notes); err != nil {
+				showErrorDetail(card.page.appState, "保存订阅备注失败", err)
 				return
 			}
-		} else {
-			// 降级方案：通过Store更新订阅
-			if card.page.appState != nil && card.page.appState.Store != nil && card.page.appState.Store.Subscriptions != nil {
-				_ = card.page.appState.Store.Subscriptions.Update(card.sub.ID, urlEntry.Text, labelEntry.Text)
-			}
 		}
-		// 更新绑定数据，自动刷新 UI
 		card.page.Refresh()
 	}, card.page.appState.Window)
 
-	d.Resize(fyne.NewSize(420, 240))
+	d.Resize(fyne.NewSize(420, 320))
 	d.Show()
 }
 
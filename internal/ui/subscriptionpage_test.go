@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+)
+
+// findEntry 在界面树中查找占位文字为 placeholder 的输入框，找不到时返回 nil。
+func findEntry(root fyne.CanvasObject, placeholder string) *widget.Entry {
+	for _, obj := range test.LaidOutObjects(root) {
+		if entry, ok := obj.(*widget.Entry); ok && entry.PlaceHolder == placeholder {
+			return entry
+		}
+	}
+	return nil
+}
+
+func TestSubscriptionPageAddSubscription(t *testing.T) {
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"name":"香港 01","addr":"10.0.0.1","port":1080},{"name":"日本 01","addr":"10.0.0.2","port":1080}]`)
+	}))
+	t.Cleanup(feed.Close)
+
+	appState := newTestAppState(t)
+	sp := NewSubscriptionPage(appState)
+	showInTestWindow(appState, sp.Build())
+
+	sp.showAddSubscriptionDialog()
+	form := appState.Window.Canvas().Overlays().Top()
+	if form == nil {
+		t.Fatal("未弹出添加订阅对话框")
+	}
+	labelEntry, urlEntry := findEntry(form, "订阅名称"), findEntry(form, "https://...")
+	if labelEntry == nil || urlEntry == nil {
+		t.Fatal("添加订阅对话框中未找到名称或链接输入框")
+	}
+	test.Type(labelEntry, "测试订阅")
+	test.Type(urlEntry, feed.URL)
+	confirm := findButton(form, "确定添加")
+	if confirm == nil {
+		t.Fatal("未找到「确定添加」按钮")
+	}
+	test.Tap(confirm)
+
+	// 添加和拉取在后台 goroutine 中进行
+	waitFor(t, "订阅节点写入", func() bool {
+		sub, err := appState.Store.Subscriptions.GetByURL(feed.URL)
+		if err != nil {
+			return false
+		}
+		count, _ := appState.Store.Subscriptions.GetServerCount(sub.ID)
+		return count == 2
+	})
+
+	// 等待后台 goroutine 刷新列表并弹出提示，避免与后续测试并发操作界面
+	waitFor(t, "添加成功提示", func() bool {
+		top := appState.Window.Canvas().Overlays().Top()
+		return top != nil && top != form
+	})
+
+	sub, _ := appState.Store.Subscriptions.GetByURL(feed.URL)
+	if sub.Label != "测试订阅" {
+		t.Errorf("订阅名称 = %q，期望 测试订阅", sub.Label)
+	}
+	if n := appState.Store.Subscriptions.GetSubscriptionCount(); n != 1 {
+		t.Errorf("订阅数 = %d，期望 1", n)
+	}
+	if n := len(appState.Store.Nodes.GetAll()); n != 2 {
+		t.Errorf("节点列表中的节点数 = %d，期望 2", n)
+	}
+}
@@ -0,0 +1,148 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/model"
+)
+
+// unconfirmedSystemChanges 返回切换到指定模式前需要用户确认的系统改动；
+// 未开启确认、或将要修改的内容都已在改动清单中时返回空。
+func (a *AppState) unconfirmedSystemChanges(mode SystemProxyMode) []model.SystemChange {
+	if a.ProxyService == nil || a.ConfigService == nil || a.Window == nil {
+		return nil
+	}
+	if !a.ConfigService.GetConfirmSystemChanges() {
+		return nil
+	}
+	return a.ProxyService.UnconfirmedSystemChanges(mode.ServiceMode())
+}
+
+// formatSystemChanges 按类别列出改动目标，每项一行。
+func formatSystemChanges(changes []model.SystemChange) string {
+	var b strings.Builder
+	for i, c := range changes {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(c.Kind.Label() + "：\n")
+		for _, t := range c.Targets {
+			b.WriteString("  • " + t + "\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// showSystemChangesConfirm 修改系统设置前列出将要修改的文件、注册表项和网络服务设置，
+// 用户确认后调用 apply；可勾选「不再询问」关闭之后的确认。
+func showSystemChangesConfirm(a *AppState, changes []model.SystemChange, apply func()) {
+	// 托盘触发时窗口可能隐藏，先显示窗口再弹出确认
+	a.Window.Show()
+
+	intro := widget.NewLabel("将修改以下系统设置和文件，可随时在 设置 → 代理配置 → 系统改动 中一键撤销：")
+	intro.Wrapping = fyne.TextWrapWord
+	list := widget.NewLabel(formatSystemChanges(changes))
+	list.Wrapping = fyne.TextWrapWord
+	dontAsk := widget.NewCheck("不再询问", nil)
+
+	var d dialog.Dialog
+	cancelBtn := widget.NewButton("取消", func() {
+		d.Hide()
+		a.refreshTrayProxyMenu()
+	})
+	applyBtn := widget.NewButtonWithIcon("确认修改", theme.ConfirmIcon(), func() {
+		d.Hide()
+		if dontAsk.Checked {
+			_ = a.ConfigService.SetConfirmSystemChanges(false)
+		}
+		apply()
+	})
+	applyBtn.Importance = widget.HighImportance
+
+	content := container.NewVBox(
+		intro,
+		container.NewVScroll(list),
+		dontAsk,
+		container.NewHBox(layout.NewSpacer(), cancelBtn, applyBtn),
+	)
+	d = dialog.NewCustomWithoutButtons("确认修改系统设置", content, a.Window)
+	d.Resize(fyne.NewSize(520, 0))
+	d.Show()
+}
+
+// showSystemChangesDialog 列出本程序已生效的系统改动，可一键撤销全部改动，并设置修改前是否确认。
+func showSystemChangesDialog(a *AppState) {
+	if a == nil || a.Window == nil || a.ProxyService == nil || a.ConfigService == nil {
+		return
+	}
+
+	list := widget.NewLabel("")
+	list.Wrapping = fyne.TextWrapWord
+	var revertBtn *widget.Button
+	refresh := func() {
+		changes := a.ProxyService.SystemChanges()
+		if len(changes) == 0 {
+			list.SetText("当前没有未撤销的系统改动。")
+			revertBtn.Disable()
+			return
+		}
+		var b strings.Builder
+		for i, c := range changes {
+			if i > 0 {
+				b.WriteString("\n\n")
+			}
+			b.WriteString(fmt.Sprintf("%s（%s）：", c.Kind.Label(), c.Time.Format("2006-01-02 15:04:05")))
+			for _, t := range c.Targets {
+				b.WriteString("\n  • " + t)
+			}
+		}
+		list.SetText(b.String())
+		revertBtn.Enable()
+	}
+
+	revertBtn = widget.NewButtonWithIcon("撤销全部系统改动", theme.ContentUndoIcon(), func() {
+		dialog.ShowConfirm("撤销全部系统改动", "将清除系统代理（恢复修改前的设置）并删除终端代理文件及 shell 配置文件中的引用，系统代理模式切换为「清除」。是否继续？",
+			func(ok bool) {
+				if !ok {
+					return
+				}
+				err := a.ProxyService.RevertAllSystemChanges()
+				if a.MainWindow != nil {
+					a.MainWindow.updateProxyModeButtonsState(SystemProxyModeClear)
+				}
+				a.refreshTrayProxyMenu()
+				refresh()
+				if err != nil {
+					a.AppendLog("ERROR", "systemproxy", "撤销系统改动失败: "+err.Error())
+					showErrorDetail(a, "部分系统改动撤销失败", err)
+					return
+				}
+				a.AppendLog("INFO", "systemproxy", "已撤销全部系统改动")
+				showToast(a, FeedbackSuccess, "已撤销全部系统改动")
+			}, a.Window)
+	})
+	revertBtn.Importance = widget.DangerImportance
+
+	confirmCheck := widget.NewCheck("修改系统设置前列出改动并确认", func(b bool) {
+		_ = a.ConfigService.SetConfirmSystemChanges(b)
+	})
+	confirmCheck.SetChecked(a.ConfigService.GetConfirmSystemChanges())
+
+	refresh()
+	content := container.NewBorder(
+		nil,
+		container.NewVBox(widget.NewSeparator(), confirmCheck, container.NewHBox(layout.NewSpacer(), revertBtn)),
+		nil, nil,
+		container.NewVScroll(list),
+	)
+	d := dialog.NewCustom("系统改动", "关闭", content, a.Window)
+	d.Resize(fyne.NewSize(560, 400))
+	d.Show()
+}
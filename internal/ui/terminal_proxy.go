@@ -0,0 +1,149 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/errs"
+	"myproxy.com/p/internal/model"
+	"myproxy.com/p/internal/service"
+)
+
+// staleTerminalActionOptions 过期终端代理文件处理方式的显示名称（顺序即下拉选项顺序）。
+var staleTerminalActionOptions = []struct {
+	action model.StaleTerminalAction
+	label  string
+}{
+	{model.StaleTerminalAsk, "询问"},
+	{model.StaleTerminalRefresh, "自动刷新"},
+	{model.StaleTerminalRemove, "自动删除"},
+}
+
+// staleTerminalActionLabels 返回下拉框选项。
+func staleTerminalActionLabels() []string {
+	labels := make([]string, len(staleTerminalActionOptions))
+	for i, o := range staleTerminalActionOptions {
+		labels[i] = o.label
+	}
+	return labels
+}
+
+// staleTerminalActionLabel 返回处理方式的显示名称。
+func staleTerminalActionLabel(action model.StaleTerminalAction) string {
+	for _, o := range staleTerminalActionOptions {
+		if o.action == action {
+			return o.label
+		}
+	}
+	return staleTerminalActionOptions[0].label
+}
+
+// staleTerminalActionFromLabel 由显示名称得到处理方式，未知名称按询问处理。
+func staleTerminalActionFromLabel(label string) model.StaleTerminalAction {
+	for _, o := range staleTerminalActionOptions {
+		if o.label == label {
+			return o.action
+		}
+	}
+	return model.StaleTerminalAsk
+}
+
+// checkStaleTerminalProxy 启动后检查终端代理环境变量文件（~/.myproxy_proxy.sh）是否过期，
+// 按设置询问、自动刷新为当前端口或自动删除。自动刷新时代理未运行则改为删除。
+func (a *AppState) checkStaleTerminalProxy() {
+	if a.ProxyService == nil || a.ConfigService == nil {
+		return
+	}
+	stale, err := a.ProxyService.CheckStaleTerminalProxy()
+	if err != nil {
+		a.AppendLog("WARN", "proxy", "检查终端代理文件失败: "+err.Error())
+		return
+	}
+	if stale == nil {
+		return
+	}
+	a.AppendLog("WARN", "proxy", fmt.Sprintf("终端代理文件 %s 已过期：%s", stale.File.Path, stale.Reason))
+
+	switch a.ConfigService.GetStaleTerminalAction() {
+	case model.StaleTerminalRefresh:
+		if stale.Refreshable {
+			err := a.ProxyService.RefreshTerminalProxy()
+			if err == nil {
+				a.AppendLog("INFO", "proxy", "已将终端代理文件刷新为当前端口")
+				return
+			}
+			// 代理未运行时文件无法指向可用端口，改为删除；其他失败（如写文件出错）保留文件并提示
+			if !errors.Is(err, errs.ErrProxyNotRunning) {
+				showErrorDetail(a, "刷新终端代理文件失败", err)
+				return
+			}
+			a.AppendLog("WARN", "proxy", "代理未运行，改为删除终端代理文件")
+		}
+		a.removeStaleTerminalProxy()
+	case model.StaleTerminalRemove:
+		a.removeStaleTerminalProxy()
+	default:
+		showStaleTerminalDialog(a, stale)
+	}
+}
+
+// removeStaleTerminalProxy 删除过期的终端代理文件并记录结果。
+func (a *AppState) removeStaleTerminalProxy() {
+	if err := a.ProxyService.RemoveTerminalProxy(); err != nil {
+		showErrorDetail(a, "删除终端代理文件失败", err)
+		return
+	}
+	a.AppendLog("INFO", "proxy", "已删除过期的终端代理文件")
+	showToast(a, FeedbackInfo, "已删除过期的终端代理文件")
+}
+
+// showStaleTerminalDialog 提示终端代理文件已过期，可刷新为当前端口、删除或暂不处理。
+func showStaleTerminalDialog(a *AppState, stale *service.StaleTerminalProxy) {
+	if a.Window == nil {
+		return
+	}
+	written := "未知（旧版文件）"
+	if !stale.File.UpdatedAt.IsZero() {
+		written = stale.File.UpdatedAt.Format("2006-01-02 15:04:05")
+	}
+	msg := widget.NewLabel(fmt.Sprintf("%s 中的代理设置已过期：%s。\n新开的终端会连向无人监听的端口而无法联网。\n\n写入时间：%s",
+		stale.File.Path, stale.Reason, written))
+	msg.Wrapping = fyne.TextWrapWord
+
+	var d dialog.Dialog
+	buttons := container.NewHBox(layout.NewSpacer())
+	ignoreBtn := widget.NewButton("暂不处理", func() { d.Hide() })
+	buttons.Add(ignoreBtn)
+	removeBtn := widget.NewButtonWithIcon("删除", theme.DeleteIcon(), func() {
+		d.Hide()
+		a.removeStaleTerminalProxy()
+	})
+	buttons.Add(removeBtn)
+	if stale.Refreshable {
+		refreshBtn := widget.NewButtonWithIcon("刷新为当前端口", theme.ViewRefreshIcon(), func() {
+			d.Hide()
+			if err := a.ProxyService.RefreshTerminalProxy(); err != nil {
+				showErrorDetail(a, "刷新终端代理文件失败", err)
+				return
+			}
+			a.AppendLog("INFO", "proxy", "已将终端代理文件刷新为当前端口")
+			showToast(a, FeedbackSuccess, "终端代理文件已刷新")
+		})
+		refreshBtn.Importance = widget.HighImportance
+		buttons.Add(refreshBtn)
+	} else {
+		removeBtn.Importance = widget.HighImportance
+	}
+
+	hint := widget.NewLabel("可在 设置 → 代理配置 中改为自动刷新或自动删除")
+	hint.Importance = widget.LowImportance
+	d = dialog.NewCustomWithoutButtons("终端代理文件已过期", container.NewVBox(msg, hint, buttons), a.Window)
+	d.Resize(fyne.NewSize(460, 0))
+	d.Show()
+}
@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/model"
+)
+
+// TooltipArea 鼠标悬停时在内容下方显示提示文字（Fyne 控件本身不支持 tooltip）。
+// 只处理悬停事件，点击仍由外层控件处理。
+type TooltipArea struct {
+	widget.BaseWidget
+	content fyne.CanvasObject
+	text    string
+	popup   *widget.PopUp
+}
+
+// NewTooltipArea 创建悬停提示区域。
+// 参数：
+//   - content: 被包裹的内容
+//
+// 返回：提示区域实例，提示文字为空时不显示
+func NewTooltipArea(content fyne.CanvasObject) *TooltipArea {
+	t := &TooltipArea{content: content}
+	t.ExtendBaseWidget(t)
+	return t
+}
+
+// SetText 设置提示文字。
+func (t *TooltipArea) SetText(text string) {
+	t.text = text
+}
+
+// CreateRenderer 实现 fyne.Widget。
+func (t *TooltipArea) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(t.content)
+}
+
+// MouseIn 实现 desktop.Hoverable：显示提示。
+func (t *TooltipArea) MouseIn(*desktop.MouseEvent) {
+	if t.text == "" {
+		return
+	}
+	c := fyne.CurrentApp().Driver().CanvasForObject(t)
+	if c == nil {
+		return
+	}
+	t.popup = widget.NewPopUp(widget.NewLabel(t.text), c)
+	pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(t)
+	t.popup.ShowAtPosition(pos.AddXY(0, t.Size().Height))
+}
+
+// MouseMoved 实现 desktop.Hoverable。
+func (t *TooltipArea) MouseMoved(*desktop.MouseEvent) {}
+
+// MouseOut 实现 desktop.Hoverable：隐藏提示。
+func (t *TooltipArea) MouseOut() {
+	if t.popup != nil {
+		t.popup.Hide()
+		t.popup = nil
+	}
+}
+
+// formatLatencyStats 格式化测速采样统计：最小 / 中位 / P95 与采样次数，第二行为分阶段耗时。
+func formatLatencyStats(stats model.LatencyStats) string {
+	var text string
+	if stats.Samples == 0 {
+		text = fmt.Sprintf("测试失败（失败 %d 次）", stats.Failures)
+	} else {
+		text = fmt.Sprintf("最小 %d ms / 中位 %d ms / P95 %d ms（%d 次采样）", stats.Min, stats.Median, stats.P95, stats.Samples)
+		if stats.Failures > 0 {
+			text += fmt.Sprintf("，失败 %d 次", stats.Failures)
+		}
+	}
+	if phases := formatDialPhases(stats.Phases); phases != "" {
+		text += "\n" + phases
+	}
+	return text
+}
+
+// dialPhaseLabels 测速连接阶段的显示名称。
+var dialPhaseLabels = map[string]string{
+	model.DialPhaseDNS: "DNS 解析",
+	model.DialPhaseTCP: "TCP 连接",
+	model.DialPhaseTLS: "TLS 握手",
+}
+
+// formatDialPhases 格式化分阶段耗时，如 "DNS 12 ms · TCP 48 ms · TLS 95 ms"；失败时附带失败阶段和原因。
+func formatDialPhases(p model.DialPhases) string {
+	var parts []string
+	if p.DNS > 0 || p.FailedAt == model.DialPhaseDNS {
+		parts = append(parts, fmt.Sprintf("DNS %d ms", p.DNS))
+	}
+	if p.FailedAt != model.DialPhaseDNS && (p.TCP > 0 || p.FailedAt == model.DialPhaseTCP) {
+		parts = append(parts, fmt.Sprintf("TCP %d ms", p.TCP))
+	}
+	if p.HasTLS {
+		parts = append(parts, fmt.Sprintf("TLS %d ms", p.TLS))
+	}
+	text := strings.Join(parts, " · ")
+	if p.FailedAt != "" {
+		text += fmt.Sprintf("\n%s失败: %s", dialPhaseLabels[p.FailedAt], p.Error)
+	}
+	return strings.TrimPrefix(text, "\n")
+}
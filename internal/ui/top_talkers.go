@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/service"
+)
+
+// showTopTalkersDialog 活跃应用：按发起连接的本机应用汇总最近一段时间经代理的连接，连接数最多的排在前面。
+// 多个应用共用代理时用于找出占满带宽的应用。
+func showTopTalkersDialog(appState *AppState) {
+	if appState == nil || appState.Window == nil || appState.TopTalkers == nil {
+		return
+	}
+	talkers := appState.TopTalkers
+
+	hint := fmt.Sprintf("最近 %d 分钟内经代理的连接，按应用的连接数排序（xray 不提供单个连接的流量）。",
+		int(talkers.Window().Minutes()))
+	if !talkers.Supported() {
+		hint += "当前系统不支持查找连接所属的应用，连接均计入「" + service.UnknownTalkerApp + "」。"
+	}
+	hintLabel := widget.NewLabel(hint)
+	hintLabel.Wrapping = fyne.TextWrapWord
+
+	rows := container.NewVBox()
+	// refresh 重新读取统计并重建列表
+	refresh := func() {
+		rows.RemoveAll()
+		stats := talkers.Top(3)
+		if len(stats) == 0 {
+			rows.Add(widget.NewLabel("暂无经代理的连接"))
+		}
+		for i, s := range stats {
+			title := widget.NewLabelWithStyle(fmt.Sprintf("%d. %s", i+1, s.App), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+			count := widget.NewLabel(fmt.Sprintf("%d 个连接（%.0f%%）", s.Connections, s.Share*100))
+			detail := widget.NewLabel(fmt.Sprintf("%d 个目标，常访问 %s，最近 %s",
+				s.Destinations, strings.Join(s.TopHosts, "、"), s.LastSeen.Format(time.TimeOnly)))
+			detail.Wrapping = fyne.TextWrapWord
+			rows.Add(container.NewBorder(nil, nil, title, count))
+			rows.Add(detail)
+		}
+		rows.Refresh()
+	}
+	refresh()
+
+	refreshBtn := widget.NewButtonWithIcon("刷新", theme.ViewRefreshIcon(), refresh)
+	refreshBtn.Importance = widget.LowImportance
+
+	scroll := container.NewVScroll(rows)
+	scroll.SetMinSize(fyne.NewSize(0, 280))
+	content := container.NewBorder(
+		container.NewVBox(hintLabel, container.NewHBox(refreshBtn)),
+		nil, nil, nil, scroll,
+	)
+	d := dialog.NewCustom("活跃应用", "关闭", content, appState.Window)
+	d.Resize(fyne.NewSize(480, 420))
+	d.Show()
+}
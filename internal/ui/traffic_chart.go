@@ -10,54 +10,31 @@ import (
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+	"myproxy.com/p/internal/service"
 )
 
-// TrafficData 流量数据点
-type TrafficData struct {
-	Upload   int64 // 上传字节数
-	Download int64 // 下载字节数
-	Time     time.Time
-}
-
-// TrafficChart 实时流量图组件
+// TrafficChart 实时流量图组件，只负责绘制 AppState.TrafficSampler 中共享的采样点；
+// 采样在后台持续进行，组件重建（如切换页面）后历史仍在。
 type TrafficChart struct {
 	widget.BaseWidget
 
 	appState *AppState
 
-	// 数据存储（最近的数据点）
-	dataPoints []TrafficData
-	maxPoints  int // 最大数据点数
-
-	// 当前流量统计
-	currentUpload   int64
-	currentDownload int64
-
-	// 上一次的流量统计（用于计算实时流量）
-	lastUpload   int64
-	lastDownload int64
-	lastTime     time.Time
-
-	// 锁保护
-	mu sync.RWMutex
-
-	// 更新定时器
+	// 更新定时器（仅用于定时重绘）
 	updateTicker *time.Ticker
 	stopChan     chan struct{}
+	stopOnce     sync.Once
 }
 
 // NewTrafficChart 创建新的流量图组件
 func NewTrafficChart(appState *AppState) *TrafficChart {
 	tc := &TrafficChart{
-		appState:   appState,
-		dataPoints: make([]TrafficData, 0),
-		maxPoints:  60, // 保留最近60个数据点（约1分钟，假设每秒更新）
-		lastTime:   time.Now(),
-		stopChan:   make(chan struct{}),
+		appState: appState,
+		stopChan: make(chan struct{}),
 	}
 	tc.ExtendBaseWidget(tc)
 
-	// 启动更新定时器（每秒更新一次）
+	// 启动重绘定时器（每秒一次，与采样间隔一致）
 	tc.updateTicker = time.NewTicker(1 * time.Second)
 	go tc.updateLoop()
 
@@ -69,7 +46,6 @@ func (tc *TrafficChart) updateLoop() {
 	for {
 		select {
 		case <-tc.updateTicker.C:
-			tc.updateData()
 			// 使用 fyne.Do 确保 UI 更新在主线程中执行
 			fyne.Do(func() {
 				tc.Refresh()
@@ -80,64 +56,22 @@ func (tc *TrafficChart) updateLoop() {
 	}
 }
 
-// updateData 更新流量数据
-func (tc *TrafficChart) updateData() {
-	tc.mu.Lock()
-	defer tc.mu.Unlock()
-
-	var totalUpload, totalDownload int64
-	if tc.appState != nil && tc.appState.XrayControlService != nil && tc.appState.XrayInstance != nil && tc.appState.XrayInstance.IsRunning() {
-		totalUpload, totalDownload = tc.appState.XrayControlService.GetTrafficStats(tc.appState.XrayInstance)
-	}
-
-	// 计算实时流量（与上一次的差值）
-	now := time.Now()
-	timeDiff := now.Sub(tc.lastTime).Seconds()
-	if timeDiff == 0 {
-		timeDiff = 1 // 避免除以零
-	}
-
-	// 计算实时流量（字节/秒）
-	upload := int64(float64(totalUpload-tc.lastUpload) / timeDiff)
-	download := int64(float64(totalDownload-tc.lastDownload) / timeDiff)
-
-	// 确保流量不为负数
-	if upload < 0 {
-		upload = 0
+// samples 返回共享的采样点，采样器未初始化时返回空。
+func (tc *TrafficChart) samples() []service.TrafficSample {
+	if tc.appState == nil || tc.appState.TrafficSampler == nil {
+		return nil
 	}
-	if download < 0 {
-		download = 0
-	}
-
-	// 更新上一次的流量数据和时间
-	tc.lastUpload = totalUpload
-	tc.lastDownload = totalDownload
-	tc.lastTime = now
-
-	// 添加新数据点
-	newPoint := TrafficData{
-		Upload:   upload,
-		Download: download,
-		Time:     now,
-	}
-
-	tc.dataPoints = append(tc.dataPoints, newPoint)
-
-	// 限制数据点数量
-	if len(tc.dataPoints) > tc.maxPoints {
-		tc.dataPoints = tc.dataPoints[len(tc.dataPoints)-tc.maxPoints:]
-	}
-
-	tc.currentUpload = upload
-	tc.currentDownload = download
+	return tc.appState.TrafficSampler.Samples()
 }
 
-// Stop 停止更新
+// Stop 停止重绘（不影响后台采样），可重复调用
 func (tc *TrafficChart) Stop() {
-	if tc.updateTicker != nil {
-		tc.updateTicker.Stop()
-	}
-	close(tc.stopChan)
+	tc.stopOnce.Do(func() {
+		if tc.updateTicker != nil {
+			tc.updateTicker.Stop()
+		}
+		close(tc.stopChan)
+	})
 }
 
 // CreateRenderer 创建渲染器
@@ -196,10 +130,7 @@ func (r *trafficChartRenderer) Layout(size fyne.Size) {
 
 // drawChart 绘制图表
 func (r *trafficChartRenderer) drawChart(width, height float32) {
-	r.trafficChart.mu.RLock()
-	dataPoints := make([]TrafficData, len(r.trafficChart.dataPoints))
-	copy(dataPoints, r.trafficChart.dataPoints)
-	r.trafficChart.mu.RUnlock()
+	dataPoints := r.trafficChart.samples()
 
 	if len(dataPoints) < 2 {
 		// 清理旧的线条
@@ -263,11 +194,11 @@ func (r *trafficChartRenderer) drawChart(width, height float32) {
 
 // Refresh 刷新
 func (r *trafficChartRenderer) Refresh() {
-	r.trafficChart.mu.RLock()
-	upload := r.trafficChart.currentUpload
-	download := r.trafficChart.currentDownload
+	var upload, download int64
+	if r.trafficChart.appState != nil && r.trafficChart.appState.TrafficSampler != nil {
+		upload, download = r.trafficChart.appState.TrafficSampler.Current()
+	}
 	size := r.trafficChart.Size()
-	r.trafficChart.mu.RUnlock()
 
 	// 使用当前主题色更新背景，切换主题后能立即生效
 	if r.trafficChart.appState != nil && r.trafficChart.appState.App != nil {
@@ -1,16 +1,68 @@
 package ui
 
 import (
+	"context"
+	"fmt"
+	"image/color"
+	"sort"
+	"strings"
+	"time"
+
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/driver/desktop"
+	"myproxy.com/p/internal/model"
+)
+
+// trayStatus 托盘图标状态点，反映代理运行状态与当前节点延迟。
+type trayStatus int
+
+const (
+	trayStatusOff  trayStatus = iota // 代理未运行：不加状态点
+	trayStatusGood                   // 运行中且延迟正常：绿
+	trayStatusSlow                   // 延迟偏高：黄
+	trayStatusBad                    // 测速失败或延迟过高：红
 )
 
+const (
+	// 托盘状态刷新间隔（延迟随测速/故障转移检测更新，流量速度随采样更新）
+	trayStatusInterval = 3 * time.Second
+	// 延迟阈值（毫秒）：低于 traySlowDelayMs 为绿，低于 trayBadDelayMs 为黄，否则为红
+	traySlowDelayMs = 300
+	trayBadDelayMs  = 1000
+)
+
+// color 返回状态点颜色。
+func (s trayStatus) color() color.Color {
+	switch s {
+	case trayStatusGood:
+		return hexToRGBA(LightSuccess)
+	case trayStatusSlow:
+		return hexToRGBA(LightWarning)
+	case trayStatusBad:
+		return hexToRGBA(LightError)
+	default:
+		return hexToRGBA(DelayNone)
+	}
+}
+
 // TrayManager 管理系统托盘
 type TrayManager struct {
 	appState           *AppState
 	app                fyne.App
 	window             fyne.Window
 	proxyModeMenuItems [2]*fyne.MenuItem // 系统代理模式菜单项（清除、系统）
+	recentNodeIDs      []string          // 当前菜单中「最近使用」节点 ID，用于判断是否需要重建菜单
+	nodesMenuKey       string            // 当前菜单中「切换节点」的排序与延迟摘要，变化时重建菜单
+	pinging            bool              // 是否正在刷新「切换节点」延迟
+
+	trayMenu       *fyne.Menu     // 当前托盘菜单，状态行变化时刷新
+	statusMenuItem *fyne.MenuItem // 菜单首行：节点名称、延迟与实时速度（只读）
+	status         trayStatus     // 当前图标状态点
+	statusText     string
+	stopCh         chan struct{}
+	lastUp         int64 // 上次采样的累计上传字节数，用于计算速度
+	lastDown       int64
+	lastSample     time.Time
 }
 
 // NewTrayManager 创建系统托盘管理器
@@ -44,6 +96,7 @@ func (tm *TrayManager) SetupTray() {
 		}
 		desk.SetSystemTrayIcon(icon)
 		tm.createTrayMenu(desk)
+		tm.startStatusLoop()
 	} else {
 		tm.appState.SafeLogger.Warn("应用不支持桌面扩展，无法显示系统托盘")
 	}
@@ -55,13 +108,109 @@ func (tm *TrayManager) RefreshTrayIcon() {
 		return
 	}
 	if desk, ok := tm.app.(desktop.App); ok {
-		icon := createTrayIconResource(tm.appState)
+		icon := createTrayStatusIconResource(tm.appState, tm.status)
 		if icon != nil {
 			desk.SetSystemTrayIcon(icon)
 		}
 	}
 }
 
+// startStatusLoop 定时刷新托盘状态点与状态行。
+func (tm *TrayManager) startStatusLoop() {
+	if tm.stopCh != nil {
+		return
+	}
+	tm.stopCh = make(chan struct{})
+	go func(stopCh chan struct{}) {
+		ticker := time.NewTicker(trayStatusInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				fyne.Do(func() {
+					tm.RefreshStatus()
+					// 延迟或降级状态变化时重排「切换节点」子菜单
+					tm.refreshProxyModeMenu()
+				})
+			}
+		}
+	}(tm.stopCh)
+}
+
+// Stop 停止托盘状态刷新。
+func (tm *TrayManager) Stop() {
+	if tm.stopCh != nil {
+		close(tm.stopCh)
+		tm.stopCh = nil
+	}
+}
+
+// RefreshStatus 根据代理运行状态和当前节点延迟更新托盘图标状态点，并刷新菜单首行的状态文字。
+func (tm *TrayManager) RefreshStatus() {
+	status, text := tm.currentStatus()
+	if status != tm.status {
+		tm.status = status
+		if desk, ok := tm.app.(desktop.App); ok {
+			if icon := createTrayStatusIconResource(tm.appState, status); icon != nil {
+				desk.SetSystemTrayIcon(icon)
+			}
+		}
+	}
+	if text != tm.statusText {
+		tm.statusText = text
+		if tm.statusMenuItem != nil && tm.trayMenu != nil {
+			tm.statusMenuItem.Label = text
+			tm.trayMenu.Refresh()
+		}
+	}
+}
+
+// currentStatus 计算当前托盘状态与状态文字（节点名称 · 延迟 · 实时速度）。
+func (tm *TrayManager) currentStatus() (trayStatus, string) {
+	if tm.appState == nil || tm.appState.XrayInstance == nil || !tm.appState.XrayInstance.IsRunning() {
+		tm.lastSample = time.Time{}
+		return trayStatusOff, "代理未运行"
+	}
+
+	name := "未知节点"
+	delay := 0
+	if tm.appState.Store != nil && tm.appState.Store.Nodes != nil {
+		if node := tm.appState.Store.Nodes.GetSelected(); node != nil {
+			name = node.Name
+			delay = node.Delay
+		}
+	}
+
+	status := trayStatusGood
+	delayText := "未测速"
+	switch {
+	case delay < 0:
+		status, delayText = trayStatusBad, "测试失败"
+	case delay >= trayBadDelayMs:
+		status, delayText = trayStatusBad, fmt.Sprintf("%d ms", delay)
+	case delay >= traySlowDelayMs:
+		status, delayText = trayStatusSlow, fmt.Sprintf("%d ms", delay)
+	case delay > 0:
+		delayText = fmt.Sprintf("%d ms", delay)
+	}
+
+	// 实时速度：与上次采样的累计流量差值
+	up, down := tm.appState.XrayInstance.TrafficStats()
+	now := time.Now()
+	var upSpeed, downSpeed int64
+	if !tm.lastSample.IsZero() && up >= tm.lastUp && down >= tm.lastDown {
+		if secs := now.Sub(tm.lastSample).Seconds(); secs > 0 {
+			upSpeed = int64(float64(up-tm.lastUp) / secs)
+			downSpeed = int64(float64(down-tm.lastDown) / secs)
+		}
+	}
+	tm.lastUp, tm.lastDown, tm.lastSample = up, down, now
+
+	return status, fmt.Sprintf("%s · %s · ↓%s ↑%s", name, delayText, formatSpeed(downSpeed), formatSpeed(upSpeed))
+}
+
 // createTrayMenu 创建托盘菜单
 func (tm *TrayManager) createTrayMenu(desk desktop.App) {
 	// 创建系统代理模式菜单项（如果尚未创建）
@@ -95,14 +244,32 @@ func (tm *TrayManager) createTrayMenu(desk desktop.App) {
 		}
 	})
 
+	// 状态行：节点名称、延迟与实时速度（只读）
+	if tm.statusText == "" {
+		tm.statusText = "代理未运行"
+	}
+	tm.statusMenuItem = fyne.NewMenuItem(tm.statusText, nil)
+	tm.statusMenuItem.Disabled = true
+
 	// 创建托盘菜单
-	menu := fyne.NewMenu("SOCKS5 代理客户端",
+	items := []*fyne.MenuItem{
+		tm.statusMenuItem,
+		fyne.NewMenuItemSeparator(),
 		fyne.NewMenuItem("显示窗口", func() {
 			tm.window.Show()
 			tm.window.RequestFocus()
 		}),
 		fyne.NewMenuItemSeparator(),
 		closeProxyMenuItem, // 关闭代理（停止Xray）
+	}
+	if nodesItem := tm.buildNodesMenuItem(); nodesItem != nil {
+		items = append(items, nodesItem) // 按延迟排序的节点快速切换
+	}
+	if recentItem := tm.buildRecentMenuItem(); recentItem != nil {
+		items = append(items, recentItem) // 最近使用节点
+	}
+	items = append(items,
+		buildProxySnippetsMenuItem(tm.appState, tm.window), // 复制 curl / 环境变量等使用命令
 		fyne.NewMenuItemSeparator(),
 		tm.proxyModeMenuItems[0], // 清除代理
 		tm.proxyModeMenuItems[1], // 系统代理
@@ -111,11 +278,206 @@ func (tm *TrayManager) createTrayMenu(desk desktop.App) {
 			tm.quit()
 		}),
 	)
+	menu := fyne.NewMenu("SOCKS5 代理客户端", items...)
+	tm.trayMenu = menu
 
 	// 设置托盘菜单
 	desk.SetSystemTrayMenu(menu)
 }
 
+// trayRecentNodeLimit 托盘「最近使用」子菜单显示的节点数量
+const trayRecentNodeLimit = 5
+
+// buildRecentMenuItem 构建「最近使用」子菜单，点击切换到对应节点；无连接历史时返回 nil。
+func (tm *TrayManager) buildRecentMenuItem() *fyne.MenuItem {
+	tm.recentNodeIDs = tm.currentRecentNodeIDs()
+	if len(tm.recentNodeIDs) == 0 {
+		return nil
+	}
+
+	selectedID := tm.appState.Store.Nodes.GetSelectedID()
+	var subItems []*fyne.MenuItem
+	for _, node := range tm.appState.Store.Nodes.GetRecent(trayRecentNodeLimit) {
+		nodeID := node.ID
+		item := fyne.NewMenuItem(tm.nodeMenuLabel(node), func() {
+			tm.switchToNode(nodeID)
+		})
+		item.Checked = nodeID == selectedID
+		subItems = append(subItems, item)
+	}
+
+	recentItem := fyne.NewMenuItem("最近使用", nil)
+	recentItem.ChildMenu = fyne.NewMenu("", subItems...)
+	return recentItem
+}
+
+// trayNodeLimit 托盘「切换节点」子菜单显示的节点数量
+const trayNodeLimit = 15
+
+// buildNodesMenuItem 构建「切换节点」子菜单：按最新延迟排序并标注延迟与健康状态，
+// 末尾提供「刷新延迟」在后台重新测速，完成后自动重排；无启用节点时返回 nil。
+// Fyne 托盘菜单不提供打开事件，因此由用户触发刷新，状态循环检测到延迟变化后重建菜单。
+func (tm *TrayManager) buildNodesMenuItem() *fyne.MenuItem {
+	nodes := tm.sortedTrayNodes()
+	tm.nodesMenuKey = tm.nodesMenuSignature(nodes)
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	selectedID := tm.appState.Store.Nodes.GetSelectedID()
+	var subItems []*fyne.MenuItem
+	for _, node := range nodes {
+		nodeID := node.ID
+		item := fyne.NewMenuItem(tm.nodeMenuLabel(node), func() {
+			tm.switchToNode(nodeID)
+		})
+		item.Checked = nodeID == selectedID
+		subItems = append(subItems, item)
+	}
+	refreshLabel := "刷新延迟"
+	if tm.pinging {
+		refreshLabel = "正在测速…"
+	}
+	refreshItem := fyne.NewMenuItem(refreshLabel, tm.refreshNodeDelays)
+	refreshItem.Disabled = tm.pinging
+	subItems = append(subItems, fyne.NewMenuItemSeparator(), refreshItem)
+
+	nodesItem := fyne.NewMenuItem("切换节点", nil)
+	nodesItem.ChildMenu = fyne.NewMenu("", subItems...)
+	return nodesItem
+}
+
+// sortedTrayNodes 返回启用节点，按延迟从低到高排序：降级节点、测速失败和未测速的排在后面。
+func (tm *TrayManager) sortedTrayNodes() []*model.Node {
+	if tm.appState == nil || tm.appState.Store == nil || tm.appState.Store.Nodes == nil {
+		return nil
+	}
+	var nodes []*model.Node
+	for _, node := range tm.appState.Store.Nodes.GetAll() {
+		if node.Enabled {
+			nodes = append(nodes, node)
+		}
+	}
+	rank := func(n *model.Node) int {
+		switch {
+		case tm.appState.NodeHealth.IsDegraded(n.ID):
+			return 3
+		case n.Delay > 0:
+			return 0
+		case n.Delay == 0:
+			return 1
+		default:
+			return 2
+		}
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		ri, rj := rank(nodes[i]), rank(nodes[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return ri == 0 && nodes[i].Delay < nodes[j].Delay
+	})
+	if len(nodes) > trayNodeLimit {
+		nodes = nodes[:trayNodeLimit]
+	}
+	return nodes
+}
+
+// nodesMenuSignature 生成「切换节点」子菜单的摘要（顺序、延迟、降级状态、测速中）。
+func (tm *TrayManager) nodesMenuSignature(nodes []*model.Node) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%t", tm.pinging)
+	for _, node := range nodes {
+		fmt.Fprintf(&b, "|%s:%d:%t", node.ID, node.Delay, tm.appState.NodeHealth.IsDegraded(node.ID))
+	}
+	return b.String()
+}
+
+// nodeMenuLabel 返回托盘节点菜单项文字：健康标记、名称与延迟。
+func (tm *TrayManager) nodeMenuLabel(node *model.Node) string {
+	if tm.appState.NodeHealth.IsDegraded(node.ID) {
+		return fmt.Sprintf("⚠️ %s · 已降级", node.Name)
+	}
+	switch {
+	case node.Delay < 0:
+		return fmt.Sprintf("🔴 %s · 测试失败", node.Name)
+	case node.Delay == 0:
+		return fmt.Sprintf("⚪ %s · 未测速", node.Name)
+	case node.Delay >= trayBadDelayMs:
+		return fmt.Sprintf("🔴 %s · %d ms", node.Name, node.Delay)
+	case node.Delay >= traySlowDelayMs:
+		return fmt.Sprintf("🟡 %s · %d ms", node.Name, node.Delay)
+	default:
+		return fmt.Sprintf("🟢 %s · %d ms", node.Name, node.Delay)
+	}
+}
+
+// refreshNodeDelays 在后台为「切换节点」中的节点重新测速，完成后重建菜单。
+func (tm *TrayManager) refreshNodeDelays() {
+	if tm.pinging || tm.appState == nil || tm.appState.Ping == nil {
+		return
+	}
+	var servers []model.Node
+	for _, node := range tm.sortedTrayNodes() {
+		servers = append(servers, *node)
+	}
+	if len(servers) == 0 {
+		return
+	}
+	tm.pinging = true
+	tm.refreshProxyModeMenu()
+
+	go func() {
+		tm.appState.Ping.TestAllServersDelayContext(context.Background(), servers, func(srv model.Node, stats model.LatencyStats) {
+			if stats.Delay() <= 0 {
+				// 标记为不可用（-1），避免托盘菜单继续显示上一次的延迟
+				tm.appState.NodeHealth.RecordFailure(srv.ID)
+				_ = tm.appState.ServerService.UpdateLatency(srv.ID, stats)
+				_ = tm.appState.ServerService.UpdateServerDelay(srv.ID, -1)
+				return
+			}
+			_ = tm.appState.ServerService.UpdateLatency(srv.ID, stats)
+		})
+		fyne.Do(func() {
+			tm.pinging = false
+			tm.refreshProxyModeMenu()
+			if tm.appState.MainWindow != nil {
+				tm.appState.MainWindow.Refresh()
+			}
+		})
+	}()
+}
+
+// currentRecentNodeIDs 返回 Store 中最近使用节点的 ID 列表。
+func (tm *TrayManager) currentRecentNodeIDs() []string {
+	if tm.appState == nil || tm.appState.Store == nil || tm.appState.Store.Nodes == nil {
+		return nil
+	}
+	var ids []string
+	for _, node := range tm.appState.Store.Nodes.GetRecent(trayRecentNodeLimit) {
+		ids = append(ids, node.ID)
+	}
+	return ids
+}
+
+// switchToNode 从托盘切换到指定节点：选中节点，代理运行中时立即以新节点重建。
+func (tm *TrayManager) switchToNode(nodeID string) {
+	if tm.appState == nil || tm.appState.Store == nil {
+		return
+	}
+	tm.appState.CancelVerify()
+	if err := tm.appState.Store.SelectServer(nodeID); err != nil {
+		tm.appState.AppendLog("ERROR", "server", "切换节点失败: "+err.Error())
+		return
+	}
+	tm.appState.UsageStatsService.Record(model.UsageFeatureTraySwitch)
+	tm.appState.ReloadProxy("托盘切换节点")
+	tm.appState.UpdateProxyStatus()
+	if tm.appState.MainWindow != nil {
+		tm.appState.MainWindow.Refresh()
+	}
+}
+
 // RefreshProxyModeMenu 刷新系统代理模式菜单的选中状态（公共方法）
 func (tm *TrayManager) RefreshProxyModeMenu() {
 	tm.refreshProxyModeMenu()
@@ -168,6 +530,19 @@ func (tm *TrayManager) refreshProxyModeMenu() {
 		}
 	}
 
+	// 最近使用节点变化（连接了新节点或清除了历史）时也需要重建菜单
+	if !needRefresh {
+		recentIDs := tm.currentRecentNodeIDs()
+		if strings.Join(recentIDs, ",") != strings.Join(tm.recentNodeIDs, ",") {
+			needRefresh = true
+		}
+	}
+
+	// 「切换节点」的排序、延迟或降级状态变化时也需要重建菜单
+	if !needRefresh && tm.nodesMenuSignature(tm.sortedTrayNodes()) != tm.nodesMenuKey {
+		needRefresh = true
+	}
+
 	// 只有在状态变化时才刷新托盘菜单（需要重新设置菜单才能更新选中状态）
 	if needRefresh {
 		if desk, ok := tm.app.(desktop.App); ok {
@@ -178,6 +553,8 @@ func (tm *TrayManager) refreshProxyModeMenu() {
 
 // quit 退出应用
 func (tm *TrayManager) quit() {
+	tm.Stop()
+
 	// 停止日志监控
 	if tm.appState.LogsPanel != nil {
 		tm.appState.LogsPanel.Stop()
@@ -1,6 +1,8 @@
 package ui
 
 import (
+	"strings"
+
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/widget"
 )
@@ -30,3 +32,25 @@ func NewTitleLabel(text string) *widget.Label {
 func NewSeparator() *widget.Separator {
 	return widget.NewSeparator()
 }
+
+// firstLine 返回多行文本的第一行（用于在列表中简要显示备注）。
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	return strings.TrimSpace(line)
+}
+
+// NewSecretEntry 创建凭据输入框：默认遮盖显示，右侧自带显示/隐藏切换按钮。
+// 密码、订阅链接（含 token）等敏感字段统一使用此输入框。
+func NewSecretEntry(placeholder string) *widget.Entry {
+	entry := widget.NewPasswordEntry()
+	entry.SetPlaceHolder(placeholder)
+	return entry
+}
+
+// revertCheck 保存失败时恢复勾选框的状态，不触发 OnChanged。
+func revertCheck(check *widget.Check, checked bool) {
+	onChanged := check.OnChanged
+	check.OnChanged = nil
+	check.SetChecked(checked)
+	check.OnChanged = onChanged
+}
@@ -0,0 +1,39 @@
+package utils
+
+import "net"
+
+// NetInterface 本机网卡信息（用于出站绑定选择）。
+type NetInterface struct {
+	Name  string   // 网卡名称
+	Addrs []string // IP 地址（不含掩码）
+}
+
+// ListNetInterfaces 列出已启用的非回环网卡及其 IP 地址，没有地址的网卡不返回。
+func ListNetInterfaces() ([]NetInterface, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	var result []NetInterface
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		ni := NetInterface{Name: iface.Name}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || ipNet.IP.IsLinkLocalUnicast() {
+				continue
+			}
+			ni.Addrs = append(ni.Addrs, ipNet.IP.String())
+		}
+		if len(ni.Addrs) > 0 {
+			result = append(result, ni)
+		}
+	}
+	return result, nil
+}
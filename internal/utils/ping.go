@@ -1,45 +1,231 @@
 package utils
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"myproxy.com/p/internal/model"
 )
 
+const (
+	// DefaultLatencySamples 默认每次测速的采样次数
+	DefaultLatencySamples = 3
+	// MaxLatencySamples 每次测速的最大采样次数
+	MaxLatencySamples = 10
+	// dialStepTimeout 测速中每一步（DNS 解析、每个地址的 TCP 连接、TLS 握手）各自的超时，
+	// 某个地址不通时不会耗尽后续地址的时间
+	dialStepTimeout = 5 * time.Second
+)
+
 // Ping 延迟测试工具。
 // 负责测试服务器延迟，不涉及数据更新操作。
+// 每次测速进行多次 TCP 连接采样，取中位数作为延迟，减少单次采样的抖动。
 type Ping struct {
+	mu      sync.RWMutex
+	samples int
 }
 
 // NewPing 创建新的延迟测试工具实例。
 // 返回：初始化后的 Ping 实例
 func NewPing() *Ping {
-	return &Ping{}
+	return &Ping{samples: DefaultLatencySamples}
+}
+
+// SetSamples 设置每次测速的采样次数（1 ~ MaxLatencySamples）。
+func (p *Ping) SetSamples(n int) {
+	if n < 1 {
+		n = 1
+	}
+	if n > MaxLatencySamples {
+		n = MaxLatencySamples
+	}
+	p.mu.Lock()
+	p.samples = n
+	p.mu.Unlock()
+}
+
+// Samples 返回每次测速的采样次数。
+func (p *Ping) Samples() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.samples
 }
 
 // TestServerDelay 测试单个服务器延迟。
 // 参数：
 //   - server: 服务器节点
 //
-// 返回：延迟值（毫秒）和错误（如果有）
+// 返回：延迟值（毫秒，多次采样的中位数）和错误（如果有）
 func (p *Ping) TestServerDelay(server model.Node) (int, error) {
-	// 使用TCP连接测试延迟
-	addr := fmt.Sprintf("%s:%d", server.Addr, server.Port)
+	return p.TestServerDelayContext(context.Background(), server)
+}
+
+// TestServerDelayContext 测试单个服务器延迟，ctx 取消时立即返回。
+// 参数：
+//   - ctx: 上下文，用于取消测试
+//   - server: 服务器节点
+//
+// 返回：延迟值（毫秒，多次采样的中位数）和错误（如果有）
+func (p *Ping) TestServerDelayContext(ctx context.Context, server model.Node) (int, error) {
+	stats, err := p.TestServerLatencyContext(ctx, server)
+	if err != nil {
+		return -1, err
+	}
+	return stats.Median, nil
+}
+
+// TestServerLatencyContext 对单个服务器进行多次 TCP 连接采样，返回最小/中位/P95 延迟。
+// 首次采样即失败时不再继续（节点大概率不可用，避免多次等待超时）；部分采样失败时按成功的采样统计。
+// 首次采样额外记录 DNS / TCP / TLS 分阶段耗时（TLS 仅对使用 TLS 的节点握手一次，不计入延迟）。
+// 全部采样失败时返回的统计中仍包含分阶段耗时和失败阶段。
+// 参数：
+//   - ctx: 上下文，用于取消测试
+//   - server: 服务器节点
+//
+// 返回：采样统计和错误（全部采样失败时）
+func (p *Ping) TestServerLatencyContext(ctx context.Context, server model.Node) (model.LatencyStats, error) {
+	var stats model.LatencyStats
+	var delays []int
+	var lastErr error
+	for i := 0; i < p.Samples(); i++ {
+		if ctx.Err() != nil {
+			break
+		}
+		delay, phases, err := dialDelay(ctx, server, i == 0)
+		if i == 0 {
+			stats.Phases = phases
+		}
+		if err != nil {
+			stats.Failures++
+			lastErr = err
+			if len(delays) == 0 {
+				break
+			}
+			continue
+		}
+		delays = append(delays, delay)
+	}
+	if len(delays) == 0 {
+		if lastErr == nil {
+			lastErr = ctx.Err()
+		}
+		return stats, lastErr
+	}
+
+	sort.Ints(delays)
+	n := len(delays)
+	stats.Samples = n
+	stats.Min = delays[0]
+	if n%2 == 1 {
+		stats.Median = delays[n/2]
+	} else {
+		stats.Median = (delays[n/2-1] + delays[n/2]) / 2
+	}
+	// 最近秩法：第 ceil(0.95n) 个采样
+	stats.P95 = delays[(n*95+99)/100-1]
+	return stats, nil
+}
+
+// dialDelay 建立一次 TCP 连接并返回耗时（毫秒，含 DNS 解析），同时记录各阶段耗时。
+// withTLS 为 true 且节点使用 TLS 时，在连接上额外完成一次 TLS 握手，仅记录到分阶段耗时中；
+// 握手失败不视为采样失败（TCP 已连通），由 phases.FailedAt 体现。
+func dialDelay(ctx context.Context, server model.Node, withTLS bool) (int, model.DialPhases, error) {
+	var phases model.DialPhases
 	start := time.Now()
 
-	// 尝试建立TCP连接
-	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
+	// DNS 解析（地址为 IP 时跳过）
+	addrs := []string{server.Addr}
+	if net.ParseIP(server.Addr) == nil {
+		dnsCtx, cancel := context.WithTimeout(ctx, dialStepTimeout)
+		var err error
+		addrs, err = net.DefaultResolver.LookupHost(dnsCtx, server.Addr)
+		cancel()
+		phases.DNS = int(time.Since(start).Milliseconds())
+		if err != nil || len(addrs) == 0 {
+			if err == nil {
+				err = fmt.Errorf("无解析结果")
+			}
+			phases.FailedAt, phases.Error = model.DialPhaseDNS, err.Error()
+			return -1, phases, fmt.Errorf("DNS 解析失败: %w", err)
+		}
+	}
+
+	// TCP 连接：依次尝试各解析结果（如 IPv6 不通时回落到 IPv4），与直接按域名拨号的行为一致；
+	// 每个地址单独计时，只有测速被取消时才停止尝试
+	tcpStart := time.Now()
+	dialer := net.Dialer{Timeout: dialStepTimeout}
+	var conn net.Conn
+	var err error
+	for _, addr := range addrs {
+		conn, err = dialer.DialContext(ctx, "tcp", net.JoinHostPort(addr, strconv.Itoa(server.Port)))
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+	}
+	phases.TCP = int(time.Since(tcpStart).Milliseconds())
 	if err != nil {
-		return -1, fmt.Errorf("连接服务器失败: %w", err)
+		phases.FailedAt, phases.Error = model.DialPhaseTCP, err.Error()
+		return -1, phases, fmt.Errorf("连接服务器失败: %w", err)
 	}
 	defer conn.Close()
-
-	// 计算延迟
 	delay := int(time.Since(start).Milliseconds())
-	return delay, nil
+
+	// TLS 握手（仅测量耗时，不校验证书）
+	if serverName, alpn, ok := nodeTLSParams(server); ok && withTLS {
+		phases.HasTLS = true
+		tlsStart := time.Now()
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: serverName, NextProtos: alpn, InsecureSkipVerify: true})
+		tlsCtx, cancel := context.WithTimeout(ctx, dialStepTimeout)
+		err := tlsConn.HandshakeContext(tlsCtx)
+		cancel()
+		phases.TLS = int(time.Since(tlsStart).Milliseconds())
+		if err != nil {
+			phases.FailedAt, phases.Error = model.DialPhaseTLS, err.Error()
+		}
+	}
+	return delay, phases, nil
+}
+
+// nodeTLSParams 返回节点 TLS 握手使用的 SNI 和 ALPN；节点不使用 TLS 时 ok 为 false。
+func nodeTLSParams(server model.Node) (serverName string, alpn []string, ok bool) {
+	switch server.ProtocolType {
+	case "trojan":
+		serverName = server.EffectiveSNI()
+		for _, p := range strings.Split(server.TrojanAlpn, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				alpn = append(alpn, p)
+			}
+		}
+	case "vmess":
+		if server.VMessTLS != "tls" {
+			return "", nil, false
+		}
+		serverName = server.EffectiveSNI()
+	case "vless":
+		// REALITY 服务端会把未认证的握手转发给伪装目标，普通 TLS 握手同样可以测得耗时
+		if server.VLESSSecurity != "tls" && server.VLESSSecurity != "reality" {
+			return "", nil, false
+		}
+		serverName = server.EffectiveSNI()
+		for _, p := range strings.Split(server.VLESSAlpn, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				alpn = append(alpn, p)
+			}
+		}
+	default:
+		return "", nil, false
+	}
+	if serverName == "" {
+		serverName = server.Addr
+	}
+	return serverName, alpn, true
 }
 
 // TestAllServersDelay 测试多个服务器延迟。
@@ -48,6 +234,18 @@ func (p *Ping) TestServerDelay(server model.Node) (int, error) {
 //
 // 返回：服务器ID到延迟值的映射（-1表示测试失败）
 func (p *Ping) TestAllServersDelay(servers []model.Node) map[string]int {
+	return p.TestAllServersDelayContext(context.Background(), servers, nil)
+}
+
+// TestAllServersDelayContext 并发测试多个服务器延迟，支持取消与进度回调。
+// ctx 取消后未完成的测试立即结束，且不再计入结果。
+// 参数：
+//   - ctx: 上下文，用于取消测试
+//   - servers: 服务器节点列表
+//   - onResult: 每完成一个节点时调用（可为 nil），在测试 goroutine 中调用，stats.Delay() 为 -1 表示测试失败
+//
+// 返回：服务器ID到延迟值的映射（-1表示测试失败）
+func (p *Ping) TestAllServersDelayContext(ctx context.Context, servers []model.Node, onResult func(server model.Node, stats model.LatencyStats)) map[string]int {
 	results := make(map[string]int)
 	var wg sync.WaitGroup
 	var mu sync.Mutex
@@ -62,14 +260,16 @@ func (p *Ping) TestAllServersDelay(servers []model.Node) map[string]int {
 		go func(s model.Node) {
 			defer wg.Done()
 
-			delay, err := p.TestServerDelay(s)
-			mu.Lock()
-			if err != nil {
-				results[s.ID] = -1
-			} else {
-				results[s.ID] = delay
+			stats, _ := p.TestServerLatencyContext(ctx, s)
+			if ctx.Err() != nil {
+				return
 			}
+			mu.Lock()
+			results[s.ID] = stats.Delay()
 			mu.Unlock()
+			if onResult != nil {
+				onResult(s, stats)
+			}
 		}(server)
 	}
 
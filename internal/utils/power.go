@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"myproxy.com/p/internal/errs"
+)
+
+// BatterySaverActive 检测系统是否处于省电模式：macOS 低电量模式、Windows 节电模式、
+// Linux power-profiles-daemon 的 power-saver 配置。
+// 返回：是否处于省电模式；平台不支持或检测工具不可用时返回错误
+func BatterySaverActive() (bool, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		output, err := exec.Command("pmset", "-g").Output()
+		if err != nil {
+			return false, fmt.Errorf("省电检测: 执行 pmset 失败: %w", err)
+		}
+		return parsePmsetLowPowerMode(output), nil
+	case "windows":
+		output, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command",
+			"[Windows.System.Power.PowerManager,Windows.System.Power,ContentType=WindowsRuntime]::EnergySaverStatus").Output()
+		if err != nil {
+			return false, fmt.Errorf("省电检测: 读取节电模式失败: %w", err)
+		}
+		return strings.TrimSpace(string(output)) == "On", nil
+	case "linux":
+		if _, err := exec.LookPath("powerprofilesctl"); err != nil {
+			return false, fmt.Errorf("省电检测: 未安装 power-profiles-daemon: %w", errs.ErrUnsupportedOS)
+		}
+		output, err := exec.Command("powerprofilesctl", "get").Output()
+		if err != nil {
+			return false, fmt.Errorf("省电检测: 执行 powerprofilesctl 失败: %w", err)
+		}
+		return strings.TrimSpace(string(output)) == "power-saver", nil
+	default:
+		return false, fmt.Errorf("省电检测: %w: %s", errs.ErrUnsupportedOS, runtime.GOOS)
+	}
+}
+
+// MeteredConnection 检测当前网络是否按流量计费（含手机热点）：Windows 读取当前连接的计费类型，
+// Linux 读取 NetworkManager 的 GENERAL.METERED 标记（含自动推断的热点连接）。macOS 无公开接口，不支持。
+// 返回：是否按流量计费；平台不支持或检测工具不可用时返回错误
+func MeteredConnection() (bool, error) {
+	switch runtime.GOOS {
+	case "windows":
+		output, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command",
+			"[Windows.Networking.Connectivity.NetworkInformation,Windows.Networking.Connectivity,ContentType=WindowsRuntime]::GetInternetConnectionProfile().GetConnectionCost().NetworkCostType").Output()
+		if err != nil {
+			return false, fmt.Errorf("计费网络检测: 读取连接计费类型失败: %w", err)
+		}
+		// Unrestricted 为不计费，Fixed / Variable 为按流量计费
+		switch strings.TrimSpace(string(output)) {
+		case "Fixed", "Variable":
+			return true, nil
+		}
+		return false, nil
+	case "linux":
+		if _, err := exec.LookPath("nmcli"); err != nil {
+			return false, fmt.Errorf("计费网络检测: 未安装 NetworkManager: %w", errs.ErrUnsupportedOS)
+		}
+		output, err := exec.Command("nmcli", "-t", "-f", "GENERAL.STATE,GENERAL.METERED", "device", "show").Output()
+		if err != nil {
+			return false, fmt.Errorf("计费网络检测: 执行 nmcli 失败: %w", err)
+		}
+		return parseNmcliMetered(output), nil
+	default:
+		return false, fmt.Errorf("计费网络检测: %w: %s", errs.ErrUnsupportedOS, runtime.GOOS)
+	}
+}
+
+// parsePmsetLowPowerMode 解析 pmset -g 输出中的 lowpowermode 项（macOS 12 起提供）。
+func parsePmsetLowPowerMode(output []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "lowpowermode" {
+			return fields[1] == "1"
+		}
+	}
+	return false
+}
+
+// parseNmcliMetered 解析 nmcli 设备列表：任一已连接设备标记为计费（yes 或 yes (guessed)）即视为计费网络。
+// 每个设备依次输出 GENERAL.STATE 与 GENERAL.METERED 两行，设备之间以空行分隔。
+func parseNmcliMetered(output []byte) bool {
+	connected := false
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok {
+			connected = false
+			continue
+		}
+		switch key {
+		case "GENERAL.STATE":
+			// 如 "100 (connected)"
+			connected = strings.HasPrefix(value, "100")
+		case "GENERAL.METERED":
+			if connected && strings.HasPrefix(value, "yes") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"myproxy.com/p/internal/errs"
+)
+
+// ProcessLookupSupported 当前平台是否支持按本地端口查找 TCP 连接所属进程（Linux 读取 /proc，macOS 使用 lsof）。
+func ProcessLookupSupported() bool {
+	return runtime.GOOS == "linux" || runtime.GOOS == "darwin"
+}
+
+// LocalTCPPortProcesses 返回本机已建立的 TCP 连接中「本地端口 -> 进程名」的映射。
+// 本进程（内嵌的 xray 核心）自身的连接不计入，因此以代理入站的来源端口查找即可得到发起连接的应用。
+// 返回：端口到进程名的映射和错误（不支持的平台返回错误）
+func LocalTCPPortProcesses() (map[int]string, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return linuxTCPPortProcesses()
+	case "darwin":
+		return darwinTCPPortProcesses()
+	default:
+		return nil, fmt.Errorf("进程查找: %w: %s", errs.ErrUnsupportedOS, runtime.GOOS)
+	}
+}
+
+// linuxTCPPortProcesses 从 /proc/net/tcp{,6} 取已建立连接的 socket inode，再遍历 /proc/<pid>/fd 找到持有者。
+func linuxTCPPortProcesses() (map[int]string, error) {
+	inodePorts := make(map[string]int)
+	for _, file := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		if err := readProcNetTCP(file, inodePorts); err != nil && file == "/proc/net/tcp" {
+			return nil, fmt.Errorf("进程查找: %w", err)
+		}
+	}
+	result := make(map[int]string)
+	if len(inodePorts) == 0 {
+		return result, nil
+	}
+
+	self := strconv.Itoa(os.Getpid())
+	procs, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, fmt.Errorf("进程查找: %w", err)
+	}
+	for _, p := range procs {
+		pid := p.Name()
+		if pid == self || pid[0] < '0' || pid[0] > '9' {
+			continue
+		}
+		fdDir := filepath.Join("/proc", pid, "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue // 无权限读取其他用户的进程
+		}
+		name := ""
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			port, ok := inodePorts[strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]")]
+			if !ok {
+				continue
+			}
+			if name == "" {
+				comm, _ := os.ReadFile(filepath.Join("/proc", pid, "comm"))
+				name = strings.TrimSpace(string(comm))
+				if name == "" {
+					name = "pid " + pid
+				}
+			}
+			result[port] = name
+		}
+	}
+	return result, nil
+}
+
+// readProcNetTCP 解析 /proc/net/tcp 格式的文件，把已建立（状态 01）连接的 inode 和本地端口写入 inodePorts。
+func readProcNetTCP(path string, inodePorts map[string]int) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // 表头
+	for scanner.Scan() {
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || fields[3] != "01" {
+			continue
+		}
+		i := strings.LastIndex(fields[1], ":")
+		if i == -1 {
+			continue
+		}
+		port, err := strconv.ParseInt(fields[1][i+1:], 16, 32)
+		if err != nil || fields[9] == "0" {
+			continue
+		}
+		inodePorts[fields[9]] = int(port)
+	}
+	return scanner.Err()
+}
+
+// darwinTCPPortProcesses 使用 lsof 列出已建立的 TCP 连接（-F 输出：p 进程号、c 命令名、n 地址对）。
+func darwinTCPPortProcesses() (map[int]string, error) {
+	output, err := exec.Command("lsof", "-nP", "-iTCP", "-sTCP:ESTABLISHED", "-Fpcn").Output()
+	if err != nil && len(output) == 0 {
+		return nil, fmt.Errorf("进程查找: 执行 lsof 失败: %w", err)
+	}
+	self := strconv.Itoa(os.Getpid())
+	result := make(map[int]string)
+	var pid, name string
+	for _, line := range strings.Split(string(output), "\n") {
+		if line == "" {
+			continue
+		}
+		switch line[0] {
+		case 'p':
+			pid, name = line[1:], ""
+		case 'c':
+			name = line[1:]
+		case 'n':
+			if pid == self {
+				continue
+			}
+			local, _, ok := strings.Cut(line[1:], "->")
+			if !ok {
+				continue
+			}
+			i := strings.LastIndex(local, ":")
+			if i == -1 {
+				continue
+			}
+			if port, err := strconv.Atoi(local[i+1:]); err == nil {
+				result[port] = name
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// DataDir 应用数据目录（相对工作目录）：数据库、系统代理快照和各次运行的临时文件都放在此目录下
+const DataDir = "data"
+
+// runDirName 数据目录下存放各次运行临时文件的子目录
+const runDirName = "run"
+
+// RunID 本次运行的唯一标识（进程号-启动时间），用于区分各次运行的临时文件。
+var RunID = fmt.Sprintf("%d-%s", os.Getpid(), time.Now().Format("20060102-150405"))
+
+// RunDir 返回本次运行的临时文件目录（dataDir/run/<RunID>），调用方按需创建。
+// 所有临时文件都应放在此目录下，正常退出时整体删除，异常退出的遗留由 ScavengeRunDirs 清理。
+func RunDir(dataDir string) string {
+	return filepath.Join(dataDir, runDirName, RunID)
+}
+
+// ScavengeRunDirs 清理以前运行遗留的临时文件目录：目录名中的进程已不存在时整体删除。
+// 本次运行和仍在运行的其他实例的目录保留。
+// 参数：
+//   - dataDir: 数据目录
+//
+// 返回：已删除的目录列表和错误（部分目录删除失败时返回最后一个错误，其余目录照常清理）
+func ScavengeRunDirs(dataDir string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(dataDir, runDirName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("清理临时文件: %w", err)
+	}
+	var removed []string
+	var lastErr error
+	for _, e := range entries {
+		if e.Name() == RunID {
+			continue
+		}
+		pidText, _, _ := strings.Cut(e.Name(), "-")
+		if pid, err := strconv.Atoi(pidText); err == nil && pid != os.Getpid() && processAlive(pid) {
+			continue
+		}
+		path := filepath.Join(dataDir, runDirName, e.Name())
+		if err := os.RemoveAll(path); err != nil {
+			lastErr = fmt.Errorf("清理临时文件: %w", err)
+			continue
+		}
+		removed = append(removed, path)
+	}
+	return removed, lastErr
+}
+
+// processAlive 判断进程是否仍在运行。无权限向该进程发信号时视为仍在运行。
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		// Windows 上 FindProcess 会打开进程句柄，进程不存在时即返回错误
+		_ = p.Release()
+		return true
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
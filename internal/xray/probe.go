@@ -0,0 +1,61 @@
+package xray
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/xtls/xray-core/app/log"
+	clog "github.com/xtls/xray-core/common/log"
+	"myproxy.com/p/internal/model"
+)
+
+// CreateProbeConfig 创建用于连通性探测的临时配置：仅本地 SOCKS5 入站和代理出站，关闭日志。
+// 参数：
+//   - localPort: 本地 SOCKS5 监听端口
+//   - server: 待探测的节点
+func CreateProbeConfig(localPort int, server *model.Node) ([]byte, error) {
+	outbound, err := CreateOutboundFromServer(server)
+	if err != nil {
+		return nil, fmt.Errorf("Xray: 创建出站配置失败: %w", err)
+	}
+	config := map[string]interface{}{
+		// 探测实例不输出日志，避免写入日志面板和访问记录
+		"log": map[string]interface{}{
+			"loglevel": "none",
+			"access":   "none",
+		},
+		"inbounds": []interface{}{
+			map[string]interface{}{
+				"tag":      "probe-in",
+				"listen":   "127.0.0.1",
+				"port":     localPort,
+				"protocol": "socks",
+				"settings": map[string]interface{}{"auth": "noauth"},
+			},
+		},
+		"outbounds": []interface{}{outbound},
+	}
+	return json.Marshal(config)
+}
+
+// NewProbeInstance 从探测配置创建临时实例，不修改日志劫持回调。
+// 注意：xray-core 的日志处理器是进程级的，探测实例创建后会接管日志输出，
+// 探测结束后需对正在运行的代理实例调用 ReclaimLogHandler 恢复日志。
+func NewProbeInstance(configJSON []byte, port int) (*XrayInstance, error) {
+	xi, err := newInstance(configJSON, nil)
+	if err != nil {
+		return nil, err
+	}
+	xi.SetPort(port)
+	return xi, nil
+}
+
+// ReclaimLogHandler 将进程级日志处理器恢复为本实例的日志模块（临时实例关闭后调用）。
+func (xi *XrayInstance) ReclaimLogHandler() {
+	if !xi.IsRunning() {
+		return
+	}
+	if logger, ok := xi.instance.GetFeature((*log.Instance)(nil)).(*log.Instance); ok && logger != nil {
+		clog.RegisterHandler(logger)
+	}
+}
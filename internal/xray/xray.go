@@ -1,6 +1,7 @@
 package xray
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -15,6 +16,7 @@ import (
 	"github.com/xtls/xray-core/features/stats"
 	"github.com/xtls/xray-core/infra/conf"
 	clog "github.com/xtls/xray-core/common/log"
+	"myproxy.com/p/internal/errs"
 	"myproxy.com/p/internal/model"
 )
 
@@ -22,11 +24,30 @@ import (
 // 参数：level (日志级别，如 "INFO", "ERROR"), message (日志消息)
 type LogCallback func(level, message string)
 
+// maxLogLineLength 单行日志的最大长度（字节）。写入方长时间不输出换行时，缓冲区达到该长度即作为一行截断输出，
+// 避免缓冲区无限增长。
+const maxLogLineLength = 64 * 1024
+
 // logWriter 是一个自定义的日志写入器，用于拦截 xray 的日志输出
 type logWriter struct {
 	callback LogCallback
 	buffer   []byte
 	mu       sync.Mutex
+
+	discarding bool // 超长行已截断输出，丢弃其剩余部分直到下一个换行
+
+	// 统计（受 mu 保护）
+	bytesWritten   int64 // 累计写入字节数
+	linesEmitted   int64 // 累计输出行数（含截断行）
+	linesTruncated int64 // 因超过 maxLogLineLength 被截断的行数
+}
+
+// LogWriterStats logWriter 的读写统计。
+type LogWriterStats struct {
+	BytesWritten   int64 // 累计写入字节数
+	LinesEmitted   int64 // 累计输出行数（含截断行）
+	LinesTruncated int64 // 被截断的超长行数
+	Buffered       int   // 当前缓冲中尚未遇到换行的字节数
 }
 
 // NewLogWriter 创建新的日志写入器
@@ -51,36 +72,81 @@ func (lw *logWriter) Write(p []byte) (n int, err error) {
 
 	// 将数据添加到缓冲区
 	lw.buffer = append(lw.buffer, p...)
+	lw.bytesWritten += int64(len(p))
 
 	// 按行处理日志
 	for {
 		// 查找换行符
-		newlineIndex := -1
-		for i, b := range lw.buffer {
-			if b == '\n' {
-				newlineIndex = i
+		newlineIndex := bytes.IndexByte(lw.buffer, '\n')
+
+		// 丢弃已截断超长行的剩余部分
+		if lw.discarding {
+			if newlineIndex == -1 {
+				lw.buffer = lw.buffer[:0]
 				break
 			}
+			lw.buffer = lw.buffer[newlineIndex+1:]
+			lw.discarding = false
+			continue
 		}
 
-		// 如果没有找到换行符，等待更多数据
+		// 如果没有找到换行符，等待更多数据；超过最大行长度时截断输出并丢弃该行剩余部分，避免缓冲区无限增长
 		if newlineIndex == -1 {
+			if len(lw.buffer) >= maxLogLineLength {
+				line := string(lw.buffer[:maxLogLineLength])
+				lw.buffer = lw.buffer[maxLogLineLength:]
+				lw.discarding = true
+				lw.linesTruncated++
+				lw.emitLine(line + " …(截断)")
+				continue
+			}
 			break
 		}
 
-		// 提取一行日志
-		line := string(lw.buffer[:newlineIndex])
+		// 提取一行日志（超长行同样截断，剩余部分丢弃）
+		lineBytes := lw.buffer[:newlineIndex]
+		truncated := len(lineBytes) > maxLogLineLength
+		if truncated {
+			lineBytes = lineBytes[:maxLogLineLength]
+			lw.linesTruncated++
+		}
+		line := string(lineBytes)
 		lw.buffer = lw.buffer[newlineIndex+1:]
-
-		// 处理日志行
-		if strings.TrimSpace(line) != "" {
-			lw.processLogLine(line)
+		if truncated {
+			line += " …(截断)"
 		}
+		lw.emitLine(line)
+	}
+
+	// 缓冲区已全部消费时释放底层数组，避免偶发的大块写入长期占用内存
+	if len(lw.buffer) == 0 && cap(lw.buffer) > maxLogLineLength {
+		lw.buffer = make([]byte, 0, 1024)
 	}
 
 	return len(p), nil
 }
 
+// emitLine 输出一行日志（空行忽略）。调用方需持有 mu。
+func (lw *logWriter) emitLine(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	lw.linesEmitted++
+	lw.processLogLine(line)
+}
+
+// Stats 返回读写统计。
+func (lw *logWriter) Stats() LogWriterStats {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	return LogWriterStats{
+		BytesWritten:   lw.bytesWritten,
+		LinesEmitted:   lw.linesEmitted,
+		LinesTruncated: lw.linesTruncated,
+		Buffered:       len(lw.buffer),
+	}
+}
+
 // processLogLine 处理单行日志，解析级别并调用回调
 func (lw *logWriter) processLogLine(line string) {
 	if lw.callback == nil {
@@ -207,7 +273,11 @@ func NewXrayInstanceFromJSON(configJSON []byte) (*XrayInstance, error) {
 // 日志通过 registerInterceptorHandler 劫持，由 callback 落盘、展示、解析访问记录。
 func NewXrayInstanceFromJSONWithCallback(configJSON []byte, logCallback LogCallback) (*XrayInstance, error) {
 	registerInterceptorHandler(logCallback)
+	return newInstance(configJSON, logCallback)
+}
 
+// newInstance 解析 JSON 配置并创建实例（不启动）。
+func newInstance(configJSON []byte, logCallback LogCallback) (*XrayInstance, error) {
 	var config conf.Config
 	if err := json.Unmarshal(configJSON, &config); err != nil {
 		return nil, fmt.Errorf("Xray: 解析配置失败: %w", err)
@@ -249,6 +319,14 @@ func (xi *XrayInstance) SetLogCallback(callback LogCallback) {
 	}
 }
 
+// LogWriterStats 返回日志写入器的读写统计（写入字节数、输出行数、截断行数）。
+func (xi *XrayInstance) LogWriterStats() LogWriterStats {
+	if xi.logWriter == nil {
+		return LogWriterStats{}
+	}
+	return xi.logWriter.Stats()
+}
+
 // Start 启动 xray-core 实例
 func (xi *XrayInstance) Start() error {
 	if xi.isRunning {
@@ -297,6 +375,12 @@ func (xi *XrayInstance) GetInstance() *core.Instance {
 // TrafficStats 返回当前出站代理的流量统计（上传、下载字节数）。
 // 需在配置中启用 "stats": {"enabled": true}，且出站 tag 为 "proxy"。
 func (xi *XrayInstance) TrafficStats() (upload, download int64) {
+	// 出站 tag 与 CreateOutboundFromServer 中一致
+	return xi.OutboundTrafficStats("proxy")
+}
+
+// OutboundTrafficStats 返回指定出站（proxy / direct / block）自实例启动以来的流量统计（上传、下载字节数）。
+func (xi *XrayInstance) OutboundTrafficStats(tag string) (upload, download int64) {
 	if !xi.IsRunning() || xi.instance == nil {
 		return 0, 0
 	}
@@ -304,13 +388,11 @@ func (xi *XrayInstance) TrafficStats() (upload, download int64) {
 	if !ok || mgr == nil {
 		return 0, 0
 	}
-	// 出站 tag 与 CreateOutboundFromServer 中一致，路径格式见 xray 文档
-	const uplinkName = "outbound>>>proxy>>>traffic>>>uplink"
-	const downlinkName = "outbound>>>proxy>>>traffic>>>downlink"
-	if c := mgr.GetCounter(uplinkName); c != nil {
+	// 计数器路径格式见 xray 文档
+	if c := mgr.GetCounter("outbound>>>" + tag + ">>>traffic>>>uplink"); c != nil {
 		upload = c.Value()
 	}
-	if c := mgr.GetCounter(downlinkName); c != nil {
+	if c := mgr.GetCounter("outbound>>>" + tag + ">>>traffic>>>downlink"); c != nil {
 		download = c.Value()
 	}
 	return upload, download
@@ -416,9 +498,9 @@ func CreateOutboundFromServer(server *model.Node) (map[string]interface{}, error
 			"allowInsecure": server.TrojanAllowInsecure,
 		}
 
-		// 设置 SNI
-		if server.TrojanSNI != "" {
-			tlsSettings["serverName"] = server.TrojanSNI
+		// 设置 SNI（用户覆盖优先）
+		if sni := server.EffectiveSNI(); sni != "" {
+			tlsSettings["serverName"] = sni
 		}
 
 		// 设置 ALPN
@@ -457,8 +539,34 @@ func CreateOutboundFromServer(server *model.Node) (map[string]interface{}, error
 			"streamSettings": streamSettings,
 		}
 
+	case "vless":
+		// 创建 VLESS 出站配置
+		user := map[string]interface{}{
+			"id":         server.VLESSUUID,
+			"encryption": getVLESSEncryption(server.VLESSEncryption),
+		}
+		if server.VLESSFlow != "" {
+			user["flow"] = server.VLESSFlow
+		}
+		vlessConfig := map[string]interface{}{
+			"vnext": []map[string]interface{}{
+				{
+					"address": server.Addr,
+					"port":    server.Port,
+					"users":   []map[string]interface{}{user},
+				},
+			},
+		}
+
+		outbound = map[string]interface{}{
+			"tag":            "proxy",
+			"protocol":       "vless",
+			"settings":       vlessConfig,
+			"streamSettings": buildVLESSStreamSettings(server),
+		}
+
 	default:
-		return nil, fmt.Errorf("Xray: 不支持的协议类型: %s", server.ProtocolType)
+		return nil, fmt.Errorf("Xray: %w: %s", errs.ErrUnsupportedProtocol, server.ProtocolType)
 	}
 
 	return outbound, nil
@@ -478,15 +586,18 @@ func buildVMessStreamSettings(server *model.Node) map[string]interface{} {
 		"network": getVMessNetwork(server.VMessNetwork),
 	}
 
+	// Host / 路径 / SNI 均以用户覆盖优先
+	host, path := server.EffectiveHost(), server.EffectivePath()
+
 	// 根据传输协议类型设置不同的配置
 	switch server.VMessNetwork {
 	case "ws", "websocket":
 		wsSettings := map[string]interface{}{}
-		if server.VMessHost != "" {
-			wsSettings["host"] = server.VMessHost
+		if host != "" {
+			wsSettings["host"] = host
 		}
-		if server.VMessPath != "" {
-			wsSettings["path"] = server.VMessPath
+		if path != "" {
+			wsSettings["path"] = path
 		}
 		if len(wsSettings) > 0 {
 			streamSettings["wsSettings"] = wsSettings
@@ -494,11 +605,11 @@ func buildVMessStreamSettings(server *model.Node) map[string]interface{} {
 
 	case "h2", "http":
 		h2Settings := map[string]interface{}{}
-		if server.VMessHost != "" {
-			h2Settings["host"] = []string{server.VMessHost}
+		if host != "" {
+			h2Settings["host"] = []string{host}
 		}
-		if server.VMessPath != "" {
-			h2Settings["path"] = server.VMessPath
+		if path != "" {
+			h2Settings["path"] = path
 		}
 		if len(h2Settings) > 0 {
 			streamSettings["httpSettings"] = h2Settings
@@ -506,8 +617,8 @@ func buildVMessStreamSettings(server *model.Node) map[string]interface{} {
 
 	case "grpc":
 		grpcSettings := map[string]interface{}{}
-		if server.VMessPath != "" {
-			grpcSettings["serviceName"] = server.VMessPath
+		if path != "" {
+			grpcSettings["serviceName"] = path
 		}
 		if len(grpcSettings) > 0 {
 			streamSettings["grpcSettings"] = grpcSettings
@@ -519,8 +630,8 @@ func buildVMessStreamSettings(server *model.Node) map[string]interface{} {
 		tlsSettings := map[string]interface{}{
 			"allowInsecure": false,
 		}
-		if server.VMessHost != "" {
-			tlsSettings["serverName"] = server.VMessHost
+		if sni := server.EffectiveSNI(); sni != "" {
+			tlsSettings["serverName"] = sni
 		}
 		streamSettings["security"] = "tls"
 		streamSettings["tlsSettings"] = tlsSettings
@@ -537,6 +648,136 @@ func getVMessNetwork(network string) string {
 	return network
 }
 
+// getVLESSEncryption 获取 VLESS 加密方式，默认为 "none"
+func getVLESSEncryption(encryption string) string {
+	if encryption == "" {
+		return "none"
+	}
+	return encryption
+}
+
+// buildVLESSStreamSettings 构建 VLESS 传输协议配置：传输方式（tcp、ws、grpc、httpupgrade、xhttp）
+// 与传输层安全（tls、reality）。Host / 路径 / SNI 均以用户覆盖优先。
+func buildVLESSStreamSettings(server *model.Node) map[string]interface{} {
+	network := getVMessNetwork(server.VLESSNetwork)
+	streamSettings := map[string]interface{}{
+		"network": network,
+	}
+	host, path := server.EffectiveHost(), server.EffectivePath()
+
+	switch network {
+	case "tcp", "raw":
+		// http 伪装：请求头中带 Host 和路径
+		if server.VLESSHeaderType == "http" {
+			request := map[string]interface{}{}
+			if path != "" {
+				request["path"] = strings.Split(path, ",")
+			}
+			if host != "" {
+				request["headers"] = map[string]interface{}{"Host": strings.Split(host, ",")}
+			}
+			streamSettings["tcpSettings"] = map[string]interface{}{
+				"header": map[string]interface{}{"type": "http", "request": request},
+			}
+		}
+
+	case "ws", "websocket", "httpupgrade":
+		settings := map[string]interface{}{}
+		if host != "" {
+			settings["host"] = host
+		}
+		if path != "" {
+			settings["path"] = path
+		}
+		if len(settings) > 0 {
+			key := "wsSettings"
+			if network == "httpupgrade" {
+				key = "httpupgradeSettings"
+			}
+			streamSettings[key] = settings
+		}
+
+	case "grpc":
+		grpcSettings := map[string]interface{}{}
+		if path != "" {
+			grpcSettings["serviceName"] = path
+		}
+		if host != "" {
+			grpcSettings["authority"] = host
+		}
+		if server.VLESSMode == "multi" {
+			grpcSettings["multiMode"] = true
+		}
+		if len(grpcSettings) > 0 {
+			streamSettings["grpcSettings"] = grpcSettings
+		}
+
+	case "xhttp", "splithttp":
+		xhttpSettings := map[string]interface{}{}
+		if host != "" {
+			xhttpSettings["host"] = host
+		}
+		if path != "" {
+			xhttpSettings["path"] = path
+		}
+		if server.VLESSMode != "" {
+			xhttpSettings["mode"] = server.VLESSMode
+		}
+		if len(xhttpSettings) > 0 {
+			streamSettings["xhttpSettings"] = xhttpSettings
+		}
+	}
+
+	var alpn []string
+	for _, p := range strings.Split(server.VLESSAlpn, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			alpn = append(alpn, p)
+		}
+	}
+
+	switch server.VLESSSecurity {
+	case "tls":
+		tlsSettings := map[string]interface{}{
+			"allowInsecure": server.VLESSAllowInsecure,
+		}
+		if sni := server.EffectiveSNI(); sni != "" {
+			tlsSettings["serverName"] = sni
+		}
+		if len(alpn) > 0 {
+			tlsSettings["alpn"] = alpn
+		}
+		if server.VLESSFingerprint != "" {
+			tlsSettings["fingerprint"] = server.VLESSFingerprint
+		}
+		streamSettings["security"] = "tls"
+		streamSettings["tlsSettings"] = tlsSettings
+
+	case "reality":
+		// REALITY 必须指定 uTLS 指纹，链接未设置时使用 chrome
+		fingerprint := server.VLESSFingerprint
+		if fingerprint == "" {
+			fingerprint = "chrome"
+		}
+		realitySettings := map[string]interface{}{
+			"fingerprint": fingerprint,
+			"publicKey":   server.VLESSPublicKey,
+		}
+		if sni := server.EffectiveSNI(); sni != "" {
+			realitySettings["serverName"] = sni
+		}
+		if server.VLESSShortID != "" {
+			realitySettings["shortId"] = server.VLESSShortID
+		}
+		if server.VLESSSpiderX != "" {
+			realitySettings["spiderX"] = server.VLESSSpiderX
+		}
+		streamSettings["security"] = "reality"
+		streamSettings["realitySettings"] = realitySettings
+	}
+
+	return streamSettings
+}
+
 // buildSSStreamSettings 构建 Shadowsocks 传输协议配置
 func buildSSStreamSettings(server *model.Node) map[string]interface{} {
 	// 默认使用 tcp
@@ -551,10 +792,24 @@ func buildSSStreamSettings(server *model.Node) map[string]interface{} {
 	return streamSettings
 }
 
-// RoutingOptions 路由相关配置（直连列表、直连列表是否走代理等）。
+// RoutingOptions 路由与出入站相关配置（用户路由规则、定时拦截列表、额外入站、出站绑定、日志）。
 type RoutingOptions struct {
-	DirectRoutes         []string // 用户配置的直连列表（domain:xxx 或 ip/cidr）
-	DirectRoutesUseProxy bool     // true：直连列表走代理；false：走直连
+	Rules         []model.RouteRule      // 用户路由规则（按顺序匹配，动作对应出站 tag）
+	BlockRoutes   []string               // 当前生效的拦截列表（定时规则），走 block 出站
+	ExtraInbounds []model.InboundProfile // 额外入站（仅启用的），global 模式的入站跳过用户路由规则
+	Binding       model.OutboundBinding  // 代理出站绑定的网卡 / 源 IP
+	Policy        *model.ConnPolicy      // 连接策略（超时与缓冲区），nil 使用 xray 默认值
+	Log           *model.XrayLogOptions  // 日志设置，nil 使用默认值（warning 级别，开启访问日志）
+	BlockQUIC     model.QUICBlockMode    // 拦截 QUIC（UDP 443）的范围，空或 off 表示不拦截
+	DNS           *model.DNSOptions      // 域名解析设置，nil 或默认值时不写入 dns 配置（直连使用系统 DNS）
+}
+
+// activeDNS 返回需要写入配置的解析设置，无需写入时返回 nil。
+func (r *RoutingOptions) activeDNS() *model.DNSOptions {
+	if r == nil || r.DNS == nil || r.DNS.IsDefault() {
+		return nil
+	}
+	return r.DNS
 }
 
 // CreateXrayConfig 创建完整的 xray 配置。
@@ -568,7 +823,8 @@ func CreateXrayConfig(localPort int, server *model.Node, logFilePath string, rou
 		localPort = 10808
 	}
 
-	// 创建入站配置（本地 SOCKS5 服务器）
+	// 创建入站配置（本地混合端口）：xray 的 socks 入站读取首字节识别协议，
+	// SOCKS4/4a/5 按 SOCKS 处理，其他按 HTTP 代理（含 CONNECT）处理，所有客户端可共用同一端口
 	inbound := map[string]interface{}{
 		"tag":      "socks-in",
 		"listen":   "127.0.0.1",
@@ -580,11 +836,21 @@ func CreateXrayConfig(localPort int, server *model.Node, logFilePath string, rou
 		},
 	}
 
+	inbounds := []interface{}{inbound}
+	if routing != nil {
+		for i := range routing.ExtraInbounds {
+			inbounds = append(inbounds, buildExtraInbound(&routing.ExtraInbounds[i]))
+		}
+	}
+
 	// 创建出站配置
 	outbound, err := CreateOutboundFromServer(server)
 	if err != nil {
 		return nil, fmt.Errorf("Xray: 创建出站配置失败: %w", err)
 	}
+	if routing != nil {
+		applyOutboundBinding(outbound, routing.Binding)
+	}
 
 	// 创建直连出站配置
 	directOutbound := map[string]interface{}{
@@ -593,13 +859,65 @@ func CreateXrayConfig(localPort int, server *model.Node, logFilePath string, rou
 		"settings": map[string]interface{}{},
 	}
 
-	// 构建日志配置：不设置 access/error，使用 Console 类型，由 registerInterceptorHandler 劫持
-	// 劫持后由 callback 落盘、展示、解析（保持原始格式，便于 access record 按 fields[5] 解析）
+	// 创建拦截出站配置（定时拦截规则使用）
+	blockOutbound := map[string]interface{}{
+		"tag":      "block",
+		"protocol": "blackhole",
+		"settings": map[string]interface{}{},
+	}
+	outbounds := []interface{}{outbound, directOutbound, blockOutbound}
+
+	// 域名解析：自定义 DNS 时直连出站改用 xray 内置 DNS 解析；Fake IP 模式另开本地 DNS 入站
+	dns := routing.activeDNS()
+	routingStrategy := "AsIs"
+	if dns != nil {
+		if len(dns.Servers) > 0 {
+			directOutbound["settings"] = map[string]interface{}{"domainStrategy": "UseIP"}
+		}
+		switch dns.Mode {
+		case model.DNSModeLocal:
+			routingStrategy = "IPIfNonMatch"
+		case model.DNSModeFakeIP:
+			// 入站嗅探把虚拟地址还原为域名，由节点解析真实地址
+			for _, in := range inbounds {
+				in.(map[string]interface{})["sniffing"] = map[string]interface{}{
+					"enabled":      true,
+					"destOverride": []string{"fakedns"},
+					"metadataOnly": true,
+				}
+			}
+			inbounds = append(inbounds, map[string]interface{}{
+				"tag":      dnsInboundTag,
+				"listen":   "127.0.0.1",
+				"port":     dns.ListenPort,
+				"protocol": "dokodemo-door",
+				"settings": map[string]interface{}{
+					"address": "1.1.1.1",
+					"port":    53,
+					"network": "tcp,udp",
+				},
+			})
+			outbounds = append(outbounds, map[string]interface{}{
+				"tag":      dnsOutboundTag,
+				"protocol": "dns",
+			})
+		}
+	}
+
+	// 构建日志配置：不设置 access/error 路径，使用 Console 类型，由 registerInterceptorHandler 劫持
+	// 劫持后由 callback 按内容区分访问日志与核心日志分别落盘、展示、解析（保持原始格式，便于 access record 按 fields[5] 解析）
+	logOptions := model.DefaultXrayLogOptions()
+	if routing != nil && routing.Log != nil {
+		logOptions = *routing.Log
+	}
 	logConfig := map[string]interface{}{
-		"loglevel": "warning",
+		"loglevel": logOptions.Level,
+	}
+	if !logOptions.AccessLog {
+		logConfig["access"] = "none"
 	}
 
-	// 构建路由规则（含用户直连列表与是否走代理）
+	// 构建路由规则（含定时拦截与用户路由规则）
 	rules := buildRoutingRules(routing)
 
 	// policy.system 中开启 outbound 统计后，outbound handler 才会注册 traffic counter（见 app/proxyman/outbound/handler.go getStatCounter）
@@ -609,27 +927,142 @@ func CreateXrayConfig(localPort int, server *model.Node, logFilePath string, rou
 			"statsOutboundDownlink": true,
 		},
 	}
+	if routing != nil && routing.Policy != nil {
+		policyConfig["levels"] = map[string]interface{}{
+			"0": buildPolicyLevel(routing.Policy),
+		}
+	}
 
 	// 构建完整配置
 	config := map[string]interface{}{
 		"log":       logConfig,
 		"stats":    map[string]interface{}{},
 		"policy":   policyConfig,
-		"inbounds":  []interface{}{inbound},
-		"outbounds": []interface{}{outbound, directOutbound},
+		"inbounds":  inbounds,
+		"outbounds": outbounds,
 		"routing": map[string]interface{}{
 			"rules":          rules,
-			"domainStrategy": "AsIs",
+			"domainStrategy": routingStrategy,
 		},
 	}
+	if dns != nil {
+		config["dns"] = buildDNSConfig(dns)
+		if dns.Mode == model.DNSModeFakeIP {
+			config["fakedns"] = []interface{}{map[string]interface{}{"ipPool": fakeDNSPool, "poolSize": 65535}}
+		}
+	}
 
 	return json.MarshalIndent(config, "", "  ")
 }
 
+// applyOutboundBinding 为出站设置网卡绑定（sockopt.interface）和源 IP（sendThrough）。
+func applyOutboundBinding(outbound map[string]interface{}, binding model.OutboundBinding) {
+	if binding.SendThrough != "" {
+		outbound["sendThrough"] = binding.SendThrough
+	}
+	if binding.Interface == "" {
+		return
+	}
+	streamSettings, ok := outbound["streamSettings"].(map[string]interface{})
+	if !ok {
+		streamSettings = map[string]interface{}{}
+		outbound["streamSettings"] = streamSettings
+	}
+	sockopt, ok := streamSettings["sockopt"].(map[string]interface{})
+	if !ok {
+		sockopt = map[string]interface{}{}
+		streamSettings["sockopt"] = sockopt
+	}
+	sockopt["interface"] = binding.Interface
+}
+
+// buildPolicyLevel 构建 policy.levels 中的用户等级策略（入站默认使用等级 0）。
+func buildPolicyLevel(p *model.ConnPolicy) map[string]interface{} {
+	level := map[string]interface{}{
+		"handshake":    p.Handshake,
+		"connIdle":     p.ConnIdle,
+		"uplinkOnly":   p.UplinkOnly,
+		"downlinkOnly": p.DownlinkOnly,
+	}
+	if p.BufferSize >= 0 {
+		level["bufferSize"] = p.BufferSize
+	}
+	return level
+}
+
+// buildExtraInbound 构建额外入站配置（仅监听 127.0.0.1）。
+func buildExtraInbound(profile *model.InboundProfile) map[string]interface{} {
+	settings := map[string]interface{}{}
+	if profile.Protocol == model.InboundProtocolSocks {
+		settings["auth"] = "noauth"
+		settings["udp"] = true
+	}
+	return map[string]interface{}{
+		"tag":      profile.Tag(),
+		"listen":   "127.0.0.1",
+		"port":     profile.Port,
+		"protocol": string(profile.Protocol),
+		"settings": settings,
+	}
+}
+
+// 路由规则标签（ruleTag）：命中时 xray 在 info 级别日志中输出 "Hit route rule: [tag]"，用于调试捕获定位匹配的规则。
+// 用户规则为 RuleTagUserPrefix + 序号（从 1 开始，合并的相邻规则为 "起-止"）。
+const (
+	RuleTagLocal         = "local"
+	RuleTagScheduleBlock = "schedule-block"
+	RuleTagGlobalInbound = "global-inbound"
+	RuleTagDefault       = "default"
+	RuleTagUserPrefix    = "user#"
+	RuleTagBlockQUIC     = "block-quic"
+	RuleTagDNS           = "dns"
+)
+
+// Fake IP 模式的本地 DNS 入站、DNS 出站 tag 与虚拟地址池
+const (
+	dnsInboundTag  = "dns-in"
+	dnsOutboundTag = "dns-out"
+	fakeDNSPool    = "198.18.0.0/15"
+)
+
+// buildDNSConfig 构建 xray 的 dns 配置：Fake IP 模式下虚拟地址优先，自定义服务器供直连解析等内部查询使用。
+func buildDNSConfig(dns *model.DNSOptions) map[string]interface{} {
+	servers := []interface{}{}
+	if dns.Mode == model.DNSModeFakeIP {
+		servers = append(servers, "fakedns")
+	}
+	for _, s := range dns.Servers {
+		servers = append(servers, strings.TrimSpace(s))
+	}
+	if len(dns.Servers) == 0 {
+		servers = append(servers, "localhost") // 未配置时使用系统 DNS
+	}
+	return map[string]interface{}{
+		"servers":      servers,
+		"disableCache": dns.DisableCache,
+	}
+}
+
 // buildRoutingRules 构建路由规则。
-// 顺序：本地直连 -> 用户直连列表（根据 directRoutesUseProxy 走直连或代理）-> 默认代理。
+// 顺序：（Fake IP 模式）DNS 入站 -> 本地直连 -> 定时拦截列表 -> 全局模式入站走代理 -> 用户路由规则（逐条指定直连/代理/拦截）-> 默认代理。
+// 拦截 QUIC 时：范围为全部则在本地直连之后拦截所有 UDP 443；范围为仅代理则在每条走代理的规则之前
+// 插入同条件的 UDP 443 拦截（带端口/协议条件的用户规则除外）。
 func buildRoutingRules(routing *RoutingOptions) []interface{} {
 	rules := []interface{}{}
+	quicMode := model.QUICBlockOff
+	if routing != nil {
+		quicMode = routing.BlockQUIC
+	}
+
+	// 0. Fake IP 模式：本地 DNS 入站的查询交给 DNS 出站
+	if dns := routing.activeDNS(); dns != nil && dns.Mode == model.DNSModeFakeIP {
+		rules = append(rules, map[string]interface{}{
+			"type":        "field",
+			"inboundTag":  []string{dnsInboundTag},
+			"outboundTag": dnsOutboundTag,
+			"ruleTag":     RuleTagDNS,
+		})
+	}
 
 	// 1. 本地地址直连
 	localRule := map[string]interface{}{
@@ -643,39 +1076,121 @@ func buildRoutingRules(routing *RoutingOptions) []interface{} {
 			"fe80::/10",
 		},
 		"outboundTag": "direct",
+		"ruleTag":     RuleTagLocal,
 	}
 	rules = append(rules, localRule)
 
-	// 2. 用户直连列表：走直连或走代理（直连列表中的地址也可以走代理）
-	if routing != nil && len(routing.DirectRoutes) > 0 {
-		domains, ips := splitDirectRoutes(routing.DirectRoutes)
+	if quicMode == model.QUICBlockAll {
+		rules = append(rules, blockQUICRule(nil))
+	}
+
+	// 2. 定时拦截列表：当前时间窗口内生效的拦截目标（域名与 IP 分为两条规则）
+	if routing != nil && len(routing.BlockRoutes) > 0 {
+		domains, ips := splitDirectRoutes(routing.BlockRoutes)
 		if len(domains) > 0 || len(ips) > 0 {
-			r := map[string]interface{}{"type": "field"}
-			if len(domains) > 0 {
-				r["domain"] = domains
+			base := map[string]interface{}{"type": "field", "outboundTag": "block", "ruleTag": RuleTagScheduleBlock}
+			for _, r := range matchRules(base, domains, ips) {
+				rules = append(rules, r)
+			}
+		}
+	}
+
+	// 3. 全局模式入站：跳过用户路由规则，全部走代理
+	if routing != nil {
+		var globalTags []string
+		for i := range routing.ExtraInbounds {
+			if routing.ExtraInbounds[i].Mode == model.InboundModeGlobal {
+				globalTags = append(globalTags, routing.ExtraInbounds[i].Tag())
+			}
+		}
+		if len(globalTags) > 0 {
+			if quicMode == model.QUICBlockProxy {
+				rules = append(rules, blockQUICRule(map[string]interface{}{"inboundTag": globalTags}))
+			}
+			rules = append(rules, map[string]interface{}{
+				"type":        "field",
+				"inboundTag":  globalTags,
+				"outboundTag": "proxy",
+				"ruleTag":     RuleTagGlobalInbound,
+			})
+		}
+	}
+
+	// 4. 用户路由规则：相邻、动作相同且目标类型（域名/IP）相同的规则合并为一条 xray 规则，保持用户配置的匹配顺序；
+	//    带端口/协议条件的规则与前后规则条件不同，单独生成
+	if routing != nil {
+		for start := 0; start < len(routing.Rules); {
+			begin := start
+			first := routing.Rules[start]
+			end := start
+			var targets []string
+			for end < len(routing.Rules) && routing.Rules[end].Action == first.Action &&
+				isDomainRoute(routing.Rules[end].Target) == isDomainRoute(first.Target) &&
+				(end == start || (!first.HasPortMatch() && !routing.Rules[end].HasPortMatch())) {
+				targets = append(targets, routing.Rules[end].Target)
+				end++
 			}
-			if len(ips) > 0 {
-				r["ip"] = ips
+			start = end
+
+			if !first.Action.Valid() {
+				continue
+			}
+			domains, ips := splitDirectRoutes(targets)
+			if len(domains) == 0 && len(ips) == 0 && !first.HasPortMatch() {
+				continue
+			}
+			ruleTag := fmt.Sprintf("%s%d", RuleTagUserPrefix, begin+1)
+			if end-begin > 1 {
+				ruleTag = fmt.Sprintf("%s%d-%d", RuleTagUserPrefix, begin+1, end)
 			}
-			if routing.DirectRoutesUseProxy {
-				r["outboundTag"] = "proxy"
-			} else {
-				r["outboundTag"] = "direct"
+			r := map[string]interface{}{"type": "field", "outboundTag": string(first.Action), "ruleTag": ruleTag}
+			if first.Port != "" {
+				r["port"] = first.Port
+			}
+			if first.Network != "" {
+				r["network"] = first.Network
+			}
+			if quicMode == model.QUICBlockProxy && first.Action == model.RouteActionProxy && !first.HasPortMatch() {
+				// 每类目标单独生成一条 UDP 443 拦截规则，不与代理规则的其余条件混合
+				for _, match := range matchRules(map[string]interface{}{}, domains, ips) {
+					rules = append(rules, blockQUICRule(match))
+				}
+			}
+			for _, m := range matchRules(r, domains, ips) {
+				rules = append(rules, m)
 			}
-			rules = append(rules, r)
 		}
 	}
 
-	// 3. 默认代理（所有其他流量）
+	// 5. 默认代理（所有其他流量）
+	if quicMode == model.QUICBlockProxy {
+		rules = append(rules, blockQUICRule(nil))
+	}
 	rules = append(rules, map[string]interface{}{
 		"type":        "field",
 		"network":     []string{"tcp", "udp"},
 		"outboundTag": "proxy",
+		"ruleTag":     RuleTagDefault,
 	})
 
 	return rules
 }
 
+// blockQUICRule 构建拦截 QUIC（UDP 443）的规则，match 为附加的匹配条件（可为 nil）。
+func blockQUICRule(match map[string]interface{}) map[string]interface{} {
+	r := map[string]interface{}{
+		"type":        "field",
+		"network":     "udp",
+		"port":        "443",
+		"outboundTag": "block",
+		"ruleTag":     RuleTagBlockQUIC,
+	}
+	for k, v := range match {
+		r[k] = v
+	}
+	return r
+}
+
 // splitDirectRoutes 将直连规则拆分为 domain 与 ip 列表（xray 规则格式）。
 func splitDirectRoutes(routes []string) (domains, ips []string) {
 	for _, r := range routes {
@@ -683,8 +1198,7 @@ func splitDirectRoutes(routes []string) (domains, ips []string) {
 		if s == "" {
 			continue
 		}
-		if strings.HasPrefix(s, "domain:") || strings.HasPrefix(s, "geosite:") ||
-			strings.HasPrefix(s, "regexp:") || strings.HasPrefix(s, "full:") {
+		if isDomainRoute(s) {
 			domains = append(domains, s)
 		} else {
 			ips = append(ips, s)
@@ -692,3 +1206,34 @@ func splitDirectRoutes(routes []string) (domains, ips []string) {
 	}
 	return domains, ips
 }
+
+// isDomainRoute 判断目标是否为域名类规则（domain:/geosite:/regexp:/full: 前缀），否则视为 IP/CIDR。
+func isDomainRoute(target string) bool {
+	s := strings.TrimSpace(target)
+	return strings.HasPrefix(s, "domain:") || strings.HasPrefix(s, "geosite:") ||
+		strings.HasPrefix(s, "regexp:") || strings.HasPrefix(s, "full:")
+}
+
+// matchRules 按目标类型生成规则：xray 同一条规则内的 domain 与 ip 条件需同时满足，
+// 因此域名与 IP 各生成一条规则，其余字段取自 base；两类目标均为空时原样返回 base。
+func matchRules(base map[string]interface{}, domains, ips []string) []map[string]interface{} {
+	if len(domains) == 0 && len(ips) == 0 {
+		return []map[string]interface{}{base}
+	}
+	var out []map[string]interface{}
+	for _, m := range []struct {
+		key     string
+		targets []string
+	}{{"domain", domains}, {"ip", ips}} {
+		if len(m.targets) == 0 {
+			continue
+		}
+		r := make(map[string]interface{}, len(base)+1)
+		for k, v := range base {
+			r[k] = v
+		}
+		r[m.key] = m.targets
+		out = append(out, r)
+	}
+	return out
+}
@@ -0,0 +1,164 @@
+package xray
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"myproxy.com/p/internal/model"
+)
+
+// routingRulesJSON 生成路由规则并经 JSON 往返，得到与写入 xray 配置一致的结构。
+func routingRulesJSON(t *testing.T, routing *RoutingOptions) []map[string]interface{} {
+	t.Helper()
+	data, err := json.Marshal(buildRoutingRules(routing))
+	if err != nil {
+		t.Fatalf("序列化路由规则失败: %v", err)
+	}
+	var rules []map[string]interface{}
+	if err := json.Unmarshal(data, &rules); err != nil {
+		t.Fatalf("解析路由规则失败: %v", err)
+	}
+	return rules
+}
+
+func TestBuildRoutingRulesSeparatesDomainAndIP(t *testing.T) {
+	tests := []struct {
+		name    string
+		routing *RoutingOptions
+		want    map[string]int // ruleTag -> 该 tag 下的规则条数
+	}{
+		{
+			name: "相邻域名与 IP 规则动作相同",
+			routing: &RoutingOptions{Rules: []model.RouteRule{
+				{Target: "domain:example.com", Action: model.RouteActionProxy},
+				{Target: "1.1.1.1/32", Action: model.RouteActionProxy},
+			}},
+			want: map[string]int{"user#1": 1, "user#2": 1},
+		},
+		{
+			name: "同类型相邻规则合并",
+			routing: &RoutingOptions{Rules: []model.RouteRule{
+				{Target: "domain:a.com", Action: model.RouteActionDirect},
+				{Target: "geosite:cn", Action: model.RouteActionDirect},
+				{Target: "10.1.0.0/16", Action: model.RouteActionDirect},
+				{Target: "8.8.8.8", Action: model.RouteActionDirect},
+			}},
+			want: map[string]int{"user#1-2": 1, "user#3-4": 1},
+		},
+		{
+			name:    "定时拦截列表同时包含域名与 IP",
+			routing: &RoutingOptions{BlockRoutes: []string{"domain:ads.com", "2.2.2.0/24"}},
+			want:    map[string]int{RuleTagScheduleBlock: 2},
+		},
+		{
+			name: "仅拦截代理流量的 QUIC",
+			routing: &RoutingOptions{
+				BlockQUIC: model.QUICBlockProxy,
+				Rules: []model.RouteRule{
+					{Target: "domain:video.com", Action: model.RouteActionProxy},
+					{Target: "3.3.3.3", Action: model.RouteActionProxy},
+				},
+			},
+			// 每条代理规则前一条 QUIC 拦截，另加默认代理前的一条
+			want: map[string]int{RuleTagBlockQUIC: 3, "user#1": 1, "user#2": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string]int{}
+			for _, r := range routingRulesJSON(t, tt.routing) {
+				_, hasDomain := r["domain"]
+				_, hasIP := r["ip"]
+				if hasDomain && hasIP {
+					t.Errorf("规则 %v 同时包含 domain 与 ip，xray 要求两者同时满足，永远无法命中", r["ruleTag"])
+				}
+				if tag, _ := r["ruleTag"].(string); tag != "" {
+					got[tag]++
+				}
+			}
+			for tag, n := range tt.want {
+				if got[tag] != n {
+					t.Errorf("ruleTag %s 规则数 = %d，期望 %d", tag, got[tag], n)
+				}
+			}
+		})
+	}
+}
+
+func TestLogWriterSplitsLines(t *testing.T) {
+	long := strings.Repeat("x", maxLogLineLength)
+	truncated := long + " …(截断)"
+
+	tests := []struct {
+		name          string
+		writes        []string
+		wantLines     []string
+		wantTruncated int64
+		wantBuffered  int
+	}{
+		{name: "整行一次写入", writes: []string{"a\n"}, wantLines: []string{"a"}},
+		{name: "一行拆成多次写入", writes: []string{"hel", "lo\nwor", "ld\n"}, wantLines: []string{"hello", "world"}},
+		{name: "末尾不完整的行留在缓冲区", writes: []string{"a\nb"}, wantLines: []string{"a"}, wantBuffered: 1},
+		{name: "去除回车符", writes: []string{"x\r\n"}, wantLines: []string{"x"}},
+		{name: "忽略空行", writes: []string{"\n\n", " \n", "a\n"}, wantLines: []string{"a"}},
+		{
+			name:          "带换行的超长行截断",
+			writes:        []string{long + "tail\nnext\n"},
+			wantLines:     []string{truncated, "next"},
+			wantTruncated: 1,
+		},
+		{
+			name:          "无换行的超长行分多次写入",
+			writes:        []string{long[:maxLogLineLength/2], long[maxLogLineLength/2:], "rest", "\nnext\n"},
+			wantLines:     []string{truncated, "next"},
+			wantTruncated: 1,
+		},
+		{
+			name:          "超长行剩余部分在换行前全部丢弃",
+			writes:        []string{long + long + "tail"},
+			wantLines:     []string{truncated},
+			wantTruncated: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var lines []string
+			lw := NewLogWriter(func(level, message string) {
+				lines = append(lines, message)
+			})
+			total := 0
+			for _, w := range tt.writes {
+				n, err := lw.Write([]byte(w))
+				if err != nil || n != len(w) {
+					t.Fatalf("Write 返回 (%d, %v)，期望 (%d, nil)", n, err, len(w))
+				}
+				total += n
+			}
+
+			if len(lines) != len(tt.wantLines) {
+				t.Fatalf("输出 %d 行，期望 %d 行", len(lines), len(tt.wantLines))
+			}
+			for i := range lines {
+				if lines[i] != tt.wantLines[i] {
+					t.Errorf("第 %d 行长度 %d，期望长度 %d（前 20 字节 %q）", i+1, len(lines[i]), len(tt.wantLines[i]), lines[i][:min(20, len(lines[i]))])
+				}
+			}
+			stats := lw.Stats()
+			if stats.BytesWritten != int64(total) {
+				t.Errorf("BytesWritten = %d，期望 %d", stats.BytesWritten, total)
+			}
+			if stats.LinesEmitted != int64(len(tt.wantLines)) {
+				t.Errorf("LinesEmitted = %d，期望 %d", stats.LinesEmitted, len(tt.wantLines))
+			}
+			if stats.LinesTruncated != tt.wantTruncated {
+				t.Errorf("LinesTruncated = %d，期望 %d", stats.LinesTruncated, tt.wantTruncated)
+			}
+			if stats.Buffered != tt.wantBuffered {
+				t.Errorf("Buffered = %d，期望 %d", stats.Buffered, tt.wantBuffered)
+			}
+		})
+	}
+}